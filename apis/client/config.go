@@ -0,0 +1,82 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+// KeyProvider provides the passphrase to encrypt and decrypt
+// the client profile saved by the application.
+type KeyProvider = appctl.KeyProvider
+
+var (
+	// ErrNoPassphrase is returned if the saved client profile is encrypted,
+	// but the KeyProvider doesn't provide the passphrase.
+	ErrNoPassphrase = appctl.ErrNoPassphrase
+
+	// ErrWrongPassphrase is returned if the saved client profile
+	// can't be decrypted with the passphrase.
+	ErrWrongPassphrase = appctl.ErrWrongPassphrase
+)
+
+// MarshalProfile returns the bytes of the client profile, which can be
+// saved by the application. If the KeyProvider is not nil, the bytes
+// are encrypted with the passphrase, so the proxy server passwords
+// are not stored in plain text.
+func MarshalProfile(profile *appctlpb.ClientProfile, kp KeyProvider) ([]byte, error) {
+	if profile == nil {
+		return nil, fmt.Errorf("%w: client config profile is nil", ErrInvalidConfigConfig)
+	}
+	b, err := appctl.MarshalConfig(profile, appctl.PROTOBUF_CONFIG_FILE_TYPE)
+	if err != nil {
+		return nil, err
+	}
+	if kp == nil {
+		return b, nil
+	}
+	passphrase, err := kp.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return appctl.EncryptConfig(b, passphrase)
+}
+
+// UnmarshalProfile returns the client profile from the bytes created by
+// MarshalProfile. If the bytes are encrypted, the KeyProvider must
+// provide the passphrase. The client profile can be used by Store.
+func UnmarshalProfile(b []byte, kp KeyProvider) (*appctlpb.ClientProfile, error) {
+	if appctl.IsEncryptedConfig(b) {
+		if kp == nil {
+			return nil, ErrNoPassphrase
+		}
+		passphrase, err := kp.Passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if b, err = appctl.DecryptConfig(b, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	profile := &appctlpb.ClientProfile{}
+	if err := appctl.UnmarshalConfig(b, profile, appctl.PROTOBUF_CONFIG_FILE_TYPE); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidConfigConfig, err.Error())
+	}
+	return profile, nil
+}
@@ -19,9 +19,12 @@ import (
 	"context"
 	"errors"
 	"net"
+	"time"
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"github.com/enfein/mieru/v3/pkg/socks5"
 )
 
 var (
@@ -29,6 +32,8 @@ var (
 	ErrInvalidConfigConfig         = errors.New("invalid client config")
 	ErrClientIsNotRunning          = errors.New("client is not running")
 	ErrStoreClientConfigAfterStart = errors.New("can't store client config after start")
+	ErrDestinationStatsDisabled    = errors.New("destination statistics is not enabled")
+	ErrDestinationNotAllowed       = errors.New("destination is not allowed by the proxy server")
 )
 
 // Client contains methods supported by a mieru client.
@@ -36,6 +41,7 @@ type Client interface {
 	ClientConfigurationService
 	ClientLifecycleService
 	ClientNetworkService
+	ClientStatisticsService
 }
 
 // ClientConfigurationService contains methods to manage proxy client configuration.
@@ -46,7 +52,17 @@ type ClientConfigurationService interface {
 
 	// Store saves the client config.
 	// It returns wrapped ErrInvalidConfigConfig if the provided client config is invalid.
+	// The client config is kept in memory. Use MarshalProfile and
+	// UnmarshalProfile to save the client profile with encryption.
 	Store(*ClientConfig) error
+
+	// SetResolver replaces the DNS resolver in the client config.
+	// Unlike Store, it can be called when the client is running.
+	// Lookups that are already started finish with the previous resolver,
+	// and the new resolver is used by the following lookups.
+	// If the resolver is nil, the system DNS resolver is used.
+	// It returns ErrNoClientConfig if client config is never stored.
+	SetResolver(apicommon.DNSResolver) error
 }
 
 // ClientLifecycleService contains methods to manage proxy client lifecycle.
@@ -60,6 +76,15 @@ type ClientLifecycleService interface {
 	// After stop, the client can't be reused.
 	Stop() error
 
+	// StopContext deactivates the client gracefully. New proxy connections
+	// are rejected with ErrClientIsNotRunning, and established proxy
+	// connections can continue until they are closed or the context is
+	// done. Then the connections to the proxy servers are closed.
+	// It returns the context error if some proxy connections are closed
+	// by force. Proxy connections dialed by DialContextWithConn are not
+	// waited. After stop, the client can't be reused.
+	StopContext(context.Context) error
+
 	// IsRunning returns true if the client has been started
 	// and has not been stopped.
 	IsRunning() bool
@@ -70,18 +95,236 @@ type ClientLifecycleService interface {
 type ClientNetworkService interface {
 	// DialContext returns a new proxy connection to reach the destination.
 	// It returns an error if the client has not been started,
-	// or has been stopped.
+	// or has been stopped. Use WithPriority to set the priority
+	// of the proxy connection. The returned connection implements
+	// apicommon.ConnInfo. It returns ErrDestinationNotAllowed if the
+	// destination is denied by the proxy server.
 	DialContext(context.Context, net.Addr) (net.Conn, error)
 
+	// DialContextWithOptions is similar to DialContext, but the proxy
+	// connection is customized by the options. For example, an application
+	// can send latency sensitive connections over UDP and bulk transfers
+	// over TCP with the same client.
+	DialContextWithOptions(context.Context, net.Addr, DialOptions) (net.Conn, error)
+
 	// DialContextWithConn is similar to DialContext, but use the given
 	// connection to establish a new proxy connection.
 	DialContextWithConn(context.Context, net.Conn, net.Addr) (net.Conn, error)
+
+	// DialDatagramContext returns a new proxy connection to exchange
+	// UDP packets with any destination. If the proxy server supports it,
+	// packets are carried as unreliable datagrams, which are neither
+	// retransmitted nor ordered. This suits protocols like QUIC and
+	// WireGuard that handle loss by themselves. Otherwise, packets are
+	// carried by a reliable stream.
+	DialDatagramContext(context.Context) (net.PacketConn, error)
+
+	// ListenContext asks the proxy server to listen on the TCP port of
+	// the address, and returns a listener that accepts the connections
+	// received by the proxy server on that port. Only the port of the
+	// address is used, and it must be allowed by the reverse tunnels
+	// of the user in the proxy server config. The context only applies
+	// to the first listen request. The listener is closed with
+	// ErrClientIsNotRunning after the client is stopped.
+	ListenContext(ctx context.Context, network, address string) (net.Listener, error)
+
+	// HealthCheck connects to every proxy server endpoint in the client
+	// config at the same time, and waits for the proxy server to respond
+	// to a request, so the application can tell which endpoint is blocked.
+	// Existing connections to the proxy servers are not used.
+	// It returns an error if the client has not been started,
+	// or has been stopped.
+	HealthCheck(context.Context) ([]HealthCheckResult, error)
+}
+
+// ClientStatisticsService contains methods to get the statistics
+// of proxy connections.
+type ClientStatisticsService interface {
+	// DestinationStats returns at most n destinations that transfer
+	// the most bytes. If n is not positive, all the destinations are
+	// returned. It returns ErrDestinationStatsDisabled if destination
+	// statistics is not enabled in the client config.
+	DestinationStats(n int) ([]DestinationStat, error)
+}
+
+// DestinationStat is the traffic of proxy connections to a destination.
+type DestinationStat = socks5.DestinationStat
+
+// EventListener receives the events of the client. Any of the callbacks
+// can be nil. The callbacks must return quickly, because they are called
+// from the goroutines that handle the connections.
+type EventListener = protocol.EventListener
+
+// UnderlayEvent describes a connection to the proxy server.
+// Reason explains why the connection is closed or the handshake fails.
+type UnderlayEvent = protocol.UnderlayEvent
+
+// SessionEvent describes a proxy connection that is closed.
+// Reason is nil if the proxy connection is closed by the application.
+type SessionEvent = protocol.SessionEvent
+
+// Priority is the priority of a proxy connection. When multiple proxy
+// connections share one connection to the proxy server, a proxy
+// connection with a higher priority sends more data in each round,
+// so bulk transfers don't starve interactive connections.
+type Priority uint8
+
+const (
+	PriorityNormal = Priority(protocol.SessionPriorityNormal)
+	PriorityLow    = Priority(protocol.SessionPriorityLow)
+	PriorityHigh   = Priority(protocol.SessionPriorityHigh)
+)
+
+// WithPriority returns a context to dial a proxy connection with the
+// priority. The priority also applies to the data sent by the proxy
+// server. Proxy connections dialed without the priority use PriorityNormal.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return protocol.WithSessionPriority(ctx, protocol.SessionPriority(priority))
+}
+
+// NameResolution decides where the domain name of a destination is resolved.
+type NameResolution uint8
+
+const (
+	// NameResolutionDefault follows the name resolution policy
+	// of the client config. It is remote if no rule matches.
+	NameResolutionDefault NameResolution = iota
+
+	// NameResolutionRemote sends the domain name to the proxy server,
+	// which resolves it. The local network doesn't see the DNS query.
+	NameResolutionRemote
+
+	// NameResolutionLocal resolves the domain name with the DNS resolver
+	// of the client config, and sends the IP address to the proxy server.
+	NameResolutionLocal
+)
+
+// NameResolutionRule selects the name resolution of
+// the destinations in a domain.
+type NameResolutionRule struct {
+	// DomainSuffix matches the domain name and all the sub-domains,
+	// for example "example.com" matches "example.com" and
+	// "www.example.com", but not "badexample.com".
+	// The match is case insensitive.
+	DomainSuffix string
+
+	// Resolution is used by the matched destinations.
+	// It can't be NameResolutionDefault.
+	Resolution NameResolution
+}
+
+// NameResolutionPolicy decides where the domain names of destinations
+// dialed by DialContext and DialContextWithConn are resolved.
+type NameResolutionPolicy struct {
+	// Rules are checked in order, and the first matched rule is used.
+	Rules []NameResolutionRule
+
+	// Default is used if no rule matches. NameResolutionDefault
+	// means NameResolutionRemote.
+	Default NameResolution
+}
+
+// DialOptions customizes a proxy connection dialed by DialContextWithOptions.
+// The zero value dials the same proxy connection as DialContext.
+type DialOptions struct {
+	// If Server is not empty, the proxy connection only uses the proxy
+	// servers with this IP address or domain name. A domain name is
+	// resolved by the DNS resolver of the client config.
+	Server string
+
+	// If Transport is not UNKNOWN_TRANSPORT_PROTOCOL, the proxy connection
+	// only uses the port bindings with this transport protocol.
+	Transport appctlpb.TransportProtocol
+
+	// If Priority is not PriorityNormal, it overrides the priority
+	// set by WithPriority.
+	Priority Priority
+
+	// By default, the domain name of the destination is resolved by the
+	// proxy server. If ResolveLocally is true, it is resolved by the DNS
+	// resolver of the client config, and the proxy server receives the
+	// IP address.
+	ResolveLocally bool
+
+	// If Resolution is not NameResolutionDefault, it overrides the name
+	// resolution policy of the client config and ResolveLocally.
+	Resolution NameResolution
+}
+
+// HealthCheckResult is the result of checking a proxy server endpoint.
+type HealthCheckResult struct {
+	// Server is the IP address and port of the proxy server endpoint.
+	Server string
+
+	// Transport is one of "TCP", "UDP", "TLS" and "WebSocket".
+	Transport string
+
+	// Reachable is true if the proxy server responded.
+	Reachable bool
+
+	// RTT is the time from sending the request to receiving the response.
+	RTT time.Duration
+
+	// Err is the reason that the proxy server is not reachable.
+	Err error
 }
 
 // ClientConfig stores proxy client configuration.
 type ClientConfig struct {
-	Profile  *appctlpb.ClientProfile
+	Profile *appctlpb.ClientProfile
+
+	// Resolver looks up the proxy servers. Use apicommon.NewSecureResolver
+	// to look up with DNS-over-HTTPS or DNS-over-TLS, so the queries
+	// are not visible to the local network. If it is nil, the system
+	// DNS resolver is used.
 	Resolver apicommon.DNSResolver
+
+	// If EarlyData is true, DialContext and DialContextWithConn return
+	// after the connection request is sent, without waiting for the
+	// response of the proxy server. Data written to the returned connection
+	// is sent together with the connection request, so the application
+	// saves one round trip. The response is checked at the first Read,
+	// which returns an error if the proxy server rejects the request.
+	EarlyData bool
+
+	// If DestinationAffinity is positive, connections to the same destination
+	// dialed within this duration reuse the same underlay, which is likely
+	// warmed up. This is useful when an application opens many short
+	// connections to the same server, like web browsers. It has no effect
+	// if multiplexing is turned off.
+	DestinationAffinity time.Duration
+
+	// NameResolution decides whether the domain names of destinations
+	// are resolved by the DNS resolver of the client config, or by the
+	// proxy server. By default, all of them are resolved by the proxy
+	// server, so the DNS queries are not visible to the local network.
+	NameResolution NameResolutionPolicy
+
+	// If DestinationStats is true, the traffic of proxy connections
+	// dialed by DialContext and DialContextWithConn is aggregated by
+	// destination domain name or IP address. The statistics are kept
+	// in memory and are lost after the client is stopped.
+	DestinationStats bool
+
+	// If EventListener is not nil, it is notified when connections to
+	// the proxy servers are connected or closed, when a handshake fails,
+	// and when a proxy connection is closed. Applications like GUI
+	// front-ends can show the connection state without polling.
+	EventListener *EventListener
+
+	// If Dialer is not nil, it creates the TCP and UDP connections to
+	// the proxy servers. It can bind the connections to a network
+	// interface, or protect the sockets from the VPN service on Android.
+	Dialer apicommon.Dialer
+
+	// If CredentialHandler is not nil, the client accepts the next password
+	// pushed by the proxy server when the operator rotates the password.
+	// The client uses the next password for new connections, and calls
+	// CredentialHandler with the user name and the next hashed password
+	// in hex encoding. The application should store it in the
+	// HashedPassword field of the user, so it is used after restart.
+	// CredentialHandler must not block.
+	CredentialHandler func(userName, hashedPassword string)
 }
 
 // NewClient creates a blank mieru client with no client config.
@@ -0,0 +1,282 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+)
+
+const (
+	// reverseListenerMinRetryInterval and reverseListenerMaxRetryInterval
+	// are the bounds of waiting time before retrying a failed BIND request.
+	reverseListenerMinRetryInterval = time.Second
+	reverseListenerMaxRetryInterval = time.Minute
+
+	// reverseListenerBacklog is the maximum number of incoming connections
+	// that are established but not accepted.
+	reverseListenerBacklog = 16
+)
+
+// reverseListener accepts the connections received by a port of the
+// proxy server. It keeps one pending socks5 BIND request, and starts
+// a new one after the proxy server receives an incoming connection.
+type reverseListener struct {
+	mc    *mieruClient
+	port  int
+	bound *net.TCPAddr
+
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	pending net.Conn // the proxy connection waiting for an incoming connection
+	err     error    // returned by Accept after the listener is closed
+}
+
+var _ net.Listener = &reverseListener{}
+
+func (mc *mieruClient) ListenContext(ctx context.Context, network, address string) (net.Listener, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("only tcp network is supported")
+	}
+	_, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid listen port %q", portStr)
+	}
+
+	l := &reverseListener{
+		mc:    mc,
+		port:  port,
+		conns: make(chan net.Conn, reverseListenerBacklog),
+		done:  make(chan struct{}),
+	}
+	conn, bound, err := l.bind(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l.bound = &net.TCPAddr{IP: bound.IP, Port: bound.Port}
+	if l.bound.Port == 0 {
+		l.bound.Port = port
+	}
+	go l.serve(conn)
+	return l, nil
+}
+
+func (l *reverseListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return nil, l.err
+	}
+}
+
+// Close stops the listener. Accepted connections are not interrupted.
+func (l *reverseListener) Close() error {
+	l.closeWithError(net.ErrClosed)
+	return nil
+}
+
+func (l *reverseListener) Addr() net.Addr {
+	return l.bound
+}
+
+// bind sends a socks5 BIND request to the proxy server, and returns
+// the proxy connection as well as the address exposed by the proxy server.
+func (l *reverseListener) bind(ctx context.Context) (net.Conn, *model.AddrSpec, error) {
+	l.mc.mu.RLock()
+	defer l.mc.mu.RUnlock()
+	if !l.mc.running {
+		return nil, nil, ErrClientIsNotRunning
+	}
+
+	conn, err := l.mc.mux.DialContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var req bytes.Buffer
+	req.Write([]byte{constant.Socks5Version, constant.Socks5BindCmd, 0})
+	if err := (model.AddrSpec{IP: net.IPv4zero, Port: l.port}).WriteToSocks5(&req); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to write socks5 bind request to the server: %w", err)
+	}
+	common.SetReadTimeout(conn, 10*time.Second)
+	bound, err := readSocks5Reply(conn)
+	common.SetReadTimeout(conn, 0)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, bound, nil
+}
+
+// serve waits for the incoming connections of the proxy server,
+// until the listener is closed or the client is stopped.
+func (l *reverseListener) serve(conn net.Conn) {
+	retryInterval := reverseListenerMinRetryInterval
+	for {
+		if !l.setPending(conn) {
+			conn.Close()
+			return
+		}
+
+		// The second reply means an incoming connection
+		// is established on the proxy server.
+		remote, err := readSocks5Reply(conn)
+		l.setPending(nil)
+		if err != nil {
+			conn.Close()
+			if l.isClosed() {
+				return
+			}
+			log.Debugf("reverse listener on port %d wait for incoming connection failed: %v", l.port, err)
+			if !l.sleep(retryInterval) {
+				return
+			}
+			retryInterval = mathext.Min(retryInterval*2, reverseListenerMaxRetryInterval)
+		} else {
+			retryInterval = reverseListenerMinRetryInterval
+			select {
+			case l.conns <- &reverseConn{Conn: conn, remote: &net.TCPAddr{IP: remote.IP, Port: remote.Port}}:
+			case <-l.done:
+				conn.Close()
+				return
+			}
+		}
+
+		// Start a new pending BIND request.
+		for {
+			conn, _, err = l.bind(context.Background())
+			if err == nil {
+				break
+			}
+			if errors.Is(err, ErrClientIsNotRunning) {
+				l.closeWithError(err)
+				return
+			}
+			log.Debugf("reverse listener on port %d BIND failed: %v", l.port, err)
+			if !l.sleep(retryInterval) {
+				return
+			}
+			retryInterval = mathext.Min(retryInterval*2, reverseListenerMaxRetryInterval)
+		}
+	}
+}
+
+// setPending stores the proxy connection waiting for an incoming connection.
+// It returns false if the listener is closed.
+func (l *reverseListener) setPending(conn net.Conn) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return false
+	}
+	l.pending = conn
+	return true
+}
+
+// sleep waits for the duration. It returns false if the listener is closed.
+func (l *reverseListener) sleep(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *reverseListener) isClosed() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *reverseListener) closeWithError(err error) {
+	l.closeOnce.Do(func() {
+		l.mu.Lock()
+		l.err = err
+		if l.pending != nil {
+			l.pending.Close()
+			l.pending = nil
+		}
+		l.mu.Unlock()
+		close(l.done)
+		for {
+			select {
+			case conn := <-l.conns:
+				conn.Close()
+			default:
+				return
+			}
+		}
+	})
+}
+
+// reverseConn is an incoming connection received by the proxy server.
+type reverseConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+// RemoteAddr returns the address of the peer that connects to the proxy server.
+func (c *reverseConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// readSocks5Reply reads a socks5 reply from the server, and returns the
+// address in the reply. It returns an error if the request is rejected.
+func readSocks5Reply(r io.Reader) (*model.AddrSpec, error) {
+	resp := make([]byte, 3)
+	if _, err := io.ReadFull(r, resp); err != nil {
+		return nil, fmt.Errorf("failed to read socks5 response from the server: %w", err)
+	}
+	addr := &model.AddrSpec{}
+	if err := addr.ReadFromSocks5(r); err != nil {
+		return nil, fmt.Errorf("failed to read socks5 address response from the server: %w", err)
+	}
+	if resp[1] != 0 {
+		return nil, fmt.Errorf("server returned socks5 error code %d", resp[1])
+	}
+	return addr, nil
+}
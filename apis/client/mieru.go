@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -35,6 +36,7 @@ import (
 	"github.com/enfein/mieru/v3/pkg/common"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/protocol"
+	"github.com/enfein/mieru/v3/pkg/socks5"
 	"github.com/enfein/mieru/v3/pkg/stderror"
 )
 
@@ -43,13 +45,18 @@ type mieruClient struct {
 	initTask sync.Once
 	mu       sync.RWMutex
 
-	config *ClientConfig
-	mux    *protocol.Mux
+	config       *ClientConfig
+	mux          *protocol.Mux
+	hosts        *common.Hosts
+	destinations *socks5.DestinationStats // nil if destination statistics is disabled
 
 	running bool
 }
 
-var _ Client = &mieruClient{}
+var (
+	_ Client                 = &mieruClient{}
+	_ apicommon.StreamDialer = &mieruClient{}
+)
 
 // initOnce should be called when constructing the mieru client.
 func (mc *mieruClient) initOnce() {
@@ -83,10 +90,38 @@ func (mc *mieruClient) Store(config *ClientConfig) error {
 	if err := appctl.ValidateClientConfigSingleProfile(config.Profile); err != nil {
 		return fmt.Errorf("%w: %s", ErrInvalidConfigConfig, err.Error())
 	}
+	if err := config.NameResolution.validate(); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidConfigConfig, err.Error())
+	}
 	mc.config = config
 	return nil
 }
 
+func (mc *mieruClient) SetResolver(resolver apicommon.DNSResolver) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.config == nil {
+		return ErrNoClientConfig
+	}
+	if mc.running {
+		muxResolver := resolver
+		if muxResolver == nil {
+			muxResolver = common.NewMeteredResolver(nil) // Default DNS resolver.
+		}
+		// Look up proxy servers again with the new resolver.
+		endpoints, err := appctl.ClientEndpoints(mc.config.Profile, muxResolver)
+		if err != nil {
+			return err
+		}
+		mc.mux.SetResolver(muxResolver)
+		mc.mux.SetEndpoints(endpoints)
+	}
+	config := *mc.config
+	config.Resolver = resolver
+	mc.config = &config
+	return nil
+}
+
 func (mc *mieruClient) Start() error {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -96,14 +131,14 @@ func (mc *mieruClient) Start() error {
 
 	var err error
 	mc.mux = protocol.NewMux(true)
-	activeProfile := mc.config.Profile
+	activeProfile := appctl.ApplyPreset(mc.config.Profile)
 
 	// Set DNS resolver.
 	var resolver apicommon.DNSResolver
 	if mc.config.Resolver != nil {
 		resolver = mc.config.Resolver
 	} else {
-		resolver = &net.Resolver{} // Default DNS resolver.
+		resolver = common.NewMeteredResolver(nil) // Default DNS resolver.
 	}
 	mc.mux.SetResolver(resolver)
 
@@ -134,49 +169,88 @@ func (mc *mieruClient) Start() error {
 	}
 	mc.mux = mc.mux.SetClientMultiplexFactor(multiplexFactor)
 
+	// Set underlay pool limits.
+	mc.mux = mc.mux.SetClientUnderlayPool(appctl.ClientUnderlayPoolConfig(activeProfile))
+
+	// Set endpoint selection policy.
+	mc.mux = mc.mux.SetClientEndpointPolicy(appctl.EndpointPolicy(activeProfile.GetEndpointPolicy()))
+
+	// Set WebSocket transport.
+	mc.mux = mc.mux.SetWebSocket(appctl.ClientWebSocketConfig(activeProfile))
+
+	// Set TLS transport.
+	mc.mux = mc.mux.SetTLS(appctl.ClientTLSConfig(activeProfile))
+
+	// Set forward error correction of UDP transport.
+	mc.mux = mc.mux.SetFECGroupSize(int(activeProfile.GetFecGroupSize()))
+
+	// Set retransmission limit of UDP transport.
+	mc.mux = mc.mux.SetRetransmissionLimit(appctl.RetransmissionLimit(activeProfile.GetRetransmissionLimit()))
+
+	// Set flow control windows of sessions.
+	mc.mux = mc.mux.SetFlowControl(appctl.FlowControl(activeProfile.GetFlowControl()))
+	mc.mux = mc.mux.SetBandwidth(appctl.Bandwidth(activeProfile.GetFlowControl()))
+
+	// Set fairness of sessions sharing a connection.
+	mc.mux = mc.mux.SetFairness(appctl.Fairness(activeProfile.GetFairness()))
+
+	// Set congestion control of UDP transport.
+	mc.mux = mc.mux.SetCongestionControl(appctl.CongestionControl(activeProfile.GetCongestionControl()))
+
+	// Set path MTU discovery of UDP transport.
+	mc.mux = mc.mux.SetClientPathMTUDiscovery(activeProfile.GetPathMTUDiscovery())
+
+	// Set session migration of UDP transport.
+	mc.mux = mc.mux.SetClientSessionMigration(activeProfile.GetSessionMigration())
+
+	// Set hybrid key exchange of sessions.
+	mc.mux = mc.mux.SetClientHybridKeyExchange(activeProfile.GetHybridKeyExchange())
+
+	// Set key rotation of TCP underlays.
+	mc.mux = mc.mux.SetClientRekey(appctl.Rekey(activeProfile.GetRekey()))
+
+	// Set UDP segmentation offload.
+	mc.mux = mc.mux.SetUDPOffload(activeProfile.GetUdpOffload())
+
+	// Set integrity diagnostic of UDP transport.
+	mc.mux = mc.mux.SetIntegrityDiagnostic(activeProfile.GetIntegrityDiagnostic())
+
+	// Set idle timeout of sessions and underlays.
+	mc.mux = mc.mux.SetClientIdleTimeout(appctl.IdleTimeout(activeProfile.GetIdleTimeout()))
+
+	// Set underlay affinity of destinations.
+	mc.mux = mc.mux.SetClientUnderlayAffinity(mc.config.DestinationAffinity)
+
+	// Set event listener.
+	if mc.config.EventListener != nil {
+		mc.mux = mc.mux.SetClientEventListener(mc.config.EventListener)
+	}
+
+	// Set underlay dialer.
+	if mc.config.Dialer != nil {
+		mc.mux = mc.mux.SetClientDialer(mc.config.Dialer)
+	}
+
+	// Set credential renewal.
+	if mc.config.CredentialHandler != nil {
+		handler := mc.config.CredentialHandler
+		mc.mux = mc.mux.SetClientCredentialHandler(func(userName string, hashedPassword []byte) {
+			handler(userName, hex.EncodeToString(hashedPassword))
+		})
+	}
+
+	// Set static hosts of destinations.
+	mc.hosts = appctl.ClientHosts(activeProfile)
+
+	// Set destination statistics.
+	if mc.config.DestinationStats {
+		mc.destinations = socks5.NewDestinationStats()
+	}
+
 	// Set server endpoints.
-	mtu := common.DefaultMTU
-	if activeProfile.GetMtu() != 0 {
-		mtu = int(activeProfile.GetMtu())
-	}
-	endpoints := make([]protocol.UnderlayProperties, 0)
-	for _, serverInfo := range activeProfile.GetServers() {
-		var proxyHost string
-		var proxyIP net.IP
-		if serverInfo.GetDomainName() != "" {
-			proxyHost = serverInfo.GetDomainName()
-			proxyIPs, err := resolver.LookupIP(context.Background(), "ip", proxyHost)
-			if err != nil {
-				return fmt.Errorf(stderror.LookupIPFailedErr, err)
-			}
-			if len(proxyIPs) == 0 {
-				return fmt.Errorf(stderror.IPAddressNotFound, proxyHost)
-			}
-			proxyIP = proxyIPs[0]
-		} else {
-			proxyHost = serverInfo.GetIpAddress()
-			proxyIP = net.ParseIP(proxyHost)
-			if proxyIP == nil {
-				return fmt.Errorf(stderror.ParseIPFailed)
-			}
-		}
-		portBindings, err := appctl.FlatPortBindings(serverInfo.GetPortBindings())
-		if err != nil {
-			return fmt.Errorf(stderror.InvalidPortBindingsErr, err)
-		}
-		for _, bindingInfo := range portBindings {
-			proxyPort := bindingInfo.GetPort()
-			switch bindingInfo.GetProtocol() {
-			case appctlpb.TransportProtocol_TCP:
-				endpoint := protocol.NewUnderlayProperties(mtu, common.StreamTransport, nil, &net.TCPAddr{IP: proxyIP, Port: int(proxyPort)})
-				endpoints = append(endpoints, endpoint)
-			case appctlpb.TransportProtocol_UDP:
-				endpoint := protocol.NewUnderlayProperties(mtu, common.PacketTransport, nil, &net.UDPAddr{IP: proxyIP, Port: int(proxyPort)})
-				endpoints = append(endpoints, endpoint)
-			default:
-				return fmt.Errorf(stderror.InvalidTransportProtocol)
-			}
-		}
+	endpoints, err := appctl.ClientEndpoints(activeProfile, resolver)
+	if err != nil {
+		return err
 	}
 	mc.mux.SetEndpoints(endpoints)
 
@@ -195,6 +269,22 @@ func (mc *mieruClient) Stop() error {
 	return nil
 }
 
+func (mc *mieruClient) StopContext(ctx context.Context) error {
+	mc.mu.Lock()
+	mc.running = false
+	mux := mc.mux
+	mc.mu.Unlock()
+
+	// Don't hold the lock when draining, so IsRunning and other methods
+	// that check the status are not blocked.
+	if mux == nil {
+		return nil
+	}
+	err := mux.Drain(ctx)
+	mux.Close()
+	return err
+}
+
 func (mc *mieruClient) IsRunning() bool {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
@@ -202,6 +292,10 @@ func (mc *mieruClient) IsRunning() bool {
 }
 
 func (mc *mieruClient) DialContext(ctx context.Context, addr net.Addr) (net.Conn, error) {
+	return mc.DialContextWithOptions(ctx, addr, DialOptions{})
+}
+
+func (mc *mieruClient) DialContextWithOptions(ctx context.Context, addr net.Addr, opts DialOptions) (net.Conn, error) {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
 	if !mc.running {
@@ -216,12 +310,25 @@ func (mc *mieruClient) DialContext(ctx context.Context, addr net.Addr) (net.Conn
 	if !strings.HasPrefix(netAddrSpec.Network(), "tcp") {
 		return nil, fmt.Errorf("only tcp network is supported")
 	}
+	destination := destinationName(netAddrSpec)
+	mc.applyHosts(&netAddrSpec)
 
-	conn, err := mc.mux.DialContext(ctx)
+	ctx, err := mc.applyDialOptions(ctx, &netAddrSpec, opts)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := mc.mux.DialContextWithAffinity(ctx, netAddrSpec.String())
+	if err != nil {
+		return nil, err
+	}
+	proxyConn, err := mc.dialPostHandshake(conn, netAddrSpec)
 	if err != nil {
 		return nil, err
 	}
-	return mc.dialPostHandshake(conn, netAddrSpec)
+	if mc.destinations != nil {
+		proxyConn = mc.destinations.Wrap(proxyConn, destination)
+	}
+	return withConnInfo(proxyConn, conn), nil
 }
 
 func (mc *mieruClient) DialContextWithConn(ctx context.Context, conn net.Conn, addr net.Addr) (net.Conn, error) {
@@ -239,12 +346,238 @@ func (mc *mieruClient) DialContextWithConn(ctx context.Context, conn net.Conn, a
 	if !strings.HasPrefix(netAddrSpec.Network(), "tcp") {
 		return nil, fmt.Errorf("only tcp network is supported")
 	}
+	destination := destinationName(netAddrSpec)
+	mc.applyHosts(&netAddrSpec)
+	if err := mc.applyNameResolution(ctx, &netAddrSpec, NameResolutionDefault); err != nil {
+		return nil, err
+	}
 
 	subConn, err := mc.mux.DialContextWithConn(ctx, conn)
 	if err != nil {
 		return nil, err
 	}
-	return mc.dialPostHandshake(subConn, netAddrSpec)
+	proxyConn, err := mc.dialPostHandshake(subConn, netAddrSpec)
+	if err != nil {
+		return nil, err
+	}
+	if mc.destinations != nil {
+		proxyConn = mc.destinations.Wrap(proxyConn, destination)
+	}
+	return withConnInfo(proxyConn, subConn), nil
+}
+
+func (mc *mieruClient) DialDatagramContext(ctx context.Context) (net.PacketConn, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	if !mc.running {
+		return nil, ErrClientIsNotRunning
+	}
+
+	conn, err := mc.mux.DialDatagramContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var req bytes.Buffer
+	req.Write([]byte{constant.Socks5Version, constant.Socks5UDPAssociateCmd, 0})
+	if err := (model.AddrSpec{IP: net.IPv4zero}).WriteToSocks5(&req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to write socks5 UDP associate request to the server: %w", err)
+	}
+	common.SetReadTimeout(conn, 10*time.Second)
+	err = readSocks5ConnResponse(conn)
+	common.SetReadTimeout(conn, 0)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	pc := &datagramPacketConn{conn: conn}
+	if conn.DatagramEnabled() {
+		pc.read = conn.ReadDatagram
+		pc.write = func(b []byte) error {
+			err := conn.WriteDatagram(b)
+			if errors.Is(err, stderror.ErrOutOfRange) {
+				// Like UDP, a packet larger than the path MTU is lost.
+				return nil
+			}
+			return err
+		}
+	} else {
+		tunnel := socks5.WrapUDPAssociateTunnel(conn)
+		pc.read = tunnel.Read
+		pc.write = func(b []byte) error {
+			_, err := tunnel.Write(b)
+			return err
+		}
+	}
+	return pc, nil
+}
+
+func (mc *mieruClient) HealthCheck(ctx context.Context) ([]HealthCheckResult, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	if !mc.running {
+		return nil, ErrClientIsNotRunning
+	}
+
+	checks, err := mc.mux.CheckEndpoints(ctx, socks5.ProbeProxyServer)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]HealthCheckResult, 0, len(checks))
+	for _, c := range checks {
+		results = append(results, HealthCheckResult{
+			Server:    c.Endpoint.RemoteAddr().String(),
+			Transport: c.Transport,
+			Reachable: c.Reachable,
+			RTT:       c.RTT,
+			Err:       c.Err,
+		})
+	}
+	return results, nil
+}
+
+func (mc *mieruClient) DestinationStats(n int) ([]DestinationStat, error) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	if mc.destinations == nil {
+		return nil, ErrDestinationStatsDisabled
+	}
+	return mc.destinations.Top(n), nil
+}
+
+// destinationName returns the domain name or IP address of the destination.
+func destinationName(netAddrSpec model.NetAddrSpec) string {
+	if netAddrSpec.FQDN != "" {
+		return netAddrSpec.FQDN
+	}
+	return netAddrSpec.IP.String()
+}
+
+// applyHosts replaces the domain name of the destination
+// with the IP address from the static hosts.
+func (mc *mieruClient) applyHosts(netAddrSpec *model.NetAddrSpec) {
+	if netAddrSpec.FQDN == "" {
+		return
+	}
+	if ips, ok := mc.hosts.Lookup(netAddrSpec.FQDN); ok {
+		netAddrSpec.FQDN = ""
+		netAddrSpec.IP = ips[0]
+	}
+}
+
+// resolver returns the DNS resolver in the client config,
+// or the system DNS resolver if it is not set.
+func (mc *mieruClient) resolver() apicommon.DNSResolver {
+	if mc.config.Resolver != nil {
+		return mc.config.Resolver
+	}
+	return net.DefaultResolver
+}
+
+// applyDialOptions returns the context to dial the proxy connection
+// with the options. If the options ask to resolve the destination
+// locally, the domain name of the destination is replaced.
+func (mc *mieruClient) applyDialOptions(ctx context.Context, netAddrSpec *model.NetAddrSpec, opts DialOptions) (context.Context, error) {
+	if opts.Priority != PriorityNormal {
+		ctx = WithPriority(ctx, opts.Priority)
+	}
+
+	var filter protocol.EndpointFilter
+	switch opts.Transport {
+	case appctlpb.TransportProtocol_UNKNOWN_TRANSPORT_PROTOCOL:
+	case appctlpb.TransportProtocol_TCP:
+		filter.TransportProtocol = common.StreamTransport
+	case appctlpb.TransportProtocol_UDP:
+		filter.TransportProtocol = common.PacketTransport
+	default:
+		return nil, fmt.Errorf("unsupported transport protocol %v", opts.Transport)
+	}
+	if opts.Server != "" {
+		if ip := net.ParseIP(opts.Server); ip != nil {
+			filter.ServerIPs = []net.IP{ip}
+		} else {
+			ips, err := mc.resolver().LookupIP(ctx, "ip", opts.Server)
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up proxy server %s: %w", opts.Server, err)
+			}
+			filter.ServerIPs = ips
+		}
+	}
+	if filter.TransportProtocol != common.UnknownTransport || len(filter.ServerIPs) > 0 {
+		ctx = protocol.WithEndpointFilter(ctx, filter)
+	}
+
+	resolution := opts.Resolution
+	if resolution == NameResolutionDefault && opts.ResolveLocally {
+		resolution = NameResolutionLocal
+	}
+	if err := mc.applyNameResolution(ctx, netAddrSpec, resolution); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// applyNameResolution replaces the domain name of the destination with
+// the IP address from the DNS resolver, if the destination is resolved
+// locally. If resolution is NameResolutionDefault, it is decided by the
+// name resolution policy of the client config.
+func (mc *mieruClient) applyNameResolution(ctx context.Context, netAddrSpec *model.NetAddrSpec, resolution NameResolution) error {
+	if netAddrSpec.FQDN == "" {
+		return nil
+	}
+	if resolution == NameResolutionDefault {
+		resolution = mc.config.NameResolution.decide(netAddrSpec.FQDN)
+	}
+	if resolution != NameResolutionLocal {
+		return nil
+	}
+	ips, err := mc.resolver().LookupIP(ctx, "ip", netAddrSpec.FQDN)
+	if err != nil {
+		return fmt.Errorf("failed to look up destination %s: %w", netAddrSpec.FQDN, err)
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("destination %s has no IP address", netAddrSpec.FQDN)
+	}
+	netAddrSpec.FQDN = ""
+	netAddrSpec.IP = ips[0]
+	return nil
+}
+
+// validate returns an error if the name resolution policy is invalid.
+func (p NameResolutionPolicy) validate() error {
+	if p.Default > NameResolutionLocal {
+		return fmt.Errorf("unknown default name resolution %d", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if strings.Trim(rule.DomainSuffix, ".") == "" {
+			return fmt.Errorf("name resolution rule %d has empty domain suffix", i)
+		}
+		if rule.Resolution != NameResolutionRemote && rule.Resolution != NameResolutionLocal {
+			return fmt.Errorf("name resolution rule %d has invalid resolution %d", i, rule.Resolution)
+		}
+	}
+	return nil
+}
+
+// decide returns where the domain name is resolved.
+// The result is never NameResolutionDefault.
+func (p NameResolutionPolicy) decide(domain string) NameResolution {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, rule := range p.Rules {
+		suffix := strings.ToLower(strings.Trim(rule.DomainSuffix, "."))
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return rule.Resolution
+		}
+	}
+	if p.Default == NameResolutionLocal {
+		return NameResolutionLocal
+	}
+	return NameResolutionRemote
 }
 
 func (mc *mieruClient) dialPostHandshake(conn net.Conn, netAddrSpec model.NetAddrSpec) (net.Conn, error) {
@@ -256,22 +589,146 @@ func (mc *mieruClient) dialPostHandshake(conn net.Conn, netAddrSpec model.NetAdd
 	if _, err := conn.Write(req.Bytes()); err != nil {
 		return nil, fmt.Errorf("failed to write socks5 connection request to the server: %w", err)
 	}
+	if mc.config.EarlyData {
+		return &earlyDataConn{Conn: conn}, nil
+	}
 
 	common.SetReadTimeout(conn, 10*time.Second)
 	defer func() {
 		common.SetReadTimeout(conn, 0)
 	}()
+	if err := readSocks5ConnResponse(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
 
+// readSocks5ConnResponse reads the socks5 connection response
+// from the server, and returns an error if the request is rejected.
+func readSocks5ConnResponse(conn net.Conn) error {
 	resp := make([]byte, 3)
 	if _, err := io.ReadFull(conn, resp); err != nil {
-		return nil, fmt.Errorf("failed to read socks5 connection response from the server: %w", err)
+		return fmt.Errorf("failed to read socks5 connection response from the server: %w", err)
 	}
 	var respAddr model.NetAddrSpec
 	if err := respAddr.ReadFromSocks5(conn); err != nil {
-		return nil, fmt.Errorf("failed to read socks5 connection address response from the server: %w", err)
+		return fmt.Errorf("failed to read socks5 connection address response from the server: %w", err)
 	}
-	if resp[1] != 0 {
-		return nil, fmt.Errorf("server returned socks5 error code %d", resp[1])
+	if resp[1] == constant.Socks5ReplyNotAllowed {
+		return ErrDestinationNotAllowed
 	}
-	return conn, nil
+	if resp[1] != constant.Socks5ReplySuccess {
+		return fmt.Errorf("server returned socks5 error code %d", resp[1])
+	}
+	return nil
+}
+
+// withConnInfo returns a proxy connection that implements
+// apicommon.ConnInfo with the information of the session.
+func withConnInfo(proxyConn, session net.Conn) net.Conn {
+	if _, ok := proxyConn.(apicommon.ConnInfo); ok {
+		return proxyConn
+	}
+	info, ok := session.(apicommon.ConnInfo)
+	if !ok {
+		return proxyConn
+	}
+	return &connInfoConn{Conn: proxyConn, ConnInfo: info}
+}
+
+// connInfoConn adds apicommon.ConnInfo to a proxy connection.
+type connInfoConn struct {
+	net.Conn
+	apicommon.ConnInfo
+}
+
+// earlyDataConn is a proxy connection returned before the socks5
+// connection response is received. The response is consumed by the
+// first Read.
+type earlyDataConn struct {
+	net.Conn
+	handshake sync.Once
+	err       error
+}
+
+func (c *earlyDataConn) Read(b []byte) (int, error) {
+	c.handshake.Do(func() {
+		c.err = readSocks5ConnResponse(c.Conn)
+	})
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Read(b)
+}
+
+// datagramPacketConn sends and receives UDP packets through a socks5
+// UDP association established in a proxy connection. Each packet is
+// prefixed with a socks5 UDP request header.
+type datagramPacketConn struct {
+	conn  protocol.DatagramConn
+	read  func([]byte) (int, error)
+	write func([]byte) error
+}
+
+var _ net.PacketConn = &datagramPacketConn{}
+
+func (c *datagramPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	buf := make([]byte, 65536)
+	for {
+		n, err := c.read(buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		if n <= 3 || buf[2] != 0 {
+			// Drop invalid and fragmented packets.
+			continue
+		}
+		r := bytes.NewReader(buf[3:n])
+		var addr model.AddrSpec
+		if err := addr.ReadFromSocks5(r); err != nil {
+			continue
+		}
+		payload := buf[n-r.Len() : n]
+		if len(addr.IP) != 0 {
+			return copy(b, payload), &net.UDPAddr{IP: addr.IP, Port: addr.Port}, nil
+		}
+		return copy(b, payload), model.NetAddrSpec{AddrSpec: addr, Net: "udp"}, nil
+	}
+}
+
+func (c *datagramPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	var netAddrSpec model.NetAddrSpec
+	if err := netAddrSpec.From(addr); err != nil {
+		return 0, fmt.Errorf("invalid destination address: %w", err)
+	}
+	var packet bytes.Buffer
+	packet.Write([]byte{0, 0, 0})
+	if err := netAddrSpec.WriteToSocks5(&packet); err != nil {
+		return 0, err
+	}
+	packet.Write(b)
+	if err := c.write(packet.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *datagramPacketConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *datagramPacketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *datagramPacketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *datagramPacketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *datagramPacketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
 }
@@ -0,0 +1,42 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import "net"
+
+// ConnInfo describes how a proxy connection reaches the proxy server.
+// The connections returned by the DialContext methods of the mieru
+// client implement this interface, which can be retrieved with a type
+// assertion. It is useful for diagnostics and logging.
+type ConnInfo interface {
+	// Transport returns one of "TCP", "UDP", "TLS" and "WebSocket".
+	Transport() string
+
+	// UnderlayLocalAddr returns the local address of the connection
+	// to the proxy server.
+	UnderlayLocalAddr() net.Addr
+
+	// UnderlayRemoteAddr returns the address of the proxy server.
+	UnderlayRemoteAddr() net.Addr
+
+	// Cipher returns the name of the encryption algorithm,
+	// for example "XChaCha20-Poly1305".
+	Cipher() string
+
+	// SessionID returns the ID of the session that carries
+	// the proxy connection.
+	SessionID() uint32
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"net"
+)
+
+// Dialer creates the network connections to the proxy servers.
+// A custom Dialer can bind the connections to a network interface,
+// set socket options like SO_MARK, or protect the sockets from
+// the VPN service on Android.
+type Dialer interface {
+	// DialContext connects to the address on the named network.
+	// It is used to create TCP connections to the proxy servers.
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+
+	// ListenPacket announces on the local network address.
+	// It is used to create UDP connections to the proxy servers.
+	// The address is empty if the local address is not specified.
+	// If the returned connection is a *net.UDPConn, more efficient
+	// I/O is used.
+	ListenPacket(ctx context.Context, network, address string) (net.PacketConn, error)
+}
+
+// NetDialer implements Dialer with the standard library.
+// Socket options can be set with the Control function of
+// Dialer and ListenConfig.
+type NetDialer struct {
+	Dialer       net.Dialer
+	ListenConfig net.ListenConfig
+}
+
+var _ Dialer = &NetDialer{}
+
+func (d *NetDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d.Dialer.DialContext(ctx, network, address)
+}
+
+func (d *NetDialer) ListenPacket(ctx context.Context, network, address string) (net.PacketConn, error) {
+	return d.ListenConfig.ListenPacket(ctx, network, address)
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/model"
+	"golang.org/x/net/proxy"
+)
+
+// StreamDialer dials TCP connections to destinations through the proxy.
+// A started mieru client implements this interface.
+type StreamDialer interface {
+	DialContext(ctx context.Context, addr net.Addr) (net.Conn, error)
+}
+
+// ProxyDialer adapts a StreamDialer to the dialer interfaces of
+// golang.org/x/net/proxy, so it can be used by libraries that accept
+// a proxy.Dialer or proxy.ContextDialer.
+type ProxyDialer struct {
+	dialer StreamDialer
+}
+
+var (
+	_ proxy.Dialer        = &ProxyDialer{}
+	_ proxy.ContextDialer = &ProxyDialer{}
+)
+
+// NewProxyDialer returns a ProxyDialer that dials through the dialer.
+func NewProxyDialer(dialer StreamDialer) *ProxyDialer {
+	return &ProxyDialer{dialer: dialer}
+}
+
+// Dial connects to the address through the proxy.
+func (d *ProxyDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the address through the proxy.
+// Only TCP networks are supported.
+func (d *ProxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("network %q is not supported", network)
+	}
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("net.SplitHostPort() failed: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %w", portStr, err)
+	}
+	addr := model.NetAddrSpec{
+		AddrSpec: model.AddrSpec{Port: port},
+		Net:      network,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		addr.IP = ip
+	} else {
+		addr.FQDN = host
+	}
+	return d.dialer.DialContext(ctx, addr)
+}
+
+// NewTransport returns a http.Transport that sends HTTP requests
+// through the proxy, for example
+//
+//	httpClient := &http.Client{Transport: apicommon.NewTransport(mieruClient)}
+//
+// The destination host names are resolved by the proxy server.
+// Proxy settings in the environment variables are ignored.
+func NewTransport(dialer StreamDialer) *http.Transport {
+	return &http.Transport{
+		DialContext:           NewProxyDialer(dialer).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
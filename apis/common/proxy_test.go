@@ -0,0 +1,102 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/enfein/mieru/v3/apis/model"
+)
+
+// recordDialer remembers the last destination without connecting to it.
+type recordDialer struct {
+	last model.NetAddrSpec
+}
+
+func (d *recordDialer) DialContext(ctx context.Context, addr net.Addr) (net.Conn, error) {
+	d.last = addr.(model.NetAddrSpec)
+	return nil, errors.New("not connected")
+}
+
+// directDialer connects to the destinations without a proxy.
+type directDialer struct {
+	recordDialer
+}
+
+func (d *directDialer) DialContext(ctx context.Context, addr net.Addr) (net.Conn, error) {
+	d.recordDialer.DialContext(ctx, addr)
+	return (&net.Dialer{}).DialContext(ctx, addr.Network(), addr.String())
+}
+
+func TestProxyDialerAddress(t *testing.T) {
+	testCases := []struct {
+		address string
+		want    model.AddrSpec
+	}{
+		{"example.com:443", model.AddrSpec{FQDN: "example.com", Port: 443}},
+		{"127.0.0.1:80", model.AddrSpec{IP: net.ParseIP("127.0.0.1"), Port: 80}},
+		{"[::1]:8080", model.AddrSpec{IP: net.ParseIP("::1"), Port: 8080}},
+	}
+	for _, tc := range testCases {
+		d := &recordDialer{}
+		NewProxyDialer(d).DialContext(context.Background(), "tcp", tc.address)
+		if d.last.String() != tc.want.String() || d.last.Network() != "tcp" {
+			t.Errorf("DialContext(%q) dialed %v, want %v", tc.address, d.last, tc.want)
+		}
+	}
+
+	for _, address := range []string{"example.com", "example.com:http"} {
+		if _, err := NewProxyDialer(&recordDialer{}).DialContext(context.Background(), "tcp", address); err == nil {
+			t.Errorf("DialContext(%q) returned no error", address)
+		}
+	}
+	if _, err := NewProxyDialer(&recordDialer{}).Dial("udp", "127.0.0.1:53"); err == nil {
+		t.Errorf("Dial() with UDP network returned no error")
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	d := &directDialer{}
+	httpClient := &http.Client{Transport: NewTransport(d)}
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("io.ReadAll() failed: %v", err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("got response %q, want %q", string(b), "hello")
+	}
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	if strconv.Itoa(d.last.Port) != port {
+		t.Errorf("dialed port %d, want %s", d.last.Port, port)
+	}
+}
@@ -0,0 +1,356 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultSecureDNSCacheTTL is the default time to keep
+	// the result of a lookup in SecureResolver.
+	DefaultSecureDNSCacheTTL = 5 * time.Minute
+
+	// maxSecureDNSCacheEntries is the maximum number of lookup results
+	// kept in SecureResolver.
+	maxSecureDNSCacheEntries = 4096
+
+	// defaultDoTPort is the default port of DNS-over-TLS servers.
+	defaultDoTPort = "853"
+
+	// defaultDoHPort is the default port of DNS-over-HTTPS servers.
+	defaultDoHPort = "443"
+
+	// dnsMessageType is the media type of DNS-over-HTTPS.
+	dnsMessageType = "application/dns-message"
+)
+
+// SecureResolverConfig configures a SecureResolver.
+type SecureResolverConfig struct {
+	// Server is the URL of a DNS-over-HTTPS server, for example
+	// "https://dns.google/dns-query", or a DNS-over-TLS server with
+	// the "tls" scheme, for example "tls://dns.google". The default
+	// port of DNS-over-TLS is 853.
+	Server string
+
+	// BootstrapIPs are the IP addresses of the DNS server. They are
+	// required if the host of the server is a domain name, so the
+	// DNS server is reached without plaintext DNS.
+	BootstrapIPs []net.IP
+
+	// Dialer creates the TCP connections to the DNS server.
+	// If it is nil, NetDialer is used.
+	Dialer Dialer
+
+	// TLSConfig is used to verify the DNS server, for example to add
+	// custom root certificates. The server name is set by the resolver.
+	// If it is nil, the system root certificates are used.
+	TLSConfig *tls.Config
+
+	// CacheTTL is the time to keep the result of a lookup.
+	// If it is 0, DefaultSecureDNSCacheTTL is used.
+	// If it is negative, the results are not cached.
+	CacheTTL time.Duration
+}
+
+// SecureResolver looks up IP addresses with a DNS-over-HTTPS or
+// DNS-over-TLS server, so the queries are not visible to the
+// local network. The results are cached.
+type SecureResolver struct {
+	resolver  *net.Resolver
+	doh       bool
+	serverURL string   // URL of DNS-over-HTTPS server
+	addrs     []string // "IP:port" of the DNS server
+	next      atomic.Uint32
+	dialer    Dialer
+	tlsConfig *tls.Config  // server name is set
+	client    *http.Client // only used by DNS-over-HTTPS
+	cacheTTL  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]secureDNSCacheEntry
+}
+
+type secureDNSCacheEntry struct {
+	ips    []net.IP
+	expire time.Time
+}
+
+var _ DNSResolver = &SecureResolver{}
+
+// NewSecureResolver creates a new SecureResolver.
+func NewSecureResolver(config SecureResolverConfig) (*SecureResolver, error) {
+	u, err := url.Parse(config.Server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS server %q: %w", config.Server, err)
+	}
+	r := &SecureResolver{
+		dialer:   config.Dialer,
+		cacheTTL: config.CacheTTL,
+		cache:    map[string]secureDNSCacheEntry{},
+	}
+	var port string
+	switch u.Scheme {
+	case "https":
+		r.doh = true
+		r.serverURL = u.String()
+		port = defaultDoHPort
+	case "tls":
+		port = defaultDoTPort
+	default:
+		return nil, fmt.Errorf("invalid DNS server %q: scheme must be https or tls", config.Server)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("invalid DNS server %q: host is empty", config.Server)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	ips := config.BootstrapIPs
+	if len(ips) == 0 {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil, fmt.Errorf("bootstrap IP addresses of DNS server %q are not set", config.Server)
+		}
+		ips = []net.IP{ip}
+	}
+	for _, ip := range ips {
+		if ip == nil {
+			return nil, fmt.Errorf("invalid bootstrap IP address of DNS server %q", config.Server)
+		}
+		r.addrs = append(r.addrs, net.JoinHostPort(ip.String(), port))
+	}
+	if r.dialer == nil {
+		r.dialer = &NetDialer{}
+	}
+	if r.cacheTTL == 0 {
+		r.cacheTTL = DefaultSecureDNSCacheTTL
+	}
+	if config.TLSConfig != nil {
+		r.tlsConfig = config.TLSConfig.Clone()
+	} else {
+		r.tlsConfig = &tls.Config{}
+	}
+	r.tlsConfig.ServerName = host
+	if r.doh {
+		r.client = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return r.dialer.DialContext(ctx, network, r.nextAddr())
+				},
+				TLSClientConfig:   r.tlsConfig,
+				ForceAttemptHTTP2: true,
+				IdleConnTimeout:   90 * time.Second,
+			},
+		}
+	}
+	r.resolver = &net.Resolver{
+		PreferGo: true,
+		Dial:     r.dial,
+	}
+	return r, nil
+}
+
+// LookupIP looks up host with the DNS server.
+func (r *SecureResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	key := network + "/" + strings.ToLower(host)
+	if ips, ok := r.lookupCache(key); ok {
+		return ips, nil
+	}
+	ips, err := r.resolver.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, err
+	}
+	r.storeCache(key, ips)
+	return ips, nil
+}
+
+func (r *SecureResolver) lookupCache(key string) ([]net.IP, bool) {
+	if r.cacheTTL < 0 {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expire) {
+		delete(r.cache, key)
+		return nil, false
+	}
+	return append([]net.IP(nil), entry.ips...), true
+}
+
+func (r *SecureResolver) storeCache(key string, ips []net.IP) {
+	if r.cacheTTL < 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if len(r.cache) >= maxSecureDNSCacheEntries {
+		for k, entry := range r.cache {
+			if now.After(entry.expire) {
+				delete(r.cache, k)
+			}
+		}
+		if len(r.cache) >= maxSecureDNSCacheEntries {
+			r.cache = map[string]secureDNSCacheEntry{}
+		}
+	}
+	r.cache[key] = secureDNSCacheEntry{
+		ips:    append([]net.IP(nil), ips...),
+		expire: now.Add(r.cacheTTL),
+	}
+}
+
+// nextAddr returns the address of the DNS server in a round-robin way.
+func (r *SecureResolver) nextAddr() string {
+	return r.addrs[int(r.next.Add(1)-1)%len(r.addrs)]
+}
+
+// dial connects to the DNS server. The address from the system
+// configuration is ignored. The returned connection is not a
+// net.PacketConn, so the resolver uses TCP message format.
+func (r *SecureResolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if r.doh {
+		return &dohConn{resolver: r}, nil
+	}
+	conn, err := r.dialer.DialContext(ctx, "tcp", r.nextAddr())
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, r.tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// dohConn sends DNS queries in TCP message format with DNS-over-HTTPS.
+// Each query is sent when it is written, and the response is
+// returned by the following reads.
+type dohConn struct {
+	resolver *SecureResolver
+	query    bytes.Buffer
+	response bytes.Buffer
+	deadline time.Time
+}
+
+var _ net.Conn = &dohConn{}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.query.Write(b)
+	for c.query.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.query.Bytes()))
+		if c.query.Len() < 2+size {
+			break
+		}
+		c.query.Next(2)
+		if err := c.roundTrip(c.query.Next(size)); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.response.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.response.Read(b)
+}
+
+// roundTrip sends a DNS query with HTTP POST method and
+// adds the response to the read buffer.
+func (c *dohConn) roundTrip(query []byte) error {
+	ctx := context.Background()
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.resolver.serverURL, bytes.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+	resp, err := c.resolver.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DNS-over-HTTPS server returned status %s", resp.Status)
+	}
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, 65535+1))
+	if err != nil {
+		return err
+	}
+	if len(msg) > 65535 {
+		return fmt.Errorf("DNS-over-HTTPS response is too large")
+	}
+	var size [2]byte
+	binary.BigEndian.PutUint16(size[:], uint16(len(msg)))
+	c.response.Write(size[:])
+	c.response.Write(msg)
+	return nil
+}
+
+func (c *dohConn) Close() error {
+	return nil
+}
+
+func (c *dohConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (c *dohConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *dohConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *dohConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
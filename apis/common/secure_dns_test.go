@@ -0,0 +1,183 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var testSecureDNSIP = net.IPv4(192, 0, 2, 1).To4()
+
+// testDNSAnswer returns a DNS response that resolves
+// all A queries to testSecureDNSIP.
+func testDNSAnswer(t *testing.T, query []byte) []byte {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		t.Errorf("dnsmessage.Parser.Start() failed: %v", err)
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		t.Errorf("dnsmessage.Parser.Question() failed: %v", err)
+		return nil
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true})
+	b.EnableCompression()
+	b.StartQuestions()
+	b.Question(q)
+	b.StartAnswers()
+	if q.Type == dnsmessage.TypeA {
+		var a [4]byte
+		copy(a[:], testSecureDNSIP)
+		b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}, dnsmessage.AResource{A: a})
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Errorf("dnsmessage.Builder.Finish() failed: %v", err)
+	}
+	return msg
+}
+
+func testRootCAs(server *httptest.Server) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	return pool
+}
+
+func TestSecureResolverDoH(t *testing.T) {
+	var queries atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != dnsMessageType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		query, _ := io.ReadAll(req.Body)
+		queries.Add(1)
+		w.Header().Set("Content-Type", dnsMessageType)
+		w.Write(testDNSAnswer(t, query))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	// The certificate of the test server is valid for example.com.
+	r, err := NewSecureResolver(SecureResolverConfig{
+		Server:       "https://example.com:" + u.Port() + "/dns-query",
+		BootstrapIPs: []net.IP{net.ParseIP("127.0.0.1")},
+		TLSConfig:    &tls.Config{RootCAs: testRootCAs(server)},
+	})
+	if err != nil {
+		t.Fatalf("NewSecureResolver() failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		ips, err := r.LookupIP(context.Background(), "ip4", "www.mieru.test.")
+		if err != nil {
+			t.Fatalf("LookupIP() failed: %v", err)
+		}
+		if len(ips) != 1 || !ips[0].Equal(testSecureDNSIP) {
+			t.Errorf("LookupIP() = %v, want [%v]", ips, testSecureDNSIP)
+		}
+	}
+	// The second lookup is cached.
+	if got := queries.Load(); got != 1 {
+		t.Errorf("got %d queries, want 1", got)
+	}
+}
+
+func TestSecureResolverDoT(t *testing.T) {
+	// Borrow the certificate of a test HTTPS server.
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certServer.TLS.Certificates})
+	if err != nil {
+		t.Fatalf("tls.Listen() failed: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					var size [2]byte
+					if _, err := io.ReadFull(conn, size[:]); err != nil {
+						return
+					}
+					query := make([]byte, binary.BigEndian.Uint16(size[:]))
+					if _, err := io.ReadFull(conn, query); err != nil {
+						return
+					}
+					resp := testDNSAnswer(t, query)
+					binary.BigEndian.PutUint16(size[:], uint16(len(resp)))
+					conn.Write(append(size[:], resp...))
+				}
+			}(conn)
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	r, err := NewSecureResolver(SecureResolverConfig{
+		Server:       "tls://example.com:" + port,
+		BootstrapIPs: []net.IP{net.ParseIP("127.0.0.1")},
+		TLSConfig:    &tls.Config{RootCAs: testRootCAs(certServer)},
+		CacheTTL:     -1,
+	})
+	if err != nil {
+		t.Fatalf("NewSecureResolver() failed: %v", err)
+	}
+	ips, err := r.LookupIP(context.Background(), "ip4", "www.mieru.test.")
+	if err != nil {
+		t.Fatalf("LookupIP() failed: %v", err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(testSecureDNSIP) {
+		t.Errorf("LookupIP() = %v, want [%v]", ips, testSecureDNSIP)
+	}
+}
+
+func TestNewSecureResolverError(t *testing.T) {
+	cases := []string{
+		"",
+		"udp://1.1.1.1",
+		"https:///dns-query",
+		"https://dns.google/dns-query",
+	}
+	for _, server := range cases {
+		if _, err := NewSecureResolver(SecureResolverConfig{Server: server}); err == nil {
+			t.Errorf("NewSecureResolver(%q) returned no error", server)
+		}
+	}
+	if _, err := NewSecureResolver(SecureResolverConfig{Server: "https://dns.google/dns-query", BootstrapIPs: []net.IP{nil}}); err == nil {
+		t.Errorf("NewSecureResolver() with invalid bootstrap IP address returned no error")
+	}
+	if _, err := NewSecureResolver(SecureResolverConfig{Server: "tls://1.1.1.1"}); err != nil {
+		t.Errorf("NewSecureResolver() failed: %v", err)
+	}
+}
@@ -34,6 +34,19 @@ const (
 	Socks5IPv6Address byte = 4
 )
 
+// socks5 reply codes.
+const (
+	Socks5ReplySuccess              byte = 0
+	Socks5ReplyServerFailure        byte = 1
+	Socks5ReplyNotAllowed           byte = 2
+	Socks5ReplyNetworkUnreachable   byte = 3
+	Socks5ReplyHostUnreachable      byte = 4
+	Socks5ReplyConnectionRefused    byte = 5
+	Socks5ReplyTTLExpired           byte = 6
+	Socks5ReplyCommandNotSupported  byte = 7
+	Socks5ReplyAddrTypeNotSupported byte = 8
+)
+
 // socks5 authentication options.
 const (
 	Socks5NoAuth           byte = 0
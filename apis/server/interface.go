@@ -0,0 +1,109 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+var (
+	ErrNoServerConfig              = errors.New("no server config")
+	ErrInvalidServerConfig         = errors.New("invalid server config")
+	ErrServerIsRunning             = errors.New("server is running")
+	ErrStoreServerConfigAfterStart = errors.New("can't store server config after start")
+	ErrUserNotFound                = errors.New("user not found")
+)
+
+// Server contains methods supported by a mieru server.
+type Server interface {
+	ServerConfigurationService
+	ServerLifecycleService
+	ServerUserService
+}
+
+// ServerConfigurationService contains methods to manage proxy server configuration.
+type ServerConfigurationService interface {
+	// Load returns a copy of the server config.
+	// It returns ErrNoServerConfig if server config is never stored.
+	Load() (*ServerConfig, error)
+
+	// Store saves a copy of the server config.
+	// It returns wrapped ErrInvalidServerConfig if the provided server config is invalid.
+	Store(*ServerConfig) error
+}
+
+// ServerLifecycleService contains methods to manage proxy server lifecycle.
+type ServerLifecycleService interface {
+	// Start listens to the port bindings of the stored configuration,
+	// and serves the proxy connections in the background.
+	// It returns ErrServerIsRunning if the server is already started.
+	Start() error
+
+	// Stop deactivates the server. The listeners and the connections
+	// from the clients are closed. After stop, the server can be started
+	// again.
+	Stop() error
+
+	// StopContext deactivates the server gracefully. New connections from
+	// the clients are rejected, and established proxy connections can
+	// continue until they are closed or the context is done. It returns
+	// the context error if some proxy connections are closed by force.
+	// After stop, the server can be started again.
+	StopContext(context.Context) error
+
+	// IsRunning returns true if the server has been started
+	// and has not been stopped.
+	IsRunning() bool
+}
+
+// ServerUserService contains methods to manage the users of the server.
+// The methods can be called when the server is running.
+type ServerUserService interface {
+	// Users returns a copy of the users in the server config.
+	// It returns ErrNoServerConfig if server config is never stored.
+	Users() ([]*appctlpb.User, error)
+
+	// PutUser adds the user, or replaces the user with the same name.
+	// If the server is running, the user is accepted by new connections
+	// from the clients. Established connections are not interrupted.
+	// It returns wrapped ErrInvalidServerConfig if the user is invalid.
+	PutUser(*appctlpb.User) error
+
+	// DeleteUser removes the user with the name. If the server is running,
+	// new connections from the user are rejected. Established connections
+	// are not interrupted. It returns ErrUserNotFound if the user doesn't
+	// exist, and wrapped ErrInvalidServerConfig if the user is still used
+	// by other settings, for example a reverse tunnel.
+	DeleteUser(name string) error
+}
+
+// ServerConfig stores proxy server configuration.
+type ServerConfig struct {
+	// Config is the same as the server config of mita. Settings
+	// that only apply to the mita daemon, such as the logging level
+	// and the debug HTTP port, are ignored.
+	Config *appctlpb.ServerConfig
+}
+
+// NewServer creates a blank mieru server with no server config.
+func NewServer() Server {
+	ms := &mitaServer{}
+	ms.initOnce()
+	return ms
+}
@@ -0,0 +1,229 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+	"google.golang.org/protobuf/proto"
+)
+
+// mitaServer is the official implementation of mieru server APIs.
+type mitaServer struct {
+	initTask sync.Once
+	mu       sync.RWMutex
+
+	config *ServerConfig
+	mux    *protocol.Mux
+	socks5 *socks5.Server
+
+	running bool
+}
+
+var _ Server = &mitaServer{}
+
+// initOnce should be called when constructing the mieru server.
+func (ms *mitaServer) initOnce() {
+	ms.initTask.Do(func() {
+		// Disable log.
+		log.SetFormatter(&log.NilFormatter{})
+	})
+}
+
+func (ms *mitaServer) Load() (*ServerConfig, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	if ms.config == nil {
+		return nil, ErrNoServerConfig
+	}
+	return cloneServerConfig(ms.config), nil
+}
+
+func (ms *mitaServer) Store(config *ServerConfig) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if config == nil {
+		return fmt.Errorf("%w: server config is nil", ErrInvalidServerConfig)
+	}
+	if config.Config == nil {
+		return fmt.Errorf("%w: server config is nil", ErrInvalidServerConfig)
+	}
+	if ms.running {
+		return ErrStoreServerConfigAfterStart
+	}
+	if err := appctl.ValidateFullServerConfig(config.Config); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidServerConfig, err.Error())
+	}
+	ms.config = cloneServerConfig(config)
+	return nil
+}
+
+func (ms *mitaServer) Start() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.config == nil {
+		return ErrNoServerConfig
+	}
+	if ms.running {
+		return ErrServerIsRunning
+	}
+
+	mux, err := appctl.NewServerMux(ms.config.Config)
+	if err != nil {
+		return err
+	}
+	socks5Server, err := appctl.NewServerSocks5(ms.config.Config)
+	if err != nil {
+		return fmt.Errorf(stderror.CreateSocks5ServerFailedErr, err)
+	}
+	if err := mux.Start(); err != nil {
+		mux.Close()
+		return err
+	}
+	go func() {
+		if err := socks5Server.Serve(mux); err != nil {
+			log.Errorf("run socks5 server failed: %v", err)
+		}
+	}()
+
+	ms.mux = mux
+	ms.socks5 = socks5Server
+	ms.running = true
+	return nil
+}
+
+func (ms *mitaServer) Stop() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if !ms.running {
+		return nil
+	}
+	ms.running = false
+	ms.socks5.Close()
+	return ms.mux.Close()
+}
+
+func (ms *mitaServer) StopContext(ctx context.Context) error {
+	ms.mu.Lock()
+	if !ms.running {
+		ms.mu.Unlock()
+		return nil
+	}
+	ms.running = false
+	mux := ms.mux
+	socks5Server := ms.socks5
+	ms.mu.Unlock()
+
+	// Don't hold the lock when draining, so IsRunning and other methods
+	// that check the status are not blocked.
+	err := mux.Drain(ctx)
+	socks5Server.Close()
+	mux.Close()
+	return err
+}
+
+func (ms *mitaServer) IsRunning() bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.running
+}
+
+func (ms *mitaServer) Users() ([]*appctlpb.User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	if ms.config == nil {
+		return nil, ErrNoServerConfig
+	}
+	users := make([]*appctlpb.User, 0, len(ms.config.Config.GetUsers()))
+	for _, user := range ms.config.Config.GetUsers() {
+		users = append(users, proto.Clone(user).(*appctlpb.User))
+	}
+	return users, nil
+}
+
+func (ms *mitaServer) PutUser(user *appctlpb.User) error {
+	if user == nil {
+		return fmt.Errorf("%w: user is nil", ErrInvalidServerConfig)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.config == nil {
+		return ErrNoServerConfig
+	}
+	user = proto.Clone(user).(*appctlpb.User)
+	users := make([]*appctlpb.User, 0, len(ms.config.Config.GetUsers())+1)
+	replaced := false
+	for _, u := range ms.config.Config.GetUsers() {
+		if u.GetName() == user.GetName() {
+			users = append(users, user)
+			replaced = true
+		} else {
+			users = append(users, u)
+		}
+	}
+	if !replaced {
+		users = append(users, user)
+	}
+	return ms.setUsers(users)
+}
+
+func (ms *mitaServer) DeleteUser(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.config == nil {
+		return ErrNoServerConfig
+	}
+	users := make([]*appctlpb.User, 0, len(ms.config.Config.GetUsers()))
+	for _, u := range ms.config.Config.GetUsers() {
+		if u.GetName() != name {
+			users = append(users, u)
+		}
+	}
+	if len(users) == len(ms.config.Config.GetUsers()) {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	return ms.setUsers(users)
+}
+
+// setUsers validates the server config with the users, and applies
+// the users to the running server. It must be called with the lock.
+func (ms *mitaServer) setUsers(users []*appctlpb.User) error {
+	config := proto.Clone(ms.config.Config).(*appctlpb.ServerConfig)
+	config.Users = users
+	if err := appctl.ValidateFullServerConfig(config); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidServerConfig, err.Error())
+	}
+	ms.config = &ServerConfig{Config: config}
+	if ms.running {
+		ms.mux.SetServerUsers(appctl.UserListToMap(config.GetUsers()))
+	}
+	return nil
+}
+
+// cloneServerConfig returns a deep copy of the server config.
+func cloneServerConfig(config *ServerConfig) *ServerConfig {
+	c := *config
+	c.Config = proto.Clone(config.Config).(*appctlpb.ServerConfig)
+	return &c
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"os"
 	"runtime/debug"
 
 	"github.com/enfein/mieru/v3/pkg/appctl"
@@ -30,6 +31,7 @@ func main() {
 	cli.RegisterServerCommands()
 	err := cli.ParseAndExecute()
 	if err != nil {
-		log.Fatalf("%v", err)
+		log.Errorf("%v", err)
+		os.Exit(cli.ExitCode(err))
 	}
 }
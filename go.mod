@@ -5,13 +5,13 @@ go 1.20
 require (
 	github.com/google/btree v1.1.3
 	golang.org/x/crypto v0.28.0
+	golang.org/x/net v0.26.0
 	golang.org/x/sys v0.26.0
 	google.golang.org/grpc v1.64.1
 	google.golang.org/protobuf v1.35.1
 )
 
 require (
-	golang.org/x/net v0.26.0 // indirect
 	golang.org/x/text v0.19.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240610135401-a8a62080eff3 // indirect
 )
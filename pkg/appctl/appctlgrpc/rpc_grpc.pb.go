@@ -39,11 +39,16 @@ const (
 	ClientLifecycleService_Exit_FullMethodName                = "/appctl.ClientLifecycleService/Exit"
 	ClientLifecycleService_GetMetrics_FullMethodName          = "/appctl.ClientLifecycleService/GetMetrics"
 	ClientLifecycleService_GetSessionInfo_FullMethodName      = "/appctl.ClientLifecycleService/GetSessionInfo"
+	ClientLifecycleService_GetSessionStates_FullMethodName    = "/appctl.ClientLifecycleService/GetSessionStates"
 	ClientLifecycleService_GetThreadDump_FullMethodName       = "/appctl.ClientLifecycleService/GetThreadDump"
 	ClientLifecycleService_StartCPUProfile_FullMethodName     = "/appctl.ClientLifecycleService/StartCPUProfile"
 	ClientLifecycleService_StopCPUProfile_FullMethodName      = "/appctl.ClientLifecycleService/StopCPUProfile"
 	ClientLifecycleService_GetHeapProfile_FullMethodName      = "/appctl.ClientLifecycleService/GetHeapProfile"
 	ClientLifecycleService_GetMemoryStatistics_FullMethodName = "/appctl.ClientLifecycleService/GetMemoryStatistics"
+	ClientLifecycleService_ReloadDNS_FullMethodName           = "/appctl.ClientLifecycleService/ReloadDNS"
+	ClientLifecycleService_GetDestinationStats_FullMethodName = "/appctl.ClientLifecycleService/GetDestinationStats"
+	ClientLifecycleService_GetAutoRouteStats_FullMethodName   = "/appctl.ClientLifecycleService/GetAutoRouteStats"
+	ClientLifecycleService_GetLogs_FullMethodName             = "/appctl.ClientLifecycleService/GetLogs"
 )
 
 // ClientLifecycleServiceClient is the client API for ClientLifecycleService service.
@@ -58,6 +63,8 @@ type ClientLifecycleServiceClient interface {
 	GetMetrics(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Metrics, error)
 	// Get client session information.
 	GetSessionInfo(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.SessionInfo, error)
+	// Get the internal state of client sessions.
+	GetSessionStates(ctx context.Context, in *appctlpb.SessionStateRequest, opts ...grpc.CallOption) (*appctlpb.SessionStates, error)
 	// Generate a thread dump of client daemon.
 	GetThreadDump(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.ThreadDump, error)
 	// Start CPU profiling.
@@ -68,6 +75,16 @@ type ClientLifecycleServiceClient interface {
 	GetHeapProfile(ctx context.Context, in *appctlpb.ProfileSavePath, opts ...grpc.CallOption) (*appctlpb.Empty, error)
 	// Get memory statistics of client daemon.
 	GetMemoryStatistics(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.MemoryStatistics, error)
+	// Replace the DNS resolver with the DNS settings in client config,
+	// and look up the proxy servers again.
+	ReloadDNS(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Empty, error)
+	// Get the destinations that transfer the most bytes.
+	GetDestinationStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.DestinationStats, error)
+	// Get the number of requests matched by each auto route rule,
+	// and the recent auto route decisions.
+	GetAutoRouteStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.AutoRouteStats, error)
+	// Get the recent log, and optionally the new log as it is written.
+	GetLogs(ctx context.Context, in *appctlpb.LogRequest, opts ...grpc.CallOption) (ClientLifecycleService_GetLogsClient, error)
 }
 
 type clientLifecycleServiceClient struct {
@@ -114,6 +131,15 @@ func (c *clientLifecycleServiceClient) GetSessionInfo(ctx context.Context, in *a
 	return out, nil
 }
 
+func (c *clientLifecycleServiceClient) GetSessionStates(ctx context.Context, in *appctlpb.SessionStateRequest, opts ...grpc.CallOption) (*appctlpb.SessionStates, error) {
+	out := new(appctlpb.SessionStates)
+	err := c.cc.Invoke(ctx, ClientLifecycleService_GetSessionStates_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *clientLifecycleServiceClient) GetThreadDump(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.ThreadDump, error) {
 	out := new(appctlpb.ThreadDump)
 	err := c.cc.Invoke(ctx, ClientLifecycleService_GetThreadDump_FullMethodName, in, out, opts...)
@@ -159,6 +185,65 @@ func (c *clientLifecycleServiceClient) GetMemoryStatistics(ctx context.Context,
 	return out, nil
 }
 
+func (c *clientLifecycleServiceClient) ReloadDNS(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Empty, error) {
+	out := new(appctlpb.Empty)
+	err := c.cc.Invoke(ctx, ClientLifecycleService_ReloadDNS_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientLifecycleServiceClient) GetDestinationStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.DestinationStats, error) {
+	out := new(appctlpb.DestinationStats)
+	err := c.cc.Invoke(ctx, ClientLifecycleService_GetDestinationStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientLifecycleServiceClient) GetAutoRouteStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.AutoRouteStats, error) {
+	out := new(appctlpb.AutoRouteStats)
+	err := c.cc.Invoke(ctx, ClientLifecycleService_GetAutoRouteStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clientLifecycleServiceClient) GetLogs(ctx context.Context, in *appctlpb.LogRequest, opts ...grpc.CallOption) (ClientLifecycleService_GetLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ClientLifecycleService_ServiceDesc.Streams[0], ClientLifecycleService_GetLogs_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &clientLifecycleServiceGetLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ClientLifecycleService_GetLogsClient interface {
+	Recv() (*appctlpb.LogLine, error)
+	grpc.ClientStream
+}
+
+type clientLifecycleServiceGetLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *clientLifecycleServiceGetLogsClient) Recv() (*appctlpb.LogLine, error) {
+	m := new(appctlpb.LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ClientLifecycleServiceServer is the server API for ClientLifecycleService service.
 // All implementations must embed UnimplementedClientLifecycleServiceServer
 // for forward compatibility
@@ -171,6 +256,8 @@ type ClientLifecycleServiceServer interface {
 	GetMetrics(context.Context, *appctlpb.Empty) (*appctlpb.Metrics, error)
 	// Get client session information.
 	GetSessionInfo(context.Context, *appctlpb.Empty) (*appctlpb.SessionInfo, error)
+	// Get the internal state of client sessions.
+	GetSessionStates(context.Context, *appctlpb.SessionStateRequest) (*appctlpb.SessionStates, error)
 	// Generate a thread dump of client daemon.
 	GetThreadDump(context.Context, *appctlpb.Empty) (*appctlpb.ThreadDump, error)
 	// Start CPU profiling.
@@ -181,6 +268,16 @@ type ClientLifecycleServiceServer interface {
 	GetHeapProfile(context.Context, *appctlpb.ProfileSavePath) (*appctlpb.Empty, error)
 	// Get memory statistics of client daemon.
 	GetMemoryStatistics(context.Context, *appctlpb.Empty) (*appctlpb.MemoryStatistics, error)
+	// Replace the DNS resolver with the DNS settings in client config,
+	// and look up the proxy servers again.
+	ReloadDNS(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error)
+	// Get the destinations that transfer the most bytes.
+	GetDestinationStats(context.Context, *appctlpb.Empty) (*appctlpb.DestinationStats, error)
+	// Get the number of requests matched by each auto route rule,
+	// and the recent auto route decisions.
+	GetAutoRouteStats(context.Context, *appctlpb.Empty) (*appctlpb.AutoRouteStats, error)
+	// Get the recent log, and optionally the new log as it is written.
+	GetLogs(*appctlpb.LogRequest, ClientLifecycleService_GetLogsServer) error
 	mustEmbedUnimplementedClientLifecycleServiceServer()
 }
 
@@ -200,6 +297,9 @@ func (UnimplementedClientLifecycleServiceServer) GetMetrics(context.Context, *ap
 func (UnimplementedClientLifecycleServiceServer) GetSessionInfo(context.Context, *appctlpb.Empty) (*appctlpb.SessionInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSessionInfo not implemented")
 }
+func (UnimplementedClientLifecycleServiceServer) GetSessionStates(context.Context, *appctlpb.SessionStateRequest) (*appctlpb.SessionStates, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSessionStates not implemented")
+}
 func (UnimplementedClientLifecycleServiceServer) GetThreadDump(context.Context, *appctlpb.Empty) (*appctlpb.ThreadDump, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetThreadDump not implemented")
 }
@@ -215,6 +315,18 @@ func (UnimplementedClientLifecycleServiceServer) GetHeapProfile(context.Context,
 func (UnimplementedClientLifecycleServiceServer) GetMemoryStatistics(context.Context, *appctlpb.Empty) (*appctlpb.MemoryStatistics, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMemoryStatistics not implemented")
 }
+func (UnimplementedClientLifecycleServiceServer) ReloadDNS(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadDNS not implemented")
+}
+func (UnimplementedClientLifecycleServiceServer) GetDestinationStats(context.Context, *appctlpb.Empty) (*appctlpb.DestinationStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDestinationStats not implemented")
+}
+func (UnimplementedClientLifecycleServiceServer) GetAutoRouteStats(context.Context, *appctlpb.Empty) (*appctlpb.AutoRouteStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAutoRouteStats not implemented")
+}
+func (UnimplementedClientLifecycleServiceServer) GetLogs(*appctlpb.LogRequest, ClientLifecycleService_GetLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
+}
 func (UnimplementedClientLifecycleServiceServer) mustEmbedUnimplementedClientLifecycleServiceServer() {
 }
 
@@ -301,6 +413,24 @@ func _ClientLifecycleService_GetSessionInfo_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClientLifecycleService_GetSessionStates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.SessionStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientLifecycleServiceServer).GetSessionStates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientLifecycleService_GetSessionStates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientLifecycleServiceServer).GetSessionStates(ctx, req.(*appctlpb.SessionStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ClientLifecycleService_GetThreadDump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(appctlpb.Empty)
 	if err := dec(in); err != nil {
@@ -391,6 +521,81 @@ func _ClientLifecycleService_GetMemoryStatistics_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClientLifecycleService_ReloadDNS_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientLifecycleServiceServer).ReloadDNS(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientLifecycleService_ReloadDNS_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientLifecycleServiceServer).ReloadDNS(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientLifecycleService_GetDestinationStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientLifecycleServiceServer).GetDestinationStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientLifecycleService_GetDestinationStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientLifecycleServiceServer).GetDestinationStats(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientLifecycleService_GetAutoRouteStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientLifecycleServiceServer).GetAutoRouteStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientLifecycleService_GetAutoRouteStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientLifecycleServiceServer).GetAutoRouteStats(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClientLifecycleService_GetLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(appctlpb.LogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClientLifecycleServiceServer).GetLogs(m, &clientLifecycleServiceGetLogsServer{stream})
+}
+
+type ClientLifecycleService_GetLogsServer interface {
+	Send(*appctlpb.LogLine) error
+	grpc.ServerStream
+}
+
+type clientLifecycleServiceGetLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *clientLifecycleServiceGetLogsServer) Send(m *appctlpb.LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // ClientLifecycleService_ServiceDesc is the grpc.ServiceDesc for ClientLifecycleService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -414,6 +619,10 @@ var ClientLifecycleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetSessionInfo",
 			Handler:    _ClientLifecycleService_GetSessionInfo_Handler,
 		},
+		{
+			MethodName: "GetSessionStates",
+			Handler:    _ClientLifecycleService_GetSessionStates_Handler,
+		},
 		{
 			MethodName: "GetThreadDump",
 			Handler:    _ClientLifecycleService_GetThreadDump_Handler,
@@ -434,24 +643,50 @@ var ClientLifecycleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMemoryStatistics",
 			Handler:    _ClientLifecycleService_GetMemoryStatistics_Handler,
 		},
+		{
+			MethodName: "ReloadDNS",
+			Handler:    _ClientLifecycleService_ReloadDNS_Handler,
+		},
+		{
+			MethodName: "GetDestinationStats",
+			Handler:    _ClientLifecycleService_GetDestinationStats_Handler,
+		},
+		{
+			MethodName: "GetAutoRouteStats",
+			Handler:    _ClientLifecycleService_GetAutoRouteStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetLogs",
+			Handler:       _ClientLifecycleService_GetLogs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
 
 const (
-	ServerLifecycleService_GetStatus_FullMethodName           = "/appctl.ServerLifecycleService/GetStatus"
-	ServerLifecycleService_Start_FullMethodName               = "/appctl.ServerLifecycleService/Start"
-	ServerLifecycleService_Stop_FullMethodName                = "/appctl.ServerLifecycleService/Stop"
-	ServerLifecycleService_Reload_FullMethodName              = "/appctl.ServerLifecycleService/Reload"
-	ServerLifecycleService_Exit_FullMethodName                = "/appctl.ServerLifecycleService/Exit"
-	ServerLifecycleService_GetMetrics_FullMethodName          = "/appctl.ServerLifecycleService/GetMetrics"
-	ServerLifecycleService_GetSessionInfo_FullMethodName      = "/appctl.ServerLifecycleService/GetSessionInfo"
-	ServerLifecycleService_GetThreadDump_FullMethodName       = "/appctl.ServerLifecycleService/GetThreadDump"
-	ServerLifecycleService_StartCPUProfile_FullMethodName     = "/appctl.ServerLifecycleService/StartCPUProfile"
-	ServerLifecycleService_StopCPUProfile_FullMethodName      = "/appctl.ServerLifecycleService/StopCPUProfile"
-	ServerLifecycleService_GetHeapProfile_FullMethodName      = "/appctl.ServerLifecycleService/GetHeapProfile"
-	ServerLifecycleService_GetMemoryStatistics_FullMethodName = "/appctl.ServerLifecycleService/GetMemoryStatistics"
+	ServerLifecycleService_GetStatus_FullMethodName               = "/appctl.ServerLifecycleService/GetStatus"
+	ServerLifecycleService_Start_FullMethodName                   = "/appctl.ServerLifecycleService/Start"
+	ServerLifecycleService_Stop_FullMethodName                    = "/appctl.ServerLifecycleService/Stop"
+	ServerLifecycleService_Drain_FullMethodName                   = "/appctl.ServerLifecycleService/Drain"
+	ServerLifecycleService_Reload_FullMethodName                  = "/appctl.ServerLifecycleService/Reload"
+	ServerLifecycleService_Exit_FullMethodName                    = "/appctl.ServerLifecycleService/Exit"
+	ServerLifecycleService_GetMetrics_FullMethodName              = "/appctl.ServerLifecycleService/GetMetrics"
+	ServerLifecycleService_GetSessionInfo_FullMethodName          = "/appctl.ServerLifecycleService/GetSessionInfo"
+	ServerLifecycleService_GetSessionStates_FullMethodName        = "/appctl.ServerLifecycleService/GetSessionStates"
+	ServerLifecycleService_GetThreadDump_FullMethodName           = "/appctl.ServerLifecycleService/GetThreadDump"
+	ServerLifecycleService_StartCPUProfile_FullMethodName         = "/appctl.ServerLifecycleService/StartCPUProfile"
+	ServerLifecycleService_StopCPUProfile_FullMethodName          = "/appctl.ServerLifecycleService/StopCPUProfile"
+	ServerLifecycleService_GetHeapProfile_FullMethodName          = "/appctl.ServerLifecycleService/GetHeapProfile"
+	ServerLifecycleService_GetMemoryStatistics_FullMethodName     = "/appctl.ServerLifecycleService/GetMemoryStatistics"
+	ServerLifecycleService_GetRouteStats_FullMethodName           = "/appctl.ServerLifecycleService/GetRouteStats"
+	ServerLifecycleService_GetUserStats_FullMethodName            = "/appctl.ServerLifecycleService/GetUserStats"
+	ServerLifecycleService_GetUserDestinationStats_FullMethodName = "/appctl.ServerLifecycleService/GetUserDestinationStats"
+	ServerLifecycleService_GetUsers_FullMethodName                = "/appctl.ServerLifecycleService/GetUsers"
+	ServerLifecycleService_GetTraffic_FullMethodName              = "/appctl.ServerLifecycleService/GetTraffic"
+	ServerLifecycleService_GetLogs_FullMethodName                 = "/appctl.ServerLifecycleService/GetLogs"
 )
 
 // ServerLifecycleServiceClient is the client API for ServerLifecycleService service.
@@ -464,6 +699,9 @@ type ServerLifecycleServiceClient interface {
 	Start(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Empty, error)
 	// Stop proxy in server application.
 	Stop(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Empty, error)
+	// Stop accepting new connections, wait for existing connections
+	// to finish until the timeout, then stop proxy in server application.
+	Drain(ctx context.Context, in *appctlpb.DrainRequest, opts ...grpc.CallOption) (*appctlpb.Empty, error)
 	// Reload server configuration.
 	Reload(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Empty, error)
 	// Quit server daemon.
@@ -472,6 +710,8 @@ type ServerLifecycleServiceClient interface {
 	GetMetrics(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Metrics, error)
 	// Get server session information.
 	GetSessionInfo(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.SessionInfo, error)
+	// Get the internal state of server sessions.
+	GetSessionStates(ctx context.Context, in *appctlpb.SessionStateRequest, opts ...grpc.CallOption) (*appctlpb.SessionStates, error)
 	// Generate a thread dump of server daemon.
 	GetThreadDump(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.ThreadDump, error)
 	// Start CPU profiling.
@@ -482,6 +722,18 @@ type ServerLifecycleServiceClient interface {
 	GetHeapProfile(ctx context.Context, in *appctlpb.ProfileSavePath, opts ...grpc.CallOption) (*appctlpb.Empty, error)
 	// Get memory statistics of server daemon.
 	GetMemoryStatistics(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.MemoryStatistics, error)
+	// Get the number of requests matched by each egress rule.
+	GetRouteStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.RouteStats, error)
+	// Get the traffic of each user.
+	GetUserStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.UserStats, error)
+	// Get the destinations of a user that transfer the most bytes.
+	GetUserDestinationStats(ctx context.Context, in *appctlpb.UserDestinationStatsRequest, opts ...grpc.CallOption) (*appctlpb.DestinationStats, error)
+	// Get the traffic, quota usage and speed limit of each configured user.
+	GetUsers(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.UserUsages, error)
+	// Get the bytes and segments transferred by each user and each active session.
+	GetTraffic(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Traffic, error)
+	// Get the recent log, and optionally the new log as it is written.
+	GetLogs(ctx context.Context, in *appctlpb.LogRequest, opts ...grpc.CallOption) (ServerLifecycleService_GetLogsClient, error)
 }
 
 type serverLifecycleServiceClient struct {
@@ -519,6 +771,15 @@ func (c *serverLifecycleServiceClient) Stop(ctx context.Context, in *appctlpb.Em
 	return out, nil
 }
 
+func (c *serverLifecycleServiceClient) Drain(ctx context.Context, in *appctlpb.DrainRequest, opts ...grpc.CallOption) (*appctlpb.Empty, error) {
+	out := new(appctlpb.Empty)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_Drain_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *serverLifecycleServiceClient) Reload(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Empty, error) {
 	out := new(appctlpb.Empty)
 	err := c.cc.Invoke(ctx, ServerLifecycleService_Reload_FullMethodName, in, out, opts...)
@@ -555,6 +816,15 @@ func (c *serverLifecycleServiceClient) GetSessionInfo(ctx context.Context, in *a
 	return out, nil
 }
 
+func (c *serverLifecycleServiceClient) GetSessionStates(ctx context.Context, in *appctlpb.SessionStateRequest, opts ...grpc.CallOption) (*appctlpb.SessionStates, error) {
+	out := new(appctlpb.SessionStates)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_GetSessionStates_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *serverLifecycleServiceClient) GetThreadDump(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.ThreadDump, error) {
 	out := new(appctlpb.ThreadDump)
 	err := c.cc.Invoke(ctx, ServerLifecycleService_GetThreadDump_FullMethodName, in, out, opts...)
@@ -600,6 +870,83 @@ func (c *serverLifecycleServiceClient) GetMemoryStatistics(ctx context.Context,
 	return out, nil
 }
 
+func (c *serverLifecycleServiceClient) GetRouteStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.RouteStats, error) {
+	out := new(appctlpb.RouteStats)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_GetRouteStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverLifecycleServiceClient) GetUserStats(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.UserStats, error) {
+	out := new(appctlpb.UserStats)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_GetUserStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverLifecycleServiceClient) GetUserDestinationStats(ctx context.Context, in *appctlpb.UserDestinationStatsRequest, opts ...grpc.CallOption) (*appctlpb.DestinationStats, error) {
+	out := new(appctlpb.DestinationStats)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_GetUserDestinationStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverLifecycleServiceClient) GetUsers(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.UserUsages, error) {
+	out := new(appctlpb.UserUsages)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_GetUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverLifecycleServiceClient) GetTraffic(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.Traffic, error) {
+	out := new(appctlpb.Traffic)
+	err := c.cc.Invoke(ctx, ServerLifecycleService_GetTraffic_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverLifecycleServiceClient) GetLogs(ctx context.Context, in *appctlpb.LogRequest, opts ...grpc.CallOption) (ServerLifecycleService_GetLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ServerLifecycleService_ServiceDesc.Streams[0], ServerLifecycleService_GetLogs_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &serverLifecycleServiceGetLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ServerLifecycleService_GetLogsClient interface {
+	Recv() (*appctlpb.LogLine, error)
+	grpc.ClientStream
+}
+
+type serverLifecycleServiceGetLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *serverLifecycleServiceGetLogsClient) Recv() (*appctlpb.LogLine, error) {
+	m := new(appctlpb.LogLine)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ServerLifecycleServiceServer is the server API for ServerLifecycleService service.
 // All implementations must embed UnimplementedServerLifecycleServiceServer
 // for forward compatibility
@@ -610,6 +957,9 @@ type ServerLifecycleServiceServer interface {
 	Start(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error)
 	// Stop proxy in server application.
 	Stop(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error)
+	// Stop accepting new connections, wait for existing connections
+	// to finish until the timeout, then stop proxy in server application.
+	Drain(context.Context, *appctlpb.DrainRequest) (*appctlpb.Empty, error)
 	// Reload server configuration.
 	Reload(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error)
 	// Quit server daemon.
@@ -618,6 +968,8 @@ type ServerLifecycleServiceServer interface {
 	GetMetrics(context.Context, *appctlpb.Empty) (*appctlpb.Metrics, error)
 	// Get server session information.
 	GetSessionInfo(context.Context, *appctlpb.Empty) (*appctlpb.SessionInfo, error)
+	// Get the internal state of server sessions.
+	GetSessionStates(context.Context, *appctlpb.SessionStateRequest) (*appctlpb.SessionStates, error)
 	// Generate a thread dump of server daemon.
 	GetThreadDump(context.Context, *appctlpb.Empty) (*appctlpb.ThreadDump, error)
 	// Start CPU profiling.
@@ -628,6 +980,18 @@ type ServerLifecycleServiceServer interface {
 	GetHeapProfile(context.Context, *appctlpb.ProfileSavePath) (*appctlpb.Empty, error)
 	// Get memory statistics of server daemon.
 	GetMemoryStatistics(context.Context, *appctlpb.Empty) (*appctlpb.MemoryStatistics, error)
+	// Get the number of requests matched by each egress rule.
+	GetRouteStats(context.Context, *appctlpb.Empty) (*appctlpb.RouteStats, error)
+	// Get the traffic of each user.
+	GetUserStats(context.Context, *appctlpb.Empty) (*appctlpb.UserStats, error)
+	// Get the destinations of a user that transfer the most bytes.
+	GetUserDestinationStats(context.Context, *appctlpb.UserDestinationStatsRequest) (*appctlpb.DestinationStats, error)
+	// Get the traffic, quota usage and speed limit of each configured user.
+	GetUsers(context.Context, *appctlpb.Empty) (*appctlpb.UserUsages, error)
+	// Get the bytes and segments transferred by each user and each active session.
+	GetTraffic(context.Context, *appctlpb.Empty) (*appctlpb.Traffic, error)
+	// Get the recent log, and optionally the new log as it is written.
+	GetLogs(*appctlpb.LogRequest, ServerLifecycleService_GetLogsServer) error
 	mustEmbedUnimplementedServerLifecycleServiceServer()
 }
 
@@ -644,6 +1008,9 @@ func (UnimplementedServerLifecycleServiceServer) Start(context.Context, *appctlp
 func (UnimplementedServerLifecycleServiceServer) Stop(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
 }
+func (UnimplementedServerLifecycleServiceServer) Drain(context.Context, *appctlpb.DrainRequest) (*appctlpb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drain not implemented")
+}
 func (UnimplementedServerLifecycleServiceServer) Reload(context.Context, *appctlpb.Empty) (*appctlpb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Reload not implemented")
 }
@@ -656,6 +1023,9 @@ func (UnimplementedServerLifecycleServiceServer) GetMetrics(context.Context, *ap
 func (UnimplementedServerLifecycleServiceServer) GetSessionInfo(context.Context, *appctlpb.Empty) (*appctlpb.SessionInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSessionInfo not implemented")
 }
+func (UnimplementedServerLifecycleServiceServer) GetSessionStates(context.Context, *appctlpb.SessionStateRequest) (*appctlpb.SessionStates, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSessionStates not implemented")
+}
 func (UnimplementedServerLifecycleServiceServer) GetThreadDump(context.Context, *appctlpb.Empty) (*appctlpb.ThreadDump, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetThreadDump not implemented")
 }
@@ -671,6 +1041,24 @@ func (UnimplementedServerLifecycleServiceServer) GetHeapProfile(context.Context,
 func (UnimplementedServerLifecycleServiceServer) GetMemoryStatistics(context.Context, *appctlpb.Empty) (*appctlpb.MemoryStatistics, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMemoryStatistics not implemented")
 }
+func (UnimplementedServerLifecycleServiceServer) GetRouteStats(context.Context, *appctlpb.Empty) (*appctlpb.RouteStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRouteStats not implemented")
+}
+func (UnimplementedServerLifecycleServiceServer) GetUserStats(context.Context, *appctlpb.Empty) (*appctlpb.UserStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserStats not implemented")
+}
+func (UnimplementedServerLifecycleServiceServer) GetUserDestinationStats(context.Context, *appctlpb.UserDestinationStatsRequest) (*appctlpb.DestinationStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserDestinationStats not implemented")
+}
+func (UnimplementedServerLifecycleServiceServer) GetUsers(context.Context, *appctlpb.Empty) (*appctlpb.UserUsages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsers not implemented")
+}
+func (UnimplementedServerLifecycleServiceServer) GetTraffic(context.Context, *appctlpb.Empty) (*appctlpb.Traffic, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTraffic not implemented")
+}
+func (UnimplementedServerLifecycleServiceServer) GetLogs(*appctlpb.LogRequest, ServerLifecycleService_GetLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
+}
 func (UnimplementedServerLifecycleServiceServer) mustEmbedUnimplementedServerLifecycleServiceServer() {
 }
 
@@ -739,6 +1127,24 @@ func _ServerLifecycleService_Stop_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ServerLifecycleService_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_Drain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).Drain(ctx, req.(*appctlpb.DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ServerLifecycleService_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(appctlpb.Empty)
 	if err := dec(in); err != nil {
@@ -811,6 +1217,24 @@ func _ServerLifecycleService_GetSessionInfo_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ServerLifecycleService_GetSessionStates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.SessionStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).GetSessionStates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_GetSessionStates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).GetSessionStates(ctx, req.(*appctlpb.SessionStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ServerLifecycleService_GetThreadDump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(appctlpb.Empty)
 	if err := dec(in); err != nil {
@@ -901,6 +1325,117 @@ func _ServerLifecycleService_GetMemoryStatistics_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ServerLifecycleService_GetRouteStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).GetRouteStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_GetRouteStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).GetRouteStats(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerLifecycleService_GetUserStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).GetUserStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_GetUserStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).GetUserStats(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerLifecycleService_GetUserDestinationStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.UserDestinationStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).GetUserDestinationStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_GetUserDestinationStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).GetUserDestinationStats(ctx, req.(*appctlpb.UserDestinationStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerLifecycleService_GetUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).GetUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_GetUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).GetUsers(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerLifecycleService_GetTraffic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerLifecycleServiceServer).GetTraffic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerLifecycleService_GetTraffic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerLifecycleServiceServer).GetTraffic(ctx, req.(*appctlpb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerLifecycleService_GetLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(appctlpb.LogRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ServerLifecycleServiceServer).GetLogs(m, &serverLifecycleServiceGetLogsServer{stream})
+}
+
+type ServerLifecycleService_GetLogsServer interface {
+	Send(*appctlpb.LogLine) error
+	grpc.ServerStream
+}
+
+type serverLifecycleServiceGetLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *serverLifecycleServiceGetLogsServer) Send(m *appctlpb.LogLine) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // ServerLifecycleService_ServiceDesc is the grpc.ServiceDesc for ServerLifecycleService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -920,6 +1455,10 @@ var ServerLifecycleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stop",
 			Handler:    _ServerLifecycleService_Stop_Handler,
 		},
+		{
+			MethodName: "Drain",
+			Handler:    _ServerLifecycleService_Drain_Handler,
+		},
 		{
 			MethodName: "Reload",
 			Handler:    _ServerLifecycleService_Reload_Handler,
@@ -936,6 +1475,10 @@ var ServerLifecycleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetSessionInfo",
 			Handler:    _ServerLifecycleService_GetSessionInfo_Handler,
 		},
+		{
+			MethodName: "GetSessionStates",
+			Handler:    _ServerLifecycleService_GetSessionStates_Handler,
+		},
 		{
 			MethodName: "GetThreadDump",
 			Handler:    _ServerLifecycleService_GetThreadDump_Handler,
@@ -956,14 +1499,43 @@ var ServerLifecycleService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMemoryStatistics",
 			Handler:    _ServerLifecycleService_GetMemoryStatistics_Handler,
 		},
+		{
+			MethodName: "GetRouteStats",
+			Handler:    _ServerLifecycleService_GetRouteStats_Handler,
+		},
+		{
+			MethodName: "GetUserStats",
+			Handler:    _ServerLifecycleService_GetUserStats_Handler,
+		},
+		{
+			MethodName: "GetUserDestinationStats",
+			Handler:    _ServerLifecycleService_GetUserDestinationStats_Handler,
+		},
+		{
+			MethodName: "GetUsers",
+			Handler:    _ServerLifecycleService_GetUsers_Handler,
+		},
+		{
+			MethodName: "GetTraffic",
+			Handler:    _ServerLifecycleService_GetTraffic_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetLogs",
+			Handler:       _ServerLifecycleService_GetLogs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
 }
 
 const (
-	ServerConfigService_GetConfig_FullMethodName = "/appctl.ServerConfigService/GetConfig"
-	ServerConfigService_SetConfig_FullMethodName = "/appctl.ServerConfigService/SetConfig"
+	ServerConfigService_GetConfig_FullMethodName  = "/appctl.ServerConfigService/GetConfig"
+	ServerConfigService_SetConfig_FullMethodName  = "/appctl.ServerConfigService/SetConfig"
+	ServerConfigService_AddUser_FullMethodName    = "/appctl.ServerConfigService/AddUser"
+	ServerConfigService_UpdateUser_FullMethodName = "/appctl.ServerConfigService/UpdateUser"
+	ServerConfigService_RemoveUser_FullMethodName = "/appctl.ServerConfigService/RemoveUser"
 )
 
 // ServerConfigServiceClient is the client API for ServerConfigService service.
@@ -974,6 +1546,14 @@ type ServerConfigServiceClient interface {
 	GetConfig(ctx context.Context, in *appctlpb.Empty, opts ...grpc.CallOption) (*appctlpb.ServerConfig, error)
 	// Update server config.
 	SetConfig(ctx context.Context, in *appctlpb.ServerConfig, opts ...grpc.CallOption) (*appctlpb.ServerConfig, error)
+	// Add a new user. The running proxy accepts the user immediately.
+	AddUser(ctx context.Context, in *appctlpb.User, opts ...grpc.CallOption) (*appctlpb.Empty, error)
+	// Replace the user with the same name. The running proxy closes the
+	// connections of the user if it is disabled or the password is changed.
+	UpdateUser(ctx context.Context, in *appctlpb.User, opts ...grpc.CallOption) (*appctlpb.Empty, error)
+	// Remove the user with the name. The running proxy closes the
+	// connections of the user.
+	RemoveUser(ctx context.Context, in *appctlpb.User, opts ...grpc.CallOption) (*appctlpb.Empty, error)
 }
 
 type serverConfigServiceClient struct {
@@ -1002,6 +1582,33 @@ func (c *serverConfigServiceClient) SetConfig(ctx context.Context, in *appctlpb.
 	return out, nil
 }
 
+func (c *serverConfigServiceClient) AddUser(ctx context.Context, in *appctlpb.User, opts ...grpc.CallOption) (*appctlpb.Empty, error) {
+	out := new(appctlpb.Empty)
+	err := c.cc.Invoke(ctx, ServerConfigService_AddUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverConfigServiceClient) UpdateUser(ctx context.Context, in *appctlpb.User, opts ...grpc.CallOption) (*appctlpb.Empty, error) {
+	out := new(appctlpb.Empty)
+	err := c.cc.Invoke(ctx, ServerConfigService_UpdateUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverConfigServiceClient) RemoveUser(ctx context.Context, in *appctlpb.User, opts ...grpc.CallOption) (*appctlpb.Empty, error) {
+	out := new(appctlpb.Empty)
+	err := c.cc.Invoke(ctx, ServerConfigService_RemoveUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ServerConfigServiceServer is the server API for ServerConfigService service.
 // All implementations must embed UnimplementedServerConfigServiceServer
 // for forward compatibility
@@ -1010,6 +1617,14 @@ type ServerConfigServiceServer interface {
 	GetConfig(context.Context, *appctlpb.Empty) (*appctlpb.ServerConfig, error)
 	// Update server config.
 	SetConfig(context.Context, *appctlpb.ServerConfig) (*appctlpb.ServerConfig, error)
+	// Add a new user. The running proxy accepts the user immediately.
+	AddUser(context.Context, *appctlpb.User) (*appctlpb.Empty, error)
+	// Replace the user with the same name. The running proxy closes the
+	// connections of the user if it is disabled or the password is changed.
+	UpdateUser(context.Context, *appctlpb.User) (*appctlpb.Empty, error)
+	// Remove the user with the name. The running proxy closes the
+	// connections of the user.
+	RemoveUser(context.Context, *appctlpb.User) (*appctlpb.Empty, error)
 	mustEmbedUnimplementedServerConfigServiceServer()
 }
 
@@ -1023,6 +1638,15 @@ func (UnimplementedServerConfigServiceServer) GetConfig(context.Context, *appctl
 func (UnimplementedServerConfigServiceServer) SetConfig(context.Context, *appctlpb.ServerConfig) (*appctlpb.ServerConfig, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
 }
+func (UnimplementedServerConfigServiceServer) AddUser(context.Context, *appctlpb.User) (*appctlpb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddUser not implemented")
+}
+func (UnimplementedServerConfigServiceServer) UpdateUser(context.Context, *appctlpb.User) (*appctlpb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedServerConfigServiceServer) RemoveUser(context.Context, *appctlpb.User) (*appctlpb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveUser not implemented")
+}
 func (UnimplementedServerConfigServiceServer) mustEmbedUnimplementedServerConfigServiceServer() {}
 
 // UnsafeServerConfigServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -1072,6 +1696,60 @@ func _ServerConfigService_SetConfig_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ServerConfigService_AddUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerConfigServiceServer).AddUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerConfigService_AddUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerConfigServiceServer).AddUser(ctx, req.(*appctlpb.User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerConfigService_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerConfigServiceServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerConfigService_UpdateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerConfigServiceServer).UpdateUser(ctx, req.(*appctlpb.User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServerConfigService_RemoveUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(appctlpb.User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerConfigServiceServer).RemoveUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ServerConfigService_RemoveUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerConfigServiceServer).RemoveUser(ctx, req.(*appctlpb.User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ServerConfigService_ServiceDesc is the grpc.ServiceDesc for ServerConfigService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1087,6 +1765,18 @@ var ServerConfigService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetConfig",
 			Handler:    _ServerConfigService_SetConfig_Handler,
 		},
+		{
+			MethodName: "AddUser",
+			Handler:    _ServerConfigService_AddUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _ServerConfigService_UpdateUser_Handler,
+		},
+		{
+			MethodName: "RemoveUser",
+			Handler:    _ServerConfigService_RemoveUser_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
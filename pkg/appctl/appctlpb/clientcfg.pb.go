@@ -90,6 +90,127 @@ func (MultiplexingLevel) EnumDescriptor() ([]byte, []int) {
 	return file_clientcfg_proto_rawDescGZIP(), []int{0}
 }
 
+type EndpointPolicy int32
+
+const (
+	// Select an endpoint randomly.
+	EndpointPolicy_ENDPOINT_RANDOM EndpointPolicy = 0
+	// Select the endpoints one after another.
+	EndpointPolicy_ENDPOINT_ROUND_ROBIN EndpointPolicy = 1
+	// Select the endpoint with the lowest handshake latency.
+	EndpointPolicy_ENDPOINT_LOWEST_LATENCY EndpointPolicy = 2
+	// Keep using the same endpoint until it fails,
+	// then move to the next endpoint in the configured order.
+	EndpointPolicy_ENDPOINT_STICKY EndpointPolicy = 3
+)
+
+// Enum value maps for EndpointPolicy.
+var (
+	EndpointPolicy_name = map[int32]string{
+		0: "ENDPOINT_RANDOM",
+		1: "ENDPOINT_ROUND_ROBIN",
+		2: "ENDPOINT_LOWEST_LATENCY",
+		3: "ENDPOINT_STICKY",
+	}
+	EndpointPolicy_value = map[string]int32{
+		"ENDPOINT_RANDOM":         0,
+		"ENDPOINT_ROUND_ROBIN":    1,
+		"ENDPOINT_LOWEST_LATENCY": 2,
+		"ENDPOINT_STICKY":         3,
+	}
+)
+
+func (x EndpointPolicy) Enum() *EndpointPolicy {
+	p := new(EndpointPolicy)
+	*p = x
+	return p
+}
+
+func (x EndpointPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EndpointPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_clientcfg_proto_enumTypes[1].Descriptor()
+}
+
+func (EndpointPolicy) Type() protoreflect.EnumType {
+	return &file_clientcfg_proto_enumTypes[1]
+}
+
+func (x EndpointPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EndpointPolicy.Descriptor instead.
+func (EndpointPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{1}
+}
+
+type ProfilePreset int32
+
+const (
+	// Don't use a preset.
+	ProfilePreset_NO_PRESET ProfilePreset = 0
+	// Moderate multiplexing, and prefer the fastest server endpoint.
+	ProfilePreset_BALANCED ProfilePreset = 1
+	// Open more connections in advance, send forward error correction
+	// segments, and prefer the fastest server endpoint.
+	ProfilePreset_LOW_LATENCY ProfilePreset = 2
+	// Reuse connections as much as possible, and use small flow control
+	// windows to reduce bursts.
+	ProfilePreset_LOW_BANDWIDTH ProfilePreset = 3
+	// Rotate connections and keys frequently, use hybrid key exchange
+	// if it is supported, and select server endpoints randomly.
+	// The server must support hybrid key exchange and rekey.
+	ProfilePreset_PARANOID ProfilePreset = 4
+)
+
+// Enum value maps for ProfilePreset.
+var (
+	ProfilePreset_name = map[int32]string{
+		0: "NO_PRESET",
+		1: "BALANCED",
+		2: "LOW_LATENCY",
+		3: "LOW_BANDWIDTH",
+		4: "PARANOID",
+	}
+	ProfilePreset_value = map[string]int32{
+		"NO_PRESET":     0,
+		"BALANCED":      1,
+		"LOW_LATENCY":   2,
+		"LOW_BANDWIDTH": 3,
+		"PARANOID":      4,
+	}
+)
+
+func (x ProfilePreset) Enum() *ProfilePreset {
+	p := new(ProfilePreset)
+	*p = x
+	return p
+}
+
+func (x ProfilePreset) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProfilePreset) Descriptor() protoreflect.EnumDescriptor {
+	return file_clientcfg_proto_enumTypes[2].Descriptor()
+}
+
+func (ProfilePreset) Type() protoreflect.EnumType {
+	return &file_clientcfg_proto_enumTypes[2]
+}
+
+func (x ProfilePreset) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProfilePreset.Descriptor instead.
+func (ProfilePreset) EnumDescriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{2}
+}
+
 type ClientConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -116,6 +237,50 @@ type ClientConfig struct {
 	// A list of accounts that can authenticate mieru socks5 proxy service.
 	// If the list is empty, authentication is not required.
 	Socks5Authentication []*Auth `protobuf:"bytes,10,rep,name=socks5Authentication,proto3" json:"socks5Authentication,omitempty"`
+	// A list of static port forwards. Traffic received from a local port
+	// is forwarded to a fixed remote destination through the proxy.
+	PortForwards []*PortForward `protobuf:"bytes,11,rep,name=portForwards,proto3" json:"portForwards,omitempty"`
+	// A list of client side services exposed on proxy server ports.
+	// The proxy server must allow the user to expose those ports.
+	ReverseForwards []*ReverseForward `protobuf:"bytes,12,rep,name=reverseForwards,proto3" json:"reverseForwards,omitempty"`
+	// A list of time windows that use a different profile or disable
+	// the proxy. They are evaluated by the running client in local time.
+	// The first matching schedule wins. If no schedule matches,
+	// the active profile is used.
+	ProfileSchedules []*ProfileSchedule `protobuf:"bytes,13,rep,name=profileSchedules,proto3" json:"profileSchedules,omitempty"`
+	// Close a proxy connection after it transfers more bytes than the cap.
+	// This setting applies to socks5 and HTTP proxy connections,
+	// but not to socks5 UDP associate.
+	TransferCap *TransferCap `protobuf:"bytes,14,opt,name=transferCap,proto3,oneof" json:"transferCap,omitempty"`
+	// DNS servers to look up the domain names of proxy servers.
+	// If it is not set, the system DNS resolver is used.
+	// Run "mieru reload dns" to apply the change to a running client.
+	Dns *DNSConfig `protobuf:"bytes,15,opt,name=dns,proto3,oneof" json:"dns,omitempty"`
+	// Connect to destinations directly when it is possible, and only use
+	// the proxy when the direct connection fails or is slow.
+	// This setting applies to socks5 and HTTP proxy connections,
+	// but not to socks5 UDP associate.
+	AutoRoute *AutoRouteConfig `protobuf:"bytes,16,opt,name=autoRoute,proto3,oneof" json:"autoRoute,omitempty"`
+	// Aggregate the traffic of proxy connections by destination domain
+	// name or IP address. Run "mieru get destinations" to show the
+	// destinations that transfer the most bytes. The statistics are
+	// kept in memory and are lost after the client is stopped.
+	// This setting doesn't apply to socks5 UDP associate.
+	DestinationStats *bool `protobuf:"varint,17,opt,name=destinationStats,proto3,oneof" json:"destinationStats,omitempty"`
+	// The port mieru is listening in localhost to serve debug HTTP
+	// endpoints. The metrics are available at "/debug/vars" in expvar
+	// format. If it is not set, the debug HTTP server is disabled.
+	DebugHttpPort *int32 `protobuf:"varint,18,opt,name=debugHttpPort,proto3,oneof" json:"debugHttpPort,omitempty"`
+	// Client log format.
+	LogFormat *LogFormat `protobuf:"varint,19,opt,name=logFormat,proto3,enum=appctl.LogFormat,oneof" json:"logFormat,omitempty"`
+	// The port mieru is listening in localhost to serve the management
+	// API in HTTP and JSON. It has the status, config, metrics and
+	// connections of the client. If it is not set, the management API
+	// is only available from RPC.
+	ManagementHttpPort *int32 `protobuf:"varint,20,opt,name=managementHttpPort,proto3,oneof" json:"managementHttpPort,omitempty"`
+	// Export a trace span of each proxy connection to OpenTelemetry.
+	// If it is not set, tracing is disabled.
+	Tracing *TracingConfig `protobuf:"bytes,21,opt,name=tracing,proto3,oneof" json:"tracing,omitempty"`
 }
 
 func (x *ClientConfig) Reset() {
@@ -220,26 +385,105 @@ func (x *ClientConfig) GetSocks5Authentication() []*Auth {
 	return nil
 }
 
-type ClientProfile struct {
+func (x *ClientConfig) GetPortForwards() []*PortForward {
+	if x != nil {
+		return x.PortForwards
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetReverseForwards() []*ReverseForward {
+	if x != nil {
+		return x.ReverseForwards
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetProfileSchedules() []*ProfileSchedule {
+	if x != nil {
+		return x.ProfileSchedules
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetTransferCap() *TransferCap {
+	if x != nil {
+		return x.TransferCap
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetDns() *DNSConfig {
+	if x != nil {
+		return x.Dns
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetAutoRoute() *AutoRouteConfig {
+	if x != nil {
+		return x.AutoRoute
+	}
+	return nil
+}
+
+func (x *ClientConfig) GetDestinationStats() bool {
+	if x != nil && x.DestinationStats != nil {
+		return *x.DestinationStats
+	}
+	return false
+}
+
+func (x *ClientConfig) GetDebugHttpPort() int32 {
+	if x != nil && x.DebugHttpPort != nil {
+		return *x.DebugHttpPort
+	}
+	return 0
+}
+
+func (x *ClientConfig) GetLogFormat() LogFormat {
+	if x != nil && x.LogFormat != nil {
+		return *x.LogFormat
+	}
+	return LogFormat_DEFAULT_LOG_FORMAT
+}
+
+func (x *ClientConfig) GetManagementHttpPort() int32 {
+	if x != nil && x.ManagementHttpPort != nil {
+		return *x.ManagementHttpPort
+	}
+	return 0
+}
+
+func (x *ClientConfig) GetTracing() *TracingConfig {
+	if x != nil {
+		return x.Tracing
+	}
+	return nil
+}
+
+type AutoRouteConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Client profile name.
-	ProfileName *string `protobuf:"bytes,1,opt,name=profileName,proto3,oneof" json:"profileName,omitempty"`
-	// User information.
-	User *User `protobuf:"bytes,2,opt,name=user,proto3,oneof" json:"user,omitempty"`
-	// A list of servers to connect.
-	Servers []*ServerEndpoint `protobuf:"bytes,3,rep,name=servers,proto3" json:"servers,omitempty"`
-	// Maximum transmission unit of L2 payload.
-	// This setting only applies to UDP protocol egress traffic.
-	Mtu *int32 `protobuf:"varint,4,opt,name=mtu,proto3,oneof" json:"mtu,omitempty"`
-	// Multiplexing behaviors.
-	Multiplexing *MultiplexingConfig `protobuf:"bytes,5,opt,name=multiplexing,proto3,oneof" json:"multiplexing,omitempty"`
+	// Enable auto route.
+	Enable *bool `protobuf:"varint,1,opt,name=enable,proto3,oneof" json:"enable,omitempty"`
+	// Use the proxy if a destination can't be connected directly within
+	// the number of milliseconds. Valid range is [10, 10000].
+	// If it is 0, the default value 300 is used.
+	MaxLatencyMs *int32 `protobuf:"varint,2,opt,name=maxLatencyMs,proto3,oneof" json:"maxLatencyMs,omitempty"`
+	// Number of seconds to remember the route of a destination.
+	// Valid range is [1, 86400]. If it is 0, the default value 600 is used.
+	CacheSeconds *int32 `protobuf:"varint,3,opt,name=cacheSeconds,proto3,oneof" json:"cacheSeconds,omitempty"`
+	// Log the route of each request at INFO level.
+	// Otherwise, it is logged at DEBUG level.
+	// Run "mieru get route-stats" to show the recent routes.
+	LogDecisions *bool `protobuf:"varint,4,opt,name=logDecisions,proto3,oneof" json:"logDecisions,omitempty"`
 }
 
-func (x *ClientProfile) Reset() {
-	*x = ClientProfile{}
+func (x *AutoRouteConfig) Reset() {
+	*x = AutoRouteConfig{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_clientcfg_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -247,13 +491,13 @@ func (x *ClientProfile) Reset() {
 	}
 }
 
-func (x *ClientProfile) String() string {
+func (x *AutoRouteConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClientProfile) ProtoMessage() {}
+func (*AutoRouteConfig) ProtoMessage() {}
 
-func (x *ClientProfile) ProtoReflect() protoreflect.Message {
+func (x *AutoRouteConfig) ProtoReflect() protoreflect.Message {
 	mi := &file_clientcfg_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -265,57 +509,60 @@ func (x *ClientProfile) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClientProfile.ProtoReflect.Descriptor instead.
-func (*ClientProfile) Descriptor() ([]byte, []int) {
+// Deprecated: Use AutoRouteConfig.ProtoReflect.Descriptor instead.
+func (*AutoRouteConfig) Descriptor() ([]byte, []int) {
 	return file_clientcfg_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *ClientProfile) GetProfileName() string {
-	if x != nil && x.ProfileName != nil {
-		return *x.ProfileName
-	}
-	return ""
-}
-
-func (x *ClientProfile) GetUser() *User {
-	if x != nil {
-		return x.User
+func (x *AutoRouteConfig) GetEnable() bool {
+	if x != nil && x.Enable != nil {
+		return *x.Enable
 	}
-	return nil
+	return false
 }
 
-func (x *ClientProfile) GetServers() []*ServerEndpoint {
-	if x != nil {
-		return x.Servers
+func (x *AutoRouteConfig) GetMaxLatencyMs() int32 {
+	if x != nil && x.MaxLatencyMs != nil {
+		return *x.MaxLatencyMs
 	}
-	return nil
+	return 0
 }
 
-func (x *ClientProfile) GetMtu() int32 {
-	if x != nil && x.Mtu != nil {
-		return *x.Mtu
+func (x *AutoRouteConfig) GetCacheSeconds() int32 {
+	if x != nil && x.CacheSeconds != nil {
+		return *x.CacheSeconds
 	}
 	return 0
 }
 
-func (x *ClientProfile) GetMultiplexing() *MultiplexingConfig {
-	if x != nil {
-		return x.Multiplexing
+func (x *AutoRouteConfig) GetLogDecisions() bool {
+	if x != nil && x.LogDecisions != nil {
+		return *x.LogDecisions
 	}
-	return nil
+	return false
 }
 
-type MultiplexingConfig struct {
+type DNSConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// How frequent a network connection is reused.
-	Level *MultiplexingLevel `protobuf:"varint,1,opt,name=level,proto3,enum=appctl.MultiplexingLevel,oneof" json:"level,omitempty"`
+	// Addresses of DNS servers in "IP:port" format, for example "1.1.1.1:53".
+	// Queries are sent to the servers in turn.
+	// If the list is empty, the system DNS resolver is used.
+	Servers []string `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`
+	// URL of a DNS-over-HTTPS server, for example "https://dns.google/dns-query",
+	// or a DNS-over-TLS server, for example "tls://dns.google".
+	// The queries are encrypted, so they are not visible to the local network.
+	// It can't be used together with servers.
+	SecureServer *string `protobuf:"bytes,2,opt,name=secureServer,proto3,oneof" json:"secureServer,omitempty"`
+	// IP addresses of the secure DNS server, for example "8.8.8.8".
+	// They are required if the host of the secure server is a domain name.
+	BootstrapIPs []string `protobuf:"bytes,3,rep,name=bootstrapIPs,proto3" json:"bootstrapIPs,omitempty"`
 }
 
-func (x *MultiplexingConfig) Reset() {
-	*x = MultiplexingConfig{}
+func (x *DNSConfig) Reset() {
+	*x = DNSConfig{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_clientcfg_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -323,13 +570,13 @@ func (x *MultiplexingConfig) Reset() {
 	}
 }
 
-func (x *MultiplexingConfig) String() string {
+func (x *DNSConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MultiplexingConfig) ProtoMessage() {}
+func (*DNSConfig) ProtoMessage() {}
 
-func (x *MultiplexingConfig) ProtoReflect() protoreflect.Message {
+func (x *DNSConfig) ProtoReflect() protoreflect.Message {
 	mi := &file_clientcfg_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -341,26 +588,123 @@ func (x *MultiplexingConfig) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MultiplexingConfig.ProtoReflect.Descriptor instead.
-func (*MultiplexingConfig) Descriptor() ([]byte, []int) {
+// Deprecated: Use DNSConfig.ProtoReflect.Descriptor instead.
+func (*DNSConfig) Descriptor() ([]byte, []int) {
 	return file_clientcfg_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *MultiplexingConfig) GetLevel() MultiplexingLevel {
-	if x != nil && x.Level != nil {
-		return *x.Level
+func (x *DNSConfig) GetServers() []string {
+	if x != nil {
+		return x.Servers
 	}
-	return MultiplexingLevel_MULTIPLEXING_DEFAULT
+	return nil
 }
 
-type ClientAdvancedSettings struct {
+func (x *DNSConfig) GetSecureServer() string {
+	if x != nil && x.SecureServer != nil {
+		return *x.SecureServer
+	}
+	return ""
+}
+
+func (x *DNSConfig) GetBootstrapIPs() []string {
+	if x != nil {
+		return x.BootstrapIPs
+	}
+	return nil
+}
+
+type ClientProfile struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	// Client profile name.
+	ProfileName *string `protobuf:"bytes,1,opt,name=profileName,proto3,oneof" json:"profileName,omitempty"`
+	// User information.
+	User *User `protobuf:"bytes,2,opt,name=user,proto3,oneof" json:"user,omitempty"`
+	// A list of servers to connect.
+	Servers []*ServerEndpoint `protobuf:"bytes,3,rep,name=servers,proto3" json:"servers,omitempty"`
+	// Maximum transmission unit of L2 payload.
+	// This setting only applies to UDP protocol egress traffic.
+	Mtu *int32 `protobuf:"varint,4,opt,name=mtu,proto3,oneof" json:"mtu,omitempty"`
+	// Multiplexing behaviors.
+	Multiplexing *MultiplexingConfig `protobuf:"bytes,5,opt,name=multiplexing,proto3,oneof" json:"multiplexing,omitempty"`
+	// Connect to the servers with WebSocket over TLS.
+	// This setting doesn't apply to UDP protocol.
+	Websocket *ClientWebSocketConfig `protobuf:"bytes,6,opt,name=websocket,proto3,oneof" json:"websocket,omitempty"`
+	// Send one parity segment for every fecGroupSize data segments, so the
+	// server can recover a lost segment without waiting for retransmission.
+	// The value must be 0 (disabled) or between 2 and 64.
+	// This setting only applies to UDP protocol egress traffic.
+	FecGroupSize *int32 `protobuf:"varint,7,opt,name=fecGroupSize,proto3,oneof" json:"fecGroupSize,omitempty"`
+	// If a segment is not acknowledged by the server within the limit,
+	// the connection is closed with an error.
+	// This setting only applies to UDP protocol.
+	RetransmissionLimit *RetransmissionLimit `protobuf:"bytes,8,opt,name=retransmissionLimit,proto3,oneof" json:"retransmissionLimit,omitempty"`
+	// Congestion control algorithm used to send data to the server.
+	// This setting only applies to UDP protocol.
+	CongestionControl *CongestionControl `protobuf:"varint,9,opt,name=congestionControl,proto3,enum=appctl.CongestionControl,oneof" json:"congestionControl,omitempty"`
+	// Find the largest packet size that doesn't need fragmentation with
+	// probe packets, instead of using the static MTU value.
+	// The server must support path MTU discovery.
+	// This setting only applies to UDP protocol.
+	PathMTUDiscovery *bool `protobuf:"varint,10,opt,name=pathMTUDiscovery,proto3,oneof" json:"pathMTUDiscovery,omitempty"`
+	// Resume sessions from a new connection when the network changes,
+	// for example switching from Wi-Fi to cellular network.
+	// The server must support session migration.
+	// This setting only applies to UDP protocol.
+	SessionMigration *bool `protobuf:"varint,11,opt,name=sessionMigration,proto3,oneof" json:"sessionMigration,omitempty"`
+	// Connect to the servers with TLS, so the traffic looks like HTTPS.
+	// It can't be used together with WebSocket.
+	// This setting doesn't apply to UDP protocol.
+	Tls *ClientTLSConfig `protobuf:"bytes,12,opt,name=tls,proto3,oneof" json:"tls,omitempty"`
+	// Flow control windows and buffer size of a session.
+	// Increase them for networks with long round trip time and
+	// high bandwidth.
+	FlowControl *FlowControlConfig `protobuf:"bytes,13,opt,name=flowControl,proto3,oneof" json:"flowControl,omitempty"`
+	// Send packets with UDP segmentation offload,
+	// if it is supported by the kernel.
+	// This setting only applies to UDP protocol on Linux.
+	UdpOffload *bool `protobuf:"varint,14,opt,name=udpOffload,proto3,oneof" json:"udpOffload,omitempty"`
+	// How long an idle session and an idle connection are kept
+	// before they are closed.
+	IdleTimeout *IdleTimeout `protobuf:"bytes,15,opt,name=idleTimeout,proto3,oneof" json:"idleTimeout,omitempty"`
+	// Static mapping from domain names to IP addresses, like the hosts
+	// file. It is used before any DNS resolver to look up the servers,
+	// and it replaces the domain names of destinations before they are
+	// sent to the server.
+	Hosts []*HostMapping `protobuf:"bytes,16,rep,name=hosts,proto3" json:"hosts,omitempty"`
+	// Derive the keys of each session with a X25519 and ML-KEM-768 hybrid
+	// key exchange in addition to the password, so the recorded traffic
+	// can't be decrypted by a quantum computer in the future.
+	// The server must support hybrid key exchange.
+	// This setting doesn't apply to socks5 UDP associate.
+	HybridKeyExchange *bool `protobuf:"varint,17,opt,name=hybridKeyExchange,proto3,oneof" json:"hybridKeyExchange,omitempty"`
+	// Rotate the keys of a TCP connection without reconnecting.
+	// The server must support rekey.
+	// This setting doesn't apply to UDP protocol.
+	Rekey *RekeyConfig `protobuf:"bytes,18,opt,name=rekey,proto3,oneof" json:"rekey,omitempty"`
+	// How to select the server endpoint of a new connection, if there are
+	// multiple servers or port bindings. Endpoints that fail to handshake
+	// are not used for a while, regardless of the policy.
+	EndpointPolicy *EndpointPolicy `protobuf:"varint,19,opt,name=endpointPolicy,proto3,enum=appctl.EndpointPolicy,oneof" json:"endpointPolicy,omitempty"`
+	// Append a checksum to UDP packets, and tell whether a packet that
+	// fails to decrypt is corrupted in transit or encrypted with a wrong key.
+	// This setting only applies to UDP protocol.
+	IntegrityDiagnostic *bool `protobuf:"varint,20,opt,name=integrityDiagnostic,proto3,oneof" json:"integrityDiagnostic,omitempty"`
+	// Keep the latency of interactive connections bounded while bulk
+	// transfer saturates a shared network connection.
+	// If it is not set, the default values are used.
+	Fairness *Fairness `protobuf:"bytes,21,opt,name=fairness,proto3,oneof" json:"fairness,omitempty"`
+	// A named set of multiplexing, transport and security settings.
+	// Settings that are set explicitly in this profile take precedence
+	// over the preset.
+	Preset *ProfilePreset `protobuf:"varint,22,opt,name=preset,proto3,enum=appctl.ProfilePreset,oneof" json:"preset,omitempty"`
 }
 
-func (x *ClientAdvancedSettings) Reset() {
-	*x = ClientAdvancedSettings{}
+func (x *ClientProfile) Reset() {
+	*x = ClientProfile{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_clientcfg_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -368,13 +712,13 @@ func (x *ClientAdvancedSettings) Reset() {
 	}
 }
 
-func (x *ClientAdvancedSettings) String() string {
+func (x *ClientProfile) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClientAdvancedSettings) ProtoMessage() {}
+func (*ClientProfile) ProtoMessage() {}
 
-func (x *ClientAdvancedSettings) ProtoReflect() protoreflect.Message {
+func (x *ClientProfile) ProtoReflect() protoreflect.Message {
 	mi := &file_clientcfg_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -386,137 +730,1222 @@ func (x *ClientAdvancedSettings) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClientAdvancedSettings.ProtoReflect.Descriptor instead.
-func (*ClientAdvancedSettings) Descriptor() ([]byte, []int) {
+// Deprecated: Use ClientProfile.ProtoReflect.Descriptor instead.
+func (*ClientProfile) Descriptor() ([]byte, []int) {
 	return file_clientcfg_proto_rawDescGZIP(), []int{3}
 }
 
-var File_clientcfg_proto protoreflect.FileDescriptor
-
-var file_clientcfg_proto_rawDesc = []byte{
-	0x0a, 0x0f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x63, 0x66, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x06, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x1a, 0x0a, 0x62, 0x61, 0x73, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa1, 0x05, 0x0a, 0x0c, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x31, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c,
-	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74,
-	0x6c, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52,
-	0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x29, 0x0a, 0x0d, 0x61, 0x63, 0x74,
-	0x69, 0x76, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x48, 0x00, 0x52, 0x0d, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
-	0x65, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07, 0x72, 0x70, 0x63, 0x50, 0x6f, 0x72, 0x74, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x07, 0x72, 0x70, 0x63, 0x50, 0x6f, 0x72, 0x74,
-	0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x50, 0x6f, 0x72,
-	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x0a, 0x73, 0x6f, 0x63, 0x6b, 0x73,
-	0x35, 0x50, 0x6f, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x4f, 0x0a, 0x10, 0x61, 0x64, 0x76, 0x61,
-	0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x43, 0x6c, 0x69, 0x65,
-	0x6e, 0x74, 0x41, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
-	0x67, 0x73, 0x48, 0x03, 0x52, 0x10, 0x61, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64, 0x53, 0x65,
-	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x88, 0x01, 0x01, 0x12, 0x3d, 0x0a, 0x0c, 0x6c, 0x6f, 0x67,
-	0x67, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32,
-	0x14, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x4c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67,
-	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x48, 0x04, 0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67,
-	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x2d, 0x0a, 0x0f, 0x73, 0x6f, 0x63, 0x6b,
-	0x73, 0x35, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x18, 0x07, 0x20, 0x01, 0x28,
-	0x08, 0x48, 0x05, 0x52, 0x0f, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x4c, 0x69, 0x73, 0x74, 0x65,
-	0x6e, 0x4c, 0x41, 0x4e, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x50,
-	0x72, 0x6f, 0x78, 0x79, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x48, 0x06,
-	0x52, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x6f, 0x72, 0x74, 0x88,
-	0x01, 0x01, 0x12, 0x33, 0x0a, 0x12, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x4c,
-	0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x48, 0x07,
-	0x52, 0x12, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x65,
-	0x6e, 0x4c, 0x41, 0x4e, 0x88, 0x01, 0x01, 0x12, 0x40, 0x0a, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73,
-	0x35, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
-	0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41,
-	0x75, 0x74, 0x68, 0x52, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41, 0x75, 0x74, 0x68, 0x65,
-	0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x61, 0x63,
-	0x74, 0x69, 0x76, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x5f,
-	0x72, 0x70, 0x63, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x73, 0x6f, 0x63, 0x6b,
-	0x73, 0x35, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x61, 0x64, 0x76, 0x61, 0x6e,
-	0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f,
-	0x6c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x42, 0x12, 0x0a, 0x10,
-	0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e,
-	0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x6f,
-	0x72, 0x74, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79,
-	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x22, 0x9d, 0x02, 0x0a, 0x0d, 0x43, 0x6c,
-	0x69, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x25, 0x0a, 0x0b, 0x70,
-	0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x48, 0x00, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x88,
-	0x01, 0x01, 0x12, 0x25, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0c, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x48, 0x01,
-	0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x30, 0x0a, 0x07, 0x73, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x61, 0x70, 0x70,
-	0x63, 0x74, 0x6c, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69,
-	0x6e, 0x74, 0x52, 0x07, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x12, 0x15, 0x0a, 0x03, 0x6d,
-	0x74, 0x75, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x03, 0x6d, 0x74, 0x75, 0x88,
-	0x01, 0x01, 0x12, 0x43, 0x0a, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69,
-	0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74,
-	0x6c, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x48, 0x03, 0x52, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65,
-	0x78, 0x69, 0x6e, 0x67, 0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x70, 0x72, 0x6f, 0x66,
-	0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x75, 0x73, 0x65, 0x72,
-	0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x74, 0x75, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x6d, 0x75, 0x6c,
-	0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x22, 0x54, 0x0a, 0x12, 0x4d, 0x75, 0x6c,
-	0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12,
-	0x34, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19,
-	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65,
-	0x78, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x65, 0x76,
-	0x65, 0x6c, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x22,
-	0x18, 0x0a, 0x16, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x41, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65,
-	0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x2a, 0x89, 0x01, 0x0a, 0x11, 0x4d, 0x75,
-	0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12,
-	0x18, 0x0a, 0x14, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f,
-	0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x4d, 0x55, 0x4c,
-	0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f, 0x4f, 0x46, 0x46, 0x10, 0x01, 0x12,
-	0x14, 0x0a, 0x10, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f,
-	0x4c, 0x4f, 0x57, 0x10, 0x02, 0x12, 0x17, 0x0a, 0x13, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c,
-	0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f, 0x4d, 0x49, 0x44, 0x44, 0x4c, 0x45, 0x10, 0x03, 0x12, 0x15,
-	0x0a, 0x11, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f, 0x48,
-	0x49, 0x47, 0x48, 0x10, 0x04, 0x42, 0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
-	0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x6e, 0x66, 0x65, 0x69, 0x6e, 0x2f, 0x6d, 0x69, 0x65, 0x72, 0x75,
-	0x2f, 0x76, 0x33, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2f, 0x61,
-	0x70, 0x70, 0x63, 0x74, 0x6c, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *ClientProfile) GetProfileName() string {
+	if x != nil && x.ProfileName != nil {
+		return *x.ProfileName
+	}
+	return ""
 }
 
-var (
-	file_clientcfg_proto_rawDescOnce sync.Once
-	file_clientcfg_proto_rawDescData = file_clientcfg_proto_rawDesc
-)
+func (x *ClientProfile) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
 
-func file_clientcfg_proto_rawDescGZIP() []byte {
+func (x *ClientProfile) GetServers() []*ServerEndpoint {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetMtu() int32 {
+	if x != nil && x.Mtu != nil {
+		return *x.Mtu
+	}
+	return 0
+}
+
+func (x *ClientProfile) GetMultiplexing() *MultiplexingConfig {
+	if x != nil {
+		return x.Multiplexing
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetWebsocket() *ClientWebSocketConfig {
+	if x != nil {
+		return x.Websocket
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetFecGroupSize() int32 {
+	if x != nil && x.FecGroupSize != nil {
+		return *x.FecGroupSize
+	}
+	return 0
+}
+
+func (x *ClientProfile) GetRetransmissionLimit() *RetransmissionLimit {
+	if x != nil {
+		return x.RetransmissionLimit
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetCongestionControl() CongestionControl {
+	if x != nil && x.CongestionControl != nil {
+		return *x.CongestionControl
+	}
+	return CongestionControl_DEFAULT_CONGESTION_CONTROL
+}
+
+func (x *ClientProfile) GetPathMTUDiscovery() bool {
+	if x != nil && x.PathMTUDiscovery != nil {
+		return *x.PathMTUDiscovery
+	}
+	return false
+}
+
+func (x *ClientProfile) GetSessionMigration() bool {
+	if x != nil && x.SessionMigration != nil {
+		return *x.SessionMigration
+	}
+	return false
+}
+
+func (x *ClientProfile) GetTls() *ClientTLSConfig {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetFlowControl() *FlowControlConfig {
+	if x != nil {
+		return x.FlowControl
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetUdpOffload() bool {
+	if x != nil && x.UdpOffload != nil {
+		return *x.UdpOffload
+	}
+	return false
+}
+
+func (x *ClientProfile) GetIdleTimeout() *IdleTimeout {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetHosts() []*HostMapping {
+	if x != nil {
+		return x.Hosts
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetHybridKeyExchange() bool {
+	if x != nil && x.HybridKeyExchange != nil {
+		return *x.HybridKeyExchange
+	}
+	return false
+}
+
+func (x *ClientProfile) GetRekey() *RekeyConfig {
+	if x != nil {
+		return x.Rekey
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetEndpointPolicy() EndpointPolicy {
+	if x != nil && x.EndpointPolicy != nil {
+		return *x.EndpointPolicy
+	}
+	return EndpointPolicy_ENDPOINT_RANDOM
+}
+
+func (x *ClientProfile) GetIntegrityDiagnostic() bool {
+	if x != nil && x.IntegrityDiagnostic != nil {
+		return *x.IntegrityDiagnostic
+	}
+	return false
+}
+
+func (x *ClientProfile) GetFairness() *Fairness {
+	if x != nil {
+		return x.Fairness
+	}
+	return nil
+}
+
+func (x *ClientProfile) GetPreset() ProfilePreset {
+	if x != nil && x.Preset != nil {
+		return *x.Preset
+	}
+	return ProfilePreset_NO_PRESET
+}
+
+type HostMapping struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Domain name to map. It is matched exactly and case insensitively.
+	DomainName *string `protobuf:"bytes,1,opt,name=domainName,proto3,oneof" json:"domainName,omitempty"`
+	// IP addresses of the domain name. Each address can be either
+	// IPv4 or IPv6. The first address is used for destinations.
+	IpAddresses []string `protobuf:"bytes,2,rep,name=ipAddresses,proto3" json:"ipAddresses,omitempty"`
+}
+
+func (x *HostMapping) Reset() {
+	*x = HostMapping{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HostMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostMapping) ProtoMessage() {}
+
+func (x *HostMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostMapping.ProtoReflect.Descriptor instead.
+func (*HostMapping) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HostMapping) GetDomainName() string {
+	if x != nil && x.DomainName != nil {
+		return *x.DomainName
+	}
+	return ""
+}
+
+func (x *HostMapping) GetIpAddresses() []string {
+	if x != nil {
+		return x.IpAddresses
+	}
+	return nil
+}
+
+type ClientWebSocketConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The HTTP host name, for example the domain name served by a CDN.
+	Host *string `protobuf:"bytes,1,opt,name=host,proto3,oneof" json:"host,omitempty"`
+	// The HTTP path of the WebSocket endpoint. Default is "/".
+	Path *string `protobuf:"bytes,2,opt,name=path,proto3,oneof" json:"path,omitempty"`
+	// The TLS server name indication. If not set, the host is used.
+	Sni *string `protobuf:"bytes,3,opt,name=sni,proto3,oneof" json:"sni,omitempty"`
+	// Use WebSocket without TLS.
+	DisableTLS *bool `protobuf:"varint,4,opt,name=disableTLS,proto3,oneof" json:"disableTLS,omitempty"`
+}
+
+func (x *ClientWebSocketConfig) Reset() {
+	*x = ClientWebSocketConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientWebSocketConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientWebSocketConfig) ProtoMessage() {}
+
+func (x *ClientWebSocketConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientWebSocketConfig.ProtoReflect.Descriptor instead.
+func (*ClientWebSocketConfig) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ClientWebSocketConfig) GetHost() string {
+	if x != nil && x.Host != nil {
+		return *x.Host
+	}
+	return ""
+}
+
+func (x *ClientWebSocketConfig) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *ClientWebSocketConfig) GetSni() string {
+	if x != nil && x.Sni != nil {
+		return *x.Sni
+	}
+	return ""
+}
+
+func (x *ClientWebSocketConfig) GetDisableTLS() bool {
+	if x != nil && x.DisableTLS != nil {
+		return *x.DisableTLS
+	}
+	return false
+}
+
+type ClientTLSConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The TLS server name indication, which must match the server certificate.
+	Sni *string `protobuf:"bytes,1,opt,name=sni,proto3,oneof" json:"sni,omitempty"`
+}
+
+func (x *ClientTLSConfig) Reset() {
+	*x = ClientTLSConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientTLSConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientTLSConfig) ProtoMessage() {}
+
+func (x *ClientTLSConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientTLSConfig.ProtoReflect.Descriptor instead.
+func (*ClientTLSConfig) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ClientTLSConfig) GetSni() string {
+	if x != nil && x.Sni != nil {
+		return *x.Sni
+	}
+	return ""
+}
+
+type IdleTimeout struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Close a session if no data is sent or received within the number
+	// of seconds. Valid range is [0, 86400]. If it is 0, idle sessions
+	// are not closed.
+	SessionSeconds *int32 `protobuf:"varint,1,opt,name=sessionSeconds,proto3,oneof" json:"sessionSeconds,omitempty"`
+	// Close a connection if it carries no session within the number
+	// of seconds. Valid range is [10, 86400]. If it is 0, the default
+	// value, a random time between 60 and 120 seconds, is used.
+	UnderlaySeconds *int32 `protobuf:"varint,2,opt,name=underlaySeconds,proto3,oneof" json:"underlaySeconds,omitempty"`
+}
+
+func (x *IdleTimeout) Reset() {
+	*x = IdleTimeout{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IdleTimeout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdleTimeout) ProtoMessage() {}
+
+func (x *IdleTimeout) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdleTimeout.ProtoReflect.Descriptor instead.
+func (*IdleTimeout) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *IdleTimeout) GetSessionSeconds() int32 {
+	if x != nil && x.SessionSeconds != nil {
+		return *x.SessionSeconds
+	}
+	return 0
+}
+
+func (x *IdleTimeout) GetUnderlaySeconds() int32 {
+	if x != nil && x.UnderlaySeconds != nil {
+		return *x.UnderlaySeconds
+	}
+	return 0
+}
+
+type RekeyConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Rotate the keys after the number of megabytes are sent and received.
+	// Valid range is [0, 1048576]. If it is 0, the keys are not rotated
+	// by traffic.
+	Megabytes *int32 `protobuf:"varint,1,opt,name=megabytes,proto3,oneof" json:"megabytes,omitempty"`
+	// Rotate the keys after the number of minutes.
+	// Valid range is [0, 1440]. If it is 0, the keys are not rotated by time.
+	Minutes *int32 `protobuf:"varint,2,opt,name=minutes,proto3,oneof" json:"minutes,omitempty"`
+}
+
+func (x *RekeyConfig) Reset() {
+	*x = RekeyConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RekeyConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RekeyConfig) ProtoMessage() {}
+
+func (x *RekeyConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RekeyConfig.ProtoReflect.Descriptor instead.
+func (*RekeyConfig) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RekeyConfig) GetMegabytes() int32 {
+	if x != nil && x.Megabytes != nil {
+		return *x.Megabytes
+	}
+	return 0
+}
+
+func (x *RekeyConfig) GetMinutes() int32 {
+	if x != nil && x.Minutes != nil {
+		return *x.Minutes
+	}
+	return 0
+}
+
+type MultiplexingConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// How frequent a network connection is reused.
+	Level *MultiplexingLevel `protobuf:"varint,1,opt,name=level,proto3,enum=appctl.MultiplexingLevel,oneof" json:"level,omitempty"`
+	// The maximum number of sessions carried by a network connection
+	// at the same time. 0 means no limit.
+	MaxSessionsPerConnection *int32 `protobuf:"varint,2,opt,name=maxSessionsPerConnection,proto3,oneof" json:"maxSessionsPerConnection,omitempty"`
+	// Stop putting new sessions into a network connection after it has
+	// been used for this number of seconds. 0 means no limit.
+	MaxConnectionLifetimeSeconds *int32 `protobuf:"varint,3,opt,name=maxConnectionLifetimeSeconds,proto3,oneof" json:"maxConnectionLifetimeSeconds,omitempty"`
+	// The number of spare network connections that are opened in advance,
+	// so new sessions don't need to wait for a connection handshake.
+	SpareConnections *int32 `protobuf:"varint,4,opt,name=spareConnections,proto3,oneof" json:"spareConnections,omitempty"`
+}
+
+func (x *MultiplexingConfig) Reset() {
+	*x = MultiplexingConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiplexingConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiplexingConfig) ProtoMessage() {}
+
+func (x *MultiplexingConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiplexingConfig.ProtoReflect.Descriptor instead.
+func (*MultiplexingConfig) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MultiplexingConfig) GetLevel() MultiplexingLevel {
+	if x != nil && x.Level != nil {
+		return *x.Level
+	}
+	return MultiplexingLevel_MULTIPLEXING_DEFAULT
+}
+
+func (x *MultiplexingConfig) GetMaxSessionsPerConnection() int32 {
+	if x != nil && x.MaxSessionsPerConnection != nil {
+		return *x.MaxSessionsPerConnection
+	}
+	return 0
+}
+
+func (x *MultiplexingConfig) GetMaxConnectionLifetimeSeconds() int32 {
+	if x != nil && x.MaxConnectionLifetimeSeconds != nil {
+		return *x.MaxConnectionLifetimeSeconds
+	}
+	return 0
+}
+
+func (x *MultiplexingConfig) GetSpareConnections() int32 {
+	if x != nil && x.SpareConnections != nil {
+		return *x.SpareConnections
+	}
+	return 0
+}
+
+type ClientAdvancedSettings struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ClientAdvancedSettings) Reset() {
+	*x = ClientAdvancedSettings{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientAdvancedSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientAdvancedSettings) ProtoMessage() {}
+
+func (x *ClientAdvancedSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientAdvancedSettings.ProtoReflect.Descriptor instead.
+func (*ClientAdvancedSettings) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{10}
+}
+
+type PortForward struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The local port to listen.
+	LocalPort *int32 `protobuf:"varint,1,opt,name=localPort,proto3,oneof" json:"localPort,omitempty"`
+	// The transport protocol of the local port. Either TCP or UDP.
+	Protocol *TransportProtocol `protobuf:"varint,2,opt,name=protocol,proto3,enum=appctl.TransportProtocol,oneof" json:"protocol,omitempty"`
+	// The remote destination in "host:port" format.
+	// The host can be an IP address or a domain name.
+	// Domain name is resolved by the proxy server.
+	RemoteAddress *string `protobuf:"bytes,3,opt,name=remoteAddress,proto3,oneof" json:"remoteAddress,omitempty"`
+	// If set, the local port listens to LAN rather than localhost.
+	ListenLAN *bool `protobuf:"varint,4,opt,name=listenLAN,proto3,oneof" json:"listenLAN,omitempty"`
+}
+
+func (x *PortForward) Reset() {
+	*x = PortForward{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PortForward) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortForward) ProtoMessage() {}
+
+func (x *PortForward) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortForward.ProtoReflect.Descriptor instead.
+func (*PortForward) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PortForward) GetLocalPort() int32 {
+	if x != nil && x.LocalPort != nil {
+		return *x.LocalPort
+	}
+	return 0
+}
+
+func (x *PortForward) GetProtocol() TransportProtocol {
+	if x != nil && x.Protocol != nil {
+		return *x.Protocol
+	}
+	return TransportProtocol_UNKNOWN_TRANSPORT_PROTOCOL
+}
+
+func (x *PortForward) GetRemoteAddress() string {
+	if x != nil && x.RemoteAddress != nil {
+		return *x.RemoteAddress
+	}
+	return ""
+}
+
+func (x *PortForward) GetListenLAN() bool {
+	if x != nil && x.ListenLAN != nil {
+		return *x.ListenLAN
+	}
+	return false
+}
+
+type ReverseForward struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The TCP port to expose on the proxy server.
+	RemotePort *int32 `protobuf:"varint,1,opt,name=remotePort,proto3,oneof" json:"remotePort,omitempty"`
+	// The client side service address in "host:port" format.
+	LocalAddress *string `protobuf:"bytes,2,opt,name=localAddress,proto3,oneof" json:"localAddress,omitempty"`
+}
+
+func (x *ReverseForward) Reset() {
+	*x = ReverseForward{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReverseForward) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseForward) ProtoMessage() {}
+
+func (x *ReverseForward) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseForward.ProtoReflect.Descriptor instead.
+func (*ReverseForward) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReverseForward) GetRemotePort() int32 {
+	if x != nil && x.RemotePort != nil {
+		return *x.RemotePort
+	}
+	return 0
+}
+
+func (x *ReverseForward) GetLocalAddress() string {
+	if x != nil && x.LocalAddress != nil {
+		return *x.LocalAddress
+	}
+	return ""
+}
+
+type ProfileSchedule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Days of the week when the time window starts, for example "Mon".
+	// If not set, the time window starts every day.
+	Days []string `protobuf:"bytes,1,rep,name=days,proto3" json:"days,omitempty"`
+	// The start time of the time window in "HH:MM" format.
+	StartTime *string `protobuf:"bytes,2,opt,name=startTime,proto3,oneof" json:"startTime,omitempty"`
+	// The end time of the time window in "HH:MM" format.
+	// If it is not later than the start time, the time window
+	// ends at the next day.
+	EndTime *string `protobuf:"bytes,3,opt,name=endTime,proto3,oneof" json:"endTime,omitempty"`
+	// The profile used in the time window.
+	ProfileName *string `protobuf:"bytes,4,opt,name=profileName,proto3,oneof" json:"profileName,omitempty"`
+	// If set, the client rejects new proxy connections in the time window.
+	// It can't be used together with profileName.
+	DisableProxy *bool `protobuf:"varint,5,opt,name=disableProxy,proto3,oneof" json:"disableProxy,omitempty"`
+}
+
+func (x *ProfileSchedule) Reset() {
+	*x = ProfileSchedule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_clientcfg_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProfileSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileSchedule) ProtoMessage() {}
+
+func (x *ProfileSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_clientcfg_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileSchedule.ProtoReflect.Descriptor instead.
+func (*ProfileSchedule) Descriptor() ([]byte, []int) {
+	return file_clientcfg_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ProfileSchedule) GetDays() []string {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *ProfileSchedule) GetStartTime() string {
+	if x != nil && x.StartTime != nil {
+		return *x.StartTime
+	}
+	return ""
+}
+
+func (x *ProfileSchedule) GetEndTime() string {
+	if x != nil && x.EndTime != nil {
+		return *x.EndTime
+	}
+	return ""
+}
+
+func (x *ProfileSchedule) GetProfileName() string {
+	if x != nil && x.ProfileName != nil {
+		return *x.ProfileName
+	}
+	return ""
+}
+
+func (x *ProfileSchedule) GetDisableProxy() bool {
+	if x != nil && x.DisableProxy != nil {
+		return *x.DisableProxy
+	}
+	return false
+}
+
+var File_clientcfg_proto protoreflect.FileDescriptor
+
+var file_clientcfg_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x63, 0x66, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x06, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x1a, 0x0a, 0x62, 0x61, 0x73, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xfe, 0x0a, 0x0a, 0x0c, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x31, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74,
+	0x6c, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52,
+	0x08, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x29, 0x0a, 0x0d, 0x61, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x00, 0x52, 0x0d, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07, 0x72, 0x70, 0x63, 0x50, 0x6f, 0x72, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x07, 0x72, 0x70, 0x63, 0x50, 0x6f, 0x72, 0x74,
+	0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x50, 0x6f, 0x72,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x0a, 0x73, 0x6f, 0x63, 0x6b, 0x73,
+	0x35, 0x50, 0x6f, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x4f, 0x0a, 0x10, 0x61, 0x64, 0x76, 0x61,
+	0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x43, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x41, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e,
+	0x67, 0x73, 0x48, 0x03, 0x52, 0x10, 0x61, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64, 0x53, 0x65,
+	0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x88, 0x01, 0x01, 0x12, 0x3d, 0x0a, 0x0c, 0x6c, 0x6f, 0x67,
+	0x67, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x14, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x4c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67,
+	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x48, 0x04, 0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67,
+	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x2d, 0x0a, 0x0f, 0x73, 0x6f, 0x63, 0x6b,
+	0x73, 0x35, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x08, 0x48, 0x05, 0x52, 0x0f, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x4c, 0x69, 0x73, 0x74, 0x65,
+	0x6e, 0x4c, 0x41, 0x4e, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x50,
+	0x72, 0x6f, 0x78, 0x79, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x48, 0x06,
+	0x52, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x6f, 0x72, 0x74, 0x88,
+	0x01, 0x01, 0x12, 0x33, 0x0a, 0x12, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x4c,
+	0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x48, 0x07,
+	0x52, 0x12, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x65,
+	0x6e, 0x4c, 0x41, 0x4e, 0x88, 0x01, 0x01, 0x12, 0x40, 0x0a, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73,
+	0x35, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41,
+	0x75, 0x74, 0x68, 0x52, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41, 0x75, 0x74, 0x68, 0x65,
+	0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x0c, 0x70, 0x6f, 0x72,
+	0x74, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x50, 0x6f, 0x72, 0x74, 0x46, 0x6f, 0x72,
+	0x77, 0x61, 0x72, 0x64, 0x52, 0x0c, 0x70, 0x6f, 0x72, 0x74, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72,
+	0x64, 0x73, 0x12, 0x40, 0x0a, 0x0f, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x46, 0x6f, 0x72,
+	0x77, 0x61, 0x72, 0x64, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x61, 0x70,
+	0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x46, 0x6f, 0x72, 0x77,
+	0x61, 0x72, 0x64, 0x52, 0x0f, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x46, 0x6f, 0x72, 0x77,
+	0x61, 0x72, 0x64, 0x73, 0x12, 0x43, 0x0a, 0x10, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x53,
+	0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x53,
+	0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x52, 0x10, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65,
+	0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x3a, 0x0a, 0x0b, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x66, 0x65, 0x72, 0x43, 0x61, 0x70, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72,
+	0x43, 0x61, 0x70, 0x48, 0x08, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x43,
+	0x61, 0x70, 0x88, 0x01, 0x01, 0x12, 0x28, 0x0a, 0x03, 0x64, 0x6e, 0x73, 0x18, 0x0f, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x44, 0x4e, 0x53, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x09, 0x52, 0x03, 0x64, 0x6e, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x3a, 0x0a, 0x09, 0x61, 0x75, 0x74, 0x6f, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x18, 0x10, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41, 0x75, 0x74, 0x6f,
+	0x52, 0x6f, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x0a, 0x52, 0x09, 0x61,
+	0x75, 0x74, 0x6f, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2f, 0x0a, 0x10, 0x64,
+	0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x18,
+	0x11, 0x20, 0x01, 0x28, 0x08, 0x48, 0x0b, 0x52, 0x10, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d,
+	0x64, 0x65, 0x62, 0x75, 0x67, 0x48, 0x74, 0x74, 0x70, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x05, 0x48, 0x0c, 0x52, 0x0d, 0x64, 0x65, 0x62, 0x75, 0x67, 0x48, 0x74, 0x74, 0x70,
+	0x50, 0x6f, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x34, 0x0a, 0x09, 0x6c, 0x6f, 0x67, 0x46, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x70,
+	0x63, 0x74, 0x6c, 0x2e, 0x4c, 0x6f, 0x67, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x48, 0x0d, 0x52,
+	0x09, 0x6c, 0x6f, 0x67, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x88, 0x01, 0x01, 0x12, 0x33, 0x0a,
+	0x12, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x74, 0x74, 0x70, 0x50,
+	0x6f, 0x72, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x05, 0x48, 0x0e, 0x52, 0x12, 0x6d, 0x61, 0x6e,
+	0x61, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x74, 0x74, 0x70, 0x50, 0x6f, 0x72, 0x74, 0x88,
+	0x01, 0x01, 0x12, 0x34, 0x0a, 0x07, 0x74, 0x72, 0x61, 0x63, 0x69, 0x6e, 0x67, 0x18, 0x15, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x54, 0x72, 0x61,
+	0x63, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x0f, 0x52, 0x07, 0x74, 0x72,
+	0x61, 0x63, 0x69, 0x6e, 0x67, 0x88, 0x01, 0x01, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x61, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x72,
+	0x70, 0x63, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x73,
+	0x35, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x61, 0x64, 0x76, 0x61, 0x6e, 0x63,
+	0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x6c,
+	0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x42, 0x12, 0x0a, 0x10, 0x5f,
+	0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x42,
+	0x10, 0x0a, 0x0e, 0x5f, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x6f, 0x72,
+	0x74, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x68, 0x74, 0x74, 0x70, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x4c,
+	0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x66, 0x65, 0x72, 0x43, 0x61, 0x70, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x64, 0x6e, 0x73,
+	0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x42, 0x13,
+	0x0a, 0x11, 0x5f, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x64, 0x65, 0x62, 0x75, 0x67, 0x48, 0x74, 0x74,
+	0x70, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x6c, 0x6f, 0x67, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x48, 0x74, 0x74, 0x70, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x74,
+	0x72, 0x61, 0x63, 0x69, 0x6e, 0x67, 0x22, 0xe7, 0x01, 0x0a, 0x0f, 0x41, 0x75, 0x74, 0x6f, 0x52,
+	0x6f, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1b, 0x0a, 0x06, 0x65, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x06, 0x65, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x4c, 0x61,
+	0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52,
+	0x0c, 0x6d, 0x61, 0x78, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x88, 0x01, 0x01,
+	0x12, 0x27, 0x0a, 0x0c, 0x63, 0x61, 0x63, 0x68, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x0c, 0x63, 0x61, 0x63, 0x68, 0x65, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0c, 0x6c, 0x6f, 0x67,
+	0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x48,
+	0x03, 0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x88,
+	0x01, 0x01, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x42, 0x0f, 0x0a,
+	0x0d, 0x5f, 0x6d, 0x61, 0x78, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x73, 0x42, 0x0f,
+	0x0a, 0x0d, 0x5f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x42,
+	0x0f, 0x0a, 0x0d, 0x5f, 0x6c, 0x6f, 0x67, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x22, 0x83, 0x01, 0x0a, 0x09, 0x44, 0x4e, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x18,
+	0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x07, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x12, 0x27, 0x0a, 0x0c, 0x73, 0x65, 0x63, 0x75,
+	0x72, 0x65, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00,
+	0x52, 0x0c, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x88, 0x01,
+	0x01, 0x12, 0x22, 0x0a, 0x0c, 0x62, 0x6f, 0x6f, 0x74, 0x73, 0x74, 0x72, 0x61, 0x70, 0x49, 0x50,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x62, 0x6f, 0x6f, 0x74, 0x73, 0x74, 0x72,
+	0x61, 0x70, 0x49, 0x50, 0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65,
+	0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x22, 0xe1, 0x0b, 0x0a, 0x0d, 0x43, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x25, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
+	0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x25, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x48, 0x01, 0x52, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x30, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c,
+	0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52,
+	0x07, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x73, 0x12, 0x15, 0x0a, 0x03, 0x6d, 0x74, 0x75, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x03, 0x6d, 0x74, 0x75, 0x88, 0x01, 0x01, 0x12,
+	0x43, 0x0a, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x4d,
+	0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x48, 0x03, 0x52, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e,
+	0x67, 0x88, 0x01, 0x01, 0x12, 0x40, 0x0a, 0x09, 0x77, 0x65, 0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c,
+	0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x57, 0x65, 0x62, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x04, 0x52, 0x09, 0x77, 0x65, 0x62, 0x73, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0c, 0x66, 0x65, 0x63, 0x47, 0x72, 0x6f,
+	0x75, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x48, 0x05, 0x52, 0x0c,
+	0x66, 0x65, 0x63, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x52, 0x0a, 0x13, 0x72, 0x65, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x61,
+	0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x48, 0x06, 0x52, 0x13, 0x72, 0x65, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x88, 0x01, 0x01, 0x12, 0x4c, 0x0a, 0x11, 0x63, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x69, 0x6f,
+	0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19,
+	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x43, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x69,
+	0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x48, 0x07, 0x52, 0x11, 0x63, 0x6f, 0x6e,
+	0x67, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x88, 0x01,
+	0x01, 0x12, 0x2f, 0x0a, 0x10, 0x70, 0x61, 0x74, 0x68, 0x4d, 0x54, 0x55, 0x44, 0x69, 0x73, 0x63,
+	0x6f, 0x76, 0x65, 0x72, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x48, 0x08, 0x52, 0x10, 0x70,
+	0x61, 0x74, 0x68, 0x4d, 0x54, 0x55, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x88,
+	0x01, 0x01, 0x12, 0x2f, 0x0a, 0x10, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x67,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x48, 0x09, 0x52, 0x10,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x88, 0x01, 0x01, 0x12, 0x2e, 0x0a, 0x03, 0x74, 0x6c, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x0a, 0x52, 0x03, 0x74, 0x6c, 0x73,
+	0x88, 0x01, 0x01, 0x12, 0x40, 0x0a, 0x0b, 0x66, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74,
+	0x6c, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x48, 0x0b, 0x52, 0x0b, 0x66, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x75, 0x64, 0x70, 0x4f, 0x66, 0x66, 0x6c,
+	0x6f, 0x61, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x48, 0x0c, 0x52, 0x0a, 0x75, 0x64, 0x70,
+	0x4f, 0x66, 0x66, 0x6c, 0x6f, 0x61, 0x64, 0x88, 0x01, 0x01, 0x12, 0x3a, 0x0a, 0x0b, 0x69, 0x64,
+	0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x49, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x48, 0x0d, 0x52, 0x0b, 0x69, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x05, 0x68, 0x6f, 0x73, 0x74, 0x73, 0x18,
+	0x10, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x48,
+	0x6f, 0x73, 0x74, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x05, 0x68, 0x6f, 0x73, 0x74,
+	0x73, 0x12, 0x31, 0x0a, 0x11, 0x68, 0x79, 0x62, 0x72, 0x69, 0x64, 0x4b, 0x65, 0x79, 0x45, 0x78,
+	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x48, 0x0e, 0x52, 0x11,
+	0x68, 0x79, 0x62, 0x72, 0x69, 0x64, 0x4b, 0x65, 0x79, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x88, 0x01, 0x01, 0x12, 0x2e, 0x0a, 0x05, 0x72, 0x65, 0x6b, 0x65, 0x79, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x6b,
+	0x65, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x0f, 0x52, 0x05, 0x72, 0x65, 0x6b, 0x65,
+	0x79, 0x88, 0x01, 0x01, 0x12, 0x43, 0x0a, 0x0e, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x61,
+	0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x50, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x48, 0x10, 0x52, 0x0e, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x88, 0x01, 0x01, 0x12, 0x35, 0x0a, 0x13, 0x69, 0x6e, 0x74,
+	0x65, 0x67, 0x72, 0x69, 0x74, 0x79, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x48, 0x11, 0x52, 0x13, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72,
+	0x69, 0x74, 0x79, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63, 0x88, 0x01, 0x01,
+	0x12, 0x31, 0x0a, 0x08, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x18, 0x15, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x46, 0x61, 0x69, 0x72,
+	0x6e, 0x65, 0x73, 0x73, 0x48, 0x12, 0x52, 0x08, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73,
+	0x88, 0x01, 0x01, 0x12, 0x32, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x18, 0x16, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x50, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x48, 0x13, 0x52, 0x06, 0x70, 0x72,
+	0x65, 0x73, 0x65, 0x74, 0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x70, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x75, 0x73, 0x65, 0x72,
+	0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x74, 0x75, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x6d, 0x75, 0x6c,
+	0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x77, 0x65,
+	0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x66, 0x65, 0x63, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x42, 0x16, 0x0a, 0x14, 0x5f, 0x72, 0x65, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x42, 0x14, 0x0a, 0x12, 0x5f, 0x63, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x43,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x4d,
+	0x54, 0x55, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x42, 0x13, 0x0a, 0x11, 0x5f,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x42, 0x06, 0x0a, 0x04, 0x5f, 0x74, 0x6c, 0x73, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x66, 0x6c, 0x6f,
+	0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x75, 0x64, 0x70,
+	0x4f, 0x66, 0x66, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x69, 0x64, 0x6c, 0x65,
+	0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x42, 0x14, 0x0a, 0x12, 0x5f, 0x68, 0x79, 0x62, 0x72,
+	0x69, 0x64, 0x4b, 0x65, 0x79, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x42, 0x08, 0x0a,
+	0x06, 0x5f, 0x72, 0x65, 0x6b, 0x65, 0x79, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x65, 0x6e, 0x64, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x42, 0x16, 0x0a, 0x14, 0x5f, 0x69,
+	0x6e, 0x74, 0x65, 0x67, 0x72, 0x69, 0x74, 0x79, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74,
+	0x69, 0x63, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x42,
+	0x09, 0x0a, 0x07, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x65, 0x74, 0x22, 0x63, 0x0a, 0x0b, 0x48, 0x6f,
+	0x73, 0x74, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x23, 0x0a, 0x0a, 0x64, 0x6f, 0x6d,
+	0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
+	0x0a, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x20,
+	0x0a, 0x0b, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x70, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73,
+	0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x22,
+	0xae, 0x01, 0x0a, 0x15, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x57, 0x65, 0x62, 0x53, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x17, 0x0a, 0x04, 0x68, 0x6f, 0x73,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x88,
+	0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x01, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x73,
+	0x6e, 0x69, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x03, 0x73, 0x6e, 0x69, 0x88,
+	0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x4c, 0x53,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x48, 0x03, 0x52, 0x0a, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c,
+	0x65, 0x54, 0x4c, 0x53, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x68, 0x6f, 0x73, 0x74,
+	0x42, 0x07, 0x0a, 0x05, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x73, 0x6e,
+	0x69, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x54, 0x4c, 0x53,
+	0x22, 0x30, 0x0a, 0x0f, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x15, 0x0a, 0x03, 0x73, 0x6e, 0x69, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x00, 0x52, 0x03, 0x73, 0x6e, 0x69, 0x88, 0x01, 0x01, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x73,
+	0x6e, 0x69, 0x22, 0x90, 0x01, 0x0a, 0x0b, 0x49, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6f,
+	0x75, 0x74, 0x12, 0x2b, 0x0a, 0x0e, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x0e, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x2d, 0x0a, 0x0f, 0x75, 0x6e, 0x64, 0x65, 0x72, 0x6c, 0x61, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x0f, 0x75, 0x6e, 0x64, 0x65,
+	0x72, 0x6c, 0x61, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x42, 0x11,
+	0x0a, 0x0f, 0x5f, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x75, 0x6e, 0x64, 0x65, 0x72, 0x6c, 0x61, 0x79, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x69, 0x0a, 0x0b, 0x52, 0x65, 0x6b, 0x65, 0x79, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x12, 0x21, 0x0a, 0x09, 0x6d, 0x65, 0x67, 0x61, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x09, 0x6d, 0x65, 0x67, 0x61, 0x62,
+	0x79, 0x74, 0x65, 0x73, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07, 0x6d, 0x69, 0x6e, 0x75, 0x74,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x07, 0x6d, 0x69, 0x6e, 0x75,
+	0x74, 0x65, 0x73, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x6d, 0x65, 0x67, 0x61, 0x62,
+	0x79, 0x74, 0x65, 0x73, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73,
+	0x22, 0xe2, 0x02, 0x0a, 0x12, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e,
+	0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x34, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e,
+	0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x3f, 0x0a,
+	0x18, 0x6d, 0x61, 0x78, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x50, 0x65, 0x72, 0x43,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48,
+	0x01, 0x52, 0x18, 0x6d, 0x61, 0x78, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x50, 0x65,
+	0x72, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x47,
+	0x0a, 0x1c, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4c,
+	0x69, 0x66, 0x65, 0x74, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x48, 0x02, 0x52, 0x1c, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x6e, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x66, 0x65, 0x74, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12, 0x2f, 0x0a, 0x10, 0x73, 0x70, 0x61, 0x72, 0x65,
+	0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x05, 0x48, 0x03, 0x52, 0x10, 0x73, 0x70, 0x61, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x42, 0x1b, 0x0a, 0x19, 0x5f, 0x6d, 0x61, 0x78, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x50, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42,
+	0x1f, 0x0a, 0x1d, 0x5f, 0x6d, 0x61, 0x78, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x4c, 0x69, 0x66, 0x65, 0x74, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x42, 0x13, 0x0a, 0x11, 0x5f, 0x73, 0x70, 0x61, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x18, 0x0a, 0x16, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x41,
+	0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x22,
+	0xf5, 0x01, 0x0a, 0x0b, 0x50, 0x6f, 0x72, 0x74, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x12,
+	0x21, 0x0a, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x48, 0x00, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x50, 0x6f, 0x72, 0x74, 0x88,
+	0x01, 0x01, 0x12, 0x3a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x48,
+	0x01, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x29,
+	0x0a, 0x0d, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x0d, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x09, 0x6c, 0x69, 0x73,
+	0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x48, 0x03, 0x52, 0x09,
+	0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a,
+	0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x72, 0x65, 0x6d, 0x6f,
+	0x74, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x6c, 0x69,
+	0x73, 0x74, 0x65, 0x6e, 0x4c, 0x41, 0x4e, 0x22, 0x7e, 0x0a, 0x0e, 0x52, 0x65, 0x76, 0x65, 0x72,
+	0x73, 0x65, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x12, 0x23, 0x0a, 0x0a, 0x72, 0x65, 0x6d,
+	0x6f, 0x74, 0x65, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52,
+	0x0a, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x50, 0x6f, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x27,
+	0x0a, 0x0c, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x0c, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x72, 0x65, 0x6d, 0x6f,
+	0x74, 0x65, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0xf2, 0x01, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64,
+	0x61, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x79, 0x73, 0x12,
+	0x21, 0x0a, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x88, 0x01,
+	0x01, 0x12, 0x25, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x61,
+	0x62, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x48, 0x03,
+	0x52, 0x0c, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x88, 0x01,
+	0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x42,
+	0x0a, 0x0a, 0x08, 0x5f, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x42, 0x0e, 0x0a, 0x0c, 0x5f,
+	0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x42, 0x0f, 0x0a, 0x0d, 0x5f,
+	0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x2a, 0x89, 0x01, 0x0a,
+	0x11, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x78, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76,
+	0x65, 0x6c, 0x12, 0x18, 0x0a, 0x14, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49,
+	0x4e, 0x47, 0x5f, 0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10,
+	0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f, 0x4f, 0x46, 0x46,
+	0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49,
+	0x4e, 0x47, 0x5f, 0x4c, 0x4f, 0x57, 0x10, 0x02, 0x12, 0x17, 0x0a, 0x13, 0x4d, 0x55, 0x4c, 0x54,
+	0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e, 0x47, 0x5f, 0x4d, 0x49, 0x44, 0x44, 0x4c, 0x45, 0x10,
+	0x03, 0x12, 0x15, 0x0a, 0x11, 0x4d, 0x55, 0x4c, 0x54, 0x49, 0x50, 0x4c, 0x45, 0x58, 0x49, 0x4e,
+	0x47, 0x5f, 0x48, 0x49, 0x47, 0x48, 0x10, 0x04, 0x2a, 0x71, 0x0a, 0x0e, 0x45, 0x6e, 0x64, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x13, 0x0a, 0x0f, 0x45, 0x4e,
+	0x44, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x5f, 0x52, 0x41, 0x4e, 0x44, 0x4f, 0x4d, 0x10, 0x00, 0x12,
+	0x18, 0x0a, 0x14, 0x45, 0x4e, 0x44, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x5f, 0x52, 0x4f, 0x55, 0x4e,
+	0x44, 0x5f, 0x52, 0x4f, 0x42, 0x49, 0x4e, 0x10, 0x01, 0x12, 0x1b, 0x0a, 0x17, 0x45, 0x4e, 0x44,
+	0x50, 0x4f, 0x49, 0x4e, 0x54, 0x5f, 0x4c, 0x4f, 0x57, 0x45, 0x53, 0x54, 0x5f, 0x4c, 0x41, 0x54,
+	0x45, 0x4e, 0x43, 0x59, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x45, 0x4e, 0x44, 0x50, 0x4f, 0x49,
+	0x4e, 0x54, 0x5f, 0x53, 0x54, 0x49, 0x43, 0x4b, 0x59, 0x10, 0x03, 0x2a, 0x5e, 0x0a, 0x0d, 0x50,
+	0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x50, 0x72, 0x65, 0x73, 0x65, 0x74, 0x12, 0x0d, 0x0a, 0x09,
+	0x4e, 0x4f, 0x5f, 0x50, 0x52, 0x45, 0x53, 0x45, 0x54, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x42,
+	0x41, 0x4c, 0x41, 0x4e, 0x43, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x4c, 0x4f, 0x57,
+	0x5f, 0x4c, 0x41, 0x54, 0x45, 0x4e, 0x43, 0x59, 0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x4c, 0x4f,
+	0x57, 0x5f, 0x42, 0x41, 0x4e, 0x44, 0x57, 0x49, 0x44, 0x54, 0x48, 0x10, 0x03, 0x12, 0x0c, 0x0a,
+	0x08, 0x50, 0x41, 0x52, 0x41, 0x4e, 0x4f, 0x49, 0x44, 0x10, 0x04, 0x42, 0x30, 0x5a, 0x2e, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x6e, 0x66, 0x65, 0x69, 0x6e,
+	0x2f, 0x6d, 0x69, 0x65, 0x72, 0x75, 0x2f, 0x76, 0x33, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70,
+	0x70, 0x63, 0x74, 0x6c, 0x2f, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_clientcfg_proto_rawDescOnce sync.Once
+	file_clientcfg_proto_rawDescData = file_clientcfg_proto_rawDesc
+)
+
+func file_clientcfg_proto_rawDescGZIP() []byte {
 	file_clientcfg_proto_rawDescOnce.Do(func() {
 		file_clientcfg_proto_rawDescData = protoimpl.X.CompressGZIP(file_clientcfg_proto_rawDescData)
 	})
 	return file_clientcfg_proto_rawDescData
 }
 
-var file_clientcfg_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_clientcfg_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_clientcfg_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_clientcfg_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_clientcfg_proto_goTypes = []interface{}{
 	(MultiplexingLevel)(0),         // 0: appctl.MultiplexingLevel
-	(*ClientConfig)(nil),           // 1: appctl.ClientConfig
-	(*ClientProfile)(nil),          // 2: appctl.ClientProfile
-	(*MultiplexingConfig)(nil),     // 3: appctl.MultiplexingConfig
-	(*ClientAdvancedSettings)(nil), // 4: appctl.ClientAdvancedSettings
-	(LoggingLevel)(0),              // 5: appctl.LoggingLevel
-	(*Auth)(nil),                   // 6: appctl.Auth
-	(*User)(nil),                   // 7: appctl.User
-	(*ServerEndpoint)(nil),         // 8: appctl.ServerEndpoint
+	(EndpointPolicy)(0),            // 1: appctl.EndpointPolicy
+	(ProfilePreset)(0),             // 2: appctl.ProfilePreset
+	(*ClientConfig)(nil),           // 3: appctl.ClientConfig
+	(*AutoRouteConfig)(nil),        // 4: appctl.AutoRouteConfig
+	(*DNSConfig)(nil),              // 5: appctl.DNSConfig
+	(*ClientProfile)(nil),          // 6: appctl.ClientProfile
+	(*HostMapping)(nil),            // 7: appctl.HostMapping
+	(*ClientWebSocketConfig)(nil),  // 8: appctl.ClientWebSocketConfig
+	(*ClientTLSConfig)(nil),        // 9: appctl.ClientTLSConfig
+	(*IdleTimeout)(nil),            // 10: appctl.IdleTimeout
+	(*RekeyConfig)(nil),            // 11: appctl.RekeyConfig
+	(*MultiplexingConfig)(nil),     // 12: appctl.MultiplexingConfig
+	(*ClientAdvancedSettings)(nil), // 13: appctl.ClientAdvancedSettings
+	(*PortForward)(nil),            // 14: appctl.PortForward
+	(*ReverseForward)(nil),         // 15: appctl.ReverseForward
+	(*ProfileSchedule)(nil),        // 16: appctl.ProfileSchedule
+	(LoggingLevel)(0),              // 17: appctl.LoggingLevel
+	(*Auth)(nil),                   // 18: appctl.Auth
+	(*TransferCap)(nil),            // 19: appctl.TransferCap
+	(LogFormat)(0),                 // 20: appctl.LogFormat
+	(*TracingConfig)(nil),          // 21: appctl.TracingConfig
+	(*User)(nil),                   // 22: appctl.User
+	(*ServerEndpoint)(nil),         // 23: appctl.ServerEndpoint
+	(*RetransmissionLimit)(nil),    // 24: appctl.RetransmissionLimit
+	(CongestionControl)(0),         // 25: appctl.CongestionControl
+	(*FlowControlConfig)(nil),      // 26: appctl.FlowControlConfig
+	(*Fairness)(nil),               // 27: appctl.Fairness
+	(TransportProtocol)(0),         // 28: appctl.TransportProtocol
 }
 var file_clientcfg_proto_depIdxs = []int32{
-	2, // 0: appctl.ClientConfig.profiles:type_name -> appctl.ClientProfile
-	4, // 1: appctl.ClientConfig.advancedSettings:type_name -> appctl.ClientAdvancedSettings
-	5, // 2: appctl.ClientConfig.loggingLevel:type_name -> appctl.LoggingLevel
-	6, // 3: appctl.ClientConfig.socks5Authentication:type_name -> appctl.Auth
-	7, // 4: appctl.ClientProfile.user:type_name -> appctl.User
-	8, // 5: appctl.ClientProfile.servers:type_name -> appctl.ServerEndpoint
-	3, // 6: appctl.ClientProfile.multiplexing:type_name -> appctl.MultiplexingConfig
-	0, // 7: appctl.MultiplexingConfig.level:type_name -> appctl.MultiplexingLevel
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	6,  // 0: appctl.ClientConfig.profiles:type_name -> appctl.ClientProfile
+	13, // 1: appctl.ClientConfig.advancedSettings:type_name -> appctl.ClientAdvancedSettings
+	17, // 2: appctl.ClientConfig.loggingLevel:type_name -> appctl.LoggingLevel
+	18, // 3: appctl.ClientConfig.socks5Authentication:type_name -> appctl.Auth
+	14, // 4: appctl.ClientConfig.portForwards:type_name -> appctl.PortForward
+	15, // 5: appctl.ClientConfig.reverseForwards:type_name -> appctl.ReverseForward
+	16, // 6: appctl.ClientConfig.profileSchedules:type_name -> appctl.ProfileSchedule
+	19, // 7: appctl.ClientConfig.transferCap:type_name -> appctl.TransferCap
+	5,  // 8: appctl.ClientConfig.dns:type_name -> appctl.DNSConfig
+	4,  // 9: appctl.ClientConfig.autoRoute:type_name -> appctl.AutoRouteConfig
+	20, // 10: appctl.ClientConfig.logFormat:type_name -> appctl.LogFormat
+	21, // 11: appctl.ClientConfig.tracing:type_name -> appctl.TracingConfig
+	22, // 12: appctl.ClientProfile.user:type_name -> appctl.User
+	23, // 13: appctl.ClientProfile.servers:type_name -> appctl.ServerEndpoint
+	12, // 14: appctl.ClientProfile.multiplexing:type_name -> appctl.MultiplexingConfig
+	8,  // 15: appctl.ClientProfile.websocket:type_name -> appctl.ClientWebSocketConfig
+	24, // 16: appctl.ClientProfile.retransmissionLimit:type_name -> appctl.RetransmissionLimit
+	25, // 17: appctl.ClientProfile.congestionControl:type_name -> appctl.CongestionControl
+	9,  // 18: appctl.ClientProfile.tls:type_name -> appctl.ClientTLSConfig
+	26, // 19: appctl.ClientProfile.flowControl:type_name -> appctl.FlowControlConfig
+	10, // 20: appctl.ClientProfile.idleTimeout:type_name -> appctl.IdleTimeout
+	7,  // 21: appctl.ClientProfile.hosts:type_name -> appctl.HostMapping
+	11, // 22: appctl.ClientProfile.rekey:type_name -> appctl.RekeyConfig
+	1,  // 23: appctl.ClientProfile.endpointPolicy:type_name -> appctl.EndpointPolicy
+	27, // 24: appctl.ClientProfile.fairness:type_name -> appctl.Fairness
+	2,  // 25: appctl.ClientProfile.preset:type_name -> appctl.ProfilePreset
+	0,  // 26: appctl.MultiplexingConfig.level:type_name -> appctl.MultiplexingLevel
+	28, // 27: appctl.PortForward.protocol:type_name -> appctl.TransportProtocol
+	28, // [28:28] is the sub-list for method output_type
+	28, // [28:28] is the sub-list for method input_type
+	28, // [28:28] is the sub-list for extension type_name
+	28, // [28:28] is the sub-list for extension extendee
+	0,  // [0:28] is the sub-list for field type_name
 }
 
 func init() { file_clientcfg_proto_init() }
@@ -539,7 +1968,7 @@ func file_clientcfg_proto_init() {
 			}
 		}
 		file_clientcfg_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ClientProfile); i {
+			switch v := v.(*AutoRouteConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -551,7 +1980,7 @@ func file_clientcfg_proto_init() {
 			}
 		}
 		file_clientcfg_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MultiplexingConfig); i {
+			switch v := v.(*DNSConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -563,6 +1992,90 @@ func file_clientcfg_proto_init() {
 			}
 		}
 		file_clientcfg_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClientProfile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HostMapping); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClientWebSocketConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClientTLSConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IdleTimeout); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RekeyConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultiplexingConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ClientAdvancedSettings); i {
 			case 0:
 				return &v.state
@@ -574,17 +2087,63 @@ func file_clientcfg_proto_init() {
 				return nil
 			}
 		}
+		file_clientcfg_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PortForward); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReverseForward); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_clientcfg_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProfileSchedule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_clientcfg_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	file_clientcfg_proto_msgTypes[1].OneofWrappers = []interface{}{}
 	file_clientcfg_proto_msgTypes[2].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[3].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[4].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[6].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[7].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[8].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[9].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[11].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[12].OneofWrappers = []interface{}{}
+	file_clientcfg_proto_msgTypes[13].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_clientcfg_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   4,
+			NumEnums:      3,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
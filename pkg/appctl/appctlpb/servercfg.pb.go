@@ -35,11 +35,120 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ReplayCachePolicy int32
+
+const (
+	// Use the default policy, which is ROTATE.
+	ReplayCachePolicy_DEFAULT_REPLAY_CACHE_POLICY ReplayCachePolicy = 0
+	// Keep entries in two generations. When the newer generation is full,
+	// the older generation is dropped. It uses the least memory.
+	ReplayCachePolicy_ROTATE ReplayCachePolicy = 1
+	// Remove the least recently seen entry when the cache is full.
+	ReplayCachePolicy_LRU ReplayCachePolicy = 2
+)
+
+// Enum value maps for ReplayCachePolicy.
+var (
+	ReplayCachePolicy_name = map[int32]string{
+		0: "DEFAULT_REPLAY_CACHE_POLICY",
+		1: "ROTATE",
+		2: "LRU",
+	}
+	ReplayCachePolicy_value = map[string]int32{
+		"DEFAULT_REPLAY_CACHE_POLICY": 0,
+		"ROTATE":                      1,
+		"LRU":                         2,
+	}
+)
+
+func (x ReplayCachePolicy) Enum() *ReplayCachePolicy {
+	p := new(ReplayCachePolicy)
+	*p = x
+	return p
+}
+
+func (x ReplayCachePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReplayCachePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_servercfg_proto_enumTypes[0].Descriptor()
+}
+
+func (ReplayCachePolicy) Type() protoreflect.EnumType {
+	return &file_servercfg_proto_enumTypes[0]
+}
+
+func (x ReplayCachePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReplayCachePolicy.Descriptor instead.
+func (ReplayCachePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{0}
+}
+
+type SessionEngineType int32
+
+const (
+	// Use the default engine, which is GOROUTINE.
+	SessionEngineType_DEFAULT_SESSION_ENGINE SessionEngineType = 0
+	// Each session runs an input goroutine and an output goroutine.
+	SessionEngineType_GOROUTINE SessionEngineType = 1
+	// Sessions are run by a shared pool of workers when they have events
+	// to process. It uses less memory when there are a lot of sessions.
+	SessionEngineType_EVENT_DRIVEN SessionEngineType = 2
+)
+
+// Enum value maps for SessionEngineType.
+var (
+	SessionEngineType_name = map[int32]string{
+		0: "DEFAULT_SESSION_ENGINE",
+		1: "GOROUTINE",
+		2: "EVENT_DRIVEN",
+	}
+	SessionEngineType_value = map[string]int32{
+		"DEFAULT_SESSION_ENGINE": 0,
+		"GOROUTINE":              1,
+		"EVENT_DRIVEN":           2,
+	}
+)
+
+func (x SessionEngineType) Enum() *SessionEngineType {
+	p := new(SessionEngineType)
+	*p = x
+	return p
+}
+
+func (x SessionEngineType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionEngineType) Descriptor() protoreflect.EnumDescriptor {
+	return file_servercfg_proto_enumTypes[1].Descriptor()
+}
+
+func (SessionEngineType) Type() protoreflect.EnumType {
+	return &file_servercfg_proto_enumTypes[1]
+}
+
+func (x SessionEngineType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionEngineType.Descriptor instead.
+func (SessionEngineType) EnumDescriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{1}
+}
+
 type ProxyProtocol int32
 
 const (
 	ProxyProtocol_UNKNOWN_PROXY_PROTOCOL ProxyProtocol = 0
 	ProxyProtocol_SOCKS5_PROXY_PROTOCOL  ProxyProtocol = 1
+	// HTTP proxy that supports the CONNECT method.
+	// It only forwards TCP connections.
+	ProxyProtocol_HTTP_PROXY_PROTOCOL ProxyProtocol = 2
 )
 
 // Enum value maps for ProxyProtocol.
@@ -47,10 +156,12 @@ var (
 	ProxyProtocol_name = map[int32]string{
 		0: "UNKNOWN_PROXY_PROTOCOL",
 		1: "SOCKS5_PROXY_PROTOCOL",
+		2: "HTTP_PROXY_PROTOCOL",
 	}
 	ProxyProtocol_value = map[string]int32{
 		"UNKNOWN_PROXY_PROTOCOL": 0,
 		"SOCKS5_PROXY_PROTOCOL":  1,
+		"HTTP_PROXY_PROTOCOL":    2,
 	}
 )
 
@@ -65,11 +176,11 @@ func (x ProxyProtocol) String() string {
 }
 
 func (ProxyProtocol) Descriptor() protoreflect.EnumDescriptor {
-	return file_servercfg_proto_enumTypes[0].Descriptor()
+	return file_servercfg_proto_enumTypes[2].Descriptor()
 }
 
 func (ProxyProtocol) Type() protoreflect.EnumType {
-	return &file_servercfg_proto_enumTypes[0]
+	return &file_servercfg_proto_enumTypes[2]
 }
 
 func (x ProxyProtocol) Number() protoreflect.EnumNumber {
@@ -78,7 +189,7 @@ func (x ProxyProtocol) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ProxyProtocol.Descriptor instead.
 func (ProxyProtocol) EnumDescriptor() ([]byte, []int) {
-	return file_servercfg_proto_rawDescGZIP(), []int{0}
+	return file_servercfg_proto_rawDescGZIP(), []int{2}
 }
 
 type EgressAction int32
@@ -117,11 +228,11 @@ func (x EgressAction) String() string {
 }
 
 func (EgressAction) Descriptor() protoreflect.EnumDescriptor {
-	return file_servercfg_proto_enumTypes[1].Descriptor()
+	return file_servercfg_proto_enumTypes[3].Descriptor()
 }
 
 func (EgressAction) Type() protoreflect.EnumType {
-	return &file_servercfg_proto_enumTypes[1]
+	return &file_servercfg_proto_enumTypes[3]
 }
 
 func (x EgressAction) Number() protoreflect.EnumNumber {
@@ -130,7 +241,55 @@ func (x EgressAction) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use EgressAction.Descriptor instead.
 func (EgressAction) EnumDescriptor() ([]byte, []int) {
-	return file_servercfg_proto_rawDescGZIP(), []int{1}
+	return file_servercfg_proto_rawDescGZIP(), []int{3}
+}
+
+type ACLAction int32
+
+const (
+	// Connect to the destination.
+	ACLAction_ALLOW ACLAction = 0
+	// Reject the request with socks5 reply "connection not allowed by ruleset".
+	ACLAction_DENY ACLAction = 1
+)
+
+// Enum value maps for ACLAction.
+var (
+	ACLAction_name = map[int32]string{
+		0: "ALLOW",
+		1: "DENY",
+	}
+	ACLAction_value = map[string]int32{
+		"ALLOW": 0,
+		"DENY":  1,
+	}
+)
+
+func (x ACLAction) Enum() *ACLAction {
+	p := new(ACLAction)
+	*p = x
+	return p
+}
+
+func (x ACLAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ACLAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_servercfg_proto_enumTypes[4].Descriptor()
+}
+
+func (ACLAction) Type() protoreflect.EnumType {
+	return &file_servercfg_proto_enumTypes[4]
+}
+
+func (x ACLAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ACLAction.Descriptor instead.
+func (ACLAction) EnumDescriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{4}
 }
 
 type ServerConfig struct {
@@ -151,6 +310,80 @@ type ServerConfig struct {
 	Mtu *int32 `protobuf:"varint,5,opt,name=mtu,proto3,oneof" json:"mtu,omitempty"`
 	// Egress proxies and rules.
 	Egress *Egress `protobuf:"bytes,6,opt,name=egress,proto3,oneof" json:"egress,omitempty"`
+	// Users that are allowed to expose client side services on server ports.
+	// If the list is empty, reverse tunnel is disabled.
+	ReverseTunnels []*ReverseTunnel `protobuf:"bytes,7,rep,name=reverseTunnels,proto3" json:"reverseTunnels,omitempty"`
+	// Accept TCP connections with WebSocket, so the server can be
+	// placed behind a CDN. This setting doesn't apply to UDP protocol.
+	Websocket *ServerWebSocketConfig `protobuf:"bytes,8,opt,name=websocket,proto3,oneof" json:"websocket,omitempty"`
+	// Send one parity segment for every fecGroupSize data segments, so the
+	// client can recover a lost segment without waiting for retransmission.
+	// The value must be 0 (disabled) or between 2 and 64.
+	// This setting only applies to UDP protocol egress traffic.
+	FecGroupSize *int32 `protobuf:"varint,9,opt,name=fecGroupSize,proto3,oneof" json:"fecGroupSize,omitempty"`
+	// If a segment is not acknowledged by the client within the limit,
+	// the connection is closed with an error.
+	// This setting only applies to UDP protocol.
+	RetransmissionLimit *RetransmissionLimit `protobuf:"bytes,10,opt,name=retransmissionLimit,proto3,oneof" json:"retransmissionLimit,omitempty"`
+	// Congestion control algorithm used to send data to the clients.
+	// This setting only applies to UDP protocol.
+	CongestionControl *CongestionControl `protobuf:"varint,11,opt,name=congestionControl,proto3,enum=appctl.CongestionControl,oneof" json:"congestionControl,omitempty"`
+	// Accept TCP connections with TLS, so the traffic looks like HTTPS.
+	// It can't be used together with WebSocket.
+	// This setting doesn't apply to UDP protocol.
+	Tls *ServerTLSConfig `protobuf:"bytes,12,opt,name=tls,proto3,oneof" json:"tls,omitempty"`
+	// Send packets with UDP segmentation offload, and receive packets
+	// with generic receive offload, if they are supported by the kernel.
+	// This setting only applies to UDP protocol on Linux.
+	UdpOffload *bool `protobuf:"varint,13,opt,name=udpOffload,proto3,oneof" json:"udpOffload,omitempty"`
+	// Size, expiry and eviction policy of the caches that detect
+	// replayed packets. If it is not set, the default values are used.
+	ReplayCache *ReplayCacheConfig `protobuf:"bytes,14,opt,name=replayCache,proto3,oneof" json:"replayCache,omitempty"`
+	// Close a proxy connection after it transfers more bytes than the cap.
+	// This setting doesn't apply to socks5 UDP associate and reverse tunnels.
+	TransferCap *TransferCap `protobuf:"bytes,15,opt,name=transferCap,proto3,oneof" json:"transferCap,omitempty"`
+	// Aggregate the traffic of proxy connections by user and destination.
+	// It is disabled by default.
+	DestinationStats *ServerDestinationStatsConfig `protobuf:"bytes,16,opt,name=destinationStats,proto3,oneof" json:"destinationStats,omitempty"`
+	// Append a checksum to UDP packets, and tell whether a packet that
+	// fails to decrypt is corrupted in transit or encrypted with a wrong key.
+	// This setting only applies to UDP protocol.
+	IntegrityDiagnostic *bool `protobuf:"varint,17,opt,name=integrityDiagnostic,proto3,oneof" json:"integrityDiagnostic,omitempty"`
+	// The port mita is listening in localhost to serve debug HTTP
+	// endpoints. The metrics are available at "/debug/vars" in expvar
+	// format. If it is not set, the debug HTTP server is disabled.
+	DebugHttpPort *int32 `protobuf:"varint,18,opt,name=debugHttpPort,proto3,oneof" json:"debugHttpPort,omitempty"`
+	// Allow or deny the destinations that the server connects to on behalf
+	// of the clients. It is checked after the domain name of a destination
+	// is resolved. If it is not set, all the destinations are allowed.
+	DestinationACL *DestinationACL `protobuf:"bytes,19,opt,name=destinationACL,proto3,oneof" json:"destinationACL,omitempty"`
+	// Keep the latency of interactive connections bounded while bulk
+	// transfer saturates a shared network connection.
+	// If it is not set, the default values are used.
+	Fairness *Fairness `protobuf:"bytes,20,opt,name=fairness,proto3,oneof" json:"fairness,omitempty"`
+	// How the server runs the sessions of the clients.
+	// If it is not set, each session runs its own goroutines.
+	SessionEngine *SessionEngineConfig `protobuf:"bytes,21,opt,name=sessionEngine,proto3,oneof" json:"sessionEngine,omitempty"`
+	// Serve the TCP connections that fail authentication with a decoy,
+	// so active probers see a web server instead of a closed connection.
+	// This setting doesn't apply to UDP protocol and WebSocket.
+	Decoy *DecoyConfig `protobuf:"bytes,22,opt,name=decoy,proto3,oneof" json:"decoy,omitempty"`
+	// Limit the underlays and handshakes of each client IP address,
+	// to protect the server from resource exhaustion and brute-force probing.
+	SourceLimit *SourceLimitConfig `protobuf:"bytes,23,opt,name=sourceLimit,proto3,oneof" json:"sourceLimit,omitempty"`
+	// Server log format.
+	LogFormat *LogFormat `protobuf:"varint,24,opt,name=logFormat,proto3,enum=appctl.LogFormat,oneof" json:"logFormat,omitempty"`
+	// Serve the management API in HTTP and JSON on the UNIX domain socket
+	// "/var/run/mita-http.sock". The socket belongs to root:mita with
+	// permission 770, the same as the RPC socket. It has the status,
+	// config, metrics and connections of the server. If it is not set,
+	// the management API is only available from RPC.
+	ManagementHttp *bool `protobuf:"varint,25,opt,name=managementHttp,proto3,oneof" json:"managementHttp,omitempty"`
+	// Export a trace span of each proxy connection to OpenTelemetry.
+	// If it is not set, tracing is disabled.
+	Tracing *TracingConfig `protobuf:"bytes,26,opt,name=tracing,proto3,oneof" json:"tracing,omitempty"`
+	// Flow control settings of sessions.
+	FlowControl *FlowControlConfig `protobuf:"bytes,27,opt,name=flowControl,proto3,oneof" json:"flowControl,omitempty"`
 }
 
 func (x *ServerConfig) Reset() {
@@ -227,18 +460,168 @@ func (x *ServerConfig) GetEgress() *Egress {
 	return nil
 }
 
-type ServerAdvancedSettings struct {
+func (x *ServerConfig) GetReverseTunnels() []*ReverseTunnel {
+	if x != nil {
+		return x.ReverseTunnels
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetWebsocket() *ServerWebSocketConfig {
+	if x != nil {
+		return x.Websocket
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetFecGroupSize() int32 {
+	if x != nil && x.FecGroupSize != nil {
+		return *x.FecGroupSize
+	}
+	return 0
+}
+
+func (x *ServerConfig) GetRetransmissionLimit() *RetransmissionLimit {
+	if x != nil {
+		return x.RetransmissionLimit
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetCongestionControl() CongestionControl {
+	if x != nil && x.CongestionControl != nil {
+		return *x.CongestionControl
+	}
+	return CongestionControl_DEFAULT_CONGESTION_CONTROL
+}
+
+func (x *ServerConfig) GetTls() *ServerTLSConfig {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetUdpOffload() bool {
+	if x != nil && x.UdpOffload != nil {
+		return *x.UdpOffload
+	}
+	return false
+}
+
+func (x *ServerConfig) GetReplayCache() *ReplayCacheConfig {
+	if x != nil {
+		return x.ReplayCache
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetTransferCap() *TransferCap {
+	if x != nil {
+		return x.TransferCap
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetDestinationStats() *ServerDestinationStatsConfig {
+	if x != nil {
+		return x.DestinationStats
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetIntegrityDiagnostic() bool {
+	if x != nil && x.IntegrityDiagnostic != nil {
+		return *x.IntegrityDiagnostic
+	}
+	return false
+}
+
+func (x *ServerConfig) GetDebugHttpPort() int32 {
+	if x != nil && x.DebugHttpPort != nil {
+		return *x.DebugHttpPort
+	}
+	return 0
+}
+
+func (x *ServerConfig) GetDestinationACL() *DestinationACL {
+	if x != nil {
+		return x.DestinationACL
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetFairness() *Fairness {
+	if x != nil {
+		return x.Fairness
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetSessionEngine() *SessionEngineConfig {
+	if x != nil {
+		return x.SessionEngine
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetDecoy() *DecoyConfig {
+	if x != nil {
+		return x.Decoy
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetSourceLimit() *SourceLimitConfig {
+	if x != nil {
+		return x.SourceLimit
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetLogFormat() LogFormat {
+	if x != nil && x.LogFormat != nil {
+		return *x.LogFormat
+	}
+	return LogFormat_DEFAULT_LOG_FORMAT
+}
+
+func (x *ServerConfig) GetManagementHttp() bool {
+	if x != nil && x.ManagementHttp != nil {
+		return *x.ManagementHttp
+	}
+	return false
+}
+
+func (x *ServerConfig) GetTracing() *TracingConfig {
+	if x != nil {
+		return x.Tracing
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetFlowControl() *FlowControlConfig {
+	if x != nil {
+		return x.FlowControl
+	}
+	return nil
+}
+
+type ServerDestinationStatsConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Allow using socks5 to access resources served in localhost.
-	// This option should be set to false unless required due to testing purpose.
-	AllowLocalDestination *bool `protobuf:"varint,1,opt,name=allowLocalDestination,proto3,oneof" json:"allowLocalDestination,omitempty"`
+	// Turn on the statistics.
+	Enable *bool `protobuf:"varint,1,opt,name=enable,proto3,oneof" json:"enable,omitempty"`
+	// Replace destinations with keyed hashes, so the traffic pattern of
+	// a user is visible without revealing the destinations. The key is
+	// generated when mita server starts.
+	HashDestinations *bool `protobuf:"varint,2,opt,name=hashDestinations,proto3,oneof" json:"hashDestinations,omitempty"`
 }
 
-func (x *ServerAdvancedSettings) Reset() {
-	*x = ServerAdvancedSettings{}
+func (x *ServerDestinationStatsConfig) Reset() {
+	*x = ServerDestinationStatsConfig{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_servercfg_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -246,13 +629,13 @@ func (x *ServerAdvancedSettings) Reset() {
 	}
 }
 
-func (x *ServerAdvancedSettings) String() string {
+func (x *ServerDestinationStatsConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ServerAdvancedSettings) ProtoMessage() {}
+func (*ServerDestinationStatsConfig) ProtoMessage() {}
 
-func (x *ServerAdvancedSettings) ProtoReflect() protoreflect.Message {
+func (x *ServerDestinationStatsConfig) ProtoReflect() protoreflect.Message {
 	mi := &file_servercfg_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -264,32 +647,37 @@ func (x *ServerAdvancedSettings) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ServerAdvancedSettings.ProtoReflect.Descriptor instead.
-func (*ServerAdvancedSettings) Descriptor() ([]byte, []int) {
+// Deprecated: Use ServerDestinationStatsConfig.ProtoReflect.Descriptor instead.
+func (*ServerDestinationStatsConfig) Descriptor() ([]byte, []int) {
 	return file_servercfg_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *ServerAdvancedSettings) GetAllowLocalDestination() bool {
-	if x != nil && x.AllowLocalDestination != nil {
-		return *x.AllowLocalDestination
+func (x *ServerDestinationStatsConfig) GetEnable() bool {
+	if x != nil && x.Enable != nil {
+		return *x.Enable
 	}
 	return false
 }
 
-type Egress struct {
+func (x *ServerDestinationStatsConfig) GetHashDestinations() bool {
+	if x != nil && x.HashDestinations != nil {
+		return *x.HashDestinations
+	}
+	return false
+}
+
+type ServerAdvancedSettings struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// A list of proxies.
-	Proxies []*EgressProxy `protobuf:"bytes,1,rep,name=proxies,proto3" json:"proxies,omitempty"`
-	// A list of rules.
-	// If no rule is matched, the default action is DIRECT.
-	Rules []*EgressRule `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	// Allow using socks5 to access resources served in localhost.
+	// This option should be set to false unless required due to testing purpose.
+	AllowLocalDestination *bool `protobuf:"varint,1,opt,name=allowLocalDestination,proto3,oneof" json:"allowLocalDestination,omitempty"`
 }
 
-func (x *Egress) Reset() {
-	*x = Egress{}
+func (x *ServerAdvancedSettings) Reset() {
+	*x = ServerAdvancedSettings{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_servercfg_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -297,13 +685,13 @@ func (x *Egress) Reset() {
 	}
 }
 
-func (x *Egress) String() string {
+func (x *ServerAdvancedSettings) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Egress) ProtoMessage() {}
+func (*ServerAdvancedSettings) ProtoMessage() {}
 
-func (x *Egress) ProtoReflect() protoreflect.Message {
+func (x *ServerAdvancedSettings) ProtoReflect() protoreflect.Message {
 	mi := &file_servercfg_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -315,43 +703,36 @@ func (x *Egress) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Egress.ProtoReflect.Descriptor instead.
-func (*Egress) Descriptor() ([]byte, []int) {
+// Deprecated: Use ServerAdvancedSettings.ProtoReflect.Descriptor instead.
+func (*ServerAdvancedSettings) Descriptor() ([]byte, []int) {
 	return file_servercfg_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *Egress) GetProxies() []*EgressProxy {
-	if x != nil {
-		return x.Proxies
-	}
-	return nil
-}
-
-func (x *Egress) GetRules() []*EgressRule {
-	if x != nil {
-		return x.Rules
+func (x *ServerAdvancedSettings) GetAllowLocalDestination() bool {
+	if x != nil && x.AllowLocalDestination != nil {
+		return *x.AllowLocalDestination
 	}
-	return nil
+	return false
 }
 
-type EgressProxy struct {
+type ReverseTunnel struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name     *string        `protobuf:"bytes,1,opt,name=name,proto3,oneof" json:"name,omitempty"`
-	Protocol *ProxyProtocol `protobuf:"varint,2,opt,name=protocol,proto3,enum=appctl.ProxyProtocol,oneof" json:"protocol,omitempty"`
-	// Proxy IP address or domain name.
-	Host *string `protobuf:"bytes,3,opt,name=host,proto3,oneof" json:"host,omitempty"`
-	// Proxy port number.
-	Port *int32 `protobuf:"varint,4,opt,name=port,proto3,oneof" json:"port,omitempty"`
-	// Credential to authenticate egress socks5 proxy.
-	// If the proxy protocol is not socks5, this is ignored.
-	Socks5Authentication *Auth `protobuf:"bytes,5,opt,name=socks5Authentication,proto3,oneof" json:"socks5Authentication,omitempty"`
+	// The user that can expose client side services.
+	UserName *string `protobuf:"bytes,1,opt,name=userName,proto3,oneof" json:"userName,omitempty"`
+	// The range of server TCP ports that the user can expose,
+	// in "start-end" format. Use "port-port" for a single port.
+	// The range must not overlap with server port bindings.
+	PortRange *string `protobuf:"bytes,2,opt,name=portRange,proto3,oneof" json:"portRange,omitempty"`
+	// Maximum number of server ports that the user can expose at the same time.
+	// If it is 0, the number is only limited by the port range.
+	MaxExposedPorts *int32 `protobuf:"varint,3,opt,name=maxExposedPorts,proto3,oneof" json:"maxExposedPorts,omitempty"`
 }
 
-func (x *EgressProxy) Reset() {
-	*x = EgressProxy{}
+func (x *ReverseTunnel) Reset() {
+	*x = ReverseTunnel{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_servercfg_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -359,14 +740,889 @@ func (x *EgressProxy) Reset() {
 	}
 }
 
-func (x *EgressProxy) String() string {
+func (x *ReverseTunnel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseTunnel) ProtoMessage() {}
+
+func (x *ReverseTunnel) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseTunnel.ProtoReflect.Descriptor instead.
+func (*ReverseTunnel) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReverseTunnel) GetUserName() string {
+	if x != nil && x.UserName != nil {
+		return *x.UserName
+	}
+	return ""
+}
+
+func (x *ReverseTunnel) GetPortRange() string {
+	if x != nil && x.PortRange != nil {
+		return *x.PortRange
+	}
+	return ""
+}
+
+func (x *ReverseTunnel) GetMaxExposedPorts() int32 {
+	if x != nil && x.MaxExposedPorts != nil {
+		return *x.MaxExposedPorts
+	}
+	return 0
+}
+
+type ServerWebSocketConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The HTTP path of the WebSocket endpoint. Default is "/".
+	Path *string `protobuf:"bytes,1,opt,name=path,proto3,oneof" json:"path,omitempty"`
+	// The TLS certificate file in PEM format.
+	// If both certificate file and key file are not set,
+	// the server accepts WebSocket without TLS.
+	CertFile *string `protobuf:"bytes,2,opt,name=certFile,proto3,oneof" json:"certFile,omitempty"`
+	// The TLS private key file in PEM format.
+	KeyFile *string `protobuf:"bytes,3,opt,name=keyFile,proto3,oneof" json:"keyFile,omitempty"`
+}
+
+func (x *ServerWebSocketConfig) Reset() {
+	*x = ServerWebSocketConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServerWebSocketConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerWebSocketConfig) ProtoMessage() {}
+
+func (x *ServerWebSocketConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerWebSocketConfig.ProtoReflect.Descriptor instead.
+func (*ServerWebSocketConfig) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ServerWebSocketConfig) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *ServerWebSocketConfig) GetCertFile() string {
+	if x != nil && x.CertFile != nil {
+		return *x.CertFile
+	}
+	return ""
+}
+
+func (x *ServerWebSocketConfig) GetKeyFile() string {
+	if x != nil && x.KeyFile != nil {
+		return *x.KeyFile
+	}
+	return ""
+}
+
+type ServerTLSConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The TLS certificate file in PEM format.
+	CertFile *string `protobuf:"bytes,1,opt,name=certFile,proto3,oneof" json:"certFile,omitempty"`
+	// The TLS private key file in PEM format.
+	KeyFile *string `protobuf:"bytes,2,opt,name=keyFile,proto3,oneof" json:"keyFile,omitempty"`
+}
+
+func (x *ServerTLSConfig) Reset() {
+	*x = ServerTLSConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServerTLSConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerTLSConfig) ProtoMessage() {}
+
+func (x *ServerTLSConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerTLSConfig.ProtoReflect.Descriptor instead.
+func (*ServerTLSConfig) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ServerTLSConfig) GetCertFile() string {
+	if x != nil && x.CertFile != nil {
+		return *x.CertFile
+	}
+	return ""
+}
+
+func (x *ServerTLSConfig) GetKeyFile() string {
+	if x != nil && x.KeyFile != nil {
+		return *x.KeyFile
+	}
+	return ""
+}
+
+type DecoyConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Address of the decoy service in "host:port" format, for example
+	// "127.0.0.1:8443". The connection is relayed to this address.
+	Address *string `protobuf:"bytes,1,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	// A HTML file returned in a HTTP response if address is not set.
+	PageFile *string `protobuf:"bytes,2,opt,name=pageFile,proto3,oneof" json:"pageFile,omitempty"`
+}
+
+func (x *DecoyConfig) Reset() {
+	*x = DecoyConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecoyConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecoyConfig) ProtoMessage() {}
+
+func (x *DecoyConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecoyConfig.ProtoReflect.Descriptor instead.
+func (*DecoyConfig) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DecoyConfig) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *DecoyConfig) GetPageFile() string {
+	if x != nil && x.PageFile != nil {
+		return *x.PageFile
+	}
+	return ""
+}
+
+type SourceLimitConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of concurrent TCP underlays of a client IP address.
+	// If it is 0, there is no limit.
+	MaxUnderlays *int32 `protobuf:"varint,1,opt,name=maxUnderlays,proto3,oneof" json:"maxUnderlays,omitempty"`
+	// Maximum number of TCP connections and UDP handshakes of a client
+	// IP address in a minute. If it is 0, there is no limit.
+	MaxHandshakesPerMinute *int32 `protobuf:"varint,2,opt,name=maxHandshakesPerMinute,proto3,oneof" json:"maxHandshakesPerMinute,omitempty"`
+	// Number of seconds to block a client IP address after it exceeds
+	// a limit. The penalty doubles each time the limit is exceeded again.
+	// If it is 0, the default value 10 is used.
+	PenaltySeconds *int32 `protobuf:"varint,3,opt,name=penaltySeconds,proto3,oneof" json:"penaltySeconds,omitempty"`
+	// Maximum number of seconds to block a client IP address.
+	// If it is 0, the default value 3600 is used.
+	// The value must not be smaller than penaltySeconds.
+	MaxPenaltySeconds *int32 `protobuf:"varint,4,opt,name=maxPenaltySeconds,proto3,oneof" json:"maxPenaltySeconds,omitempty"`
+}
+
+func (x *SourceLimitConfig) Reset() {
+	*x = SourceLimitConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SourceLimitConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceLimitConfig) ProtoMessage() {}
+
+func (x *SourceLimitConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceLimitConfig.ProtoReflect.Descriptor instead.
+func (*SourceLimitConfig) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SourceLimitConfig) GetMaxUnderlays() int32 {
+	if x != nil && x.MaxUnderlays != nil {
+		return *x.MaxUnderlays
+	}
+	return 0
+}
+
+func (x *SourceLimitConfig) GetMaxHandshakesPerMinute() int32 {
+	if x != nil && x.MaxHandshakesPerMinute != nil {
+		return *x.MaxHandshakesPerMinute
+	}
+	return 0
+}
+
+func (x *SourceLimitConfig) GetPenaltySeconds() int32 {
+	if x != nil && x.PenaltySeconds != nil {
+		return *x.PenaltySeconds
+	}
+	return 0
+}
+
+func (x *SourceLimitConfig) GetMaxPenaltySeconds() int32 {
+	if x != nil && x.MaxPenaltySeconds != nil {
+		return *x.MaxPenaltySeconds
+	}
+	return 0
+}
+
+type ReplayCacheConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of entries in one replay cache.
+	// If it is 0, the default value 4194304 is used.
+	// The value must be between 1024 and 67108864.
+	Capacity *int32 `protobuf:"varint,1,opt,name=capacity,proto3,oneof" json:"capacity,omitempty"`
+	// Number of seconds to remember a packet.
+	// If it is 0, the default value is used.
+	// The value must be between 60 and 3600.
+	ExpireSeconds *int32 `protobuf:"varint,2,opt,name=expireSeconds,proto3,oneof" json:"expireSeconds,omitempty"`
+	// Which entries are removed from a full replay cache.
+	Policy *ReplayCachePolicy `protobuf:"varint,3,opt,name=policy,proto3,enum=appctl.ReplayCachePolicy,oneof" json:"policy,omitempty"`
+}
+
+func (x *ReplayCacheConfig) Reset() {
+	*x = ReplayCacheConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReplayCacheConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayCacheConfig) ProtoMessage() {}
+
+func (x *ReplayCacheConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayCacheConfig.ProtoReflect.Descriptor instead.
+func (*ReplayCacheConfig) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReplayCacheConfig) GetCapacity() int32 {
+	if x != nil && x.Capacity != nil {
+		return *x.Capacity
+	}
+	return 0
+}
+
+func (x *ReplayCacheConfig) GetExpireSeconds() int32 {
+	if x != nil && x.ExpireSeconds != nil {
+		return *x.ExpireSeconds
+	}
+	return 0
+}
+
+func (x *ReplayCacheConfig) GetPolicy() ReplayCachePolicy {
+	if x != nil && x.Policy != nil {
+		return *x.Policy
+	}
+	return ReplayCachePolicy_DEFAULT_REPLAY_CACHE_POLICY
+}
+
+type SessionEngineConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The type of the session engine.
+	Type *SessionEngineType `protobuf:"varint,1,opt,name=type,proto3,enum=appctl.SessionEngineType,oneof" json:"type,omitempty"`
+	// Number of workers of the event driven engine.
+	// If it is 0, the default value, 16 times the number of CPUs, is used.
+	// The value must be between 1 and 65536.
+	Workers *int32 `protobuf:"varint,2,opt,name=workers,proto3,oneof" json:"workers,omitempty"`
+}
+
+func (x *SessionEngineConfig) Reset() {
+	*x = SessionEngineConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SessionEngineConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEngineConfig) ProtoMessage() {}
+
+func (x *SessionEngineConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEngineConfig.ProtoReflect.Descriptor instead.
+func (*SessionEngineConfig) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SessionEngineConfig) GetType() SessionEngineType {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return SessionEngineType_DEFAULT_SESSION_ENGINE
+}
+
+func (x *SessionEngineConfig) GetWorkers() int32 {
+	if x != nil && x.Workers != nil {
+		return *x.Workers
+	}
+	return 0
+}
+
+type Egress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// A list of proxies.
+	Proxies []*EgressProxy `protobuf:"bytes,1,rep,name=proxies,proto3" json:"proxies,omitempty"`
+	// A list of rules. The first matched rule decides the action.
+	// If no rule is matched, the default action is DIRECT.
+	Rules []*EgressRule `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	// Log the matched rule of each request at INFO level.
+	// Otherwise, it is logged at DEBUG level.
+	LogDecisions *bool `protobuf:"varint,3,opt,name=logDecisions,proto3,oneof" json:"logDecisions,omitempty"`
+}
+
+func (x *Egress) Reset() {
+	*x = Egress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Egress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Egress) ProtoMessage() {}
+
+func (x *Egress) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Egress.ProtoReflect.Descriptor instead.
+func (*Egress) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Egress) GetProxies() []*EgressProxy {
+	if x != nil {
+		return x.Proxies
+	}
+	return nil
+}
+
+func (x *Egress) GetRules() []*EgressRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *Egress) GetLogDecisions() bool {
+	if x != nil && x.LogDecisions != nil {
+		return *x.LogDecisions
+	}
+	return false
+}
+
+type EgressProxy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     *string        `protobuf:"bytes,1,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Protocol *ProxyProtocol `protobuf:"varint,2,opt,name=protocol,proto3,enum=appctl.ProxyProtocol,oneof" json:"protocol,omitempty"`
+	// Proxy IP address or domain name.
+	Host *string `protobuf:"bytes,3,opt,name=host,proto3,oneof" json:"host,omitempty"`
+	// Proxy port number.
+	Port *int32 `protobuf:"varint,4,opt,name=port,proto3,oneof" json:"port,omitempty"`
+	// Credential to authenticate egress socks5 proxy.
+	// If the proxy protocol is not socks5, this is ignored.
+	Socks5Authentication *Auth `protobuf:"bytes,5,opt,name=socks5Authentication,proto3,oneof" json:"socks5Authentication,omitempty"`
+	// Credential to authenticate egress HTTP proxy with basic authentication.
+	// If the proxy protocol is not HTTP, this is ignored.
+	HttpAuthentication *Auth `protobuf:"bytes,6,opt,name=httpAuthentication,proto3,oneof" json:"httpAuthentication,omitempty"`
+}
+
+func (x *EgressProxy) Reset() {
+	*x = EgressProxy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EgressProxy) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
 func (*EgressProxy) ProtoMessage() {}
 
-func (x *EgressProxy) ProtoReflect() protoreflect.Message {
-	mi := &file_servercfg_proto_msgTypes[3]
+func (x *EgressProxy) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EgressProxy.ProtoReflect.Descriptor instead.
+func (*EgressProxy) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EgressProxy) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *EgressProxy) GetProtocol() ProxyProtocol {
+	if x != nil && x.Protocol != nil {
+		return *x.Protocol
+	}
+	return ProxyProtocol_UNKNOWN_PROXY_PROTOCOL
+}
+
+func (x *EgressProxy) GetHost() string {
+	if x != nil && x.Host != nil {
+		return *x.Host
+	}
+	return ""
+}
+
+func (x *EgressProxy) GetPort() int32 {
+	if x != nil && x.Port != nil {
+		return *x.Port
+	}
+	return 0
+}
+
+func (x *EgressProxy) GetSocks5Authentication() *Auth {
+	if x != nil {
+		return x.Socks5Authentication
+	}
+	return nil
+}
+
+func (x *EgressProxy) GetHttpAuthentication() *Auth {
+	if x != nil {
+		return x.HttpAuthentication
+	}
+	return nil
+}
+
+type EgressRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// A list of CIDR to match the rule, for example "10.0.0.0/8".
+	// Use "*" to match all IP addresses.
+	// Requests to domain names don't match the IP ranges.
+	IpRanges []string `protobuf:"bytes,1,rep,name=ipRanges,proto3" json:"ipRanges,omitempty"`
+	// A list of domain names to match the rule.
+	// A domain name also matches the subdomains.
+	// Use "*" to match all domain names.
+	DomainNames []string `protobuf:"bytes,2,rep,name=domainNames,proto3" json:"domainNames,omitempty"`
+	// The action to do when the rule is matched.
+	Action *EgressAction `protobuf:"varint,3,opt,name=action,proto3,enum=appctl.EgressAction,oneof" json:"action,omitempty"`
+	// The name of proxy to connect.
+	// This is required when the action is PROXY.
+	ProxyName *string `protobuf:"bytes,4,opt,name=proxyName,proto3,oneof" json:"proxyName,omitempty"`
+	// The local IP address used to connect to the destination,
+	// for example "203.0.113.2". It must be assigned to the server,
+	// and be the same IP version as the destination.
+	// This can only be set when the action is DIRECT.
+	SourceIP *string `protobuf:"bytes,5,opt,name=sourceIP,proto3,oneof" json:"sourceIP,omitempty"`
+	// The network interface used to connect to the destination,
+	// for example "eth1". It is only supported on Linux.
+	// This can only be set when the action is DIRECT.
+	InterfaceName *string `protobuf:"bytes,6,opt,name=interfaceName,proto3,oneof" json:"interfaceName,omitempty"`
+	// A list of files that contain more IP ranges and domain names of
+	// the rule, one in each line. A line is an IP range if it is a CIDR
+	// or an IP address, otherwise it is a domain name. Empty lines and
+	// lines starting with "#" are ignored. The files are read again
+	// when they are changed.
+	RuleFiles []string `protobuf:"bytes,7,rep,name=ruleFiles,proto3" json:"ruleFiles,omitempty"`
+}
+
+func (x *EgressRule) Reset() {
+	*x = EgressRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EgressRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EgressRule) ProtoMessage() {}
+
+func (x *EgressRule) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EgressRule.ProtoReflect.Descriptor instead.
+func (*EgressRule) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *EgressRule) GetIpRanges() []string {
+	if x != nil {
+		return x.IpRanges
+	}
+	return nil
+}
+
+func (x *EgressRule) GetDomainNames() []string {
+	if x != nil {
+		return x.DomainNames
+	}
+	return nil
+}
+
+func (x *EgressRule) GetAction() EgressAction {
+	if x != nil && x.Action != nil {
+		return *x.Action
+	}
+	return EgressAction_PROXY
+}
+
+func (x *EgressRule) GetProxyName() string {
+	if x != nil && x.ProxyName != nil {
+		return *x.ProxyName
+	}
+	return ""
+}
+
+func (x *EgressRule) GetSourceIP() string {
+	if x != nil && x.SourceIP != nil {
+		return *x.SourceIP
+	}
+	return ""
+}
+
+func (x *EgressRule) GetInterfaceName() string {
+	if x != nil && x.InterfaceName != nil {
+		return *x.InterfaceName
+	}
+	return ""
+}
+
+func (x *EgressRule) GetRuleFiles() []string {
+	if x != nil {
+		return x.RuleFiles
+	}
+	return nil
+}
+
+type DestinationACL struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// A list of rules. The first matched rule decides the action.
+	Rules []*DestinationACLRule `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	// The action if no rule is matched. The default is ALLOW.
+	DefaultAction *ACLAction `protobuf:"varint,2,opt,name=defaultAction,proto3,enum=appctl.ACLAction,oneof" json:"defaultAction,omitempty"`
+}
+
+func (x *DestinationACL) Reset() {
+	*x = DestinationACL{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DestinationACL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DestinationACL) ProtoMessage() {}
+
+func (x *DestinationACL) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DestinationACL.ProtoReflect.Descriptor instead.
+func (*DestinationACL) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DestinationACL) GetRules() []*DestinationACLRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *DestinationACL) GetDefaultAction() ACLAction {
+	if x != nil && x.DefaultAction != nil {
+		return *x.DefaultAction
+	}
+	return ACLAction_ALLOW
+}
+
+type DestinationACLRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// A list of CIDR to match the IP address of the destination,
+	// for example "10.0.0.0/8". If a domain name is resolved,
+	// the resolved IP address is matched.
+	// If the list is empty, all IP addresses are matched.
+	IpRanges []string `protobuf:"bytes,1,rep,name=ipRanges,proto3" json:"ipRanges,omitempty"`
+	// A list of domain names to match the domain name of the destination,
+	// including the sub-domains. For example, "example.com" matches
+	// "example.com" and "www.example.com". A destination that is an IP
+	// address doesn't match the rule if the list is not empty.
+	// If the list is empty, all destinations are matched.
+	DomainNames []string `protobuf:"bytes,2,rep,name=domainNames,proto3" json:"domainNames,omitempty"`
+	// A list of ports or port ranges to match the port of the destination,
+	// for example "25" or "6881-6889".
+	// If the list is empty, all ports are matched.
+	Ports []string `protobuf:"bytes,3,rep,name=ports,proto3" json:"ports,omitempty"`
+	// The action to do when the rule is matched.
+	Action *ACLAction `protobuf:"varint,4,opt,name=action,proto3,enum=appctl.ACLAction,oneof" json:"action,omitempty"`
+}
+
+func (x *DestinationACLRule) Reset() {
+	*x = DestinationACLRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DestinationACLRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DestinationACLRule) ProtoMessage() {}
+
+func (x *DestinationACLRule) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DestinationACLRule.ProtoReflect.Descriptor instead.
+func (*DestinationACLRule) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DestinationACLRule) GetIpRanges() []string {
+	if x != nil {
+		return x.IpRanges
+	}
+	return nil
+}
+
+func (x *DestinationACLRule) GetDomainNames() []string {
+	if x != nil {
+		return x.DomainNames
+	}
+	return nil
+}
+
+func (x *DestinationACLRule) GetPorts() []string {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *DestinationACLRule) GetAction() ACLAction {
+	if x != nil && x.Action != nil {
+		return *x.Action
+	}
+	return ACLAction_ALLOW
+}
+
+type RouteStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of requests matched by each rule.
+	Rules []*RuleStats `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	// Recent routing decisions, the newest first.
+	RecentDecisions []*RouteDecision `protobuf:"bytes,2,rep,name=recentDecisions,proto3" json:"recentDecisions,omitempty"`
+}
+
+func (x *RouteStats) Reset() {
+	*x = RouteStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouteStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteStats) ProtoMessage() {}
+
+func (x *RouteStats) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,81 +1633,130 @@ func (x *EgressProxy) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EgressProxy.ProtoReflect.Descriptor instead.
-func (*EgressProxy) Descriptor() ([]byte, []int) {
-	return file_servercfg_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use RouteStats.ProtoReflect.Descriptor instead.
+func (*RouteStats) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *EgressProxy) GetName() string {
-	if x != nil && x.Name != nil {
-		return *x.Name
+func (x *RouteStats) GetRules() []*RuleStats {
+	if x != nil {
+		return x.Rules
 	}
-	return ""
+	return nil
 }
 
-func (x *EgressProxy) GetProtocol() ProxyProtocol {
-	if x != nil && x.Protocol != nil {
-		return *x.Protocol
+func (x *RouteStats) GetRecentDecisions() []*RouteDecision {
+	if x != nil {
+		return x.RecentDecisions
 	}
-	return ProxyProtocol_UNKNOWN_PROXY_PROTOCOL
+	return nil
 }
 
-func (x *EgressProxy) GetHost() string {
-	if x != nil && x.Host != nil {
-		return *x.Host
+type RuleStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ID of the rule, which starts from 1 in the order of egress rules.
+	// 0 means no rule is matched and the default action is used.
+	RuleID    *int32        `protobuf:"varint,1,opt,name=ruleID,proto3,oneof" json:"ruleID,omitempty"`
+	Action    *EgressAction `protobuf:"varint,2,opt,name=action,proto3,enum=appctl.EgressAction,oneof" json:"action,omitempty"`
+	ProxyName *string       `protobuf:"bytes,3,opt,name=proxyName,proto3,oneof" json:"proxyName,omitempty"`
+	// Number of requests matched by the rule.
+	Matches *int64 `protobuf:"varint,4,opt,name=matches,proto3,oneof" json:"matches,omitempty"`
+}
+
+func (x *RuleStats) Reset() {
+	*x = RuleStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servercfg_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return ""
 }
 
-func (x *EgressProxy) GetPort() int32 {
-	if x != nil && x.Port != nil {
-		return *x.Port
+func (x *RuleStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuleStats) ProtoMessage() {}
+
+func (x *RuleStats) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuleStats.ProtoReflect.Descriptor instead.
+func (*RuleStats) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RuleStats) GetRuleID() int32 {
+	if x != nil && x.RuleID != nil {
+		return *x.RuleID
 	}
 	return 0
 }
 
-func (x *EgressProxy) GetSocks5Authentication() *Auth {
-	if x != nil {
-		return x.Socks5Authentication
+func (x *RuleStats) GetAction() EgressAction {
+	if x != nil && x.Action != nil {
+		return *x.Action
 	}
-	return nil
+	return EgressAction_PROXY
 }
 
-type EgressRule struct {
+func (x *RuleStats) GetProxyName() string {
+	if x != nil && x.ProxyName != nil {
+		return *x.ProxyName
+	}
+	return ""
+}
+
+func (x *RuleStats) GetMatches() int64 {
+	if x != nil && x.Matches != nil {
+		return *x.Matches
+	}
+	return 0
+}
+
+type RouteDecision struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// A list of CIDR to match the rule.
-	// Use "*" to match all IP addresses.
-	IpRanges []string `protobuf:"bytes,1,rep,name=ipRanges,proto3" json:"ipRanges,omitempty"`
-	// A list of domain names to match the rule.
-	// Use "*" to match all domain names.
-	DomainNames []string `protobuf:"bytes,2,rep,name=domainNames,proto3" json:"domainNames,omitempty"`
-	// The action to do when the rule is matched.
-	Action *EgressAction `protobuf:"varint,3,opt,name=action,proto3,enum=appctl.EgressAction,oneof" json:"action,omitempty"`
-	// The name of proxy to connect.
-	// This is required when the action is PROXY.
-	ProxyName *string `protobuf:"bytes,4,opt,name=proxyName,proto3,oneof" json:"proxyName,omitempty"`
+	// Time of the decision in RFC 3339 format.
+	Time *string `protobuf:"bytes,1,opt,name=time,proto3,oneof" json:"time,omitempty"`
+	// Destination host and port of the request.
+	Destination *string       `protobuf:"bytes,2,opt,name=destination,proto3,oneof" json:"destination,omitempty"`
+	Action      *EgressAction `protobuf:"varint,3,opt,name=action,proto3,enum=appctl.EgressAction,oneof" json:"action,omitempty"`
+	// ID of the matched rule, 0 if no rule is matched.
+	RuleID *int32 `protobuf:"varint,4,opt,name=ruleID,proto3,oneof" json:"ruleID,omitempty"`
 }
 
-func (x *EgressRule) Reset() {
-	*x = EgressRule{}
+func (x *RouteDecision) Reset() {
+	*x = RouteDecision{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_servercfg_proto_msgTypes[4]
+		mi := &file_servercfg_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *EgressRule) String() string {
+func (x *RouteDecision) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EgressRule) ProtoMessage() {}
+func (*RouteDecision) ProtoMessage() {}
 
-func (x *EgressRule) ProtoReflect() protoreflect.Message {
-	mi := &file_servercfg_proto_msgTypes[4]
+func (x *RouteDecision) ProtoReflect() protoreflect.Message {
+	mi := &file_servercfg_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -462,37 +1767,37 @@ func (x *EgressRule) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EgressRule.ProtoReflect.Descriptor instead.
-func (*EgressRule) Descriptor() ([]byte, []int) {
-	return file_servercfg_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use RouteDecision.ProtoReflect.Descriptor instead.
+func (*RouteDecision) Descriptor() ([]byte, []int) {
+	return file_servercfg_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *EgressRule) GetIpRanges() []string {
-	if x != nil {
-		return x.IpRanges
+func (x *RouteDecision) GetTime() string {
+	if x != nil && x.Time != nil {
+		return *x.Time
 	}
-	return nil
+	return ""
 }
 
-func (x *EgressRule) GetDomainNames() []string {
-	if x != nil {
-		return x.DomainNames
+func (x *RouteDecision) GetDestination() string {
+	if x != nil && x.Destination != nil {
+		return *x.Destination
 	}
-	return nil
+	return ""
 }
 
-func (x *EgressRule) GetAction() EgressAction {
+func (x *RouteDecision) GetAction() EgressAction {
 	if x != nil && x.Action != nil {
 		return *x.Action
 	}
 	return EgressAction_PROXY
 }
 
-func (x *EgressRule) GetProxyName() string {
-	if x != nil && x.ProxyName != nil {
-		return *x.ProxyName
+func (x *RouteDecision) GetRuleID() int32 {
+	if x != nil && x.RuleID != nil {
+		return *x.RuleID
 	}
-	return ""
+	return 0
 }
 
 var File_servercfg_proto protoreflect.FileDescriptor
@@ -500,7 +1805,7 @@ var File_servercfg_proto protoreflect.FileDescriptor
 var file_servercfg_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x63, 0x66, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x12, 0x06, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x1a, 0x0a, 0x62, 0x61, 0x73, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf8, 0x02, 0x0a, 0x0c, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa9, 0x0f, 0x0a, 0x0c, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72,
 	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x37, 0x0a, 0x0c, 0x70, 0x6f, 0x72, 0x74, 0x42, 0x69,
 	0x6e, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61,
 	0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x69, 0x6e, 0x64, 0x69, 0x6e,
@@ -520,10 +1825,118 @@ var file_servercfg_proto_rawDesc = []byte{
 	0x48, 0x02, 0x52, 0x03, 0x6d, 0x74, 0x75, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x06, 0x65, 0x67,
 	0x72, 0x65, 0x73, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x61, 0x70, 0x70,
 	0x63, 0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x48, 0x03, 0x52, 0x06, 0x65, 0x67,
-	0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x61, 0x64, 0x76, 0x61,
-	0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x42, 0x0f, 0x0a, 0x0d,
-	0x5f, 0x6c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x42, 0x06, 0x0a,
-	0x04, 0x5f, 0x6d, 0x74, 0x75, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12, 0x3d, 0x0a, 0x0e, 0x72, 0x65, 0x76, 0x65, 0x72,
+	0x73, 0x65, 0x54, 0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65,
+	0x54, 0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x0e, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x54,
+	0x75, 0x6e, 0x6e, 0x65, 0x6c, 0x73, 0x12, 0x40, 0x0a, 0x09, 0x77, 0x65, 0x62, 0x73, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x61, 0x70, 0x70, 0x63,
+	0x74, 0x6c, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x57, 0x65, 0x62, 0x53, 0x6f, 0x63, 0x6b,
+	0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x04, 0x52, 0x09, 0x77, 0x65, 0x62, 0x73,
+	0x6f, 0x63, 0x6b, 0x65, 0x74, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0c, 0x66, 0x65, 0x63, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x48, 0x05,
+	0x52, 0x0c, 0x66, 0x65, 0x63, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x53, 0x69, 0x7a, 0x65, 0x88, 0x01,
+	0x01, 0x12, 0x52, 0x0a, 0x13, 0x72, 0x65, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x48, 0x06, 0x52, 0x13, 0x72,
+	0x65, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d,
+	0x69, 0x74, 0x88, 0x01, 0x01, 0x12, 0x4c, 0x0a, 0x11, 0x63, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74,
+	0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x43, 0x6f, 0x6e, 0x67, 0x65, 0x73,
+	0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x48, 0x07, 0x52, 0x11, 0x63,
+	0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
+	0x88, 0x01, 0x01, 0x12, 0x2e, 0x0a, 0x03, 0x74, 0x6c, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x08, 0x52, 0x03, 0x74, 0x6c, 0x73,
+	0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x75, 0x64, 0x70, 0x4f, 0x66, 0x66, 0x6c, 0x6f, 0x61,
+	0x64, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x48, 0x09, 0x52, 0x0a, 0x75, 0x64, 0x70, 0x4f, 0x66,
+	0x66, 0x6c, 0x6f, 0x61, 0x64, 0x88, 0x01, 0x01, 0x12, 0x40, 0x0a, 0x0b, 0x72, 0x65, 0x70, 0x6c,
+	0x61, 0x79, 0x43, 0x61, 0x63, 0x68, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x43, 0x61, 0x63,
+	0x68, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x0a, 0x52, 0x0b, 0x72, 0x65, 0x70, 0x6c,
+	0x61, 0x79, 0x43, 0x61, 0x63, 0x68, 0x65, 0x88, 0x01, 0x01, 0x12, 0x3a, 0x0a, 0x0b, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x43, 0x61, 0x70, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65,
+	0x72, 0x43, 0x61, 0x70, 0x48, 0x0b, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x65, 0x72,
+	0x43, 0x61, 0x70, 0x88, 0x01, 0x01, 0x12, 0x55, 0x0a, 0x10, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x24, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x0c, 0x52, 0x10, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x88, 0x01, 0x01, 0x12, 0x35, 0x0a,
+	0x13, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x69, 0x74, 0x79, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f,
+	0x73, 0x74, 0x69, 0x63, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x48, 0x0d, 0x52, 0x13, 0x69, 0x6e,
+	0x74, 0x65, 0x67, 0x72, 0x69, 0x74, 0x79, 0x44, 0x69, 0x61, 0x67, 0x6e, 0x6f, 0x73, 0x74, 0x69,
+	0x63, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d, 0x64, 0x65, 0x62, 0x75, 0x67, 0x48, 0x74, 0x74,
+	0x70, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x12, 0x20, 0x01, 0x28, 0x05, 0x48, 0x0e, 0x52, 0x0d, 0x64,
+	0x65, 0x62, 0x75, 0x67, 0x48, 0x74, 0x74, 0x70, 0x50, 0x6f, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12,
+	0x43, 0x0a, 0x0e, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x43,
+	0x4c, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c,
+	0x2e, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x43, 0x4c, 0x48,
+	0x0f, 0x52, 0x0e, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x43,
+	0x4c, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a, 0x08, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e,
+	0x46, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x48, 0x10, 0x52, 0x08, 0x66, 0x61, 0x69, 0x72,
+	0x6e, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12, 0x46, 0x0a, 0x0d, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x45,
+	0x6e, 0x67, 0x69, 0x6e, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x11, 0x52, 0x0d, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x2e, 0x0a, 0x05, 0x64, 0x65, 0x63, 0x6f, 0x79, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13,
+	0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x44, 0x65, 0x63, 0x6f, 0x79, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x48, 0x12, 0x52, 0x05, 0x64, 0x65, 0x63, 0x6f, 0x79, 0x88, 0x01, 0x01, 0x12,
+	0x40, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x17,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x53, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48,
+	0x13, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x88, 0x01,
+	0x01, 0x12, 0x34, 0x0a, 0x09, 0x6c, 0x6f, 0x67, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x18,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x4c, 0x6f,
+	0x67, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x48, 0x14, 0x52, 0x09, 0x6c, 0x6f, 0x67, 0x46, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x0e, 0x6d, 0x61, 0x6e, 0x61, 0x67,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x74, 0x74, 0x70, 0x18, 0x19, 0x20, 0x01, 0x28, 0x08, 0x48,
+	0x15, 0x52, 0x0e, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x74, 0x74,
+	0x70, 0x88, 0x01, 0x01, 0x12, 0x34, 0x0a, 0x07, 0x74, 0x72, 0x61, 0x63, 0x69, 0x6e, 0x67, 0x18,
+	0x1a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x54,
+	0x72, 0x61, 0x63, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x16, 0x52, 0x07,
+	0x74, 0x72, 0x61, 0x63, 0x69, 0x6e, 0x67, 0x88, 0x01, 0x01, 0x12, 0x40, 0x0a, 0x0b, 0x66, 0x6c,
+	0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e,
+	0x74, 0x72, 0x6f, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x17, 0x52, 0x0b, 0x66, 0x6c,
+	0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x88, 0x01, 0x01, 0x42, 0x13, 0x0a, 0x11,
+	0x5f, 0x61, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67,
+	0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x6c, 0x6f, 0x67, 0x67, 0x69, 0x6e, 0x67, 0x4c, 0x65, 0x76,
+	0x65, 0x6c, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x74, 0x75, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x65,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x77, 0x65, 0x62, 0x73, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x66, 0x65, 0x63, 0x47, 0x72, 0x6f, 0x75, 0x70,
+	0x53, 0x69, 0x7a, 0x65, 0x42, 0x16, 0x0a, 0x14, 0x5f, 0x72, 0x65, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x42, 0x14, 0x0a, 0x12,
+	0x5f, 0x63, 0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x74, 0x6c, 0x73, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x75,
+	0x64, 0x70, 0x4f, 0x66, 0x66, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x72, 0x65,
+	0x70, 0x6c, 0x61, 0x79, 0x43, 0x61, 0x63, 0x68, 0x65, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x72,
+	0x61, 0x6e, 0x73, 0x66, 0x65, 0x72, 0x43, 0x61, 0x70, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x64, 0x65,
+	0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x42, 0x16,
+	0x0a, 0x14, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x69, 0x74, 0x79, 0x44, 0x69, 0x61, 0x67,
+	0x6e, 0x6f, 0x73, 0x74, 0x69, 0x63, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x64, 0x65, 0x62, 0x75, 0x67,
+	0x48, 0x74, 0x74, 0x70, 0x50, 0x6f, 0x72, 0x74, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x64, 0x65, 0x73,
+	0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x43, 0x4c, 0x42, 0x0b, 0x0a, 0x09, 0x5f,
+	0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x64,
+	0x65, 0x63, 0x6f, 0x79, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c,
+	0x69, 0x6d, 0x69, 0x74, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x6c, 0x6f, 0x67, 0x46, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x48, 0x74, 0x74, 0x70, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x74, 0x72, 0x61, 0x63, 0x69, 0x6e,
+	0x67, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x66, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
+	0x6c, 0x22, 0x8c, 0x01, 0x0a, 0x1c, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x44, 0x65, 0x73, 0x74,
+	0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x12, 0x1b, 0x0a, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x48, 0x00, 0x52, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x2f, 0x0a, 0x10, 0x68, 0x61, 0x73, 0x68, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x48, 0x01, 0x52, 0x10, 0x68, 0x61, 0x73,
+	0x68, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x88, 0x01, 0x01,
+	0x42, 0x09, 0x0a, 0x07, 0x5f, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x42, 0x13, 0x0a, 0x11, 0x5f,
+	0x68, 0x61, 0x73, 0x68, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
 	0x22, 0x6d, 0x0a, 0x16, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x41, 0x64, 0x76, 0x61, 0x6e, 0x63,
 	0x65, 0x64, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x39, 0x0a, 0x15, 0x61, 0x6c,
 	0x6c, 0x6f, 0x77, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74,
@@ -531,53 +1944,211 @@ var file_servercfg_proto_rawDesc = []byte{
 	0x6f, 0x77, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69,
 	0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x18, 0x0a, 0x16, 0x5f, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x4c,
 	0x6f, 0x63, 0x61, 0x6c, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22,
-	0x61, 0x0a, 0x06, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x2d, 0x0a, 0x07, 0x70, 0x72, 0x6f,
-	0x78, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x70,
-	0x63, 0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x52,
-	0x07, 0x70, 0x72, 0x6f, 0x78, 0x69, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x05, 0x72, 0x75, 0x6c, 0x65,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c,
-	0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72, 0x75, 0x6c,
-	0x65, 0x73, 0x22, 0x98, 0x02, 0x0a, 0x0b, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f,
-	0x78, 0x79, 0x12, 0x17, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x48, 0x00, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x36, 0x0a, 0x08, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e,
-	0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x72, 0x6f, 0x74,
-	0x6f, 0x63, 0x6f, 0x6c, 0x48, 0x01, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
-	0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x48, 0x02, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04,
-	0x70, 0x6f, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x04, 0x70, 0x6f,
-	0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x45, 0x0a, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41,
-	0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41, 0x75, 0x74,
-	0x68, 0x48, 0x04, 0x52, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41, 0x75, 0x74, 0x68, 0x65,
-	0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05,
-	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
-	0x6f, 0x6c, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x68, 0x6f, 0x73, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x5f,
-	0x70, 0x6f, 0x72, 0x74, 0x42, 0x17, 0x0a, 0x15, 0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41,
-	0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xb9, 0x01,
-	0x0a, 0x0a, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08,
-	0x69, 0x70, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08,
-	0x69, 0x70, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x6f, 0x6d, 0x61,
-	0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x64,
-	0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x31, 0x0a, 0x06, 0x61, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x61, 0x70, 0x70,
-	0x63, 0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x48, 0x00, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a,
-	0x09, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x48, 0x01, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01,
+	0xb1, 0x01, 0x0a, 0x0d, 0x52, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x54, 0x75, 0x6e, 0x6e, 0x65,
+	0x6c, 0x12, 0x1f, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x21, 0x0a, 0x09, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x09, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x61, 0x6e,
+	0x67, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2d, 0x0a, 0x0f, 0x6d, 0x61, 0x78, 0x45, 0x78, 0x70, 0x6f,
+	0x73, 0x65, 0x64, 0x50, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x48, 0x02,
+	0x52, 0x0f, 0x6d, 0x61, 0x78, 0x45, 0x78, 0x70, 0x6f, 0x73, 0x65, 0x64, 0x50, 0x6f, 0x72, 0x74,
+	0x73, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d,
+	0x65, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x42,
+	0x12, 0x0a, 0x10, 0x5f, 0x6d, 0x61, 0x78, 0x45, 0x78, 0x70, 0x6f, 0x73, 0x65, 0x64, 0x50, 0x6f,
+	0x72, 0x74, 0x73, 0x22, 0x92, 0x01, 0x0a, 0x15, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x57, 0x65,
+	0x62, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x17, 0x0a,
+	0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x70,
+	0x61, 0x74, 0x68, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x63, 0x65, 0x72, 0x74, 0x46, 0x69,
+	0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x08, 0x63, 0x65, 0x72, 0x74,
+	0x46, 0x69, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x46, 0x69,
+	0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x46,
+	0x69, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x42,
+	0x0b, 0x0a, 0x09, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x42, 0x0a, 0x0a, 0x08,
+	0x5f, 0x6b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x22, 0x6a, 0x0a, 0x0f, 0x53, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x54, 0x4c, 0x53, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1f, 0x0a, 0x08, 0x63,
+	0x65, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
+	0x08, 0x63, 0x65, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07,
+	0x6b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52,
+	0x07, 0x6b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f,
+	0x63, 0x65, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x6b, 0x65, 0x79,
+	0x46, 0x69, 0x6c, 0x65, 0x22, 0x66, 0x0a, 0x0b, 0x44, 0x65, 0x63, 0x6f, 0x79, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x1d, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x88,
+	0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x65,
+	0x88, 0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42,
+	0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x22, 0xae, 0x02, 0x0a,
+	0x11, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x12, 0x27, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x55, 0x6e, 0x64, 0x65, 0x72, 0x6c, 0x61,
+	0x79, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x55,
+	0x6e, 0x64, 0x65, 0x72, 0x6c, 0x61, 0x79, 0x73, 0x88, 0x01, 0x01, 0x12, 0x3b, 0x0a, 0x16, 0x6d,
+	0x61, 0x78, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x73, 0x50, 0x65, 0x72, 0x4d,
+	0x69, 0x6e, 0x75, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x16, 0x6d,
+	0x61, 0x78, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x73, 0x50, 0x65, 0x72, 0x4d,
+	0x69, 0x6e, 0x75, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x0e, 0x70, 0x65, 0x6e, 0x61,
+	0x6c, 0x74, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x48, 0x02, 0x52, 0x0e, 0x70, 0x65, 0x6e, 0x61, 0x6c, 0x74, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a, 0x11, 0x6d, 0x61, 0x78, 0x50, 0x65, 0x6e, 0x61,
+	0x6c, 0x74, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x48, 0x03, 0x52, 0x11, 0x6d, 0x61, 0x78, 0x50, 0x65, 0x6e, 0x61, 0x6c, 0x74, 0x79, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x6d, 0x61, 0x78,
+	0x55, 0x6e, 0x64, 0x65, 0x72, 0x6c, 0x61, 0x79, 0x73, 0x42, 0x19, 0x0a, 0x17, 0x5f, 0x6d, 0x61,
+	0x78, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x73, 0x50, 0x65, 0x72, 0x4d, 0x69,
+	0x6e, 0x75, 0x74, 0x65, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x70, 0x65, 0x6e, 0x61, 0x6c, 0x74, 0x79,
+	0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x42, 0x14, 0x0a, 0x12, 0x5f, 0x6d, 0x61, 0x78, 0x50,
+	0x65, 0x6e, 0x61, 0x6c, 0x74, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0xc1, 0x01,
+	0x0a, 0x11, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x43, 0x61, 0x63, 0x68, 0x65, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x1f, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74,
+	0x79, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x0d, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x36, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x43,
+	0x61, 0x63, 0x68, 0x65, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x48, 0x02, 0x52, 0x06, 0x70, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x63, 0x61, 0x70, 0x61,
+	0x63, 0x69, 0x74, 0x79, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x22, 0x7d, 0x0a, 0x13, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x67, 0x69,
+	0x6e, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x32, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x54, 0x79, 0x70,
+	0x65, 0x48, 0x00, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07,
+	0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52,
+	0x07, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73,
+	0x22, 0x9b, 0x01, 0x0a, 0x06, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x2d, 0x0a, 0x07, 0x70,
+	0x72, 0x6f, 0x78, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61,
+	0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f, 0x78,
+	0x79, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x78, 0x69, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x05, 0x72, 0x75,
+	0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x70, 0x70, 0x63,
+	0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72,
+	0x75, 0x6c, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0c, 0x6c, 0x6f, 0x67, 0x44, 0x65, 0x63, 0x69, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0c, 0x6c, 0x6f,
+	0x67, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x88, 0x01, 0x01, 0x42, 0x0f, 0x0a,
+	0x0d, 0x5f, 0x6c, 0x6f, 0x67, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xf2,
+	0x02, 0x0a, 0x0b, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x12, 0x17,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x36, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63,
+	0x74, 0x6c, 0x2e, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x48, 0x01, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x88, 0x01, 0x01, 0x12,
+	0x17, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52,
+	0x04, 0x68, 0x6f, 0x73, 0x74, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x88, 0x01,
+	0x01, 0x12, 0x45, 0x0a, 0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41, 0x75, 0x74, 0x68, 0x65,
+	0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x48, 0x04, 0x52,
+	0x14, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x41, 0x0a, 0x12, 0x68, 0x74, 0x74, 0x70,
+	0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41, 0x75,
+	0x74, 0x68, 0x48, 0x05, 0x52, 0x12, 0x68, 0x74, 0x74, 0x70, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e,
+	0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a, 0x05, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x68, 0x6f, 0x73, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x70,
+	0x6f, 0x72, 0x74, 0x42, 0x17, 0x0a, 0x15, 0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x73, 0x35, 0x41, 0x75,
+	0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x15, 0x0a, 0x13,
+	0x5f, 0x68, 0x74, 0x74, 0x70, 0x41, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0xc2, 0x02, 0x0a, 0x0a, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x75,
+	0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x70, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x69, 0x70, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x20,
+	0x0a, 0x0b, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x73,
+	0x12, 0x31, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x14, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e,
+	0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x49, 0x50, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x08, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x49, 0x50, 0x88, 0x01, 0x01, 0x12, 0x29, 0x0a, 0x0d, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x66, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x03,
+	0x52, 0x0d, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x75, 0x6c, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x18,
+	0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x72, 0x75, 0x6c, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x73,
 	0x42, 0x09, 0x0a, 0x07, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f,
-	0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x2a, 0x46, 0x0a, 0x0d, 0x50, 0x72, 0x6f,
+	0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x49, 0x50, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x66, 0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x92, 0x01, 0x0a, 0x0e, 0x44, 0x65, 0x73,
+	0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x43, 0x4c, 0x12, 0x30, 0x0a, 0x05, 0x72,
+	0x75, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x70, 0x70,
+	0x63, 0x74, 0x6c, 0x2e, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41,
+	0x43, 0x4c, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x3c, 0x0a,
+	0x0d, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x41, 0x43,
+	0x4c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0d, 0x64, 0x65, 0x66, 0x61, 0x75,
+	0x6c, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x10, 0x0a, 0x0e, 0x5f,
+	0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xa3, 0x01,
+	0x0a, 0x12, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x43, 0x4c,
+	0x52, 0x75, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x70, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x69, 0x70, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x73,
+	0x12, 0x20, 0x0a, 0x0b, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x4e, 0x61, 0x6d,
+	0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x12, 0x2e, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74,
+	0x6c, 0x2e, 0x41, 0x43, 0x4c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x06, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0x76, 0x0a, 0x0a, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x12, 0x27, 0x0a, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x75, 0x6c, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x52, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x3f, 0x0a, 0x0f, 0x72, 0x65,
+	0x63, 0x65, 0x6e, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x52, 0x6f, 0x75,
+	0x74, 0x65, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x65,
+	0x6e, 0x74, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xcd, 0x01, 0x0a, 0x09,
+	0x52, 0x75, 0x6c, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1b, 0x0a, 0x06, 0x72, 0x75, 0x6c,
+	0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x06, 0x72, 0x75, 0x6c,
+	0x65, 0x49, 0x44, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e,
+	0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x01, 0x52, 0x06,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x09, 0x70, 0x72, 0x6f,
+	0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x09,
+	0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x48, 0x03, 0x52,
+	0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x88, 0x01, 0x01, 0x42, 0x09, 0x0a, 0x07, 0x5f,
+	0x72, 0x75, 0x6c, 0x65, 0x49, 0x44, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x42,
+	0x0a, 0x0a, 0x08, 0x5f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x22, 0xce, 0x01, 0x0a, 0x0d,
+	0x52, 0x6f, 0x75, 0x74, 0x65, 0x44, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x0a,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x74,
+	0x69, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x25, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x0b, 0x64,
+	0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e,
+	0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2e, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x48, 0x02, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01,
+	0x12, 0x1b, 0x0a, 0x06, 0x72, 0x75, 0x6c, 0x65, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x48, 0x03, 0x52, 0x06, 0x72, 0x75, 0x6c, 0x65, 0x49, 0x44, 0x88, 0x01, 0x01, 0x42, 0x07, 0x0a,
+	0x05, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x64, 0x65, 0x73, 0x74, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x49, 0x44, 0x2a, 0x49, 0x0a, 0x11,
+	0x52, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x43, 0x61, 0x63, 0x68, 0x65, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x12, 0x1f, 0x0a, 0x1b, 0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x5f, 0x52, 0x45, 0x50,
+	0x4c, 0x41, 0x59, 0x5f, 0x43, 0x41, 0x43, 0x48, 0x45, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59,
+	0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x4f, 0x54, 0x41, 0x54, 0x45, 0x10, 0x01, 0x12, 0x07,
+	0x0a, 0x03, 0x4c, 0x52, 0x55, 0x10, 0x02, 0x2a, 0x50, 0x0a, 0x11, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x16,
+	0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x5f, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f,
+	0x45, 0x4e, 0x47, 0x49, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x47, 0x4f, 0x52, 0x4f,
+	0x55, 0x54, 0x49, 0x4e, 0x45, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x45, 0x56, 0x45, 0x4e, 0x54,
+	0x5f, 0x44, 0x52, 0x49, 0x56, 0x45, 0x4e, 0x10, 0x02, 0x2a, 0x5f, 0x0a, 0x0d, 0x50, 0x72, 0x6f,
 	0x78, 0x79, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x1a, 0x0a, 0x16, 0x55, 0x4e,
 	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x50, 0x52, 0x4f, 0x54,
 	0x4f, 0x43, 0x4f, 0x4c, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x4f, 0x43, 0x4b, 0x53, 0x35,
 	0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f, 0x50, 0x52, 0x4f, 0x54, 0x4f, 0x43, 0x4f, 0x4c, 0x10,
-	0x01, 0x2a, 0x31, 0x0a, 0x0c, 0x45, 0x67, 0x72, 0x65, 0x73, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06,
-	0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x45, 0x4a, 0x45,
-	0x43, 0x54, 0x10, 0x02, 0x42, 0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x65, 0x6e, 0x66, 0x65, 0x69, 0x6e, 0x2f, 0x6d, 0x69, 0x65, 0x72, 0x75, 0x2f,
-	0x76, 0x33, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2f, 0x61, 0x70,
-	0x70, 0x63, 0x74, 0x6c, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x12, 0x17, 0x0a, 0x13, 0x48, 0x54, 0x54, 0x50, 0x5f, 0x50, 0x52, 0x4f, 0x58, 0x59, 0x5f,
+	0x50, 0x52, 0x4f, 0x54, 0x4f, 0x43, 0x4f, 0x4c, 0x10, 0x02, 0x2a, 0x31, 0x0a, 0x0c, 0x45, 0x67,
+	0x72, 0x65, 0x73, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x52,
+	0x4f, 0x58, 0x59, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x10,
+	0x01, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x10, 0x02, 0x2a, 0x20, 0x0a,
+	0x09, 0x41, 0x43, 0x4c, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x09, 0x0a, 0x05, 0x41, 0x4c,
+	0x4c, 0x4f, 0x57, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x44, 0x45, 0x4e, 0x59, 0x10, 0x01, 0x42,
+	0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x6e,
+	0x66, 0x65, 0x69, 0x6e, 0x2f, 0x6d, 0x69, 0x65, 0x72, 0x75, 0x2f, 0x76, 0x33, 0x2f, 0x70, 0x6b,
+	0x67, 0x2f, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x2f, 0x61, 0x70, 0x70, 0x63, 0x74, 0x6c, 0x70,
+	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -592,37 +2163,86 @@ func file_servercfg_proto_rawDescGZIP() []byte {
 	return file_servercfg_proto_rawDescData
 }
 
-var file_servercfg_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_servercfg_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_servercfg_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_servercfg_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_servercfg_proto_goTypes = []interface{}{
-	(ProxyProtocol)(0),             // 0: appctl.ProxyProtocol
-	(EgressAction)(0),              // 1: appctl.EgressAction
-	(*ServerConfig)(nil),           // 2: appctl.ServerConfig
-	(*ServerAdvancedSettings)(nil), // 3: appctl.ServerAdvancedSettings
-	(*Egress)(nil),                 // 4: appctl.Egress
-	(*EgressProxy)(nil),            // 5: appctl.EgressProxy
-	(*EgressRule)(nil),             // 6: appctl.EgressRule
-	(*PortBinding)(nil),            // 7: appctl.PortBinding
-	(*User)(nil),                   // 8: appctl.User
-	(LoggingLevel)(0),              // 9: appctl.LoggingLevel
-	(*Auth)(nil),                   // 10: appctl.Auth
+	(ReplayCachePolicy)(0),               // 0: appctl.ReplayCachePolicy
+	(SessionEngineType)(0),               // 1: appctl.SessionEngineType
+	(ProxyProtocol)(0),                   // 2: appctl.ProxyProtocol
+	(EgressAction)(0),                    // 3: appctl.EgressAction
+	(ACLAction)(0),                       // 4: appctl.ACLAction
+	(*ServerConfig)(nil),                 // 5: appctl.ServerConfig
+	(*ServerDestinationStatsConfig)(nil), // 6: appctl.ServerDestinationStatsConfig
+	(*ServerAdvancedSettings)(nil),       // 7: appctl.ServerAdvancedSettings
+	(*ReverseTunnel)(nil),                // 8: appctl.ReverseTunnel
+	(*ServerWebSocketConfig)(nil),        // 9: appctl.ServerWebSocketConfig
+	(*ServerTLSConfig)(nil),              // 10: appctl.ServerTLSConfig
+	(*DecoyConfig)(nil),                  // 11: appctl.DecoyConfig
+	(*SourceLimitConfig)(nil),            // 12: appctl.SourceLimitConfig
+	(*ReplayCacheConfig)(nil),            // 13: appctl.ReplayCacheConfig
+	(*SessionEngineConfig)(nil),          // 14: appctl.SessionEngineConfig
+	(*Egress)(nil),                       // 15: appctl.Egress
+	(*EgressProxy)(nil),                  // 16: appctl.EgressProxy
+	(*EgressRule)(nil),                   // 17: appctl.EgressRule
+	(*DestinationACL)(nil),               // 18: appctl.DestinationACL
+	(*DestinationACLRule)(nil),           // 19: appctl.DestinationACLRule
+	(*RouteStats)(nil),                   // 20: appctl.RouteStats
+	(*RuleStats)(nil),                    // 21: appctl.RuleStats
+	(*RouteDecision)(nil),                // 22: appctl.RouteDecision
+	(*PortBinding)(nil),                  // 23: appctl.PortBinding
+	(*User)(nil),                         // 24: appctl.User
+	(LoggingLevel)(0),                    // 25: appctl.LoggingLevel
+	(*RetransmissionLimit)(nil),          // 26: appctl.RetransmissionLimit
+	(CongestionControl)(0),               // 27: appctl.CongestionControl
+	(*TransferCap)(nil),                  // 28: appctl.TransferCap
+	(*Fairness)(nil),                     // 29: appctl.Fairness
+	(LogFormat)(0),                       // 30: appctl.LogFormat
+	(*TracingConfig)(nil),                // 31: appctl.TracingConfig
+	(*FlowControlConfig)(nil),            // 32: appctl.FlowControlConfig
+	(*Auth)(nil),                         // 33: appctl.Auth
 }
 var file_servercfg_proto_depIdxs = []int32{
-	7,  // 0: appctl.ServerConfig.portBindings:type_name -> appctl.PortBinding
-	8,  // 1: appctl.ServerConfig.users:type_name -> appctl.User
-	3,  // 2: appctl.ServerConfig.advancedSettings:type_name -> appctl.ServerAdvancedSettings
-	9,  // 3: appctl.ServerConfig.loggingLevel:type_name -> appctl.LoggingLevel
-	4,  // 4: appctl.ServerConfig.egress:type_name -> appctl.Egress
-	5,  // 5: appctl.Egress.proxies:type_name -> appctl.EgressProxy
-	6,  // 6: appctl.Egress.rules:type_name -> appctl.EgressRule
-	0,  // 7: appctl.EgressProxy.protocol:type_name -> appctl.ProxyProtocol
-	10, // 8: appctl.EgressProxy.socks5Authentication:type_name -> appctl.Auth
-	1,  // 9: appctl.EgressRule.action:type_name -> appctl.EgressAction
-	10, // [10:10] is the sub-list for method output_type
-	10, // [10:10] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	23, // 0: appctl.ServerConfig.portBindings:type_name -> appctl.PortBinding
+	24, // 1: appctl.ServerConfig.users:type_name -> appctl.User
+	7,  // 2: appctl.ServerConfig.advancedSettings:type_name -> appctl.ServerAdvancedSettings
+	25, // 3: appctl.ServerConfig.loggingLevel:type_name -> appctl.LoggingLevel
+	15, // 4: appctl.ServerConfig.egress:type_name -> appctl.Egress
+	8,  // 5: appctl.ServerConfig.reverseTunnels:type_name -> appctl.ReverseTunnel
+	9,  // 6: appctl.ServerConfig.websocket:type_name -> appctl.ServerWebSocketConfig
+	26, // 7: appctl.ServerConfig.retransmissionLimit:type_name -> appctl.RetransmissionLimit
+	27, // 8: appctl.ServerConfig.congestionControl:type_name -> appctl.CongestionControl
+	10, // 9: appctl.ServerConfig.tls:type_name -> appctl.ServerTLSConfig
+	13, // 10: appctl.ServerConfig.replayCache:type_name -> appctl.ReplayCacheConfig
+	28, // 11: appctl.ServerConfig.transferCap:type_name -> appctl.TransferCap
+	6,  // 12: appctl.ServerConfig.destinationStats:type_name -> appctl.ServerDestinationStatsConfig
+	18, // 13: appctl.ServerConfig.destinationACL:type_name -> appctl.DestinationACL
+	29, // 14: appctl.ServerConfig.fairness:type_name -> appctl.Fairness
+	14, // 15: appctl.ServerConfig.sessionEngine:type_name -> appctl.SessionEngineConfig
+	11, // 16: appctl.ServerConfig.decoy:type_name -> appctl.DecoyConfig
+	12, // 17: appctl.ServerConfig.sourceLimit:type_name -> appctl.SourceLimitConfig
+	30, // 18: appctl.ServerConfig.logFormat:type_name -> appctl.LogFormat
+	31, // 19: appctl.ServerConfig.tracing:type_name -> appctl.TracingConfig
+	32, // 20: appctl.ServerConfig.flowControl:type_name -> appctl.FlowControlConfig
+	0,  // 21: appctl.ReplayCacheConfig.policy:type_name -> appctl.ReplayCachePolicy
+	1,  // 22: appctl.SessionEngineConfig.type:type_name -> appctl.SessionEngineType
+	16, // 23: appctl.Egress.proxies:type_name -> appctl.EgressProxy
+	17, // 24: appctl.Egress.rules:type_name -> appctl.EgressRule
+	2,  // 25: appctl.EgressProxy.protocol:type_name -> appctl.ProxyProtocol
+	33, // 26: appctl.EgressProxy.socks5Authentication:type_name -> appctl.Auth
+	33, // 27: appctl.EgressProxy.httpAuthentication:type_name -> appctl.Auth
+	3,  // 28: appctl.EgressRule.action:type_name -> appctl.EgressAction
+	19, // 29: appctl.DestinationACL.rules:type_name -> appctl.DestinationACLRule
+	4,  // 30: appctl.DestinationACL.defaultAction:type_name -> appctl.ACLAction
+	4,  // 31: appctl.DestinationACLRule.action:type_name -> appctl.ACLAction
+	21, // 32: appctl.RouteStats.rules:type_name -> appctl.RuleStats
+	22, // 33: appctl.RouteStats.recentDecisions:type_name -> appctl.RouteDecision
+	3,  // 34: appctl.RuleStats.action:type_name -> appctl.EgressAction
+	3,  // 35: appctl.RouteDecision.action:type_name -> appctl.EgressAction
+	36, // [36:36] is the sub-list for method output_type
+	36, // [36:36] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_servercfg_proto_init() }
@@ -645,7 +2265,7 @@ func file_servercfg_proto_init() {
 			}
 		}
 		file_servercfg_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ServerAdvancedSettings); i {
+			switch v := v.(*ServerDestinationStatsConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -657,7 +2277,7 @@ func file_servercfg_proto_init() {
 			}
 		}
 		file_servercfg_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Egress); i {
+			switch v := v.(*ServerAdvancedSettings); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -669,7 +2289,7 @@ func file_servercfg_proto_init() {
 			}
 		}
 		file_servercfg_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EgressProxy); i {
+			switch v := v.(*ReverseTunnel); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -681,6 +2301,102 @@ func file_servercfg_proto_init() {
 			}
 		}
 		file_servercfg_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServerWebSocketConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ServerTLSConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DecoyConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SourceLimitConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReplayCacheConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SessionEngineConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Egress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EgressProxy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*EgressRule); i {
 			case 0:
 				return &v.state
@@ -692,18 +2408,91 @@ func file_servercfg_proto_init() {
 				return nil
 			}
 		}
+		file_servercfg_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DestinationACL); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DestinationACLRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouteStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RuleStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servercfg_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouteDecision); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_servercfg_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	file_servercfg_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[2].OneofWrappers = []interface{}{}
 	file_servercfg_proto_msgTypes[3].OneofWrappers = []interface{}{}
 	file_servercfg_proto_msgTypes[4].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[6].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[7].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[8].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[9].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[10].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[11].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[12].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[13].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[14].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[16].OneofWrappers = []interface{}{}
+	file_servercfg_proto_msgTypes[17].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_servercfg_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   5,
+			NumEnums:      5,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,74 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+	"google.golang.org/protobuf/proto"
+)
+
+// validateAutoRoute validates the auto route settings.
+// A nil config is valid.
+func validateAutoRoute(config *pb.AutoRouteConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.GetMaxLatencyMs() != 0 && (config.GetMaxLatencyMs() < 10 || config.GetMaxLatencyMs() > 10000) {
+		return fmt.Errorf("auto route: max latency %d milliseconds is out of range, valid range is [10, 10000]", config.GetMaxLatencyMs())
+	}
+	if config.GetCacheSeconds() < 0 || config.GetCacheSeconds() > 86400 {
+		return fmt.Errorf("auto route: cache seconds %d is out of range, valid range is [0, 86400]", config.GetCacheSeconds())
+	}
+	return nil
+}
+
+// AutoRoute returns the auto route settings of socks5 server
+// from the configuration.
+func AutoRoute(config *pb.AutoRouteConfig) socks5.AutoRoute {
+	return socks5.AutoRoute{
+		Enabled:      config.GetEnable(),
+		MaxLatency:   time.Duration(config.GetMaxLatencyMs()) * time.Millisecond,
+		CacheTTL:     time.Duration(config.GetCacheSeconds()) * time.Second,
+		LogDecisions: config.GetLogDecisions(),
+	}
+}
+
+// autoRouteStatsToProto converts the auto route statistics
+// to the RPC response.
+func autoRouteStatsToProto(stats *socks5.AutoRouteStats) *pb.AutoRouteStats {
+	res := &pb.AutoRouteStats{}
+	for _, rule := range stats.Rules {
+		res.Rules = append(res.Rules, &pb.AutoRouteRuleStats{
+			Rule:    proto.String(rule.Rule),
+			Direct:  proto.Bool(rule.Direct),
+			Matches: proto.Int64(rule.Matches),
+		})
+	}
+	for _, d := range stats.RecentDecisions {
+		res.RecentDecisions = append(res.RecentDecisions, &pb.AutoRouteDecision{
+			Time:        proto.String(d.Time.Format(time.RFC3339)),
+			Destination: proto.String(d.Destination),
+			Direct:      proto.Bool(d.Direct),
+			Rule:        proto.String(d.Rule),
+			LatencyMs:   proto.Int64(d.Latency.Milliseconds()),
+		})
+	}
+	return res
+}
@@ -17,6 +17,9 @@ package appctl
 
 import (
 	"context"
+	crand "crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
@@ -24,11 +27,14 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlgrpc"
 	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
@@ -70,6 +76,15 @@ var (
 
 	// clientMuxRef holds a pointer to client multiplexier.
 	clientMuxRef atomic.Pointer[protocol.Mux]
+
+	// clientResolverRef holds a pointer to client DNS resolver.
+	clientResolverRef atomic.Pointer[common.SwappableResolver]
+
+	// clientForwarderRefs holds client port forwarders and reverse forwarders.
+	clientForwarderRefs []io.Closer
+
+	// clientForwarderRefsLock protects clientForwarderRefs.
+	clientForwarderRefsLock sync.Mutex
 )
 
 func SetClientRPCServerRef(server *grpc.Server) {
@@ -84,6 +99,16 @@ func SetClientMuxRef(mux *protocol.Mux) {
 	clientMuxRef.Store(mux)
 }
 
+func SetClientResolverRef(resolver *common.SwappableResolver) {
+	clientResolverRef.Store(resolver)
+}
+
+func AddClientForwarderRef(forwarder io.Closer) {
+	clientForwarderRefsLock.Lock()
+	defer clientForwarderRefsLock.Unlock()
+	clientForwarderRefs = append(clientForwarderRefs, forwarder)
+}
+
 // clientLifecycleService implements ClientLifecycleService defined in lifecycle.proto.
 type clientLifecycleService struct {
 	appctlgrpc.UnimplementedClientLifecycleServiceServer
@@ -92,7 +117,31 @@ type clientLifecycleService struct {
 func (c *clientLifecycleService) GetStatus(ctx context.Context, req *pb.Empty) (*pb.AppStatusMsg, error) {
 	status := GetAppStatus()
 	log.Infof("return app status %s back to RPC caller", status.String())
-	return &pb.AppStatusMsg{Status: &status}, nil
+	msg := &pb.AppStatusMsg{Status: &status}
+	mux := clientMuxRef.Load()
+	if status == pb.AppStatus_RUNNING && mux != nil {
+		quality := mux.ConnectionQuality()
+		msg.Quality = &pb.ConnectionQuality{
+			Score:           proto.Int32(int32(quality.Score)),
+			LossRate:        proto.Float64(quality.LossRate),
+			RttMs:           proto.Int64(quality.SmoothedRTT.Milliseconds()),
+			RttVarianceMs:   proto.Int64(quality.RTTVariance.Milliseconds()),
+			DialFailureRate: proto.Float64(quality.DialFailureRate),
+		}
+		if state := clientScheduledState.Load(); state != nil {
+			msg.ActiveProfile = proto.String(state.ProfileName)
+			msg.ProxyDisabled = proto.Bool(state.ProxyDisabled)
+		}
+		if info, ok := mux.ServerInfo(); ok {
+			msg.ServerInfo = &pb.ServerInfo{
+				Version:         proto.String(info.Version),
+				Features:        info.Features.Names(),
+				MissingFeatures: info.MissingFeatures().Names(),
+			}
+		}
+	}
+	setStorageHealth(msg)
+	return msg, nil
 }
 
 func (c *clientLifecycleService) Exit(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
@@ -107,6 +156,17 @@ func (c *clientLifecycleService) Exit(ctx context.Context, req *pb.Empty) (*pb.E
 	} else {
 		log.Infof("socks5 server reference not found")
 	}
+	clientForwarderRefsLock.Lock()
+	if len(clientForwarderRefs) > 0 {
+		log.Infof("stopping port forwards")
+	}
+	for _, forwarder := range clientForwarderRefs {
+		if err := forwarder.Close(); err != nil {
+			log.Infof("port forward Close() failed: %v", err)
+		}
+	}
+	clientForwarderRefs = nil
+	clientForwarderRefsLock.Unlock()
 	grpcServer := clientRPCServerRef.Load()
 	if grpcServer != nil {
 		log.Infof("stopping RPC server")
@@ -134,6 +194,22 @@ func (c *clientLifecycleService) GetSessionInfo(context.Context, *pb.Empty) (*pb
 	return &pb.SessionInfo{Table: mux.ExportSessionInfoTable()}, nil
 }
 
+func (c *clientLifecycleService) GetSessionStates(ctx context.Context, req *pb.SessionStateRequest) (*pb.SessionStates, error) {
+	mux := clientMuxRef.Load()
+	if mux == nil {
+		return &pb.SessionStates{}, fmt.Errorf("client multiplexier is unavailable")
+	}
+	states, err := getSessionStates(mux, req.GetSessionID())
+	if err != nil {
+		return &pb.SessionStates{}, err
+	}
+	return &pb.SessionStates{Json: proto.String(states)}, nil
+}
+
+func (c *clientLifecycleService) GetLogs(req *pb.LogRequest, stream appctlgrpc.ClientLifecycleService_GetLogsServer) error {
+	return sendLogs(stream.Context(), req, stream.Send)
+}
+
 func (c *clientLifecycleService) GetThreadDump(ctx context.Context, req *pb.Empty) (*pb.ThreadDump, error) {
 	return &pb.ThreadDump{ThreadDump: proto.String(string(getThreadDump()))}, nil
 }
@@ -157,6 +233,39 @@ func (c *clientLifecycleService) GetMemoryStatistics(ctx context.Context, req *p
 	return &pb.MemoryStatistics{Json: proto.String(getMemoryStats())}, nil
 }
 
+func (c *clientLifecycleService) ReloadDNS(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	if err := reloadClientDNS(); err != nil {
+		log.Errorf("Reload DNS failed: %v", err)
+		return &pb.Empty{}, err
+	}
+	log.Infof("DNS resolver is reloaded")
+	return &pb.Empty{}, nil
+}
+
+func (c *clientLifecycleService) GetDestinationStats(ctx context.Context, req *pb.Empty) (*pb.DestinationStats, error) {
+	socks5Server := clientSocks5ServerRef.Load()
+	if socks5Server == nil {
+		return &pb.DestinationStats{}, fmt.Errorf("socks5 server is unavailable")
+	}
+	stats := socks5Server.DestinationStats()
+	if stats == nil {
+		return &pb.DestinationStats{}, fmt.Errorf("destination statistics is not enabled")
+	}
+	return destinationStatsToProto(stats.Top(maxReportedDestinations)), nil
+}
+
+func (c *clientLifecycleService) GetAutoRouteStats(ctx context.Context, req *pb.Empty) (*pb.AutoRouteStats, error) {
+	socks5Server := clientSocks5ServerRef.Load()
+	if socks5Server == nil {
+		return &pb.AutoRouteStats{}, fmt.Errorf("socks5 server is unavailable")
+	}
+	stats := socks5Server.AutoRouteStats()
+	if stats == nil {
+		return &pb.AutoRouteStats{}, fmt.Errorf("auto route is not enabled")
+	}
+	return autoRouteStatsToProto(stats), nil
+}
+
 // NewClientLifecycleService creates a new ClientLifecycleService RPC server.
 func NewClientLifecycleService() *clientLifecycleService {
 	return &clientLifecycleService{}
@@ -194,15 +303,36 @@ func IsClientDaemonRunning(ctx context.Context) error {
 	return nil
 }
 
+// GetClientStatusWithRPC gets client application status via ClientLifecycleService.GetStatus() RPC.
+func GetClientStatusWithRPC(ctx context.Context) (*pb.AppStatusMsg, error) {
+	client, err := NewClientLifecycleRPCClient()
+	if err != nil {
+		return nil, fmt.Errorf("NewClientLifecycleRPCClient() failed: %w", err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(ctx, RPCTimeout)
+	defer cancelFunc()
+	status, err := client.GetStatus(timedctx, &pb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("ClientLifecycleService.GetStatus() failed: %w", err)
+	}
+	return status, nil
+}
+
 // GetJSONClientConfig returns the client config as JSON.
 func GetJSONClientConfig() (string, error) {
+	return GetFormattedClientConfig(JSON_CONFIG_FILE_TYPE)
+}
+
+// GetFormattedClientConfig returns the client config in the given type of
+// configuration file, which can be JSON, YAML or TOML.
+func GetFormattedClientConfig(fileType ConfigFileType) (string, error) {
 	config, err := LoadClientConfig()
 	if err != nil {
 		return "", fmt.Errorf("LoadClientConfig() failed: %w", err)
 	}
-	b, err := common.MarshalJSON(config)
+	b, err := MarshalConfig(config, fileType)
 	if err != nil {
-		return "", fmt.Errorf("common.MarshalJSON() failed: %w", err)
+		return "", err
 	}
 	return string(b), nil
 }
@@ -234,38 +364,31 @@ func LoadClientConfig() (*pb.ClientConfig, error) {
 	}
 
 	log.Debugf("loading client config from %q", fileName)
-	f, err := os.Open(fileName)
-	if err != nil && os.IsNotExist(err) {
-		return nil, stderror.ErrFileNotExist
-	} else if err != nil {
-		return nil, fmt.Errorf("os.Open() failed: %w", err)
-	}
-	defer f.Close()
-
-	b, err := io.ReadAll(f)
+	b, err := readConfigFile(fileName)
 	if err != nil {
-		return nil, fmt.Errorf("io.ReadAll() failed: %w", err)
+		return nil, err
+	}
+	if b, err = openClientConfig(b); err != nil {
+		return nil, err
 	}
 
 	c := &pb.ClientConfig{}
-	switch fileType {
-	case PROTOBUF_CONFIG_FILE_TYPE:
-		if err := proto.Unmarshal(b, c); err != nil {
-			return nil, fmt.Errorf("proto.Unmarshal() failed: %w", err)
-		}
-	case JSON_CONFIG_FILE_TYPE:
-		if err := common.UnmarshalJSON(b, c); err != nil {
-			return nil, fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("config file type is invalid")
+	if err := UnmarshalConfig(b, c, fileType); err != nil {
+		return nil, err
 	}
 
 	return c, nil
 }
 
 // StoreClientConfig writes client config to disk.
+// If the stored client config is encrypted, the new one is also encrypted.
 func StoreClientConfig(config *pb.ClientConfig) error {
+	return storeClientConfig(config, nil)
+}
+
+// storeClientConfig writes client config to disk. If encrypt is not nil,
+// it decides whether the client config is encrypted.
+func storeClientConfig(config *pb.ClientConfig, encrypt *bool) error {
 	clientIOLock.Lock()
 	defer clientIOLock.Unlock()
 
@@ -281,39 +404,29 @@ func StoreClientConfig(config *pb.ClientConfig) error {
 		profile.User = HashUserPassword(profile.GetUser(), true)
 	}
 
-	var b []byte
-	switch fileType {
-	case PROTOBUF_CONFIG_FILE_TYPE:
-		if b, err = proto.Marshal(config); err != nil {
-			return fmt.Errorf("proto.Marshal() failed: %w", err)
-		}
-	case JSON_CONFIG_FILE_TYPE:
-		if b, err = common.MarshalJSON(config); err != nil {
-			return fmt.Errorf("common.MarshalJSON() failed: %w", err)
-		}
-	default:
-		return fmt.Errorf("config file type is invalid")
-	}
-
-	err = os.WriteFile(fileName, b, 0660)
+	b, err := MarshalConfig(config, fileType)
 	if err != nil {
-		return fmt.Errorf("os.WriteFile(%q) failed: %w", fileName, err)
+		return err
+	}
+	if b, err = sealClientConfig(fileName, b, encrypt); err != nil {
+		return err
 	}
 
-	return nil
+	return writeConfigFile(log.StorageClientConfig, fileName, b)
 }
 
-// ApplyJSONClientConfig applies user provided JSON client config from the given file.
+// ApplyJSONClientConfig applies user provided client config from the given file.
+// The file is JSON, unless the file extension name is .yaml, .yml or .toml.
 func ApplyJSONClientConfig(path string) error {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("os.ReadFile(%q) failed: %w", path, err)
 	}
 	c := &pb.ClientConfig{}
-	if err = common.UnmarshalJSON(b, c); err != nil {
-		return fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
+	if err = UnmarshalConfig(b, c, FindConfigPatchFileType(path)); err != nil {
+		return err
 	}
-	return applyClientConfig(c)
+	return ApplyClientConfig(c)
 }
 
 // ApplyJSONClientConfig applies user provided client config URL.
@@ -322,7 +435,7 @@ func ApplyURLClientConfig(u string) error {
 	if err != nil {
 		return fmt.Errorf("URLToClientConfig() failed: %w", err)
 	}
-	return applyClientConfig(c)
+	return ApplyClientConfig(c)
 }
 
 // DeleteClientConfigProfile deletes a profile stored in client config.
@@ -335,6 +448,11 @@ func DeleteClientConfigProfile(profileName string) error {
 	if config.GetActiveProfile() == profileName {
 		return fmt.Errorf("activeProfile %q can't be deleted", profileName)
 	}
+	for _, schedule := range config.GetProfileSchedules() {
+		if schedule.GetProfileName() == profileName {
+			return fmt.Errorf("profile %q used by a profile schedule can't be deleted", profileName)
+		}
+	}
 	profiles := config.GetProfiles()
 	updated := make([]*pb.ClientProfile, 0)
 	for _, profile := range profiles {
@@ -350,6 +468,29 @@ func DeleteClientConfigProfile(profileName string) error {
 	return nil
 }
 
+// RenewClientPassword replaces the password of the user in the profile
+// with the next hashed password pushed by the proxy server.
+func RenewClientPassword(profileName, userName string, hashedPassword []byte) error {
+	config, err := LoadClientConfig()
+	if err != nil {
+		return fmt.Errorf("LoadClientConfig() failed: %w", err)
+	}
+	profile, err := GetActiveProfileFromConfig(config, profileName)
+	if err != nil {
+		return fmt.Errorf("GetActiveProfileFromConfig() failed: %w", err)
+	}
+	if profile.GetUser().GetName() != userName {
+		return fmt.Errorf("user of profile %q is not %q", profileName, userName)
+	}
+	// The raw password is no longer valid.
+	profile.User.Password = nil
+	profile.User.HashedPassword = proto.String(hex.EncodeToString(hashedPassword))
+	if err = StoreClientConfig(config); err != nil {
+		return fmt.Errorf("StoreClientConfig() failed: %w", err)
+	}
+	return nil
+}
+
 // ValidateClientConfigPatch validates a patch of client config.
 //
 // A client config patch must satisfy:
@@ -370,6 +511,47 @@ func ValidateClientConfigPatch(patch *pb.ClientConfig) error {
 			return fmt.Errorf("socks5 authentication password is not set")
 		}
 	}
+	forwardPorts := map[string]struct{}{}
+	for _, forward := range patch.GetPortForwards() {
+		if err := validatePortForward(forward); err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%s:%d", forward.GetProtocol().String(), forward.GetLocalPort())
+		if _, found := forwardPorts[key]; found {
+			return fmt.Errorf("port forward local port %d with protocol %s is duplicated", forward.GetLocalPort(), forward.GetProtocol().String())
+		}
+		forwardPorts[key] = struct{}{}
+	}
+	reverseForwardPorts := map[int32]struct{}{}
+	for _, forward := range patch.GetReverseForwards() {
+		if forward.GetRemotePort() < 1 || forward.GetRemotePort() > 65535 {
+			return fmt.Errorf("reverse forward remote port number %d is invalid", forward.GetRemotePort())
+		}
+		if _, found := reverseForwardPorts[forward.GetRemotePort()]; found {
+			return fmt.Errorf("reverse forward remote port %d is duplicated", forward.GetRemotePort())
+		}
+		reverseForwardPorts[forward.GetRemotePort()] = struct{}{}
+		if _, _, err := net.SplitHostPort(forward.GetLocalAddress()); err != nil {
+			return fmt.Errorf("reverse forward local address %q is invalid: %w", forward.GetLocalAddress(), err)
+		}
+	}
+	for _, schedule := range patch.GetProfileSchedules() {
+		if err := validateProfileSchedule(schedule); err != nil {
+			return err
+		}
+	}
+	if err := validateTransferCap(patch.GetTransferCap()); err != nil {
+		return err
+	}
+	if err := validateDNSConfig(patch.GetDns()); err != nil {
+		return err
+	}
+	if err := validateAutoRoute(patch.GetAutoRoute()); err != nil {
+		return err
+	}
+	if err := validateTracing(patch.GetTracing()); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -401,6 +583,18 @@ func ValidateClientConfigSingleProfile(profile *pb.ClientProfile) error {
 	if len(user.GetQuotas()) != 0 {
 		return fmt.Errorf("user quota is not supported by proxy client")
 	}
+	if user.ExpireTime != nil {
+		return fmt.Errorf("user expire time is not supported by proxy client")
+	}
+	if user.Guest != nil {
+		return fmt.Errorf("guest user is not supported by proxy client")
+	}
+	if user.GetNextPassword() != "" || user.GetNextHashedPassword() != "" {
+		return fmt.Errorf("user next password is not supported by proxy client")
+	}
+	if user.RateLimit != nil {
+		return fmt.Errorf("user rate limit is not supported by proxy client")
+	}
 	servers := profile.GetServers()
 	if len(servers) == 0 {
 		return fmt.Errorf("servers are not set")
@@ -423,6 +617,65 @@ func ValidateClientConfigSingleProfile(profile *pb.ClientProfile) error {
 	if profile.GetMtu() != 0 && (profile.GetMtu() < 1280 || profile.GetMtu() > 1500) {
 		return fmt.Errorf("MTU value %d is out of range, valid range is [1280, 1500]", profile.GetMtu())
 	}
+	if profile.Websocket != nil {
+		if profile.GetWebsocket().GetHost() == "" {
+			return fmt.Errorf("WebSocket host is not set")
+		}
+		if path := profile.GetWebsocket().GetPath(); path != "" && !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("WebSocket path %q doesn't start with \"/\"", path)
+		}
+	}
+	if profile.Tls != nil {
+		if profile.Websocket != nil {
+			return fmt.Errorf("TLS and WebSocket can't be used together")
+		}
+		if profile.GetTls().GetSni() == "" {
+			return fmt.Errorf("TLS server name is not set")
+		}
+	}
+	if profile.GetFecGroupSize() != 0 && (profile.GetFecGroupSize() < 2 || profile.GetFecGroupSize() > protocol.MaxFECGroupSize) {
+		return fmt.Errorf("FEC group size %d is out of range, valid range is [2, %d]", profile.GetFecGroupSize(), protocol.MaxFECGroupSize)
+	}
+	multiplexing := profile.GetMultiplexing()
+	if multiplexing.GetMaxSessionsPerConnection() < 0 {
+		return fmt.Errorf("maximum number of sessions per connection %d is negative", multiplexing.GetMaxSessionsPerConnection())
+	}
+	if multiplexing.GetMaxConnectionLifetimeSeconds() < 0 {
+		return fmt.Errorf("maximum connection lifetime %d seconds is negative", multiplexing.GetMaxConnectionLifetimeSeconds())
+	}
+	if multiplexing.GetSpareConnections() < 0 || multiplexing.GetSpareConnections() > protocol.MaxSpareUnderlays {
+		return fmt.Errorf("number of spare connections %d is out of range, valid range is [0, %d]", multiplexing.GetSpareConnections(), protocol.MaxSpareUnderlays)
+	}
+	if err := validateRetransmissionLimit(profile.GetRetransmissionLimit()); err != nil {
+		return err
+	}
+	if err := validateIdleTimeout(profile.GetIdleTimeout()); err != nil {
+		return err
+	}
+	if err := validateRekey(profile.GetRekey()); err != nil {
+		return err
+	}
+	if err := validateFairness(profile.GetFairness()); err != nil {
+		return err
+	}
+	if err := validateHosts(profile.GetHosts()); err != nil {
+		return err
+	}
+	if err := validateFlowControl(profile.GetFlowControl()); err != nil {
+		return err
+	}
+	if err := validateCongestionControl(profile.GetCongestionControl()); err != nil {
+		return err
+	}
+	if err := validateEndpointPolicy(profile.GetEndpointPolicy()); err != nil {
+		return err
+	}
+	if err := validatePreset(profile.GetPreset()); err != nil {
+		return err
+	}
+	if profile.GetHybridKeyExchange() && !cipher.HybridKeyExchangeSupported {
+		return cipher.ErrHybridKeyExchangeNotSupported
+	}
 	return nil
 }
 
@@ -434,6 +687,7 @@ func ValidateClientConfigSingleProfile(profile *pb.ClientProfile) error {
 // 3. RPC port is valid
 // 4. socks5 port is valid
 // 5. RPC port, socks5 port, http proxy port are different
+// 6. TCP port forwards don't use RPC port, socks5 port or http proxy port
 func ValidateFullClientConfig(config *pb.ClientConfig) error {
 	if err := ValidateClientConfigPatch(config); err != nil {
 		return err
@@ -454,6 +708,14 @@ func ValidateFullClientConfig(config *pb.ClientConfig) error {
 	if !foundActiveProfile {
 		return fmt.Errorf("active profile is not found in the profile list")
 	}
+	for _, schedule := range config.GetProfileSchedules() {
+		if schedule.GetProfileName() == "" {
+			continue
+		}
+		if _, err := GetActiveProfileFromConfig(config, schedule.GetProfileName()); err != nil {
+			return fmt.Errorf("profile schedule uses profile %q which is not found in the profile list", schedule.GetProfileName())
+		}
+	}
 	// RPC port is allowed to be 0, which means disable RPC.
 	if config.GetRpcPort() < 0 || config.GetRpcPort() > 65535 {
 		return fmt.Errorf("RPC port number %d is invalid", config.GetRpcPort())
@@ -475,6 +737,73 @@ func ValidateFullClientConfig(config *pb.ClientConfig) error {
 			return fmt.Errorf("HTTP proxy port number %d is the same as socks5 port number", config.GetHttpProxyPort())
 		}
 	}
+	if config.DebugHttpPort != nil {
+		if config.GetDebugHttpPort() < 1 || config.GetDebugHttpPort() > 65535 {
+			return fmt.Errorf("debug HTTP port number %d is invalid", config.GetDebugHttpPort())
+		}
+		if config.GetDebugHttpPort() == config.GetRpcPort() {
+			return fmt.Errorf("debug HTTP port number %d is the same as RPC port number", config.GetDebugHttpPort())
+		}
+		if config.GetDebugHttpPort() == config.GetSocks5Port() {
+			return fmt.Errorf("debug HTTP port number %d is the same as socks5 port number", config.GetDebugHttpPort())
+		}
+		if config.HttpProxyPort != nil && config.GetDebugHttpPort() == config.GetHttpProxyPort() {
+			return fmt.Errorf("debug HTTP port number %d is the same as HTTP proxy port number", config.GetDebugHttpPort())
+		}
+	}
+	if config.ManagementHttpPort != nil {
+		if config.GetManagementHttpPort() < 1 || config.GetManagementHttpPort() > 65535 {
+			return fmt.Errorf("management HTTP port number %d is invalid", config.GetManagementHttpPort())
+		}
+		if config.GetManagementHttpPort() == config.GetRpcPort() {
+			return fmt.Errorf("management HTTP port number %d is the same as RPC port number", config.GetManagementHttpPort())
+		}
+		if config.GetManagementHttpPort() == config.GetSocks5Port() {
+			return fmt.Errorf("management HTTP port number %d is the same as socks5 port number", config.GetManagementHttpPort())
+		}
+		if config.HttpProxyPort != nil && config.GetManagementHttpPort() == config.GetHttpProxyPort() {
+			return fmt.Errorf("management HTTP port number %d is the same as HTTP proxy port number", config.GetManagementHttpPort())
+		}
+		if config.DebugHttpPort != nil && config.GetManagementHttpPort() == config.GetDebugHttpPort() {
+			return fmt.Errorf("management HTTP port number %d is the same as debug HTTP port number", config.GetManagementHttpPort())
+		}
+	}
+	for _, forward := range config.GetPortForwards() {
+		if forward.GetProtocol() != pb.TransportProtocol_TCP {
+			continue
+		}
+		if forward.GetLocalPort() == config.GetRpcPort() {
+			return fmt.Errorf("port forward local port number %d is the same as RPC port number", forward.GetLocalPort())
+		}
+		if forward.GetLocalPort() == config.GetSocks5Port() {
+			return fmt.Errorf("port forward local port number %d is the same as socks5 port number", forward.GetLocalPort())
+		}
+		if config.HttpProxyPort != nil && forward.GetLocalPort() == config.GetHttpProxyPort() {
+			return fmt.Errorf("port forward local port number %d is the same as HTTP proxy port number", forward.GetLocalPort())
+		}
+	}
+	return nil
+}
+
+// validatePortForward validates a single port forward.
+func validatePortForward(forward *pb.PortForward) error {
+	if forward.GetLocalPort() < 1 || forward.GetLocalPort() > 65535 {
+		return fmt.Errorf("port forward local port number %d is invalid", forward.GetLocalPort())
+	}
+	if forward.GetProtocol() != pb.TransportProtocol_TCP && forward.GetProtocol() != pb.TransportProtocol_UDP {
+		return fmt.Errorf("port forward protocol %s is invalid", forward.GetProtocol().String())
+	}
+	host, port, err := net.SplitHostPort(forward.GetRemoteAddress())
+	if err != nil {
+		return fmt.Errorf("port forward remote address %q is invalid: %w", forward.GetRemoteAddress(), err)
+	}
+	if host == "" {
+		return fmt.Errorf("port forward remote address %q has no host", forward.GetRemoteAddress())
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum < 1 || portNum > 65535 {
+		return fmt.Errorf("port forward remote address %q has invalid port number", forward.GetRemoteAddress())
+	}
 	return nil
 }
 
@@ -491,6 +820,53 @@ func GetActiveProfileFromConfig(config *pb.ClientConfig, name string) (*pb.Clien
 	return nil, fmt.Errorf("profile %q is not found", name)
 }
 
+// ClientWebSocketConfig returns the WebSocket config used by the client mux.
+// It returns nil if WebSocket is not enabled in the profile.
+func ClientWebSocketConfig(profile *pb.ClientProfile) *protocol.WebSocketConfig {
+	if profile.Websocket == nil {
+		return nil
+	}
+	ws := profile.GetWebsocket()
+	config := &protocol.WebSocketConfig{
+		Host: ws.GetHost(),
+		Path: ws.GetPath(),
+	}
+	if !ws.GetDisableTLS() {
+		serverName := ws.GetSni()
+		if serverName == "" {
+			serverName = ws.GetHost()
+		}
+		config.TLSConfig = &tls.Config{
+			ServerName: serverName,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return config
+}
+
+// ClientTLSConfig returns the TLS config used by the client mux.
+// It returns nil if TLS is not enabled in the profile.
+func ClientTLSConfig(profile *pb.ClientProfile) *tls.Config {
+	if profile.Tls == nil {
+		return nil
+	}
+	return &tls.Config{
+		ServerName: profile.GetTls().GetSni(),
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+// ClientUnderlayPoolConfig returns the underlay pool config used by
+// the client mux.
+func ClientUnderlayPoolConfig(profile *pb.ClientProfile) protocol.UnderlayPoolConfig {
+	multiplexing := profile.GetMultiplexing()
+	return protocol.UnderlayPoolConfig{
+		MaxSessionsPerUnderlay: int(multiplexing.GetMaxSessionsPerConnection()),
+		MaxUnderlayLifetime:    time.Duration(multiplexing.GetMaxConnectionLifetimeSeconds()) * time.Second,
+		SpareUnderlays:         int(multiplexing.GetSpareConnections()),
+	}
+}
+
 // ClientUpdaterHistoryPath returns the file path to retrieve
 // client updater history.
 func ClientUpdaterHistoryPath() (string, error) {
@@ -500,6 +876,32 @@ func ClientUpdaterHistoryPath() (string, error) {
 	return filepath.Join(cachedClientConfigDir, "client.updater.pb"), nil
 }
 
+// ClientManagementToken returns the bearer token of client management API.
+// The token is created when it is used for the first time, and stored in
+// the client config directory that can only be read by the current user.
+func ClientManagementToken() (string, error) {
+	if err := prepareClientConfigDir(); err != nil {
+		return "", err
+	}
+	path := filepath.Join(cachedClientConfigDir, "management.token")
+	if b, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
 // newClientLifecycleRPCClient creates a new ClientLifecycleService RPC client
 // and connects to the given server address.
 func newClientLifecycleRPCClient(serverAddr string) (appctlgrpc.ClientLifecycleServiceClient, error) {
@@ -549,7 +951,9 @@ func clientConfigFilePath() (string, ConfigFileType, error) {
 	return cachedClientConfigFilePath, FindConfigFileType(cachedClientConfigFilePath), nil
 }
 
-func applyClientConfig(c *pb.ClientConfig) error {
+// ApplyClientConfig validates the client config and merges it into
+// the stored client config.
+func ApplyClientConfig(c *pb.ClientConfig) error {
 	if err := ValidateClientConfigPatch(c); err != nil {
 		return fmt.Errorf("ValidateClientConfigPatch() failed: %w", err)
 	}
@@ -642,6 +1046,51 @@ func mergeClientConfigByProfile(dst, src *pb.ClientConfig) {
 	if src.Socks5Authentication != nil {
 		socks5Authentication = src.Socks5Authentication
 	}
+	var portForwards []*pb.PortForward = dst.PortForwards
+	if src.PortForwards != nil {
+		portForwards = src.PortForwards
+	}
+	var reverseForwards []*pb.ReverseForward = dst.ReverseForwards
+	if src.ReverseForwards != nil {
+		reverseForwards = src.ReverseForwards
+	}
+	var profileSchedules []*pb.ProfileSchedule = dst.ProfileSchedules
+	if src.ProfileSchedules != nil {
+		profileSchedules = src.ProfileSchedules
+	}
+	var transferCap *pb.TransferCap = dst.TransferCap
+	if src.TransferCap != nil {
+		transferCap = src.TransferCap
+	}
+	var dns *pb.DNSConfig = dst.Dns
+	if src.Dns != nil {
+		dns = src.Dns
+	}
+	var autoRoute *pb.AutoRouteConfig = dst.AutoRoute
+	if src.AutoRoute != nil {
+		autoRoute = src.AutoRoute
+	}
+	var destinationStats *bool = dst.DestinationStats
+	if src.DestinationStats != nil {
+		destinationStats = src.DestinationStats
+	}
+
+	var debugHttpPort *int32 = dst.DebugHttpPort
+	if src.DebugHttpPort != nil {
+		debugHttpPort = src.DebugHttpPort
+	}
+	var logFormat *pb.LogFormat = dst.LogFormat
+	if src.LogFormat != nil {
+		logFormat = src.LogFormat
+	}
+	var managementHttpPort *int32 = dst.ManagementHttpPort
+	if src.ManagementHttpPort != nil {
+		managementHttpPort = src.ManagementHttpPort
+	}
+	var tracingConfig *pb.TracingConfig = dst.Tracing
+	if src.Tracing != nil {
+		tracingConfig = src.Tracing
+	}
 
 	proto.Reset(dst)
 
@@ -656,6 +1105,17 @@ func mergeClientConfigByProfile(dst, src *pb.ClientConfig) {
 	dst.HttpProxyPort = httpProxyPort
 	dst.HttpProxyListenLAN = httpProxyListenLAN
 	dst.Socks5Authentication = socks5Authentication
+	dst.PortForwards = portForwards
+	dst.ReverseForwards = reverseForwards
+	dst.ProfileSchedules = profileSchedules
+	dst.TransferCap = transferCap
+	dst.Dns = dns
+	dst.AutoRoute = autoRoute
+	dst.DestinationStats = destinationStats
+	dst.DebugHttpPort = debugHttpPort
+	dst.LogFormat = logFormat
+	dst.ManagementHttpPort = managementHttpPort
+	dst.Tracing = tracingConfig
 }
 
 // deleteClientConfigFile deletes the client config file.
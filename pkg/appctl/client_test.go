@@ -16,10 +16,12 @@
 package appctl
 
 import (
+	"encoding/hex"
 	"os"
 	"testing"
 
 	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
 	"google.golang.org/protobuf/proto"
 )
@@ -67,6 +69,13 @@ func TestApply2ClientConfig(t *testing.T) {
 func TestClientApplyReject(t *testing.T) {
 	cases := []string{
 		"testdata/client_reject_active_profile_mismatch.json",
+		"testdata/client_reject_auto_route_latency_too_small.json",
+		"testdata/client_reject_fairness_max_unsent_bytes_too_small.json",
+		"testdata/client_reject_fec_group_size_too_big.json",
+		"testdata/client_reject_flow_control_negative_bandwidth.json",
+		"testdata/client_reject_flow_control_window_too_big.json",
+		"testdata/client_reject_hosts_invalid_ip_address.json",
+		"testdata/client_reject_idle_timeout_too_small.json",
 		"testdata/client_reject_invalid_http_port.json",
 		"testdata/client_reject_invalid_rpc_port.json",
 		"testdata/client_reject_mtu_too_big.json",
@@ -82,12 +91,26 @@ func TestClientApplyReject(t *testing.T) {
 		"testdata/client_reject_no_servers.json",
 		"testdata/client_reject_no_socks5_port.json",
 		"testdata/client_reject_no_user_name.json",
+		"testdata/client_reject_port_forward_invalid_remote.json",
+		"testdata/client_reject_port_forward_no_protocol.json",
+		"testdata/client_reject_port_forward_same_port_socks5.json",
+		"testdata/client_reject_retransmission_limit_too_small.json",
+		"testdata/client_reject_same_port_debug_socks5.json",
 		"testdata/client_reject_same_port_http_rpc.json",
 		"testdata/client_reject_same_port_http_socks5.json",
 		"testdata/client_reject_same_port_rpc_socks5.json",
 		"testdata/client_reject_socks5_auth_no_password.json",
 		"testdata/client_reject_socks5_auth_no_user.json",
+		"testdata/client_reject_tls_with_websocket.json",
+		"testdata/client_reject_too_many_spare_connections.json",
+		"testdata/client_reject_unknown_congestion_control.json",
+		"testdata/client_reject_unknown_preset.json",
+		"testdata/client_reject_user_has_expire_time.json",
+		"testdata/client_reject_user_has_next_password.json",
 		"testdata/client_reject_user_has_quota.json",
+		"testdata/client_reject_user_has_rate_limit.json",
+		"testdata/client_reject_user_is_guest.json",
+		"testdata/client_reject_websocket_no_host.json",
 		"testdata/client_reject_wrong_ipv4_address.json",
 		"testdata/client_reject_wrong_ipv6_address.json",
 	}
@@ -211,6 +234,38 @@ func TestClientDeleteProfileRejectActiveProfile(t *testing.T) {
 	afterClientTest(t)
 }
 
+func TestClientRenewPassword(t *testing.T) {
+	beforeClientTest(t)
+
+	configFile := "testdata/client_before_delete_profile.json"
+	if err := ApplyJSONClientConfig(configFile); err != nil {
+		t.Fatalf("ApplyJSONClientConfig(%q) failed: %v", configFile, err)
+	}
+	next := cipher.HashPassword([]byte("5d8e3c21b0a9"), []byte("user2"))
+	if err := RenewClientPassword("default", "user3", next); err == nil {
+		t.Errorf("want error in RenewClientPassword() with a different user, got no error")
+	}
+	if err := RenewClientPassword("default", "user2", next); err != nil {
+		t.Fatalf("RenewClientPassword() failed: %v", err)
+	}
+	config, err := LoadClientConfig()
+	if err != nil {
+		t.Fatalf("LoadClientConfig() failed: %v", err)
+	}
+	profile, err := GetActiveProfileFromConfig(config, "default")
+	if err != nil {
+		t.Fatalf("GetActiveProfileFromConfig() failed: %v", err)
+	}
+	if profile.GetUser().GetPassword() != "" {
+		t.Errorf("password %q is not removed", profile.GetUser().GetPassword())
+	}
+	if got, want := profile.GetUser().GetHashedPassword(), hex.EncodeToString(next); got != want {
+		t.Errorf("hashed password = %q, want %q", got, want)
+	}
+
+	afterClientTest(t)
+}
+
 func beforeClientTest(t *testing.T) {
 	dir := os.TempDir()
 	if dir == "" {
@@ -0,0 +1,299 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// ConfigPassphraseEnv is the environment variable that contains
+	// the passphrase of the encrypted client config.
+	ConfigPassphraseEnv = "MIERU_CONFIG_PASSPHRASE"
+
+	// ConfigPassphraseFileEnv is the environment variable that contains
+	// the path of a file, which stores the passphrase of the encrypted
+	// client config. It is used if ConfigPassphraseEnv is not set.
+	ConfigPassphraseFileEnv = "MIERU_CONFIG_PASSPHRASE_FILE"
+)
+
+const (
+	encryptedConfigMagic = "MIERU-ENCRYPTED-CONFIG-1\n"
+	encryptedConfigSalt  = 16
+	encryptedConfigKey   = 32 // AES-256
+
+	// scrypt parameters recommended for interactive logins.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	// ErrNoPassphrase is returned if the client config is encrypted,
+	// but the passphrase is not provided.
+	ErrNoPassphrase = errors.New("passphrase of the encrypted config is not provided")
+
+	// ErrWrongPassphrase is returned if the encrypted client config
+	// can't be decrypted with the passphrase.
+	ErrWrongPassphrase = errors.New("passphrase is wrong or the encrypted config is corrupted")
+)
+
+// KeyProvider provides the passphrase to encrypt and decrypt
+// the stored client config.
+type KeyProvider interface {
+	// Passphrase returns the passphrase. It returns ErrNoPassphrase
+	// if the passphrase is not available.
+	Passphrase() ([]byte, error)
+}
+
+// EnvKeyProvider reads the passphrase from ConfigPassphraseEnv,
+// or from the file of ConfigPassphraseFileEnv.
+type EnvKeyProvider struct{}
+
+var _ KeyProvider = EnvKeyProvider{}
+
+func (EnvKeyProvider) Passphrase() ([]byte, error) {
+	if v, found := os.LookupEnv(ConfigPassphraseEnv); found && v != "" {
+		return []byte(v), nil
+	}
+	if path, found := os.LookupEnv(ConfigPassphraseFileEnv); found && path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile(%q) failed: %w", path, err)
+		}
+		passphrase := strings.TrimRight(string(b), "\r\n")
+		if passphrase == "" {
+			return nil, fmt.Errorf("passphrase file %q is empty", path)
+		}
+		return []byte(passphrase), nil
+	}
+	return nil, ErrNoPassphrase
+}
+
+var (
+	clientKeyProvider   KeyProvider = EnvKeyProvider{}
+	clientKeyProviderMu sync.Mutex
+)
+
+// SetClientConfigKeyProvider sets the provider of the passphrase
+// of the encrypted client config. By default, EnvKeyProvider is used.
+func SetClientConfigKeyProvider(kp KeyProvider) {
+	clientKeyProviderMu.Lock()
+	defer clientKeyProviderMu.Unlock()
+	clientKeyProvider = kp
+}
+
+// IsClientConfigEncrypted returns true if the stored client config is encrypted.
+func IsClientConfigEncrypted() (bool, error) {
+	clientIOLock.Lock()
+	defer clientIOLock.Unlock()
+
+	fileName, _, err := clientConfigFilePath()
+	if err != nil {
+		return false, fmt.Errorf("clientConfigFilePath() failed: %w", err)
+	}
+	b, err := readConfigFile(fileName)
+	if err != nil {
+		return false, err
+	}
+	return IsEncryptedConfig(b), nil
+}
+
+// EncryptClientConfig stores the client config encrypted with the
+// passphrase of the key provider.
+func EncryptClientConfig() error {
+	return setClientConfigEncryption(true)
+}
+
+// DecryptClientConfig stores the client config without encryption.
+func DecryptClientConfig() error {
+	return setClientConfigEncryption(false)
+}
+
+func setClientConfigEncryption(encrypt bool) error {
+	config, err := LoadClientConfig()
+	if err != nil {
+		return fmt.Errorf("LoadClientConfig() failed: %w", err)
+	}
+	return storeClientConfig(config, &encrypt)
+}
+
+// sealClientConfig encrypts the content of client config if encrypt is
+// true. If encrypt is nil, the content is encrypted if the stored client
+// config is encrypted. The caller must hold clientIOLock.
+func sealClientConfig(fileName string, b []byte, encrypt *bool) ([]byte, error) {
+	clientKeyProviderMu.Lock()
+	kp := clientKeyProvider
+	clientKeyProviderMu.Unlock()
+
+	var salt []byte
+	old, err := readConfigFile(fileName)
+	if err == nil && IsEncryptedConfig(old) && len(old) >= len(encryptedConfigMagic)+encryptedConfigSalt {
+		// Keep the salt, so the derived key is reused.
+		salt = old[len(encryptedConfigMagic) : len(encryptedConfigMagic)+encryptedConfigSalt]
+		if encrypt == nil {
+			encrypt = new(bool)
+			*encrypt = true
+		}
+	}
+	if encrypt == nil || !*encrypt {
+		return b, nil
+	}
+	if kp == nil {
+		return nil, ErrNoPassphrase
+	}
+	passphrase, err := kp.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return encryptConfig(b, passphrase, salt)
+}
+
+// openClientConfig decrypts the content of client config if it is encrypted.
+func openClientConfig(b []byte) ([]byte, error) {
+	if !IsEncryptedConfig(b) {
+		return b, nil
+	}
+	clientKeyProviderMu.Lock()
+	kp := clientKeyProvider
+	clientKeyProviderMu.Unlock()
+	if kp == nil {
+		return nil, ErrNoPassphrase
+	}
+	passphrase, err := kp.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return DecryptConfig(b, passphrase)
+}
+
+// IsEncryptedConfig returns true if the content is created by EncryptConfig.
+func IsEncryptedConfig(b []byte) bool {
+	return bytes.HasPrefix(b, []byte(encryptedConfigMagic))
+}
+
+// EncryptConfig encrypts the content of a config file with the passphrase.
+// The key is derived from the passphrase with scrypt, and the content
+// is encrypted by AES-256-GCM.
+func EncryptConfig(b, passphrase []byte) ([]byte, error) {
+	return encryptConfig(b, passphrase, nil)
+}
+
+// encryptConfig encrypts the content with the salt. A new salt is
+// generated if it is nil.
+func encryptConfig(b, passphrase, salt []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrNoPassphrase
+	}
+	if salt == nil {
+		salt = make([]byte, encryptedConfigSalt)
+		if _, err := crand.Read(salt); err != nil {
+			return nil, fmt.Errorf("crand.Read() failed: %w", err)
+		}
+	}
+	aead, err := configAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := crand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("crand.Read() failed: %w", err)
+	}
+	res := make([]byte, 0, len(encryptedConfigMagic)+len(salt)+len(nonce)+len(b)+aead.Overhead())
+	res = append(res, encryptedConfigMagic...)
+	res = append(res, salt...)
+	res = append(res, nonce...)
+	return aead.Seal(res, nonce, b, []byte(encryptedConfigMagic)), nil
+}
+
+// DecryptConfig decrypts the content created by EncryptConfig.
+func DecryptConfig(b, passphrase []byte) ([]byte, error) {
+	if !IsEncryptedConfig(b) {
+		return nil, fmt.Errorf("config is not encrypted")
+	}
+	b = b[len(encryptedConfigMagic):]
+	if len(b) < encryptedConfigSalt {
+		return nil, ErrWrongPassphrase
+	}
+	salt := b[:encryptedConfigSalt]
+	aead, err := configAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	b = b[encryptedConfigSalt:]
+	if len(b) < aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(encryptedConfigMagic))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// derivedConfigKey caches the last key derived from the passphrase,
+// because scrypt is slow on purpose. The passphrase is not kept in
+// memory: the cache is looked up by the HMAC-SHA256 of the passphrase,
+// keyed by the salt.
+var derivedConfigKey struct {
+	mu     sync.Mutex
+	digest [sha256.Size]byte
+	key    []byte
+}
+
+func configAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(passphrase)
+	var digest [sha256.Size]byte
+	copy(digest[:], mac.Sum(nil))
+
+	derivedConfigKey.mu.Lock()
+	key := derivedConfigKey.key
+	if key == nil || subtle.ConstantTimeCompare(derivedConfigKey.digest[:], digest[:]) != 1 {
+		var err error
+		key, err = scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, encryptedConfigKey)
+		if err != nil {
+			derivedConfigKey.mu.Unlock()
+			return nil, fmt.Errorf("scrypt.Key() failed: %w", err)
+		}
+		derivedConfigKey.digest = digest
+		derivedConfigKey.key = key
+	}
+	derivedConfigKey.mu.Unlock()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher() failed: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cipher.NewGCM() failed: %w", err)
+	}
+	return aead, nil
+}
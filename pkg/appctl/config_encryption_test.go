@@ -0,0 +1,119 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"google.golang.org/protobuf/proto"
+)
+
+type testKeyProvider struct {
+	passphrase string
+}
+
+func (kp testKeyProvider) Passphrase() ([]byte, error) {
+	if kp.passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+	return []byte(kp.passphrase), nil
+}
+
+func TestEncryptConfig(t *testing.T) {
+	plaintext := []byte("secret client config")
+	b, err := EncryptConfig(plaintext, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("EncryptConfig() failed: %v", err)
+	}
+	if !IsEncryptedConfig(b) {
+		t.Errorf("IsEncryptedConfig() = false, want true")
+	}
+	if bytes.Contains(b, plaintext) {
+		t.Errorf("encrypted config contains the plaintext")
+	}
+	got, err := DecryptConfig(b, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("DecryptConfig() failed: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("DecryptConfig() = %q, want %q", got, plaintext)
+	}
+	if _, err := DecryptConfig(b, []byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("DecryptConfig() with wrong passphrase returned %v, want %v", err, ErrWrongPassphrase)
+	}
+	if _, err := DecryptConfig(b[:len(b)-1], []byte("passphrase")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("DecryptConfig() of truncated config returned %v, want %v", err, ErrWrongPassphrase)
+	}
+}
+
+func TestEncryptClientConfig(t *testing.T) {
+	beforeClientTest(t)
+	defer SetClientConfigKeyProvider(EnvKeyProvider{})
+
+	config := &pb.ClientConfig{
+		ActiveProfile: proto.String("default"),
+		Socks5Port:    proto.Int32(1080),
+	}
+	if err := StoreClientConfig(config); err != nil {
+		t.Fatalf("StoreClientConfig() failed: %v", err)
+	}
+
+	SetClientConfigKeyProvider(testKeyProvider{passphrase: "passphrase"})
+	if err := EncryptClientConfig(); err != nil {
+		t.Fatalf("EncryptClientConfig() failed: %v", err)
+	}
+	if encrypted, err := IsClientConfigEncrypted(); err != nil || !encrypted {
+		t.Fatalf("IsClientConfigEncrypted() = %v, %v, want true, nil", encrypted, err)
+	}
+
+	// The encryption is kept when the client config is stored again.
+	config.Socks5Port = proto.Int32(1081)
+	if err := StoreClientConfig(config); err != nil {
+		t.Fatalf("StoreClientConfig() failed: %v", err)
+	}
+	if encrypted, err := IsClientConfigEncrypted(); err != nil || !encrypted {
+		t.Fatalf("IsClientConfigEncrypted() = %v, %v, want true, nil", encrypted, err)
+	}
+	got, err := LoadClientConfig()
+	if err != nil {
+		t.Fatalf("LoadClientConfig() failed: %v", err)
+	}
+	if !proto.Equal(got, config) {
+		t.Errorf("LoadClientConfig() = %v, want %v", got, config)
+	}
+
+	SetClientConfigKeyProvider(testKeyProvider{})
+	if _, err := LoadClientConfig(); !errors.Is(err, ErrNoPassphrase) {
+		t.Errorf("LoadClientConfig() without passphrase returned %v, want %v", err, ErrNoPassphrase)
+	}
+	SetClientConfigKeyProvider(testKeyProvider{passphrase: "wrong"})
+	if _, err := LoadClientConfig(); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("LoadClientConfig() with wrong passphrase returned %v, want %v", err, ErrWrongPassphrase)
+	}
+
+	SetClientConfigKeyProvider(testKeyProvider{passphrase: "passphrase"})
+	if err := DecryptClientConfig(); err != nil {
+		t.Fatalf("DecryptClientConfig() failed: %v", err)
+	}
+	if encrypted, err := IsClientConfigEncrypted(); err != nil || encrypted {
+		t.Fatalf("IsClientConfigEncrypted() = %v, %v, want false, nil", encrypted, err)
+	}
+
+	afterClientTest(t)
+}
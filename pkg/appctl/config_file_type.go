@@ -15,7 +15,13 @@
 
 package appctl
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"google.golang.org/protobuf/proto"
+)
 
 type ConfigFileType int
 
@@ -23,13 +29,104 @@ const (
 	INVALID_CONFIG_FILE_TYPE ConfigFileType = iota
 	PROTOBUF_CONFIG_FILE_TYPE
 	JSON_CONFIG_FILE_TYPE
+	YAML_CONFIG_FILE_TYPE
+	TOML_CONFIG_FILE_TYPE
 )
 
 // FindConfigFileType returns the type of configuration file.
 // Similar to Windows, it uses file extension name to make the decision.
 func FindConfigFileType(fileName string) ConfigFileType {
-	if strings.HasSuffix(fileName, ".json") {
+	switch {
+	case strings.HasSuffix(fileName, ".json"):
 		return JSON_CONFIG_FILE_TYPE
+	case strings.HasSuffix(fileName, ".yaml"), strings.HasSuffix(fileName, ".yml"):
+		return YAML_CONFIG_FILE_TYPE
+	case strings.HasSuffix(fileName, ".toml"):
+		return TOML_CONFIG_FILE_TYPE
 	}
 	return PROTOBUF_CONFIG_FILE_TYPE
 }
+
+// FindConfigPatchFileType returns the type of the configuration file
+// provided by "apply config" command. It is JSON unless the file
+// extension name is YAML or TOML.
+func FindConfigPatchFileType(fileName string) ConfigFileType {
+	fileType := FindConfigFileType(fileName)
+	if fileType == PROTOBUF_CONFIG_FILE_TYPE {
+		return JSON_CONFIG_FILE_TYPE
+	}
+	return fileType
+}
+
+// ParseConfigFormat returns the type of configuration file
+// from the format name "json", "yaml" or "toml".
+func ParseConfigFormat(format string) (ConfigFileType, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		return JSON_CONFIG_FILE_TYPE, nil
+	case "yaml", "yml":
+		return YAML_CONFIG_FILE_TYPE, nil
+	case "toml":
+		return TOML_CONFIG_FILE_TYPE, nil
+	default:
+		return INVALID_CONFIG_FILE_TYPE, fmt.Errorf("config format %q is invalid, supported formats are json, yaml and toml", format)
+	}
+}
+
+// MarshalConfig returns the representation of the config
+// in the given type of configuration file.
+func MarshalConfig(config proto.Message, fileType ConfigFileType) ([]byte, error) {
+	switch fileType {
+	case PROTOBUF_CONFIG_FILE_TYPE:
+		b, err := proto.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("proto.Marshal() failed: %w", err)
+		}
+		return b, nil
+	case JSON_CONFIG_FILE_TYPE:
+		b, err := common.MarshalJSON(config)
+		if err != nil {
+			return nil, fmt.Errorf("common.MarshalJSON() failed: %w", err)
+		}
+		return b, nil
+	case YAML_CONFIG_FILE_TYPE:
+		b, err := common.MarshalYAML(config)
+		if err != nil {
+			return nil, fmt.Errorf("common.MarshalYAML() failed: %w", err)
+		}
+		return b, nil
+	case TOML_CONFIG_FILE_TYPE:
+		b, err := common.MarshalTOML(config)
+		if err != nil {
+			return nil, fmt.Errorf("common.MarshalTOML() failed: %w", err)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("config file type is invalid")
+	}
+}
+
+// UnmarshalConfig reads the config from the given type of configuration file.
+func UnmarshalConfig(b []byte, config proto.Message, fileType ConfigFileType) error {
+	switch fileType {
+	case PROTOBUF_CONFIG_FILE_TYPE:
+		if err := proto.Unmarshal(b, config); err != nil {
+			return fmt.Errorf("proto.Unmarshal() failed: %w", err)
+		}
+	case JSON_CONFIG_FILE_TYPE:
+		if err := common.UnmarshalJSON(b, config); err != nil {
+			return fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
+		}
+	case YAML_CONFIG_FILE_TYPE:
+		if err := common.UnmarshalYAML(b, config); err != nil {
+			return fmt.Errorf("common.UnmarshalYAML() failed: %w", err)
+		}
+	case TOML_CONFIG_FILE_TYPE:
+		if err := common.UnmarshalTOML(b, config); err != nil {
+			return fmt.Errorf("common.UnmarshalTOML() failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("config file type is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/congestion"
+)
+
+// validateCongestionControl validates the congestion control algorithm.
+func validateCongestionControl(cc pb.CongestionControl) error {
+	if _, ok := pb.CongestionControl_name[int32(cc)]; !ok {
+		return fmt.Errorf("congestion control %d is unknown", cc)
+	}
+	return nil
+}
+
+// CongestionControl returns the congestion control algorithm
+// from the configuration.
+func CongestionControl(cc pb.CongestionControl) congestion.Algorithm {
+	switch cc {
+	case pb.CongestionControl_BBR:
+		return congestion.AlgorithmBBR
+	case pb.CongestionControl_CUBIC:
+		return congestion.AlgorithmCubic
+	default:
+		return congestion.DefaultAlgorithm
+	}
+}
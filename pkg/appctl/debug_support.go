@@ -1,6 +1,7 @@
 package appctl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,7 +9,11 @@ import (
 	"runtime/pprof"
 	"sync"
 
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/stderror"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -104,3 +109,72 @@ func getMemoryStats() string {
 	}
 	return string(b)
 }
+
+// getSessionStates returns the JSON dump of the internal state of sessions.
+// If sessionID is 0, all the sessions are dumped.
+func getSessionStates(mux *protocol.Mux, sessionID uint32) (string, error) {
+	states := mux.ExportSessionStates(sessionID)
+	if sessionID != 0 && len(states) == 0 {
+		return "", fmt.Errorf("session %d: %w", sessionID, stderror.ErrNotFound)
+	}
+	b, err := json.MarshalIndent(states, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// sendLogs sends the recent log lines at the requested level or more severe.
+// If follow is set, it keeps sending the new log lines until the context
+// is done.
+func sendLogs(ctx context.Context, req *pb.LogRequest, send func(*pb.LogLine) error) error {
+	level := log.TraceLevel
+	if req.GetLevel() != pb.LoggingLevel_DEFAULT {
+		if l, err := log.ParseLevel(req.GetLevel().String()); err == nil {
+			level = l
+		}
+	}
+	for _, line := range log.RecentLines(level) {
+		if err := send(logLine(line)); err != nil {
+			return err
+		}
+	}
+	if !req.GetFollow() {
+		return nil
+	}
+	lines, cancel := log.Subscribe(level)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line := <-lines:
+			if err := send(logLine(line)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// logLine converts the log line to protobuf.
+func logLine(line log.Line) *pb.LogLine {
+	var level pb.LoggingLevel
+	switch line.Level {
+	case log.PanicLevel, log.FatalLevel:
+		level = pb.LoggingLevel_FATAL
+	case log.ErrorLevel:
+		level = pb.LoggingLevel_ERROR
+	case log.WarnLevel:
+		level = pb.LoggingLevel_WARN
+	case log.InfoLevel:
+		level = pb.LoggingLevel_INFO
+	case log.DebugLevel:
+		level = pb.LoggingLevel_DEBUG
+	default:
+		level = pb.LoggingLevel_TRACE
+	}
+	return &pb.LogLine{
+		Level: &level,
+		Text:  proto.String(line.Text),
+	}
+}
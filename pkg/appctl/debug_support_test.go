@@ -0,0 +1,74 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSendLogs(t *testing.T) {
+	log.SetFormatter(&log.DaemonFormatter{})
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer func() {
+		log.SetFormatter(&log.CliFormatter{})
+		log.SetOutput(os.Stdout)
+	}()
+
+	log.Infof("TestSendLogs info message")
+	log.Errorf("TestSendLogs error message")
+	var got []*pb.LogLine
+	send := func(line *pb.LogLine) error {
+		if strings.Contains(line.GetText(), "TestSendLogs") {
+			got = append(got, line)
+		}
+		return nil
+	}
+	req := &pb.LogRequest{Level: pb.LoggingLevel_ERROR.Enum()}
+	if err := sendLogs(context.Background(), req, send); err != nil {
+		t.Fatalf("sendLogs() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].GetLevel() != pb.LoggingLevel_ERROR || !strings.Contains(got[0].GetText(), "error message") {
+		t.Fatalf("got %v, want the error message", got)
+	}
+
+	// Follow the new log until the context is canceled.
+	got = nil
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sendLogs(ctx, &pb.LogRequest{Level: pb.LoggingLevel_INFO.Enum(), Follow: proto.Bool(true)}, send)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	log.Warnf("TestSendLogs new warning message")
+	time.Sleep(100 * time.Millisecond)
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("sendLogs() failed: %v", err)
+	}
+	if len(got) != 3 || !strings.Contains(got[2].GetText(), "new warning message") {
+		t.Errorf("got %v, want the recent messages and the new warning message", got)
+	}
+}
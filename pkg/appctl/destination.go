@@ -0,0 +1,59 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxReportedDestinations is the maximum number of destinations
+// returned by the RPC.
+const maxReportedDestinations = 100
+
+// destinationStatsToProto converts the destination statistics
+// to the RPC response.
+func destinationStatsToProto(stats []socks5.DestinationStat) *pb.DestinationStats {
+	res := &pb.DestinationStats{}
+	for _, stat := range stats {
+		res.Destinations = append(res.Destinations, &pb.DestinationStat{
+			Destination:   proto.String(stat.Destination),
+			Connections:   proto.Int64(stat.Connections),
+			UploadBytes:   proto.Int64(stat.UploadBytes),
+			DownloadBytes: proto.Int64(stat.DownloadBytes),
+			LastSeen:      proto.String(stat.LastSeen.Format(time.RFC3339)),
+		})
+	}
+	return res
+}
+
+// userDestinationStats returns the traffic aggregated by user and
+// destination in the socks5 server.
+func userDestinationStats() (*socks5.UserDestinationStats, error) {
+	server := socks5ServerRef.Load()
+	if server == nil {
+		return nil, fmt.Errorf("socks5 server is unavailable")
+	}
+	stats := server.UserDestinationStats()
+	if stats == nil {
+		return nil, fmt.Errorf("destination statistics is not enabled")
+	}
+	return stats, nil
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// validateDNSConfig validates the DNS settings.
+// A nil config is valid.
+func validateDNSConfig(config *pb.DNSConfig) error {
+	if config.GetSecureServer() != "" {
+		if len(config.GetServers()) != 0 {
+			return fmt.Errorf("DNS servers and secure DNS server can't be used together")
+		}
+		_, err := apicommon.NewSecureResolver(secureResolverConfig(config))
+		return err
+	}
+	if len(config.GetBootstrapIPs()) != 0 {
+		return fmt.Errorf("bootstrap IP addresses are set without secure DNS server")
+	}
+	for _, server := range config.GetServers() {
+		host, port, err := net.SplitHostPort(server)
+		if err != nil {
+			return fmt.Errorf("DNS server %q is invalid: %w", server, err)
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Errorf("DNS server %q is invalid: %q is not an IP address", server, host)
+		}
+		portNum, err := strconv.Atoi(port)
+		if err != nil || portNum < 1 || portNum > 65535 {
+			return fmt.Errorf("DNS server %q is invalid: port %q is out of range, valid range is [1, 65535]", server, port)
+		}
+	}
+	return nil
+}
+
+// NewDNSResolver returns a DNS resolver from the DNS settings.
+// If no DNS server is configured, the system DNS resolver is used.
+func NewDNSResolver(config *pb.DNSConfig) (apicommon.DNSResolver, error) {
+	if config.GetSecureServer() != "" {
+		return apicommon.NewSecureResolver(secureResolverConfig(config))
+	}
+	return common.NewMeteredResolver(config.GetServers()), nil
+}
+
+// secureResolverConfig returns the settings of secure DNS resolver.
+// Bootstrap IP addresses that can't be parsed are kept as nil,
+// which are rejected by apicommon.NewSecureResolver.
+func secureResolverConfig(config *pb.DNSConfig) apicommon.SecureResolverConfig {
+	c := apicommon.SecureResolverConfig{
+		Server: config.GetSecureServer(),
+	}
+	for _, ip := range config.GetBootstrapIPs() {
+		c.BootstrapIPs = append(c.BootstrapIPs, net.ParseIP(ip))
+	}
+	return c
+}
+
+// ClientEndpoints looks up the proxy servers in the profile with the
+// static hosts of the profile and the resolver, and returns the endpoints
+// to be used by the client mux.
+func ClientEndpoints(profile *pb.ClientProfile, resolver apicommon.DNSResolver) ([]protocol.UnderlayProperties, error) {
+	mtu := common.DefaultMTU
+	if profile.GetMtu() != 0 {
+		mtu = int(profile.GetMtu())
+	}
+	resolver = common.NewHostsResolver(ClientHosts(profile), resolver)
+	endpoints := make([]protocol.UnderlayProperties, 0)
+	for _, serverInfo := range profile.GetServers() {
+		var proxyHost string
+		var proxyIP net.IP
+		if serverInfo.GetDomainName() != "" {
+			proxyHost = serverInfo.GetDomainName()
+			proxyIPs, err := resolver.LookupIP(context.Background(), "ip", proxyHost)
+			if err != nil {
+				return nil, fmt.Errorf(stderror.LookupIPFailedErr, err)
+			}
+			if len(proxyIPs) == 0 {
+				return nil, fmt.Errorf(stderror.IPAddressNotFound, proxyHost)
+			}
+			proxyIP = proxyIPs[0]
+		} else {
+			proxyHost = serverInfo.GetIpAddress()
+			proxyIP = net.ParseIP(proxyHost)
+			if proxyIP == nil {
+				return nil, fmt.Errorf(stderror.ParseIPFailed)
+			}
+		}
+		portBindings, err := ClientPortBindings(serverInfo.GetPortBindings())
+		if err != nil {
+			return nil, fmt.Errorf(stderror.InvalidPortBindingsErr, err)
+		}
+		for _, bindingInfo := range portBindings {
+			if bindingInfo.GetPortRange() != "" {
+				begin, end, _ := parsePortRange(bindingInfo.GetPortRange())
+				switch bindingInfo.GetProtocol() {
+				case pb.TransportProtocol_TCP:
+					endpoint := protocol.NewUnderlayProperties(mtu, common.StreamTransport, nil, &protocol.PortRangeAddr{Net: "tcp", IP: proxyIP, Begin: begin, End: end})
+					endpoints = append(endpoints, endpoint)
+				case pb.TransportProtocol_UDP:
+					endpoint := protocol.NewUnderlayProperties(mtu, common.PacketTransport, nil, &protocol.PortRangeAddr{Net: "udp", IP: proxyIP, Begin: begin, End: end})
+					endpoints = append(endpoints, endpoint)
+				default:
+					return nil, fmt.Errorf(stderror.InvalidTransportProtocol)
+				}
+				continue
+			}
+			proxyPort := bindingInfo.GetPort()
+			switch bindingInfo.GetProtocol() {
+			case pb.TransportProtocol_TCP:
+				endpoint := protocol.NewUnderlayProperties(mtu, common.StreamTransport, nil, &net.TCPAddr{IP: proxyIP, Port: int(proxyPort)})
+				endpoints = append(endpoints, endpoint)
+			case pb.TransportProtocol_UDP:
+				endpoint := protocol.NewUnderlayProperties(mtu, common.PacketTransport, nil, &net.UDPAddr{IP: proxyIP, Port: int(proxyPort)})
+				endpoints = append(endpoints, endpoint)
+			default:
+				return nil, fmt.Errorf(stderror.InvalidTransportProtocol)
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+// reloadClientDNS replaces the DNS resolver of the running client with
+// the DNS settings in client config, and looks up the proxy servers
+// of the profile in use again. Nothing is changed if the lookup fails.
+func reloadClientDNS() error {
+	resolverRef := clientResolverRef.Load()
+	mux := clientMuxRef.Load()
+	if resolverRef == nil || mux == nil {
+		return fmt.Errorf(stderror.ClientNotRunning)
+	}
+	config, err := LoadClientConfig()
+	if err != nil {
+		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+	}
+	if err := ValidateFullClientConfig(config); err != nil {
+		return fmt.Errorf(stderror.ValidateFullClientConfigFailedErr, err)
+	}
+	profileName := config.GetActiveProfile()
+	if state := clientScheduledState.Load(); state != nil {
+		profileName = state.ProfileName
+	}
+	profile, err := GetActiveProfileFromConfig(config, profileName)
+	if err != nil {
+		return fmt.Errorf(stderror.ClientGetActiveProfileFailedErr, err)
+	}
+	resolver, err := NewDNSResolver(config.GetDns())
+	if err != nil {
+		return err
+	}
+	endpoints, err := ClientEndpoints(profile, resolver)
+	if err != nil {
+		return err
+	}
+	resolverRef.Swap(resolver)
+	mux.SetEndpoints(endpoints)
+	return nil
+}
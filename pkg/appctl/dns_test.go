@@ -0,0 +1,98 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"net"
+	"testing"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestValidateDNSConfig(t *testing.T) {
+	cases := []struct {
+		servers []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"1.1.1.1:53", "[2606:4700:4700::1111]:53"}, false},
+		{[]string{"1.1.1.1"}, true},
+		{[]string{"dns.example.com:53"}, true},
+		{[]string{"1.1.1.1:0"}, true},
+		{[]string{"1.1.1.1:dns"}, true},
+	}
+	for _, tc := range cases {
+		err := validateDNSConfig(&pb.DNSConfig{Servers: tc.servers})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("validateDNSConfig(%v) returned error %v, want error %v", tc.servers, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidateSecureDNSConfig(t *testing.T) {
+	cases := []struct {
+		config  *pb.DNSConfig
+		wantErr bool
+	}{
+		{&pb.DNSConfig{SecureServer: proto.String("https://dns.google/dns-query"), BootstrapIPs: []string{"8.8.8.8", "8.8.4.4"}}, false},
+		{&pb.DNSConfig{SecureServer: proto.String("tls://1.1.1.1")}, false},
+		{&pb.DNSConfig{SecureServer: proto.String("https://dns.google/dns-query")}, true},
+		{&pb.DNSConfig{SecureServer: proto.String("https://dns.google/dns-query"), BootstrapIPs: []string{"dns.google"}}, true},
+		{&pb.DNSConfig{SecureServer: proto.String("udp://1.1.1.1")}, true},
+		{&pb.DNSConfig{SecureServer: proto.String("tls://1.1.1.1"), Servers: []string{"1.1.1.1:53"}}, true},
+		{&pb.DNSConfig{BootstrapIPs: []string{"8.8.8.8"}}, true},
+	}
+	for _, tc := range cases {
+		err := validateDNSConfig(tc.config)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("validateDNSConfig(%v) returned error %v, want error %v", tc.config, err, tc.wantErr)
+		}
+	}
+}
+
+func TestClientEndpointsPortRange(t *testing.T) {
+	profile := &pb.ClientProfile{
+		Servers: []*pb.ServerEndpoint{
+			{
+				IpAddress: proto.String("10.3.0.1"),
+				PortBindings: []*pb.PortBinding{
+					{PortRange: proto.String("20000-21000"), Protocol: pb.TransportProtocol_UDP.Enum()},
+					{Port: proto.Int32(8964), Protocol: pb.TransportProtocol_TCP.Enum()},
+					{PortRange: proto.String("8964-8964"), Protocol: pb.TransportProtocol_TCP.Enum()},
+				},
+			},
+		},
+	}
+	endpoints, err := ClientEndpoints(profile, &net.Resolver{})
+	if err != nil {
+		t.Fatalf("ClientEndpoints() failed: %v", err)
+	}
+	if len(endpoints) != 2 {
+		t.Fatalf("got %d endpoints, want 2", len(endpoints))
+	}
+	if _, ok := endpoints[0].RemoteAddr().(*net.TCPAddr); !ok || endpoints[0].RemoteAddr().String() != "10.3.0.1:8964" {
+		t.Errorf("endpoints[0] = %v, want TCP address 10.3.0.1:8964", endpoints[0].RemoteAddr())
+	}
+	r, ok := endpoints[1].RemoteAddr().(*protocol.PortRangeAddr)
+	if !ok {
+		t.Fatalf("endpoints[1] = %v, want a port range", endpoints[1].RemoteAddr())
+	}
+	if r.Network() != "udp" || r.Begin != 20000 || r.End != 21000 {
+		t.Errorf("endpoints[1] = %s %v, want udp 10.3.0.1:20000-21000", r.Network(), r)
+	}
+}
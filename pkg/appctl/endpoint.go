@@ -0,0 +1,46 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+)
+
+// validateEndpointPolicy validates the endpoint selection policy.
+func validateEndpointPolicy(policy pb.EndpointPolicy) error {
+	if _, ok := pb.EndpointPolicy_name[int32(policy)]; !ok {
+		return fmt.Errorf("endpoint policy %d is unknown", policy)
+	}
+	return nil
+}
+
+// EndpointPolicy returns the endpoint selection policy
+// from the configuration.
+func EndpointPolicy(policy pb.EndpointPolicy) protocol.EndpointPolicy {
+	switch policy {
+	case pb.EndpointPolicy_ENDPOINT_ROUND_ROBIN:
+		return protocol.RoundRobinEndpoint
+	case pb.EndpointPolicy_ENDPOINT_LOWEST_LATENCY:
+		return protocol.LowestLatencyEndpoint
+	case pb.EndpointPolicy_ENDPOINT_STICKY:
+		return protocol.StickyEndpoint
+	default:
+		return protocol.RandomEndpoint
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+)
+
+// validateFairness validates the fairness settings. A nil config is valid.
+func validateFairness(fairness *pb.Fairness) error {
+	if fairness == nil {
+		return nil
+	}
+	if fairness.GetMaxUnsentBytes() != 0 && (fairness.GetMaxUnsentBytes() < protocol.MinMaxUnsentBytes || fairness.GetMaxUnsentBytes() > protocol.MaxMaxUnsentBytes) {
+		return fmt.Errorf("fairness: max unsent bytes %d is out of range, valid range is [%d, %d]", fairness.GetMaxUnsentBytes(), protocol.MinMaxUnsentBytes, protocol.MaxMaxUnsentBytes)
+	}
+	if fairness.GetHighPriorityWeight() != 0 && (fairness.GetHighPriorityWeight() < 2 || fairness.GetHighPriorityWeight() > protocol.MaxPriorityWeight) {
+		return fmt.Errorf("fairness: high priority weight %d is out of range, valid range is [2, %d]", fairness.GetHighPriorityWeight(), protocol.MaxPriorityWeight)
+	}
+	if fairness.GetLowPriorityWeight() < 0 || fairness.GetLowPriorityWeight() > 2 {
+		return fmt.Errorf("fairness: low priority weight %d is out of range, valid range is [1, 2]", fairness.GetLowPriorityWeight())
+	}
+	return nil
+}
+
+// Fairness returns the fairness settings of the mux from the configuration.
+func Fairness(fairness *pb.Fairness) protocol.Fairness {
+	return protocol.Fairness{
+		MaxUnsentBytes:     int(fairness.GetMaxUnsentBytes()),
+		HighPriorityWeight: int(fairness.GetHighPriorityWeight()),
+		LowPriorityWeight:  int(fairness.GetLowPriorityWeight()),
+	}
+}
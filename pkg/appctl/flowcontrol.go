@@ -0,0 +1,64 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+)
+
+// maxBandwidthMbps is the maximum bandwidth in the flow control config.
+const maxBandwidthMbps = 100000
+
+// validateFlowControl validates the flow control config.
+// A nil config is valid.
+func validateFlowControl(config *pb.FlowControlConfig) error {
+	if config == nil {
+		return nil
+	}
+	values := []struct {
+		name  string
+		value int32
+	}{
+		{"send window", config.GetSendWindow()},
+		{"receive window", config.GetReceiveWindow()},
+		{"buffer size", config.GetBufferSize()},
+	}
+	for _, v := range values {
+		if v.value != 0 && (v.value < protocol.MinFlowControlWindow || v.value > protocol.MaxFlowControlWindow) {
+			return fmt.Errorf("flow control: %s %d is out of range, valid range is [%d, %d]", v.name, v.value, protocol.MinFlowControlWindow, protocol.MaxFlowControlWindow)
+		}
+	}
+	if config.GetBandwidthMbps() < 0 || config.GetBandwidthMbps() > maxBandwidthMbps {
+		return fmt.Errorf("flow control: bandwidth %d Mbps is out of range, valid range is [0, %d]", config.GetBandwidthMbps(), maxBandwidthMbps)
+	}
+	return nil
+}
+
+// FlowControl returns the send window, the receive window and
+// the buffer size from the configuration.
+// 0 means the default value is used.
+func FlowControl(config *pb.FlowControlConfig) (int, int, int) {
+	return int(config.GetSendWindow()), int(config.GetReceiveWindow()), int(config.GetBufferSize())
+}
+
+// Bandwidth returns the bandwidth in bytes per second from the
+// configuration. 0 means the default receive rate is used.
+func Bandwidth(config *pb.FlowControlConfig) int64 {
+	return int64(config.GetBandwidthMbps()) * 1000 * 1000 / 8
+}
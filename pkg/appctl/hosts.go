@@ -0,0 +1,66 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+)
+
+// validateHosts validates the static hosts of a client profile.
+func validateHosts(hosts []*pb.HostMapping) error {
+	names := make(map[string]struct{})
+	for _, mapping := range hosts {
+		name := mapping.GetDomainName()
+		if name == "" {
+			return fmt.Errorf("hosts: domain name is not set")
+		}
+		if net.ParseIP(name) != nil {
+			return fmt.Errorf("hosts: domain name %q is an IP address", name)
+		}
+		key := strings.ToLower(strings.TrimSuffix(name, "."))
+		if _, found := names[key]; found {
+			return fmt.Errorf("hosts: domain name %q is duplicated", name)
+		}
+		names[key] = struct{}{}
+		if len(mapping.GetIpAddresses()) == 0 {
+			return fmt.Errorf("hosts: IP address of domain name %q is not set", name)
+		}
+		for _, addr := range mapping.GetIpAddresses() {
+			if net.ParseIP(addr) == nil {
+				return fmt.Errorf("hosts: IP address %q of domain name %q is invalid", addr, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ClientHosts returns the static hosts of the client profile.
+func ClientHosts(profile *pb.ClientProfile) *common.Hosts {
+	m := make(map[string][]net.IP)
+	for _, mapping := range profile.GetHosts() {
+		for _, addr := range mapping.GetIpAddresses() {
+			if ip := net.ParseIP(addr); ip != nil {
+				m[mapping.GetDomainName()] = append(m[mapping.GetDomainName()], ip)
+			}
+		}
+	}
+	return common.NewHosts(m)
+}
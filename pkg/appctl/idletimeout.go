@@ -0,0 +1,53 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+const (
+	// maxIdleTimeoutSeconds is the maximum idle timeout of a session
+	// or a connection.
+	maxIdleTimeoutSeconds = 86400
+
+	// minUnderlayIdleTimeoutSeconds is the minimum idle timeout of
+	// a connection.
+	minUnderlayIdleTimeoutSeconds = 10
+)
+
+// validateIdleTimeout validates the idle timeout. A nil timeout is valid.
+func validateIdleTimeout(timeout *pb.IdleTimeout) error {
+	if timeout == nil {
+		return nil
+	}
+	if timeout.GetSessionSeconds() < 0 || timeout.GetSessionSeconds() > maxIdleTimeoutSeconds {
+		return fmt.Errorf("idle timeout: session seconds %d is out of range, valid range is [0, %d]", timeout.GetSessionSeconds(), maxIdleTimeoutSeconds)
+	}
+	if timeout.GetUnderlaySeconds() != 0 && (timeout.GetUnderlaySeconds() < minUnderlayIdleTimeoutSeconds || timeout.GetUnderlaySeconds() > maxIdleTimeoutSeconds) {
+		return fmt.Errorf("idle timeout: underlay seconds %d is out of range, valid range is [%d, %d]", timeout.GetUnderlaySeconds(), minUnderlayIdleTimeoutSeconds, maxIdleTimeoutSeconds)
+	}
+	return nil
+}
+
+// IdleTimeout returns the idle timeout of sessions and the idle timeout
+// of underlays from the configuration. 0 means the default value is used.
+func IdleTimeout(timeout *pb.IdleTimeout) (time.Duration, time.Duration) {
+	return time.Duration(timeout.GetSessionSeconds()) * time.Second, time.Duration(timeout.GetUnderlaySeconds()) * time.Second
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"os"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+// LogFormatter returns the log formatter of a daemon from the log format
+// in the configuration. If noTimestamp is true, the time is not printed.
+func LogFormatter(format pb.LogFormat, noTimestamp bool) log.Formatter {
+	if format == pb.LogFormat_JSON {
+		return &log.JSONFormatter{NoTimestamp: noTimestamp}
+	}
+	return &log.DaemonFormatter{NoTimestamp: noTimestamp}
+}
+
+// ServerLogFormatter returns the log formatter of mita daemon.
+// The time is not printed if MITA_LOG_NO_TIMESTAMP environment variable
+// is set, for example when the log is collected by journald.
+func ServerLogFormatter(format pb.LogFormat) log.Formatter {
+	_, noTimestamp := os.LookupEnv("MITA_LOG_NO_TIMESTAMP")
+	return LogFormatter(format, noTimestamp)
+}
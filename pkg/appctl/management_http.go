@@ -0,0 +1,338 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ManagementAPIPrefix is the path prefix of the management API.
+	ManagementAPIPrefix = "/api/v1"
+
+	// maxManagementRequestSize is the max size of a management API request body.
+	maxManagementRequestSize = 4 * 1024 * 1024
+)
+
+// managementEndpoint serves one path of the management API.
+// GET is used to read and POST is used to apply a change.
+type managementEndpoint struct {
+	get  func(ctx context.Context) ([]byte, error)
+	post func(ctx context.Context, body []byte) ([]byte, error)
+}
+
+// NewClientManagementHTTPServer returns a HTTP server that serves the
+// management API of mieru client in JSON. It mirrors the RPC of
+// ClientLifecycleService and the commands that manage client config.
+// Every request must carry the token in the Authorization header,
+// because the TCP port can be reached by every user of the computer.
+// The passwords are removed from the returned client config.
+func NewClientManagementHTTPServer(addr, token string) *http.Server {
+	svc := NewClientLifecycleService()
+	return newManagementHTTPServer(addr, token, map[string]managementEndpoint{
+		"/status": {
+			get: func(ctx context.Context) ([]byte, error) {
+				return marshalManagementResponse(svc.GetStatus(ctx, &pb.Empty{}))
+			},
+		},
+		"/config": {
+			get: func(ctx context.Context) ([]byte, error) {
+				return marshalManagementResponse(redactClientConfig(LoadClientConfig()))
+			},
+			post: func(ctx context.Context, body []byte) ([]byte, error) {
+				patch := &pb.ClientConfig{}
+				if err := common.UnmarshalJSON(body, patch); err != nil {
+					return nil, &managementError{code: http.StatusBadRequest, err: err}
+				}
+				if config, err := LoadClientConfig(); err == nil {
+					keepClientPasswords(patch, config)
+				}
+				if err := ApplyClientConfig(patch); err != nil {
+					return nil, &managementError{code: http.StatusBadRequest, err: err}
+				}
+				return marshalManagementResponse(redactClientConfig(LoadClientConfig()))
+			},
+		},
+		"/metrics": {
+			get: func(ctx context.Context) ([]byte, error) {
+				m, err := svc.GetMetrics(ctx, &pb.Empty{})
+				if err != nil {
+					return nil, err
+				}
+				return []byte(m.GetJson()), nil
+			},
+		},
+		"/connections": {
+			get: func(ctx context.Context) ([]byte, error) {
+				states, err := svc.GetSessionStates(ctx, &pb.SessionStateRequest{})
+				if err != nil {
+					return nil, &managementError{code: http.StatusServiceUnavailable, err: err}
+				}
+				return []byte(states.GetJson()), nil
+			},
+		},
+	})
+}
+
+// NewServerManagementHTTPServer returns a HTTP server that serves the
+// management API of mita server in JSON. It mirrors the RPC of
+// ServerLifecycleService and ServerConfigService. The server must
+// serve a listener of ServerManagementUDS(), so the API has the same
+// access control as the RPC. The passwords are removed from the
+// returned server config.
+func NewServerManagementHTTPServer() *http.Server {
+	lifecycle := NewServerLifecycleService()
+	config := NewServerConfigService()
+	return newManagementHTTPServer("", "", map[string]managementEndpoint{
+		"/status": {
+			get: func(ctx context.Context) ([]byte, error) {
+				return marshalManagementResponse(lifecycle.GetStatus(ctx, &pb.Empty{}))
+			},
+		},
+		"/config": {
+			get: func(ctx context.Context) ([]byte, error) {
+				return marshalManagementResponse(redactServerConfig(config.GetConfig(ctx, &pb.Empty{})))
+			},
+			post: func(ctx context.Context, body []byte) ([]byte, error) {
+				patch := &pb.ServerConfig{}
+				if err := common.UnmarshalJSON(body, patch); err != nil {
+					return nil, &managementError{code: http.StatusBadRequest, err: err}
+				}
+				if err := ValidateServerConfigPatch(patch); err != nil {
+					return nil, &managementError{code: http.StatusBadRequest, err: err}
+				}
+				return marshalManagementResponse(redactServerConfig(config.SetConfig(ctx, patch)))
+			},
+		},
+		"/metrics": {
+			get: func(ctx context.Context) ([]byte, error) {
+				m, err := lifecycle.GetMetrics(ctx, &pb.Empty{})
+				if err != nil {
+					return nil, err
+				}
+				return []byte(m.GetJson()), nil
+			},
+		},
+		"/connections": {
+			get: func(ctx context.Context) ([]byte, error) {
+				states, err := lifecycle.GetSessionStates(ctx, &pb.SessionStateRequest{})
+				if err != nil {
+					return nil, &managementError{code: http.StatusServiceUnavailable, err: err}
+				}
+				return []byte(states.GetJson()), nil
+			},
+		},
+	})
+}
+
+// newManagementHTTPServer returns a HTTP server of the endpoints.
+// If token is not empty, the requests must carry the bearer token.
+func newManagementHTTPServer(addr, token string, endpoints map[string]managementEndpoint) *http.Server {
+	mux := http.NewServeMux()
+	for path, endpoint := range endpoints {
+		mux.Handle(ManagementAPIPrefix+path, endpoint)
+	}
+	var handler http.Handler = mux
+	if token != "" {
+		handler = bearerTokenOnly(token, handler)
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           localhostOnly(handler),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+func (e managementEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b []byte
+	var err error
+	switch {
+	case r.Method == http.MethodGet && e.get != nil:
+		b, err = e.get(r.Context())
+	case r.Method == http.MethodPost && e.post != nil:
+		// Only accept JSON, so a web page can't apply a change
+		// with a simple cross-site form submission.
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeManagementError(w, http.StatusUnsupportedMediaType, fmt.Errorf("content type must be application/json"))
+			return
+		}
+		var body []byte
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxManagementRequestSize))
+		if err == nil {
+			b, err = e.post(r.Context(), body)
+		}
+	default:
+		writeManagementError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		if me, ok := err.(*managementError); ok {
+			code = me.code
+			err = me.err
+		}
+		log.Debugf("management API %s %s failed: %v", r.Method, r.URL.Path, err)
+		writeManagementError(w, code, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// localhostOnly rejects the requests that are not sent to localhost,
+// to protect the management API from DNS rebinding.
+func localhostOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if host != "localhost" {
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				writeManagementError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerTokenOnly rejects the requests that don't carry the token
+// in the Authorization header.
+func bearerTokenOnly(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeManagementError(w, http.StatusUnauthorized, fmt.Errorf("bearer token is missing or invalid"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// managementError is an error with the HTTP status code.
+type managementError struct {
+	code int
+	err  error
+}
+
+func (e *managementError) Error() string {
+	return e.err.Error()
+}
+
+// redactClientConfig returns a copy of the client config without
+// the passwords of users and socks5 authentication.
+func redactClientConfig(config *pb.ClientConfig, err error) (*pb.ClientConfig, error) {
+	if err != nil {
+		return nil, err
+	}
+	redacted := proto.Clone(config).(*pb.ClientConfig)
+	for _, profile := range redacted.GetProfiles() {
+		if profile.GetUser() != nil {
+			profile.GetUser().Password = nil
+			profile.GetUser().HashedPassword = nil
+		}
+	}
+	for _, auth := range redacted.GetSocks5Authentication() {
+		auth.Password = nil
+	}
+	return redacted, nil
+}
+
+// keepClientPasswords copies the stored passwords to the patch,
+// if the patch is a redacted config that doesn't have the passwords.
+// The stored password is only kept when the user name is not changed.
+func keepClientPasswords(patch, stored *pb.ClientConfig) {
+	storedUsers := map[string]*pb.User{}
+	for _, profile := range stored.GetProfiles() {
+		storedUsers[profile.GetProfileName()] = profile.GetUser()
+	}
+	for _, profile := range patch.GetProfiles() {
+		user := profile.GetUser()
+		if user == nil || user.Password != nil || user.HashedPassword != nil {
+			continue
+		}
+		if storedUser, ok := storedUsers[profile.GetProfileName()]; ok && storedUser.GetName() == user.GetName() {
+			user.Password = storedUser.Password
+			user.HashedPassword = storedUser.HashedPassword
+		}
+	}
+	storedAuths := map[string]*pb.Auth{}
+	for _, auth := range stored.GetSocks5Authentication() {
+		storedAuths[auth.GetUser()] = auth
+	}
+	for _, auth := range patch.GetSocks5Authentication() {
+		if auth.Password != nil {
+			continue
+		}
+		if storedAuth, ok := storedAuths[auth.GetUser()]; ok {
+			auth.Password = storedAuth.Password
+		}
+	}
+}
+
+// redactServerConfig returns a copy of the server config without
+// the passwords of users and egress proxies.
+func redactServerConfig(config *pb.ServerConfig, err error) (*pb.ServerConfig, error) {
+	if err != nil {
+		return nil, err
+	}
+	redacted := proto.Clone(config).(*pb.ServerConfig)
+	for _, user := range redacted.GetUsers() {
+		user.Password = nil
+		user.HashedPassword = nil
+		user.NextPassword = nil
+		user.NextHashedPassword = nil
+	}
+	for _, proxy := range redacted.GetEgress().GetProxies() {
+		if proxy.GetSocks5Authentication() != nil {
+			proxy.GetSocks5Authentication().Password = nil
+		}
+		if proxy.GetHttpAuthentication() != nil {
+			proxy.GetHttpAuthentication().Password = nil
+		}
+	}
+	return redacted, nil
+}
+
+func marshalManagementResponse[T proto.Message](m T, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return common.MarshalJSON(m)
+}
+
+func writeManagementError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Write(b)
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+)
+
+func TestClientManagementHTTPServer(t *testing.T) {
+	beforeClientTest(t)
+	defer afterClientTest(t)
+	token, err := ClientManagementToken()
+	if err != nil {
+		t.Fatalf("ClientManagementToken() failed: %v", err)
+	}
+	defer os.Remove(filepath.Join(cachedClientConfigDir, "management.token"))
+	if token2, err := ClientManagementToken(); err != nil || token2 != token {
+		t.Fatalf("ClientManagementToken() = %q, %v, want %q", token2, err, token)
+	}
+	handler := NewClientManagementHTTPServer("127.0.0.1:0", token).Handler
+
+	serveWithToken := func(token, method, host, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://"+host+ManagementAPIPrefix+path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	serve := func(method, host, path, contentType, body string) *httptest.ResponseRecorder {
+		return serveWithToken(token, method, host, path, contentType, body)
+	}
+
+	// Metrics are always available.
+	w := serve(http.MethodGet, "127.0.0.1:8080", "/metrics", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics returned %d: %s", w.Code, w.Body.String())
+	}
+	if !json.Valid(w.Body.Bytes()) {
+		t.Errorf("GET /metrics returned invalid JSON: %s", w.Body.String())
+	}
+
+	// Apply a client config.
+	patch := `{"activeProfile": "default", "profiles": [{"profileName": "default", "user": {"name": "abc", "password": "xyz"}, "servers": [{"ipAddress": "1.2.3.4", "portBindings": [{"port": 6666, "protocol": "TCP"}]}]}], "socks5Port": 1080}`
+	w = serve(http.MethodPost, "localhost", "/config", "application/json", patch)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /config returned %d: %s", w.Code, w.Body.String())
+	}
+	w = serve(http.MethodGet, "[::1]:8080", "/config", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /config returned %d: %s", w.Code, w.Body.String())
+	}
+	config := &pb.ClientConfig{}
+	if err := common.UnmarshalJSON(w.Body.Bytes(), config); err != nil {
+		t.Fatalf("UnmarshalJSON() failed: %v", err)
+	}
+	if config.GetActiveProfile() != "default" || config.GetSocks5Port() != 1080 {
+		t.Errorf("GET /config returned unexpected config %v", config)
+	}
+	if user := config.GetProfiles()[0].GetUser(); user.GetName() != "abc" || user.Password != nil || user.HashedPassword != nil {
+		t.Errorf("GET /config returned the password: %s", w.Body.String())
+	}
+
+	// Apply the redacted config back, the stored password is kept.
+	w = serve(http.MethodPost, "localhost", "/config", "application/json", w.Body.String())
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /config with redacted config returned %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := LoadClientConfig()
+	if err != nil {
+		t.Fatalf("LoadClientConfig() failed: %v", err)
+	}
+	if stored.GetProfiles()[0].GetUser().GetPassword() != "xyz" && stored.GetProfiles()[0].GetUser().GetHashedPassword() == "" {
+		t.Errorf("password is removed from the stored config")
+	}
+
+	// Requests without a valid token are rejected.
+	for _, tok := range []string{"", "wrong"} {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			w := serveWithToken(tok, method, "localhost", "/config", "application/json", patch)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%s /config with token %q returned %d, want %d", method, tok, w.Code, http.StatusUnauthorized)
+			}
+		}
+	}
+
+	// Invalid requests.
+	testCases := []struct {
+		method      string
+		host        string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{http.MethodGet, "example.com", "/config", "", "", http.StatusForbidden},
+		{http.MethodDelete, "localhost", "/config", "", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "localhost", "/metrics", "application/json", "{}", http.StatusMethodNotAllowed},
+		{http.MethodPost, "localhost", "/config", "text/plain", "{}", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "localhost", "/config", "application/json", "{", http.StatusBadRequest},
+		{http.MethodPost, "localhost", "/config", "application/json", `{"socks5Port": 100000}`, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		w := serve(tc.method, tc.host, tc.path, tc.contentType, tc.body)
+		if w.Code != tc.want {
+			t.Errorf("%s %s to host %s returned %d, want %d", tc.method, tc.path, tc.host, w.Code, tc.want)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Errorf("%s %s returned invalid JSON: %s", tc.method, tc.path, w.Body.String())
+		}
+	}
+}
+
+func TestServerManagementHTTPServerRedactsPasswords(t *testing.T) {
+	beforeServerTest(t)
+	defer afterServerTest(t)
+	handler := NewServerManagementHTTPServer().Handler
+
+	patch := `{"portBindings": [{"port": 8964, "protocol": "TCP"}], "users": [{"name": "user1", "password": "fa7206ed2a94", "nextPassword": "0a5a0bc31b4d"}], "egress": {"proxies": [{"name": "cloudflare", "protocol": "SOCKS5_PROXY_PROTOCOL", "host": "127.0.0.1", "port": 4000, "socks5Authentication": {"user": "shilishanlu", "password": "buhuanjian"}}]}}`
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "http://localhost"+ManagementAPIPrefix+"/config", strings.NewReader(patch)),
+		httptest.NewRequest(http.MethodGet, "http://localhost"+ManagementAPIPrefix+"/config", nil),
+	} {
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s /config returned %d: %s", req.Method, w.Code, w.Body.String())
+		}
+		for _, secret := range []string{"fa7206ed2a94", "0a5a0bc31b4d", "buhuanjian", "hashedPassword"} {
+			if strings.Contains(w.Body.String(), secret) {
+				t.Errorf("%s /config returned %q: %s", req.Method, secret, w.Body.String())
+			}
+		}
+		if !strings.Contains(w.Body.String(), "user1") {
+			t.Errorf("%s /config doesn't return the user name: %s", req.Method, w.Body.String())
+		}
+	}
+
+	// The passwords are kept in the stored config.
+	config, err := LoadServerConfig()
+	if err != nil {
+		t.Fatalf("LoadServerConfig() failed: %v", err)
+	}
+	if config.GetUsers()[0].GetHashedPassword() == "" && config.GetUsers()[0].GetPassword() == "" {
+		t.Errorf("password is removed from the stored config")
+	}
+}
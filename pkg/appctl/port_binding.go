@@ -54,26 +54,9 @@ func FlatPortBindings(bindings []*pb.PortBinding) ([]*pb.PortBinding, error) {
 				return res, fmt.Errorf("unknown protocol %s", binding.GetProtocol().String())
 			}
 		} else {
-			matches := validPortRange.FindStringSubmatch(binding.GetPortRange())
-			if len(matches) != 3 {
-				return res, fmt.Errorf("unable to parse port range %q", binding.GetPortRange())
-			}
-			small, err := strconv.Atoi(matches[1])
-			if err != nil {
-				return res, fmt.Errorf("unable to parse int from %q", matches[1])
-			}
-			big, err := strconv.Atoi(matches[2])
+			small, big, err := parsePortRange(binding.GetPortRange())
 			if err != nil {
-				return res, fmt.Errorf("unable to parse int from %q", matches[2])
-			}
-			if small < 1 || small > 65535 {
-				return res, fmt.Errorf("port number %d is invalid", small)
-			}
-			if big < 1 || big > 65535 {
-				return res, fmt.Errorf("port number %d is invalid", big)
-			}
-			if small > big {
-				return res, fmt.Errorf("begin of port range %d is bigger than end of port range %d", small, big)
+				return res, err
 			}
 			switch binding.GetProtocol() {
 			case pb.TransportProtocol_TCP:
@@ -113,3 +96,84 @@ func FlatPortBindings(bindings []*pb.PortBinding) ([]*pb.PortBinding, error) {
 	}
 	return res, nil
 }
+
+// ClientPortBindings checks port bindings used by the client. Different from
+// FlatPortBindings, a port range is kept as a whole, so the client can pick
+// a random port from the range. The result is sorted by protocol and port,
+// and duplicated bindings are removed.
+func ClientPortBindings(bindings []*pb.PortBinding) ([]*pb.PortBinding, error) {
+	if _, err := FlatPortBindings(bindings); err != nil {
+		return nil, err
+	}
+	type span struct {
+		protocol   pb.TransportProtocol
+		begin, end int
+	}
+	spans := make([]span, 0)
+	seen := make(map[span]struct{})
+	for _, binding := range bindings {
+		sp := span{protocol: binding.GetProtocol()}
+		if binding.GetPort() != 0 {
+			sp.begin, sp.end = int(binding.GetPort()), int(binding.GetPort())
+		} else {
+			sp.begin, sp.end, _ = parsePortRange(binding.GetPortRange())
+		}
+		if _, ok := seen[sp]; ok {
+			continue
+		}
+		seen[sp] = struct{}{}
+		spans = append(spans, sp)
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].protocol != spans[j].protocol {
+			// TCP goes before UDP.
+			return spans[i].protocol == pb.TransportProtocol_TCP
+		}
+		if spans[i].begin != spans[j].begin {
+			return spans[i].begin < spans[j].begin
+		}
+		return spans[i].end < spans[j].end
+	})
+	res := make([]*pb.PortBinding, 0, len(spans))
+	for _, sp := range spans {
+		if sp.begin == sp.end {
+			res = append(res, &pb.PortBinding{
+				Port:     proto.Int32(int32(sp.begin)),
+				Protocol: sp.protocol.Enum(),
+			})
+		} else {
+			res = append(res, &pb.PortBinding{
+				PortRange: proto.String(fmt.Sprintf("%d-%d", sp.begin, sp.end)),
+				Protocol:  sp.protocol.Enum(),
+			})
+		}
+	}
+	return res, nil
+}
+
+// parsePortRange returns the begin and end of a port range
+// in "start-end" format.
+func parsePortRange(portRange string) (int, int, error) {
+	matches := validPortRange.FindStringSubmatch(portRange)
+	if len(matches) != 3 {
+		return 0, 0, fmt.Errorf("unable to parse port range %q", portRange)
+	}
+	small, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse int from %q", matches[1])
+	}
+	big, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to parse int from %q", matches[2])
+	}
+	if small < 1 || small > 65535 {
+		return 0, 0, fmt.Errorf("port number %d is invalid", small)
+	}
+	if big < 1 || big > 65535 {
+		return 0, 0, fmt.Errorf("port number %d is invalid", big)
+	}
+	if small > big {
+		return 0, 0, fmt.Errorf("begin of port range %d is bigger than end of port range %d", small, big)
+	}
+	return small, big, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"google.golang.org/protobuf/proto"
+)
+
+// validatePreset validates the profile preset.
+func validatePreset(preset pb.ProfilePreset) error {
+	if _, ok := pb.ProfilePreset_name[int32(preset)]; !ok {
+		return fmt.Errorf("profile preset %d is unknown", preset)
+	}
+	return nil
+}
+
+// ParsePreset returns the profile preset from a name like "low-latency".
+func ParsePreset(name string) (pb.ProfilePreset, error) {
+	v, ok := pb.ProfilePreset_value[strings.ToUpper(strings.ReplaceAll(name, "-", "_"))]
+	if !ok || v == int32(pb.ProfilePreset_NO_PRESET) {
+		return pb.ProfilePreset_NO_PRESET, fmt.Errorf("profile preset %q is unknown, valid presets are %s", name, strings.Join(PresetNames(), ", "))
+	}
+	return pb.ProfilePreset(v), nil
+}
+
+// PresetNames returns the names of all the profile presets.
+func PresetNames() []string {
+	presets := []pb.ProfilePreset{pb.ProfilePreset_BALANCED, pb.ProfilePreset_LOW_LATENCY, pb.ProfilePreset_LOW_BANDWIDTH, pb.ProfilePreset_PARANOID}
+	names := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		names = append(names, PresetName(preset))
+	}
+	return names
+}
+
+// PresetName returns the name of the profile preset, like "low-latency".
+func PresetName(preset pb.ProfilePreset) string {
+	return strings.ToLower(strings.ReplaceAll(preset.String(), "_", "-"))
+}
+
+// presetProfile returns the settings of the profile preset.
+func presetProfile(preset pb.ProfilePreset) *pb.ClientProfile {
+	switch preset {
+	case pb.ProfilePreset_BALANCED:
+		return &pb.ClientProfile{
+			Multiplexing: &pb.MultiplexingConfig{
+				Level:            pb.MultiplexingLevel_MULTIPLEXING_MIDDLE.Enum(),
+				SpareConnections: proto.Int32(1),
+			},
+			EndpointPolicy: pb.EndpointPolicy_ENDPOINT_LOWEST_LATENCY.Enum(),
+		}
+	case pb.ProfilePreset_LOW_LATENCY:
+		return &pb.ClientProfile{
+			Multiplexing: &pb.MultiplexingConfig{
+				Level:            pb.MultiplexingLevel_MULTIPLEXING_LOW.Enum(),
+				SpareConnections: proto.Int32(2),
+			},
+			FecGroupSize:   proto.Int32(8),
+			EndpointPolicy: pb.EndpointPolicy_ENDPOINT_LOWEST_LATENCY.Enum(),
+		}
+	case pb.ProfilePreset_LOW_BANDWIDTH:
+		return &pb.ClientProfile{
+			Multiplexing: &pb.MultiplexingConfig{
+				Level:            pb.MultiplexingLevel_MULTIPLEXING_HIGH.Enum(),
+				SpareConnections: proto.Int32(0),
+			},
+			FecGroupSize: proto.Int32(0),
+			FlowControl: &pb.FlowControlConfig{
+				SendWindow:    proto.Int32(256),
+				ReceiveWindow: proto.Int32(256),
+				BufferSize:    proto.Int32(256),
+			},
+			IdleTimeout: &pb.IdleTimeout{
+				UnderlaySeconds: proto.Int32(600),
+			},
+		}
+	case pb.ProfilePreset_PARANOID:
+		return &pb.ClientProfile{
+			Multiplexing: &pb.MultiplexingConfig{
+				Level:                        pb.MultiplexingLevel_MULTIPLEXING_LOW.Enum(),
+				MaxConnectionLifetimeSeconds: proto.Int32(600),
+			},
+			IdleTimeout: &pb.IdleTimeout{
+				UnderlaySeconds: proto.Int32(60),
+			},
+			HybridKeyExchange: proto.Bool(cipher.HybridKeyExchangeSupported),
+			Rekey: &pb.RekeyConfig{
+				Megabytes: proto.Int32(256),
+				Minutes:   proto.Int32(30),
+			},
+			EndpointPolicy: pb.EndpointPolicy_ENDPOINT_RANDOM.Enum(),
+		}
+	default:
+		return &pb.ClientProfile{}
+	}
+}
+
+// ApplyPreset returns a copy of the client profile with the settings of
+// its preset. Settings that are set explicitly in the profile are kept.
+func ApplyPreset(profile *pb.ClientProfile) *pb.ClientProfile {
+	if profile.GetPreset() == pb.ProfilePreset_NO_PRESET {
+		return profile
+	}
+	res := presetProfile(profile.GetPreset())
+	proto.Merge(res, profile)
+	return res
+}
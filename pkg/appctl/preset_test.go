@@ -0,0 +1,110 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"testing"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func presetTestProfile() *pb.ClientProfile {
+	return &pb.ClientProfile{
+		ProfileName: proto.String("default"),
+		User: &pb.User{
+			Name:     proto.String("user1"),
+			Password: proto.String("fa7206ed2a94"),
+		},
+		Servers: []*pb.ServerEndpoint{
+			{
+				IpAddress: proto.String("127.0.0.1"),
+				PortBindings: []*pb.PortBinding{
+					{Port: proto.Int32(8964), Protocol: pb.TransportProtocol_UDP.Enum()},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	for _, name := range PresetNames() {
+		preset, err := ParsePreset(name)
+		if err != nil {
+			t.Fatalf("ParsePreset(%q) failed: %v", name, err)
+		}
+		profile := presetTestProfile()
+		profile.Preset = preset.Enum()
+		applied := ApplyPreset(profile)
+		if err := ValidateClientConfigSingleProfile(applied); err != nil {
+			t.Errorf("profile with preset %s is invalid: %v", name, err)
+		}
+		if applied.GetProfileName() != "default" || len(applied.GetServers()) != 1 {
+			t.Errorf("preset %s changed the profile name or servers", name)
+		}
+		if proto.Equal(applied, profile) {
+			t.Errorf("preset %s doesn't change any setting", name)
+		}
+	}
+
+	// Settings in the profile take precedence over the preset.
+	profile := presetTestProfile()
+	profile.Preset = pb.ProfilePreset_LOW_LATENCY.Enum()
+	profile.FecGroupSize = proto.Int32(0)
+	profile.Multiplexing = &pb.MultiplexingConfig{
+		Level: pb.MultiplexingLevel_MULTIPLEXING_OFF.Enum(),
+	}
+	applied := ApplyPreset(profile)
+	if applied.FecGroupSize == nil || applied.GetFecGroupSize() != 0 {
+		t.Errorf("FEC group size = %d, want 0 set by the profile", applied.GetFecGroupSize())
+	}
+	if applied.GetMultiplexing().GetLevel() != pb.MultiplexingLevel_MULTIPLEXING_OFF {
+		t.Errorf("multiplexing level = %v, want %v", applied.GetMultiplexing().GetLevel(), pb.MultiplexingLevel_MULTIPLEXING_OFF)
+	}
+	if applied.GetMultiplexing().GetSpareConnections() != 2 {
+		t.Errorf("spare connections = %d, want 2 set by the preset", applied.GetMultiplexing().GetSpareConnections())
+	}
+	if profile.GetMultiplexing().SpareConnections != nil {
+		t.Errorf("ApplyPreset() modified the original profile")
+	}
+
+	// A profile without preset is not changed.
+	profile = presetTestProfile()
+	if got := ApplyPreset(profile); got != profile {
+		t.Errorf("ApplyPreset() returned a different profile without preset")
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    pb.ProfilePreset
+		wantErr bool
+	}{
+		{"balanced", pb.ProfilePreset_BALANCED, false},
+		{"low-latency", pb.ProfilePreset_LOW_LATENCY, false},
+		{"LOW_BANDWIDTH", pb.ProfilePreset_LOW_BANDWIDTH, false},
+		{"Paranoid", pb.ProfilePreset_PARANOID, false},
+		{"no-preset", pb.ProfilePreset_NO_PRESET, true},
+		{"fast", pb.ProfilePreset_NO_PRESET, true},
+	}
+	for _, tc := range testCases {
+		got, err := ParsePreset(tc.name)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParsePreset(%q) = %v, %v, want %v and error %v", tc.name, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+const (
+	// maxRekeyMegabytes is the maximum number of megabytes
+	// between two rekeys.
+	maxRekeyMegabytes = 1024 * 1024
+
+	// maxRekeyMinutes is the maximum number of minutes
+	// between two rekeys.
+	maxRekeyMinutes = 1440
+)
+
+// validateRekey validates the rekey config. A nil config is valid.
+func validateRekey(rekey *pb.RekeyConfig) error {
+	if rekey == nil {
+		return nil
+	}
+	if rekey.GetMegabytes() < 0 || rekey.GetMegabytes() > maxRekeyMegabytes {
+		return fmt.Errorf("rekey: megabytes %d is out of range, valid range is [0, %d]", rekey.GetMegabytes(), maxRekeyMegabytes)
+	}
+	if rekey.GetMinutes() < 0 || rekey.GetMinutes() > maxRekeyMinutes {
+		return fmt.Errorf("rekey: minutes %d is out of range, valid range is [0, %d]", rekey.GetMinutes(), maxRekeyMinutes)
+	}
+	return nil
+}
+
+// Rekey returns the number of bytes and the interval between two rekeys
+// from the configuration. 0 means the limit is not used.
+func Rekey(rekey *pb.RekeyConfig) (int64, time.Duration) {
+	return int64(rekey.GetMegabytes()) * 1024 * 1024, time.Duration(rekey.GetMinutes()) * time.Minute
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"github.com/enfein/mieru/v3/pkg/replay"
+)
+
+const (
+	minReplayCacheExpireSeconds = 60
+	maxReplayCacheExpireSeconds = 3600
+)
+
+// validateReplayCache validates the replay cache configuration.
+// A nil configuration is valid.
+func validateReplayCache(config *pb.ReplayCacheConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.GetCapacity() != 0 && (config.GetCapacity() < protocol.MinReplayCacheCapacity || config.GetCapacity() > protocol.MaxReplayCacheCapacity) {
+		return fmt.Errorf("replay cache: capacity %d is out of range, valid range is [%d, %d]", config.GetCapacity(), protocol.MinReplayCacheCapacity, protocol.MaxReplayCacheCapacity)
+	}
+	if config.GetExpireSeconds() != 0 && (config.GetExpireSeconds() < minReplayCacheExpireSeconds || config.GetExpireSeconds() > maxReplayCacheExpireSeconds) {
+		return fmt.Errorf("replay cache: expire seconds %d is out of range, valid range is [%d, %d]", config.GetExpireSeconds(), minReplayCacheExpireSeconds, maxReplayCacheExpireSeconds)
+	}
+	if _, ok := pb.ReplayCachePolicy_name[int32(config.GetPolicy())]; !ok {
+		return fmt.Errorf("replay cache: policy %d is unknown", config.GetPolicy())
+	}
+	return nil
+}
+
+// ReplayCache returns the capacity, the expire interval and the eviction
+// policy of replay caches from the configuration.
+// 0 means the default value is used.
+func ReplayCache(config *pb.ReplayCacheConfig) (int, time.Duration, replay.Policy) {
+	policy := replay.PolicyRotate
+	if config.GetPolicy() == pb.ReplayCachePolicy_LRU {
+		policy = replay.PolicyLRU
+	}
+	return int(config.GetCapacity()), time.Duration(config.GetExpireSeconds()) * time.Second, policy
+}
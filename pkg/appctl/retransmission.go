@@ -0,0 +1,46 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+)
+
+// validateRetransmissionLimit validates the retransmission limit.
+// A nil limit is valid.
+func validateRetransmissionLimit(limit *pb.RetransmissionLimit) error {
+	if limit == nil {
+		return nil
+	}
+	if limit.GetMaxCount() != 0 && (limit.GetMaxCount() < 2 || limit.GetMaxCount() > protocol.MaxTxCountLimit) {
+		return fmt.Errorf("retransmission limit: max count %d is out of range, valid range is [2, %d]", limit.GetMaxCount(), protocol.MaxTxCountLimit)
+	}
+	if limit.GetMaxSeconds() < 0 {
+		return fmt.Errorf("retransmission limit: max seconds %d is negative", limit.GetMaxSeconds())
+	}
+	return nil
+}
+
+// RetransmissionLimit returns the maximum number of transmissions and
+// the maximum time to wait for acknowledgement from the configuration.
+// 0 means the default value is used.
+func RetransmissionLimit(limit *pb.RetransmissionLimit) (int, time.Duration) {
+	return int(limit.GetMaxCount()), time.Duration(limit.GetMaxSeconds()) * time.Second
+}
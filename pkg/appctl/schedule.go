@@ -0,0 +1,139 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+// ScheduledState is the profile and proxy state selected by
+// the profile schedules at a point of time.
+type ScheduledState struct {
+	// ProfileName is the profile to use.
+	ProfileName string
+
+	// ProxyDisabled is true if new proxy connections are rejected.
+	ProxyDisabled bool
+}
+
+// clientScheduledState holds the state applied by the running client.
+var clientScheduledState atomic.Pointer[ScheduledState]
+
+// SetClientScheduledState records the state applied by the running client.
+func SetClientScheduledState(state ScheduledState) {
+	clientScheduledState.Store(&state)
+}
+
+// ScheduledStateAt returns the state selected by the profile schedules
+// at the given time. If no schedule matches, the active profile is used.
+// The client config must be validated.
+func ScheduledStateAt(config *pb.ClientConfig, now time.Time) ScheduledState {
+	for _, schedule := range config.GetProfileSchedules() {
+		if !scheduleMatches(schedule, now) {
+			continue
+		}
+		if schedule.GetDisableProxy() {
+			return ScheduledState{ProfileName: config.GetActiveProfile(), ProxyDisabled: true}
+		}
+		return ScheduledState{ProfileName: schedule.GetProfileName()}
+	}
+	return ScheduledState{ProfileName: config.GetActiveProfile()}
+}
+
+// validateProfileSchedule validates a single profile schedule.
+// It doesn't check if the profile exists.
+func validateProfileSchedule(schedule *pb.ProfileSchedule) error {
+	for _, day := range schedule.GetDays() {
+		if _, err := parseWeekday(day); err != nil {
+			return err
+		}
+	}
+	if _, err := parseClockTime(schedule.GetStartTime()); err != nil {
+		return fmt.Errorf("profile schedule start time: %w", err)
+	}
+	if _, err := parseClockTime(schedule.GetEndTime()); err != nil {
+		return fmt.Errorf("profile schedule end time: %w", err)
+	}
+	if schedule.GetProfileName() == "" && !schedule.GetDisableProxy() {
+		return fmt.Errorf("profile schedule must set either profile name or disable proxy")
+	}
+	if schedule.GetProfileName() != "" && schedule.GetDisableProxy() {
+		return fmt.Errorf("profile schedule can't set both profile name and disable proxy")
+	}
+	return nil
+}
+
+// scheduleMatches returns true if the time is within the time window
+// of the schedule. A time window that passes midnight belongs to
+// the day it starts.
+func scheduleMatches(schedule *pb.ProfileSchedule, now time.Time) bool {
+	start, err := parseClockTime(schedule.GetStartTime())
+	if err != nil {
+		return false
+	}
+	end, err := parseClockTime(schedule.GetEndTime())
+	if err != nil {
+		return false
+	}
+	minute := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if start < end {
+		return minute >= start && minute < end && scheduleHasDay(schedule, now.Weekday())
+	}
+	// The time window ends at the next day.
+	if minute >= start && scheduleHasDay(schedule, now.Weekday()) {
+		return true
+	}
+	yesterday := (now.Weekday() + 6) % 7
+	return minute < end && scheduleHasDay(schedule, yesterday)
+}
+
+// scheduleHasDay returns true if the time window starts at the day.
+func scheduleHasDay(schedule *pb.ProfileSchedule, day time.Weekday) bool {
+	if len(schedule.GetDays()) == 0 {
+		return true
+	}
+	for _, d := range schedule.GetDays() {
+		if wd, err := parseWeekday(d); err == nil && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWeekday parses the short name of a day, for example "Mon".
+func parseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()[:3]) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("profile schedule day %q is invalid, use one of Mon, Tue, Wed, Thu, Fri, Sat, Sun", s)
+}
+
+// parseClockTime parses the time of day in "HH:MM" format and returns
+// the duration since midnight.
+func parseClockTime(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time %q is not in HH:MM format", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
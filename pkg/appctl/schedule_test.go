@@ -0,0 +1,85 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestScheduledStateAt(t *testing.T) {
+	config := &pb.ClientConfig{
+		ActiveProfile: proto.String("default"),
+		ProfileSchedules: []*pb.ProfileSchedule{
+			{
+				Days:        []string{"Mon", "Tue", "Wed", "Thu", "Fri"},
+				StartTime:   proto.String("09:00"),
+				EndTime:     proto.String("18:00"),
+				ProfileName: proto.String("work"),
+			},
+			{
+				Days:         []string{"fri"},
+				StartTime:    proto.String("23:00"),
+				EndTime:      proto.String("07:00"),
+				DisableProxy: proto.Bool(true),
+			},
+		},
+	}
+	// 2024-01-01 is Monday.
+	testCases := []struct {
+		now  time.Time
+		want ScheduledState
+	}{
+		{time.Date(2024, 1, 1, 8, 59, 0, 0, time.Local), ScheduledState{ProfileName: "default"}},
+		{time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local), ScheduledState{ProfileName: "work"}},
+		{time.Date(2024, 1, 5, 17, 59, 0, 0, time.Local), ScheduledState{ProfileName: "work"}},
+		{time.Date(2024, 1, 5, 18, 0, 0, 0, time.Local), ScheduledState{ProfileName: "default"}},
+		{time.Date(2024, 1, 6, 9, 0, 0, 0, time.Local), ScheduledState{ProfileName: "default"}},
+		{time.Date(2024, 1, 5, 23, 30, 0, 0, time.Local), ScheduledState{ProfileName: "default", ProxyDisabled: true}},
+		{time.Date(2024, 1, 6, 6, 59, 0, 0, time.Local), ScheduledState{ProfileName: "default", ProxyDisabled: true}},
+		{time.Date(2024, 1, 6, 7, 0, 0, 0, time.Local), ScheduledState{ProfileName: "default"}},
+		{time.Date(2024, 1, 5, 6, 0, 0, 0, time.Local), ScheduledState{ProfileName: "default"}},
+	}
+	for _, tc := range testCases {
+		if got := ScheduledStateAt(config, tc.now); got != tc.want {
+			t.Errorf("ScheduledStateAt(%v) = %+v, want %+v", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestValidateProfileSchedule(t *testing.T) {
+	testCases := []struct {
+		schedule *pb.ProfileSchedule
+		wantErr  bool
+	}{
+		{&pb.ProfileSchedule{StartTime: proto.String("00:00"), EndTime: proto.String("00:00"), ProfileName: proto.String("a")}, false},
+		{&pb.ProfileSchedule{Days: []string{"Sun"}, StartTime: proto.String("22:30"), EndTime: proto.String("06:00"), DisableProxy: proto.Bool(true)}, false},
+		{&pb.ProfileSchedule{Days: []string{"Sunday"}, StartTime: proto.String("22:30"), EndTime: proto.String("06:00"), DisableProxy: proto.Bool(true)}, true},
+		{&pb.ProfileSchedule{StartTime: proto.String("24:00"), EndTime: proto.String("06:00"), ProfileName: proto.String("a")}, true},
+		{&pb.ProfileSchedule{StartTime: proto.String("08:00"), ProfileName: proto.String("a")}, true},
+		{&pb.ProfileSchedule{StartTime: proto.String("08:00"), EndTime: proto.String("09:00")}, true},
+		{&pb.ProfileSchedule{StartTime: proto.String("08:00"), EndTime: proto.String("09:00"), ProfileName: proto.String("a"), DisableProxy: proto.Bool(true)}, true},
+	}
+	for i, tc := range testCases {
+		err := validateProfileSchedule(tc.schedule)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("test case %d: validateProfileSchedule() returned %v, want error %v", i, err, tc.wantErr)
+		}
+	}
+}
@@ -17,12 +17,17 @@ package appctl
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -36,11 +41,16 @@ import (
 	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/socks5"
 	"github.com/enfein/mieru/v3/pkg/stderror"
+	"github.com/enfein/mieru/v3/pkg/tracing"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/proto"
 )
 
+// DefaultDrainTimeout is the default maximum time to wait for
+// existing connections when the server is drained.
+const DefaultDrainTimeout = 5 * time.Minute
+
 var (
 	// ServerRPCServerStarted is closed when server RPC server is started.
 	ServerRPCServerStarted chan struct{} = make(chan struct{})
@@ -48,10 +58,14 @@ var (
 	cachedServerConfigDir      string = "/etc/mita"
 	cachedServerConfigFilePath string = "/etc/mita/server.conf.pb"
 	cachedServerUDS            string = "/var/run/mita.sock"
+	cachedServerManagementUDS  string = "/var/run/mita-http.sock"
 
 	// serverIOLock is required to load server config and store server config.
 	serverIOLock sync.Mutex
 
+	// serverUsersLock serializes the updates of users in server config.
+	serverUsersLock sync.Mutex
+
 	// serverRPCServerRef holds a pointer to server RPC server.
 	serverRPCServerRef atomic.Pointer[grpc.Server]
 
@@ -83,6 +97,15 @@ func ServerUDS() string {
 	return cachedServerUDS
 }
 
+// ServerManagementUDS returns the UNIX domain socket that mita server
+// is listening to management API requests in HTTP and JSON.
+func ServerManagementUDS() string {
+	if v, found := os.LookupEnv("MITA_HTTP_UDS_PATH"); found {
+		cachedServerManagementUDS = v
+	}
+	return cachedServerManagementUDS
+}
+
 // serverLifecycleService implements ServerLifecycleService defined in lifecycle.proto.
 type serverLifecycleService struct {
 	appctlgrpc.UnimplementedServerLifecycleServiceServer
@@ -91,7 +114,9 @@ type serverLifecycleService struct {
 func (s *serverLifecycleService) GetStatus(ctx context.Context, req *pb.Empty) (*pb.AppStatusMsg, error) {
 	status := GetAppStatus()
 	log.Infof("return app status %s back to RPC caller", status.String())
-	return &pb.AppStatusMsg{Status: &status}, nil
+	msg := &pb.AppStatusMsg{Status: &status}
+	setStorageHealth(msg)
+	return msg, nil
 }
 
 func (s *serverLifecycleService) Start(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
@@ -107,6 +132,7 @@ func (s *serverLifecycleService) Start(ctx context.Context, req *pb.Empty) (*pb.
 	if loggingLevel != pb.LoggingLevel_DEFAULT.String() {
 		log.SetLevel(loggingLevel)
 	}
+	log.SetFormatter(ServerLogFormatter(config.GetLogFormat()))
 	if socks5ServerRef.Load() != nil {
 		log.Infof("socks5 server already exist")
 		return &pb.Empty{}, nil
@@ -114,28 +140,14 @@ func (s *serverLifecycleService) Start(ctx context.Context, req *pb.Empty) (*pb.
 
 	SetAppStatus(pb.AppStatus_STARTING)
 
-	mux := protocol.NewMux(false).SetServerUsers(UserListToMap(config.GetUsers()))
-	SetServerMuxRef(mux)
-	mtu := common.DefaultMTU
-	if config.GetMtu() != 0 {
-		mtu = int(config.GetMtu())
-	}
-	endpoints, err := PortBindingsToUnderlayProperties(config.GetPortBindings(), mtu)
+	mux, err := NewServerMux(config)
 	if err != nil {
 		return &pb.Empty{}, err
 	}
-	mux.SetEndpoints(endpoints)
+	SetServerMuxRef(mux)
 
 	// Create the egress socks5 server.
-	socks5Config := &socks5.Config{
-		AllowLocalDestination: config.GetAdvancedSettings().GetAllowLocalDestination(),
-		AuthOpts: socks5.Auth{
-			ClientSideAuthentication: true,
-		},
-		EgressController: egress.NewSocks5Controller(config.GetEgress()),
-		HandshakeTimeout: 10 * time.Second,
-	}
-	socks5Server, err := socks5.New(socks5Config)
+	socks5Server, err := NewServerSocks5(config)
 	if err != nil {
 		return &pb.Empty{}, fmt.Errorf(stderror.CreateSocks5ServerFailedErr, err)
 	}
@@ -181,6 +193,32 @@ func (s *serverLifecycleService) Stop(ctx context.Context, req *pb.Empty) (*pb.E
 	return &pb.Empty{}, nil
 }
 
+func (s *serverLifecycleService) Drain(ctx context.Context, req *pb.DrainRequest) (*pb.Empty, error) {
+	mux := serverMuxRef.Load()
+	if mux == nil || socks5ServerRef.Load() == nil {
+		return &pb.Empty{}, fmt.Errorf("mita server proxy is not running")
+	}
+	timeout := DefaultDrainTimeout
+	if req.GetTimeoutSeconds() > 0 {
+		timeout = time.Duration(req.GetTimeoutSeconds()) * time.Second
+	}
+	SetAppStatus(pb.AppStatus_STOPPING)
+	log.Infof("received drain request from RPC caller, timeout is %v", timeout)
+	drainCtx, cancelFunc := context.WithTimeout(context.Background(), timeout)
+	defer cancelFunc()
+	if err := mux.Drain(drainCtx); err != nil {
+		log.Infof("server mux Drain() failed: %v", err)
+	}
+	log.Infof("stopping socks5 server")
+	if err := socks5ServerRef.Load().Close(); err != nil {
+		log.Infof("socks5 server Close() failed: %v", err)
+	}
+	SetSocks5Server(nil)
+	SetAppStatus(pb.AppStatus_IDLE)
+	log.Infof("completed drain request from RPC caller")
+	return &pb.Empty{}, nil
+}
+
 func (s *serverLifecycleService) Reload(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
 	log.Infof("received start request from RPC caller")
 	config, err := LoadServerConfig()
@@ -191,12 +229,13 @@ func (s *serverLifecycleService) Reload(ctx context.Context, req *pb.Empty) (*pb
 		return &pb.Empty{}, fmt.Errorf("ValidateFullServerConfig() failed: %w", err)
 	}
 
-	// Adjust loggingLevel.
+	// Adjust loggingLevel and logFormat.
 	// This needs to happen before adjusting other settings.
 	loggingLevel := config.GetLoggingLevel().String()
 	if loggingLevel != pb.LoggingLevel_DEFAULT.String() {
 		log.SetLevel(loggingLevel)
 	}
+	log.SetFormatter(ServerLogFormatter(config.GetLogFormat()))
 
 	mux := serverMuxRef.Load()
 	if mux != nil {
@@ -258,6 +297,84 @@ func (s *serverLifecycleService) GetSessionInfo(context.Context, *pb.Empty) (*pb
 	return &pb.SessionInfo{Table: mux.ExportSessionInfoTable()}, nil
 }
 
+func (s *serverLifecycleService) GetSessionStates(ctx context.Context, req *pb.SessionStateRequest) (*pb.SessionStates, error) {
+	mux := serverMuxRef.Load()
+	if mux == nil {
+		return &pb.SessionStates{}, fmt.Errorf("server multiplexier is unavailable")
+	}
+	states, err := getSessionStates(mux, req.GetSessionID())
+	if err != nil {
+		return &pb.SessionStates{}, err
+	}
+	return &pb.SessionStates{Json: proto.String(states)}, nil
+}
+
+func (s *serverLifecycleService) GetLogs(req *pb.LogRequest, stream appctlgrpc.ServerLifecycleService_GetLogsServer) error {
+	return sendLogs(stream.Context(), req, stream.Send)
+}
+
+func (s *serverLifecycleService) GetRouteStats(context.Context, *pb.Empty) (*pb.RouteStats, error) {
+	server := socks5ServerRef.Load()
+	if server == nil {
+		return &pb.RouteStats{}, fmt.Errorf("socks5 server is unavailable")
+	}
+	controller, ok := server.EgressController().(*egress.Socks5Controller)
+	if !ok {
+		return &pb.RouteStats{}, nil
+	}
+	return controller.RouteStats(), nil
+}
+
+func (s *serverLifecycleService) GetUserStats(context.Context, *pb.Empty) (*pb.UserStats, error) {
+	stats, err := userDestinationStats()
+	if err != nil {
+		return &pb.UserStats{}, err
+	}
+	res := &pb.UserStats{}
+	for _, user := range stats.Users() {
+		res.Users = append(res.Users, &pb.UserStat{
+			UserName:      proto.String(user.UserName),
+			Connections:   proto.Int64(user.Connections),
+			UploadBytes:   proto.Int64(user.UploadBytes),
+			DownloadBytes: proto.Int64(user.DownloadBytes),
+			Destinations:  proto.Int32(int32(user.Destinations)),
+			LastSeen:      proto.String(user.LastSeen.Format(time.RFC3339)),
+		})
+	}
+	return res, nil
+}
+
+func (s *serverLifecycleService) GetUsers(context.Context, *pb.Empty) (*pb.UserUsages, error) {
+	config, err := LoadServerConfig()
+	if err != nil {
+		return &pb.UserUsages{}, fmt.Errorf("LoadServerConfig() failed: %w", err)
+	}
+	return userUsages(config.GetUsers(), time.Now()), nil
+}
+
+func (s *serverLifecycleService) GetTraffic(context.Context, *pb.Empty) (*pb.Traffic, error) {
+	config, err := LoadServerConfig()
+	if err != nil {
+		return &pb.Traffic{}, fmt.Errorf("LoadServerConfig() failed: %w", err)
+	}
+	var sessions []protocol.SessionTraffic
+	if mux := serverMuxRef.Load(); mux != nil {
+		sessions = mux.ExportSessionTraffic()
+	}
+	return trafficToProto(config.GetUsers(), sessions), nil
+}
+
+func (s *serverLifecycleService) GetUserDestinationStats(ctx context.Context, req *pb.UserDestinationStatsRequest) (*pb.DestinationStats, error) {
+	stats, err := userDestinationStats()
+	if err != nil {
+		return &pb.DestinationStats{}, err
+	}
+	if req.GetUserName() == "" {
+		return &pb.DestinationStats{}, fmt.Errorf("user name is not provided")
+	}
+	return destinationStatsToProto(stats.Top(req.GetUserName(), maxReportedDestinations)), nil
+}
+
 func (s *serverLifecycleService) GetThreadDump(ctx context.Context, req *pb.Empty) (*pb.ThreadDump, error) {
 	return &pb.ThreadDump{ThreadDump: proto.String(string(getThreadDump()))}, nil
 }
@@ -320,6 +437,27 @@ func (s *serverConfigService) SetConfig(ctx context.Context, req *pb.ServerConfi
 	return config, nil
 }
 
+func (s *serverConfigService) AddUser(ctx context.Context, req *pb.User) (*pb.Empty, error) {
+	if err := AddServerUser(req); err != nil {
+		return &pb.Empty{}, fmt.Errorf("AddServerUser() failed: %w", err)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (s *serverConfigService) UpdateUser(ctx context.Context, req *pb.User) (*pb.Empty, error) {
+	if err := UpdateServerUser(req); err != nil {
+		return &pb.Empty{}, fmt.Errorf("UpdateServerUser() failed: %w", err)
+	}
+	return &pb.Empty{}, nil
+}
+
+func (s *serverConfigService) RemoveUser(ctx context.Context, req *pb.User) (*pb.Empty, error) {
+	if err := RemoveServerUser(req.GetName()); err != nil {
+		return &pb.Empty{}, fmt.Errorf("RemoveServerUser() failed: %w", err)
+	}
+	return &pb.Empty{}, nil
+}
+
 // NewServerConfigService creates a new ServerConfigService RPC server.
 func NewServerConfigService() *serverConfigService {
 	return &serverConfigService{}
@@ -378,9 +516,9 @@ func GetJSONServerConfig() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("LoadServerConfig() failed: %w", err)
 	}
-	b, err := common.MarshalJSON(config)
+	b, err := MarshalConfig(config, JSON_CONFIG_FILE_TYPE)
 	if err != nil {
-		return "", fmt.Errorf("common.MarshalJSON() failed: %w", err)
+		return "", err
 	}
 	return string(b), nil
 }
@@ -399,31 +537,14 @@ func LoadServerConfig() (*pb.ServerConfig, error) {
 	}
 
 	log.Debugf("loading server config from %q", fileName)
-	f, err := os.Open(fileName)
-	if err != nil && os.IsNotExist(err) {
-		return nil, stderror.ErrFileNotExist
-	} else if err != nil {
-		return nil, fmt.Errorf("os.Open() failed: %w", err)
-	}
-	defer f.Close()
-
-	b, err := io.ReadAll(f)
+	b, err := readConfigFile(fileName)
 	if err != nil {
-		return nil, fmt.Errorf("io.ReadAll(%q) failed: %w", fileName, err)
+		return nil, err
 	}
 
 	s := &pb.ServerConfig{}
-	switch fileType {
-	case PROTOBUF_CONFIG_FILE_TYPE:
-		if err := proto.Unmarshal(b, s); err != nil {
-			return nil, fmt.Errorf("proto.Unmarshal() failed: %w", err)
-		}
-	case JSON_CONFIG_FILE_TYPE:
-		if err := common.UnmarshalJSON(b, s); err != nil {
-			return nil, fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("config file type is invalid")
+	if err := UnmarshalConfig(b, s, fileType); err != nil {
+		return nil, err
 	}
 
 	return s, nil
@@ -447,36 +568,24 @@ func StoreServerConfig(config *pb.ServerConfig) error {
 		return fmt.Errorf("checkServerConfigDir() failed: %w", err)
 	}
 
-	var b []byte
-	switch fileType {
-	case PROTOBUF_CONFIG_FILE_TYPE:
-		if b, err = proto.Marshal(config); err != nil {
-			return fmt.Errorf("proto.Marshal() failed: %w", err)
-		}
-	case JSON_CONFIG_FILE_TYPE:
-		if b, err = common.MarshalJSON(config); err != nil {
-			return fmt.Errorf("common.MarshalJSON() failed: %w", err)
-		}
-	default:
-		return fmt.Errorf("config file type is invalid")
-	}
-
-	err = os.WriteFile(fileName, b, 0660)
+	b, err := MarshalConfig(config, fileType)
 	if err != nil {
-		return fmt.Errorf("os.WriteFile(%q) failed: %w", fileName, err)
+		return err
 	}
-	return nil
+
+	return writeConfigFile(log.StorageServerConfig, fileName, b)
 }
 
-// ApplyJSONServerConfig applies user provided JSON server config from path.
+// ApplyJSONServerConfig applies user provided server config from path.
+// The file is JSON, unless the file extension name is .yaml, .yml or .toml.
 func ApplyJSONServerConfig(path string) error {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("os.ReadFile(%q) failed: %w", path, err)
 	}
 	s := &pb.ServerConfig{}
-	if err = common.UnmarshalJSON(b, s); err != nil {
-		return fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
+	if err = UnmarshalConfig(b, s, FindConfigPatchFileType(path)); err != nil {
+		return err
 	}
 	if err := ValidateServerConfigPatch(s); err != nil {
 		return fmt.Errorf("ValidateServerConfigPatch() failed: %w", err)
@@ -527,6 +636,118 @@ func DeleteServerUsers(names []string) error {
 	return nil
 }
 
+// AddServerUser adds a new user to server config.
+// If the proxy is running, the user can connect immediately.
+func AddServerUser(user *pb.User) error {
+	return updateServerUsers(func(config *pb.ServerConfig) error {
+		if findServerUser(config, user.GetName()) != nil {
+			return fmt.Errorf("user %q already exists", user.GetName())
+		}
+		return ImportServerUsers(config, []*pb.User{user})
+	})
+}
+
+// UpdateServerUser replaces the user with the same name in server config.
+// If the proxy is running, the existing connections of the user are closed
+// when the user is disabled or the password is changed.
+func UpdateServerUser(user *pb.User) error {
+	return updateServerUsers(func(config *pb.ServerConfig) error {
+		if findServerUser(config, user.GetName()) == nil {
+			return fmt.Errorf("user %q is not found", user.GetName())
+		}
+		return ImportServerUsers(config, []*pb.User{user})
+	})
+}
+
+// RemoveServerUser removes the user from server config.
+// If the proxy is running, the existing connections of the user are closed.
+func RemoveServerUser(name string) error {
+	return updateServerUsers(func(config *pb.ServerConfig) error {
+		if findServerUser(config, name) == nil {
+			return fmt.Errorf("user %q is not found", name)
+		}
+		remaining := make([]*pb.User, 0, len(config.GetUsers()))
+		for _, user := range config.GetUsers() {
+			if user.GetName() != name {
+				remaining = append(remaining, user)
+			}
+		}
+		config.Users = remaining
+		if err := ValidateFullServerConfig(config); err != nil {
+			return fmt.Errorf("ValidateFullServerConfig() failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// updateServerUsers loads server config, updates it, and stores it.
+// If the proxy is running, the users in the updated config are applied
+// to the proxy.
+func updateServerUsers(update func(config *pb.ServerConfig) error) error {
+	serverUsersLock.Lock()
+	defer serverUsersLock.Unlock()
+
+	config, err := LoadServerConfig()
+	if err != nil {
+		return fmt.Errorf("LoadServerConfig() failed: %w", err)
+	}
+	if err := update(config); err != nil {
+		return err
+	}
+	if err := StoreServerConfig(config); err != nil {
+		return fmt.Errorf("StoreServerConfig() failed: %w", err)
+	}
+	if mux := serverMuxRef.Load(); mux != nil {
+		mux.SetServerUsers(UserListToMap(config.GetUsers()))
+	}
+	return nil
+}
+
+// findServerUser returns the user with the name in server config,
+// or nil if the user is not found.
+func findServerUser(config *pb.ServerConfig, name string) *pb.User {
+	for _, user := range config.GetUsers() {
+		if user.GetName() == name {
+			return user
+		}
+	}
+	return nil
+}
+
+// DeleteExpiredGuestUsers deletes the guest users that expire before
+// the given time from server config. If the proxy is running, the
+// deleted users are removed from the proxy. It returns the names of
+// deleted users.
+func DeleteExpiredGuestUsers(now time.Time) ([]string, error) {
+	config, err := LoadServerConfig()
+	if errors.Is(err, stderror.ErrFileNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("LoadServerConfig() failed: %w", err)
+	}
+	remaining := make([]*pb.User, 0, len(config.GetUsers()))
+	deleted := make([]string, 0)
+	for _, user := range config.GetUsers() {
+		if user.GetGuest() && UserExpired(user, now) {
+			deleted = append(deleted, user.GetName())
+			continue
+		}
+		remaining = append(remaining, user)
+	}
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+	config.Users = remaining
+	if err := StoreServerConfig(config); err != nil {
+		return nil, fmt.Errorf("StoreServerConfig() failed: %w", err)
+	}
+	if mux := serverMuxRef.Load(); mux != nil {
+		mux.SetServerUsers(UserListToMap(remaining))
+	}
+	return deleted, nil
+}
+
 // ValidateServerConfigPatch validates a patch of server config.
 //
 // A server config patch must satisfy:
@@ -535,8 +756,12 @@ func DeleteServerUsers(names []string) error {
 // 2.1. user name is not empty
 // 2.2. user has either a password or a hashed password
 // 2.3. for each quota
-// 2.3.1. number of days is valid
+// 2.3.1. number of days is valid, or the quota is monthly
 // 2.3.2. traffic volume in megabyte is valid
+// 2.4. if set, expire time is valid
+// 2.5. guest user has an expire time
+// 2.6. if set, next hashed password is valid
+// 2.7. if set, rate limit is valid
 // 3. if set, MTU is valid
 // 4. for each egress proxy
 // 4.1. name is not empty
@@ -545,11 +770,14 @@ func DeleteServerUsers(names []string) error {
 // 4.4. host is not empty
 // 4.5. port is valid
 // 4.6. if socks5 authentication is used, the user and password are not empty
-// 5. there is maximum 1 egress rule
-// 5.1. the IP ranges must be "*"
-// 5.2. the domain names must be "*"
-// 5.3. the action must be "PROXY"
-// 5.4. the proxy name is defined
+// 4.7. if HTTP authentication is used, the user and password are not empty
+// 5. for each egress rule
+// 5.1. there is at least one IP range or domain name
+// 5.2. each IP range is "*" or a valid CIDR
+// 5.3. each domain name is not empty
+// 5.4. if the action is "PROXY", the proxy name is defined
+// 5.5. if set, source IP is valid
+// 5.6. source IP and interface name are only set with "DIRECT" action
 func ValidateServerConfigPatch(patch *pb.ServerConfig) error {
 	if _, err := FlatPortBindings(patch.GetPortBindings()); err != nil {
 		return err
@@ -562,17 +790,69 @@ func ValidateServerConfigPatch(patch *pb.ServerConfig) error {
 			return fmt.Errorf("user password is not set")
 		}
 		for _, quota := range user.GetQuotas() {
-			if quota.GetDays() <= 0 {
+			if quota.GetMonthly() && quota.GetDays() != 0 {
+				return fmt.Errorf("quota: number of days can't be set for a monthly quota")
+			}
+			if !quota.GetMonthly() && quota.GetDays() <= 0 {
 				return fmt.Errorf("quota: number of days %d is invalid", quota.GetDays())
 			}
 			if quota.GetMegabytes() <= 0 {
 				return fmt.Errorf("quota: traffic volume in megabyte %d is invalid", quota.GetMegabytes())
 			}
 		}
+		if user.ExpireTime != nil {
+			if _, err := time.Parse(time.RFC3339, user.GetExpireTime()); err != nil {
+				return fmt.Errorf("user %q: expire time %q is invalid: %w", user.GetName(), user.GetExpireTime(), err)
+			}
+		}
+		if user.GetGuest() && user.ExpireTime == nil {
+			return fmt.Errorf("guest user %q has no expire time", user.GetName())
+		}
+		if user.GetNextHashedPassword() != "" {
+			if b, err := hex.DecodeString(user.GetNextHashedPassword()); err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("user %q: next hashed password is invalid", user.GetName())
+			}
+		}
+		if err := validateRateLimit(user.GetRateLimit()); err != nil {
+			return fmt.Errorf("user %q: %w", user.GetName(), err)
+		}
 	}
 	if patch.GetMtu() != 0 && (patch.GetMtu() < 1280 || patch.GetMtu() > 1500) {
 		return fmt.Errorf("MTU value %d is out of range, valid range is [1280, 1500]", patch.GetMtu())
 	}
+	if patch.GetFecGroupSize() != 0 && (patch.GetFecGroupSize() < 2 || patch.GetFecGroupSize() > protocol.MaxFECGroupSize) {
+		return fmt.Errorf("FEC group size %d is out of range, valid range is [2, %d]", patch.GetFecGroupSize(), protocol.MaxFECGroupSize)
+	}
+	if err := validateRetransmissionLimit(patch.GetRetransmissionLimit()); err != nil {
+		return err
+	}
+	if err := validateCongestionControl(patch.GetCongestionControl()); err != nil {
+		return err
+	}
+	if err := validateReplayCache(patch.GetReplayCache()); err != nil {
+		return err
+	}
+	if err := validateTransferCap(patch.GetTransferCap()); err != nil {
+		return err
+	}
+	if err := validateFairness(patch.GetFairness()); err != nil {
+		return err
+	}
+	if err := validateFlowControl(patch.GetFlowControl()); err != nil {
+		return err
+	}
+	if err := validateSessionEngine(patch.GetSessionEngine()); err != nil {
+		return err
+	}
+	if err := validateSourceLimit(patch.GetSourceLimit()); err != nil {
+		return err
+	}
+	if err := validateTracing(patch.GetTracing()); err != nil {
+		return err
+	}
+	if patch.DebugHttpPort != nil && (patch.GetDebugHttpPort() < 1 || patch.GetDebugHttpPort() > 65535) {
+		return fmt.Errorf("debug HTTP port number %d is invalid", patch.GetDebugHttpPort())
+	}
 	usedProxyNames := map[string]bool{}
 	for _, proxy := range patch.GetEgress().GetProxies() {
 		if proxy.GetName() == "" {
@@ -599,33 +879,97 @@ func ValidateServerConfigPatch(patch *pb.ServerConfig) error {
 		if hasSocks5AuthenticationUser && !hasSocks5AuthenticationPassword {
 			return fmt.Errorf("egress proxy socks5 authentication password is not set")
 		}
+		hasHTTPAuthenticationUser := proxy.GetHttpAuthentication().GetUser() != ""
+		hasHTTPAuthenticationPassword := proxy.GetHttpAuthentication().GetPassword() != ""
+		if !hasHTTPAuthenticationUser && hasHTTPAuthenticationPassword {
+			return fmt.Errorf("egress proxy HTTP authentication user is not set")
+		}
+		if hasHTTPAuthenticationUser && !hasHTTPAuthenticationPassword {
+			return fmt.Errorf("egress proxy HTTP authentication password is not set")
+		}
 	}
-	if len(patch.GetEgress().GetRules()) > 1 {
-		return fmt.Errorf("found %d egress rules, maximum number of supported rules is 1", len(patch.GetEgress().GetRules()))
-	}
-	if len(patch.GetEgress().GetRules()) == 1 {
-		rule := patch.GetEgress().GetRules()[0]
-		if len(rule.GetIpRanges()) != 1 || rule.GetIpRanges()[0] != "*" {
-			return fmt.Errorf("egress rule: the only supported IP range value is %q", "*")
+	for i, rule := range patch.GetEgress().GetRules() {
+		if len(rule.GetIpRanges()) == 0 && len(rule.GetDomainNames()) == 0 && len(rule.GetRuleFiles()) == 0 {
+			return fmt.Errorf("egress rule %d: IP ranges, domain names and rule files are not set", i+1)
+		}
+		for _, ipRange := range rule.GetIpRanges() {
+			if ipRange == "*" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(ipRange); err != nil {
+				return fmt.Errorf("egress rule %d: invalid IP range %q", i+1, ipRange)
+			}
+		}
+		for _, domainName := range rule.GetDomainNames() {
+			if strings.Trim(domainName, ".") == "" {
+				return fmt.Errorf("egress rule %d: domain name is empty", i+1)
+			}
+		}
+		for _, ruleFile := range rule.GetRuleFiles() {
+			if ruleFile == "" {
+				return fmt.Errorf("egress rule %d: rule file path is empty", i+1)
+			}
+		}
+		if rule.GetSourceIP() != "" && net.ParseIP(rule.GetSourceIP()) == nil {
+			return fmt.Errorf("egress rule %d: invalid source IP %q", i+1, rule.GetSourceIP())
 		}
-		if len(rule.GetDomainNames()) != 1 || rule.GetDomainNames()[0] != "*" {
-			return fmt.Errorf("egress rule: the only supported domain name value is %q", "*")
+		if (rule.GetSourceIP() != "" || rule.GetInterfaceName() != "") && rule.GetAction() != pb.EgressAction_DIRECT {
+			return fmt.Errorf("egress rule %d: source IP and interface name can only be set with DIRECT action", i+1)
 		}
 		if rule.GetAction() != pb.EgressAction_PROXY {
-			return fmt.Errorf("egress rule: the only supported action is %q", pb.EgressAction_PROXY.String())
+			continue
 		}
 		if rule.GetProxyName() == "" {
-			return fmt.Errorf("egress rule: proxy name is not set")
+			return fmt.Errorf("egress rule %d: proxy name is not set", i+1)
 		}
-		foundProxy := false
-		for _, proxy := range patch.GetEgress().GetProxies() {
-			if proxy.GetName() == rule.GetProxyName() {
-				foundProxy = true
-				break
+		if !usedProxyNames[rule.GetProxyName()] {
+			return fmt.Errorf("egress rule %d: proxy %q is not defined", i+1, rule.GetProxyName())
+		}
+	}
+	if _, err := egress.NewACL(patch.GetDestinationACL()); err != nil {
+		return err
+	}
+	if patch.Websocket != nil {
+		ws := patch.GetWebsocket()
+		if ws.GetPath() != "" && !strings.HasPrefix(ws.GetPath(), "/") {
+			return fmt.Errorf("WebSocket path %q doesn't start with \"/\"", ws.GetPath())
+		}
+		if (ws.GetCertFile() == "") != (ws.GetKeyFile() == "") {
+			return fmt.Errorf("WebSocket TLS certificate file and key file must be set together")
+		}
+	}
+	if patch.Tls != nil {
+		if patch.Websocket != nil {
+			return fmt.Errorf("TLS and WebSocket can't be used together")
+		}
+		if patch.GetTls().GetCertFile() == "" || patch.GetTls().GetKeyFile() == "" {
+			return fmt.Errorf("TLS certificate file and key file must be set")
+		}
+	}
+	if patch.Decoy != nil {
+		decoy := patch.GetDecoy()
+		if (decoy.GetAddress() == "") == (decoy.GetPageFile() == "") {
+			return fmt.Errorf("decoy: exactly one of address and page file must be set")
+		}
+		if decoy.GetAddress() != "" {
+			_, port, err := net.SplitHostPort(decoy.GetAddress())
+			if err != nil {
+				return fmt.Errorf("decoy: address %q is invalid: %w", decoy.GetAddress(), err)
 			}
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("decoy: port of address %q is invalid", decoy.GetAddress())
+			}
+		}
+	}
+	for _, tunnel := range patch.GetReverseTunnels() {
+		if tunnel.GetUserName() == "" {
+			return fmt.Errorf("reverse tunnel: user name is not set")
+		}
+		if _, _, err := parsePortRange(tunnel.GetPortRange()); err != nil {
+			return fmt.Errorf("reverse tunnel: %w", err)
 		}
-		if !foundProxy {
-			return fmt.Errorf("egress rule: proxy %q is not defined", rule.GetProxyName())
+		if tunnel.GetMaxExposedPorts() < 0 {
+			return fmt.Errorf("reverse tunnel: maximum number of exposed ports %d is invalid", tunnel.GetMaxExposedPorts())
 		}
 	}
 	return nil
@@ -635,6 +979,8 @@ func ValidateServerConfigPatch(patch *pb.ServerConfig) error {
 //
 // In addition to ValidateServerConfigPatch, it also validates:
 // 1. there is at least 1 port binding
+// 2. reverse tunnel users are registered
+// 3. reverse tunnel port ranges don't overlap with TCP port bindings
 //
 // It is not an error if no user is configured. However mita won't be functional.
 func ValidateFullServerConfig(config *pb.ServerConfig) error {
@@ -647,9 +993,109 @@ func ValidateFullServerConfig(config *pb.ServerConfig) error {
 	if len(config.GetPortBindings()) == 0 {
 		return fmt.Errorf("server port binding is not set")
 	}
+	if config.DebugHttpPort != nil {
+		portBindings, err := FlatPortBindings(config.GetPortBindings())
+		if err != nil {
+			return err
+		}
+		for _, binding := range portBindings {
+			if binding.GetProtocol() == pb.TransportProtocol_TCP && binding.GetPort() == config.GetDebugHttpPort() {
+				return fmt.Errorf("debug HTTP port number %d is the same as TCP port binding", config.GetDebugHttpPort())
+			}
+		}
+	}
+	if len(config.GetReverseTunnels()) > 0 {
+		users := UserListToMap(config.GetUsers())
+		portBindings, err := FlatPortBindings(config.GetPortBindings())
+		if err != nil {
+			return err
+		}
+		for _, tunnel := range config.GetReverseTunnels() {
+			if _, found := users[tunnel.GetUserName()]; !found {
+				return fmt.Errorf("reverse tunnel: user %q is not found", tunnel.GetUserName())
+			}
+			small, big, _ := parsePortRange(tunnel.GetPortRange())
+			for _, binding := range portBindings {
+				if binding.GetProtocol() == pb.TransportProtocol_TCP && int(binding.GetPort()) >= small && int(binding.GetPort()) <= big {
+					return fmt.Errorf("reverse tunnel: port range %q overlaps with TCP port binding %d", tunnel.GetPortRange(), binding.GetPort())
+				}
+			}
+		}
+	}
 	return nil
 }
 
+// NewServerMux creates the proxy server mux from a valid server config.
+// The mux is not started.
+func NewServerMux(config *pb.ServerConfig) (*protocol.Mux, error) {
+	mux := protocol.NewMux(false).SetServerUsers(UserListToMap(config.GetUsers()))
+	mtu := common.DefaultMTU
+	if config.GetMtu() != 0 {
+		mtu = int(config.GetMtu())
+	}
+	endpoints, err := PortBindingsToUnderlayProperties(config.GetPortBindings(), mtu)
+	if err != nil {
+		return nil, err
+	}
+	mux.SetEndpoints(endpoints)
+	websocket, err := ServerWebSocketConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mux.SetWebSocket(websocket)
+	tlsConfig, err := ServerTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mux.SetTLS(tlsConfig)
+	mux.SetFECGroupSize(int(config.GetFecGroupSize()))
+	mux.SetRetransmissionLimit(RetransmissionLimit(config.GetRetransmissionLimit()))
+	mux.SetCongestionControl(CongestionControl(config.GetCongestionControl()))
+	mux.SetUDPOffload(config.GetUdpOffload())
+	mux.SetIntegrityDiagnostic(config.GetIntegrityDiagnostic())
+	mux.SetReplayCache(ReplayCache(config.GetReplayCache()))
+	mux.SetFairness(Fairness(config.GetFairness()))
+	mux.SetFlowControl(FlowControl(config.GetFlowControl()))
+	mux.SetBandwidth(Bandwidth(config.GetFlowControl()))
+	mux.SetServerSessionEngine(SessionEngine(config.GetSessionEngine()))
+	decoy, err := ServerDecoy(config)
+	if err != nil {
+		return nil, err
+	}
+	mux.SetServerDecoy(decoy)
+	mux.SetServerSourceLimit(SourceLimit(config.GetSourceLimit()))
+	return mux, nil
+}
+
+// NewServerSocks5 creates the egress socks5 server from a valid server
+// config. The socks5 server serves the connections accepted by the mux.
+func NewServerSocks5(config *pb.ServerConfig) (*socks5.Server, error) {
+	acl, err := egress.NewACL(config.GetDestinationACL())
+	if err != nil {
+		return nil, err
+	}
+	socks5Config := &socks5.Config{
+		AllowLocalDestination: config.GetAdvancedSettings().GetAllowLocalDestination(),
+		AuthOpts: socks5.Auth{
+			ClientSideAuthentication: true,
+		},
+		EgressController: egress.NewSocks5Controller(config.GetEgress()),
+		DestinationACL:   acl,
+		HandshakeTimeout: 10 * time.Second,
+		ReverseTunnels:   config.GetReverseTunnels(),
+		TransferCap:      TransferCap(config.GetTransferCap()),
+	}
+	if config.GetDestinationStats().GetEnable() {
+		socks5Config.UserDestinationStats = socks5.NewUserDestinationStats(config.GetDestinationStats().GetHashDestinations())
+	}
+	tracer, err := Tracer(config.GetTracing(), tracing.SpanKindServer)
+	if err != nil {
+		return nil, err
+	}
+	socks5Config.Tracer = tracer
+	return socks5.New(socks5Config)
+}
+
 // PortBindingsToUnderlayProperties converts port bindings to underlay properties.
 func PortBindingsToUnderlayProperties(portBindings []*pb.PortBinding, mtu int) ([]protocol.UnderlayProperties, error) {
 	endpoints := make([]protocol.UnderlayProperties, 0)
@@ -679,6 +1125,61 @@ func PortBindingsToUnderlayProperties(portBindings []*pb.PortBinding, mtu int) (
 	return endpoints, nil
 }
 
+// ServerWebSocketConfig returns the WebSocket config used by the server mux.
+// It returns nil if WebSocket is not enabled.
+func ServerWebSocketConfig(config *pb.ServerConfig) (*protocol.WebSocketConfig, error) {
+	if config.Websocket == nil {
+		return nil, nil
+	}
+	ws := config.GetWebsocket()
+	wsConfig := &protocol.WebSocketConfig{
+		Path: ws.GetPath(),
+	}
+	if ws.GetCertFile() != "" {
+		cert, err := tls.LoadX509KeyPair(ws.GetCertFile(), ws.GetKeyFile())
+		if err != nil {
+			return nil, fmt.Errorf("tls.LoadX509KeyPair() failed: %w", err)
+		}
+		wsConfig.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return wsConfig, nil
+}
+
+// ServerTLSConfig returns the TLS config used by the server mux.
+// It returns nil if TLS is not enabled.
+func ServerTLSConfig(config *pb.ServerConfig) (*tls.Config, error) {
+	if config.Tls == nil {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(config.GetTls().GetCertFile(), config.GetTls().GetKeyFile())
+	if err != nil {
+		return nil, fmt.Errorf("tls.LoadX509KeyPair() failed: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ServerDecoy returns the decoy of the server mux from the server config.
+// It returns nil if decoy is not configured.
+func ServerDecoy(config *pb.ServerConfig) (*protocol.Decoy, error) {
+	if config.Decoy == nil {
+		return nil, nil
+	}
+	if config.GetDecoy().GetAddress() != "" {
+		return &protocol.Decoy{Address: config.GetDecoy().GetAddress()}, nil
+	}
+	page, err := os.ReadFile(config.GetDecoy().GetPageFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decoy page file: %w", err)
+	}
+	return &protocol.Decoy{Page: page}, nil
+}
+
 // checkServerConfigDir validates if server config directory exists.
 func checkServerConfigDir() error {
 	_, err := os.Stat(cachedServerConfigDir)
@@ -759,6 +1260,123 @@ func mergeServerConfig(dst, src *pb.ServerConfig) error {
 		egress = dst.GetEgress()
 	}
 
+	var reverseTunnels []*pb.ReverseTunnel
+	if src.ReverseTunnels != nil {
+		reverseTunnels = src.GetReverseTunnels()
+	} else {
+		reverseTunnels = dst.GetReverseTunnels()
+	}
+	var websocket *pb.ServerWebSocketConfig
+	if src.Websocket != nil {
+		websocket = src.GetWebsocket()
+	} else {
+		websocket = dst.GetWebsocket()
+	}
+	var tlsConfig *pb.ServerTLSConfig
+	if src.Tls != nil {
+		tlsConfig = src.GetTls()
+	} else {
+		tlsConfig = dst.GetTls()
+	}
+	var fecGroupSize int32
+	if src.FecGroupSize != nil {
+		fecGroupSize = src.GetFecGroupSize()
+	} else {
+		fecGroupSize = dst.GetFecGroupSize()
+	}
+	var retransmissionLimit *pb.RetransmissionLimit
+	if src.RetransmissionLimit != nil {
+		retransmissionLimit = src.GetRetransmissionLimit()
+	} else {
+		retransmissionLimit = dst.GetRetransmissionLimit()
+	}
+	var congestionControl pb.CongestionControl
+	if src.CongestionControl != nil {
+		congestionControl = src.GetCongestionControl()
+	} else {
+		congestionControl = dst.GetCongestionControl()
+	}
+	var udpOffload *bool = dst.UdpOffload
+	if src.UdpOffload != nil {
+		udpOffload = src.UdpOffload
+	}
+	var integrityDiagnostic *bool = dst.IntegrityDiagnostic
+	if src.IntegrityDiagnostic != nil {
+		integrityDiagnostic = src.IntegrityDiagnostic
+	}
+	var debugHttpPort *int32 = dst.DebugHttpPort
+	if src.DebugHttpPort != nil {
+		debugHttpPort = src.DebugHttpPort
+	}
+	var managementHttp *bool = dst.ManagementHttp
+	if src.ManagementHttp != nil {
+		managementHttp = src.ManagementHttp
+	}
+	var replayCache *pb.ReplayCacheConfig
+	if src.ReplayCache != nil {
+		replayCache = src.GetReplayCache()
+	} else {
+		replayCache = dst.GetReplayCache()
+	}
+	var transferCap *pb.TransferCap
+	if src.TransferCap != nil {
+		transferCap = src.GetTransferCap()
+	} else {
+		transferCap = dst.GetTransferCap()
+	}
+	var destinationStats *pb.ServerDestinationStatsConfig
+	if src.DestinationStats != nil {
+		destinationStats = src.GetDestinationStats()
+	} else {
+		destinationStats = dst.GetDestinationStats()
+	}
+	var destinationACL *pb.DestinationACL
+	if src.DestinationACL != nil {
+		destinationACL = src.GetDestinationACL()
+	} else {
+		destinationACL = dst.GetDestinationACL()
+	}
+	var fairness *pb.Fairness
+	if src.Fairness != nil {
+		fairness = src.GetFairness()
+	} else {
+		fairness = dst.GetFairness()
+	}
+	var sessionEngine *pb.SessionEngineConfig
+	if src.SessionEngine != nil {
+		sessionEngine = src.GetSessionEngine()
+	} else {
+		sessionEngine = dst.GetSessionEngine()
+	}
+	var decoy *pb.DecoyConfig
+	if src.Decoy != nil {
+		decoy = src.GetDecoy()
+	} else {
+		decoy = dst.GetDecoy()
+	}
+	var logFormat *pb.LogFormat = dst.LogFormat
+	if src.LogFormat != nil {
+		logFormat = src.LogFormat
+	}
+	var sourceLimit *pb.SourceLimitConfig
+	if src.SourceLimit != nil {
+		sourceLimit = src.GetSourceLimit()
+	} else {
+		sourceLimit = dst.GetSourceLimit()
+	}
+	var tracingConfig *pb.TracingConfig
+	if src.Tracing != nil {
+		tracingConfig = src.GetTracing()
+	} else {
+		tracingConfig = dst.GetTracing()
+	}
+	var flowControl *pb.FlowControlConfig
+	if src.FlowControl != nil {
+		flowControl = src.GetFlowControl()
+	} else {
+		flowControl = dst.GetFlowControl()
+	}
+
 	proto.Reset(dst)
 	dst.PortBindings = portBindings
 	dst.Users = mergedUsers
@@ -766,6 +1384,31 @@ func mergeServerConfig(dst, src *pb.ServerConfig) error {
 	dst.LoggingLevel = &loggingLevel
 	dst.Mtu = proto.Int32(mtu)
 	dst.Egress = egress
+	dst.ReverseTunnels = reverseTunnels
+	dst.Websocket = websocket
+	dst.Tls = tlsConfig
+	if fecGroupSize != 0 {
+		dst.FecGroupSize = proto.Int32(fecGroupSize)
+	}
+	dst.RetransmissionLimit = retransmissionLimit
+	if congestionControl != pb.CongestionControl_DEFAULT_CONGESTION_CONTROL {
+		dst.CongestionControl = &congestionControl
+	}
+	dst.UdpOffload = udpOffload
+	dst.IntegrityDiagnostic = integrityDiagnostic
+	dst.DebugHttpPort = debugHttpPort
+	dst.ManagementHttp = managementHttp
+	dst.ReplayCache = replayCache
+	dst.TransferCap = transferCap
+	dst.DestinationStats = destinationStats
+	dst.DestinationACL = destinationACL
+	dst.Fairness = fairness
+	dst.SessionEngine = sessionEngine
+	dst.Decoy = decoy
+	dst.SourceLimit = sourceLimit
+	dst.LogFormat = logFormat
+	dst.Tracing = tracingConfig
+	dst.FlowControl = flowControl
 	return nil
 }
 
@@ -18,6 +18,7 @@ package appctl
 import (
 	"os"
 	"testing"
+	"time"
 
 	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/common"
@@ -64,13 +65,68 @@ func TestApply2ServerConfig(t *testing.T) {
 	afterServerTest(t)
 }
 
+func TestApplyServerConfigFormats(t *testing.T) {
+	var want *pb.ServerConfig
+	for _, configFile := range []string{
+		"testdata/server_apply_config_2.json",
+		"testdata/server_apply_config_2.yaml",
+		"testdata/server_apply_config_2.toml",
+	} {
+		beforeServerTest(t)
+		if err := ApplyJSONServerConfig(configFile); err != nil {
+			t.Fatalf("ApplyJSONServerConfig(%q) failed: %v", configFile, err)
+		}
+		got, err := LoadServerConfig()
+		if err != nil {
+			t.Fatalf("LoadServerConfig() failed: %v", err)
+		}
+		if want == nil {
+			want = got
+		} else if !proto.Equal(got, want) {
+			gotJSON, _ := common.MarshalJSON(got)
+			wantJSON, _ := common.MarshalJSON(want)
+			t.Errorf("server config from %q doesn't equal:\ngot = %v\nwant = %v", configFile, string(gotJSON), string(wantJSON))
+		}
+	}
+
+	// Export the config in each format, and then read it back.
+	for _, fileType := range []ConfigFileType{JSON_CONFIG_FILE_TYPE, YAML_CONFIG_FILE_TYPE, TOML_CONFIG_FILE_TYPE} {
+		b, err := MarshalConfig(want, fileType)
+		if err != nil {
+			t.Fatalf("MarshalConfig() failed: %v", err)
+		}
+		got := &pb.ServerConfig{}
+		if err := UnmarshalConfig(b, got, fileType); err != nil {
+			t.Fatalf("UnmarshalConfig() failed: %v\n%s", err, b)
+		}
+		if !proto.Equal(got, want) {
+			t.Errorf("server config doesn't equal after round trip:\n%s", b)
+		}
+	}
+
+	afterServerTest(t)
+}
+
 func TestServerApplyReject(t *testing.T) {
 	cases := []string{
+		"testdata/server_reject_debug_port_same_as_tcp.json",
+		"testdata/server_reject_decoy_invalid_address.json",
+		"testdata/server_reject_destination_acl_invalid_port.json",
+		"testdata/server_reject_egress_rule_empty_rule_file.json",
+		"testdata/server_reject_egress_rule_invalid_ip_range.json",
+		"testdata/server_reject_egress_rule_source_ip_with_proxy.json",
+		"testdata/server_reject_fairness_high_priority_weight_too_big.json",
+		"testdata/server_reject_fec_group_size_too_small.json",
+		"testdata/server_reject_flow_control_bandwidth_too_big.json",
+		"testdata/server_reject_guest_no_expire_time.json",
+		"testdata/server_reject_invalid_expire_time.json",
+		"testdata/server_reject_invalid_next_hashed_password.json",
 		"testdata/server_reject_invalid_port_range_1.json",
 		"testdata/server_reject_invalid_port_range_2.json",
 		"testdata/server_reject_invalid_port_range_3.json",
 		"testdata/server_reject_invalid_quota_days.json",
 		"testdata/server_reject_invalid_quota_megabytes.json",
+		"testdata/server_reject_monthly_quota_with_days.json",
 		"testdata/server_reject_mtu_too_big.json",
 		"testdata/server_reject_mtu_too_small.json",
 		"testdata/server_reject_no_password.json",
@@ -78,6 +134,16 @@ func TestServerApplyReject(t *testing.T) {
 		"testdata/server_reject_no_port.json",
 		"testdata/server_reject_no_protocol.json",
 		"testdata/server_reject_no_user_name.json",
+		"testdata/server_reject_retransmission_limit_negative_seconds.json",
+		"testdata/server_reject_reverse_tunnel_invalid_port_range.json",
+		"testdata/server_reject_reverse_tunnel_overlap.json",
+		"testdata/server_reject_reverse_tunnel_unknown_user.json",
+		"testdata/server_reject_session_engine_workers_without_event_driven.json",
+		"testdata/server_reject_source_limit_max_penalty_too_small.json",
+		"testdata/server_reject_tls_no_key_file.json",
+		"testdata/server_reject_tracing_invalid_endpoint.json",
+		"testdata/server_reject_tracing_sample_ratio_too_big.json",
+		"testdata/server_reject_websocket_no_key_file.json",
 	}
 
 	for _, c := range cases {
@@ -119,6 +185,115 @@ func TestServerDeleteUser(t *testing.T) {
 	afterServerTest(t)
 }
 
+func TestServerDeleteExpiredGuestUsers(t *testing.T) {
+	beforeServerTest(t)
+
+	configFile := "testdata/server_apply_config_2.json"
+	if err := ApplyJSONServerConfig(configFile); err != nil {
+		t.Fatalf("ApplyJSONServerConfig() failed: %v", err)
+	}
+	config, err := LoadServerConfig()
+	if err != nil {
+		t.Fatalf("LoadServerConfig() failed: %v", err)
+	}
+	now := time.Now()
+	guest, err := NewGuestUser(time.Hour, now)
+	if err != nil {
+		t.Fatalf("NewGuestUser() failed: %v", err)
+	}
+	// A user that is not a guest is kept after expiry.
+	config.GetUsers()[0].ExpireTime = proto.String(now.Add(time.Minute).Format(time.RFC3339))
+	if err := ImportServerUsers(config, []*pb.User{guest}); err != nil {
+		t.Fatalf("ImportServerUsers() failed: %v", err)
+	}
+	if err := StoreServerConfig(config); err != nil {
+		t.Fatalf("StoreServerConfig() failed: %v", err)
+	}
+
+	if deleted, err := DeleteExpiredGuestUsers(now); err != nil || len(deleted) != 0 {
+		t.Errorf("DeleteExpiredGuestUsers() = %v, %v, want no deleted user", deleted, err)
+	}
+	deleted, err := DeleteExpiredGuestUsers(now.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteExpiredGuestUsers() failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != guest.GetName() {
+		t.Errorf("DeleteExpiredGuestUsers() = %v, want [%s]", deleted, guest.GetName())
+	}
+	config, err = LoadServerConfig()
+	if err != nil {
+		t.Fatalf("LoadServerConfig() failed: %v", err)
+	}
+	if len(config.GetUsers()) != 2 {
+		t.Errorf("want 2 users, got %d user(s)", len(config.GetUsers()))
+	}
+
+	afterServerTest(t)
+}
+
+func TestServerAddUpdateRemoveUser(t *testing.T) {
+	beforeServerTest(t)
+
+	configFile := "testdata/server_apply_config_2.json"
+	if err := ApplyJSONServerConfig(configFile); err != nil {
+		t.Fatalf("ApplyJSONServerConfig() failed: %v", err)
+	}
+	user := &pb.User{
+		Name:     proto.String("user3"),
+		Password: proto.String("password3"),
+	}
+	if err := AddServerUser(user); err != nil {
+		t.Fatalf("AddServerUser() failed: %v", err)
+	}
+	if err := AddServerUser(user); err == nil {
+		t.Errorf("AddServerUser() with an existing user is not rejected")
+	}
+	config, err := LoadServerConfig()
+	if err != nil {
+		t.Fatalf("LoadServerConfig() failed: %v", err)
+	}
+	added := findServerUser(config, "user3")
+	if added == nil || added.GetPassword() != "" || added.GetHashedPassword() == "" {
+		t.Fatalf("added user = %v, want a user with only the hashed password", added)
+	}
+
+	updated := proto.Clone(added).(*pb.User)
+	updated.Disabled = proto.Bool(true)
+	if err := UpdateServerUser(updated); err != nil {
+		t.Fatalf("UpdateServerUser() failed: %v", err)
+	}
+	if err := UpdateServerUser(&pb.User{Name: proto.String("user4"), Password: proto.String("password4")}); err == nil {
+		t.Errorf("UpdateServerUser() with an unknown user is not rejected")
+	}
+	config, err = LoadServerConfig()
+	if err != nil {
+		t.Fatalf("LoadServerConfig() failed: %v", err)
+	}
+	if !findServerUser(config, "user3").GetDisabled() {
+		t.Errorf("user3 is not disabled")
+	}
+
+	if err := RemoveServerUser("user3"); err != nil {
+		t.Fatalf("RemoveServerUser() failed: %v", err)
+	}
+	if err := RemoveServerUser("user3"); err == nil {
+		t.Errorf("RemoveServerUser() with an unknown user is not rejected")
+	}
+	// user1 is used by a reverse tunnel.
+	if err := RemoveServerUser("user1"); err == nil {
+		t.Errorf("RemoveServerUser() with a user of reverse tunnel is not rejected")
+	}
+	config, err = LoadServerConfig()
+	if err != nil {
+		t.Fatalf("LoadServerConfig() failed: %v", err)
+	}
+	if len(config.GetUsers()) != 2 {
+		t.Errorf("want 2 users, got %d user(s)", len(config.GetUsers()))
+	}
+
+	afterServerTest(t)
+}
+
 func TestServerHashUserPassword(t *testing.T) {
 	beforeServerTest(t)
 
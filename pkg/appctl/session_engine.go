@@ -0,0 +1,50 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+)
+
+// validateSessionEngine validates the session engine settings.
+// A nil config is valid.
+func validateSessionEngine(config *pb.SessionEngineConfig) error {
+	if config == nil {
+		return nil
+	}
+	if _, ok := pb.SessionEngineType_name[int32(config.GetType())]; !ok {
+		return fmt.Errorf("session engine: type %d is unknown", config.GetType())
+	}
+	if config.GetWorkers() < 0 || config.GetWorkers() > protocol.MaxEventEngineWorkers {
+		return fmt.Errorf("session engine: workers %d is out of range, valid range is [1, %d]", config.GetWorkers(), protocol.MaxEventEngineWorkers)
+	}
+	if config.GetWorkers() != 0 && config.GetType() != pb.SessionEngineType_EVENT_DRIVEN {
+		return fmt.Errorf("session engine: workers can only be set with EVENT_DRIVEN type")
+	}
+	return nil
+}
+
+// SessionEngine returns the session engine and the number of workers
+// from the configuration. 0 workers means the default value is used.
+func SessionEngine(config *pb.SessionEngineConfig) (protocol.SessionEngine, int) {
+	if config.GetType() == pb.SessionEngineType_EVENT_DRIVEN {
+		return protocol.SessionEngineEventDriven, int(config.GetWorkers())
+	}
+	return protocol.SessionEngineGoroutine, 0
+}
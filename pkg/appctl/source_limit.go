@@ -0,0 +1,59 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+)
+
+// validateSourceLimit validates the source limit settings.
+// A nil config is valid.
+func validateSourceLimit(config *pb.SourceLimitConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.GetMaxUnderlays() < 0 {
+		return fmt.Errorf("source limit: maximum number of underlays %d is invalid", config.GetMaxUnderlays())
+	}
+	if config.GetMaxHandshakesPerMinute() < 0 {
+		return fmt.Errorf("source limit: maximum number of handshakes per minute %d is invalid", config.GetMaxHandshakesPerMinute())
+	}
+	if config.GetPenaltySeconds() < 0 {
+		return fmt.Errorf("source limit: penalty seconds %d is invalid", config.GetPenaltySeconds())
+	}
+	if config.GetMaxPenaltySeconds() < 0 {
+		return fmt.Errorf("source limit: maximum penalty seconds %d is invalid", config.GetMaxPenaltySeconds())
+	}
+	if config.GetMaxPenaltySeconds() != 0 && config.GetMaxPenaltySeconds() < config.GetPenaltySeconds() {
+		return fmt.Errorf("source limit: maximum penalty seconds %d is smaller than penalty seconds %d", config.GetMaxPenaltySeconds(), config.GetPenaltySeconds())
+	}
+	return nil
+}
+
+// SourceLimit returns the limits of each client IP address
+// from the configuration.
+func SourceLimit(config *pb.SourceLimitConfig) protocol.SourceLimit {
+	return protocol.SourceLimit{
+		MaxUnderlays:           int(config.GetMaxUnderlays()),
+		MaxHandshakesPerMinute: int(config.GetMaxHandshakesPerMinute()),
+		Penalty:                time.Duration(config.GetPenaltySeconds()) * time.Second,
+		MaxPenalty:             time.Duration(config.GetMaxPenaltySeconds()) * time.Second,
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+	"google.golang.org/protobuf/proto"
+)
+
+// configRetryInterval is the interval to retry writing a config file
+// that is kept in memory.
+const configRetryInterval = time.Minute
+
+var (
+	// configMemoryFallback is true if a config file that can't be written
+	// is kept in memory. It is only enabled by the daemons, because the
+	// memory of a short lived command is lost after it exits.
+	configMemoryFallback atomic.Bool
+
+	// pendingConfigMu protects pendingConfigFiles and config file writes.
+	pendingConfigMu sync.Mutex
+
+	// pendingConfigFiles are config files kept in memory, keyed by path.
+	pendingConfigFiles = make(map[string]*pendingConfigFile)
+
+	// retryingConfigFiles are config files with a running retry goroutine.
+	retryingConfigFiles = make(map[string]bool)
+)
+
+// pendingConfigFile is a config file that is not written to disk yet.
+type pendingConfigFile struct {
+	component string
+	content   []byte
+}
+
+// EnableConfigMemoryFallback keeps a config file in memory if it can't be
+// written to disk, for example when the disk is full. The config is written
+// to disk after the disk is writable again.
+func EnableConfigMemoryFallback() {
+	configMemoryFallback.Store(true)
+}
+
+// readConfigFile returns the content of a config file. The content kept
+// in memory takes precedence.
+func readConfigFile(fileName string) ([]byte, error) {
+	pendingConfigMu.Lock()
+	if p, ok := pendingConfigFiles[fileName]; ok {
+		b := p.content
+		pendingConfigMu.Unlock()
+		return b, nil
+	}
+	pendingConfigMu.Unlock()
+
+	f, err := os.Open(fileName)
+	if err != nil && os.IsNotExist(err) {
+		return nil, stderror.ErrFileNotExist
+	} else if err != nil {
+		return nil, fmt.Errorf("os.Open() failed: %w", err)
+	}
+	defer f.Close()
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll(%q) failed: %w", fileName, err)
+	}
+	return b, nil
+}
+
+// writeConfigFile writes the content of a config file. If the write fails
+// and memory fallback is enabled, the content is kept in memory and no
+// error is returned.
+func writeConfigFile(component, fileName string, b []byte) error {
+	pendingConfigMu.Lock()
+	defer pendingConfigMu.Unlock()
+
+	err := os.WriteFile(fileName, b, 0660)
+	if err == nil {
+		if _, ok := pendingConfigFiles[fileName]; ok {
+			delete(pendingConfigFiles, fileName)
+			log.ReportStorageRecovery(component)
+		}
+		return nil
+	}
+	if !configMemoryFallback.Load() {
+		return fmt.Errorf("os.WriteFile(%q) failed: %w", fileName, err)
+	}
+	log.ReportStorageFailure(component, err)
+	if !retryingConfigFiles[fileName] {
+		retryingConfigFiles[fileName] = true
+		go retryConfigFile(fileName)
+	}
+	pendingConfigFiles[fileName] = &pendingConfigFile{
+		component: component,
+		content:   b,
+	}
+	return nil
+}
+
+// retryConfigFile writes the config file kept in memory to disk
+// until it succeeds.
+func retryConfigFile(fileName string) {
+	for {
+		time.Sleep(configRetryInterval)
+		pendingConfigMu.Lock()
+		p, ok := pendingConfigFiles[fileName]
+		if !ok {
+			delete(retryingConfigFiles, fileName)
+			pendingConfigMu.Unlock()
+			return
+		}
+		if err := os.WriteFile(fileName, p.content, 0660); err == nil {
+			delete(pendingConfigFiles, fileName)
+			delete(retryingConfigFiles, fileName)
+			pendingConfigMu.Unlock()
+			log.ReportStorageRecovery(p.component)
+			return
+		}
+		pendingConfigMu.Unlock()
+	}
+}
+
+// setStorageHealth adds the storage health to the app status.
+func setStorageHealth(msg *pb.AppStatusMsg) {
+	failures := log.StorageFailures()
+	if len(failures) > 0 {
+		msg.StorageDegraded = proto.Bool(true)
+		msg.StorageFailures = failures
+	}
+}
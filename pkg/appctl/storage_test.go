@@ -0,0 +1,74 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+func TestWriteConfigFileMemoryFallback(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "unwritable")
+	fileName := filepath.Join(dir, "server.conf.json")
+	defer configMemoryFallback.Store(false)
+	defer log.ReportStorageRecovery(log.StorageServerConfig)
+
+	if err := writeConfigFile(log.StorageServerConfig, fileName, []byte("{}")); err == nil {
+		t.Fatalf("writeConfigFile() succeeded without memory fallback, want error")
+	}
+
+	EnableConfigMemoryFallback()
+	if err := writeConfigFile(log.StorageServerConfig, fileName, []byte("{}")); err != nil {
+		t.Fatalf("writeConfigFile() failed with memory fallback: %v", err)
+	}
+	b, err := readConfigFile(fileName)
+	if err != nil {
+		t.Fatalf("readConfigFile() failed: %v", err)
+	}
+	if !bytes.Equal(b, []byte("{}")) {
+		t.Errorf("readConfigFile() = %q, want %q", b, "{}")
+	}
+	msg := &pb.AppStatusMsg{}
+	setStorageHealth(msg)
+	if !msg.GetStorageDegraded() || len(msg.GetStorageFailures()) != 1 {
+		t.Errorf("storage health is not reported: %v", msg)
+	}
+
+	// The config is written to disk after the disk is writable again.
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("os.MkdirAll() failed: %v", err)
+	}
+	if err := writeConfigFile(log.StorageServerConfig, fileName, []byte("{ }")); err != nil {
+		t.Fatalf("writeConfigFile() failed: %v", err)
+	}
+	b, err = os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("os.ReadFile() failed: %v", err)
+	}
+	if !bytes.Equal(b, []byte("{ }")) {
+		t.Errorf("config file content is %q, want %q", b, "{ }")
+	}
+	msg = &pb.AppStatusMsg{}
+	setStorageHealth(msg)
+	if msg.GetStorageDegraded() {
+		t.Errorf("storage is still degraded after recovery: %v", msg)
+	}
+}
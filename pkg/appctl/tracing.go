@@ -0,0 +1,90 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/tracing"
+)
+
+// tracingExporter is the exporter shared by the socks5 servers, so the
+// spans are not lost when the proxy is stopped and started again.
+var tracingExporter struct {
+	mu       sync.Mutex
+	exporter *tracing.OTLPExporter
+}
+
+// validateTracing validates the tracing config. A nil config is valid.
+func validateTracing(config *pb.TracingConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.GetOtlpEndpoint() != "" {
+		if err := tracing.ValidateOTLPEndpoint(config.GetOtlpEndpoint()); err != nil {
+			return fmt.Errorf("tracing: %w", err)
+		}
+	}
+	ratio := config.GetSampleRatio()
+	if math.IsNaN(ratio) || ratio < 0 || ratio > 1 {
+		return fmt.Errorf("tracing: sample ratio %v is out of range, valid range is [0, 1]", ratio)
+	}
+	return nil
+}
+
+// Tracer returns the tracer of the proxy connections of socks5 server
+// from the configuration. It returns nil if tracing is disabled.
+// The exporter is reused if the OTLP endpoint doesn't change.
+func Tracer(config *pb.TracingConfig, kind tracing.SpanKind) (*tracing.Tracer, error) {
+	tracingExporter.mu.Lock()
+	defer tracingExporter.mu.Unlock()
+	endpoint := config.GetOtlpEndpoint()
+	if old := tracingExporter.exporter; old != nil && old.Endpoint() != endpoint {
+		old.Close()
+		tracingExporter.exporter = nil
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+	if tracingExporter.exporter == nil {
+		serviceName := "mita"
+		if kind == tracing.SpanKindClient {
+			serviceName = "mieru"
+		}
+		exporter, err := tracing.NewOTLPExporter(endpoint, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		tracingExporter.exporter = exporter
+		log.Infof("proxy connection spans are exported to %s", endpoint)
+	}
+	return tracing.NewTracer(kind, tracingExporter.exporter, config.GetSampleRatio()), nil
+}
+
+// CloseTracer sends the spans that are not exported yet,
+// and stops the exporter.
+func CloseTracer() {
+	tracingExporter.mu.Lock()
+	defer tracingExporter.mu.Unlock()
+	if tracingExporter.exporter != nil {
+		tracingExporter.exporter.Close()
+		tracingExporter.exporter = nil
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"fmt"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+)
+
+// validateTransferCap validates the per-connection transfer cap.
+// A nil cap is valid.
+func validateTransferCap(transferCap *pb.TransferCap) error {
+	if transferCap == nil {
+		return nil
+	}
+	if transferCap.GetMaxUploadBytes() < 0 {
+		return fmt.Errorf("transfer cap: max upload bytes %d is negative", transferCap.GetMaxUploadBytes())
+	}
+	if transferCap.GetMaxDownloadBytes() < 0 {
+		return fmt.Errorf("transfer cap: max download bytes %d is negative", transferCap.GetMaxDownloadBytes())
+	}
+	return nil
+}
+
+// TransferCap returns the per-connection transfer cap of socks5 server
+// from the configuration.
+func TransferCap(transferCap *pb.TransferCap) socks5.TransferCap {
+	return socks5.TransferCap{
+		MaxUploadBytes:   transferCap.GetMaxUploadBytes(),
+		MaxDownloadBytes: transferCap.GetMaxDownloadBytes(),
+	}
+}
@@ -16,13 +16,34 @@
 package appctl
 
 import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/protocol"
 	"google.golang.org/protobuf/proto"
 )
 
+// monthlyQuota replaces the number of days of a monthly quota in CSV format.
+const monthlyQuota = "monthly"
+
+// userCSVHeader is the header row of users in CSV format.
+// Each quota is written as "DAYS:MEGABYTES", or "monthly:MEGABYTES"
+// for a monthly quota, and quotas are separated by ";".
+var userCSVHeader = []string{"name", "password", "hashedPassword", "quotas", "expireTime"}
+
 // UserListToMap convert a slice of User to a map of <name, User>.
 func UserListToMap(users []*pb.User) map[string]*pb.User {
 	m := map[string]*pb.User{}
@@ -34,13 +55,22 @@ func UserListToMap(users []*pb.User) map[string]*pb.User {
 }
 
 // HashUserPassword replaces user's password with hashed password.
+// The next password is hashed in the same way.
 func HashUserPassword(user *pb.User, keepPlaintext bool) *pb.User {
-	if user == nil || user.GetPassword() == "" {
+	if user == nil {
 		return user
 	}
-	user.HashedPassword = proto.String(hex.EncodeToString(cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))))
-	if !keepPlaintext {
-		user.Password = proto.String("")
+	if user.GetPassword() != "" {
+		user.HashedPassword = proto.String(hex.EncodeToString(cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))))
+		if !keepPlaintext {
+			user.Password = proto.String("")
+		}
+	}
+	if user.GetNextPassword() != "" {
+		user.NextHashedPassword = proto.String(hex.EncodeToString(cipher.HashPassword([]byte(user.GetNextPassword()), []byte(user.GetName()))))
+		if !keepPlaintext {
+			user.NextPassword = proto.String("")
+		}
 	}
 	return user
 }
@@ -52,3 +82,345 @@ func HashUserPasswords(users []*pb.User, keepPlaintext bool) []*pb.User {
 	}
 	return users
 }
+
+// GenerateUserPassword returns a new random password.
+func GenerateUserPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := crand.Read(b); err != nil {
+		return "", fmt.Errorf("crand.Read() failed: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ReadUsers reads users in JSON or CSV format. JSON format is the same
+// as the users of the server config, for example {"users": [...]}.
+// The format is JSON if the content starts with "{".
+func ReadUsers(b []byte) ([]*pb.User, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return readUsersJSON(b)
+	}
+	return readUsersCSV(bytes.NewReader(b))
+}
+
+func readUsersJSON(b []byte) ([]*pb.User, error) {
+	config := &pb.ServerConfig{}
+	if err := common.UnmarshalJSON(b, config); err != nil {
+		return nil, fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
+	}
+	if !proto.Equal(config, &pb.ServerConfig{Users: config.GetUsers()}) {
+		return nil, fmt.Errorf("only users are allowed")
+	}
+	return config.GetUsers(), nil
+}
+
+func readUsersCSV(r io.Reader) ([]*pb.User, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("csv.Reader.ReadAll() failed: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV header is not found")
+	}
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, h := range userCSVHeader {
+			if name == h {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicated CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("CSV column %q is not found", "name")
+	}
+
+	users := make([]*pb.User, 0, len(records)-1)
+	for line, record := range records[1:] {
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		user := &pb.User{Name: proto.String(field("name"))}
+		if v := field("password"); v != "" {
+			user.Password = proto.String(v)
+		}
+		if v := field("hashedPassword"); v != "" {
+			user.HashedPassword = proto.String(v)
+		}
+		if v := field("quotas"); v != "" {
+			for _, q := range strings.Split(v, ";") {
+				quota, err := parseQuota(q)
+				if err != nil {
+					return nil, fmt.Errorf("CSV line %d: %w", line+2, err)
+				}
+				user.Quotas = append(user.Quotas, quota)
+			}
+		}
+		if v := field("expireTime"); v != "" {
+			user.ExpireTime = proto.String(v)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// parseQuota parses a quota in "DAYS:MEGABYTES" or "monthly:MEGABYTES" format.
+func parseQuota(s string) (*pb.Quota, error) {
+	days, megabytes, found := strings.Cut(strings.TrimSpace(s), ":")
+	if !found {
+		return nil, fmt.Errorf("quota %q is not in DAYS:MEGABYTES format", s)
+	}
+	m, err := strconv.ParseInt(megabytes, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("quota %q has invalid megabytes: %w", s, err)
+	}
+	if days == monthlyQuota {
+		return &pb.Quota{Monthly: proto.Bool(true), Megabytes: proto.Int32(int32(m))}, nil
+	}
+	d, err := strconv.ParseInt(days, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("quota %q has invalid number of days: %w", s, err)
+	}
+	return &pb.Quota{Days: proto.Int32(int32(d)), Megabytes: proto.Int32(int32(m))}, nil
+}
+
+// formatQuota returns a quota in the format parsed by parseQuota.
+func formatQuota(quota *pb.Quota) string {
+	if quota.GetMonthly() {
+		return fmt.Sprintf("%s:%d", monthlyQuota, quota.GetMegabytes())
+	}
+	return fmt.Sprintf("%d:%d", quota.GetDays(), quota.GetMegabytes())
+}
+
+// validateRateLimit validates the rate limit of a user. A nil config is valid.
+func validateRateLimit(rateLimit *pb.RateLimit) error {
+	if rateLimit == nil {
+		return nil
+	}
+	if rateLimit.GetUploadKilobytesPerSecond() < 0 || rateLimit.GetUploadKilobytesPerSecond() > protocol.MaxRateLimitKilobytesPerSecond {
+		return fmt.Errorf("rate limit: upload kilobytes per second %d is out of range, valid range is [0, %d]", rateLimit.GetUploadKilobytesPerSecond(), protocol.MaxRateLimitKilobytesPerSecond)
+	}
+	if rateLimit.GetDownloadKilobytesPerSecond() < 0 || rateLimit.GetDownloadKilobytesPerSecond() > protocol.MaxRateLimitKilobytesPerSecond {
+		return fmt.Errorf("rate limit: download kilobytes per second %d is out of range, valid range is [0, %d]", rateLimit.GetDownloadKilobytesPerSecond(), protocol.MaxRateLimitKilobytesPerSecond)
+	}
+	return nil
+}
+
+// userUsages returns the traffic, quota usage and rate limit of the users,
+// sorted by name.
+func userUsages(users []*pb.User, now time.Time) *pb.UserUsages {
+	res := &pb.UserUsages{}
+	for _, user := range users {
+		uploadBytes, downloadBytes := protocol.UserTrafficBytes(user.GetName())
+		usage := &pb.UserUsage{
+			UserName:      proto.String(user.GetName()),
+			UploadBytes:   proto.Int64(uploadBytes),
+			DownloadBytes: proto.Int64(downloadBytes),
+			RateLimit:     user.GetRateLimit(),
+			ExpireTime:    user.ExpireTime,
+		}
+		for _, quota := range user.GetQuotas() {
+			// The user has no traffic if the metrics are not found.
+			usedBytes, _ := protocol.QuotaUsedBytes(user.GetName(), quota, now)
+			usage.Quotas = append(usage.Quotas, &pb.QuotaUsage{
+				Quota:     quota,
+				UsedBytes: proto.Int64(usedBytes),
+			})
+		}
+		res.Users = append(res.Users, usage)
+	}
+	sort.Slice(res.Users, func(i, j int) bool {
+		return res.Users[i].GetUserName() < res.Users[j].GetUserName()
+	})
+	return res
+}
+
+// trafficToProto returns the bytes and segments transferred by the users
+// and the active sessions. The users in the config and the users of the
+// active sessions are reported. From the server's view, bytes read by
+// a session are uploaded by the user.
+func trafficToProto(users []*pb.User, sessions []protocol.SessionTraffic) *pb.Traffic {
+	sessionCount := make(map[string]int32)
+	for _, user := range users {
+		sessionCount[user.GetName()] = 0
+	}
+	res := &pb.Traffic{}
+	for _, session := range sessions {
+		sessionCount[session.UserName]++
+		res.Sessions = append(res.Sessions, &pb.SessionTraffic{
+			SessionID:        proto.Uint32(session.ID),
+			UserName:         proto.String(session.UserName),
+			UploadBytes:      proto.Int64(int64(session.BytesRead)),
+			DownloadBytes:    proto.Int64(int64(session.BytesWritten)),
+			UploadSegments:   proto.Int64(int64(session.SegmentsRecv)),
+			DownloadSegments: proto.Int64(int64(session.SegmentsSent)),
+		})
+	}
+	for userName, count := range sessionCount {
+		if userName == "" {
+			// The session is not authenticated yet.
+			continue
+		}
+		uploadBytes, downloadBytes := protocol.UserTrafficBytes(userName)
+		uploadSegments, downloadSegments := protocol.UserTrafficSegments(userName)
+		res.Users = append(res.Users, &pb.UserTraffic{
+			UserName:         proto.String(userName),
+			UploadBytes:      proto.Int64(uploadBytes),
+			DownloadBytes:    proto.Int64(downloadBytes),
+			UploadSegments:   proto.Int64(uploadSegments),
+			DownloadSegments: proto.Int64(downloadSegments),
+			Sessions:         proto.Int32(count),
+		})
+	}
+	sort.Slice(res.Users, func(i, j int) bool {
+		return res.Users[i].GetUserName() < res.Users[j].GetUserName()
+	})
+	return res
+}
+
+// WriteUsersJSON writes users in JSON format.
+func WriteUsersJSON(w io.Writer, users []*pb.User) error {
+	b, err := common.MarshalJSON(&pb.ServerConfig{Users: users})
+	if err != nil {
+		return fmt.Errorf("common.MarshalJSON() failed: %w", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteUsersCSV writes users in CSV format.
+func WriteUsersCSV(w io.Writer, users []*pb.User) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(userCSVHeader); err != nil {
+		return err
+	}
+	for _, user := range users {
+		quotas := make([]string, 0, len(user.GetQuotas()))
+		for _, quota := range user.GetQuotas() {
+			quotas = append(quotas, formatQuota(quota))
+		}
+		record := []string{user.GetName(), user.GetPassword(), user.GetHashedPassword(), strings.Join(quotas, ";"), user.GetExpireTime()}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportServerUsers adds the users to the server config, or replaces
+// the existing users with the same name. The server config is not
+// changed if any user is invalid.
+func ImportServerUsers(config *pb.ServerConfig, users []*pb.User) error {
+	names := map[string]struct{}{}
+	for _, user := range users {
+		if _, found := names[user.GetName()]; found {
+			return fmt.Errorf("user %q is duplicated", user.GetName())
+		}
+		names[user.GetName()] = struct{}{}
+	}
+	patch := &pb.ServerConfig{Users: users}
+	if err := ValidateServerConfigPatch(patch); err != nil {
+		return fmt.Errorf("ValidateServerConfigPatch() failed: %w", err)
+	}
+	merged := proto.Clone(config).(*pb.ServerConfig)
+	if err := mergeServerConfig(merged, patch); err != nil {
+		return fmt.Errorf("mergeServerConfig() failed: %w", err)
+	}
+	if err := ValidateFullServerConfig(merged); err != nil {
+		return fmt.Errorf("ValidateFullServerConfig() failed: %w", err)
+	}
+	proto.Reset(config)
+	proto.Merge(config, merged)
+	return nil
+}
+
+// guestUserPrefix is the name prefix of guest users.
+const guestUserPrefix = "guest-"
+
+// NewGuestUser returns a guest user with a random name and password,
+// which expires after the duration.
+func NewGuestUser(ttl time.Duration, now time.Time) (*pb.User, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("guest user duration %v is not positive", ttl)
+	}
+	b := make([]byte, 4)
+	if _, err := crand.Read(b); err != nil {
+		return nil, fmt.Errorf("crand.Read() failed: %w", err)
+	}
+	password, err := GenerateUserPassword()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.User{
+		Name:       proto.String(guestUserPrefix + hex.EncodeToString(b)),
+		Password:   proto.String(password),
+		ExpireTime: proto.String(now.Add(ttl).UTC().Format(time.RFC3339)),
+		Guest:      proto.Bool(true),
+	}, nil
+}
+
+// UserExpired returns true if the user has an expire time
+// that is not after the given time.
+func UserExpired(user *pb.User, now time.Time) bool {
+	if user.ExpireTime == nil {
+		return false
+	}
+	expireTime, err := time.Parse(time.RFC3339, user.GetExpireTime())
+	if err != nil {
+		return false
+	}
+	return !now.Before(expireTime)
+}
+
+// GuestClientConfig returns a client config patch with a single profile
+// for the user to connect to the server at the host. The host is an IP
+// address or a domain name.
+func GuestClientConfig(config *pb.ServerConfig, user *pb.User, host string) (*pb.ClientConfig, error) {
+	if host == "" {
+		return nil, fmt.Errorf("server host is empty")
+	}
+	if user.GetPassword() == "" {
+		return nil, fmt.Errorf("user %q has no password", user.GetName())
+	}
+	server := &pb.ServerEndpoint{
+		PortBindings: config.GetPortBindings(),
+	}
+	if net.ParseIP(host) != nil {
+		server.IpAddress = proto.String(host)
+	} else {
+		server.DomainName = proto.String(host)
+	}
+	profile := &pb.ClientProfile{
+		ProfileName: proto.String(user.GetName()),
+		User: &pb.User{
+			Name:     proto.String(user.GetName()),
+			Password: proto.String(user.GetPassword()),
+		},
+		Servers: []*pb.ServerEndpoint{server},
+	}
+	if config.Mtu != nil {
+		profile.Mtu = proto.Int32(config.GetMtu())
+	}
+	patch := &pb.ClientConfig{
+		Profiles:      []*pb.ClientProfile{profile},
+		ActiveProfile: proto.String(user.GetName()),
+	}
+	if err := ValidateClientConfigPatch(patch); err != nil {
+		return nil, fmt.Errorf("ValidateClientConfigPatch() failed: %w", err)
+	}
+	return patch, nil
+}
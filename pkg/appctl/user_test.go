@@ -0,0 +1,305 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package appctl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReadUsersCSV(t *testing.T) {
+	input := "name,password,quotas,expireTime\n" +
+		"user1,pass1,30:1024;1:100,2030-01-01T00:00:00Z\n" +
+		"user2,,,\n"
+	users, err := ReadUsers([]byte(input))
+	if err != nil {
+		t.Fatalf("ReadUsers() failed: %v", err)
+	}
+	want := []*pb.User{
+		{
+			Name:     proto.String("user1"),
+			Password: proto.String("pass1"),
+			Quotas: []*pb.Quota{
+				{Days: proto.Int32(30), Megabytes: proto.Int32(1024)},
+				{Days: proto.Int32(1), Megabytes: proto.Int32(100)},
+			},
+			ExpireTime: proto.String("2030-01-01T00:00:00Z"),
+		},
+		{
+			Name: proto.String("user2"),
+		},
+	}
+	if len(users) != len(want) {
+		t.Fatalf("got %d users, want %d", len(users), len(want))
+	}
+	for i := range want {
+		if !proto.Equal(users[i], want[i]) {
+			t.Errorf("user %d = %v, want %v", i, users[i], want[i])
+		}
+	}
+}
+
+func TestReadUsersReject(t *testing.T) {
+	cases := []string{
+		"password\npass1\n",
+		"name,unknown\nuser1,x\n",
+		"name,quotas\nuser1,30\n",
+		"name,quotas\nuser1,yearly:100\n",
+		`{"users": [{"name": "user1"}], "mtu": 1400}`,
+	}
+	for _, c := range cases {
+		if _, err := ReadUsers([]byte(c)); err == nil {
+			t.Errorf("ReadUsers(%q) returned no error", c)
+		}
+	}
+}
+
+func TestWriteAndReadUsers(t *testing.T) {
+	users := []*pb.User{
+		{
+			Name:           proto.String("user1"),
+			HashedPassword: proto.String("abcd"),
+			Quotas: []*pb.Quota{
+				{Days: proto.Int32(30), Megabytes: proto.Int32(1024)},
+				{Monthly: proto.Bool(true), Megabytes: proto.Int32(2048)},
+			},
+			ExpireTime: proto.String("2030-01-01T00:00:00Z"),
+		},
+		{
+			Name:           proto.String("user2"),
+			HashedPassword: proto.String("ef01"),
+		},
+	}
+	for _, write := range []func(io.Writer, []*pb.User) error{WriteUsersCSV, WriteUsersJSON} {
+		var buf bytes.Buffer
+		if err := write(&buf, users); err != nil {
+			t.Fatalf("write users failed: %v", err)
+		}
+		got, err := ReadUsers(buf.Bytes())
+		if err != nil {
+			t.Fatalf("ReadUsers() failed: %v", err)
+		}
+		if len(got) != len(users) {
+			t.Fatalf("got %d users, want %d", len(got), len(users))
+		}
+		for i := range users {
+			if !proto.Equal(got[i], users[i]) {
+				t.Errorf("user %d = %v, want %v", i, got[i], users[i])
+			}
+		}
+	}
+}
+
+func TestUserUsages(t *testing.T) {
+	uploadBytes := metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, "usage-user1"), metrics.UserMetricUploadBytes, metrics.COUNTER_TIME_SERIES)
+	downloadBytes := metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, "usage-user1"), metrics.UserMetricDownloadBytes, metrics.COUNTER_TIME_SERIES)
+	uploadBytes.Add(1048576)
+	downloadBytes.Add(2 * 1048576)
+	users := []*pb.User{
+		{
+			Name: proto.String("usage-user2"),
+		},
+		{
+			Name: proto.String("usage-user1"),
+			Quotas: []*pb.Quota{
+				{Days: proto.Int32(30), Megabytes: proto.Int32(1024)},
+				{Monthly: proto.Bool(true), Megabytes: proto.Int32(2048)},
+			},
+			RateLimit: &pb.RateLimit{UploadKilobytesPerSecond: proto.Int32(512)},
+		},
+	}
+	usages := userUsages(users, time.Now().Add(time.Second))
+	if len(usages.GetUsers()) != 2 {
+		t.Fatalf("got %d users, want 2", len(usages.GetUsers()))
+	}
+	user1 := usages.GetUsers()[0]
+	if user1.GetUserName() != "usage-user1" {
+		t.Fatalf("first user is %q, want %q", user1.GetUserName(), "usage-user1")
+	}
+	if user1.GetUploadBytes() != 1048576 || user1.GetDownloadBytes() != 2*1048576 {
+		t.Errorf("user traffic is %d/%d bytes, want %d/%d", user1.GetUploadBytes(), user1.GetDownloadBytes(), 1048576, 2*1048576)
+	}
+	for _, quota := range user1.GetQuotas() {
+		if quota.GetUsedBytes() != 3*1048576 {
+			t.Errorf("quota %v used %d bytes, want %d", quota.GetQuota(), quota.GetUsedBytes(), 3*1048576)
+		}
+	}
+	if user1.GetRateLimit().GetUploadKilobytesPerSecond() != 512 {
+		t.Errorf("rate limit is %v, want 512 KB/s upload", user1.GetRateLimit())
+	}
+	user2 := usages.GetUsers()[1]
+	if user2.GetUploadBytes() != 0 || user2.GetDownloadBytes() != 0 || len(user2.GetQuotas()) != 0 {
+		t.Errorf("user %q has unexpected usage %v", user2.GetUserName(), user2)
+	}
+}
+
+func TestTrafficToProto(t *testing.T) {
+	metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, "traffic-user1"), metrics.UserMetricUploadBytes, metrics.COUNTER_TIME_SERIES).Add(100)
+	metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, "traffic-user1"), metrics.UserMetricUploadSegments, metrics.COUNTER).Add(2)
+	users := []*pb.User{
+		{Name: proto.String("traffic-user2")},
+		{Name: proto.String("traffic-user1")},
+	}
+	sessions := []protocol.SessionTraffic{
+		{ID: 1, UserName: "traffic-user1", BytesRead: 100, BytesWritten: 200, SegmentsRecv: 2, SegmentsSent: 3},
+		{ID: 2, UserName: "traffic-user3", BytesRead: 10},
+		{ID: 3},
+	}
+	traffic := trafficToProto(users, sessions)
+	if len(traffic.GetUsers()) != 3 {
+		t.Fatalf("got %d users, want 3", len(traffic.GetUsers()))
+	}
+	user1 := traffic.GetUsers()[0]
+	if user1.GetUserName() != "traffic-user1" || user1.GetSessions() != 1 {
+		t.Errorf("first user is %q with %d sessions, want %q with 1 session", user1.GetUserName(), user1.GetSessions(), "traffic-user1")
+	}
+	if user1.GetUploadBytes() != 100 || user1.GetUploadSegments() != 2 {
+		t.Errorf("user upload is %d bytes and %d segments, want 100 bytes and 2 segments", user1.GetUploadBytes(), user1.GetUploadSegments())
+	}
+	if traffic.GetUsers()[1].GetSessions() != 0 {
+		t.Errorf("user %q has %d sessions, want 0", traffic.GetUsers()[1].GetUserName(), traffic.GetUsers()[1].GetSessions())
+	}
+	if len(traffic.GetSessions()) != 3 {
+		t.Fatalf("got %d sessions, want 3", len(traffic.GetSessions()))
+	}
+	session1 := traffic.GetSessions()[0]
+	if session1.GetUploadBytes() != 100 || session1.GetDownloadBytes() != 200 || session1.GetUploadSegments() != 2 || session1.GetDownloadSegments() != 3 {
+		t.Errorf("unexpected session traffic %v", session1)
+	}
+}
+
+func TestImportServerUsers(t *testing.T) {
+	config := &pb.ServerConfig{
+		PortBindings: []*pb.PortBinding{
+			{Port: proto.Int32(8000), Protocol: pb.TransportProtocol_TCP.Enum()},
+		},
+		Users: []*pb.User{
+			{Name: proto.String("user1"), HashedPassword: proto.String("abcd")},
+			{Name: proto.String("user2"), HashedPassword: proto.String("ef01")},
+		},
+	}
+	before := proto.Clone(config)
+
+	// An invalid user rejects the whole import.
+	invalid := []*pb.User{
+		{Name: proto.String("user3"), Password: proto.String("pass3")},
+		{Name: proto.String("user4")},
+	}
+	if err := ImportServerUsers(config, invalid); err == nil {
+		t.Errorf("ImportServerUsers() returned no error with a user without password")
+	}
+	duplicated := []*pb.User{
+		{Name: proto.String("user3"), Password: proto.String("pass3")},
+		{Name: proto.String("user3"), Password: proto.String("pass4")},
+	}
+	if err := ImportServerUsers(config, duplicated); err == nil {
+		t.Errorf("ImportServerUsers() returned no error with duplicated users")
+	}
+	if !proto.Equal(config, before) {
+		t.Errorf("server config is changed after failed import")
+	}
+
+	users := []*pb.User{
+		{Name: proto.String("user2"), Password: proto.String("pass2"), ExpireTime: proto.String("2030-01-01T00:00:00Z")},
+		{Name: proto.String("user3"), Password: proto.String("pass3")},
+	}
+	if err := ImportServerUsers(config, users); err != nil {
+		t.Fatalf("ImportServerUsers() failed: %v", err)
+	}
+	got := UserListToMap(config.GetUsers())
+	if len(got) != 3 {
+		t.Fatalf("got %d users, want 3", len(got))
+	}
+	if got["user1"].GetHashedPassword() != "abcd" {
+		t.Errorf("user1 is changed")
+	}
+	if got["user2"].GetExpireTime() != "2030-01-01T00:00:00Z" {
+		t.Errorf("user2 is not updated")
+	}
+	if got["user3"].GetPassword() != "pass3" {
+		t.Errorf("user3 is not added")
+	}
+}
+
+func TestNewGuestUser(t *testing.T) {
+	now := time.Now()
+	guest, err := NewGuestUser(24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("NewGuestUser() failed: %v", err)
+	}
+	if !strings.HasPrefix(guest.GetName(), guestUserPrefix) || guest.GetPassword() == "" || !guest.GetGuest() {
+		t.Errorf("NewGuestUser() = %v, want a guest user with password", guest)
+	}
+	if UserExpired(guest, now.Add(23*time.Hour)) {
+		t.Errorf("guest user is expired before the duration")
+	}
+	if !UserExpired(guest, now.Add(25*time.Hour)) {
+		t.Errorf("guest user is not expired after the duration")
+	}
+	if _, err := NewGuestUser(0, now); err == nil {
+		t.Errorf("NewGuestUser() with zero duration returned no error")
+	}
+}
+
+func TestGuestClientConfig(t *testing.T) {
+	config := &pb.ServerConfig{
+		PortBindings: []*pb.PortBinding{
+			{Port: proto.Int32(8000), Protocol: pb.TransportProtocol_TCP.Enum()},
+		},
+		Mtu: proto.Int32(1400),
+	}
+	guest, err := NewGuestUser(time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("NewGuestUser() failed: %v", err)
+	}
+	for _, host := range []string{"example.com", "127.0.0.1"} {
+		clientConfig, err := GuestClientConfig(config, guest, host)
+		if err != nil {
+			t.Fatalf("GuestClientConfig() failed: %v", err)
+		}
+		u, err := ClientConfigToURL(clientConfig)
+		if err != nil {
+			t.Fatalf("ClientConfigToURL() failed: %v", err)
+		}
+		got, err := URLToClientConfig(u)
+		if err != nil {
+			t.Fatalf("URLToClientConfig() failed: %v", err)
+		}
+		if !proto.Equal(got, clientConfig) {
+			t.Errorf("client config changed after URL conversion")
+		}
+		profile := got.GetProfiles()[0]
+		if profile.GetUser().GetName() != guest.GetName() || profile.GetUser().GetPassword() != guest.GetPassword() {
+			t.Errorf("profile user = %v, want %v", profile.GetUser(), guest)
+		}
+		server := profile.GetServers()[0]
+		if server.GetIpAddress() != host && server.GetDomainName() != host {
+			t.Errorf("server = %v, want host %s", server, host)
+		}
+	}
+	if _, err := GuestClientConfig(config, guest, ""); err == nil {
+		t.Errorf("GuestClientConfig() with empty host returned no error")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build chaos
+
+package chaos
+
+import (
+	"sync"
+	"time"
+)
+
+// Enabled is true if the binary is built with chaos build tag.
+const Enabled = true
+
+var (
+	mu                 sync.Mutex
+	dropSegments       int
+	delayAcks          int
+	ackDelay           time.Duration
+	corruptCiphertexts int
+	killUnderlays      int
+)
+
+// DropSegments drops the next n data segments sent by any underlay.
+func DropSegments(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	dropSegments = n
+}
+
+// DelayAcks delays each of the next n acknowledge segments by d
+// before they are sent.
+func DelayAcks(n int, d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	delayAcks = n
+	ackDelay = d
+}
+
+// CorruptCiphertexts flips one bit in each of the next n encrypted
+// segments sent by any underlay.
+func CorruptCiphertexts(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	corruptCiphertexts = n
+}
+
+// KillUnderlays closes the next n underlays that send a segment.
+func KillUnderlays(n int) {
+	mu.Lock()
+	defer mu.Unlock()
+	killUnderlays = n
+}
+
+// Reset removes all the faults that are not injected yet.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	dropSegments = 0
+	delayAcks = 0
+	ackDelay = 0
+	corruptCiphertexts = 0
+	killUnderlays = 0
+}
+
+// OnWriteSegment is called by an underlay before a segment is sent.
+// isData and isAck tell whether the segment carries data or only
+// acknowledges. It may block if acknowledges are delayed.
+func OnWriteSegment(isData, isAck bool) Fault {
+	mu.Lock()
+	if killUnderlays > 0 {
+		killUnderlays--
+		mu.Unlock()
+		return Kill
+	}
+	if isData && dropSegments > 0 {
+		dropSegments--
+		mu.Unlock()
+		return Drop
+	}
+	var delay time.Duration
+	if isAck && delayAcks > 0 {
+		delayAcks--
+		delay = ackDelay
+	}
+	mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return None
+}
+
+// OnWriteCiphertext is called by an underlay before the encrypted
+// segment is written to the network. It may modify the ciphertext.
+func OnWriteCiphertext(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if corruptCiphertexts > 0 {
+		corruptCiphertexts--
+		b[len(b)/2] ^= 0x01
+	}
+}
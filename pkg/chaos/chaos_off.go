@@ -0,0 +1,29 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !chaos
+
+package chaos
+
+// Enabled is true if the binary is built with chaos build tag.
+const Enabled = false
+
+// OnWriteSegment is a no-op without chaos build tag.
+func OnWriteSegment(isData, isAck bool) Fault {
+	return None
+}
+
+// OnWriteCiphertext is a no-op without chaos build tag.
+func OnWriteCiphertext(b []byte) {}
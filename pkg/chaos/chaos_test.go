@@ -0,0 +1,82 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build chaos
+
+package chaos
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDropSegments(t *testing.T) {
+	defer Reset()
+	DropSegments(2)
+	if got := OnWriteSegment(false, true); got != None {
+		t.Errorf("acknowledge segment got %v, want None", got)
+	}
+	for i := 0; i < 2; i++ {
+		if got := OnWriteSegment(true, false); got != Drop {
+			t.Errorf("data segment %d got %v, want Drop", i, got)
+		}
+	}
+	if got := OnWriteSegment(true, false); got != None {
+		t.Errorf("data segment after faults got %v, want None", got)
+	}
+}
+
+func TestDelayAcks(t *testing.T) {
+	defer Reset()
+	DelayAcks(1, 50*time.Millisecond)
+	begin := time.Now()
+	OnWriteSegment(false, true)
+	if elapsed := time.Since(begin); elapsed < 50*time.Millisecond {
+		t.Errorf("acknowledge is delayed by %v, want at least 50ms", elapsed)
+	}
+	begin = time.Now()
+	OnWriteSegment(false, true)
+	if elapsed := time.Since(begin); elapsed >= 50*time.Millisecond {
+		t.Errorf("second acknowledge is delayed by %v", elapsed)
+	}
+}
+
+func TestCorruptCiphertexts(t *testing.T) {
+	defer Reset()
+	CorruptCiphertexts(1)
+	b := []byte{1, 2, 3, 4}
+	OnWriteCiphertext(b)
+	if bytes.Equal(b, []byte{1, 2, 3, 4}) {
+		t.Errorf("ciphertext is not corrupted")
+	}
+	b = []byte{1, 2, 3, 4}
+	OnWriteCiphertext(b)
+	if !bytes.Equal(b, []byte{1, 2, 3, 4}) {
+		t.Errorf("second ciphertext is corrupted")
+	}
+}
+
+func TestKillUnderlays(t *testing.T) {
+	defer Reset()
+	KillUnderlays(1)
+	DropSegments(1)
+	if got := OnWriteSegment(true, false); got != Kill {
+		t.Errorf("got %v, want Kill", got)
+	}
+	if got := OnWriteSegment(true, false); got != Drop {
+		t.Errorf("got %v, want Drop", got)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package chaos injects faults into mieru protocol, so tests can
+// reproduce network problems like lost segments, late acknowledges,
+// corrupted packets and broken connections.
+//
+// The faults can only be injected when the binary is built with
+// "chaos" build tag, for example
+//
+//	go test -tags chaos ./...
+//
+// Without the build tag, the fault points are no-op and the control
+// functions are not available.
+package chaos
+
+// Fault is the action to take on a segment that is about to be sent.
+type Fault int
+
+const (
+	// None sends the segment as usual.
+	None Fault = iota
+
+	// Drop doesn't send the segment, as if it is lost in the network.
+	Drop
+
+	// Kill closes the underlay instead of sending the segment.
+	Kill
+)
@@ -67,6 +67,16 @@ type BlockCipher interface {
 	// This method is not supported by stateful BlockCipher.
 	DecryptWithNonce(ciphertext, nonce []byte) ([]byte, error)
 
+	// DecryptTo is the same as Decrypt, but appends the plaintext to dst
+	// and returns the updated slice. dst must not overlap ciphertext.
+	DecryptTo(dst, ciphertext []byte) ([]byte, error)
+
+	// DecryptWithNonceTo is the same as DecryptWithNonce, but appends
+	// the plaintext to dst and returns the updated slice.
+	// dst must not overlap ciphertext.
+	// This method is not supported by stateful BlockCipher.
+	DecryptWithNonceTo(dst, ciphertext, nonce []byte) ([]byte, error)
+
 	// NonceSize returns the size of the nonce that must be passed to Seal
 	// and Open.
 	NonceSize() int
@@ -95,6 +105,9 @@ type BlockCipher interface {
 	// and Decrypt() in any sequence.
 	IsStateless() bool
 
+	// Algorithm returns the AEAD algorithm of the block cipher.
+	Algorithm() AEADType
+
 	// BlockContext returns a copy of BlockContext.
 	BlockContext() BlockContext
 
@@ -105,6 +118,10 @@ type BlockCipher interface {
 // BlockContext contains optional context associated to a cipher block.
 type BlockContext struct {
 	UserName string
+
+	// NextPassword is true if the block is derived from the
+	// next password of the user. It is only used by server.
+	NextPassword bool
 }
 
 // HashPassword generates a hashed password from
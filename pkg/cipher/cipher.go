@@ -38,6 +38,19 @@ const (
 	XChaCha20Poly1305
 )
 
+func (t AEADType) String() string {
+	switch t {
+	case AES256GCM:
+		return "AES-256-GCM"
+	case ChaCha20Poly1305:
+		return "ChaCha20-Poly1305"
+	case XChaCha20Poly1305:
+		return "XChaCha20-Poly1305"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 var (
 	_ BlockCipher = &AEADBlockCipher{}
 )
@@ -182,6 +195,10 @@ func (c *AEADBlockCipher) EncryptWithNonce(plaintext, nonce []byte) ([]byte, err
 }
 
 func (c *AEADBlockCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return c.DecryptTo(nil, ciphertext)
+}
+
+func (c *AEADBlockCipher) DecryptTo(dst, ciphertext []byte) ([]byte, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var nonce []byte
@@ -205,7 +222,7 @@ func (c *AEADBlockCipher) Decrypt(ciphertext []byte) ([]byte, error) {
 		ciphertext = ciphertext[c.NonceSize():]
 	}
 
-	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := c.aead.Open(dst, nonce, ciphertext, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cipher.AEAD.Open() failed: %w", err)
 	}
@@ -213,6 +230,10 @@ func (c *AEADBlockCipher) Decrypt(ciphertext []byte) ([]byte, error) {
 }
 
 func (c *AEADBlockCipher) DecryptWithNonce(ciphertext, nonce []byte) ([]byte, error) {
+	return c.DecryptWithNonceTo(nil, ciphertext, nonce)
+}
+
+func (c *AEADBlockCipher) DecryptWithNonceTo(dst, ciphertext, nonce []byte) ([]byte, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if c.enableImplicitNonce {
@@ -221,7 +242,7 @@ func (c *AEADBlockCipher) DecryptWithNonce(ciphertext, nonce []byte) ([]byte, er
 	if len(nonce) != DefaultNonceSize {
 		return nil, fmt.Errorf("want nonce size %d, got %d", DefaultNonceSize, len(nonce))
 	}
-	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := c.aead.Open(dst, nonce, ciphertext, nil)
 	if err != nil {
 		return nil, fmt.Errorf("cipher.AEAD.Open() failed: %w", err)
 	}
@@ -271,6 +292,10 @@ func (c *AEADBlockCipher) IsStateless() bool {
 	return !c.enableImplicitNonce
 }
 
+func (c *AEADBlockCipher) Algorithm() AEADType {
+	return c.aeadType
+}
+
 func (c *AEADBlockCipher) BlockContext() BlockContext {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -98,6 +98,18 @@ func TestAEADBlockCipherEncryptDecrypt(t *testing.T) {
 		if !bytes.Equal(data, plaintext) {
 			t.Errorf("data after decryption is different")
 		}
+
+		dst := make([]byte, 0, size)
+		plaintext, err = cipher.DecryptWithNonceTo(dst, ciphertext, nonce)
+		if err != nil {
+			t.Fatalf("DecryptWithNonceTo() failed: %v", err)
+		}
+		if !bytes.Equal(data, plaintext) {
+			t.Errorf("data after decryption is different")
+		}
+		if size > 0 && &plaintext[0] != &dst[:1][0] {
+			t.Errorf("DecryptWithNonceTo() didn't reuse the storage of dst")
+		}
 	}
 }
 
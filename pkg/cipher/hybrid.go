@@ -0,0 +1,177 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cipher
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// HybridClientShareSize is the size of the key share sent by the client
+	// in a hybrid key exchange. It contains a X25519 public key and
+	// a ML-KEM-768 encapsulation key.
+	HybridClientShareSize = x25519KeySize + 1184
+
+	// HybridServerShareSize is the size of the key share sent by the server
+	// in a hybrid key exchange. It contains a X25519 public key and
+	// a ML-KEM-768 ciphertext.
+	HybridServerShareSize = x25519KeySize + 1088
+
+	x25519KeySize = 32
+
+	// hybridKeyInfo is part of mieru protocol. This value should not be changed.
+	hybridKeyInfo = "mieru hybrid key exchange"
+)
+
+// ErrHybridKeyExchangeNotSupported is returned if this binary is built
+// without ML-KEM.
+var ErrHybridKeyExchangeNotSupported = errors.New("hybrid key exchange is not supported by this binary")
+
+// kemKey is the decapsulation key of ML-KEM-768.
+type kemKey interface {
+	// encapsulationKey returns the public key sent to the peer.
+	encapsulationKey() []byte
+
+	// decapsulate returns the shared key from the ciphertext of the peer.
+	decapsulate(ciphertext []byte) ([]byte, error)
+}
+
+// HybridClientKey is the ephemeral private key of the client in a hybrid
+// key exchange. The shared secret is derived from both a X25519 key
+// agreement and a ML-KEM-768 key encapsulation, so it is not revealed
+// unless both of them are broken.
+type HybridClientKey struct {
+	x25519 *ecdh.PrivateKey
+	kem    kemKey
+	share  []byte
+}
+
+// NewHybridClientKey generates a new ephemeral key of the client.
+func NewHybridClientKey() (*HybridClientKey, error) {
+	kem, err := newKEMKey()
+	if err != nil {
+		return nil, err
+	}
+	x, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("GenerateKey() failed: %w", err)
+	}
+	share := make([]byte, 0, HybridClientShareSize)
+	share = append(share, x.PublicKey().Bytes()...)
+	share = append(share, kem.encapsulationKey()...)
+	return &HybridClientKey{x25519: x, kem: kem, share: share}, nil
+}
+
+// Share returns the key share sent to the server.
+func (k *HybridClientKey) Share() []byte {
+	return k.share
+}
+
+// Finish derives the block ciphers of the session from the key share of
+// the server and the pre-shared key. The client encrypts with send and
+// decrypts with recv.
+func (k *HybridClientKey) Finish(serverShare, psk []byte) (send, recv BlockCipher, err error) {
+	if len(serverShare) != HybridServerShareSize {
+		return nil, nil, fmt.Errorf("server key share size is %d, want %d", len(serverShare), HybridServerShareSize)
+	}
+	peer, err := ecdh.X25519().NewPublicKey(serverShare[:x25519KeySize])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid X25519 public key: %w", err)
+	}
+	ecdhSecret, err := k.x25519.ECDH(peer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ECDH() failed: %w", err)
+	}
+	kemSecret, err := k.kem.decapsulate(serverShare[x25519KeySize:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("ML-KEM decapsulate failed: %w", err)
+	}
+	return hybridBlockCiphers(ecdhSecret, kemSecret, psk, k.share, serverShare, true)
+}
+
+// HybridServerFinish responds to the key share of the client. It returns
+// the key share sent to the client, and the block ciphers of the session.
+// The server encrypts with send and decrypts with recv.
+func HybridServerFinish(clientShare, psk []byte) (serverShare []byte, send, recv BlockCipher, err error) {
+	if len(clientShare) != HybridClientShareSize {
+		return nil, nil, nil, fmt.Errorf("client key share size is %d, want %d", len(clientShare), HybridClientShareSize)
+	}
+	peer, err := ecdh.X25519().NewPublicKey(clientShare[:x25519KeySize])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid X25519 public key: %w", err)
+	}
+	x, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("GenerateKey() failed: %w", err)
+	}
+	ecdhSecret, err := x.ECDH(peer)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("ECDH() failed: %w", err)
+	}
+	kemSecret, ciphertext, err := kemEncapsulate(clientShare[x25519KeySize:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	serverShare = make([]byte, 0, HybridServerShareSize)
+	serverShare = append(serverShare, x.PublicKey().Bytes()...)
+	serverShare = append(serverShare, ciphertext...)
+	send, recv, err = hybridBlockCiphers(ecdhSecret, kemSecret, psk, clientShare, serverShare, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return serverShare, send, recv, nil
+}
+
+// hybridBlockCiphers derives a key for each direction with HKDF.
+// Both shared secrets are the input key material and the pre-shared key
+// is the salt, so the keys are safe if any of them is not revealed.
+// The key shares are bound to the keys.
+func hybridBlockCiphers(ecdhSecret, kemSecret, psk, clientShare, serverShare []byte, isClient bool) (send, recv BlockCipher, err error) {
+	secret := make([]byte, 0, len(kemSecret)+len(ecdhSecret))
+	secret = append(secret, kemSecret...)
+	secret = append(secret, ecdhSecret...)
+	transcript := sha256.New()
+	transcript.Write(clientShare)
+	transcript.Write(serverShare)
+	info := append([]byte(hybridKeyInfo), transcript.Sum(nil)...)
+
+	r := hkdf.New(sha256.New, secret, psk, info)
+	blocks := make([]BlockCipher, 2)
+	for i := range blocks {
+		key := make([]byte, DefaultKeyLen)
+		if _, err := io.ReadFull(r, key); err != nil {
+			return nil, nil, fmt.Errorf("HKDF failed: %w", err)
+		}
+		block, err := newXChaCha20Poly1305BlockCipher(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		block.SetImplicitNonceMode(true)
+		blocks[i] = block
+	}
+	// The first key is used from client to server.
+	if isClient {
+		return blocks[0], blocks[1], nil
+	}
+	return blocks[1], blocks[0], nil
+}
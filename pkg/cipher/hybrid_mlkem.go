@@ -0,0 +1,59 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build go1.24
+
+package cipher
+
+import (
+	"crypto/mlkem"
+	"fmt"
+)
+
+// HybridKeyExchangeSupported is true if this binary supports
+// hybrid key exchange.
+const HybridKeyExchangeSupported = true
+
+// mlkemKey implements kemKey with the standard library.
+type mlkemKey struct {
+	dk *mlkem.DecapsulationKey768
+}
+
+func (k mlkemKey) encapsulationKey() []byte {
+	return k.dk.EncapsulationKey().Bytes()
+}
+
+func (k mlkemKey) decapsulate(ciphertext []byte) ([]byte, error) {
+	return k.dk.Decapsulate(ciphertext)
+}
+
+func newKEMKey() (kemKey, error) {
+	dk, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, fmt.Errorf("mlkem.GenerateKey768() failed: %w", err)
+	}
+	return mlkemKey{dk: dk}, nil
+}
+
+// kemEncapsulate returns a shared key and the ciphertext
+// sent to the owner of the encapsulation key.
+func kemEncapsulate(encapsulationKey []byte) (sharedKey, ciphertext []byte, err error) {
+	ek, err := mlkem.NewEncapsulationKey768(encapsulationKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid ML-KEM encapsulation key: %w", err)
+	}
+	sharedKey, ciphertext = ek.Encapsulate()
+	return sharedKey, ciphertext, nil
+}
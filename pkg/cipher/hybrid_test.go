@@ -0,0 +1,115 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cipher
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHybridKeyExchange(t *testing.T) {
+	if !HybridKeyExchangeSupported {
+		t.Skip("hybrid key exchange is not supported")
+	}
+	psk := HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))
+	clientKey, err := NewHybridClientKey()
+	if err != nil {
+		t.Fatalf("NewHybridClientKey() failed: %v", err)
+	}
+	if len(clientKey.Share()) != HybridClientShareSize {
+		t.Fatalf("client key share size is %d, want %d", len(clientKey.Share()), HybridClientShareSize)
+	}
+	serverShare, serverSend, serverRecv, err := HybridServerFinish(clientKey.Share(), psk)
+	if err != nil {
+		t.Fatalf("HybridServerFinish() failed: %v", err)
+	}
+	if len(serverShare) != HybridServerShareSize {
+		t.Fatalf("server key share size is %d, want %d", len(serverShare), HybridServerShareSize)
+	}
+	clientSend, clientRecv, err := clientKey.Finish(serverShare, psk)
+	if err != nil {
+		t.Fatalf("Finish() failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		plaintext := []byte("client to server")
+		ciphertext, err := clientSend.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt() failed: %v", err)
+		}
+		decrypted, err := serverRecv.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt() failed: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("got %q, want %q", decrypted, plaintext)
+		}
+
+		plaintext = []byte("server to client")
+		ciphertext, err = serverSend.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt() failed: %v", err)
+		}
+		decrypted, err = clientRecv.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt() failed: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("got %q, want %q", decrypted, plaintext)
+		}
+	}
+}
+
+func TestHybridKeyExchangeWrongPSK(t *testing.T) {
+	if !HybridKeyExchangeSupported {
+		t.Skip("hybrid key exchange is not supported")
+	}
+	clientKey, err := NewHybridClientKey()
+	if err != nil {
+		t.Fatalf("NewHybridClientKey() failed: %v", err)
+	}
+	serverShare, _, serverRecv, err := HybridServerFinish(clientKey.Share(), []byte("server"))
+	if err != nil {
+		t.Fatalf("HybridServerFinish() failed: %v", err)
+	}
+	clientSend, _, err := clientKey.Finish(serverShare, []byte("client"))
+	if err != nil {
+		t.Fatalf("Finish() failed: %v", err)
+	}
+	ciphertext, err := clientSend.Encrypt([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	if _, err := serverRecv.Decrypt(ciphertext); err == nil {
+		t.Errorf("Decrypt() succeeded with a different pre-shared key")
+	}
+}
+
+func TestHybridKeyExchangeInvalidShare(t *testing.T) {
+	if !HybridKeyExchangeSupported {
+		t.Skip("hybrid key exchange is not supported")
+	}
+	if _, _, _, err := HybridServerFinish(make([]byte, HybridClientShareSize-1), nil); err == nil {
+		t.Errorf("HybridServerFinish() succeeded with a short key share")
+	}
+	clientKey, err := NewHybridClientKey()
+	if err != nil {
+		t.Fatalf("NewHybridClientKey() failed: %v", err)
+	}
+	if _, _, err := clientKey.Finish(make([]byte, HybridServerShareSize+1), nil); err == nil {
+		t.Errorf("Finish() succeeded with a long key share")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cipher
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// rekeyInfo is the HKDF info to derive the next key of a connection.
+const rekeyInfo = "mieru rekey"
+
+// NextBlockCipher derives a new BlockCipher from the key of the given
+// BlockCipher and the salt. The new BlockCipher uses the same AEAD
+// algorithm, and keeps the implicit nonce and the block context, so the
+// peer can switch to it at the same position of a stream. The key is
+// derived in one direction, so a leaked key doesn't reveal earlier keys.
+func NextBlockCipher(block BlockCipher, salt []byte) (BlockCipher, error) {
+	c, ok := block.(*AEADBlockCipher)
+	if !ok {
+		return nil, fmt.Errorf("%T doesn't support rekey", block)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := make([]byte, len(c.key))
+	if _, err := io.ReadFull(hkdf.New(sha256.New, c.key, salt, []byte(rekeyInfo)), key); err != nil {
+		return nil, fmt.Errorf("HKDF failed: %w", err)
+	}
+	var next *AEADBlockCipher
+	var err error
+	switch c.aeadType {
+	case AES256GCM:
+		next, err = newAESGCMBlockCipher(key)
+	case ChaCha20Poly1305:
+		next, err = newChaCha20Poly1305BlockCipher(key)
+	case XChaCha20Poly1305:
+		next, err = newXChaCha20Poly1305BlockCipher(key)
+	default:
+		return nil, fmt.Errorf("invalid AEAD type %d", c.aeadType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	next.enableImplicitNonce = c.enableImplicitNonce
+	if len(c.implicitNonce) != 0 {
+		next.implicitNonce = make([]byte, len(c.implicitNonce))
+		copy(next.implicitNonce, c.implicitNonce)
+	}
+	next.ctx = c.ctx
+	return next, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cipher
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNextBlockCipher(t *testing.T) {
+	send, err := BlockCipherFromPassword([]byte("kuiranbudong"), false)
+	if err != nil {
+		t.Fatalf("BlockCipherFromPassword() failed: %v", err)
+	}
+	send.SetBlockContext(BlockContext{UserName: "xiaochitang"})
+	recv := send.Clone()
+
+	// Use the implicit nonce before rekey.
+	ciphertext, err := send.Encrypt([]byte("before rekey"))
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	if _, err := recv.Decrypt(ciphertext); err != nil {
+		t.Fatalf("Decrypt() failed: %v", err)
+	}
+
+	salt := []byte("salt")
+	nextSend, err := NextBlockCipher(send, salt)
+	if err != nil {
+		t.Fatalf("NextBlockCipher() failed: %v", err)
+	}
+	nextRecv, err := NextBlockCipher(recv, salt)
+	if err != nil {
+		t.Fatalf("NextBlockCipher() failed: %v", err)
+	}
+	if nextSend.BlockContext().UserName != "xiaochitang" {
+		t.Errorf("block context is not kept")
+	}
+	plaintext := []byte("after rekey")
+	ciphertext, err = nextSend.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	if len(ciphertext) != len(plaintext)+DefaultOverhead {
+		t.Errorf("ciphertext size is %d, want %d without nonce", len(ciphertext), len(plaintext)+DefaultOverhead)
+	}
+	if _, err := recv.Clone().Decrypt(ciphertext); err == nil {
+		t.Errorf("the old key decrypted the data of the new key")
+	}
+	decrypted, err := nextRecv.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("got %q, want %q", decrypted, plaintext)
+	}
+
+	otherRecv, err := NextBlockCipher(recv, []byte("other salt"))
+	if err != nil {
+		t.Fatalf("NextBlockCipher() failed: %v", err)
+	}
+	if _, err := otherRecv.Decrypt(ciphertext); err == nil {
+		t.Errorf("a different salt derived the same key")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cipher
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+var (
+	// Number of iterate decryption that succeeded with the user
+	// remembered from the source address.
+	ServerSourceCacheHit = metrics.RegisterMetric(ServerDecryptionMetricGroupName, "SourceCacheHit", metrics.COUNTER)
+
+	// Number of iterate decryption that can't be resolved by the user
+	// remembered from the source address.
+	ServerSourceCacheMiss = metrics.RegisterMetric(ServerDecryptionMetricGroupName, "SourceCacheMiss", metrics.COUNTER)
+)
+
+// SourceCache remembers the user whose cipher block last decrypted data
+// from a source IP address. A returning client is tried with the blocks
+// of that user first, so the server doesn't need to try every candidate.
+//
+// The ciphertext doesn't carry any plain text that identifies the user,
+// so a cache miss still falls back to iterate all the candidates.
+type SourceCache struct {
+	mu       sync.Mutex
+	capacity int
+
+	// lru stores sourceEntry from the most recently seen to
+	// the least recently seen.
+	lru *list.List
+
+	// entries maps the source key to the element in lru.
+	entries map[string]*list.Element
+}
+
+// sourceEntry is an entry of SourceCache.
+type sourceEntry struct {
+	key      string
+	userName string
+}
+
+// NewSourceCache creates a new SourceCache that stores at most
+// capacity source addresses.
+func NewSourceCache(capacity int) *SourceCache {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("invalid source cache capacity %d", capacity))
+	}
+	return &SourceCache{
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the user name remembered from the source address.
+func (c *SourceCache) Get(source net.Addr) (string, bool) {
+	if source == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[sourceKey(source)]
+	if !ok {
+		return "", false
+	}
+	return e.Value.(*sourceEntry).userName, true
+}
+
+// Put remembers the user name of the source address, and marks
+// the source address as the most recently seen.
+func (c *SourceCache) Put(source net.Addr, userName string) {
+	if source == nil || userName == "" {
+		return
+	}
+	key := sourceKey(source)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*sourceEntry).userName = userName
+		c.lru.MoveToFront(e)
+		return
+	}
+	if c.lru.Len() >= c.capacity {
+		// Remove the source address that is not seen for the longest time.
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*sourceEntry).key)
+	}
+	c.entries[key] = c.lru.PushFront(&sourceEntry{key: key, userName: userName})
+}
+
+// Len returns the number of source addresses in the cache.
+func (c *SourceCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// SelectDecrypt is similar to the SelectDecrypt function, but tries
+// the blocks of the user remembered from the source address first.
+// The user of the block that decrypts the data is remembered, and
+// the source address becomes the most recently seen.
+func (c *SourceCache) SelectDecrypt(source net.Addr, data []byte, blocks []BlockCipher) (BlockCipher, []byte, error) {
+	userName, ok := c.Get(source)
+	if ok {
+		for _, block := range blocks {
+			if block.BlockContext().UserName != userName {
+				continue
+			}
+			decrypted, err := block.Decrypt(data)
+			if err != nil {
+				continue
+			}
+			ServerSourceCacheHit.Add(1)
+			c.Put(source, userName)
+			return block, decrypted, nil
+		}
+	}
+	ServerSourceCacheMiss.Add(1)
+	for _, block := range blocks {
+		if ok && block.BlockContext().UserName == userName {
+			// Already tried.
+			continue
+		}
+		decrypted, err := block.Decrypt(data)
+		if err != nil {
+			continue
+		}
+		c.Put(source, block.BlockContext().UserName)
+		return block, decrypted, nil
+	}
+	return nil, nil, fmt.Errorf("unable to decrypt from supplied %d cipher blocks", len(blocks))
+}
+
+// sourceKey returns the IP address of the source.
+// A returning client usually connects from a different port.
+func sourceKey(source net.Addr) string {
+	switch addr := source.(type) {
+	case *net.TCPAddr:
+		return addr.IP.String()
+	case *net.UDPAddr:
+		return addr.IP.String()
+	}
+	host, _, err := net.SplitHostPort(source.String())
+	if err != nil {
+		return source.String()
+	}
+	return host
+}
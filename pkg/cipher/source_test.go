@@ -0,0 +1,143 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cipher
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestSourceCacheSelectDecrypt(t *testing.T) {
+	var blocks []BlockCipher
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("user%d", i)
+		userBlocks, err := BlockCipherListFromPassword([]byte(name), true)
+		if err != nil {
+			t.Fatalf("BlockCipherListFromPassword() failed: %v", err)
+		}
+		for _, block := range userBlocks {
+			block.SetBlockContext(BlockContext{UserName: name})
+		}
+		blocks = append(blocks, userBlocks...)
+	}
+	plaintext := []byte("source cache")
+	ciphertext, err := blocks[10].Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+
+	c := NewSourceCache(16)
+	source := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 10000}
+	missBefore := ServerSourceCacheMiss.Load()
+	block, decrypted, err := c.SelectDecrypt(source, ciphertext, blocks)
+	if err != nil {
+		t.Fatalf("SelectDecrypt() failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("decrypted %q, want %q", decrypted, plaintext)
+	}
+	if block.BlockContext().UserName != "user3" {
+		t.Errorf("got user %q, want %q", block.BlockContext().UserName, "user3")
+	}
+	if ServerSourceCacheMiss.Load() != missBefore+1 {
+		t.Errorf("first decryption is not a cache miss")
+	}
+
+	// The same client returns from a different port.
+	returning := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 20000}
+	if userName, ok := c.Get(returning); !ok || userName != "user3" {
+		t.Errorf("Get() = %q, %v, want %q, true", userName, ok, "user3")
+	}
+	hitBefore := ServerSourceCacheHit.Load()
+	if _, _, err := c.SelectDecrypt(returning, ciphertext, blocks); err != nil {
+		t.Fatalf("SelectDecrypt() failed: %v", err)
+	}
+	if ServerSourceCacheHit.Load() != hitBefore+1 {
+		t.Errorf("returning client is not a cache hit")
+	}
+
+	// A different client of the same IP address falls back to iterate all blocks.
+	ciphertext, err = blocks[1].Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	block, _, err = c.SelectDecrypt(returning, ciphertext, blocks)
+	if err != nil {
+		t.Fatalf("SelectDecrypt() failed: %v", err)
+	}
+	if block.BlockContext().UserName != "user0" {
+		t.Errorf("got user %q, want %q", block.BlockContext().UserName, "user0")
+	}
+	if userName, _ := c.Get(source); userName != "user0" {
+		t.Errorf("Get() = %q, want %q", userName, "user0")
+	}
+
+	if _, _, err := c.SelectDecrypt(source, []byte("not encrypted by any block"), blocks); err == nil {
+		t.Errorf("SelectDecrypt() succeeded with invalid data")
+	}
+}
+
+func TestSourceCacheEviction(t *testing.T) {
+	c := NewSourceCache(4)
+	for i := 0; i < 10; i++ {
+		c.Put(&net.UDPAddr{IP: net.IPv4(192, 0, 2, byte(i)), Port: 443}, "user")
+	}
+	if c.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", c.Len())
+	}
+	if _, ok := c.Get(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 9), Port: 443}); !ok {
+		t.Errorf("the newest source address is evicted")
+	}
+}
+
+func TestSourceCacheHitRefreshesRecency(t *testing.T) {
+	blocks, err := BlockCipherListFromPassword([]byte("user"), true)
+	if err != nil {
+		t.Fatalf("BlockCipherListFromPassword() failed: %v", err)
+	}
+	for _, block := range blocks {
+		block.SetBlockContext(BlockContext{UserName: "user"})
+	}
+	ciphertext, err := blocks[0].Encrypt([]byte("source cache"))
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+
+	c := NewSourceCache(2)
+	first := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 443}
+	second := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 443}
+	c.Put(first, "user")
+	c.Put(second, "user")
+
+	// The first source address is seen again, so the second one
+	// is evicted when a new source address is added.
+	hitBefore := ServerSourceCacheHit.Load()
+	if _, _, err := c.SelectDecrypt(first, ciphertext, blocks); err != nil {
+		t.Fatalf("SelectDecrypt() failed: %v", err)
+	}
+	if ServerSourceCacheHit.Load() != hitBefore+1 {
+		t.Errorf("returning client is not a cache hit")
+	}
+	c.Put(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 3), Port: 443}, "user")
+	if _, ok := c.Get(first); !ok {
+		t.Errorf("the recently seen source address is evicted")
+	}
+	if _, ok := c.Get(second); ok {
+		t.Errorf("the least recently seen source address is not evicted")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/i18n"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// checkTimeout is the maximum time to wait for all the proxy servers
+// to respond to the health check.
+const checkTimeout = 10 * time.Second
+
+// clientCheckFunc connects to every proxy server endpoint of the active
+// profile and prints whether each of them responds. It doesn't need the
+// mieru client to be running.
+var clientCheckFunc = func(s []string) error {
+	config, err := appctl.LoadClientConfig()
+	if err != nil {
+		return withExitCode(ExitConfigInvalid, i18n.Errorf(stderror.GetClientConfigFailedErr, err))
+	}
+	activeProfile, err := appctl.GetActiveProfileFromConfig(config, config.GetActiveProfile())
+	if err != nil {
+		return withExitCode(ExitConfigInvalid, i18n.Errorf(stderror.ClientGetActiveProfileFailedErr, err))
+	}
+	resolver, err := appctl.NewDNSResolver(config.GetDns())
+	if err != nil {
+		return withExitCode(ExitConfigInvalid, i18n.Errorf(stderror.CreateDNSResolverFailedErr, err))
+	}
+	mux, err := newClientMux(activeProfile, resolver)
+	if err != nil {
+		return withExitCode(ExitServerUnreachable, err)
+	}
+	defer mux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancelFunc()
+	results, err := mux.CheckEndpoints(ctx, socks5.ProbeProxyServer)
+	if err != nil {
+		return withExitCode(ExitServerUnreachable, err)
+	}
+	rows := [][]string{{"Server", "Transport", "Reachable", "RTT", "Error"}}
+	reachable := 0
+	for _, r := range results {
+		rtt := "-"
+		errMsg := ""
+		if r.Reachable {
+			reachable++
+			rtt = r.RTT.Round(time.Millisecond).String()
+		} else if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+		rows = append(rows, []string{
+			r.Endpoint.RemoteAddr().String(),
+			r.Transport,
+			fmt.Sprintf("%t", r.Reachable),
+			rtt,
+			errMsg,
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	if reachable == 0 {
+		return exitErrorf(ExitServerUnreachable, "none of the %d proxy server endpoints is reachable", len(results))
+	}
+	return nil
+}
@@ -23,8 +23,10 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime/pprof"
 	"strconv"
 	"strings"
@@ -33,17 +35,21 @@ import (
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
 	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
 	"github.com/enfein/mieru/v3/pkg/appctl"
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlgrpc"
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
 	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+	"github.com/enfein/mieru/v3/pkg/crash"
+	"github.com/enfein/mieru/v3/pkg/i18n"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
 	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/socks5"
 	"github.com/enfein/mieru/v3/pkg/stderror"
+	"github.com/enfein/mieru/v3/pkg/tracing"
 	"github.com/enfein/mieru/v3/pkg/version/updater"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
@@ -51,10 +57,12 @@ import (
 
 // RegisterClientCommands registers all the client side CLI commands.
 func RegisterClientCommands() {
+	appctl.SetClientConfigKeyProvider(&promptKeyProvider{})
+
 	RegisterCallback(
 		[]string{"", "help"},
 		func(s []string) error {
-			return unexpectedArgsError(s, 2)
+			return nil
 		},
 		clientHelpFunc,
 	)
@@ -101,6 +109,33 @@ func RegisterClientCommands() {
 		},
 		clientTestFunc,
 	)
+	RegisterCallback(
+		[]string{"", "check"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 2)
+		},
+		clientCheckFunc,
+	)
+	RegisterCallback(
+		[]string{"", "monitor"},
+		func(s []string) error {
+			if len(s) > 3 {
+				return fmt.Errorf("usage: mieru monitor [OUTPUT_FILE]. More than 1 output file is provided")
+			}
+			if len(s) == 3 {
+				return validateMonitorOutput(s[2])
+			}
+			return nil
+		},
+		clientMonitorFunc,
+	)
+	RegisterCallback(
+		[]string{"", "setup"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 2)
+		},
+		clientSetupFunc,
+	)
 	RegisterCallback(
 		[]string{"", "apply", "config"},
 		func(s []string) error {
@@ -114,10 +149,33 @@ func RegisterClientCommands() {
 		clientApplyConfigFunc,
 	)
 	RegisterCallback(
-		[]string{"", "describe", "config"},
+		[]string{"", "apply", "preset"},
+		func(s []string) error {
+			if len(s) < 4 {
+				return fmt.Errorf("usage: mieru apply preset <PRESET> [PROFILE_NAME]. No preset is provided")
+			} else if len(s) > 5 {
+				return fmt.Errorf("usage: mieru apply preset <PRESET> [PROFILE_NAME]. More than 1 profile is provided")
+			}
+			if _, err := appctl.ParsePreset(s[3]); err != nil {
+				return fmt.Errorf("usage: mieru apply preset <PRESET> [PROFILE_NAME]. %w", err)
+			}
+			return nil
+		},
+		clientApplyPresetFunc,
+	)
+	RegisterCallback(
+		[]string{"", "reload", "dns"},
 		func(s []string) error {
 			return unexpectedArgsError(s, 3)
 		},
+		clientReloadDNSFunc,
+	)
+	RegisterCallback(
+		[]string{"", "describe", "config"},
+		func(s []string) error {
+			_, err := configFormat(s, "mieru")
+			return err
+		},
 		clientDescribeConfigFunc,
 	)
 	RegisterCallback(
@@ -139,6 +197,20 @@ func RegisterClientCommands() {
 		},
 		clientExportConfigFunc,
 	)
+	RegisterCallback(
+		[]string{"", "encrypt", "config"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		clientEncryptConfigFunc,
+	)
+	RegisterCallback(
+		[]string{"", "decrypt", "config"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		clientDecryptConfigFunc,
+	)
 	RegisterCallback(
 		[]string{"", "delete", "profile"},
 		func(s []string) error {
@@ -179,6 +251,18 @@ func RegisterClientCommands() {
 		},
 		clientCheckUpdateFunc,
 	)
+	RegisterCallback(
+		[]string{"", "bugreport"},
+		func(s []string) error {
+			if len(s) < 3 {
+				return fmt.Errorf("usage: mieru bugreport <FILE>. no file save path is provided")
+			} else if len(s) > 3 {
+				return fmt.Errorf("usage: mieru bugreport <FILE>. more than 1 file save path is provided")
+			}
+			return nil
+		},
+		clientBugReportFunc,
+	)
 	RegisterCallback(
 		[]string{"", "get", "metrics"},
 		func(s []string) error {
@@ -193,6 +277,36 @@ func RegisterClientCommands() {
 		},
 		clientGetConnectionsFunc,
 	)
+	RegisterCallback(
+		[]string{"", "get", "destinations"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		clientGetDestinationsFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "route-stats"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		clientGetRouteStatsFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "session-state"},
+		func(s []string) error {
+			_, err := sessionStateRequest(s, "mieru")
+			return err
+		},
+		clientGetSessionStateFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "logs"},
+		func(s []string) error {
+			_, err := logRequest(s, "mieru")
+			return err
+		},
+		clientGetLogsFunc,
+	)
 	RegisterCallback(
 		[]string{"", "get", "thread-dump"},
 		func(s []string) error {
@@ -238,6 +352,9 @@ func RegisterClientCommands() {
 		},
 		clientStopCPUProfileFunc,
 	)
+	registerCompletionCommands()
+	RegisterCompletion([]string{"", "apply", "preset"}, appctl.PresetNames)
+	RegisterCompletion([]string{"", "delete", "profile"}, clientProfileNames)
 }
 
 var clientHelpFunc = func(s []string) error {
@@ -245,8 +362,8 @@ var clientHelpFunc = func(s []string) error {
 		appName: "mieru",
 		entries: []helpCmdEntry{
 			{
-				cmd:  "help",
-				help: "Show mieru client help.",
+				cmd:  "help [COMMAND]",
+				help: "Show mieru client help. If a command is provided, only show the help of matching commands.",
 			},
 			{
 				cmd:  "start",
@@ -264,13 +381,33 @@ var clientHelpFunc = func(s []string) error {
 				cmd:  "test [URL]",
 				help: "Test mieru client connection to the Internet via proxy server.",
 			},
+			{
+				cmd:  "check",
+				help: "Connect to every proxy server in the active profile and show whether it responds. mieru client doesn't need to be running.",
+			},
+			{
+				cmd:  "monitor [OUTPUT_FILE]",
+				help: "Measure mieru client connection to the proxy server continuously and print a summary every minute. If a CSV or JSON file is provided, the measurements are appended to the file.",
+			},
+			{
+				cmd:  "setup",
+				help: "Create a client configuration profile interactively.",
+			},
 			{
 				cmd:  "apply config <FILE>",
-				help: "Apply client configuration from JSON file.",
+				help: "Apply client configuration from JSON, YAML or TOML file.",
+			},
+			{
+				cmd:  "apply preset <PRESET> [PROFILE_NAME]",
+				help: "Use a preset of settings in a client configuration profile. Valid presets are balanced, low-latency, low-bandwidth and paranoid. If profile name is not provided, the active profile is used.",
+			},
+			{
+				cmd:  "reload dns",
+				help: "Apply DNS settings in client configuration to the running mieru client.",
 			},
 			{
-				cmd:  "describe config",
-				help: "Show current client configuration.",
+				cmd:  describeConfigUsage,
+				help: "Show current client configuration. The format can be json, yaml or toml, and the default is json.",
 			},
 			{
 				cmd:  "import config <URL>",
@@ -280,6 +417,14 @@ var clientHelpFunc = func(s []string) error {
 				cmd:  "export config",
 				help: "Export client configuration as URL.",
 			},
+			{
+				cmd:  "encrypt config",
+				help: "Encrypt the stored client configuration with a passphrase. The passphrase is read from MIERU_CONFIG_PASSPHRASE environment variable, or the file of MIERU_CONFIG_PASSPHRASE_FILE environment variable. Otherwise, it is typed in the terminal.",
+			},
+			{
+				cmd:  "decrypt config",
+				help: "Store the client configuration without encryption.",
+			},
 			{
 				cmd:  "delete profile <PROFILE_NAME>",
 				help: "Delete an inactive client configuration profile.",
@@ -300,6 +445,18 @@ var clientHelpFunc = func(s []string) error {
 				cmd:  "get connections",
 				help: "Get mieru client connections.",
 			},
+			{
+				cmd:  logsUsage,
+				help: "Get the recent log of mieru client. With --follow, keep printing the new log. With --level, only print the log at the level or more severe.",
+			},
+			{
+				cmd:  "get destinations",
+				help: "Get the destinations that transfer the most bytes through mieru client.",
+			},
+			{
+				cmd:  "get route-stats",
+				help: "Get mieru client auto route statistics.",
+			},
 			{
 				cmd:  "version",
 				help: "Show mieru client version.",
@@ -308,12 +465,24 @@ var clientHelpFunc = func(s []string) error {
 				cmd:  "check update",
 				help: "Check mieru client update.",
 			},
+			{
+				cmd:  "bugreport <ZIP_FILE>",
+				help: "Package the crash reports of mieru client into the zip file.",
+			},
+			{
+				cmd:  "completion <SHELL>",
+				help: "Print shell completion script. Supported shells are bash, zsh and fish.",
+			},
 		},
 		advanced: []helpCmdEntry{
 			{
 				cmd:  "run",
 				help: "Run mieru client in foreground.",
 			},
+			{
+				cmd:  "get session-state [SESSION_ID]",
+				help: "Get the internal state of mieru client sessions for debugging. If the session ID is not provided, all the sessions are dumped.",
+			},
 			{
 				cmd:  "get thread-dump",
 				help: "Get mieru client thread dump.",
@@ -336,8 +505,7 @@ var clientHelpFunc = func(s []string) error {
 			},
 		},
 	}
-	helpFmt.print()
-	return nil
+	return helpFmt.print(s[2:])
 }
 
 var clientStartFunc = func(s []string) error {
@@ -345,20 +513,20 @@ var clientStartFunc = func(s []string) error {
 	config, err := appctl.LoadClientConfig()
 	if err != nil {
 		if err == stderror.ErrFileNotExist {
-			return fmt.Errorf(stderror.ClientConfigNotExist)
+			return exitErrorf(ExitConfigInvalid, stderror.ClientConfigNotExist)
 		} else {
-			return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+			return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 		}
 	}
 	if err = appctl.ValidateFullClientConfig(config); err != nil {
-		return fmt.Errorf(stderror.ValidateFullClientConfigFailedErr, err)
+		return exitErrorf(ExitConfigInvalid, stderror.ValidateFullClientConfigFailedErr, err)
 	}
 
 	if err = appctl.IsClientDaemonRunning(context.Background()); err == nil {
 		if config.GetSocks5ListenLAN() {
-			log.Infof("mieru client is running, listening to socks5://0.0.0.0:%d", config.GetSocks5Port())
+			log.Infof(i18n.T("mieru client is running, listening to socks5://0.0.0.0:%d"), config.GetSocks5Port())
 		} else {
-			log.Infof("mieru client is running, listening to socks5://127.0.0.1:%d", config.GetSocks5Port())
+			log.Infof(i18n.T("mieru client is running, listening to socks5://127.0.0.1:%d"), config.GetSocks5Port())
 		}
 		return nil
 	}
@@ -370,7 +538,7 @@ var clientStartFunc = func(s []string) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf(stderror.StartClientFailedErr, err)
+		return i18n.Errorf(stderror.StartClientFailedErr, err)
 	}
 
 	// Wait until client daemon is running.
@@ -380,9 +548,9 @@ var clientStartFunc = func(s []string) error {
 		lastErr = appctl.IsClientDaemonRunning(context.Background())
 		if lastErr == nil {
 			if config.GetSocks5ListenLAN() {
-				log.Infof("mieru client is started, listening to socks5://0.0.0.0:%d", config.GetSocks5Port())
+				log.Infof(i18n.T("mieru client is started, listening to socks5://0.0.0.0:%d"), config.GetSocks5Port())
 			} else {
-				log.Infof("mieru client is started, listening to socks5://127.0.0.1:%d", config.GetSocks5Port())
+				log.Infof(i18n.T("mieru client is started, listening to socks5://127.0.0.1:%d"), config.GetSocks5Port())
 			}
 
 			if should, _ := clientShouldCheckUpdate(); should {
@@ -396,51 +564,65 @@ var clientStartFunc = func(s []string) error {
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
-	return fmt.Errorf(stderror.ClientNotRunningErr, lastErr)
+	return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunningErr, lastErr)
 }
 
 var clientRunFunc = func(s []string) error {
 	log.SetFormatter(&log.DaemonFormatter{})
 	appctl.SetAppStatus(appctlpb.AppStatus_STARTING)
+	appctl.EnableConfigMemoryFallback()
 
 	logFile, err := log.NewClientLogFile()
 	if err == nil {
-		log.SetOutput(logFile)
+		log.SetOutput(log.NewFallbackWriter(logFile))
 		if err = log.RemoveOldClientLogFiles(); err != nil {
 			log.Errorf("remove old client log files failed: %v", err)
 		}
 	} else {
 		log.Infof("log to stdout due to the following reason: %v", err)
+		log.ReportStorageFailure(log.StorageLog, err)
 	}
 
 	// Load and verify client config.
 	config, err := appctl.LoadClientConfig()
 	if err != nil {
 		if err == stderror.ErrFileNotExist {
-			return fmt.Errorf(stderror.ClientConfigNotExist)
+			return exitErrorf(ExitConfigInvalid, stderror.ClientConfigNotExist)
 		} else {
-			return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+			return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 		}
 	}
 	if proto.Equal(config, &appctlpb.ClientConfig{}) {
-		return fmt.Errorf(stderror.ClientConfigIsEmpty)
+		return exitErrorf(ExitConfigInvalid, stderror.ClientConfigIsEmpty)
 	}
 	if err = appctl.ValidateFullClientConfig(config); err != nil {
-		return fmt.Errorf(stderror.ValidateFullClientConfigFailedErr, err)
+		return exitErrorf(ExitConfigInvalid, stderror.ValidateFullClientConfigFailedErr, err)
 	}
 
-	// Set logging level based on client config.
+	// Set logging level and format based on client config.
 	loggingLevel := config.GetLoggingLevel().String()
 	if loggingLevel != appctlpb.LoggingLevel_DEFAULT.String() {
 		log.SetLevel(loggingLevel)
 	}
+	log.SetFormatter(appctl.LogFormatter(config.GetLogFormat(), false))
+
+	// Save crash reports if a proxy connection panics.
+	if dir, err := clientCrashReportDir(); err == nil {
+		crash.SetReportDir(dir)
+	}
+	crash.SetConfigHash(crash.HashConfig(config))
 
 	// Disable server side metrics.
 	if serverDecryptionMetricGroup := metrics.GetMetricGroupByName(cipher.ServerDecryptionMetricGroupName); serverDecryptionMetricGroup != nil {
 		serverDecryptionMetricGroup.DisableLogging()
 	}
 
-	resolver := &net.Resolver{}
+	dnsResolver, err := appctl.NewDNSResolver(config.GetDns())
+	if err != nil {
+		return exitErrorf(ExitConfigInvalid, stderror.CreateDNSResolverFailedErr, err)
+	}
+	resolver := common.NewSwappableResolver(dnsResolver)
+	appctl.SetClientResolverRef(resolver)
 
 	var wg sync.WaitGroup
 
@@ -477,81 +659,17 @@ var clientRunFunc = func(s []string) error {
 	}
 
 	// Collect remote proxy addresses and password.
-	mux := protocol.NewMux(true)
-	appctl.SetClientMuxRef(mux)
-	activeProfile, err := appctl.GetActiveProfileFromConfig(config, config.GetActiveProfile())
+	// The profile can be selected by a profile schedule.
+	scheduledState := appctl.ScheduledStateAt(config, time.Now())
+	activeProfile, err := appctl.GetActiveProfileFromConfig(config, scheduledState.ProfileName)
 	if err != nil {
-		return fmt.Errorf(stderror.ClientGetActiveProfileFailedErr, err)
-	}
-	user := activeProfile.GetUser()
-	var hashedPassword []byte
-	if user.GetHashedPassword() != "" {
-		hashedPassword, err = hex.DecodeString(user.GetHashedPassword())
-		if err != nil {
-			return fmt.Errorf(stderror.DecodeHashedPasswordFailedErr, err)
-		}
-	} else {
-		hashedPassword = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
-	}
-	mux = mux.SetClientUserNamePassword(user.GetName(), hashedPassword)
-
-	multiplexFactor := 1
-	switch activeProfile.GetMultiplexing().GetLevel() {
-	case appctlpb.MultiplexingLevel_MULTIPLEXING_OFF:
-		multiplexFactor = 0
-	case appctlpb.MultiplexingLevel_MULTIPLEXING_LOW:
-		multiplexFactor = 1
-	case appctlpb.MultiplexingLevel_MULTIPLEXING_MIDDLE:
-		multiplexFactor = 2
-	case appctlpb.MultiplexingLevel_MULTIPLEXING_HIGH:
-		multiplexFactor = 3
+		return i18n.Errorf(stderror.ClientGetActiveProfileFailedErr, err)
 	}
-	mux = mux.SetClientMultiplexFactor(multiplexFactor)
-
-	mtu := common.DefaultMTU
-	if activeProfile.GetMtu() != 0 {
-		mtu = int(activeProfile.GetMtu())
-	}
-	endpoints := make([]protocol.UnderlayProperties, 0)
-	for _, serverInfo := range activeProfile.GetServers() {
-		var proxyHost string
-		var proxyIP net.IP
-		if serverInfo.GetDomainName() != "" {
-			proxyHost = serverInfo.GetDomainName()
-			proxyIPs, err := resolver.LookupIP(context.Background(), "ip", proxyHost)
-			if err != nil {
-				return fmt.Errorf(stderror.LookupIPFailedErr, err)
-			}
-			if len(proxyIPs) == 0 {
-				return fmt.Errorf(stderror.IPAddressNotFound, proxyHost)
-			}
-			proxyIP = proxyIPs[0]
-		} else {
-			proxyHost = serverInfo.GetIpAddress()
-			proxyIP = net.ParseIP(proxyHost)
-			if proxyIP == nil {
-				return fmt.Errorf(stderror.ParseIPFailed)
-			}
-		}
-		portBindings, err := appctl.FlatPortBindings(serverInfo.GetPortBindings())
-		if err != nil {
-			return fmt.Errorf(stderror.InvalidPortBindingsErr, err)
-		}
-		for _, bindingInfo := range portBindings {
-			proxyPort := bindingInfo.GetPort()
-			switch bindingInfo.GetProtocol() {
-			case appctlpb.TransportProtocol_TCP:
-				endpoint := protocol.NewUnderlayProperties(mtu, common.StreamTransport, nil, &net.TCPAddr{IP: proxyIP, Port: int(proxyPort)})
-				endpoints = append(endpoints, endpoint)
-			case appctlpb.TransportProtocol_UDP:
-				endpoint := protocol.NewUnderlayProperties(mtu, common.PacketTransport, nil, &net.UDPAddr{IP: proxyIP, Port: int(proxyPort)})
-				endpoints = append(endpoints, endpoint)
-			default:
-				return fmt.Errorf(stderror.InvalidTransportProtocol)
-			}
-		}
+	mux, err := newClientMux(activeProfile, resolver)
+	if err != nil {
+		return err
 	}
-	mux.SetEndpoints(endpoints)
+	appctl.SetClientMuxRef(mux)
 
 	// Create the local socks5 server.
 	var socks5IngressCredentials []socks5.Credential
@@ -570,12 +688,28 @@ var clientRunFunc = func(s []string) error {
 		ProxyMux:         mux,
 		Resolver:         resolver,
 		HandshakeTimeout: 10 * time.Second,
+		TransferCap:      appctl.TransferCap(config.GetTransferCap()),
+		Hosts:            appctl.ClientHosts(activeProfile),
+		AutoRoute:        appctl.AutoRoute(config.GetAutoRoute()),
+	}
+	if config.GetDestinationStats() {
+		socks5Config.DestinationStats = socks5.NewDestinationStats()
+	}
+	tracer, err := appctl.Tracer(config.GetTracing(), tracing.SpanKindClient)
+	if err != nil {
+		return exitErrorf(ExitConfigInvalid, stderror.CreateTracerFailedErr, err)
 	}
+	socks5Config.Tracer = tracer
 	socks5Server, err := socks5.New(socks5Config)
 	if err != nil {
-		return fmt.Errorf(stderror.CreateSocks5ServerFailedErr, err)
+		return i18n.Errorf(stderror.CreateSocks5ServerFailedErr, err)
 	}
 	appctl.SetClientSocks5ServerRef(socks5Server)
+	socks5Server.SetProxyDisabled(scheduledState.ProxyDisabled)
+	appctl.SetClientScheduledState(scheduledState)
+	if len(config.GetProfileSchedules()) > 0 {
+		go runProfileScheduler(scheduledState, mux, resolver, socks5Server)
+	}
 
 	// Run the local socks5 server in the background.
 	var socks5Addr string
@@ -631,12 +765,94 @@ var clientRunFunc = func(s []string) error {
 		}(socks5Addr)
 	}
 
+	// If debug HTTP server is enabled, run it in the background.
+	if config.GetDebugHttpPort() != 0 {
+		debugServer := metrics.NewDebugHTTPServer(common.MaybeDecorateIPv6(common.LocalIPAddr()) + ":" + strconv.Itoa(int(config.GetDebugHttpPort())))
+		go func() {
+			log.Infof("mieru client debug HTTP server is running")
+			if err := debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("run debug HTTP server failed: %v", err)
+			}
+		}()
+	}
+
+	// If management HTTP server is enabled, run it in the background.
+	if config.GetManagementHttpPort() != 0 {
+		token, err := appctl.ClientManagementToken()
+		if err != nil {
+			return i18n.Errorf(stderror.GetManagementTokenFailedErr, err)
+		}
+		managementServer := appctl.NewClientManagementHTTPServer(common.MaybeDecorateIPv6(common.LocalIPAddr())+":"+strconv.Itoa(int(config.GetManagementHttpPort())), token)
+		go func() {
+			log.Infof("mieru client management HTTP server is running")
+			if err := managementServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("run management HTTP server failed: %v", err)
+			}
+		}()
+	}
+
 	<-appctl.ClientSocks5ServerStarted
+
+	// Run static port forwards and reverse forwards in the background.
+	// The forwarded traffic goes through the local socks5 server.
+	if len(config.GetPortForwards()) > 0 || len(config.GetReverseForwards()) > 0 {
+		socks5URI := "socks5://"
+		if len(config.GetSocks5Authentication()) > 0 {
+			auth := config.GetSocks5Authentication()[0]
+			socks5URI += url.UserPassword(auth.GetUser(), auth.GetPassword()).String() + "@"
+		}
+		socks5URI += socks5Addr + "?timeout=10s"
+		for _, forward := range config.GetPortForwards() {
+			var listenAddr string
+			if forward.GetListenLAN() {
+				listenAddr = common.MaybeDecorateIPv6(common.AllIPAddr()) + ":" + strconv.Itoa(int(forward.GetLocalPort()))
+			} else {
+				listenAddr = common.MaybeDecorateIPv6(common.LocalIPAddr()) + ":" + strconv.Itoa(int(forward.GetLocalPort()))
+			}
+			forwarder := &socks5.PortForwarder{
+				Network:    strings.ToLower(forward.GetProtocol().String()),
+				ListenAddr: listenAddr,
+				RemoteAddr: forward.GetRemoteAddress(),
+				ProxyURI:   socks5URI,
+			}
+			appctl.AddClientForwarderRef(forwarder)
+			wg.Add(1)
+			go func(forwarder *socks5.PortForwarder) {
+				log.Infof("mieru client port forward %s %s -> %s is running", forwarder.Network, forwarder.ListenAddr, forwarder.RemoteAddr)
+				if err := forwarder.ListenAndServe(); err != nil {
+					log.Fatalf("run port forward %s %s -> %s failed: %v", forwarder.Network, forwarder.ListenAddr, forwarder.RemoteAddr, err)
+				}
+				log.Infof("mieru client port forward %s %s -> %s is stopped", forwarder.Network, forwarder.ListenAddr, forwarder.RemoteAddr)
+				wg.Done()
+			}(forwarder)
+		}
+		for _, forward := range config.GetReverseForwards() {
+			forwarder := &socks5.ReverseForwarder{
+				RemotePort: int(forward.GetRemotePort()),
+				LocalAddr:  forward.GetLocalAddress(),
+				ProxyURI:   socks5URI,
+			}
+			appctl.AddClientForwarderRef(forwarder)
+			wg.Add(1)
+			go func(forwarder *socks5.ReverseForwarder) {
+				log.Infof("mieru client reverse forward %d -> %s is running", forwarder.RemotePort, forwarder.LocalAddr)
+				if err := forwarder.Serve(); err != nil {
+					log.Fatalf("run reverse forward %d -> %s failed: %v", forwarder.RemotePort, forwarder.LocalAddr, err)
+				}
+				log.Infof("mieru client reverse forward %d -> %s is stopped", forwarder.RemotePort, forwarder.LocalAddr)
+				wg.Done()
+			}(forwarder)
+		}
+	}
+
 	metrics.EnableLogging()
 
 	appctl.SetAppStatus(appctlpb.AppStatus_RUNNING)
 	wg.Wait()
 
+	// Send the spans that are not exported yet.
+	appctl.CloseTracer()
+
 	// Stop CPU profiling, if previously started.
 	pprof.StopCPUProfile()
 
@@ -649,7 +865,7 @@ var clientStopFunc = func(s []string) error {
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		log.Infof(stderror.ClientNotRunning)
+		log.Infof(i18n.T(stderror.ClientNotRunning))
 		return nil
 	}
 	if err != nil {
@@ -657,9 +873,9 @@ var clientStopFunc = func(s []string) error {
 	}
 
 	if _, err = client.Exit(ctx, &appctlpb.Empty{}); err != nil {
-		return fmt.Errorf(stderror.ExitFailedErr, err)
+		return i18n.Errorf(stderror.ExitFailedErr, err)
 	}
-	log.Infof("mieru client is stopped")
+	log.Infof(i18n.T("mieru client is stopped"))
 	return nil
 }
 
@@ -667,25 +883,168 @@ var clientStatusFunc = func(s []string) error {
 	if err := appctl.IsClientDaemonRunning(context.Background()); err != nil {
 		if stderror.IsConnRefused(err) {
 			// This is the most common reason, no need to show more details.
-			return fmt.Errorf(stderror.ClientNotRunning)
+			return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 		} else if errors.Is(err, stderror.ErrFileNotExist) {
 			// Ask the user to create a client config.
-			return fmt.Errorf(stderror.ClientConfigNotExist + ", please create one with \"mieru apply config <FILE>\" command")
+			return exitErrorf(ExitConfigInvalid, "%s, please create one with \"mieru apply config <FILE>\" command", i18n.T(stderror.ClientConfigNotExist))
 		} else {
-			return fmt.Errorf(stderror.ClientNotRunningErr, err)
+			return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunningErr, err)
+		}
+	}
+	log.Infof(i18n.T("mieru client is running"))
+	status, err := appctl.GetClientStatusWithRPC(context.Background())
+	if err == nil && status.ActiveProfile != nil {
+		log.Infof(i18n.T("active profile: %s"), status.GetActiveProfile())
+		if status.GetProxyDisabled() {
+			log.Warnf(i18n.T("proxy is disabled by a profile schedule"))
+		}
+	}
+	if err == nil && status.GetQuality() != nil {
+		quality := status.GetQuality()
+		log.Infof(i18n.T("connection quality score: %d (round trip time %d ms, loss rate %.1f%%, dial failure rate %.1f%%)"), quality.GetScore(), quality.GetRttMs(), quality.GetLossRate()*100, quality.GetDialFailureRate()*100)
+		if serverInfo := status.GetServerInfo(); serverInfo != nil {
+			if serverInfo.GetVersion() != "" {
+				log.Infof(i18n.T("proxy server version: %s"), serverInfo.GetVersion())
+			} else {
+				log.Infof(i18n.T("proxy server version: unknown, the server is too old to advertise the version"))
+			}
+			log.Infof(i18n.T("proxy server features: %s"), strings.Join(serverInfo.GetFeatures(), ", "))
+			if len(serverInfo.GetMissingFeatures()) > 0 {
+				log.Warnf(i18n.T("proxy server doesn't support: %s. Upgrade the server to use these features."), strings.Join(serverInfo.GetMissingFeatures(), ", "))
+			}
 		}
 	}
-	log.Infof("mieru client is running")
+	if err == nil {
+		printStorageHealth(status)
+	}
 	return nil
 }
 
+// profileScheduleInterval is the interval to evaluate profile schedules.
+const profileScheduleInterval = 30 * time.Second
+
+// runProfileScheduler applies the profile schedules to the running client.
+// The client config is loaded again every time, so the schedules changed
+// by "mieru apply config" take effect without restarting the client.
+func runProfileScheduler(state appctl.ScheduledState, mux *protocol.Mux, resolver apicommon.DNSResolver, socks5Server *socks5.Server) {
+	ticker := time.NewTicker(profileScheduleInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if appctl.GetAppStatus() == appctlpb.AppStatus_STOPPING {
+			return
+		}
+		config, err := appctl.LoadClientConfig()
+		if err != nil {
+			log.Warnf("Load client config to evaluate profile schedules failed: %v", err)
+			continue
+		}
+		if err := appctl.ValidateFullClientConfig(config); err != nil {
+			log.Warnf("Client config is invalid, profile schedules are not evaluated: %v", err)
+			continue
+		}
+		next := appctl.ScheduledStateAt(config, time.Now())
+		if next.ProfileName != state.ProfileName {
+			profile, err := appctl.GetActiveProfileFromConfig(config, next.ProfileName)
+			if err != nil {
+				log.Errorf("Switch to profile %q failed: %v", next.ProfileName, err)
+				continue
+			}
+			nextMux, err := newClientMux(profile, resolver)
+			if err != nil {
+				log.Errorf("Switch to profile %q failed: %v", next.ProfileName, err)
+				continue
+			}
+			socks5Server.SetProxyMux(nextMux)
+			socks5Server.SetHosts(appctl.ClientHosts(profile))
+			appctl.SetClientMuxRef(nextMux)
+			if err := mux.Close(); err != nil {
+				log.Warnf("Close mux of profile %q failed: %v", state.ProfileName, err)
+			}
+			mux = nextMux
+			log.Infof("Profile schedule switched from profile %q to profile %q", state.ProfileName, next.ProfileName)
+		}
+		if next.ProxyDisabled != state.ProxyDisabled {
+			socks5Server.SetProxyDisabled(next.ProxyDisabled)
+			if next.ProxyDisabled {
+				log.Infof("Profile schedule disabled proxy")
+			} else {
+				log.Infof("Profile schedule enabled proxy")
+			}
+		}
+		state = next
+		appctl.SetClientScheduledState(state)
+	}
+}
+
+// newClientMux creates a client multiplexer from the profile.
+func newClientMux(activeProfile *appctlpb.ClientProfile, resolver apicommon.DNSResolver) (*protocol.Mux, error) {
+	activeProfile = appctl.ApplyPreset(activeProfile)
+	mux := protocol.NewMux(true).SetResolver(resolver)
+	user := activeProfile.GetUser()
+	var hashedPassword []byte
+	var err error
+	if user.GetHashedPassword() != "" {
+		hashedPassword, err = hex.DecodeString(user.GetHashedPassword())
+		if err != nil {
+			return nil, i18n.Errorf(stderror.DecodeHashedPasswordFailedErr, err)
+		}
+	} else {
+		hashedPassword = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
+	}
+	mux = mux.SetClientUserNamePassword(user.GetName(), hashedPassword)
+	profileName := activeProfile.GetProfileName()
+	mux = mux.SetClientCredentialHandler(func(userName string, hashedPassword []byte) {
+		// The handler must not block the underlay.
+		go func() {
+			if err := appctl.RenewClientPassword(profileName, userName, hashedPassword); err != nil {
+				log.Errorf("Unable to store the password renewed by the proxy server: %v", err)
+			}
+		}()
+	})
+
+	multiplexFactor := 1
+	switch activeProfile.GetMultiplexing().GetLevel() {
+	case appctlpb.MultiplexingLevel_MULTIPLEXING_OFF:
+		multiplexFactor = 0
+	case appctlpb.MultiplexingLevel_MULTIPLEXING_LOW:
+		multiplexFactor = 1
+	case appctlpb.MultiplexingLevel_MULTIPLEXING_MIDDLE:
+		multiplexFactor = 2
+	case appctlpb.MultiplexingLevel_MULTIPLEXING_HIGH:
+		multiplexFactor = 3
+	}
+	mux = mux.SetClientMultiplexFactor(multiplexFactor)
+	mux = mux.SetClientUnderlayPool(appctl.ClientUnderlayPoolConfig(activeProfile))
+	mux = mux.SetClientEndpointPolicy(appctl.EndpointPolicy(activeProfile.GetEndpointPolicy()))
+	mux = mux.SetWebSocket(appctl.ClientWebSocketConfig(activeProfile))
+	mux = mux.SetTLS(appctl.ClientTLSConfig(activeProfile))
+	mux = mux.SetFECGroupSize(int(activeProfile.GetFecGroupSize()))
+	mux = mux.SetRetransmissionLimit(appctl.RetransmissionLimit(activeProfile.GetRetransmissionLimit()))
+	mux = mux.SetFlowControl(appctl.FlowControl(activeProfile.GetFlowControl()))
+	mux = mux.SetBandwidth(appctl.Bandwidth(activeProfile.GetFlowControl()))
+	mux = mux.SetCongestionControl(appctl.CongestionControl(activeProfile.GetCongestionControl()))
+	mux = mux.SetClientPathMTUDiscovery(activeProfile.GetPathMTUDiscovery())
+	mux = mux.SetClientSessionMigration(activeProfile.GetSessionMigration())
+	mux = mux.SetClientHybridKeyExchange(activeProfile.GetHybridKeyExchange())
+	mux = mux.SetClientRekey(appctl.Rekey(activeProfile.GetRekey()))
+	mux = mux.SetUDPOffload(activeProfile.GetUdpOffload())
+	mux = mux.SetIntegrityDiagnostic(activeProfile.GetIntegrityDiagnostic())
+	mux = mux.SetClientIdleTimeout(appctl.IdleTimeout(activeProfile.GetIdleTimeout()))
+
+	endpoints, err := appctl.ClientEndpoints(activeProfile, resolver)
+	if err != nil {
+		return nil, err
+	}
+	return mux.SetEndpoints(endpoints), nil
+}
+
 var clientTestFunc = func(s []string) error {
 	if err := appctl.IsClientDaemonRunning(context.Background()); err != nil {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	config, err := appctl.LoadClientConfig()
 	if err != nil {
-		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 	}
 
 	httpClient := &http.Client{
@@ -705,6 +1064,18 @@ var clientTestFunc = func(s []string) error {
 	beginTime := time.Now()
 	resp, err := httpClient.Get(destination)
 	if err != nil {
+		// Find out if the proxy server is the cause.
+		if u, parseErr := url.Parse(destination); parseErr == nil {
+			addr := model.AddrSpec{FQDN: u.Hostname(), Port: 443}
+			if u.Port() != "" {
+				addr.Port, _ = strconv.Atoi(u.Port())
+			} else if u.Scheme == "http" {
+				addr.Port = 80
+			}
+			if diagErr := diagnoseActiveProfile(config, addr); diagErr != nil {
+				return diagErr
+			}
+		}
 		return err
 	}
 	endTime := time.Now()
@@ -715,7 +1086,7 @@ var clientTestFunc = func(s []string) error {
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("received unexpected status code %d after %v", resp.StatusCode, d)
 	}
-	log.Infof("Connected to %q after %v", destination, d)
+	log.Infof(i18n.T("Connected to %q after %v"), destination, d)
 	return nil
 }
 
@@ -723,22 +1094,23 @@ var clientApplyConfigFunc = func(s []string) error {
 	_, err := appctl.LoadClientConfig()
 	if err == stderror.ErrFileNotExist {
 		if err = appctl.StoreClientConfig(&appctlpb.ClientConfig{}); err != nil {
-			return fmt.Errorf(stderror.StoreClientConfigFailedErr, err)
+			return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
 		}
 	}
-	return appctl.ApplyJSONClientConfig(s[3])
+	return withExitCode(ExitConfigInvalid, appctl.ApplyJSONClientConfig(s[3]))
 }
 
 var clientDescribeConfigFunc = func(s []string) error {
 	_, err := appctl.LoadClientConfig()
 	if err == stderror.ErrFileNotExist {
 		if err = appctl.StoreClientConfig(&appctlpb.ClientConfig{}); err != nil {
-			return fmt.Errorf(stderror.StoreClientConfigFailedErr, err)
+			return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
 		}
 	}
-	out, err := appctl.GetJSONClientConfig()
+	fileType, _ := configFormat(s, "mieru")
+	out, err := appctl.GetFormattedClientConfig(fileType)
 	if err != nil {
-		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 	}
 	log.Infof("%s", out)
 	return nil
@@ -748,31 +1120,92 @@ var clientImportConfigFunc = func(s []string) error {
 	_, err := appctl.LoadClientConfig()
 	if err == stderror.ErrFileNotExist {
 		if err = appctl.StoreClientConfig(&appctlpb.ClientConfig{}); err != nil {
-			return fmt.Errorf(stderror.StoreClientConfigFailedErr, err)
+			return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
 		}
 	}
-	return appctl.ApplyURLClientConfig(s[3])
+	return withExitCode(ExitConfigInvalid, appctl.ApplyURLClientConfig(s[3]))
 }
 
 var clientExportConfigFunc = func(s []string) error {
 	_, err := appctl.LoadClientConfig()
 	if err == stderror.ErrFileNotExist {
 		if err = appctl.StoreClientConfig(&appctlpb.ClientConfig{}); err != nil {
-			return fmt.Errorf(stderror.StoreClientConfigFailedErr, err)
+			return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
 		}
 	}
 	out, err := appctl.GetURLClientConfig()
 	if err != nil {
-		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 	}
 	log.Infof("%s", out)
 	return nil
 }
 
+var clientEncryptConfigFunc = func(s []string) error {
+	_, err := appctl.LoadClientConfig()
+	if err == stderror.ErrFileNotExist {
+		return exitErrorf(ExitConfigInvalid, stderror.ClientConfigNotExist)
+	} else if err != nil {
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
+	}
+	if encrypted, _ := appctl.IsClientConfigEncrypted(); encrypted {
+		log.Infof(i18n.T("Client configuration is already encrypted."))
+		return nil
+	}
+	if _, err := newPassphrase(); err != nil {
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
+	}
+	if err := appctl.EncryptClientConfig(); err != nil {
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
+	}
+	log.Infof(i18n.T("Client configuration is encrypted. Provide the passphrase with MIERU_CONFIG_PASSPHRASE or MIERU_CONFIG_PASSPHRASE_FILE environment variable if the client is started without a terminal."))
+	return nil
+}
+
+var clientDecryptConfigFunc = func(s []string) error {
+	encrypted, err := appctl.IsClientConfigEncrypted()
+	if err == stderror.ErrFileNotExist {
+		return exitErrorf(ExitConfigInvalid, stderror.ClientConfigNotExist)
+	} else if err != nil {
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
+	}
+	if !encrypted {
+		log.Infof(i18n.T("Client configuration is not encrypted."))
+		return nil
+	}
+	if err := appctl.DecryptClientConfig(); err != nil {
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
+	}
+	log.Infof(i18n.T("Client configuration is decrypted."))
+	return nil
+}
+
+var clientApplyPresetFunc = func(s []string) error {
+	preset, _ := appctl.ParsePreset(s[3])
+	config, err := appctl.LoadClientConfig()
+	if err != nil {
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
+	}
+	profileName := config.GetActiveProfile()
+	if len(s) == 5 {
+		profileName = s[4]
+	}
+	profile, err := appctl.GetActiveProfileFromConfig(config, profileName)
+	if err != nil {
+		return err
+	}
+	profile.Preset = preset.Enum()
+	if err := appctl.StoreClientConfig(config); err != nil {
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
+	}
+	log.Infof(i18n.T("Preset %s is applied to profile %q. Restart mieru client to use it."), appctl.PresetName(preset), profileName)
+	return nil
+}
+
 var clientDeleteProfileFunc = func(s []string) error {
 	_, err := appctl.LoadClientConfig()
 	if err != nil {
-		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 	}
 	return appctl.DeleteClientConfigProfile(s[3])
 }
@@ -780,35 +1213,35 @@ var clientDeleteProfileFunc = func(s []string) error {
 var clientDeleteHTTPProxyFunc = func(_ []string) error {
 	config, err := appctl.LoadClientConfig()
 	if err != nil {
-		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 	}
 	if config.HttpProxyPort == nil && config.HttpProxyListenLAN == nil {
-		log.Infof("HTTP proxy is already deleted from client config.")
+		log.Infof(i18n.T("HTTP proxy is already deleted from client config."))
 		return nil
 	}
 	config.HttpProxyPort = nil
 	config.HttpProxyListenLAN = nil
 	if err := appctl.StoreClientConfig(config); err != nil {
-		return fmt.Errorf(stderror.StoreClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
 	}
-	log.Infof("HTTP proxy is deleted from client config.")
+	log.Infof(i18n.T("HTTP proxy is deleted from client config."))
 	return nil
 }
 
 var clientDeleteSocks5AuthenticationFunc = func(_ []string) error {
 	config, err := appctl.LoadClientConfig()
 	if err != nil {
-		return fmt.Errorf(stderror.GetClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
 	}
 	if len(config.GetSocks5Authentication()) == 0 {
-		log.Infof("socks5 user password authentication is already deleted from client config.")
+		log.Infof(i18n.T("socks5 user password authentication is already deleted from client config."))
 		return nil
 	}
 	config.Socks5Authentication = nil
 	if err := appctl.StoreClientConfig(config); err != nil {
-		return fmt.Errorf(stderror.StoreClientConfigFailedErr, err)
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
 	}
-	log.Infof("socks5 user password authentication is deleted from client config.")
+	log.Infof(i18n.T("socks5 user password authentication is deleted from client config."))
 	return nil
 }
 
@@ -840,7 +1273,7 @@ var clientGetMetricsFunc = func(s []string) error {
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
@@ -848,7 +1281,7 @@ var clientGetMetricsFunc = func(s []string) error {
 
 	metrics, err := client.GetMetrics(ctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetMetricsFailedErr, err)
+		return i18n.Errorf(stderror.GetMetricsFailedErr, err)
 	}
 	log.Infof("%s", metrics.GetJson())
 	return nil
@@ -859,7 +1292,7 @@ var clientGetConnectionsFunc = func(s []string) error {
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
@@ -867,7 +1300,7 @@ var clientGetConnectionsFunc = func(s []string) error {
 
 	info, err := client.GetSessionInfo(ctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetConnectionsFailedErr, err)
+		return i18n.Errorf(stderror.GetConnectionsFailedErr, err)
 	}
 	for _, line := range info.GetTable() {
 		log.Infof("%s", line)
@@ -875,12 +1308,149 @@ var clientGetConnectionsFunc = func(s []string) error {
 	return nil
 }
 
+var clientGetDestinationsFunc = func(s []string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	client, running, err := newClientLifecycleRPCClient(ctx)
+	if !running {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
+	}
+	if err != nil {
+		return err
+	}
+
+	stats, err := client.GetDestinationStats(ctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetDestinationStatsFailedErr, err)
+	}
+	rows := [][]string{{"Destination", "Connections", "Upload", "Download", "LastSeen"}}
+	for _, stat := range stats.GetDestinations() {
+		rows = append(rows, []string{
+			stat.GetDestination(),
+			fmt.Sprintf("%d", stat.GetConnections()),
+			fmt.Sprintf("%d", stat.GetUploadBytes()),
+			fmt.Sprintf("%d", stat.GetDownloadBytes()),
+			stat.GetLastSeen(),
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+var clientGetSessionStateFunc = func(s []string) error {
+	req, err := sessionStateRequest(s, "mieru")
+	if err != nil {
+		return err
+	}
+	ctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	client, running, err := newClientLifecycleRPCClient(ctx)
+	if !running {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
+	}
+	if err != nil {
+		return err
+	}
+
+	states, err := client.GetSessionStates(ctx, req)
+	if err != nil {
+		return i18n.Errorf(stderror.GetSessionStatesFailedErr, err)
+	}
+	log.Infof("%s", states.GetJson())
+	return nil
+}
+
+var clientGetLogsFunc = func(s []string) error {
+	req, err := logRequest(s, "mieru")
+	if err != nil {
+		return err
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	client, running, err := newClientLifecycleRPCClient(timedctx)
+	if !running {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := timedctx
+	if req.GetFollow() {
+		ctx = context.Background()
+	}
+	stream, err := client.GetLogs(ctx, req)
+	if err != nil {
+		return i18n.Errorf(stderror.GetLogsFailedErr, err)
+	}
+	if err := printLogs(stream.Recv); err != nil {
+		return i18n.Errorf(stderror.GetLogsFailedErr, err)
+	}
+	return nil
+}
+
+var clientGetRouteStatsFunc = func(s []string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	client, running, err := newClientLifecycleRPCClient(ctx)
+	if !running {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
+	}
+	if err != nil {
+		return err
+	}
+
+	stats, err := client.GetAutoRouteStats(ctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetRouteStatsFailedErr, err)
+	}
+	rules := [][]string{{"Rule", "Route", "Matches"}}
+	for _, rule := range stats.GetRules() {
+		rules = append(rules, []string{
+			rule.GetRule(),
+			autoRouteName(rule.GetDirect()),
+			fmt.Sprintf("%d", rule.GetMatches()),
+		})
+	}
+	for _, line := range formatTable(rules) {
+		log.Infof("%s", line)
+	}
+	if len(stats.GetRecentDecisions()) == 0 {
+		return nil
+	}
+	log.Infof("")
+	decisions := [][]string{{"Time", "Destination", "Route", "Rule", "LatencyMs"}}
+	for _, d := range stats.GetRecentDecisions() {
+		decisions = append(decisions, []string{
+			d.GetTime(),
+			d.GetDestination(),
+			autoRouteName(d.GetDirect()),
+			d.GetRule(),
+			fmt.Sprintf("%d", d.GetLatencyMs()),
+		})
+	}
+	for _, line := range formatTable(decisions) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+// autoRouteName returns the name of the route decided by auto route.
+func autoRouteName(direct bool) string {
+	if direct {
+		return "DIRECT"
+	}
+	return "PROXY"
+}
+
 var clientGetThreadDumpFunc = func(s []string) error {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
@@ -888,7 +1458,7 @@ var clientGetThreadDumpFunc = func(s []string) error {
 
 	dump, err := client.GetThreadDump(ctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetThreadDumpFailedErr, err)
+		return i18n.Errorf(stderror.GetThreadDumpFailedErr, err)
 	}
 	log.Infof("%s", dump.GetThreadDump())
 	return nil
@@ -899,25 +1469,56 @@ var clientGetHeapProfileFunc = func(s []string) error {
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
 	}
 
 	if _, err := client.GetHeapProfile(ctx, &appctlpb.ProfileSavePath{FilePath: proto.String(s[3])}); err != nil {
-		return fmt.Errorf(stderror.GetHeapProfileFailedErr, err)
+		return i18n.Errorf(stderror.GetHeapProfileFailedErr, err)
 	}
-	log.Infof("heap profile is saved to %q", s[3])
+	log.Infof(i18n.T("heap profile is saved to %q"), s[3])
 	return nil
 }
 
+var clientBugReportFunc = func(s []string) error {
+	dir, err := clientCrashReportDir()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateBugReportFailedErr, err)
+	}
+	crash.SetReportDir(dir)
+	f, err := os.Create(s[2])
+	if err != nil {
+		return i18n.Errorf(stderror.CreateBugReportFailedErr, err)
+	}
+	if err := crash.WriteBundle(f); err != nil {
+		f.Close()
+		return i18n.Errorf(stderror.CreateBugReportFailedErr, err)
+	}
+	if err := f.Close(); err != nil {
+		return i18n.Errorf(stderror.CreateBugReportFailedErr, err)
+	}
+	log.Infof(i18n.T("bug report is saved to %q"), s[2])
+	return nil
+}
+
+// clientCrashReportDir returns the directory to save crash reports
+// of mieru client. It is next to the client log files.
+func clientCrashReportDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "mieru", "crash"), nil
+}
+
 var clientGetMemoryStatisticsFunc = func(s []string) error {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
@@ -925,7 +1526,7 @@ var clientGetMemoryStatisticsFunc = func(s []string) error {
 
 	memStats, err := client.GetMemoryStatistics(ctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetMemoryStatisticsFailedErr, err)
+		return i18n.Errorf(stderror.GetMemoryStatisticsFailedErr, err)
 	}
 	log.Infof("%s", memStats.GetJson())
 	return nil
@@ -936,16 +1537,16 @@ var clientStartCPUProfileFunc = func(s []string) error {
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
 	}
 
 	if _, err := client.StartCPUProfile(ctx, &appctlpb.ProfileSavePath{FilePath: proto.String(s[4])}); err != nil {
-		return fmt.Errorf(stderror.StartCPUProfileFailedErr, err)
+		return i18n.Errorf(stderror.StartCPUProfileFailedErr, err)
 	}
-	log.Infof("CPU profile will be saved to %q", s[4])
+	log.Infof(i18n.T("CPU profile will be saved to %q"), s[4])
 	return nil
 }
 
@@ -954,7 +1555,7 @@ var clientStopCPUProfileFunc = func(s []string) error {
 	defer cancelFunc()
 	client, running, err := newClientLifecycleRPCClient(ctx)
 	if !running {
-		return fmt.Errorf(stderror.ClientNotRunning)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
 	}
 	if err != nil {
 		return err
@@ -964,6 +1565,24 @@ var clientStopCPUProfileFunc = func(s []string) error {
 	return nil
 }
 
+var clientReloadDNSFunc = func(s []string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	client, running, err := newClientLifecycleRPCClient(ctx)
+	if !running {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.ReloadDNS(ctx, &appctlpb.Empty{}); err != nil {
+		return i18n.Errorf(stderror.ReloadDNSFailedErr, err)
+	}
+	log.Infof(i18n.T("DNS settings are applied to mieru client."))
+	return nil
+}
+
 // newClientLifecycleRPCClient returns a new client lifecycle RPC client.
 // No RPC client is returned if mieru is not running.
 func newClientLifecycleRPCClient(ctx context.Context) (client appctlgrpc.ClientLifecycleServiceClient, running bool, err error) {
@@ -973,7 +1592,7 @@ func newClientLifecycleRPCClient(ctx context.Context) (client appctlgrpc.ClientL
 	running = true
 	client, err = appctl.NewClientLifecycleRPCClient()
 	if err != nil {
-		return nil, true, fmt.Errorf(stderror.CreateClientLifecycleRPCClientFailedErr, err)
+		return nil, true, i18n.Errorf(stderror.CreateClientLifecycleRPCClientFailedErr, err)
 	}
 	return
 }
@@ -0,0 +1,197 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/i18n"
+)
+
+// completeCmd is a hidden command called by the shell completion scripts.
+// The remaining arguments are the words typed after the binary name,
+// and the last one is the word being completed.
+const completeCmd = "__complete"
+
+// completionEntry provides candidates of the arguments after a command.
+type completionEntry struct {
+	matches  []string
+	complete func() []string
+}
+
+// completions contains registered dynamic completions.
+var completions = make([]completionEntry, 0)
+
+// RegisterCompletion registers a function that returns the candidates of
+// the arguments after the command given by exactMatches, for example
+// the profile names after "mieru delete profile".
+func RegisterCompletion(exactMatches []string, complete func() []string) {
+	completions = append(completions, completionEntry{
+		matches:  exactMatches,
+		complete: complete,
+	})
+}
+
+// complete returns the candidates of the last word in words.
+// words doesn't include the binary name.
+func complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	typed := []string{binaryName}
+	for _, w := range words[:len(words)-1] {
+		if !strings.HasPrefix(w, langFlag) {
+			typed = append(typed, w)
+		}
+	}
+
+	candidates := make(map[string]struct{})
+	if strings.HasPrefix(current, "-") {
+		for _, l := range []i18n.Locale{i18n.English, i18n.SimplifiedChinese, i18n.Farsi} {
+			candidates[langFlag+string(l)] = struct{}{}
+		}
+	} else {
+		// Subcommands.
+		for _, hook := range hooks {
+			if len(hook.matches) > len(typed) && doExactMatch(typed, hook.matches[:len(typed)]) && hook.matches[len(typed)] != "" {
+				candidates[hook.matches[len(typed)]] = struct{}{}
+			}
+		}
+		// Dynamic arguments.
+		for _, c := range completions {
+			if len(typed) >= len(c.matches) && doExactMatch(typed, c.matches) {
+				for _, arg := range c.complete() {
+					candidates[arg] = struct{}{}
+				}
+				// Don't suggest the arguments already provided.
+				for _, arg := range typed[len(c.matches):] {
+					delete(candidates, arg)
+				}
+			}
+		}
+	}
+
+	res := make([]string, 0, len(candidates))
+	for c := range candidates {
+		if strings.HasPrefix(c, current) {
+			res = append(res, c)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// completionScript returns the completion script of the shell.
+func completionScript(shell string) (string, error) {
+	name := binaryName
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(`# bash completion for %[1]s
+_%[1]s_completion() {
+    local IFS=$'\n'
+    COMPREPLY=($(%[1]s %[2]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *= ]]; then
+        compopt -o nospace
+    fi
+}
+complete -o default -F _%[1]s_completion %[1]s
+`, name, completeCmd), nil
+	case "zsh":
+		return fmt.Sprintf(`#compdef %[1]s
+# zsh completion for %[1]s
+_%[1]s() {
+    local -a candidates
+    candidates=(${(f)"$(%[1]s %[2]s "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    if (( ${#candidates} )); then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _%[1]s %[1]s
+`, name, completeCmd), nil
+	case "fish":
+		return fmt.Sprintf(`# fish completion for %[1]s
+function __%[1]s_complete
+    set -l previous (commandline -opc)
+    set -l current (commandline -ct)
+    %[1]s %[2]s $previous[2..-1] "$current" 2>/dev/null
+end
+complete -c %[1]s -a '(__%[1]s_complete)'
+`, name, completeCmd), nil
+	default:
+		return "", exitErrorf(ExitUsage, "unsupported shell %q, supported shells are bash, zsh and fish", shell)
+	}
+}
+
+// registerCompletionCommands registers the commands to generate
+// shell completion scripts and to complete the command line.
+func registerCompletionCommands() {
+	RegisterCallback(
+		[]string{"", "completion"},
+		func(s []string) error {
+			if len(s) < 3 {
+				return fmt.Errorf("usage: %s completion <SHELL>. no shell is provided", binaryName)
+			}
+			return unexpectedArgsError(s, 3)
+		},
+		func(s []string) error {
+			script, err := completionScript(s[2])
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	)
+}
+
+// runCompletion prints the candidates of the command line to stdout.
+func runCompletion(args []string) {
+	for _, c := range complete(args[2:]) {
+		fmt.Println(c)
+	}
+}
+
+// clientProfileNames returns the profile names in the client config.
+func clientProfileNames() []string {
+	config, err := appctl.LoadClientConfig()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0)
+	for _, profile := range config.GetProfiles() {
+		names = append(names, profile.GetProfileName())
+	}
+	return names
+}
+
+// serverUserNames returns the user names in the server config.
+func serverUserNames() []string {
+	config, err := appctl.LoadServerConfig()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0)
+	for _, user := range config.GetUsers() {
+		names = append(names, user.GetName())
+	}
+	return names
+}
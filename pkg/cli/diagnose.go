@@ -0,0 +1,81 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+// diagnoseTimeout is the maximum time to wait for the proxy server
+// in each step of the diagnosis.
+const diagnoseTimeout = 10 * time.Second
+
+// diagnoseActiveProfile connects to the proxy server of the active profile
+// directly, and finds out if the server is unreachable or the server
+// doesn't accept the user. The returned error has the matching exit code.
+// It returns nil if no problem is found.
+func diagnoseActiveProfile(config *appctlpb.ClientConfig, destination model.AddrSpec) error {
+	activeProfile, err := appctl.GetActiveProfileFromConfig(config, config.GetActiveProfile())
+	if err != nil {
+		return nil
+	}
+	mux, err := newClientMux(activeProfile, &net.Resolver{})
+	if err != nil {
+		return withExitCode(ExitServerUnreachable, err)
+	}
+	defer mux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), diagnoseTimeout)
+	defer cancelFunc()
+	conn, err := mux.DialContext(ctx)
+	if err != nil {
+		return exitErrorf(ExitServerUnreachable, "unable to connect to proxy server: %v", err)
+	}
+	defer conn.Close()
+
+	// The server only responds to the socks5 request if the user
+	// is accepted, even if the destination can't be reached.
+	var req bytes.Buffer
+	req.Write([]byte{constant.Socks5Version, constant.Socks5ConnectCmd, 0})
+	if err := destination.WriteToSocks5(&req); err != nil {
+		return nil
+	}
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return exitErrorf(ExitServerUnreachable, "unable to connect to proxy server: %v", err)
+	}
+	common.SetReadTimeout(conn, diagnoseTimeout)
+	if _, err := io.ReadFull(conn, make([]byte, 3)); err != nil {
+		if strings.HasPrefix(conn.RemoteAddr().Network(), "udp") {
+			// Without a response, UDP can't tell whether the server is reachable.
+			return exitErrorf(ExitServerUnreachable, "proxy server doesn't respond; the server may be unreachable, or the user name, password or system time is wrong: %v", err)
+		}
+		return exitErrorf(ExitAuthFailure, "proxy server doesn't accept the user; check the user name, password, and that the system time of client and server is in sync: %v", err)
+	}
+	log.Debugf("Proxy server accepted the user")
+	return nil
+}
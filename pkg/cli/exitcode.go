@@ -0,0 +1,94 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"errors"
+
+	"github.com/enfein/mieru/v3/pkg/i18n"
+)
+
+// Exit codes of mieru and mita commands. Scripts and installers can
+// check the exit code to find the class of a failure.
+const (
+	// ExitOK means the command is successful.
+	ExitOK = 0
+
+	// ExitFailure is used by failures not covered by other exit codes.
+	ExitFailure = 1
+
+	// ExitUsage means the command or the arguments are invalid.
+	ExitUsage = 2
+
+	// ExitConfigInvalid means the configuration doesn't exist or is invalid.
+	ExitConfigInvalid = 3
+
+	// ExitDaemonNotRunning means the mieru client or mita server daemon
+	// is not running.
+	ExitDaemonNotRunning = 4
+
+	// ExitServerUnreachable means none of the proxy servers can be reached.
+	ExitServerUnreachable = 5
+
+	// ExitAuthFailure means the proxy server doesn't accept the user.
+	ExitAuthFailure = 6
+)
+
+// exitError annotates an error with an exit code.
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode annotates the error with the exit code. If the error
+// already has an exit code, the existing one is kept.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return err
+	}
+	return &exitError{err: err, code: code}
+}
+
+// exitErrorf is similar to i18n.Errorf, and annotates the error
+// with the exit code.
+func exitErrorf(code int, format string, a ...interface{}) error {
+	return &exitError{err: i18n.Errorf(format, a...), code: code}
+}
+
+// ExitCode returns the process exit code of the error returned by
+// ParseAndExecute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return ExitFailure
+}
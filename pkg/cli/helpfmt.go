@@ -15,7 +15,12 @@
 
 package cli
 
-import "github.com/enfein/mieru/v3/pkg/log"
+import (
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/i18n"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
 
 type helpFormatter struct {
 	appName  string
@@ -28,25 +33,55 @@ type helpCmdEntry struct {
 	help string
 }
 
-func (m helpFormatter) print() {
+// print shows the help of the commands. If words are provided,
+// only the commands that start with the words are shown.
+func (m helpFormatter) print(words []string) error {
+	entries := filterHelpEntries(m.entries, words)
+	advanced := filterHelpEntries(m.advanced, words)
+	if len(words) > 0 && len(entries) == 0 && len(advanced) == 0 {
+		return exitErrorf(ExitUsage, "no help is found for %q. Run \"%s help\" to get the list of supported commands", strings.Join(words, " "), binaryName)
+	}
 	if m.appName != "" {
-		log.Infof("Usage: %s <COMMAND> [<ARGS>]", m.appName)
+		log.Infof(i18n.T("Usage: %s <COMMAND> [<ARGS>]"), m.appName)
 		log.Infof("")
 	}
-	if len(m.entries) != 0 {
-		log.Infof("Commands:")
-		for _, entry := range m.entries {
+	if len(entries) != 0 {
+		log.Infof(i18n.T("Commands:"))
+		for _, entry := range entries {
 			log.Infof("  %s", entry.cmd)
-			log.Infof("        %s", entry.help)
+			log.Infof("        %s", i18n.T(entry.help))
 			log.Infof("")
 		}
 	}
-	if len(m.advanced) != 0 {
-		log.Infof("Commands for developers and experienced users:")
-		for _, entry := range m.advanced {
+	if len(advanced) != 0 {
+		log.Infof(i18n.T("Commands for developers and experienced users:"))
+		for _, entry := range advanced {
 			log.Infof("  %s", entry.cmd)
-			log.Infof("        %s", entry.help)
+			log.Infof("        %s", i18n.T(entry.help))
 			log.Infof("")
 		}
 	}
+	return nil
+}
+
+// filterHelpEntries returns the entries whose command starts with the words.
+func filterHelpEntries(entries []helpCmdEntry, words []string) []helpCmdEntry {
+	res := make([]helpCmdEntry, 0)
+	for _, entry := range entries {
+		fields := strings.Fields(entry.cmd)
+		if len(fields) < len(words) {
+			continue
+		}
+		match := true
+		for i, w := range words {
+			if fields[i] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			res = append(res, entry)
+		}
+	}
+	return res
 }
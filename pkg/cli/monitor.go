@@ -0,0 +1,257 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/i18n"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+const (
+	// monitorInterval is the interval between two probes of mieru monitor.
+	monitorInterval = 10 * time.Second
+
+	// monitorSummaryInterval is the interval to print the rolling summary.
+	monitorSummaryInterval = time.Minute
+
+	// monitorSummaryWindow is the duration of samples in the rolling summary.
+	monitorSummaryWindow = 10 * time.Minute
+
+	// monitorProbeURL is the destination of the probe requests.
+	monitorProbeURL = "https://google.com/generate_204"
+)
+
+// monitorSample is one measurement of mieru monitor.
+type monitorSample struct {
+	Time            time.Time `json:"time"`
+	Success         bool      `json:"success"`         // the probe request is successful
+	LatencyMs       int64     `json:"latencyMs"`       // time to finish the probe request
+	RTTMs           int64     `json:"rttMs"`           // moving average of round trip time
+	RTTVarianceMs   int64     `json:"rttVarianceMs"`   // moving average of round trip time deviation
+	LossRate        float64   `json:"lossRate"`        // ratio of UDP segments sent again
+	DialFailureRate float64   `json:"dialFailureRate"` // ratio of failed connections to the proxy server
+	Score           int32     `json:"score"`           // connection quality score
+	Error           string    `json:"error,omitempty"`
+}
+
+var monitorCSVHeader = []string{"time", "success", "latencyMs", "rttMs", "rttVarianceMs", "lossRate", "dialFailureRate", "score", "error"}
+
+func (s monitorSample) csvRecord() []string {
+	return []string{
+		s.Time.Format(time.RFC3339),
+		strconv.FormatBool(s.Success),
+		strconv.FormatInt(s.LatencyMs, 10),
+		strconv.FormatInt(s.RTTMs, 10),
+		strconv.FormatInt(s.RTTVarianceMs, 10),
+		strconv.FormatFloat(s.LossRate, 'f', 4, 64),
+		strconv.FormatFloat(s.DialFailureRate, 'f', 4, 64),
+		strconv.FormatInt(int64(s.Score), 10),
+		s.Error,
+	}
+}
+
+// monitorWriter saves the samples to a file.
+type monitorWriter interface {
+	write(monitorSample) error
+}
+
+type csvMonitorWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvMonitorWriter) write(s monitorSample) error {
+	if err := c.w.Write(s.csvRecord()); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonMonitorWriter writes one JSON object per line.
+type jsonMonitorWriter struct {
+	w io.Writer
+}
+
+func (j *jsonMonitorWriter) write(s monitorSample) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(append(b, '\n'))
+	return err
+}
+
+// validateMonitorOutput checks the output file of mieru monitor.
+func validateMonitorOutput(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".json", ".jsonl":
+		return nil
+	default:
+		return fmt.Errorf("output file %q must have .csv, .json or .jsonl extension", path)
+	}
+}
+
+// newMonitorWriter opens the output file for append. A CSV header is
+// written if the CSV file is empty.
+func newMonitorWriter(path string) (monitorWriter, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.ToLower(filepath.Ext(path)) != ".csv" {
+		return &jsonMonitorWriter{w: f}, f, nil
+	}
+	w := &csvMonitorWriter{w: csv.NewWriter(f)}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		if err := w.w.Write(monitorCSVHeader); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		w.w.Flush()
+	}
+	return w, f, nil
+}
+
+// monitorSummary returns the rolling summary of the samples.
+func monitorSummary(samples []monitorSample) string {
+	var succeeded int
+	var latency, maxLatency, rtt int64
+	var loss float64
+	for _, s := range samples {
+		if s.Success {
+			succeeded++
+			latency += s.LatencyMs
+			if s.LatencyMs > maxLatency {
+				maxLatency = s.LatencyMs
+			}
+		}
+		rtt += s.RTTMs
+		loss += s.LossRate
+	}
+	if succeeded > 0 {
+		latency /= int64(succeeded)
+	}
+	if len(samples) > 0 {
+		rtt /= int64(len(samples))
+		loss /= float64(len(samples))
+	}
+	return fmt.Sprintf(i18n.T("last %v: %d/%d probes succeeded, average latency %d ms, maximum latency %d ms, average round trip time %d ms, average loss rate %.1f%%"), monitorSummaryWindow, succeeded, len(samples), latency, maxLatency, rtt, loss*100)
+}
+
+var clientMonitorFunc = func(s []string) error {
+	if err := appctl.IsClientDaemonRunning(context.Background()); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ClientNotRunning)
+	}
+	config, err := appctl.LoadClientConfig()
+	if err != nil {
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
+	}
+
+	var writer monitorWriter
+	if len(s) == 3 {
+		w, closer, err := newMonitorWriter(s[2])
+		if err != nil {
+			return fmt.Errorf("open output file %q failed: %w", s[2], err)
+		}
+		defer closer.Close()
+		writer = w
+	}
+
+	// Disable keep-alive, so each probe opens a new connection
+	// and a new session with the proxy server.
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Dial:              socks5.Dial(fmt.Sprintf("socks5://127.0.0.1:%d", config.GetSocks5Port()), constant.Socks5ConnectCmd),
+			DisableKeepAlives: true,
+		},
+		Timeout: appctl.RPCTimeout,
+	}
+
+	log.Infof(i18n.T("monitoring the connection to the proxy server every %v, press Ctrl+C to stop"), monitorInterval)
+	samples := make([]monitorSample, 0)
+	lastSummary := time.Now()
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+	for {
+		sample := monitorProbe(httpClient)
+		if sample.Success {
+			log.Infof(i18n.T("probe succeeded in %d ms, round trip time %d ms, loss rate %.1f%%"), sample.LatencyMs, sample.RTTMs, sample.LossRate*100)
+		} else {
+			log.Warnf(i18n.T("probe failed: %s"), sample.Error)
+		}
+		if writer != nil {
+			if err := writer.write(sample); err != nil {
+				return fmt.Errorf("write output file %q failed: %w", s[2], err)
+			}
+		}
+
+		samples = append(samples, sample)
+		for len(samples) > 0 && time.Since(samples[0].Time) > monitorSummaryWindow {
+			samples = samples[1:]
+		}
+		if time.Since(lastSummary) >= monitorSummaryInterval {
+			log.Infof("%s", monitorSummary(samples))
+			lastSummary = time.Now()
+		}
+		<-ticker.C
+	}
+}
+
+// monitorProbe sends a probe request through the proxy and collects
+// the connection quality from the running client.
+func monitorProbe(httpClient *http.Client) monitorSample {
+	sample := monitorSample{Time: time.Now()}
+	resp, err := httpClient.Get(monitorProbeURL)
+	if err != nil {
+		sample.Error = err.Error()
+	} else {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		sample.LatencyMs = time.Since(sample.Time).Milliseconds()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			sample.Success = true
+		} else {
+			sample.Error = fmt.Sprintf("received unexpected status code %d", resp.StatusCode)
+		}
+	}
+
+	status, err := appctl.GetClientStatusWithRPC(context.Background())
+	if err == nil && status.GetQuality() != nil {
+		quality := status.GetQuality()
+		sample.RTTMs = quality.GetRttMs()
+		sample.RTTVarianceMs = quality.GetRttVarianceMs()
+		sample.LossRate = quality.GetLossRate()
+		sample.DialFailureRate = quality.GetDialFailureRate()
+		sample.Score = quality.GetScore()
+	}
+	return sample
+}
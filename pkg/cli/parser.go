@@ -16,14 +16,19 @@
 package cli
 
 import (
-	"fmt"
 	"os"
 	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/i18n"
 )
 
 // binaryName is the name of this program.
 var binaryName = "mieru"
 
+// langFlag selects the language of messages. It can appear anywhere
+// in the command line, for example "mieru --lang=zh_CN status".
+const langFlag = "--lang="
+
 type matchProcessor struct {
 	matches   []string
 	validator func([]string) error
@@ -53,7 +58,21 @@ func RegisterCallback(exactMatches []string, validator func([]string) error, cal
 // ParseAndExecute runs the command coming from args.
 // This function will wait for the command to finish before return.
 func ParseAndExecute() error {
-	args := os.Args
+	if len(os.Args) > 1 && os.Args[1] == completeCmd {
+		runCompletion(os.Args)
+		return nil
+	}
+	args, err := setLanguage(os.Args)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	target, args, err := splitRemoteTarget(args)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if target != "" {
+		return runRemote(target, args[1:])
+	}
 	found := false
 	for _, hook := range hooks {
 		if !doExactMatch(args, hook.matches) {
@@ -61,7 +80,7 @@ func ParseAndExecute() error {
 		}
 		found = true
 		if err := hook.validator(args); err != nil {
-			return err
+			return withExitCode(ExitUsage, err)
 		}
 		err := hook.callback(args)
 		if err != nil {
@@ -71,7 +90,7 @@ func ParseAndExecute() error {
 	}
 	if !found {
 		cmd := strings.Join(args, " ")
-		return fmt.Errorf("%q is not a valid command. Run \"%s help\" to get the list of supported commands", cmd, binaryName)
+		return exitErrorf(ExitUsage, "%q is not a valid command. Run \"%s help\" to get the list of supported commands", cmd, binaryName)
 	}
 	return nil
 }
@@ -94,7 +113,28 @@ func unexpectedArgsError(args []string, length int) error {
 	if len(args) > length {
 		prefix := strings.Join(args[:length], " ")
 		unexpected := strings.Join(args[length:], " ")
-		return fmt.Errorf("unexpected arguments %q after %q", unexpected, prefix)
+		return i18n.Errorf("unexpected arguments %q after %q", unexpected, prefix)
 	}
 	return nil
 }
+
+// setLanguage selects the language of messages from the command line flag,
+// or environment variables if the flag is not provided. It returns the
+// arguments without the flag.
+func setLanguage(args []string) ([]string, error) {
+	locale := i18n.DetectLocale(strings.ToUpper(binaryName) + "_LANG")
+	remaining := make([]string, 0, len(args))
+	for i, arg := range args {
+		if i == 0 || !strings.HasPrefix(arg, langFlag) {
+			remaining = append(remaining, arg)
+			continue
+		}
+		l, ok := i18n.ParseLocale(strings.TrimPrefix(arg, langFlag))
+		if !ok {
+			return nil, i18n.Errorf("unsupported language %q, supported languages are en, zh_CN and fa", strings.TrimPrefix(arg, langFlag))
+		}
+		locale = l
+	}
+	i18n.SetLocale(locale)
+	return remaining, nil
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/i18n"
+)
+
+// promptKeyProvider reads the passphrase of the encrypted client config
+// from the environment variables. If they are not set, it asks the user
+// to type the passphrase in the terminal. The typed passphrase is saved
+// to the environment variable, so the client daemon started by this
+// process can decrypt the client config.
+type promptKeyProvider struct {
+	mu sync.Mutex
+}
+
+var _ appctl.KeyProvider = &promptKeyProvider{}
+
+func (kp *promptKeyProvider) Passphrase() ([]byte, error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	passphrase, err := appctl.EnvKeyProvider{}.Passphrase()
+	if err != appctl.ErrNoPassphrase {
+		return passphrase, err
+	}
+	if !isTerminal(os.Stdin) {
+		return nil, appctl.ErrNoPassphrase
+	}
+	passphrase, err = readPassphrase(i18n.T("Passphrase of client config: "))
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, appctl.ErrNoPassphrase
+	}
+	os.Setenv(appctl.ConfigPassphraseEnv, string(passphrase))
+	return passphrase, nil
+}
+
+// newPassphrase returns the passphrase to encrypt the client config.
+// If the environment variables are not set, it asks the user to type
+// the new passphrase twice.
+func newPassphrase() ([]byte, error) {
+	passphrase, err := appctl.EnvKeyProvider{}.Passphrase()
+	if err != appctl.ErrNoPassphrase {
+		return passphrase, err
+	}
+	if !isTerminal(os.Stdin) {
+		return nil, appctl.ErrNoPassphrase
+	}
+	passphrase, err = readPassphrase(i18n.T("New passphrase of client config: "))
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, appctl.ErrNoPassphrase
+	}
+	again, err := readPassphrase(i18n.T("Type the passphrase again: "))
+	if err != nil {
+		return nil, err
+	}
+	if string(again) != string(passphrase) {
+		return nil, i18n.Errorf("The passphrases don't match.")
+	}
+	os.Setenv(appctl.ConfigPassphraseEnv, string(passphrase))
+	return passphrase, nil
+}
+
+// readPassphrase prints the prompt and reads a line from the terminal
+// without echo.
+func readPassphrase(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	restore, err := disableEcho(os.Stdin)
+	if err == nil {
+		defer restore()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
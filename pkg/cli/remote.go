@@ -0,0 +1,149 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/i18n"
+)
+
+// remoteFlag runs a mita command on a remote host with SSH, for example
+// "mita --remote root@example.com status". The remote mita is controlled
+// by its own UNIX domain socket, so no admin port is exposed.
+const remoteFlag = "--remote"
+
+// remoteStdinPath is the file read by the remote mita when the content
+// of a local file is sent through the standard input of SSH.
+const remoteStdinPath = "/dev/stdin"
+
+// sshExitCode is the exit code of ssh when it fails to connect.
+const sshExitCode = 255
+
+// splitRemoteTarget returns the SSH destination of the remote flag,
+// and the arguments without the flag. The destination is empty if the
+// flag is not provided.
+func splitRemoteTarget(args []string) (string, []string, error) {
+	target := ""
+	found := false
+	remaining := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if i == 0 || (arg != remoteFlag && !strings.HasPrefix(arg, remoteFlag+"=")) {
+			remaining = append(remaining, arg)
+			continue
+		}
+		if found {
+			return "", nil, i18n.Errorf("%s is provided more than once", remoteFlag)
+		}
+		found = true
+		if arg == remoteFlag {
+			if i+1 >= len(args) {
+				return "", nil, i18n.Errorf("usage: %s %s <USER@HOST> <COMMAND>", binaryName, remoteFlag)
+			}
+			i++
+			target = args[i]
+		} else {
+			target = strings.TrimPrefix(arg, remoteFlag+"=")
+		}
+		if target == "" || strings.HasPrefix(target, "-") {
+			return "", nil, i18n.Errorf("invalid SSH destination %q", target)
+		}
+	}
+	if found && binaryName != "mita" {
+		return "", nil, i18n.Errorf("%s doesn't support %s", binaryName, remoteFlag)
+	}
+	return target, remaining, nil
+}
+
+// runRemote runs the command on the SSH destination, and waits for the
+// command to finish. The files of "apply config" and "users import" are
+// read locally. A YAML or TOML config file is sent as JSON, because
+// the remote mita can't tell the format from the standard input.
+func runRemote(target string, args []string) error {
+	if len(args) == 0 {
+		return exitErrorf(ExitUsage, "usage: %s %s <USER@HOST> <COMMAND>", binaryName, remoteFlag)
+	}
+	var stdin io.Reader
+	for _, cmd := range [][]string{{"apply", "config"}, {"users", "import"}} {
+		if doExactMatch(args, cmd) && len(args) == 3 {
+			if cmd[0] == "apply" && appctl.FindConfigPatchFileType(args[2]) != appctl.JSON_CONFIG_FILE_TYPE {
+				b, err := configPatchToJSON(args[2])
+				if err != nil {
+					return withExitCode(ExitConfigInvalid, err)
+				}
+				stdin = bytes.NewReader(b)
+			} else {
+				f, err := os.Open(args[2])
+				if err != nil {
+					return withExitCode(ExitConfigInvalid, err)
+				}
+				defer f.Close()
+				stdin = f
+			}
+			args = []string{args[0], args[1], remoteStdinPath}
+		}
+	}
+
+	remoteCmd := []string{binaryName, langFlag + string(i18n.CurrentLocale())}
+	for _, arg := range args {
+		remoteCmd = append(remoteCmd, shellQuote(arg))
+	}
+	cmd := exec.Command("ssh", "--", target, strings.Join(remoteCmd, " "))
+	cmd.Stdin = stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		if exitErr.ExitCode() == sshExitCode {
+			return exitErrorf(ExitFailure, "SSH connection to %q failed", target)
+		}
+		// The remote mita has printed the error.
+		return exitErrorf(exitErr.ExitCode(), "remote command on %q failed with exit code %d", target, exitErr.ExitCode())
+	}
+	return nil
+}
+
+// configPatchToJSON converts the server config file to JSON.
+func configPatchToJSON(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	patch := &appctlpb.ServerConfig{}
+	if err := appctl.UnmarshalConfig(b, patch, appctl.FindConfigPatchFileType(path)); err != nil {
+		return nil, err
+	}
+	return appctl.MarshalConfig(patch, appctl.JSON_CONFIG_FILE_TYPE)
+}
+
+// shellQuote quotes the argument for the POSIX shell of the remote host.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=./:@,+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -16,11 +16,16 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"os"
 	"os/user"
+	"path/filepath"
 	"runtime/pprof"
 	"strconv"
 	"strings"
@@ -33,10 +38,10 @@ import (
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
-	"github.com/enfein/mieru/v3/pkg/egress"
+	"github.com/enfein/mieru/v3/pkg/crash"
+	"github.com/enfein/mieru/v3/pkg/i18n"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
-	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/socks5"
 	"github.com/enfein/mieru/v3/pkg/stderror"
 	"github.com/enfein/mieru/v3/pkg/version/updater"
@@ -50,7 +55,7 @@ func RegisterServerCommands() {
 	RegisterCallback(
 		[]string{"", "help"},
 		func(s []string) error {
-			return unexpectedArgsError(s, 2)
+			return nil
 		},
 		serverHelpFunc,
 	)
@@ -75,6 +80,21 @@ func RegisterServerCommands() {
 		},
 		serverStopFunc,
 	)
+	RegisterCallback(
+		[]string{"", "drain"},
+		func(s []string) error {
+			if len(s) > 3 {
+				return fmt.Errorf("usage: mita drain [SECONDS]. more than 1 argument is provided")
+			}
+			if len(s) == 3 {
+				if n, err := strconv.Atoi(s[2]); err != nil || n <= 0 {
+					return fmt.Errorf("usage: mita drain [SECONDS]. %q is not a positive number", s[2])
+				}
+			}
+			return nil
+		},
+		serverDrainFunc,
+	)
 	RegisterCallback(
 		[]string{"", "reload"},
 		func(s []string) error {
@@ -104,7 +124,8 @@ func RegisterServerCommands() {
 	RegisterCallback(
 		[]string{"", "describe", "config"},
 		func(s []string) error {
-			return unexpectedArgsError(s, 3)
+			_, err := configFormat(s, "mita")
+			return err
 		},
 		serverDescribeConfigFunc,
 	)
@@ -118,6 +139,60 @@ func RegisterServerCommands() {
 		},
 		serverDeleteUserFunc,
 	)
+	RegisterCallback(
+		[]string{"", "add", "user"},
+		func(s []string) error {
+			_, err := parseUserFlags(s, addUserUsage)
+			return err
+		},
+		serverAddUserFunc,
+	)
+	RegisterCallback(
+		[]string{"", "update", "user"},
+		func(s []string) error {
+			_, err := parseUserFlags(s, updateUserUsage)
+			return err
+		},
+		serverUpdateUserFunc,
+	)
+	RegisterCallback(
+		[]string{"", "remove", "user"},
+		func(s []string) error {
+			if len(s) < 4 {
+				return fmt.Errorf("usage: mita remove user <USER_NAME>. no user is provided")
+			}
+			return unexpectedArgsError(s, 4)
+		},
+		serverRemoveUserFunc,
+	)
+	RegisterCallback(
+		[]string{"", "users", "import"},
+		func(s []string) error {
+			if len(s) < 4 {
+				return fmt.Errorf("usage: mita users import <FILE>. no file is provided")
+			}
+			return unexpectedArgsError(s, 4)
+		},
+		serverImportUsersFunc,
+	)
+	RegisterCallback(
+		[]string{"", "users", "export"},
+		func(s []string) error {
+			if len(s) < 4 {
+				return fmt.Errorf("usage: mita users export <FILE>. no file is provided")
+			}
+			return unexpectedArgsError(s, 4)
+		},
+		serverExportUsersFunc,
+	)
+	RegisterCallback(
+		[]string{"", "users", "create-guest"},
+		func(s []string) error {
+			_, err := parseGuestFlags(s)
+			return err
+		},
+		serverCreateGuestFunc,
+	)
 	RegisterCallback(
 		[]string{"", "version"},
 		func(s []string) error {
@@ -146,6 +221,62 @@ func RegisterServerCommands() {
 		},
 		serverGetConnectionsFunc,
 	)
+	RegisterCallback(
+		[]string{"", "get", "route-stats"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		serverGetRouteStatsFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "users"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		serverGetUsersFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "user-stats"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		serverGetUserStatsFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "traffic"},
+		func(s []string) error {
+			return unexpectedArgsError(s, 3)
+		},
+		serverGetTrafficFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "destinations"},
+		func(s []string) error {
+			if len(s) < 4 {
+				return fmt.Errorf("usage: mita get destinations <USER_NAME>. no user is provided")
+			} else if len(s) > 4 {
+				return fmt.Errorf("usage: mita get destinations <USER_NAME>. more than 1 user is provided")
+			}
+			return nil
+		},
+		serverGetDestinationsFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "session-state"},
+		func(s []string) error {
+			_, err := sessionStateRequest(s, "mita")
+			return err
+		},
+		serverGetSessionStateFunc,
+	)
+	RegisterCallback(
+		[]string{"", "get", "logs"},
+		func(s []string) error {
+			_, err := logRequest(s, "mita")
+			return err
+		},
+		serverGetLogsFunc,
+	)
 	RegisterCallback(
 		[]string{"", "get", "thread-dump"},
 		func(s []string) error {
@@ -191,6 +322,10 @@ func RegisterServerCommands() {
 		},
 		serverStopCPUProfileFunc,
 	)
+	registerCompletionCommands()
+	RegisterCompletion([]string{"", "delete", "user"}, serverUserNames)
+	RegisterCompletion([]string{"", "update", "user"}, serverUserNames)
+	RegisterCompletion([]string{"", "remove", "user"}, serverUserNames)
 }
 
 var serverHelpFunc = func(s []string) error {
@@ -198,8 +333,8 @@ var serverHelpFunc = func(s []string) error {
 		appName: "mita",
 		entries: []helpCmdEntry{
 			{
-				cmd:  "help",
-				help: "Show mita server help.",
+				cmd:  "help [COMMAND]",
+				help: "Show mita server help. If a command is provided, only show the help of matching commands.",
 			},
 			{
 				cmd:  "start",
@@ -209,6 +344,10 @@ var serverHelpFunc = func(s []string) error {
 				cmd:  "stop",
 				help: "Stop mita server proxy service.",
 			},
+			{
+				cmd:  "drain [SECONDS]",
+				help: "Stop mita server proxy service after connections finish.",
+			},
 			{
 				cmd:  "reload",
 				help: "Reload mita server configuration without stopping proxy service.",
@@ -219,16 +358,40 @@ var serverHelpFunc = func(s []string) error {
 			},
 			{
 				cmd:  "apply config <FILE>",
-				help: "Apply server configuration from JSON file.",
+				help: "Apply server configuration from JSON, YAML or TOML file.",
 			},
 			{
-				cmd:  "describe config",
-				help: "Show current server configuration.",
+				cmd:  describeConfigUsage,
+				help: "Show current server configuration. The format can be json, yaml or toml, and the default is json.",
 			},
 			{
 				cmd:  "delete user <USER_NAME>",
 				help: "Delete a user from server configuration.",
 			},
+			{
+				cmd:  addUserUsage,
+				help: "Add a user to the running server. A random password is generated if it is not provided.",
+			},
+			{
+				cmd:  updateUserUsage,
+				help: "Change the password of a user, or disable or enable a user, on the running server.",
+			},
+			{
+				cmd:  "remove user <USER_NAME>",
+				help: "Remove a user from the running server and close the connections of the user.",
+			},
+			{
+				cmd:  "users import <FILE>",
+				help: "Add or update users from a CSV or JSON file.",
+			},
+			{
+				cmd:  "users export <FILE>",
+				help: "Save users to a CSV or JSON file.",
+			},
+			{
+				cmd:  "users create-guest --ttl <DURATION> [--host <HOST>]",
+				help: "Create a guest user that expires after the duration, e.g. 24h.",
+			},
 			{
 				cmd:  "get metrics",
 				help: "Get mita server metrics.",
@@ -237,6 +400,10 @@ var serverHelpFunc = func(s []string) error {
 				cmd:  "get connections",
 				help: "Get mita server connections.",
 			},
+			{
+				cmd:  logsUsage,
+				help: "Get the recent log of mita server. With --follow, keep printing the new log. With --level, only print the log at the level or more severe.",
+			},
 			{
 				cmd:  "version",
 				help: "Show mita server version.",
@@ -245,12 +412,24 @@ var serverHelpFunc = func(s []string) error {
 				cmd:  "check update",
 				help: "Check mita server update.",
 			},
+			{
+				cmd:  "completion <SHELL>",
+				help: "Print shell completion script. Supported shells are bash, zsh and fish.",
+			},
 		},
 		advanced: []helpCmdEntry{
 			{
 				cmd:  "run",
 				help: "Run mita server in foreground.",
 			},
+			{
+				cmd:  "--remote <USER@HOST> <COMMAND>",
+				help: "Run the command on a remote mita server with SSH. Input files are read from this computer.",
+			},
+			{
+				cmd:  "get session-state [SESSION_ID]",
+				help: "Get the internal state of mita server sessions for debugging. If the session ID is not provided, all the sessions are dumped.",
+			},
 			{
 				cmd:  "get thread-dump",
 				help: "Get mita server thread dump.",
@@ -263,6 +442,26 @@ var serverHelpFunc = func(s []string) error {
 				cmd:  "get memory-statistics",
 				help: "Get mita server memory statistics.",
 			},
+			{
+				cmd:  "get route-stats",
+				help: "Get mita server egress rule statistics.",
+			},
+			{
+				cmd:  "get users",
+				help: "Get the traffic, quota usage and speed limit of each user of mita server.",
+			},
+			{
+				cmd:  "get user-stats",
+				help: "Get the traffic of each user of mita server.",
+			},
+			{
+				cmd:  "get traffic",
+				help: "Get the bytes and segments transferred by each user and each active session of mita server.",
+			},
+			{
+				cmd:  "get destinations <USER_NAME>",
+				help: "Get the destinations of a user that transfer the most bytes through mita server.",
+			},
 			{
 				cmd:  "profile cpu start <GZ_FILE>",
 				help: "Start mita server CPU profile and save results to the file.",
@@ -273,49 +472,53 @@ var serverHelpFunc = func(s []string) error {
 			},
 		},
 	}
-	helpFmt.print()
-	return nil
+	return helpFmt.print(s[2:])
 }
 
 var serverStartFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 	if err := appctl.IsServerProxyRunning(appStatus); err == nil {
-		log.Infof("mita server proxy is running")
+		log.Infof(i18n.T("mita server proxy is running"))
 		return nil
 	}
 
 	// Start server proxy.
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	_, err = client.Start(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.StartServerProxyFailedErr, err)
+		return i18n.Errorf(stderror.StartServerProxyFailedErr, err)
 	}
-	log.Infof("mita server proxy is started")
+	log.Infof(i18n.T("mita server proxy is started"))
 	return nil
 }
 
 var serverRunFunc = func(s []string) error {
-	if _, found := os.LookupEnv("MITA_LOG_NO_TIMESTAMP"); found {
-		log.SetFormatter(&log.DaemonFormatter{NoTimestamp: true})
-	} else {
-		log.SetFormatter(&log.DaemonFormatter{})
-	}
+	log.SetFormatter(appctl.ServerLogFormatter(appctlpb.LogFormat_DEFAULT_LOG_FORMAT))
 
 	appctl.SetAppStatus(appctlpb.AppStatus_IDLE)
+	appctl.EnableConfigMemoryFallback()
+
+	// Take the proxy sockets passed by systemd socket activation.
+	// They are used by the proxy instead of binding new sockets.
+	if n, err := common.LoadSystemdSockets(); err != nil {
+		log.Warnf("Load sockets from systemd socket activation failed: %v", err)
+	} else if n > 0 {
+		log.Infof("Loaded %d sockets from systemd socket activation", n)
+	}
 
 	var rpcTasks sync.WaitGroup
 	rpcTasks.Add(1)
@@ -332,7 +535,7 @@ var serverRunFunc = func(s []string) error {
 			log.Fatalf("listen on RPC address %q failed: %v", rpcAddr, err)
 		}
 		if _, found := os.LookupEnv("MITA_INSECURE_UDS"); !found {
-			if err = updateServerUDSPermission(); err != nil {
+			if err = updateServerUDSPermission(rpcAddr); err != nil {
 				log.Fatalf("update server unix domain socket permission failed: %v", err)
 			}
 		}
@@ -355,7 +558,7 @@ var serverRunFunc = func(s []string) error {
 	if err != nil {
 		if err == stderror.ErrFileNotExist {
 			if err = appctl.StoreServerConfig(&appctlpb.ServerConfig{}); err != nil {
-				return fmt.Errorf(stderror.CreateEmptyServerConfigFailedErr, err)
+				return i18n.Errorf(stderror.CreateEmptyServerConfigFailedErr, err)
 			}
 		}
 	}
@@ -364,15 +567,20 @@ var serverRunFunc = func(s []string) error {
 	if config == nil {
 		config, err = appctl.LoadServerConfig()
 		if err != nil {
-			return fmt.Errorf(stderror.GetServerConfigFailedErr, err)
+			return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
 		}
 	}
 
-	// Set logging level based on server config.
+	// Set logging level and format based on server config.
 	loggingLevel := config.GetLoggingLevel().String()
 	if loggingLevel != appctlpb.LoggingLevel_DEFAULT.String() {
 		log.SetLevel(loggingLevel)
 	}
+	log.SetFormatter(appctl.ServerLogFormatter(config.GetLogFormat()))
+
+	// Save crash reports if a proxy connection panics.
+	crash.SetReportDir("/var/lib/mita/crash")
+	crash.SetConfigHash(crash.HashConfig(config))
 
 	// Load previous metrics if possible.
 	if err := os.MkdirAll("/var/lib/mita", 0775); err == nil {
@@ -401,34 +609,74 @@ var serverRunFunc = func(s []string) error {
 		log.Infof("TCP congestion control algorithm is %q", algo)
 	}
 
+	// If debug HTTP server is enabled, run it in the background.
+	// It keeps running when the proxy is stopped and started again.
+	if config.GetDebugHttpPort() != 0 {
+		debugServer := metrics.NewDebugHTTPServer(common.MaybeDecorateIPv6(common.LocalIPAddr()) + ":" + strconv.Itoa(int(config.GetDebugHttpPort())))
+		go func() {
+			log.Infof("mita server debug HTTP server is running")
+			if err := debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("run debug HTTP server failed: %v", err)
+			}
+		}()
+	}
+
+	// If management HTTP server is enabled, run it in the background.
+	// It listens to a UNIX domain socket with the same permission as
+	// the RPC server.
+	if config.GetManagementHttp() {
+		managementAddr := appctl.ServerManagementUDS()
+		if err := syscall.Unlink(managementAddr); err != nil {
+			log.Debugf("syscall.Unlink(%q) failed: %v", managementAddr, err)
+		}
+		managementListener, err := net.Listen("unix", managementAddr)
+		if err != nil {
+			log.Fatalf("listen on management HTTP address %q failed: %v", managementAddr, err)
+		}
+		if _, found := os.LookupEnv("MITA_INSECURE_UDS"); !found {
+			if err = updateServerUDSPermission(managementAddr); err != nil {
+				log.Fatalf("update server unix domain socket permission failed: %v", err)
+			}
+		}
+		managementServer := appctl.NewServerManagementHTTPServer()
+		go func() {
+			log.Infof("mita server management HTTP server is running")
+			if err := managementServer.Serve(managementListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Errorf("run management HTTP server failed: %v", err)
+			}
+		}()
+	}
+
+	// Delete expired guest users in the background.
+	go func() {
+		ticker := time.NewTicker(guestCleanInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := appctl.DeleteExpiredGuestUsers(time.Now())
+			if err != nil {
+				log.Warnf("Delete expired guest users failed: %v", err)
+				continue
+			}
+			for _, name := range deleted {
+				log.Infof("Deleted expired guest user %s", name)
+			}
+		}
+	}()
+
 	// Start proxy if server config is valid.
 	if err = appctl.ValidateFullServerConfig(config); err == nil {
 		appctl.SetAppStatus(appctlpb.AppStatus_STARTING)
 
-		mux := protocol.NewMux(false).SetServerUsers(appctl.UserListToMap(config.GetUsers()))
-		appctl.SetServerMuxRef(mux)
-		mtu := common.DefaultMTU
-		if config.GetMtu() != 0 {
-			mtu = int(config.GetMtu())
-		}
-		endpoints, err := appctl.PortBindingsToUnderlayProperties(config.GetPortBindings(), mtu)
+		mux, err := appctl.NewServerMux(config)
 		if err != nil {
 			return err
 		}
-		mux.SetEndpoints(endpoints)
+		appctl.SetServerMuxRef(mux)
 
 		// Create the egress socks5 server.
-		socks5Config := &socks5.Config{
-			AllowLocalDestination: config.GetAdvancedSettings().GetAllowLocalDestination(),
-			AuthOpts: socks5.Auth{
-				ClientSideAuthentication: true,
-			},
-			EgressController: egress.NewSocks5Controller(config.GetEgress()),
-			HandshakeTimeout: 10 * time.Second,
-		}
-		socks5Server, err := socks5.New(socks5Config)
+		socks5Server, err := appctl.NewServerSocks5(config)
 		if err != nil {
-			return fmt.Errorf(stderror.CreateSocks5ServerFailedErr, err)
+			return i18n.Errorf(stderror.CreateSocks5ServerFailedErr, err)
 		}
 		appctl.SetSocks5Server(socks5Server)
 
@@ -454,13 +702,22 @@ var serverRunFunc = func(s []string) error {
 		initProxyTasks.Wait()
 		metrics.EnableLogging()
 		appctl.SetAppStatus(appctlpb.AppStatus_RUNNING)
+		notifySystemdReady()
 		proxyTasks.Wait()
+	} else {
+		// If fails to validate server configuration, do nothing.
+		// Most likely the server configuration is empty.
+		// It will be set by new RPC calls.
+		notifySystemdReady()
 	}
-	// If fails to validate server configuration, do nothing.
-	// Most likely the server configuration is empty.
-	// It will be set by new RPC calls.
 
 	rpcTasks.Wait()
+	if err := common.SdNotify(common.SdNotifyStopping); err != nil {
+		log.Debugf("SdNotify() failed: %v", err)
+	}
+
+	// Send the spans that are not exported yet.
+	appctl.CloseTracer()
 
 	// Stop CPU profiling, if previously started.
 	pprof.StopCPUProfile()
@@ -469,65 +726,127 @@ var serverRunFunc = func(s []string) error {
 	return nil
 }
 
+// notifySystemdReady tells systemd that mita server daemon is ready,
+// and sends watchdog keep-alive notifications in the background
+// if systemd watchdog is enabled.
+func notifySystemdReady() {
+	if err := common.SdNotify(common.SdNotifyReady); err != nil {
+		log.Warnf("Notify systemd ready failed: %v", err)
+		return
+	}
+	interval := common.SdWatchdogInterval()
+	if interval == 0 {
+		return
+	}
+	go func() {
+		// Send keep-alive twice in each interval, as suggested by systemd.
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := appctl.GetServerStatusWithRPC(context.Background()); err != nil {
+				log.Warnf("Skip systemd watchdog keep-alive: %v", err)
+				continue
+			}
+			if err := common.SdNotify(common.SdNotifyWatchdog); err != nil {
+				log.Debugf("SdNotify() failed: %v", err)
+			}
+		}
+	}()
+}
+
 var serverStopFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 	if err := appctl.IsServerProxyRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerProxyNotRunningErr, err)
+		return i18n.Errorf(stderror.ServerProxyNotRunningErr, err)
 	}
 
 	// Stop server proxy.
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	if _, err = client.Stop(timedctx, &appctlpb.Empty{}); err != nil {
-		return fmt.Errorf(stderror.StopServerProxyFailedErr, err)
+		return i18n.Errorf(stderror.StopServerProxyFailedErr, err)
+	}
+	log.Infof(i18n.T("mita server proxy is stopped"))
+	return nil
+}
+
+var serverDrainFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+	if err := appctl.IsServerProxyRunning(appStatus); err != nil {
+		return i18n.Errorf(stderror.ServerProxyNotRunningErr, err)
+	}
+	timeout := appctl.DefaultDrainTimeout
+	if len(s) == 3 {
+		seconds, _ := strconv.Atoi(s[2])
+		timeout = time.Duration(seconds) * time.Second
 	}
-	log.Infof("mita server proxy is stopped")
+
+	// Drain server proxy.
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), timeout+appctl.RPCTimeout)
+	defer cancelFunc()
+	if _, err = client.Drain(timedctx, &appctlpb.DrainRequest{TimeoutSeconds: proto.Int32(int32(timeout / time.Second))}); err != nil {
+		return i18n.Errorf(stderror.DrainServerProxyFailedErr, err)
+	}
+	log.Infof(i18n.T("mita server proxy is drained"))
 	return nil
 }
 
 var serverReloadFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	if _, err = client.Reload(timedctx, &appctlpb.Empty{}); err != nil {
-		return fmt.Errorf(stderror.ReloadServerFailedErr, err)
+		return i18n.Errorf(stderror.ReloadServerFailedErr, err)
 	}
-	log.Infof("mita server is reloaded")
+	log.Infof(i18n.T("mita server is reloaded"))
 	return nil
 }
 
 var serverStatusFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		} else if stderror.IsPermissionDenied(err) {
 			currentUser, err := user.Current()
 			if err != nil {
@@ -536,30 +855,31 @@ var serverStatusFunc = func(s []string) error {
 			}
 			return fmt.Errorf("unable to connect to mita server daemon via %q; please retry after running \"sudo usermod -a -G mita %s\" command and logout the system, then login again", appctl.ServerUDS(), currentUser.Username)
 		} else {
-			return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+			return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 		}
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 	if err := appctl.IsServerProxyRunning(appStatus); err != nil {
 		log.Infof("%s", err.Error())
 	} else {
-		log.Infof("mita server status is %q", appctlpb.AppStatus_RUNNING.String())
+		log.Infof(i18n.T("mita server status is %q"), appctlpb.AppStatus_RUNNING.String())
 	}
+	printStorageHealth(appStatus)
 	return nil
 }
 
 var serverApplyConfigFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	path := s[3]
@@ -568,22 +888,26 @@ var serverApplyConfigFunc = func(s []string) error {
 		return fmt.Errorf("os.ReadFile(%q) failed: %w", path, err)
 	}
 	patch := &appctlpb.ServerConfig{}
-	if err = common.UnmarshalJSON(b, patch); err != nil {
-		return fmt.Errorf("common.UnmarshalJSON() failed: %w", err)
+	if err = appctl.UnmarshalConfig(b, patch, appctl.FindConfigPatchFileType(path)); err != nil {
+		return withExitCode(ExitConfigInvalid, err)
 	}
 	if err := appctl.ValidateServerConfigPatch(patch); err != nil {
-		return fmt.Errorf(stderror.ValidateServerConfigPatchFailedErr, err)
+		return exitErrorf(ExitConfigInvalid, stderror.ValidateServerConfigPatchFailedErr, err)
 	}
 
 	client, err := appctl.NewServerConfigRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	_, err = client.SetConfig(timedctx, patch)
 	if err != nil {
-		return fmt.Errorf(stderror.SetServerConfigFailedErr, err)
+		return i18n.Errorf(stderror.SetServerConfigFailedErr, err)
+	}
+	// The server keeps the config in memory if the disk is not writable.
+	if appStatus, err = appctl.GetServerStatusWithRPC(context.Background()); err == nil {
+		printStorageHealth(appStatus)
 	}
 	return nil
 }
@@ -591,54 +915,55 @@ var serverApplyConfigFunc = func(s []string) error {
 var serverDescribeConfigFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerConfigRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	config, err := client.GetConfig(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
 	}
-	jsonBytes, err := common.MarshalJSON(config)
+	fileType, _ := configFormat(s, "mita")
+	b, err := appctl.MarshalConfig(config, fileType)
 	if err != nil {
-		return fmt.Errorf("common.MarshalJSON() failed: %w", err)
+		return err
 	}
-	log.Infof("%s", string(jsonBytes))
+	log.Infof("%s", string(b))
 	return nil
 }
 
 var serverDeleteUserFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerConfigRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	config, err := client.GetConfig(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerConfigFailedErr, err)
+		return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
 	}
 	users := config.GetUsers()
 	remaining := make([]*appctlpb.User, 0)
@@ -657,7 +982,397 @@ var serverDeleteUserFunc = func(s []string) error {
 	config.Users = remaining
 	_, err = client.SetConfig(timedctx, config)
 	if err != nil {
-		return fmt.Errorf(stderror.SetServerConfigFailedErr, err)
+		return i18n.Errorf(stderror.SetServerConfigFailedErr, err)
+	}
+	return nil
+}
+
+var serverImportUsersFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	path := s[3]
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("os.ReadFile(%q) failed: %w", path, err)
+	}
+	users, err := appctl.ReadUsers(b)
+	if err != nil {
+		return withExitCode(ExitConfigInvalid, fmt.Errorf("ReadUsers() failed: %w", err))
+	}
+	generated := make([]*appctlpb.User, 0)
+	for _, user := range users {
+		if user.GetPassword() == "" && user.GetHashedPassword() == "" {
+			password, err := appctl.GenerateUserPassword()
+			if err != nil {
+				return err
+			}
+			user.Password = proto.String(password)
+			generated = append(generated, proto.Clone(user).(*appctlpb.User))
+		}
+	}
+
+	client, err := appctl.NewServerConfigRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	config, err := client.GetConfig(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
+	}
+	if err := appctl.ImportServerUsers(config, users); err != nil {
+		return withExitCode(ExitConfigInvalid, err)
+	}
+	if _, err = client.SetConfig(timedctx, config); err != nil {
+		return i18n.Errorf(stderror.SetServerConfigFailedErr, err)
+	}
+	log.Infof(i18n.T("%d users are imported"), len(users))
+	if len(generated) > 0 {
+		// The server only stores hashed passwords, so the generated
+		// passwords are printed once.
+		var sb strings.Builder
+		if err := appctl.WriteUsersCSV(&sb, generated); err != nil {
+			return err
+		}
+		log.Infof(i18n.T("Generated passwords:"))
+		log.Infof("%s", strings.TrimSpace(sb.String()))
+	}
+	return nil
+}
+
+var serverExportUsersFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	path := s[3]
+	var write func(io.Writer, []*appctlpb.User) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		write = appctl.WriteUsersCSV
+	case ".json":
+		write = appctl.WriteUsersJSON
+	default:
+		return exitErrorf(ExitUsage, "file %q must have .csv or .json extension", path)
+	}
+
+	client, err := appctl.NewServerConfigRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	config, err := client.GetConfig(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, config.GetUsers()); err != nil {
+		return err
+	}
+	// The file contains hashed passwords.
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("os.WriteFile(%q) failed: %w", path, err)
+	}
+	log.Infof(i18n.T("%d users are exported to %q"), len(config.GetUsers()), path)
+	return nil
+}
+
+// guestCleanInterval is the interval to delete expired guest users.
+const guestCleanInterval = time.Minute
+
+// guestFlags are the options of "users create-guest" command.
+type guestFlags struct {
+	ttl  time.Duration
+	host string
+}
+
+// parseGuestFlags parses the options of "users create-guest" command.
+// An option and its value are separated by a space or "=".
+func parseGuestFlags(s []string) (guestFlags, error) {
+	var flags guestFlags
+	for i := 3; i < len(s); i++ {
+		name, value, found := strings.Cut(s[i], "=")
+		if !found {
+			if i+1 >= len(s) {
+				return flags, fmt.Errorf("usage: mita users create-guest --ttl <DURATION> [--host <HOST>]. %s has no value", name)
+			}
+			i++
+			value = s[i]
+		}
+		switch name {
+		case "--ttl":
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return flags, fmt.Errorf("invalid duration %q: %w", value, err)
+			}
+			if ttl <= 0 {
+				return flags, fmt.Errorf("duration %q is not positive", value)
+			}
+			flags.ttl = ttl
+		case "--host":
+			flags.host = value
+		default:
+			return flags, fmt.Errorf("usage: mita users create-guest --ttl <DURATION> [--host <HOST>]. unknown option %s", name)
+		}
+	}
+	if flags.ttl == 0 {
+		return flags, fmt.Errorf("usage: mita users create-guest --ttl <DURATION> [--host <HOST>]. --ttl is not provided")
+	}
+	return flags, nil
+}
+
+var serverCreateGuestFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	flags, err := parseGuestFlags(s)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	guest, err := appctl.NewGuestUser(flags.ttl, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := appctl.NewServerConfigRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	config, err := client.GetConfig(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
+	}
+	var clientURL string
+	if flags.host != "" {
+		clientConfig, err := appctl.GuestClientConfig(config, guest, flags.host)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		if clientURL, err = appctl.ClientConfigToURL(clientConfig); err != nil {
+			return err
+		}
+	}
+	if err := appctl.ImportServerUsers(config, []*appctlpb.User{proto.Clone(guest).(*appctlpb.User)}); err != nil {
+		return withExitCode(ExitConfigInvalid, err)
+	}
+	if _, err = client.SetConfig(timedctx, config); err != nil {
+		return i18n.Errorf(stderror.SetServerConfigFailedErr, err)
+	}
+
+	// Let the running proxy accept the guest user.
+	if err := appctl.IsServerProxyRunning(appStatus); err == nil {
+		lifecycleClient, err := appctl.NewServerLifecycleRPCClient()
+		if err != nil {
+			return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		}
+		if _, err = lifecycleClient.Reload(timedctx, &appctlpb.Empty{}); err != nil {
+			return i18n.Errorf(stderror.ReloadServerFailedErr, err)
+		}
+	}
+
+	log.Infof(i18n.T("Guest user: %s"), guest.GetName())
+	log.Infof(i18n.T("Password: %s"), guest.GetPassword())
+	log.Infof(i18n.T("Expire time: %s"), guest.GetExpireTime())
+	if clientURL != "" {
+		log.Infof(i18n.T("Client URL: %s"), clientURL)
+	}
+	return nil
+}
+
+const (
+	addUserUsage    = "add user <USER_NAME> [--password <PASSWORD>]"
+	updateUserUsage = "update user <USER_NAME> [--password <PASSWORD>] [--disable | --enable]"
+)
+
+// userFlags are the options of "add user" and "update user" commands.
+type userFlags struct {
+	name     string
+	password string
+	disable  bool
+	enable   bool
+}
+
+// parseUserFlags parses the options of "add user" and "update user" commands.
+// An option and its value are separated by a space or "=".
+func parseUserFlags(s []string, usage string) (userFlags, error) {
+	var flags userFlags
+	if len(s) < 4 {
+		return flags, fmt.Errorf("usage: mita %s. no user is provided", usage)
+	}
+	flags.name = s[3]
+	for i := 4; i < len(s); i++ {
+		name, value, found := strings.Cut(s[i], "=")
+		switch name {
+		case "--password":
+			if !found {
+				if i+1 >= len(s) {
+					return flags, fmt.Errorf("usage: mita %s. %s has no value", usage, name)
+				}
+				i++
+				value = s[i]
+			}
+			if value == "" {
+				return flags, fmt.Errorf("usage: mita %s. password is empty", usage)
+			}
+			flags.password = value
+		case "--disable", "--enable":
+			if usage != updateUserUsage || found {
+				return flags, fmt.Errorf("usage: mita %s. unknown option %s", usage, s[i])
+			}
+			flags.disable = flags.disable || name == "--disable"
+			flags.enable = flags.enable || name == "--enable"
+		default:
+			return flags, fmt.Errorf("usage: mita %s. unknown option %s", usage, name)
+		}
+	}
+	if flags.disable && flags.enable {
+		return flags, fmt.Errorf("usage: mita %s. --disable and --enable can't be used together", usage)
+	}
+	if usage == updateUserUsage && flags.password == "" && !flags.disable && !flags.enable {
+		return flags, fmt.Errorf("usage: mita %s. nothing to update", usage)
+	}
+	return flags, nil
+}
+
+var serverAddUserFunc = func(s []string) error {
+	if err := checkServerDaemonRunning(); err != nil {
+		return err
+	}
+	flags, err := parseUserFlags(s, addUserUsage)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	password := flags.password
+	if password == "" {
+		if password, err = appctl.GenerateUserPassword(); err != nil {
+			return err
+		}
+	}
+
+	client, err := appctl.NewServerConfigRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	user := &appctlpb.User{
+		Name:     proto.String(flags.name),
+		Password: proto.String(password),
+	}
+	if _, err = client.AddUser(timedctx, user); err != nil {
+		return i18n.Errorf(stderror.AddUserFailedErr, err)
+	}
+	log.Infof(i18n.T("User %q is added"), flags.name)
+	if flags.password == "" {
+		log.Infof(i18n.T("Password: %s"), password)
+	}
+	return nil
+}
+
+var serverUpdateUserFunc = func(s []string) error {
+	if err := checkServerDaemonRunning(); err != nil {
+		return err
+	}
+	flags, err := parseUserFlags(s, updateUserUsage)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	client, err := appctl.NewServerConfigRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	config, err := client.GetConfig(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetServerConfigFailedErr, err)
+	}
+	var user *appctlpb.User
+	for _, u := range config.GetUsers() {
+		if u.GetName() == flags.name {
+			user = u
+			break
+		}
+	}
+	if user == nil {
+		return withExitCode(ExitUsage, fmt.Errorf("user %q is not found", flags.name))
+	}
+	if flags.password != "" {
+		// The hashed password is derived from the new password.
+		user.Password = proto.String(flags.password)
+		user.HashedPassword = nil
+	}
+	if flags.disable {
+		user.Disabled = proto.Bool(true)
+	}
+	if flags.enable {
+		user.Disabled = nil
+	}
+	if _, err = client.UpdateUser(timedctx, user); err != nil {
+		return i18n.Errorf(stderror.UpdateUserFailedErr, err)
+	}
+	log.Infof(i18n.T("User %q is updated"), flags.name)
+	return nil
+}
+
+var serverRemoveUserFunc = func(s []string) error {
+	if err := checkServerDaemonRunning(); err != nil {
+		return err
+	}
+
+	client, err := appctl.NewServerConfigRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerConfigRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	if _, err = client.RemoveUser(timedctx, &appctlpb.User{Name: proto.String(s[3])}); err != nil {
+		return i18n.Errorf(stderror.RemoveUserFailedErr, err)
+	}
+	log.Infof(i18n.T("User %q is removed"), s[3])
+	return nil
+}
+
+// checkServerDaemonRunning returns an error if mita server daemon is not running.
+func checkServerDaemonRunning() error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 	return nil
 }
@@ -674,24 +1389,24 @@ var serverCheckUpdateFunc = func(s []string) error {
 var serverGetMetricsFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	metrics, err := client.GetMetrics(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetMetricsFailedErr, err)
+		return i18n.Errorf(stderror.GetMetricsFailedErr, err)
 	}
 	log.Infof("%s", metrics.GetJson())
 	return nil
@@ -700,24 +1415,24 @@ var serverGetMetricsFunc = func(s []string) error {
 var serverGetConnectionsFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		if stderror.IsConnRefused(err) {
-			return fmt.Errorf(stderror.ServerNotRunningWithCommand)
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
 		}
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	info, err := client.GetSessionInfo(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetConnectionsFailedErr, err)
+		return i18n.Errorf(stderror.GetConnectionsFailedErr, err)
 	}
 	for _, line := range info.GetTable() {
 		log.Infof("%s", line)
@@ -725,24 +1440,340 @@ var serverGetConnectionsFunc = func(s []string) error {
 	return nil
 }
 
+var serverGetRouteStatsFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	stats, err := client.GetRouteStats(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetRouteStatsFailedErr, err)
+	}
+	rules := [][]string{{"RuleID", "Action", "Proxy", "Matches"}}
+	for _, rule := range stats.GetRules() {
+		rules = append(rules, []string{
+			fmt.Sprintf("%d", rule.GetRuleID()),
+			rule.GetAction().String(),
+			rule.GetProxyName(),
+			fmt.Sprintf("%d", rule.GetMatches()),
+		})
+	}
+	for _, line := range formatTable(rules) {
+		log.Infof("%s", line)
+	}
+	if len(stats.GetRecentDecisions()) == 0 {
+		return nil
+	}
+	log.Infof("")
+	decisions := [][]string{{"Time", "Destination", "Action", "RuleID"}}
+	for _, d := range stats.GetRecentDecisions() {
+		decisions = append(decisions, []string{
+			d.GetTime(),
+			d.GetDestination(),
+			d.GetAction().String(),
+			fmt.Sprintf("%d", d.GetRuleID()),
+		})
+	}
+	for _, line := range formatTable(decisions) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+var serverGetUsersFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	usages, err := client.GetUsers(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetUsersFailedErr, err)
+	}
+	rows := [][]string{{"User", "Upload", "Download", "Quotas", "RateLimit", "ExpireTime"}}
+	for _, user := range usages.GetUsers() {
+		quotas := make([]string, 0, len(user.GetQuotas()))
+		for _, quota := range user.GetQuotas() {
+			period := fmt.Sprintf("%d days", quota.GetQuota().GetDays())
+			if quota.GetQuota().GetMonthly() {
+				period = "month"
+			}
+			quotas = append(quotas, fmt.Sprintf("%d/%d MB per %s", quota.GetUsedBytes()/1048576, quota.GetQuota().GetMegabytes(), period))
+		}
+		rateLimit := "-"
+		if user.GetRateLimit() != nil {
+			rateLimit = fmt.Sprintf("up %s, down %s", formatRateLimit(user.GetRateLimit().GetUploadKilobytesPerSecond()), formatRateLimit(user.GetRateLimit().GetDownloadKilobytesPerSecond()))
+		}
+		expireTime := "-"
+		if user.GetExpireTime() != "" {
+			expireTime = user.GetExpireTime()
+		}
+		quotaText := "-"
+		if len(quotas) > 0 {
+			quotaText = strings.Join(quotas, "; ")
+		}
+		rows = append(rows, []string{
+			user.GetUserName(),
+			fmt.Sprintf("%d", user.GetUploadBytes()),
+			fmt.Sprintf("%d", user.GetDownloadBytes()),
+			quotaText,
+			rateLimit,
+			expireTime,
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+// formatRateLimit returns the speed limit of one direction.
+func formatRateLimit(kilobytesPerSecond int32) string {
+	if kilobytesPerSecond == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d KB/s", kilobytesPerSecond)
+}
+
+var serverGetUserStatsFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	stats, err := client.GetUserStats(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetUserStatsFailedErr, err)
+	}
+	rows := [][]string{{"User", "Connections", "Upload", "Download", "Destinations", "LastSeen"}}
+	for _, user := range stats.GetUsers() {
+		rows = append(rows, []string{
+			user.GetUserName(),
+			fmt.Sprintf("%d", user.GetConnections()),
+			fmt.Sprintf("%d", user.GetUploadBytes()),
+			fmt.Sprintf("%d", user.GetDownloadBytes()),
+			fmt.Sprintf("%d", user.GetDestinations()),
+			user.GetLastSeen(),
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+var serverGetTrafficFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	traffic, err := client.GetTraffic(timedctx, &appctlpb.Empty{})
+	if err != nil {
+		return i18n.Errorf(stderror.GetTrafficFailedErr, err)
+	}
+	rows := [][]string{{"User", "Sessions", "UploadBytes", "DownloadBytes", "UploadSegments", "DownloadSegments"}}
+	for _, user := range traffic.GetUsers() {
+		rows = append(rows, []string{
+			user.GetUserName(),
+			fmt.Sprintf("%d", user.GetSessions()),
+			fmt.Sprintf("%d", user.GetUploadBytes()),
+			fmt.Sprintf("%d", user.GetDownloadBytes()),
+			fmt.Sprintf("%d", user.GetUploadSegments()),
+			fmt.Sprintf("%d", user.GetDownloadSegments()),
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	if len(traffic.GetSessions()) == 0 {
+		return nil
+	}
+	log.Infof("")
+	rows = [][]string{{"SessionID", "User", "UploadBytes", "DownloadBytes", "UploadSegments", "DownloadSegments"}}
+	for _, session := range traffic.GetSessions() {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", session.GetSessionID()),
+			session.GetUserName(),
+			fmt.Sprintf("%d", session.GetUploadBytes()),
+			fmt.Sprintf("%d", session.GetDownloadBytes()),
+			fmt.Sprintf("%d", session.GetUploadSegments()),
+			fmt.Sprintf("%d", session.GetDownloadSegments()),
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+var serverGetDestinationsFunc = func(s []string) error {
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		if stderror.IsConnRefused(err) || stderror.IsNoSuchFile(err) {
+			return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningWithCommand)
+		}
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	stats, err := client.GetUserDestinationStats(timedctx, &appctlpb.UserDestinationStatsRequest{
+		UserName: proto.String(s[3]),
+	})
+	if err != nil {
+		return i18n.Errorf(stderror.GetDestinationStatsFailedErr, err)
+	}
+	rows := [][]string{{"Destination", "Connections", "Upload", "Download", "LastSeen"}}
+	for _, stat := range stats.GetDestinations() {
+		rows = append(rows, []string{
+			stat.GetDestination(),
+			fmt.Sprintf("%d", stat.GetConnections()),
+			fmt.Sprintf("%d", stat.GetUploadBytes()),
+			fmt.Sprintf("%d", stat.GetDownloadBytes()),
+			stat.GetLastSeen(),
+		})
+	}
+	for _, line := range formatTable(rows) {
+		log.Infof("%s", line)
+	}
+	return nil
+}
+
+var serverGetSessionStateFunc = func(s []string) error {
+	req, err := sessionStateRequest(s, "mita")
+	if err != nil {
+		return err
+	}
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
+	defer cancelFunc()
+	states, err := client.GetSessionStates(timedctx, req)
+	if err != nil {
+		return i18n.Errorf(stderror.GetSessionStatesFailedErr, err)
+	}
+	log.Infof("%s", states.GetJson())
+	return nil
+}
+
+var serverGetLogsFunc = func(s []string) error {
+	req, err := logRequest(s, "mita")
+	if err != nil {
+		return err
+	}
+	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
+	if err != nil {
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
+	}
+	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
+	}
+
+	client, err := appctl.NewServerLifecycleRPCClient()
+	if err != nil {
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+	}
+	ctx := context.Background()
+	if !req.GetFollow() {
+		var cancelFunc context.CancelFunc
+		ctx, cancelFunc = context.WithTimeout(ctx, appctl.RPCTimeout)
+		defer cancelFunc()
+	}
+	stream, err := client.GetLogs(ctx, req)
+	if err != nil {
+		return i18n.Errorf(stderror.GetLogsFailedErr, err)
+	}
+	if err := printLogs(stream.Recv); err != nil {
+		return i18n.Errorf(stderror.GetLogsFailedErr, err)
+	}
+	return nil
+}
+
 var serverGetThreadDumpFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	dump, err := client.GetThreadDump(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetThreadDumpFailedErr, err)
+		return i18n.Errorf(stderror.GetThreadDumpFailedErr, err)
 	}
 	log.Infof("%s", dump.GetThreadDump())
 	return nil
@@ -751,43 +1782,43 @@ var serverGetThreadDumpFunc = func(s []string) error {
 var serverGetHeapProfileFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	if _, err := client.GetHeapProfile(timedctx, &appctlpb.ProfileSavePath{FilePath: proto.String(s[3])}); err != nil {
-		return fmt.Errorf(stderror.GetHeapProfileFailedErr, err)
+		return i18n.Errorf(stderror.GetHeapProfileFailedErr, err)
 	}
-	log.Infof("heap profile is saved to %q", s[3])
+	log.Infof(i18n.T("heap profile is saved to %q"), s[3])
 	return nil
 }
 
 var serverGetMemoryStatisticsFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	memStats, err := client.GetMemoryStatistics(timedctx, &appctlpb.Empty{})
 	if err != nil {
-		return fmt.Errorf(stderror.GetMemoryStatisticsFailedErr, err)
+		return i18n.Errorf(stderror.GetMemoryStatisticsFailedErr, err)
 	}
 	log.Infof("%s", memStats.GetJson())
 	return nil
@@ -796,37 +1827,37 @@ var serverGetMemoryStatisticsFunc = func(s []string) error {
 var serverStartCPUProfileFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
 	if _, err := client.StartCPUProfile(timedctx, &appctlpb.ProfileSavePath{FilePath: proto.String(s[4])}); err != nil {
-		return fmt.Errorf(stderror.StartCPUProfileFailedErr, err)
+		return i18n.Errorf(stderror.StartCPUProfileFailedErr, err)
 	}
-	log.Infof("CPU profile will be saved to %q", s[4])
+	log.Infof(i18n.T("CPU profile will be saved to %q"), s[4])
 	return nil
 }
 
 var serverStopCPUProfileFunc = func(s []string) error {
 	appStatus, err := appctl.GetServerStatusWithRPC(context.Background())
 	if err != nil {
-		return fmt.Errorf(stderror.GetServerStatusFailedErr, err)
+		return i18n.Errorf(stderror.GetServerStatusFailedErr, err)
 	}
 	if err := appctl.IsServerDaemonRunning(appStatus); err != nil {
-		return fmt.Errorf(stderror.ServerNotRunningErr, err)
+		return exitErrorf(ExitDaemonNotRunning, stderror.ServerNotRunningErr, err)
 	}
 
 	client, err := appctl.NewServerLifecycleRPCClient()
 	if err != nil {
-		return fmt.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
+		return i18n.Errorf(stderror.CreateServerLifecycleRPCClientFailedErr, err)
 	}
 	timedctx, cancelFunc := context.WithTimeout(context.Background(), appctl.RPCTimeout)
 	defer cancelFunc()
@@ -835,7 +1866,7 @@ var serverStopCPUProfileFunc = func(s []string) error {
 }
 
 // Update server unix domain socket permission to 770, belongs to root:mita.
-func updateServerUDSPermission() error {
+func updateServerUDSPermission(path string) error {
 	rootUidStr, err := getUid("root")
 	if err != nil {
 		return fmt.Errorf("getUid(%q) failed: %w", "root", err)
@@ -852,11 +1883,11 @@ func updateServerUDSPermission() error {
 	if err != nil {
 		return fmt.Errorf("convert mita UID with strconv.Atoi(%q) failed: %w", mitaGidStr, err)
 	}
-	if err = os.Chown(appctl.ServerUDS(), rootUid, mitaGid); err != nil {
-		return fmt.Errorf("os.Chown(%q) failed: %w", appctl.ServerUDS(), err)
+	if err = os.Chown(path, rootUid, mitaGid); err != nil {
+		return fmt.Errorf("os.Chown(%q) failed: %w", path, err)
 	}
-	if err = os.Chmod(appctl.ServerUDS(), 0770); err != nil {
-		return fmt.Errorf("os.Chmod(%q) failed: %w", appctl.ServerUDS(), err)
+	if err = os.Chmod(path, 0770); err != nil {
+		return fmt.Errorf("os.Chmod(%q) failed: %w", path, err)
 	}
 	return nil
 }
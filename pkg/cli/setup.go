@@ -0,0 +1,343 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/i18n"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// setupCheckTimeout is the maximum time of each connectivity check.
+	setupCheckTimeout = 5 * time.Second
+
+	setupTransportTCP = "TCP"
+	setupTransportUDP = "UDP"
+	setupTransportTLS = "TLS"
+
+	setupPresetNone = "none"
+)
+
+var clientSetupFunc = func(s []string) error {
+	return newSetupWizard(os.Stdin, os.Stdout).run()
+}
+
+// setupWizard asks the user questions to create a client profile.
+type setupWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newSetupWizard(in io.Reader, out io.Writer) *setupWizard {
+	return &setupWizard{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+func (w *setupWizard) run() error {
+	config, err := appctl.LoadClientConfig()
+	if err == stderror.ErrFileNotExist {
+		config = &appctlpb.ClientConfig{}
+		if err = appctl.StoreClientConfig(config); err != nil {
+			return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
+		}
+	} else if err != nil {
+		return i18n.Errorf(stderror.GetClientConfigFailedErr, err)
+	}
+
+	w.printf("This wizard creates a client profile step by step. Press Enter to accept the default value in brackets.")
+	w.printf("")
+	patch, err := w.askConfig(config)
+	if err != nil {
+		return err
+	}
+
+	w.printf("")
+	b, err := common.MarshalJSON(patch)
+	if err != nil {
+		return fmt.Errorf("common.MarshalJSON() failed: %w", err)
+	}
+	fmt.Fprintln(w.out, string(b))
+	save, err := w.confirm("Save the profile above?")
+	if err != nil {
+		return err
+	}
+	if !save {
+		return i18n.Errorf("Setup is canceled.")
+	}
+	if err := appctl.ApplyClientConfig(patch); err != nil {
+		return i18n.Errorf(stderror.StoreClientConfigFailedErr, err)
+	}
+	w.printf("Profile %q is saved.", patch.GetActiveProfile())
+	w.printf("Run \"mieru start\" to start the client, and \"mieru test\" to check the connection.")
+	return nil
+}
+
+// askConfig returns a client config patch that contains a new profile,
+// which is also the active profile.
+func (w *setupWizard) askConfig(config *appctlpb.ClientConfig) (*appctlpb.ClientConfig, error) {
+	profileName, err := w.ask("Profile name", "default", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var server *appctlpb.ServerEndpoint
+	var tlsConfig *appctlpb.ClientTLSConfig
+	for server == nil {
+		host, err := w.ask("Proxy server IP address or domain name", "", nil)
+		if err != nil {
+			return nil, err
+		}
+		transport, err := w.ask("Transport protocol (TCP, UDP or TLS)", setupTransportTCP, validateSetupTransport)
+		if err != nil {
+			return nil, err
+		}
+		transport = strings.ToUpper(transport)
+		port, err := w.ask("Proxy server port", "", validateSetupPort(1))
+		if err != nil {
+			return nil, err
+		}
+		var sni string
+		if transport == setupTransportTLS {
+			defaultSNI := ""
+			if net.ParseIP(host) == nil {
+				defaultSNI = host
+			}
+			if sni, err = w.ask("TLS server name of the proxy server", defaultSNI, nil); err != nil {
+				return nil, err
+			}
+		}
+
+		ok, err := w.checkServer(host, port, transport, sni)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		portNumber, _ := strconv.Atoi(port)
+		protocol := appctlpb.TransportProtocol_TCP
+		if transport == setupTransportUDP {
+			protocol = appctlpb.TransportProtocol_UDP
+		}
+		server = &appctlpb.ServerEndpoint{
+			PortBindings: []*appctlpb.PortBinding{
+				{
+					Port:     proto.Int32(int32(portNumber)),
+					Protocol: protocol.Enum(),
+				},
+			},
+		}
+		if net.ParseIP(host) != nil {
+			server.IpAddress = proto.String(host)
+		} else {
+			server.DomainName = proto.String(host)
+		}
+		if transport == setupTransportTLS {
+			tlsConfig = &appctlpb.ClientTLSConfig{Sni: proto.String(sni)}
+		}
+	}
+
+	userName, err := w.ask("User name", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	password, err := w.ask("Password", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	presetName, err := w.ask("Preset (none, balanced, low-latency, low-bandwidth or paranoid)", setupPresetNone, validateSetupPreset)
+	if err != nil {
+		return nil, err
+	}
+	var preset *appctlpb.ProfilePreset
+	if !strings.EqualFold(presetName, setupPresetNone) {
+		p, _ := appctl.ParsePreset(presetName)
+		preset = p.Enum()
+	}
+
+	socks5Port := "1080"
+	if config.GetSocks5Port() != 0 {
+		socks5Port = strconv.Itoa(int(config.GetSocks5Port()))
+	}
+	if socks5Port, err = w.ask("Local socks5 proxy port", socks5Port, validateSetupPort(1025)); err != nil {
+		return nil, err
+	}
+	rpcPort := "8964"
+	if config.GetRpcPort() != 0 {
+		rpcPort = strconv.Itoa(int(config.GetRpcPort()))
+	}
+	if rpcPort, err = w.ask("Local RPC port", rpcPort, validateSetupPort(1025)); err != nil {
+		return nil, err
+	}
+	socks5PortNumber, _ := strconv.Atoi(socks5Port)
+	rpcPortNumber, _ := strconv.Atoi(rpcPort)
+
+	return &appctlpb.ClientConfig{
+		Profiles: []*appctlpb.ClientProfile{
+			{
+				ProfileName: proto.String(profileName),
+				User: &appctlpb.User{
+					Name:     proto.String(userName),
+					Password: proto.String(password),
+				},
+				Servers: []*appctlpb.ServerEndpoint{server},
+				Tls:     tlsConfig,
+				Preset:  preset,
+			},
+		},
+		ActiveProfile: proto.String(profileName),
+		Socks5Port:    proto.Int32(int32(socks5PortNumber)),
+		RpcPort:       proto.Int32(int32(rpcPortNumber)),
+	}, nil
+}
+
+// checkServer checks if the proxy server can be reached.
+// It returns false if the user wants to enter the server again.
+func (w *setupWizard) checkServer(host, port, transport, sni string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), setupCheckTimeout)
+	defer cancel()
+	if net.ParseIP(host) == nil {
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			w.printf("Unable to resolve %s: %v", host, err)
+			return w.confirm("Continue anyway?")
+		}
+		w.printf("Resolved %s to %v", host, ips)
+	}
+
+	if transport == setupTransportUDP {
+		w.printf("UDP port can't be checked before the client starts.")
+		return true, nil
+	}
+	addr := net.JoinHostPort(host, port)
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		w.printf("Unable to connect to %s: %v", addr, err)
+		return w.confirm("Continue anyway?")
+	}
+	defer conn.Close()
+	w.printf("Connected to %s", addr)
+	if transport == setupTransportTLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: sni, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			w.printf("TLS handshake with %s failed: %v", addr, err)
+			return w.confirm("Continue anyway?")
+		}
+		w.printf("TLS handshake with %s succeeded", addr)
+	}
+	return true, nil
+}
+
+// ask prints the prompt and returns the answer of the user.
+// If the answer is empty, the default value is used. If the answer is
+// not valid, the user is asked again.
+func (w *setupWizard) ask(prompt, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", i18n.T(prompt), defaultValue)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", i18n.T(prompt))
+		}
+		line, err := w.in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			fmt.Fprintln(w.out)
+			return "", i18n.Errorf("Setup is canceled.")
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = defaultValue
+		}
+		if answer == "" {
+			w.printf("A value is required.")
+			continue
+		}
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintln(w.out, err.Error())
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// confirm asks a yes or no question. The default answer is yes.
+func (w *setupWizard) confirm(prompt string) (bool, error) {
+	answer, err := w.ask(prompt, "Y", func(s string) error {
+		switch strings.ToLower(s) {
+		case "y", "yes", "n", "no":
+			return nil
+		default:
+			return i18n.Errorf("Please answer yes or no.")
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y"), nil
+}
+
+func (w *setupWizard) printf(format string, a ...interface{}) {
+	fmt.Fprintln(w.out, i18n.Sprintf(format, a...))
+}
+
+func validateSetupTransport(s string) error {
+	switch strings.ToUpper(s) {
+	case setupTransportTCP, setupTransportUDP, setupTransportTLS:
+		return nil
+	default:
+		return i18n.Errorf("Invalid transport protocol %q.", s)
+	}
+}
+
+func validateSetupPreset(s string) error {
+	if strings.EqualFold(s, setupPresetNone) {
+		return nil
+	}
+	if _, err := appctl.ParsePreset(s); err != nil {
+		return i18n.Errorf("Invalid preset %q.", s)
+	}
+	return nil
+}
+
+// validateSetupPort returns a validator of port numbers between min and 65535.
+func validateSetupPort(min int) func(string) error {
+	return func(s string) error {
+		port, err := strconv.Atoi(s)
+		if err != nil || port < min || port > 65535 {
+			return i18n.Errorf("Port number must be between %d and %d.", min, 65535)
+		}
+		return nil
+	}
+}
@@ -16,11 +16,144 @@
 package cli
 
 import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/appctl"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/i18n"
 	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
 	"github.com/enfein/mieru/v3/pkg/version"
+	"google.golang.org/protobuf/proto"
 )
 
 var versionFunc = func(s []string) error {
 	log.Infof(version.AppVersion)
 	return nil
 }
+
+// formatTable aligns the columns of the rows and returns one line per row.
+func formatTable(rows [][]string) []string {
+	widths := make([]int, 0)
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = mathext.Max(widths[i], len(cell))
+		}
+	}
+	res := make([]string, 0, len(rows))
+	delim := "  "
+	for _, row := range rows {
+		line := make([]string, 0, len(row))
+		for i, cell := range row {
+			line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", widths[i])+"s", cell))
+		}
+		res = append(res, strings.TrimRight(strings.Join(line, delim), " "))
+	}
+	return res
+}
+
+// printStorageHealth warns about the data that the daemon can't write to disk.
+func printStorageHealth(status *appctlpb.AppStatusMsg) {
+	if !status.GetStorageDegraded() {
+		return
+	}
+	for _, failure := range status.GetStorageFailures() {
+		log.Warnf(i18n.T("unable to write to disk, data is kept in memory: %s"), failure)
+	}
+}
+
+// sessionStateRequest parses the optional session ID of
+// "get session-state [SESSION_ID]" command.
+func sessionStateRequest(s []string, binaryName string) (*appctlpb.SessionStateRequest, error) {
+	if len(s) > 4 {
+		return nil, fmt.Errorf("usage: %s get session-state [SESSION_ID]. more than 1 session ID is provided", binaryName)
+	}
+	if len(s) < 4 {
+		return &appctlpb.SessionStateRequest{}, nil
+	}
+	id, err := strconv.ParseUint(s[3], 10, 32)
+	if err != nil || id == 0 {
+		return nil, fmt.Errorf("usage: %s get session-state [SESSION_ID]. session ID %q is invalid", binaryName, s[3])
+	}
+	return &appctlpb.SessionStateRequest{SessionID: proto.Uint32(uint32(id))}, nil
+}
+
+// logsUsage is the usage of "get logs" command.
+const describeConfigUsage = "describe config [--format <FORMAT>]"
+
+// configFormat parses the options of "describe config" command.
+func configFormat(s []string, binaryName string) (appctl.ConfigFileType, error) {
+	fileType := appctl.JSON_CONFIG_FILE_TYPE
+	for i := 3; i < len(s); i++ {
+		name, value, found := strings.Cut(s[i], "=")
+		if name != "--format" {
+			return appctl.INVALID_CONFIG_FILE_TYPE, fmt.Errorf("usage: %s %s. unknown option %s", binaryName, describeConfigUsage, name)
+		}
+		if !found {
+			if i+1 >= len(s) {
+				return appctl.INVALID_CONFIG_FILE_TYPE, fmt.Errorf("usage: %s %s. %s has no value", binaryName, describeConfigUsage, name)
+			}
+			i++
+			value = s[i]
+		}
+		var err error
+		if fileType, err = appctl.ParseConfigFormat(value); err != nil {
+			return appctl.INVALID_CONFIG_FILE_TYPE, fmt.Errorf("usage: %s %s. %w", binaryName, describeConfigUsage, err)
+		}
+	}
+	return fileType, nil
+}
+
+const logsUsage = "get logs [--follow] [--level <LEVEL>]"
+
+// logRequest parses the options of "get logs" command.
+func logRequest(s []string, binaryName string) (*appctlpb.LogRequest, error) {
+	req := &appctlpb.LogRequest{}
+	for i := 3; i < len(s); i++ {
+		name, value, found := strings.Cut(s[i], "=")
+		switch name {
+		case "--follow", "-f":
+			if found {
+				return nil, fmt.Errorf("usage: %s %s. unknown option %s", binaryName, logsUsage, s[i])
+			}
+			req.Follow = proto.Bool(true)
+		case "--level":
+			if !found {
+				if i+1 >= len(s) {
+					return nil, fmt.Errorf("usage: %s %s. %s has no value", binaryName, logsUsage, name)
+				}
+				i++
+				value = s[i]
+			}
+			level, ok := appctlpb.LoggingLevel_value[strings.ToUpper(value)]
+			if !ok || level == int32(appctlpb.LoggingLevel_DEFAULT) {
+				return nil, fmt.Errorf("usage: %s %s. level %q is invalid, valid levels are FATAL, ERROR, WARN, INFO, DEBUG and TRACE", binaryName, logsUsage, value)
+			}
+			req.Level = appctlpb.LoggingLevel(level).Enum()
+		default:
+			return nil, fmt.Errorf("usage: %s %s. unknown option %s", binaryName, logsUsage, name)
+		}
+	}
+	return req, nil
+}
+
+// printLogs prints the log lines received from the daemon,
+// until the stream is closed.
+func printLogs(recv func() (*appctlpb.LogLine, error)) error {
+	for {
+		line, err := recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Infof("%s", strings.TrimSuffix(line.GetText(), "\n"))
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// Hosts is a static mapping from domain names to IP addresses,
+// like the hosts file of the operating system. Domain names are
+// matched exactly and case insensitively. A nil Hosts has no mapping.
+type Hosts struct {
+	m map[string][]net.IP
+}
+
+// NewHosts creates a Hosts from the mapping.
+// Domain names without IP address are ignored.
+func NewHosts(m map[string][]net.IP) *Hosts {
+	h := &Hosts{m: make(map[string][]net.IP)}
+	for name, ips := range m {
+		if len(ips) == 0 {
+			continue
+		}
+		key := normalizeHostName(name)
+		for _, ip := range ips {
+			dup := make(net.IP, len(ip))
+			copy(dup, ip)
+			h.m[key] = append(h.m[key], dup)
+		}
+	}
+	return h
+}
+
+// Len returns the number of domain names in the mapping.
+func (h *Hosts) Len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.m)
+}
+
+// Lookup returns the IP addresses of the domain name.
+// It returns false if the domain name is not in the mapping.
+// The returned slice must not be modified.
+func (h *Hosts) Lookup(name string) ([]net.IP, bool) {
+	if h == nil {
+		return nil, false
+	}
+	ips, ok := h.m[normalizeHostName(name)]
+	return ips, ok
+}
+
+// normalizeHostName returns the domain name in lower case
+// without the trailing dot.
+func normalizeHostName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// HostsResolver is a DNS resolver that looks up the static hosts
+// before another resolver. If a domain name is in the static hosts,
+// the other resolver is not used.
+type HostsResolver struct {
+	hosts    *Hosts
+	resolver apicommon.DNSResolver
+}
+
+var _ apicommon.DNSResolver = &HostsResolver{}
+
+// NewHostsResolver creates a new HostsResolver. If the resolver is nil,
+// the system DNS resolver is used.
+func NewHostsResolver(hosts *Hosts, resolver apicommon.DNSResolver) *HostsResolver {
+	if resolver == nil {
+		resolver = &net.Resolver{}
+	}
+	return &HostsResolver{hosts: hosts, resolver: resolver}
+}
+
+// LookupIP looks up host from the static hosts, then from the resolver.
+// The network must be "ip", "ip4" or "ip6".
+func (r *HostsResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	ips, ok := r.hosts.Lookup(host)
+	if !ok {
+		return r.resolver.LookupIP(ctx, network, host)
+	}
+	var res []net.IP
+	for _, ip := range ips {
+		switch network {
+		case "ip":
+			res = append(res, ip)
+		case "ip4":
+			if ip.To4() != nil {
+				res = append(res, ip)
+			}
+		case "ip6":
+			if ip.To4() == nil {
+				res = append(res, ip)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported network %q", network)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf(stderror.IPAddressNotFound, host)
+	}
+	return res, nil
+}
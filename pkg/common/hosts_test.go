@@ -0,0 +1,87 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestHostsLookup(t *testing.T) {
+	h := NewHosts(map[string][]net.IP{
+		"Example.COM.": {net.ParseIP("192.0.2.1")},
+		"empty.com":    nil,
+	})
+	if h.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", h.Len())
+	}
+	for _, name := range []string{"example.com", "EXAMPLE.com", "example.com."} {
+		ips, ok := h.Lookup(name)
+		if !ok || len(ips) != 1 || !ips[0].Equal(net.ParseIP("192.0.2.1")) {
+			t.Errorf("Lookup(%q) = %v, %v, want [192.0.2.1], true", name, ips, ok)
+		}
+	}
+	for _, name := range []string{"www.example.com", "empty.com"} {
+		if _, ok := h.Lookup(name); ok {
+			t.Errorf("Lookup(%q) found the domain name", name)
+		}
+	}
+
+	var nilHosts *Hosts
+	if _, ok := nilHosts.Lookup("example.com"); ok {
+		t.Errorf("Lookup() of nil Hosts found the domain name")
+	}
+}
+
+func TestHostsResolver(t *testing.T) {
+	h := NewHosts(map[string][]net.IP{
+		"example.com": {net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+		"v4.com":      {net.ParseIP("192.0.2.2")},
+	})
+	r := NewHostsResolver(h, staticResolver{ip: net.ParseIP("198.51.100.1")})
+	ctx := context.Background()
+
+	testCases := []struct {
+		network string
+		host    string
+		want    []string
+	}{
+		{"ip", "example.com", []string{"192.0.2.1", "2001:db8::1"}},
+		{"ip4", "example.com", []string{"192.0.2.1"}},
+		{"ip6", "example.com", []string{"2001:db8::1"}},
+		{"ip", "other.com", []string{"198.51.100.1"}},
+	}
+	for _, tc := range testCases {
+		ips, err := r.LookupIP(ctx, tc.network, tc.host)
+		if err != nil {
+			t.Fatalf("LookupIP(%q, %q) failed: %v", tc.network, tc.host, err)
+		}
+		if len(ips) != len(tc.want) {
+			t.Fatalf("LookupIP(%q, %q) = %v, want %v", tc.network, tc.host, ips, tc.want)
+		}
+		for i := range ips {
+			if !ips[i].Equal(net.ParseIP(tc.want[i])) {
+				t.Errorf("LookupIP(%q, %q) = %v, want %v", tc.network, tc.host, ips, tc.want)
+			}
+		}
+	}
+
+	// The resolver is not used if the domain name is in the static hosts.
+	if _, err := r.LookupIP(ctx, "ip6", "v4.com"); err == nil {
+		t.Errorf("LookupIP(\"ip6\", \"v4.com\") got no error")
+	}
+}
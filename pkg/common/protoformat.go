@@ -0,0 +1,116 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/enfein/mieru/v3/pkg/toml"
+	"github.com/enfein/mieru/v3/pkg/yaml"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MarshalYAML returns a YAML representation of protobuf.
+// The field names are the same as JSON.
+func MarshalYAML(m protoreflect.ProtoMessage) ([]byte, error) {
+	v, err := protoToValue(m)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(v)
+}
+
+// UnmarshalYAML writes protobuf based on YAML data.
+func UnmarshalYAML(b []byte, m protoreflect.ProtoMessage) error {
+	v, err := yaml.Unmarshal(b)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		v = map[string]any{}
+	}
+	return valueToProto(v, m)
+}
+
+// MarshalTOML returns a TOML representation of protobuf.
+// The field names are the same as JSON.
+func MarshalTOML(m protoreflect.ProtoMessage) ([]byte, error) {
+	v, err := protoToValue(m)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("protobuf message is not a JSON object")
+	}
+	return toml.Marshal(doc)
+}
+
+// UnmarshalTOML writes protobuf based on TOML data.
+func UnmarshalTOML(b []byte, m protoreflect.ProtoMessage) error {
+	doc, err := toml.Unmarshal(b)
+	if err != nil {
+		return err
+	}
+	return valueToProto(doc, m)
+}
+
+// protoToValue converts protobuf to a generic value via JSON.
+// Integers are kept as int64 instead of float64.
+func protoToValue(m protoreflect.ProtoMessage) (any, error) {
+	b, err := MarshalJSON(m)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertJSONNumbers(v), nil
+}
+
+func convertJSONNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []any:
+		for i := range val {
+			val[i] = convertJSONNumbers(val[i])
+		}
+	case map[string]any:
+		for k := range val {
+			val[k] = convertJSONNumbers(val[k])
+		}
+	}
+	return v
+}
+
+// valueToProto converts a generic value to protobuf via JSON.
+func valueToProto(v any, m protoreflect.ProtoMessage) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("json.Marshal() failed: %w", err)
+	}
+	return UnmarshalJSON(b, m)
+}
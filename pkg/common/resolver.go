@@ -0,0 +1,307 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// SystemDNSServer is the name of the system DNS resolver
+// in metrics and errors.
+const SystemDNSServer = "system"
+
+// dnsRCodeNames maps DNS response codes to their names.
+var dnsRCodeNames = map[byte]string{
+	0: "NOERROR",
+	1: "FORMERR",
+	2: "SERVFAIL",
+	3: "NXDOMAIN",
+	4: "NOTIMP",
+	5: "REFUSED",
+}
+
+func dnsRCodeName(rcode byte) string {
+	if name, ok := dnsRCodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// SwappableResolver is a DNS resolver that forwards lookups to
+// another resolver, which can be replaced at any time.
+type SwappableResolver struct {
+	resolver atomic.Pointer[apicommon.DNSResolver]
+}
+
+var _ apicommon.DNSResolver = &SwappableResolver{}
+
+// NewSwappableResolver creates a new SwappableResolver that forwards
+// lookups to the given resolver. If the resolver is nil,
+// the system DNS resolver is used.
+func NewSwappableResolver(resolver apicommon.DNSResolver) *SwappableResolver {
+	r := &SwappableResolver{}
+	r.Swap(resolver)
+	return r
+}
+
+// LookupIP looks up host with the current resolver.
+func (r *SwappableResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return (*r.resolver.Load()).LookupIP(ctx, network, host)
+}
+
+// Swap replaces the resolver. Lookups that are already started
+// are finished by the previous resolver. If the resolver is nil,
+// the system DNS resolver is used.
+func (r *SwappableResolver) Swap(resolver apicommon.DNSResolver) {
+	if resolver == nil {
+		resolver = &net.Resolver{}
+	}
+	r.resolver.Store(&resolver)
+}
+
+// DNSLookupError is returned by MeteredResolver when a lookup fails.
+// It tells which DNS server failed and the DNS response code.
+type DNSLookupError struct {
+	Host   string // host name to look up
+	Server string // address of the DNS server, or SystemDNSServer
+	RCode  string // DNS response code, empty if no response is received
+	Err    error  // error returned by the resolver
+}
+
+func (e *DNSLookupError) Error() string {
+	server := "DNS server " + e.Server
+	if e.Server == SystemDNSServer {
+		server = "system DNS resolver"
+	}
+	if e.RCode != "" {
+		return fmt.Sprintf("look up %s with %s failed with rcode %s: %v", e.Host, server, e.RCode, e.Err)
+	}
+	return fmt.Sprintf("look up %s with %s failed: %v", e.Host, server, e.Err)
+}
+
+// Unwrap returns the error returned by the resolver.
+func (e *DNSLookupError) Unwrap() error {
+	return e.Err
+}
+
+// MeteredResolver looks up IP addresses with the system DNS resolver or
+// the given DNS servers. It records the number of successful and failed
+// queries and the query latency of each DNS server. A failed lookup
+// returns a *DNSLookupError.
+type MeteredResolver struct {
+	resolver *net.Resolver
+	servers  []string
+	next     atomic.Uint32
+}
+
+var _ apicommon.DNSResolver = &MeteredResolver{}
+
+// NewMeteredResolver creates a new MeteredResolver. Queries are sent to
+// the DNS servers in a round-robin way. If no DNS server is given,
+// the system DNS resolver is used.
+func NewMeteredResolver(servers []string) *MeteredResolver {
+	r := &MeteredResolver{servers: servers}
+	if len(servers) == 0 {
+		r.resolver = &net.Resolver{}
+	} else {
+		r.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial:     r.dial,
+		}
+	}
+	return r
+}
+
+// LookupIP looks up host with the DNS servers.
+func (r *MeteredResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	trace := &dnsTrace{}
+	ctx = context.WithValue(ctx, dnsTraceKey{}, trace)
+	start := time.Now()
+	ips, err := r.resolver.LookupIP(ctx, network, host)
+	if len(r.servers) == 0 {
+		// The queries of the system DNS resolver are not visible.
+		var dnsErr *net.DNSError
+		recordDNSQuery(SystemDNSServer, time.Since(start), err == nil || (errors.As(err, &dnsErr) && dnsErr.IsNotFound))
+	}
+	if err != nil {
+		lookupErr := &DNSLookupError{
+			Host:   host,
+			Server: SystemDNSServer,
+			Err:    err,
+		}
+		trace.mu.Lock()
+		if trace.server != "" {
+			lookupErr.Server = trace.server
+			lookupErr.RCode = trace.rcode
+		}
+		trace.mu.Unlock()
+		return nil, lookupErr
+	}
+	return ips, nil
+}
+
+// dial connects to the next DNS server. The address
+// from the system configuration is ignored.
+func (r *MeteredResolver) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	server := r.servers[int(r.next.Add(1)-1)%len(r.servers)]
+	trace, _ := ctx.Value(dnsTraceKey{}).(*dnsTrace)
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		recordDNSQuery(server, 0, false)
+		trace.record(server, "", true)
+		return nil, err
+	}
+	if pc, ok := conn.(net.PacketConn); ok {
+		// The resolver uses UDP message format only if
+		// the connection is a net.PacketConn.
+		return &meteredDNSPacketConn{
+			meteredDNSConn: &meteredDNSConn{
+				Conn:     conn,
+				server:   server,
+				isPacket: true,
+				trace:    trace,
+			},
+			PacketConn: pc,
+		}, nil
+	}
+	return &meteredDNSConn{
+		Conn:   conn,
+		server: server,
+		trace:  trace,
+	}, nil
+}
+
+// dnsTraceKey is the context key of dnsTrace.
+type dnsTraceKey struct{}
+
+// dnsTrace remembers the DNS server that failed in a lookup.
+// If no DNS server failed, it remembers the first DNS server
+// that responded.
+type dnsTrace struct {
+	mu     sync.Mutex
+	server string
+	rcode  string
+	failed bool
+}
+
+func (t *dnsTrace) record(server, rcode string, failed bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if failed || (t.server == "" && !t.failed) {
+		t.server = server
+		t.rcode = rcode
+		t.failed = t.failed || failed
+	}
+}
+
+// meteredDNSConn is a connection to a DNS server. It reads the
+// response code from the header of DNS responses.
+type meteredDNSConn struct {
+	net.Conn
+	server   string
+	isPacket bool
+	trace    *dnsTrace
+
+	queryTime time.Time
+	header    [6]byte // DNS message header with 2 bytes length prefix over TCP
+	headerLen int
+	done      bool
+}
+
+func (c *meteredDNSConn) Write(b []byte) (int, error) {
+	if c.queryTime.IsZero() || c.done {
+		c.queryTime = time.Now()
+		c.headerLen = 0
+		c.done = false
+	}
+	n, err := c.Conn.Write(b)
+	if err != nil {
+		c.finish(false, "")
+	}
+	return n, err
+}
+
+func (c *meteredDNSConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if c.done {
+		return n, err
+	}
+	if c.isPacket {
+		// Each read returns a full DNS message.
+		c.headerLen = 0
+	}
+	c.headerLen += copy(c.header[c.headerLen:], b[:n])
+	rcodePos := 5
+	if c.isPacket {
+		rcodePos = 3
+	}
+	if c.headerLen > rcodePos {
+		rcode := c.header[rcodePos] & 0x0f
+		// NXDOMAIN is a valid response from a working DNS server.
+		c.finish(rcode == 0 || rcode == 3, dnsRCodeName(rcode))
+	} else if err != nil {
+		c.finish(false, "")
+	}
+	return n, err
+}
+
+// finish records the result of the current query.
+func (c *meteredDNSConn) finish(ok bool, rcode string) {
+	if c.done {
+		return
+	}
+	c.done = true
+	recordDNSQuery(c.server, time.Since(c.queryTime), ok)
+	c.trace.record(c.server, rcode, rcode != dnsRCodeName(0))
+}
+
+// meteredDNSPacketConn is a meteredDNSConn over UDP.
+type meteredDNSPacketConn struct {
+	*meteredDNSConn
+	net.PacketConn
+}
+
+func (c *meteredDNSPacketConn) Read(b []byte) (int, error) {
+	return c.meteredDNSConn.Read(b)
+}
+
+func (c *meteredDNSPacketConn) Write(b []byte) (int, error) {
+	return c.meteredDNSConn.Write(b)
+}
+
+// recordDNSQuery updates the metrics of the DNS server.
+func recordDNSQuery(server string, latency time.Duration, ok bool) {
+	group := fmt.Sprintf(metrics.DNSMetricGroupFormat, server)
+	if ok {
+		metrics.RegisterMetric(group, metrics.DNSMetricSuccess, metrics.COUNTER).Add(1)
+	} else {
+		metrics.RegisterMetric(group, metrics.DNSMetricFailure, metrics.COUNTER).Add(1)
+	}
+	metrics.RegisterMetric(group, metrics.DNSMetricLatencyMs, metrics.COUNTER).Add(latency.Milliseconds())
+}
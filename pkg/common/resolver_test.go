@@ -0,0 +1,182 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+type staticResolver struct {
+	ip net.IP
+}
+
+func (r staticResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return []net.IP{r.ip}, nil
+}
+
+func TestSwappableResolver(t *testing.T) {
+	r := NewSwappableResolver(staticResolver{ip: net.ParseIP("192.0.2.1")})
+	ips, err := r.LookupIP(context.Background(), "ip", "example.com")
+	if err != nil {
+		t.Fatalf("LookupIP() failed: %v", err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("LookupIP() = %v, want [192.0.2.1]", ips)
+	}
+
+	r.Swap(staticResolver{ip: net.ParseIP("2001:db8::1")})
+	ips, err = r.LookupIP(context.Background(), "ip", "example.com")
+	if err != nil {
+		t.Fatalf("LookupIP() failed: %v", err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("LookupIP() = %v, want [2001:db8::1]", ips)
+	}
+
+	r.Swap(nil)
+	if _, ok := (*r.resolver.Load()).(*net.Resolver); !ok {
+		t.Errorf("resolver is not the system DNS resolver after Swap(nil)")
+	}
+}
+
+// runServFailDNSServer starts a DNS server that responds
+// SERVFAIL to all the queries.
+func runServFailDNSServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		b := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			if n < 12 {
+				continue
+			}
+			b[2] |= 0x80                // response
+			b[3] = (b[3] & 0xf0) | 0x82 // recursion available, SERVFAIL
+			conn.WriteTo(b[:n], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestMeteredResolverDial(t *testing.T) {
+	if r := NewMeteredResolver(nil); r.resolver.Dial != nil {
+		t.Errorf("NewMeteredResolver(nil) is not the system DNS resolver")
+	}
+
+	var servers []string
+	for i := 0; i < 2; i++ {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("ListenPacket() failed: %v", err)
+		}
+		defer conn.Close()
+		servers = append(servers, conn.LocalAddr().String())
+	}
+	r := NewMeteredResolver(servers)
+	if !r.resolver.PreferGo {
+		t.Errorf("PreferGo is false")
+	}
+	for i := 0; i < 4; i++ {
+		conn, err := r.dial(context.Background(), "udp", "192.0.2.1:53")
+		if err != nil {
+			t.Fatalf("dial() failed: %v", err)
+		}
+		if got, want := conn.RemoteAddr().String(), servers[i%2]; got != want {
+			t.Errorf("query %d is sent to %s, want %s", i, got, want)
+		}
+		conn.Close()
+	}
+}
+
+func TestMeteredResolverLookupError(t *testing.T) {
+	server := runServFailDNSServer(t)
+	r := NewMeteredResolver([]string{server})
+	_, err := r.LookupIP(context.Background(), "ip4", "servfail.example.com")
+	var lookupErr *DNSLookupError
+	if !errors.As(err, &lookupErr) {
+		t.Fatalf("LookupIP() got error %v, want DNSLookupError", err)
+	}
+	if lookupErr.Server != server {
+		t.Errorf("Server = %q, want %q", lookupErr.Server, server)
+	}
+	if lookupErr.RCode != "SERVFAIL" {
+		t.Errorf("RCode = %q, want %q", lookupErr.RCode, "SERVFAIL")
+	}
+
+	group := metrics.GetMetricGroupByName(fmt.Sprintf(metrics.DNSMetricGroupFormat, server))
+	if group == nil {
+		t.Fatalf("metric group of DNS server %s is not found", server)
+	}
+	failure := metrics.RegisterMetric(fmt.Sprintf(metrics.DNSMetricGroupFormat, server), metrics.DNSMetricFailure, metrics.COUNTER)
+	if failure.Load() == 0 {
+		t.Errorf("%s is 0", metrics.DNSMetricFailure)
+	}
+}
+
+func TestMeteredDNSConnStream(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	trace := &dnsTrace{}
+	conn := &meteredDNSConn{Conn: client, server: "192.0.2.53:53", trace: trace}
+
+	go func() {
+		query := make([]byte, 14)
+		if _, err := server.Read(query); err != nil {
+			return
+		}
+		// Length prefix and a response header with REFUSED,
+		// written in small pieces.
+		response := []byte{0, 12, query[2], query[3], 0x81, 0x85, 0, 0, 0, 0, 0, 0, 0, 0}
+		for i := 0; i < len(response); i += 3 {
+			end := i + 3
+			if end > len(response) {
+				end = len(response)
+			}
+			if _, err := server.Write(response[i:end]); err != nil {
+				return
+			}
+		}
+	}()
+
+	if _, err := conn.Write([]byte{0, 12, 0x12, 0x34, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	b := make([]byte, 14)
+	for read := 0; read < len(b); {
+		n, err := conn.Read(b[read:])
+		if err != nil {
+			t.Fatalf("Read() failed: %v", err)
+		}
+		read += n
+	}
+	if trace.server != "192.0.2.53:53" || trace.rcode != "REFUSED" {
+		t.Errorf("trace got server %q and rcode %q, want %q and %q", trace.server, trace.rcode, "192.0.2.53:53", "REFUSED")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build android || linux
+
+package sockopts
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func BindToDevice(name string) Control {
+	return func(network, address string, conn syscall.RawConn) error {
+		var err error
+		if ctlErr := conn.Control(func(fd uintptr) { err = BindToDeviceRawErr(name)(fd) }); ctlErr != nil {
+			return ctlErr
+		}
+		return err
+	}
+}
+
+func BindToDeviceRawErr(name string) RawControlErr {
+	return func(fd uintptr) error {
+		// Send packets through the network interface, regardless of
+		// the routing table. It requires CAP_NET_RAW before Linux 5.7.
+		return unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_BINDTODEVICE, name)
+	}
+}
@@ -60,3 +60,34 @@ func ApplyUDPControls(conn *net.UDPConn) error {
 	}
 	return nil
 }
+
+// ApplyDontFragment sets the DF bit of packets sent by the UDP connection.
+func ApplyDontFragment(conn *net.UDPConn) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("SyscallConn() failed: %w", err)
+	}
+	var ctlErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ctlErr = DontFragmentRawErr()(fd)
+	}); err != nil {
+		return err
+	}
+	return ctlErr
+}
+
+// ApplyNotSentLowat limits the number of bytes written to the TCP
+// connection but not sent yet.
+func ApplyNotSentLowat(conn syscall.Conn, bytes int) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("SyscallConn() failed: %w", err)
+	}
+	var ctlErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ctlErr = NotSentLowatRawErr(bytes)(fd)
+	}); err != nil {
+		return err
+	}
+	return ctlErr
+}
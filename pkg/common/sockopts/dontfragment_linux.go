@@ -0,0 +1,44 @@
+// Copyright (C) 2022  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build android || linux
+
+package sockopts
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+func DontFragmentRaw() RawControl {
+	return func(fd uintptr) {
+		DontFragmentRawErr()(fd)
+	}
+}
+
+func DontFragmentRawErr() RawControlErr {
+	return func(fd uintptr) error {
+		// Set the DF bit and ignore the path MTU cached by the kernel,
+		// so packets larger than the path MTU are dropped instead of
+		// fragmented. The socket may be IPv4, IPv6 or dual stack.
+		err4 := unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_PROBE)
+		err6 := unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, unix.IPV6_PMTUDISC_PROBE)
+		if err4 != nil && err6 != nil {
+			return errors.Join(err4, err6)
+		}
+		return nil
+	}
+}
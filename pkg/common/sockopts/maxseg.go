@@ -0,0 +1,37 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !(android || darwin || linux)
+
+package sockopts
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// MaxSegment returns an error in unsupported platforms.
+func MaxSegment(mss int) Control {
+	return func(network, address string, conn syscall.RawConn) error {
+		return MaxSegmentRawErr(mss)(0)
+	}
+}
+
+func MaxSegmentRawErr(mss int) RawControlErr {
+	return func(fd uintptr) error {
+		return fmt.Errorf("TCP_MAXSEG socket option is not supported on %s", runtime.GOOS)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// sdListenFDsStart is the first file descriptor passed by
+	// systemd socket activation.
+	sdListenFDsStart = 3
+
+	// States sent to systemd service manager by SdNotify.
+	SdNotifyReady    = "READY=1"
+	SdNotifyStopping = "STOPPING=1"
+	SdNotifyWatchdog = "WATCHDOG=1"
+)
+
+// inheritedSockets are the sockets passed by systemd socket activation.
+// They are kept open until the process exits.
+var inheritedSockets struct {
+	mu        sync.Mutex
+	loaded    bool
+	listeners []*net.TCPListener
+	conns     []*net.UDPConn
+}
+
+// LoadSystemdSockets takes the sockets passed by systemd socket activation.
+// The environment variables of socket activation are unset, so the sockets
+// are not inherited by child processes. It returns the number of sockets.
+// It does nothing if the sockets are already loaded.
+func LoadSystemdSockets() (int, error) {
+	inheritedSockets.mu.Lock()
+	defer inheritedSockets.mu.Unlock()
+	if inheritedSockets.loaded {
+		return len(inheritedSockets.listeners) + len(inheritedSockets.conns), nil
+	}
+	inheritedSockets.loaded = true
+
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return 0, nil
+	}
+	for fd := sdListenFDsStart; fd < sdListenFDsStart+n; fd++ {
+		f := os.NewFile(uintptr(fd), "systemd-socket-"+strconv.Itoa(fd))
+		if l, err := net.FileListener(f); err == nil {
+			if tcpListener, ok := l.(*net.TCPListener); ok {
+				inheritedSockets.listeners = append(inheritedSockets.listeners, tcpListener)
+			} else {
+				l.Close()
+			}
+		} else if c, err := net.FilePacketConn(f); err == nil {
+			if udpConn, ok := c.(*net.UDPConn); ok {
+				inheritedSockets.conns = append(inheritedSockets.conns, udpConn)
+			} else {
+				c.Close()
+			}
+		} else {
+			f.Close()
+			return len(inheritedSockets.listeners) + len(inheritedSockets.conns), fmt.Errorf("file descriptor %d is not a TCP or UDP socket", fd)
+		}
+		// The socket is duplicated by net package.
+		f.Close()
+	}
+	return len(inheritedSockets.listeners) + len(inheritedSockets.conns), nil
+}
+
+// TakeSystemdTCPListener returns the TCP listener passed by systemd socket
+// activation that listens to the address. It returns nil if not found.
+// The returned listener is a duplicate of the inherited socket, so the
+// caller can close it, and the socket can be taken again when the proxy
+// is restarted in the same process.
+func TakeSystemdTCPListener(addr *net.TCPAddr) *net.TCPListener {
+	inheritedSockets.mu.Lock()
+	defer inheritedSockets.mu.Unlock()
+	for _, l := range inheritedSockets.listeners {
+		laddr, ok := l.Addr().(*net.TCPAddr)
+		if !ok || !matchListenAddr(laddr.IP, laddr.Port, addr.IP, addr.Port) {
+			continue
+		}
+		f, err := l.File()
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		dup, err := net.FileListener(f)
+		if err != nil {
+			return nil
+		}
+		return dup.(*net.TCPListener)
+	}
+	return nil
+}
+
+// TakeSystemdUDPConn returns the UDP socket passed by systemd socket
+// activation that listens to the address. It returns nil if not found.
+// The returned socket is a duplicate of the inherited socket, so the
+// caller can close it, and the socket can be taken again when the proxy
+// is restarted in the same process.
+func TakeSystemdUDPConn(addr *net.UDPAddr) *net.UDPConn {
+	inheritedSockets.mu.Lock()
+	defer inheritedSockets.mu.Unlock()
+	for _, c := range inheritedSockets.conns {
+		laddr, ok := c.LocalAddr().(*net.UDPAddr)
+		if !ok || !matchListenAddr(laddr.IP, laddr.Port, addr.IP, addr.Port) {
+			continue
+		}
+		f, err := c.File()
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		dup, err := net.FilePacketConn(f)
+		if err != nil {
+			return nil
+		}
+		return dup.(*net.UDPConn)
+	}
+	return nil
+}
+
+// matchListenAddr returns true if the socket listening to the first address
+// can be used in place of the second address. An unspecified IP address
+// matches any IP address.
+func matchListenAddr(ip1 net.IP, port1 int, ip2 net.IP, port2 int) bool {
+	if port1 != port2 {
+		return false
+	}
+	if len(ip1) == 0 || len(ip2) == 0 || ip1.IsUnspecified() || ip2.IsUnspecified() {
+		return true
+	}
+	return ip1.Equal(ip2)
+}
+
+// SdNotify sends the state to systemd service manager.
+// It does nothing if the process is not started by systemd
+// with notify access.
+func SdNotify(state string) error {
+	socketAddr := os.Getenv("NOTIFY_SOCKET")
+	if socketAddr == "" {
+		return nil
+	}
+	if socketAddr[0] == '@' {
+		// Abstract namespace socket.
+		socketAddr = "\x00" + socketAddr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketAddr, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("DialUnix() failed: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("Write() failed: %w", err)
+	}
+	return nil
+}
+
+// SdWatchdogInterval returns the interval that the process must send
+// the watchdog keep-alive notification to systemd service manager.
+// It returns 0 if watchdog is not enabled.
+func SdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		if pid, err := strconv.Atoi(pidStr); err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+	return time.Duration(usec) * time.Microsecond
+}
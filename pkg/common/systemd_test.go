@@ -0,0 +1,171 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMatchListenAddr(t *testing.T) {
+	testCases := []struct {
+		ip1   string
+		port1 int
+		ip2   string
+		port2 int
+		want  bool
+	}{
+		{"::", 8964, "0.0.0.0", 8964, true},
+		{"::", 8964, "", 8964, true},
+		{"127.0.0.1", 8964, "127.0.0.1", 8964, true},
+		{"127.0.0.1", 8964, "::", 8964, true},
+		{"127.0.0.1", 8964, "127.0.0.2", 8964, false},
+		{"::", 8964, "::", 8965, false},
+	}
+	for _, tc := range testCases {
+		if got := matchListenAddr(net.ParseIP(tc.ip1), tc.port1, net.ParseIP(tc.ip2), tc.port2); got != tc.want {
+			t.Errorf("matchListenAddr(%s, %d, %s, %d) = %v, want %v", tc.ip1, tc.port1, tc.ip2, tc.port2, got, tc.want)
+		}
+	}
+}
+
+func TestTakeSystemdSocketsTwice(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File() is not supported")
+	}
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("ListenTCP() failed: %v", err)
+	}
+	c, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("ListenUDP() failed: %v", err)
+	}
+	inheritedSockets.mu.Lock()
+	inheritedSockets.listeners = append(inheritedSockets.listeners, l)
+	inheritedSockets.conns = append(inheritedSockets.conns, c)
+	inheritedSockets.mu.Unlock()
+	defer func() {
+		inheritedSockets.mu.Lock()
+		inheritedSockets.listeners = nil
+		inheritedSockets.conns = nil
+		inheritedSockets.mu.Unlock()
+		l.Close()
+		c.Close()
+	}()
+	tcpAddr := l.Addr().(*net.TCPAddr)
+	udpAddr := c.LocalAddr().(*net.UDPAddr)
+
+	// Take and close the sockets twice, like the proxy is stopped
+	// and started again in the same process.
+	for i := 0; i < 2; i++ {
+		listener := TakeSystemdTCPListener(tcpAddr)
+		if listener == nil {
+			t.Fatalf("[round %d] TakeSystemdTCPListener() returned nil", i)
+		}
+		go func() {
+			if conn, err := net.Dial("tcp", tcpAddr.String()); err == nil {
+				conn.Close()
+			}
+		}()
+		listener.SetDeadline(time.Now().Add(time.Second))
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("[round %d] Accept() failed: %v", i, err)
+		}
+		conn.Close()
+		listener.Close()
+
+		udpConn := TakeSystemdUDPConn(udpAddr)
+		if udpConn == nil {
+			t.Fatalf("[round %d] TakeSystemdUDPConn() returned nil", i)
+		}
+		sender, err := net.DialUDP("udp", nil, udpAddr)
+		if err != nil {
+			t.Fatalf("[round %d] DialUDP() failed: %v", i, err)
+		}
+		if _, err := sender.Write([]byte("ping")); err != nil {
+			t.Fatalf("[round %d] Write() failed: %v", i, err)
+		}
+		sender.Close()
+		udpConn.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 16)
+		n, _, err := udpConn.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("[round %d] ReadFromUDP() failed: %v", i, err)
+		}
+		if string(buf[:n]) != "ping" {
+			t.Errorf("[round %d] got %q, want %q", i, buf[:n], "ping")
+		}
+		udpConn.Close()
+	}
+
+	if got := TakeSystemdTCPListener(&net.TCPAddr{Port: tcpAddr.Port + 1}); got != nil {
+		got.Close()
+		t.Errorf("TakeSystemdTCPListener() with other port returned %v, want nil", got.Addr())
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram is not supported")
+	}
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := SdNotify(SdNotifyReady); err != nil {
+		t.Errorf("SdNotify() without NOTIFY_SOCKET failed: %v", err)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram() failed: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	if err := SdNotify(SdNotifyReady); err != nil {
+		t.Fatalf("SdNotify() failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if string(buf[:n]) != SdNotifyReady {
+		t.Errorf("got state %q, want %q", buf[:n], SdNotifyReady)
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	t.Setenv("WATCHDOG_PID", "")
+	if got := SdWatchdogInterval(); got != 0 {
+		t.Errorf("SdWatchdogInterval() = %v, want 0", got)
+	}
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	if got := SdWatchdogInterval(); got != 30*time.Second {
+		t.Errorf("SdWatchdogInterval() = %v, want %v", got, 30*time.Second)
+	}
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if got := SdWatchdogInterval(); got != 0 {
+		t.Errorf("SdWatchdogInterval() with other PID = %v, want 0", got)
+	}
+}
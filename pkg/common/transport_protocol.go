@@ -22,3 +22,14 @@ const (
 	StreamTransport
 	PacketTransport
 )
+
+func (p TransportProtocol) String() string {
+	switch p {
+	case StreamTransport:
+		return "TCP"
+	case PacketTransport:
+		return "UDP"
+	default:
+		return "UNKNOWN"
+	}
+}
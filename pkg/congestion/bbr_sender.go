@@ -307,6 +307,13 @@ func NewBBRSender(loggingContext string, rttStats *RTTStats) *BBRSender {
 	return s
 }
 
+// SetMaxCongestionWindow sets the maximum congestion window
+// in number of packets. It must be called before the sender is used.
+func (b *BBRSender) SetMaxCongestionWindow(packets uint32) {
+	b.maxCongestionWindow = mathext.Max(int64(packets)*maxDatagramSize, b.minCongestionWindow)
+	b.recoveryWindow = b.maxCongestionWindow
+}
+
 // OnPacketSent updates BBR sender state when a packet is being sent.
 func (b *BBRSender) OnPacketSent(sentTime time.Time, bytesInFlight int64, packetNumber int64, bytes int64, hasRetransmittableData bool) {
 	b.mu.Lock()
@@ -438,6 +445,20 @@ func (b *BBRSender) BandwidthEstimate() int64 {
 	return b.maxBandwidth.GetBest()
 }
 
+// PacingRate returns the pacing rate in bytes per second.
+func (b *BBRSender) PacingRate() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.getPacingRate()
+}
+
+// CongestionWindow returns the congestion window in bytes.
+func (b *BBRSender) CongestionWindow() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.getCongestionWindow()
+}
+
 func (b *BBRSender) getPacingRate() int64 {
 	if b.pacingRate <= 0 {
 		return int64(highGain * float64(BandwidthFromBytesAndTimeDelta(b.initialCongestionWindow, b.getMinRTT())))
@@ -0,0 +1,73 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package congestion
+
+import (
+	"fmt"
+	"time"
+)
+
+// CongestionController decides when a packet can be sent.
+type CongestionController interface {
+	// OnPacketSent is called when a packet is sent.
+	OnPacketSent(sentTime time.Time, bytesInFlight int64, packetNumber int64, bytes int64, hasRetransmittableData bool)
+
+	// OnCongestionEvent is called when packets are acknowledged or lost.
+	OnCongestionEvent(priorInFlight int64, eventTime time.Time, ackedPackets []AckedPacketInfo, lostPackets []LostPacketInfo)
+
+	// OnApplicationLimited is called when there is no application data to send.
+	OnApplicationLimited(bytesInFlight int64)
+
+	// CanSend returns true if a packet with the given size can be sent now.
+	CanSend(bytesInFlight, bytes int64) bool
+
+	// PacingRate returns the pacing rate in bytes per second.
+	PacingRate() int64
+
+	// CongestionWindow returns the congestion window in bytes.
+	CongestionWindow() int64
+}
+
+var (
+	_ CongestionController = &BBRSender{}
+	_ CongestionController = &CubicSender{}
+)
+
+// Algorithm is the name of a congestion control algorithm.
+type Algorithm string
+
+const (
+	AlgorithmBBR   Algorithm = "BBR"
+	AlgorithmCubic Algorithm = "CUBIC"
+)
+
+// DefaultAlgorithm is used if the congestion control algorithm is not set.
+const DefaultAlgorithm = AlgorithmBBR
+
+// NewCongestionController creates a congestion controller that implements
+// the algorithm. minWindow and maxWindow are number of packets.
+func NewCongestionController(algorithm Algorithm, loggingContext string, rttStats *RTTStats, minWindow, maxWindow uint32) (CongestionController, error) {
+	switch algorithm {
+	case "", AlgorithmBBR:
+		sender := NewBBRSender(loggingContext, rttStats)
+		sender.SetMaxCongestionWindow(maxWindow)
+		return sender, nil
+	case AlgorithmCubic:
+		return NewCubicSender(minWindow, maxWindow, rttStats), nil
+	default:
+		return nil, fmt.Errorf("congestion control algorithm %q is not supported", algorithm)
+	}
+}
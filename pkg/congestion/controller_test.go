@@ -0,0 +1,84 @@
+// Copyright (C) 2023  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package congestion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCongestionController(t *testing.T) {
+	testcases := []struct {
+		algorithm Algorithm
+		wantBBR   bool
+		wantErr   bool
+	}{
+		{"", true, false},
+		{AlgorithmBBR, true, false},
+		{AlgorithmCubic, false, false},
+		{"RENO", false, true},
+	}
+	for _, tc := range testcases {
+		controller, err := NewCongestionController(tc.algorithm, "", nil, 16, 64)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("NewCongestionController(%q) succeeded, want error", tc.algorithm)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("NewCongestionController(%q) failed: %v", tc.algorithm, err)
+		}
+		if _, ok := controller.(*BBRSender); ok != tc.wantBBR {
+			t.Errorf("NewCongestionController(%q) returned %T", tc.algorithm, controller)
+		}
+	}
+}
+
+func TestCubicSender(t *testing.T) {
+	rttStats := NewRTTStats()
+	rttStats.UpdateRTT(100 * time.Millisecond)
+	sender := NewCubicSender(16, 64, rttStats)
+
+	cwnd := sender.CongestionWindow()
+	if cwnd != 16*maxDatagramSize {
+		t.Errorf("CongestionWindow() = %d, want %d", cwnd, 16*maxDatagramSize)
+	}
+	if !sender.CanSend(cwnd-1, maxDatagramSize) {
+		t.Errorf("CanSend() = false, want true")
+	}
+	if sender.CanSend(cwnd, maxDatagramSize) {
+		t.Errorf("CanSend() = true, want false")
+	}
+	if rate := sender.PacingRate(); rate != cwnd*10 {
+		t.Errorf("PacingRate() = %d, want %d", rate, cwnd*10)
+	}
+
+	// Window grows in slow start.
+	acked := make([]AckedPacketInfo, 4)
+	sender.OnCongestionEvent(cwnd, time.Now(), acked, nil)
+	if got := sender.CongestionWindow(); got <= cwnd {
+		t.Errorf("CongestionWindow() = %d after acknowledgement, want > %d", got, cwnd)
+	}
+
+	// Window is reduced once for multiple lost packets.
+	cwnd = sender.CongestionWindow()
+	lost := make([]LostPacketInfo, 2)
+	sender.OnCongestionEvent(cwnd, time.Now(), nil, lost)
+	if got := sender.CongestionWindow(); got >= cwnd {
+		t.Errorf("CongestionWindow() = %d after loss, want < %d", got, cwnd)
+	}
+}
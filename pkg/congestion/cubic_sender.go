@@ -0,0 +1,78 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package congestion
+
+import (
+	"time"
+)
+
+// CubicSender is a window based congestion controller that uses
+// CubicSendAlgorithm. The window is counted by packets.
+type CubicSender struct {
+	cubic    *CubicSendAlgorithm
+	rttStats *RTTStats
+}
+
+// NewCubicSender creates a new CubicSender. minWindow and maxWindow
+// are number of packets.
+func NewCubicSender(minWindow, maxWindow uint32, rttStats *RTTStats) *CubicSender {
+	if rttStats == nil {
+		rttStats = NewRTTStats()
+	}
+	return &CubicSender{
+		cubic:    NewCubicSendAlgorithm(minWindow, maxWindow),
+		rttStats: rttStats,
+	}
+}
+
+// OnPacketSent implements CongestionController.
+func (c *CubicSender) OnPacketSent(sentTime time.Time, bytesInFlight int64, packetNumber int64, bytes int64, hasRetransmittableData bool) {
+}
+
+// OnCongestionEvent grows the window for each acknowledged packet,
+// and reduces the window once if any packet is lost.
+func (c *CubicSender) OnCongestionEvent(priorInFlight int64, eventTime time.Time, ackedPackets []AckedPacketInfo, lostPackets []LostPacketInfo) {
+	for range ackedPackets {
+		c.cubic.OnAck()
+	}
+	if len(lostPackets) > 0 {
+		c.cubic.OnLoss()
+	}
+}
+
+// OnApplicationLimited implements CongestionController.
+func (c *CubicSender) OnApplicationLimited(bytesInFlight int64) {
+}
+
+// CanSend returns true if the bytes in flight is smaller than
+// the congestion window.
+func (c *CubicSender) CanSend(bytesInFlight, bytes int64) bool {
+	return bytesInFlight < c.CongestionWindow()
+}
+
+// PacingRate returns the congestion window sent in a smoothed RTT.
+func (c *CubicSender) PacingRate() int64 {
+	rtt := c.rttStats.SmoothedRTT()
+	if rtt <= 0 {
+		rtt = defaultInitialRTT
+	}
+	return BandwidthFromBytesAndTimeDelta(c.CongestionWindow(), rtt)
+}
+
+// CongestionWindow returns the congestion window in bytes.
+func (c *CubicSender) CongestionWindow() int64 {
+	return int64(c.cubic.CongestionWindowSize()) * maxDatagramSize
+}
@@ -0,0 +1,231 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package crash recovers from panics in long running goroutines,
+// and saves crash reports that can be packaged into a bug report.
+package crash
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/version"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// maxReports is the maximum number of crash reports stored in the disk.
+	maxReports = 10
+
+	reportPrefix = "crash_"
+	reportSuffix = ".json"
+)
+
+var (
+	// Number of panics recovered.
+	Panics = metrics.RegisterMetric("crash", "Panics", metrics.COUNTER)
+
+	reportDir  atomic.Pointer[string]
+	configHash atomic.Pointer[string]
+
+	// writeMu serializes writing crash reports.
+	writeMu sync.Mutex
+)
+
+// Report is the content of a crash report.
+// It doesn't contain the configuration, which may have secrets.
+type Report struct {
+	Time       string          `json:"time"`
+	Version    string          `json:"version"`
+	Goroutine  string          `json:"goroutine"`
+	Panic      string          `json:"panic"`
+	Stack      string          `json:"stack"`
+	ConfigHash string          `json:"configHash,omitempty"`
+	Metrics    json.RawMessage `json:"metrics,omitempty"`
+}
+
+// SetReportDir sets the directory to save crash reports.
+// If it is not set, crash reports are only printed in the log.
+func SetReportDir(dir string) {
+	reportDir.Store(&dir)
+}
+
+// ReportDir returns the directory to save crash reports.
+func ReportDir() string {
+	if dir := reportDir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// SetConfigHash sets the hash of the running configuration, which is
+// included in crash reports to tell whether two crashes share the
+// same configuration.
+func SetConfigHash(hash string) {
+	configHash.Store(&hash)
+}
+
+// HashConfig returns a short hash of the configuration.
+func HashConfig(config proto.Message) string {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Recover recovers from a panic of the current goroutine, and saves
+// a crash report. The cleanup function, if not nil, is called after
+// that to release the resources owned by the goroutine. Other
+// goroutines continue to run.
+//
+// Recover must be called directly by a defer statement.
+func Recover(goroutine string, cleanup func()) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	Panics.Add(1)
+	report := Report{
+		Time:      time.Now().Format(time.RFC3339Nano),
+		Version:   version.AppVersion,
+		Goroutine: goroutine,
+		Panic:     fmt.Sprint(r),
+		Stack:     string(debug.Stack()),
+	}
+	if hash := configHash.Load(); hash != nil {
+		report.ConfigHash = *hash
+	}
+	if b, err := metrics.GetMetricsAsJSON(); err == nil {
+		report.Metrics = b
+	}
+	log.Errorf("recovered from panic in %s: %s\n%s", goroutine, report.Panic, report.Stack)
+	if path, err := saveReport(report); err != nil {
+		log.Warnf("failed to save crash report: %v", err)
+	} else if path != "" {
+		log.Errorf("crash report is saved to %s", path)
+	}
+	if cleanup != nil {
+		cleanup()
+	}
+}
+
+// ListReports returns the paths of saved crash reports, from the oldest
+// to the newest.
+func ListReports() ([]string, error) {
+	dir := ReportDir()
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("os.ReadDir(%q) failed: %w", dir, err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), reportPrefix) && strings.HasSuffix(entry.Name(), reportSuffix) {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// WriteBundle writes a zip archive with all the saved crash reports,
+// and a file describing the running environment.
+func WriteBundle(w io.Writer) error {
+	paths, err := ListReports()
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	info, err := zw.Create("info.txt")
+	if err != nil {
+		return fmt.Errorf("create info.txt failed: %w", err)
+	}
+	fmt.Fprintf(info, "version: %s\n", version.AppVersion)
+	fmt.Fprintf(info, "time: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(info, "crash reports: %d\n", len(paths))
+	if hash := configHash.Load(); hash != nil {
+		fmt.Fprintf(info, "config hash: %s\n", *hash)
+	}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("os.ReadFile(%q) failed: %w", path, err)
+		}
+		f, err := zw.Create(filepath.Base(path))
+		if err != nil {
+			return fmt.Errorf("create %s failed: %w", filepath.Base(path), err)
+		}
+		if _, err := f.Write(b); err != nil {
+			return fmt.Errorf("write %s failed: %w", filepath.Base(path), err)
+		}
+	}
+	return zw.Close()
+}
+
+// saveReport writes the crash report to the report directory, and
+// returns the file path. The oldest reports are removed if there are
+// too many of them.
+func saveReport(report Report) (string, error) {
+	dir := ReportDir()
+	if dir == "" {
+		return "", nil
+	}
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("os.MkdirAll(%q) failed: %w", dir, err)
+	}
+	b, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("json.MarshalIndent() failed: %w", err)
+	}
+	t := time.Now()
+	name := reportPrefix + t.Format("20060102_150405.000000000") + "_" + strconv.Itoa(os.Getpid()) + reportSuffix
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return "", fmt.Errorf("os.WriteFile(%q) failed: %w", path, err)
+	}
+
+	paths, err := ListReports()
+	if err != nil {
+		return path, nil
+	}
+	for i := 0; i < len(paths)-maxReports; i++ {
+		os.Remove(paths[i])
+	}
+	return path, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package crash
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	SetReportDir(t.TempDir())
+	SetConfigHash("abcd")
+	defer SetReportDir("")
+
+	cleaned := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer Recover("test goroutine", func() { close(cleaned) })
+		var m map[string]int
+		m["boom"] = 1
+	}()
+	<-done
+	select {
+	case <-cleaned:
+	default:
+		t.Fatalf("cleanup function is not called")
+	}
+
+	paths, err := ListReports()
+	if err != nil {
+		t.Fatalf("ListReports() failed: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("got %d crash reports, want 1", len(paths))
+	}
+	b, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("os.ReadFile() failed: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if report.Goroutine != "test goroutine" || report.ConfigHash != "abcd" {
+		t.Errorf("got goroutine %q and config hash %q", report.Goroutine, report.ConfigHash)
+	}
+	if !strings.Contains(report.Panic, "nil map") {
+		t.Errorf("panic %q doesn't describe the error", report.Panic)
+	}
+	if !strings.Contains(report.Stack, "TestRecover") {
+		t.Errorf("stack doesn't contain the panic location")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf); err != nil {
+		t.Fatalf("WriteBundle() failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() failed: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Errorf("got %d files in bundle, want 2", len(zr.File))
+	}
+}
+
+func TestRecoverNoPanic(t *testing.T) {
+	called := false
+	func() {
+		defer Recover("no panic", func() { called = true })
+	}()
+	if called {
+		t.Errorf("cleanup function is called without panic")
+	}
+}
+
+func TestSaveReportLimit(t *testing.T) {
+	SetReportDir(t.TempDir())
+	defer SetReportDir("")
+	for i := 0; i < maxReports+3; i++ {
+		if _, err := saveReport(Report{Panic: "test"}); err != nil {
+			t.Fatalf("saveReport() failed: %v", err)
+		}
+	}
+	paths, err := ListReports()
+	if err != nil {
+		t.Fatalf("ListReports() failed: %v", err)
+	}
+	if len(paths) != maxReports {
+		t.Errorf("got %d crash reports, want %d", len(paths), maxReports)
+	}
+}
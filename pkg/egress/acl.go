@@ -0,0 +1,157 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+// ACL decides whether the server can connect to a destination
+// on behalf of the clients.
+type ACL struct {
+	rules         []aclRule
+	defaultAction appctlpb.ACLAction
+}
+
+// aclRule is a parsed DestinationACLRule.
+type aclRule struct {
+	ipRanges    []*net.IPNet
+	domainNames []string
+	ports       []portRange
+	action      appctlpb.ACLAction
+}
+
+// portRange is an inclusive range of ports.
+type portRange struct {
+	begin int
+	end   int
+}
+
+// NewACL parses the destination ACL config. It returns an error if
+// the config is invalid. A nil config allows all the destinations.
+func NewACL(config *appctlpb.DestinationACL) (*ACL, error) {
+	acl := &ACL{
+		defaultAction: config.GetDefaultAction(),
+	}
+	for i, r := range config.GetRules() {
+		rule := aclRule{
+			action: r.GetAction(),
+		}
+		for _, ipRange := range r.GetIpRanges() {
+			_, ipNet, err := net.ParseCIDR(ipRange)
+			if err != nil {
+				return nil, fmt.Errorf("destination ACL rule %d: invalid IP range %q", i+1, ipRange)
+			}
+			rule.ipRanges = append(rule.ipRanges, ipNet)
+		}
+		for _, domainName := range r.GetDomainNames() {
+			name := strings.ToLower(strings.Trim(domainName, "."))
+			if name == "" {
+				return nil, fmt.Errorf("destination ACL rule %d: domain name is empty", i+1)
+			}
+			rule.domainNames = append(rule.domainNames, name)
+		}
+		for _, port := range r.GetPorts() {
+			pr, err := parseACLPort(port)
+			if err != nil {
+				return nil, fmt.Errorf("destination ACL rule %d: %w", i+1, err)
+			}
+			rule.ports = append(rule.ports, pr)
+		}
+		acl.rules = append(acl.rules, rule)
+	}
+	return acl, nil
+}
+
+// Allow returns true if the server can connect to the destination.
+// fqdn is the domain name of the destination, or empty if the destination
+// is an IP address. ip is the IP address of the destination, or the resolved
+// IP address of the domain name. It also returns the ID of the matched rule,
+// starting from 1, or 0 if no rule is matched.
+func (a *ACL) Allow(fqdn string, ip net.IP, port int) (bool, int) {
+	if a == nil {
+		return true, 0
+	}
+	fqdn = strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	for i, rule := range a.rules {
+		if rule.match(fqdn, ip, port) {
+			return rule.action == appctlpb.ACLAction_ALLOW, i + 1
+		}
+	}
+	return a.defaultAction == appctlpb.ACLAction_ALLOW, 0
+}
+
+func (r aclRule) match(fqdn string, ip net.IP, port int) bool {
+	if len(r.ipRanges) > 0 {
+		matched := false
+		for _, ipNet := range r.ipRanges {
+			if ip != nil && ipNet.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.domainNames) > 0 {
+		matched := false
+		for _, name := range r.domainNames {
+			if fqdn != "" && (fqdn == name || strings.HasSuffix(fqdn, "."+name)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.ports) > 0 {
+		matched := false
+		for _, pr := range r.ports {
+			if port >= pr.begin && port <= pr.end {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// parseACLPort parses a port like "25" or a port range like "6881-6889".
+func parseACLPort(s string) (portRange, error) {
+	begin, end, found := strings.Cut(s, "-")
+	if !found {
+		end = begin
+	}
+	b, err := strconv.Atoi(strings.TrimSpace(begin))
+	if err != nil || b < 1 || b > 65535 {
+		return portRange{}, fmt.Errorf("invalid port %q", s)
+	}
+	e, err := strconv.Atoi(strings.TrimSpace(end))
+	if err != nil || e < 1 || e > 65535 || e < b {
+		return portRange{}, fmt.Errorf("invalid port %q", s)
+	}
+	return portRange{begin: b, end: e}, nil
+}
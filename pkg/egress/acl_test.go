@@ -0,0 +1,113 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/egress"
+)
+
+func TestACL(t *testing.T) {
+	acl, err := egress.NewACL(&appctlpb.DestinationACL{
+		Rules: []*appctlpb.DestinationACLRule{
+			{
+				DomainNames: []string{"allowed.example.com"},
+				Action:      appctlpb.ACLAction_ALLOW.Enum(),
+			},
+			{
+				DomainNames: []string{"example.com"},
+				Action:      appctlpb.ACLAction_DENY.Enum(),
+			},
+			{
+				IpRanges: []string{"10.0.0.0/8", "192.168.0.0/16"},
+				Action:   appctlpb.ACLAction_DENY.Enum(),
+			},
+			{
+				Ports:  []string{"25", "6881-6889"},
+				Action: appctlpb.ACLAction_DENY.Enum(),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewACL() failed: %v", err)
+	}
+	testcases := []struct {
+		fqdn    string
+		ip      string
+		port    int
+		allowed bool
+		ruleID  int
+	}{
+		{"www.allowed.example.com", "1.2.3.4", 443, true, 1},
+		{"WWW.Example.COM.", "1.2.3.4", 443, false, 2},
+		{"badexample.com", "1.2.3.4", 443, true, 0},
+		{"", "10.1.2.3", 443, false, 3},
+		{"intranet.test", "192.168.1.1", 80, false, 3},
+		{"", "1.2.3.4", 25, false, 4},
+		{"", "1.2.3.4", 6885, false, 4},
+		{"", "1.2.3.4", 6890, true, 0},
+	}
+	for _, tc := range testcases {
+		allowed, ruleID := acl.Allow(tc.fqdn, net.ParseIP(tc.ip), tc.port)
+		if allowed != tc.allowed || ruleID != tc.ruleID {
+			t.Errorf("Allow(%q, %s, %d) = %v, %d, want %v, %d", tc.fqdn, tc.ip, tc.port, allowed, ruleID, tc.allowed, tc.ruleID)
+		}
+	}
+}
+
+func TestACLDefaultDeny(t *testing.T) {
+	acl, err := egress.NewACL(&appctlpb.DestinationACL{
+		Rules: []*appctlpb.DestinationACLRule{
+			{
+				Ports:  []string{"80", "443"},
+				Action: appctlpb.ACLAction_ALLOW.Enum(),
+			},
+		},
+		DefaultAction: appctlpb.ACLAction_DENY.Enum(),
+	})
+	if err != nil {
+		t.Fatalf("NewACL() failed: %v", err)
+	}
+	if allowed, _ := acl.Allow("", net.ParseIP("1.2.3.4"), 443); !allowed {
+		t.Errorf("port 443 is denied, want allowed")
+	}
+	if allowed, _ := acl.Allow("", net.ParseIP("1.2.3.4"), 22); allowed {
+		t.Errorf("port 22 is allowed, want denied")
+	}
+
+	var nilACL *egress.ACL
+	if allowed, _ := nilACL.Allow("", net.ParseIP("1.2.3.4"), 22); !allowed {
+		t.Errorf("nil ACL denied the destination")
+	}
+}
+
+func TestACLInvalidConfig(t *testing.T) {
+	rules := []*appctlpb.DestinationACLRule{
+		{IpRanges: []string{"10.0.0.0"}},
+		{DomainNames: []string{"."}},
+		{Ports: []string{"0"}},
+		{Ports: []string{"100-99"}},
+		{Ports: []string{"http"}},
+	}
+	for _, rule := range rules {
+		if _, err := egress.NewACL(&appctlpb.DestinationACL{Rules: []*appctlpb.DestinationACLRule{rule}}); err == nil {
+			t.Errorf("NewACL() with rule %v succeeded, want error", rule)
+		}
+	}
+}
@@ -15,7 +15,11 @@
 
 package egress
 
-import "github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+import (
+	"net"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
 
 type Input struct {
 	Protocol appctlpb.ProxyProtocol
@@ -25,6 +29,18 @@ type Input struct {
 type Action struct {
 	Action appctlpb.EgressAction
 	Proxy  *appctlpb.EgressProxy
+
+	// SourceIP is the local IP address to connect to the destination
+	// directly. It is nil if the system chooses the IP address.
+	SourceIP net.IP
+
+	// InterfaceName is the network interface to connect to the destination
+	// directly. It is empty if the system chooses the network interface.
+	InterfaceName string
+
+	// RuleID is the ID of the matched rule, starting from 1.
+	// It is 0 if no rule is matched.
+	RuleID int
 }
 
 type Controller interface {
@@ -0,0 +1,230 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+)
+
+// Dialer connects to a destination on behalf of the proxy clients.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+var (
+	_ Dialer = &net.Dialer{}
+	_ Dialer = &proxyDialer{}
+)
+
+// NewDirectDialer returns a Dialer that connects to the destination
+// directly. If the source IP address is not nil, it is used as the local
+// address. If the interface name is not empty, the connection is bound to
+// the network interface.
+func NewDirectDialer(sourceIP net.IP, interfaceName string) Dialer {
+	d := &net.Dialer{}
+	if sourceIP != nil {
+		d.LocalAddr = &net.TCPAddr{IP: sourceIP}
+	}
+	if interfaceName != "" {
+		d.Control = sockopts.BindToDevice(interfaceName)
+	}
+	return d
+}
+
+// NewProxyDialer returns a Dialer that connects to the destination through
+// the egress proxy. Only TCP destinations are supported. The handshake with
+// the egress proxy must finish within the timeout, or before the context
+// is done.
+func NewProxyDialer(proxy *appctlpb.EgressProxy, timeout time.Duration) Dialer {
+	return &proxyDialer{
+		proxy:   proxy,
+		timeout: timeout,
+	}
+}
+
+type proxyDialer struct {
+	proxy   *appctlpb.EgressProxy
+	timeout time.Duration
+}
+
+func (d *proxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("network %q is not supported by egress proxy", network)
+	}
+	var dialer net.Dialer
+	proxyAddr := net.JoinHostPort(d.proxy.GetHost(), strconv.Itoa(int(d.proxy.GetPort())))
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("dial to egress proxy %s failed: %w", proxyAddr, err)
+	}
+	deadline, ok := ctx.Deadline()
+	if d.timeout > 0 && (!ok || time.Now().Add(d.timeout).Before(deadline)) {
+		deadline = time.Now().Add(d.timeout)
+	}
+	conn.SetDeadline(deadline)
+	switch d.proxy.GetProtocol() {
+	case appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL:
+		err = socks5Connect(conn, address, d.proxy.GetSocks5Authentication())
+	case appctlpb.ProxyProtocol_HTTP_PROXY_PROTOCOL:
+		conn, err = httpConnect(conn, address, d.proxy.GetHttpAuthentication())
+	default:
+		err = fmt.Errorf("egress proxy protocol %s is not supported", d.proxy.GetProtocol().String())
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// socks5Connect asks the socks5 proxy to connect to the address.
+func socks5Connect(conn net.Conn, address string, auth *appctlpb.Auth) error {
+	method := constant.Socks5NoAuth
+	if auth.GetUser() != "" && auth.GetPassword() != "" {
+		method = constant.Socks5UserPassAuth
+	}
+	if _, err := conn.Write([]byte{constant.Socks5Version, 1, method}); err != nil {
+		return fmt.Errorf("failed to write socks5 authentication header to egress proxy: %w", err)
+	}
+	resp := []byte{0, 0}
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return fmt.Errorf("failed to read socks5 authentication response from egress proxy: %w", err)
+	}
+	if resp[0] != constant.Socks5Version || resp[1] != method {
+		return fmt.Errorf("got unexpected socks5 authentication response from egress proxy: %v", resp)
+	}
+	if method == constant.Socks5UserPassAuth {
+		credential := []byte{constant.Socks5UserPassAuthVersion}
+		credential = append(credential, byte(len(auth.GetUser())))
+		credential = append(credential, []byte(auth.GetUser())...)
+		credential = append(credential, byte(len(auth.GetPassword())))
+		credential = append(credential, []byte(auth.GetPassword())...)
+		if _, err := conn.Write(credential); err != nil {
+			return fmt.Errorf("failed to write socks5 authentication credential to egress proxy: %w", err)
+		}
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			return fmt.Errorf("failed to read socks5 authentication response from egress proxy: %w", err)
+		}
+		if resp[0] != constant.Socks5UserPassAuthVersion || resp[1] != constant.Socks5AuthSuccess {
+			return fmt.Errorf("socks5 authentication with egress proxy failed: %v", resp)
+		}
+	}
+
+	dst, err := addrSpec(address)
+	if err != nil {
+		return err
+	}
+	var req bytes.Buffer
+	req.Write([]byte{constant.Socks5Version, constant.Socks5ConnectCmd, 0})
+	if err := dst.WriteToSocks5(&req); err != nil {
+		return fmt.Errorf("failed to encode destination %s: %w", address, err)
+	}
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return fmt.Errorf("failed to write socks5 request to egress proxy: %w", err)
+	}
+	header := []byte{0, 0, 0}
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("failed to read socks5 response from egress proxy: %w", err)
+	}
+	if header[0] != constant.Socks5Version {
+		return fmt.Errorf("got unexpected socks5 response from egress proxy: %v", header)
+	}
+	if header[1] != constant.Socks5ReplySuccess {
+		return fmt.Errorf("egress proxy failed to connect to %s with socks5 reply %d", address, header[1])
+	}
+	var bind model.AddrSpec
+	if err := bind.ReadFromSocks5(conn); err != nil {
+		return fmt.Errorf("failed to read socks5 bind address from egress proxy: %w", err)
+	}
+	return nil
+}
+
+// httpConnect asks the HTTP proxy to connect to the address with
+// the CONNECT method. The returned connection replaces conn.
+func httpConnect(conn net.Conn, address string, auth *appctlpb.Auth) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if auth.GetUser() != "" && auth.GetPassword() != "" {
+		credential := base64.StdEncoding.EncodeToString([]byte(auth.GetUser() + ":" + auth.GetPassword()))
+		req.Header.Set("Proxy-Authorization", "Basic "+credential)
+	}
+	if err := req.Write(conn); err != nil {
+		return conn, fmt.Errorf("failed to write HTTP CONNECT request to egress proxy: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return conn, fmt.Errorf("failed to read HTTP CONNECT response from egress proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return conn, fmt.Errorf("egress proxy failed to connect to %s with HTTP status %q", address, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		// The destination has sent data after the response.
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads the data buffered by the reader
+// before reading from the connection again.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// addrSpec converts the address in host:port format to AddrSpec.
+func addrSpec(address string) (model.AddrSpec, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return model.AddrSpec{}, fmt.Errorf("invalid destination %q: %w", address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 65535 {
+		return model.AddrSpec{}, fmt.Errorf("invalid port of destination %q", address)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return model.AddrSpec{IP: ip, Port: port}, nil
+	}
+	return model.AddrSpec{FQDN: host, Port: port}, nil
+}
@@ -0,0 +1,214 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/egress"
+	"github.com/enfein/mieru/v3/pkg/socks5"
+	"google.golang.org/protobuf/proto"
+)
+
+// startEchoServer starts a TCP server that echoes the data back,
+// and returns its address.
+func startEchoServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// startHTTPProxy starts an HTTP proxy that only supports the CONNECT
+// method, and returns its port. If auth is not empty, the proxy requires
+// the basic authentication credential.
+func startHTTPProxy(t *testing.T, auth string) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || req.Method != http.MethodConnect {
+					io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\n\r\n")
+					return
+				}
+				if auth != "" && req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)) {
+					io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+					return
+				}
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer target.Close()
+				io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+				common.BidiCopy(conn, target)
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startSocks5Proxy starts a socks5 proxy and returns its port.
+func startSocks5Proxy(t *testing.T) int {
+	server, err := socks5.New(&socks5.Config{
+		AllowLocalDestination: true,
+	})
+	if err != nil {
+		t.Fatalf("socks5.New() failed: %v", err)
+	}
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	go server.ListenAndServe("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	t.Cleanup(func() { server.Close() })
+	time.Sleep(100 * time.Millisecond)
+	return port
+}
+
+func TestProxyDialer(t *testing.T) {
+	echoAddr := startEchoServer(t)
+	testcases := []struct {
+		name    string
+		proxy   *appctlpb.EgressProxy
+		wantErr bool
+	}{
+		{
+			name: "socks5",
+			proxy: &appctlpb.EgressProxy{
+				Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL.Enum(),
+				Port:     proto.Int32(int32(startSocks5Proxy(t))),
+			},
+		},
+		{
+			name: "HTTP",
+			proxy: &appctlpb.EgressProxy{
+				Protocol: appctlpb.ProxyProtocol_HTTP_PROXY_PROTOCOL.Enum(),
+				Port:     proto.Int32(int32(startHTTPProxy(t, ""))),
+			},
+		},
+		{
+			name: "HTTP with authentication",
+			proxy: &appctlpb.EgressProxy{
+				Protocol: appctlpb.ProxyProtocol_HTTP_PROXY_PROTOCOL.Enum(),
+				Port:     proto.Int32(int32(startHTTPProxy(t, "shilishanlu:buhuanjian"))),
+				HttpAuthentication: &appctlpb.Auth{
+					User:     proto.String("shilishanlu"),
+					Password: proto.String("buhuanjian"),
+				},
+			},
+		},
+		{
+			name: "HTTP with wrong password",
+			proxy: &appctlpb.EgressProxy{
+				Protocol: appctlpb.ProxyProtocol_HTTP_PROXY_PROTOCOL.Enum(),
+				Port:     proto.Int32(int32(startHTTPProxy(t, "shilishanlu:buhuanjian"))),
+				HttpAuthentication: &appctlpb.Auth{
+					User:     proto.String("shilishanlu"),
+					Password: proto.String("huanjian"),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.proxy.Host = proto.String("127.0.0.1")
+			ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelFunc()
+			conn, err := egress.NewProxyDialer(tc.proxy, 5*time.Second).DialContext(ctx, "tcp", echoAddr)
+			if tc.wantErr {
+				if err == nil {
+					conn.Close()
+					t.Errorf("DialContext() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DialContext() failed: %v", err)
+			}
+			defer conn.Close()
+			if _, err := conn.Write([]byte("ping")); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			buf := make([]byte, 4)
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				t.Fatalf("io.ReadFull() failed: %v", err)
+			}
+			if string(buf) != "ping" {
+				t.Errorf("got %q, want %q", buf, "ping")
+			}
+		})
+	}
+}
+
+func TestDirectDialer(t *testing.T) {
+	echoAddr := startEchoServer(t)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	conn, err := egress.NewDirectDialer(net.ParseIP("127.0.0.1"), "").DialContext(ctx, "tcp", echoAddr)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("got local IP %v, want 127.0.0.1", ip)
+	}
+
+	// The source IP address is not assigned to this machine.
+	conn, err = egress.NewDirectDialer(net.ParseIP("192.0.2.123"), "").DialContext(ctx, "tcp", echoAddr)
+	if err == nil {
+		conn.Close()
+		t.Errorf("DialContext() with unassigned source IP succeeded, want error")
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+// ruleFile is the content of a rule file, which lists IP ranges and
+// domain names of an egress rule.
+type ruleFile struct {
+	loaded      bool
+	modTime     time.Time
+	size        int64
+	ipRanges    []string
+	domainNames []string
+}
+
+// update reads the rule file again if it is changed since the last read.
+// It returns true if the content is updated.
+func (f *ruleFile) update(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("os.Stat() failed: %w", err)
+	}
+	if f.loaded && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return false, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("os.ReadFile() failed: %w", err)
+	}
+	f.ipRanges, f.domainNames = parseRuleFile(b)
+	f.loaded = true
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return true, nil
+}
+
+// parseRuleFile returns the IP ranges and domain names in the content
+// of a rule file. An IP address is converted to the IP range that only
+// contains the address. Invalid lines are ignored.
+func parseRuleFile(b []byte) (ipRanges, domainNames []string) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(line); err == nil {
+			ipRanges = append(ipRanges, line)
+			continue
+		}
+		if ip := net.ParseIP(line); ip != nil {
+			if ip.To4() != nil {
+				ipRanges = append(ipRanges, line+"/32")
+			} else {
+				ipRanges = append(ipRanges, line+"/128")
+			}
+			continue
+		}
+		if line == "*" || strings.ContainsAny(line, " \t/") || strings.Trim(line, ".") == "" {
+			log.Debugf("egress rule file: ignore invalid line %q", line)
+			continue
+		}
+		domainNames = append(domainNames, line)
+	}
+	return ipRanges, domainNames
+}
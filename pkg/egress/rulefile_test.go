@@ -0,0 +1,109 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+)
+
+func TestParseRuleFile(t *testing.T) {
+	content := `# ad servers
+10.0.0.0/8
+  1.2.3.4
+2001:db8::1
+
+ads.example.com
+*
+bad/domain
+`
+	ipRanges, domainNames := parseRuleFile([]byte(content))
+	wantIPRanges := []string{"10.0.0.0/8", "1.2.3.4/32", "2001:db8::1/128"}
+	wantDomainNames := []string{"ads.example.com"}
+	if !reflect.DeepEqual(ipRanges, wantIPRanges) {
+		t.Errorf("got IP ranges %v, want %v", ipRanges, wantIPRanges)
+	}
+	if !reflect.DeepEqual(domainNames, wantDomainNames) {
+		t.Errorf("got domain names %v, want %v", domainNames, wantDomainNames)
+	}
+}
+
+func TestReloadRuleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	if err := os.WriteFile(path, []byte("1.2.3.0/24\n"), 0644); err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+	controller := NewSocks5Controller(&appctlpb.Egress{
+		Rules: []*appctlpb.EgressRule{
+			{
+				DomainNames: []string{"example.com"},
+				RuleFiles:   []string{path},
+				Action:      appctlpb.EgressAction_REJECT.Enum(),
+			},
+		},
+	})
+	ipInput := Input{
+		Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL,
+		Data:     []byte{5, 1, 0, 1, 1, 2, 3, 4, 5, 6},
+	}
+	domainInput := Input{
+		Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL,
+		Data:     []byte{5, 1, 0, 3, 9, 'g', 'o', 'o', 'g', 'l', 'e', '.', 'i', 'o', 1, 187},
+	}
+	if action := controller.FindAction(ipInput); action.Action != appctlpb.EgressAction_REJECT {
+		t.Errorf("got action %s for IP in rule file, want %s", action.Action.String(), appctlpb.EgressAction_REJECT.String())
+	}
+	if action := controller.FindAction(domainInput); action.Action != appctlpb.EgressAction_DIRECT {
+		t.Errorf("got action %s for domain name not in rule file, want %s", action.Action.String(), appctlpb.EgressAction_DIRECT.String())
+	}
+
+	// Change the rule file.
+	if err := os.WriteFile(path, []byte("google.io\n"), 0644); err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("os.Chtimes() failed: %v", err)
+	}
+	if !controller.loadRuleFiles() {
+		t.Fatalf("loadRuleFiles() = false, want true")
+	}
+	if controller.loadRuleFiles() {
+		t.Errorf("loadRuleFiles() = true for unchanged rule file, want false")
+	}
+	if action := controller.FindAction(ipInput); action.Action != appctlpb.EgressAction_DIRECT {
+		t.Errorf("got action %s for IP removed from rule file, want %s", action.Action.String(), appctlpb.EgressAction_DIRECT.String())
+	}
+	if action := controller.FindAction(domainInput); action.Action != appctlpb.EgressAction_REJECT {
+		t.Errorf("got action %s for domain name in rule file, want %s", action.Action.String(), appctlpb.EgressAction_REJECT.String())
+	}
+
+	// The previous content is kept if the rule file is removed.
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("os.Remove() failed: %v", err)
+	}
+	if controller.loadRuleFiles() {
+		t.Errorf("loadRuleFiles() = true for removed rule file, want false")
+	}
+	if action := controller.FindAction(domainInput); action.Action != appctlpb.EgressAction_REJECT {
+		t.Errorf("got action %s after rule file is removed, want %s", action.Action.String(), appctlpb.EgressAction_REJECT.String())
+	}
+}
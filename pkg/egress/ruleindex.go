@@ -0,0 +1,176 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"math"
+	"net"
+	"strings"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+)
+
+// noRule is the rule index when no rule is matched.
+const noRule = math.MaxInt
+
+// ruleIndex finds the first egress rule that matches a destination.
+// Domain names are stored in a trie of reversed labels, and IP ranges
+// are stored in a binary trie of address prefixes, so the time to find
+// a rule doesn't grow with the number of domain names and IP ranges.
+type ruleIndex struct {
+	rules      []egressRule
+	allIPs     int // first rule that matches all IP addresses
+	allDomains int // first rule that matches all domain names
+	ipv4       *prefixNode
+	ipv6       *prefixNode
+	domains    *labelNode
+}
+
+// prefixNode is a node of the binary trie of IP address prefixes.
+type prefixNode struct {
+	children [2]*prefixNode
+	rule     int // first rule with the prefix ending at this node
+}
+
+// labelNode is a node of the trie of domain name labels,
+// starting from the top level domain.
+type labelNode struct {
+	children map[string]*labelNode
+	rule     int // first rule with the domain name ending at this node
+}
+
+func newRuleIndex(rules []egressRule) *ruleIndex {
+	idx := &ruleIndex{
+		rules:      rules,
+		allIPs:     noRule,
+		allDomains: noRule,
+		ipv4:       &prefixNode{rule: noRule},
+		ipv6:       &prefixNode{rule: noRule},
+		domains:    &labelNode{rule: noRule},
+	}
+	// Rules are added in order, so a node keeps the first rule.
+	for i, rule := range rules {
+		if rule.action == appctlpb.EgressAction_PROXY && rule.proxy == nil {
+			// The proxy is not defined.
+			continue
+		}
+		if rule.allIPs && idx.allIPs == noRule {
+			idx.allIPs = i
+		}
+		if rule.allDomains && idx.allDomains == noRule {
+			idx.allDomains = i
+		}
+		for _, ipNet := range rule.ipRanges {
+			idx.addIPRange(ipNet, i)
+		}
+		for _, name := range rule.domainNames {
+			idx.addDomainName(name, i)
+		}
+	}
+	return idx
+}
+
+func (idx *ruleIndex) addIPRange(ipNet *net.IPNet, rule int) {
+	node := idx.ipv6
+	ip := ipNet.IP
+	ones, _ := ipNet.Mask.Size()
+	if ip4 := ip.To4(); ip4 != nil {
+		// Like net.IPNet.Contains, an IPv4-mapped IPv6 range
+		// matches IPv4 addresses with the last 32 bits of the mask.
+		node = idx.ipv4
+		ip = ip4
+		if len(ipNet.Mask) == net.IPv6len {
+			ones = mathext.Max(ones-96, 0)
+		}
+	}
+	for i := 0; i < ones; i++ {
+		bit := ip[i/8] >> (7 - i%8) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &prefixNode{rule: noRule}
+		}
+		node = node.children[bit]
+	}
+	if node.rule == noRule {
+		node.rule = rule
+	}
+}
+
+func (idx *ruleIndex) addDomainName(name string, rule int) {
+	node := idx.domains
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		child, ok := node.children[labels[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[string]*labelNode)
+			}
+			child = &labelNode{rule: noRule}
+			node.children[labels[i]] = child
+		}
+		node = child
+	}
+	if node.rule == noRule {
+		node.rule = rule
+	}
+}
+
+// matchIP returns the first rule with an IP range that contains the IP address.
+func (idx *ruleIndex) matchIP(ip net.IP) int {
+	node := idx.ipv6
+	if ip4 := ip.To4(); ip4 != nil {
+		node = idx.ipv4
+		ip = ip4
+	}
+	best := mathext.Min(idx.allIPs, node.rule)
+	for i := 0; i < len(ip)*8; i++ {
+		node = node.children[ip[i/8]>>(7-i%8)&1]
+		if node == nil {
+			break
+		}
+		best = mathext.Min(best, node.rule)
+	}
+	return best
+}
+
+// matchDomainName returns the first rule with the domain name or
+// a parent domain of it.
+func (idx *ruleIndex) matchDomainName(fqdn string) int {
+	best := idx.allDomains
+	node := idx.domains
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(fqdn, ".")), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = node.children[labels[i]]
+		if node == nil {
+			break
+		}
+		best = mathext.Min(best, node.rule)
+	}
+	return best
+}
+
+// find returns the index of the first rule that matches the IP address
+// or the domain name. ok is false if no rule is matched.
+func (idx *ruleIndex) find(ip net.IP, fqdn string) (i int, ok bool) {
+	i = noRule
+	if ip != nil {
+		i = idx.matchIP(ip)
+	}
+	if fqdn != "" {
+		i = mathext.Min(i, idx.matchDomainName(fqdn))
+	}
+	return i, i != noRule
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRuleIndex(t *testing.T) {
+	proxies := []*appctlpb.EgressProxy{{Name: proto.String("socks5")}}
+	config := []*appctlpb.EgressRule{
+		{
+			IpRanges: []string{"10.1.0.0/16", "2001:db8::/32", "::ffff:192.168.1.0/120"},
+			Action:   appctlpb.EgressAction_REJECT.Enum(),
+		},
+		{
+			DomainNames: []string{"ads.example.com"},
+			Action:      appctlpb.EgressAction_REJECT.Enum(),
+		},
+		{
+			// The proxy is not defined, so the rule is skipped.
+			DomainNames: []string{"example.org"},
+			Action:      appctlpb.EgressAction_PROXY.Enum(),
+			ProxyName:   proto.String("http"),
+		},
+		{
+			IpRanges:    []string{"10.0.0.0/8"},
+			DomainNames: []string{"example.com", "example.org"},
+			Action:      appctlpb.EgressAction_PROXY.Enum(),
+			ProxyName:   proto.String("socks5"),
+		},
+		{
+			IpRanges: []string{"*"},
+			Action:   appctlpb.EgressAction_DIRECT.Enum(),
+		},
+	}
+	rules := make([]egressRule, 0, len(config))
+	for _, rule := range config {
+		rules = append(rules, newEgressRule(rule, proxies, nil))
+	}
+	idx := newRuleIndex(rules)
+
+	testCases := []struct {
+		ip   string
+		fqdn string
+		want int
+	}{
+		{"10.1.2.3", "", 0},
+		{"10.2.3.4", "", 3},
+		{"11.2.3.4", "", 4},
+		{"::ffff:10.1.2.3", "", 0},
+		{"192.168.1.1", "", 0},
+		{"192.168.2.1", "", 4},
+		{"2001:db8::1", "", 0},
+		{"2001:db9::1", "", 4},
+		{"", "ads.example.com", 1},
+		{"", "x.Ads.Example.com.", 1},
+		{"", "www.example.com", 3},
+		{"", "example.org", 3},
+		{"", "notexample.com", noRule},
+		{"", "com", noRule},
+	}
+	for _, tc := range testCases {
+		got, ok := idx.find(net.ParseIP(tc.ip), tc.fqdn)
+		if got != tc.want || ok != (tc.want != noRule) {
+			t.Errorf("find(%q, %q) = %d, %v, want %d", tc.ip, tc.fqdn, got, ok, tc.want)
+		}
+	}
+}
+
+func TestRuleIndexMatchesLinearScan(t *testing.T) {
+	r := mrand.New(mrand.NewSource(1))
+	labels := []string{"a", "b", "c", "com", "net"}
+	randomDomainName := func() string {
+		n := 1 + r.Intn(4)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = labels[r.Intn(len(labels))]
+		}
+		return strings.Join(parts, ".")
+	}
+	randomIP := func() net.IP {
+		if r.Intn(2) == 0 {
+			return net.IPv4(10, byte(r.Intn(4)), byte(r.Intn(4)), byte(r.Intn(256)))
+		}
+		ip := net.ParseIP("2001:db8::")
+		ip[4] = byte(r.Intn(4))
+		ip[15] = byte(r.Intn(256))
+		return ip
+	}
+
+	var rules []egressRule
+	for i := 0; i < 50; i++ {
+		config := appctlpb.EgressRule{Action: appctlpb.EgressAction_DIRECT.Enum()}
+		for j := 0; j < 3; j++ {
+			ip := randomIP()
+			bits := 8 * len(ip.To4())
+			if bits == 0 {
+				bits = 128
+			}
+			config.IpRanges = append(config.IpRanges, fmt.Sprintf("%s/%d", ip.String(), r.Intn(bits+1)))
+			config.DomainNames = append(config.DomainNames, randomDomainName())
+		}
+		rules = append(rules, newEgressRule(&config, nil, nil))
+	}
+	idx := newRuleIndex(rules)
+
+	linearScan := func(ip net.IP, fqdn string) int {
+		for i, rule := range rules {
+			for _, ipNet := range rule.ipRanges {
+				if ipNet.Contains(ip) {
+					return i
+				}
+			}
+			for _, name := range rule.domainNames {
+				if fqdn == name || strings.HasSuffix(fqdn, "."+name) {
+					return i
+				}
+			}
+		}
+		return noRule
+	}
+	for i := 0; i < 10000; i++ {
+		ip, fqdn := randomIP(), randomDomainName()
+		if got, want := idx.matchIP(ip), linearScan(ip, ""); got != want {
+			t.Fatalf("matchIP(%v) = %d, want %d", ip, got, want)
+		}
+		if got, want := idx.matchDomainName(fqdn), linearScan(nil, fqdn); got != want {
+			t.Fatalf("matchDomainName(%q) = %d, want %d", fqdn, got, want)
+		}
+	}
+}
+
+func BenchmarkRuleIndex(b *testing.B) {
+	config := appctlpb.EgressRule{Action: appctlpb.EgressAction_DIRECT.Enum()}
+	for i := 0; i < 100000; i++ {
+		config.DomainNames = append(config.DomainNames, fmt.Sprintf("host%d.example.com", i))
+		config.IpRanges = append(config.IpRanges, fmt.Sprintf("10.%d.%d.0/24", i/256%256, i%256))
+	}
+	idx := newRuleIndex([]egressRule{newEgressRule(&config, nil, nil)})
+	ip := net.ParseIP("192.168.1.1")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.find(ip, "www.example.net")
+	}
+}
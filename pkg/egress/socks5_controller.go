@@ -16,12 +16,89 @@
 package egress
 
 import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/log"
 )
 
+// ruleFileCheckInterval is the minimum interval to check whether
+// the rule files are changed.
+const ruleFileCheckInterval = 10 * time.Second
+
 type Socks5Controller struct {
 	config *appctlpb.Egress
+	stats  *routeStats
+
+	mu    sync.RWMutex
+	index *ruleIndex
+
+	// ---- rule files ----
+	files     map[string]*ruleFile // key is the file path, keys are not changed after creation
+	reloadMu  sync.Mutex           // held when the rule files are loaded
+	lastCheck atomic.Int64         // unix nano time of the last check of rule files
+}
+
+// egressRule is a parsed EgressRule.
+type egressRule struct {
+	allIPs      bool
+	ipRanges    []*net.IPNet
+	allDomains  bool
+	domainNames []string
+	action      appctlpb.EgressAction
+	proxy       *appctlpb.EgressProxy
+	sourceIP    net.IP
+	ifName      string
+}
+
+// newEgressRule parses the rule, including the IP ranges and domain names
+// of the rule files. Invalid IP ranges and source IP address are ignored.
+func newEgressRule(rule *appctlpb.EgressRule, proxies []*appctlpb.EgressProxy, files map[string]*ruleFile) egressRule {
+	r := egressRule{
+		action:   rule.GetAction(),
+		sourceIP: net.ParseIP(rule.GetSourceIP()),
+		ifName:   rule.GetInterfaceName(),
+	}
+	ipRanges := rule.GetIpRanges()
+	domainNames := rule.GetDomainNames()
+	for _, path := range rule.GetRuleFiles() {
+		if f, ok := files[path]; ok {
+			ipRanges = append(ipRanges[:len(ipRanges):len(ipRanges)], f.ipRanges...)
+			domainNames = append(domainNames[:len(domainNames):len(domainNames)], f.domainNames...)
+		}
+	}
+	for _, ipRange := range ipRanges {
+		if ipRange == "*" {
+			r.allIPs = true
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(ipRange)
+		if err != nil {
+			log.Debugf("egress Socks5Controller: ignore invalid IP range %q", ipRange)
+			continue
+		}
+		r.ipRanges = append(r.ipRanges, ipNet)
+	}
+	for _, domainName := range domainNames {
+		if domainName == "*" {
+			r.allDomains = true
+			continue
+		}
+		if name := strings.ToLower(strings.Trim(domainName, ".")); name != "" {
+			r.domainNames = append(r.domainNames, name)
+		}
+	}
+	for _, proxy := range proxies {
+		if proxy.GetName() == rule.GetProxyName() {
+			r.proxy = proxy
+			break
+		}
+	}
+	return r
 }
 
 var (
@@ -32,12 +109,48 @@ func NewSocks5Controller(config *appctlpb.Egress) *Socks5Controller {
 	if config == nil {
 		config = &appctlpb.Egress{}
 	}
-	return &Socks5Controller{
+	c := &Socks5Controller{
 		config: config,
+		stats:  newRouteStats(len(config.GetRules())),
+		files:  make(map[string]*ruleFile),
 	}
+	for _, rule := range config.GetRules() {
+		for _, path := range rule.GetRuleFiles() {
+			c.files[path] = &ruleFile{}
+		}
+	}
+	c.lastCheck.Store(time.Now().UnixNano())
+	if !c.loadRuleFiles() {
+		c.index = c.buildIndex()
+	}
+	return c
 }
 
 func (c *Socks5Controller) FindAction(in Input) Action {
+	c.maybeReloadRuleFiles()
+	action := c.findAction(in)
+	d := decision{
+		time:        time.Now(),
+		destination: socks5Destination(in.Data),
+		action:      action.Action,
+		ruleID:      action.RuleID,
+	}
+	c.stats.record(d)
+	if c.config.GetLogDecisions() {
+		log.Infof("egress Socks5Controller: request to %s matched rule %d, action is %s", d.destination, d.ruleID, d.action.String())
+	} else {
+		log.Debugf("egress Socks5Controller: request to %s matched rule %d, action is %s", d.destination, d.ruleID, d.action.String())
+	}
+	return action
+}
+
+// RouteStats returns the number of requests matched by each rule,
+// and the recent routing decisions.
+func (c *Socks5Controller) RouteStats() *appctlpb.RouteStats {
+	return c.stats.export(c.config.GetRules())
+}
+
+func (c *Socks5Controller) findAction(in Input) Action {
 	if in.Protocol != appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL {
 		log.Debugf("egress Socks5Controller: %s is not supported", in.Protocol.String())
 		return Action{
@@ -58,18 +171,23 @@ func (c *Socks5Controller) FindAction(in Input) Action {
 			Action: appctlpb.EgressAction_DIRECT,
 		}
 	} else if in.Data[1] == 0x01 {
-		isIP := in.Data[3] == 0x01 || in.Data[3] == 0x04
-		isDomainName := in.Data[3] == 0x03
-		for _, rule := range c.config.GetRules() {
-			if (isIP && len(rule.GetIpRanges()) > 0 && rule.GetIpRanges()[0] == "*") || (isDomainName && len(rule.GetDomainNames()) > 0 && rule.GetDomainNames()[0] == "*") {
-				for _, proxy := range c.config.GetProxies() {
-					if proxy.GetName() == rule.GetProxyName() {
-						return Action{
-							Action: rule.GetAction(),
-							Proxy:  proxy,
-						}
-					}
-				}
+		ip, fqdn, ok := socks5DestinationHost(in.Data)
+		if !ok {
+			return Action{
+				Action: appctlpb.EgressAction_DIRECT,
+			}
+		}
+		c.mu.RLock()
+		index := c.index
+		c.mu.RUnlock()
+		if i, ok := index.find(ip, fqdn); ok {
+			rule := index.rules[i]
+			return Action{
+				Action:        rule.action,
+				Proxy:         rule.proxy,
+				SourceIP:      rule.sourceIP,
+				InterfaceName: rule.ifName,
+				RuleID:        i + 1,
 			}
 		}
 		return Action{
@@ -81,3 +199,84 @@ func (c *Socks5Controller) FindAction(in Input) Action {
 		}
 	}
 }
+
+// socks5DestinationHost returns the IP address or the domain name of
+// the destination in the socks5 request. ok is false if the request
+// is too short or the address type is unknown.
+func socks5DestinationHost(data []byte) (ip net.IP, fqdn string, ok bool) {
+	if len(data) < 4 {
+		return nil, "", false
+	}
+	switch data[3] {
+	case 0x01:
+		if len(data) < 4+net.IPv4len {
+			return nil, "", false
+		}
+		return net.IP(data[4 : 4+net.IPv4len]), "", true
+	case 0x03:
+		if len(data) < 5 || len(data) < 5+int(data[4]) {
+			return nil, "", false
+		}
+		return nil, string(data[5 : 5+int(data[4])]), true
+	case 0x04:
+		if len(data) < 4+net.IPv6len {
+			return nil, "", false
+		}
+		return net.IP(data[4 : 4+net.IPv6len]), "", true
+	default:
+		return nil, "", false
+	}
+}
+
+// buildIndex parses the egress rules with the loaded rule files.
+// The caller must hold reloadMu.
+func (c *Socks5Controller) buildIndex() *ruleIndex {
+	rules := make([]egressRule, 0, len(c.config.GetRules()))
+	for _, rule := range c.config.GetRules() {
+		rules = append(rules, newEgressRule(rule, c.config.GetProxies(), c.files))
+	}
+	return newRuleIndex(rules)
+}
+
+// maybeReloadRuleFiles checks the rule files in the background if
+// they are not checked recently.
+func (c *Socks5Controller) maybeReloadRuleFiles() {
+	if len(c.files) == 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := c.lastCheck.Load()
+	if now-last < int64(ruleFileCheckInterval) || !c.lastCheck.CompareAndSwap(last, now) {
+		return
+	}
+	go c.loadRuleFiles()
+}
+
+// loadRuleFiles reads the rule files that are changed, and rebuilds the
+// egress rules if any of them is changed. If a rule file can't be read,
+// the previous content of the file is used. It returns true if the egress
+// rules are rebuilt.
+func (c *Socks5Controller) loadRuleFiles() bool {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	changed := false
+	for path, f := range c.files {
+		updated, err := f.update(path)
+		if err != nil {
+			log.Warnf("egress Socks5Controller: failed to load rule file: %v", err)
+			continue
+		}
+		if updated {
+			log.Infof("egress Socks5Controller: loaded %d IP ranges and %d domain names from rule file %q", len(f.ipRanges), len(f.domainNames), path)
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	index := c.buildIndex()
+	c.mu.Lock()
+	c.index = index
+	c.mu.Unlock()
+	return true
+}
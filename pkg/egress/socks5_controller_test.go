@@ -18,6 +18,7 @@ package egress_test
 import (
 	crand "crypto/rand"
 	mrand "math/rand"
+	"net"
 	"testing"
 
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
@@ -107,3 +108,157 @@ func TestRule(t *testing.T) {
 		}
 	}
 }
+
+func TestRuleMatching(t *testing.T) {
+	controller := egress.NewSocks5Controller(&appctlpb.Egress{
+		Proxies: []*appctlpb.EgressProxy{
+			{
+				Name:     proto.String("socks5"),
+				Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL.Enum(),
+				Host:     proto.String("127.0.0.1"),
+				Port:     proto.Int32(6789),
+			},
+			{
+				Name:     proto.String("http"),
+				Protocol: appctlpb.ProxyProtocol_HTTP_PROXY_PROTOCOL.Enum(),
+				Host:     proto.String("127.0.0.1"),
+				Port:     proto.Int32(8080),
+			},
+		},
+		Rules: []*appctlpb.EgressRule{
+			{
+				IpRanges: []string{"1.2.3.0/24"},
+				Action:   appctlpb.EgressAction_REJECT.Enum(),
+			},
+			{
+				DomainNames: []string{"google.com"},
+				Action:      appctlpb.EgressAction_PROXY.Enum(),
+				ProxyName:   proto.String("http"),
+			},
+			{
+				IpRanges:    []string{"*"},
+				DomainNames: []string{"*"},
+				Action:      appctlpb.EgressAction_PROXY.Enum(),
+				ProxyName:   proto.String("socks5"),
+			},
+		},
+	})
+	subdomain := egress.Input{
+		Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL,
+		Data:     []byte{5, 1, 0, 3, 15, 'm', 'a', 'i', 'l', '.', 'G', 'o', 'o', 'g', 'l', 'e', '.', 'c', 'o', 'm', 1, 187},
+	}
+	otherDomain := egress.Input{
+		Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL,
+		Data:     []byte{5, 1, 0, 3, 10, 'n', 'o', 't', 'g', 'o', 'o', 'g', 'l', 'e', '.', 1, 187},
+	}
+	testcases := []struct {
+		name      string
+		input     egress.Input
+		action    appctlpb.EgressAction
+		proxyName string
+		ruleID    int
+	}{
+		{"IPv4 in range", inputIPv4, appctlpb.EgressAction_REJECT, "", 1},
+		{"domain name", inputDomainName, appctlpb.EgressAction_PROXY, "http", 2},
+		{"subdomain", subdomain, appctlpb.EgressAction_PROXY, "http", 2},
+		{"other domain name", otherDomain, appctlpb.EgressAction_PROXY, "socks5", 3},
+		{"IPv6", inputIPv6, appctlpb.EgressAction_PROXY, "socks5", 3},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			action := controller.FindAction(tc.input)
+			if action.Action != tc.action || action.Proxy.GetName() != tc.proxyName || action.RuleID != tc.ruleID {
+				t.Errorf("got action %s with proxy %q and rule %d, want %s with proxy %q and rule %d", action.Action.String(), action.Proxy.GetName(), action.RuleID, tc.action.String(), tc.proxyName, tc.ruleID)
+			}
+		})
+	}
+}
+
+func TestRouteStats(t *testing.T) {
+	controller := egress.NewSocks5Controller(&appctlpb.Egress{
+		Proxies: []*appctlpb.EgressProxy{
+			{
+				Name:     proto.String("wrap"),
+				Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL.Enum(),
+				Host:     proto.String("127.0.0.1"),
+				Port:     proto.Int32(6789),
+			},
+		},
+		Rules: []*appctlpb.EgressRule{
+			{
+				DomainNames: []string{"*"},
+				Action:      appctlpb.EgressAction_PROXY.Enum(),
+				ProxyName:   proto.String("wrap"),
+			},
+		},
+	})
+	if action := controller.FindAction(inputIPv4); action.RuleID != 0 {
+		t.Errorf("got rule ID %d for IPv4 input, want 0", action.RuleID)
+	}
+	if action := controller.FindAction(inputDomainName); action.RuleID != 1 {
+		t.Errorf("got rule ID %d for domain name input, want 1", action.RuleID)
+	}
+	controller.FindAction(inputDomainName)
+
+	stats := controller.RouteStats()
+	if len(stats.GetRules()) != 2 {
+		t.Fatalf("got %d rule stats, want 2", len(stats.GetRules()))
+	}
+	if stats.GetRules()[0].GetMatches() != 1 || stats.GetRules()[0].GetAction() != appctlpb.EgressAction_DIRECT {
+		t.Errorf("got unexpected stats of the default action: %v", stats.GetRules()[0])
+	}
+	if stats.GetRules()[1].GetMatches() != 2 || stats.GetRules()[1].GetProxyName() != "wrap" {
+		t.Errorf("got unexpected stats of rule 1: %v", stats.GetRules()[1])
+	}
+	decisions := stats.GetRecentDecisions()
+	if len(decisions) != 3 {
+		t.Fatalf("got %d recent decisions, want 3", len(decisions))
+	}
+	if decisions[0].GetDestination() != "google.com:443" || decisions[0].GetRuleID() != 1 || decisions[0].GetAction() != appctlpb.EgressAction_PROXY {
+		t.Errorf("got unexpected newest decision: %v", decisions[0])
+	}
+	if decisions[2].GetDestination() != "1.2.3.4:1286" || decisions[2].GetRuleID() != 0 || decisions[2].GetAction() != appctlpb.EgressAction_DIRECT {
+		t.Errorf("got unexpected oldest decision: %v", decisions[2])
+	}
+
+	// Only the recent decisions are kept.
+	for i := 0; i < 100; i++ {
+		controller.FindAction(inputIPv6)
+	}
+	decisions = controller.RouteStats().GetRecentDecisions()
+	if len(decisions) != 64 {
+		t.Fatalf("got %d recent decisions, want 64", len(decisions))
+	}
+	for _, d := range decisions {
+		if d.GetDestination() != "[102:304:506:708:90a:b0c:d0e:f10]:4370" {
+			t.Fatalf("got unexpected recent decision: %v", d)
+		}
+	}
+}
+
+func TestDirectRuleSourceIP(t *testing.T) {
+	controller := egress.NewSocks5Controller(&appctlpb.Egress{
+		Rules: []*appctlpb.EgressRule{
+			{
+				IpRanges:      []string{"1.2.3.0/24"},
+				Action:        appctlpb.EgressAction_DIRECT.Enum(),
+				SourceIP:      proto.String("203.0.113.2"),
+				InterfaceName: proto.String("eth1"),
+			},
+		},
+	})
+	action := controller.FindAction(inputIPv4)
+	if action.Action != appctlpb.EgressAction_DIRECT || action.RuleID != 1 {
+		t.Fatalf("got action %s with rule %d, want DIRECT with rule 1", action.Action.String(), action.RuleID)
+	}
+	if !action.SourceIP.Equal(net.ParseIP("203.0.113.2")) {
+		t.Errorf("got source IP %v, want 203.0.113.2", action.SourceIP)
+	}
+	if action.InterfaceName != "eth1" {
+		t.Errorf("got interface name %q, want %q", action.InterfaceName, "eth1")
+	}
+	action = controller.FindAction(inputIPv6)
+	if action.RuleID != 0 || action.SourceIP != nil || action.InterfaceName != "" {
+		t.Errorf("unmatched request got source IP %v and interface name %q from rule %d", action.SourceIP, action.InterfaceName, action.RuleID)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package egress
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxRecentDecisions is the number of recent routing decisions to keep.
+const maxRecentDecisions = 64
+
+// decision is a routing decision of a request.
+type decision struct {
+	time        time.Time
+	destination string
+	action      appctlpb.EgressAction
+	ruleID      int
+}
+
+// routeStats counts the requests matched by each rule,
+// and keeps the recent routing decisions.
+type routeStats struct {
+	mu      sync.Mutex
+	matches []int64    // index is the rule ID, 0 is the default action
+	recent  []decision // ring buffer of recent decisions
+	next    int        // index in recent to store the next decision
+}
+
+func newRouteStats(rules int) *routeStats {
+	return &routeStats{
+		matches: make([]int64, rules+1),
+		recent:  make([]decision, 0, maxRecentDecisions),
+	}
+}
+
+func (s *routeStats) record(d decision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d.ruleID >= 0 && d.ruleID < len(s.matches) {
+		s.matches[d.ruleID]++
+	}
+	if len(s.recent) < maxRecentDecisions {
+		s.recent = append(s.recent, d)
+	} else {
+		s.recent[s.next] = d
+	}
+	s.next = (s.next + 1) % maxRecentDecisions
+}
+
+// export returns the statistics. rules are the egress rules
+// used to decide the actions.
+func (s *routeStats) export(rules []*appctlpb.EgressRule) *appctlpb.RouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &appctlpb.RouteStats{}
+	for id, matches := range s.matches {
+		stats := &appctlpb.RuleStats{
+			RuleID:  proto.Int32(int32(id)),
+			Matches: proto.Int64(matches),
+		}
+		if id == 0 {
+			stats.Action = appctlpb.EgressAction_DIRECT.Enum()
+		} else if id <= len(rules) {
+			stats.Action = rules[id-1].GetAction().Enum()
+			stats.ProxyName = proto.String(rules[id-1].GetProxyName())
+		}
+		res.Rules = append(res.Rules, stats)
+	}
+	for i := 1; i <= len(s.recent); i++ {
+		// Start from the newest decision.
+		d := s.recent[(s.next-i+len(s.recent))%len(s.recent)]
+		res.RecentDecisions = append(res.RecentDecisions, &appctlpb.RouteDecision{
+			Time:        proto.String(d.time.Format(time.RFC3339)),
+			Destination: proto.String(d.destination),
+			Action:      d.action.Enum(),
+			RuleID:      proto.Int32(int32(d.ruleID)),
+		})
+	}
+	return res
+}
+
+// socks5Destination returns the destination host and port of a socks5
+// request, or an empty string if the request is malformed.
+func socks5Destination(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	var host string
+	var rest []byte
+	switch data[3] {
+	case 0x01:
+		if len(data) < 4+net.IPv4len {
+			return ""
+		}
+		host = net.IP(data[4 : 4+net.IPv4len]).String()
+		rest = data[4+net.IPv4len:]
+	case 0x03:
+		if len(data) < 5 || len(data) < 5+int(data[4]) {
+			return ""
+		}
+		host = string(data[5 : 5+int(data[4])])
+		rest = data[5+int(data[4]):]
+	case 0x04:
+		if len(data) < 4+net.IPv6len {
+			return ""
+		}
+		host = net.IP(data[4 : 4+net.IPv6len]).String()
+		rest = data[4+net.IPv6len:]
+	default:
+		return ""
+	}
+	if len(rest) < 2 {
+		return ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(rest))))
+}
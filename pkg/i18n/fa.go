@@ -0,0 +1,255 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+import "github.com/enfein/mieru/v3/pkg/stderror"
+
+// faMessages contains Farsi translations.
+var faMessages = map[string]string{
+	// Command line parser and help.
+	"%q is not a valid command. Run \"%s help\" to get the list of supported commands": "%q یک دستور معتبر نیست. برای دیدن فهرست دستورهای پشتیبانی‌شده \"%s help\" را اجرا کنید",
+	"unexpected arguments %q after %q":                                                 "آرگومان‌های غیرمنتظره %q پس از %q",
+	"unsupported language %q, supported languages are en, zh_CN and fa":                "زبان %q پشتیبانی نمی‌شود، زبان‌های پشتیبانی‌شده en، zh_CN و fa هستند",
+	"Usage: %s <COMMAND> [<ARGS>]":                                                     "نحوه استفاده: %s <COMMAND> [<ARGS>]",
+	"Commands:":                                                                        "دستورها:",
+	"Commands for developers and experienced users:":                                   "دستورها برای توسعه‌دهندگان و کاربران باتجربه:",
+	"%s is provided more than once":                                                    "%s بیش از یک بار داده شده است",
+	"usage: %s %s <USER@HOST> <COMMAND>":                                               "نحوه استفاده: %s %s <USER@HOST> <COMMAND>",
+	"invalid SSH destination %q":                                                       "مقصد SSH نامعتبر %q",
+	"%s doesn't support %s":                                                            "%s از %s پشتیبانی نمی‌کند",
+	"SSH connection to %q failed":                                                      "اتصال SSH به %q ناموفق بود",
+	"remote command on %q failed with exit code %d":                                    "دستور راه دور روی %q با کد خروج %d ناموفق بود",
+
+	// mieru client commands.
+	"Show mieru client help. If a command is provided, only show the help of matching commands.": "نمایش راهنمای کلاینت mieru. اگر دستوری داده شود، فقط راهنمای دستورهای منطبق نمایش داده می‌شود.",
+	"Start mieru client in background.":                              "اجرای کلاینت mieru در پس‌زمینه.",
+	"Stop mieru client.":                                             "توقف کلاینت mieru.",
+	"Check mieru client status.":                                     "بررسی وضعیت کلاینت mieru.",
+	"Test mieru client connection to the Internet via proxy server.": "آزمایش اتصال کلاینت mieru به اینترنت از طریق سرور پراکسی.",
+	"Connect to every proxy server in the active profile and show whether it responds. mieru client doesn't need to be running.":                                                       "به هر سرور پروکسی در پروفایل فعال متصل شده و نشان می‌دهد که آیا پاسخ می‌دهد. نیازی به اجرای کلاینت mieru نیست.",
+	"Measure mieru client connection to the proxy server continuously and print a summary every minute. If a CSV or JSON file is provided, the measurements are appended to the file.": "اتصال کلاینت mieru به سرور پروکسی را به طور پیوسته اندازه‌گیری کرده و هر دقیقه یک خلاصه چاپ می‌کند. اگر فایل CSV یا JSON ارائه شود، اندازه‌گیری‌ها به انتهای فایل افزوده می‌شوند.",
+	"Create a client configuration profile interactively.":     "ایجاد تعاملی پروفایل پیکربندی کلاینت.",
+	"Apply client configuration from JSON, YAML or TOML file.": "اعمال پیکربندی کلاینت از فایل JSON، YAML یا TOML.",
+	"Use a preset of settings in a client configuration profile. Valid presets are balanced, low-latency, low-bandwidth and paranoid. If profile name is not provided, the active profile is used.": "استفاده از یک مجموعه تنظیمات از پیش تعریف‌شده در یک پروفایل پیکربندی کلاینت. مقادیر معتبر balanced، low-latency، low-bandwidth و paranoid هستند. اگر نام پروفایل ارائه نشود، پروفایل فعال استفاده می‌شود.",
+	"Apply DNS settings in client configuration to the running mieru client.":                           "اعمال تنظیمات DNS پیکربندی کلاینت روی کلاینت mieru در حال اجرا.",
+	"Show current client configuration. The format can be json, yaml or toml, and the default is json.": "نمایش پیکربندی فعلی کلاینت. قالب می‌تواند json، yaml یا toml باشد و پیش‌فرض json است.",
+	"Import client configuration from URL.":                                                             "وارد کردن پیکربندی کلاینت از URL.",
+	"Export client configuration as URL.":                                                               "خروجی گرفتن از پیکربندی کلاینت به صورت URL.",
+	"Encrypt the stored client configuration with a passphrase. The passphrase is read from MIERU_CONFIG_PASSPHRASE environment variable, or the file of MIERU_CONFIG_PASSPHRASE_FILE environment variable. Otherwise, it is typed in the terminal.": "رمزگذاری پیکربندی ذخیره‌شده کلاینت با یک عبارت عبور. عبارت عبور از متغیر محیطی MIERU_CONFIG_PASSPHRASE یا فایل متغیر محیطی MIERU_CONFIG_PASSPHRASE_FILE خوانده می‌شود. در غیر این صورت، در ترمینال وارد می‌شود.",
+	"Store the client configuration without encryption.": "ذخیره پیکربندی کلاینت بدون رمزگذاری.",
+	"Passphrase of client config: ":                      "عبارت عبور پیکربندی کلاینت: ",
+	"New passphrase of client config: ":                  "عبارت عبور جدید پیکربندی کلاینت: ",
+	"Type the passphrase again: ":                        "عبارت عبور را دوباره وارد کنید: ",
+	"The passphrases don't match.":                       "عبارت‌های عبور مطابقت ندارند.",
+	"Client configuration is already encrypted.":         "پیکربندی کلاینت قبلاً رمزگذاری شده است.",
+	"Client configuration is encrypted. Provide the passphrase with MIERU_CONFIG_PASSPHRASE or MIERU_CONFIG_PASSPHRASE_FILE environment variable if the client is started without a terminal.": "پیکربندی کلاینت رمزگذاری شد. اگر کلاینت بدون ترمینال اجرا می‌شود، عبارت عبور را با متغیر محیطی MIERU_CONFIG_PASSPHRASE یا MIERU_CONFIG_PASSPHRASE_FILE ارائه دهید.",
+	"Client configuration is not encrypted.":                                      "پیکربندی کلاینت رمزگذاری نشده است.",
+	"Client configuration is decrypted.":                                          "رمزگذاری پیکربندی کلاینت برداشته شد.",
+	"Delete an inactive client configuration profile.":                            "حذف یک پروفایل پیکربندی غیرفعال کلاینت.",
+	"Delete HTTP(S) proxy. Allow socks5 user password authentication to be used.": "حذف پراکسی HTTP(S). امکان استفاده از احراز هویت نام کاربری و رمز عبور socks5 را فراهم می‌کند.",
+	"Delete socks5 user password authentication. Allow HTTP(S) proxy to be used.": "حذف احراز هویت نام کاربری و رمز عبور socks5. امکان استفاده از پراکسی HTTP(S) را فراهم می‌کند.",
+	"Get mieru client metrics.":                                                   "دریافت معیارهای کلاینت mieru.",
+	"Get mieru client connections.":                                               "دریافت اتصال‌های کلاینت mieru.",
+	"Get the recent log of mieru client. With --follow, keep printing the new log. With --level, only print the log at the level or more severe.": "دریافت لاگ‌های اخیر کلاینت mieru. با --follow، لاگ‌های جدید به‌طور پیوسته چاپ می‌شوند. با --level، فقط لاگ‌های آن سطح یا شدیدتر چاپ می‌شوند.",
+	"Get the destinations that transfer the most bytes through mieru client.":                                                                     "دریافت مقصدهایی که بیشترین بایت را از طریق کلاینت mieru منتقل می‌کنند.",
+	"Get mieru client auto route statistics.":                                        "دریافت آمار مسیریابی خودکار کلاینت mieru.",
+	"Show mieru client version.":                                                     "نمایش نسخه کلاینت mieru.",
+	"Check mieru client update.":                                                     "بررسی به‌روزرسانی کلاینت mieru.",
+	"Package the crash reports of mieru client into the zip file.":                   "بسته‌بندی گزارش‌های خرابی کلاینت mieru در فایل zip.",
+	"Print shell completion script. Supported shells are bash, zsh and fish.":        "چاپ اسکریپت تکمیل خودکار پوسته. پوسته‌های پشتیبانی‌شده bash، zsh و fish هستند.",
+	"unsupported shell %q, supported shells are bash, zsh and fish":                  "پوسته %q پشتیبانی نمی‌شود، پوسته‌های پشتیبانی‌شده bash، zsh و fish هستند",
+	"no help is found for %q. Run \"%s help\" to get the list of supported commands": "راهنمایی برای %q پیدا نشد. برای دیدن فهرست دستورهای پشتیبانی‌شده \"%s help\" را اجرا کنید",
+	"Run mieru client in foreground.":                                                "اجرای کلاینت mieru در پیش‌زمینه.",
+	"Get the internal state of mieru client sessions for debugging. If the session ID is not provided, all the sessions are dumped.": "دریافت وضعیت داخلی نشست‌های کلاینت mieru برای اشکال‌زدایی. اگر شناسه نشست ارائه نشود، همه نشست‌ها خروجی داده می‌شوند.",
+	"Get mieru client thread dump.":                                "دریافت thread dump کلاینت mieru.",
+	"Get mieru client heap profile and save results to the file.":  "دریافت heap profile کلاینت mieru و ذخیره نتیجه در فایل.",
+	"Get mieru client memory statistics.":                          "دریافت آمار حافظه کلاینت mieru.",
+	"Start mieru client CPU profile and save results to the file.": "شروع CPU profile کلاینت mieru و ذخیره نتیجه در فایل.",
+	"Stop mieru client CPU profile.":                               "توقف CPU profile کلاینت mieru.",
+
+	// mieru client messages.
+	"mieru client is running, listening to socks5://0.0.0.0:%d":                                        "کلاینت mieru در حال اجراست و به socks5://0.0.0.0:%d گوش می‌دهد",
+	"mieru client is running, listening to socks5://127.0.0.1:%d":                                      "کلاینت mieru در حال اجراست و به socks5://127.0.0.1:%d گوش می‌دهد",
+	"mieru client is started, listening to socks5://0.0.0.0:%d":                                        "کلاینت mieru شروع شد و به socks5://0.0.0.0:%d گوش می‌دهد",
+	"mieru client is started, listening to socks5://127.0.0.1:%d":                                      "کلاینت mieru شروع شد و به socks5://127.0.0.1:%d گوش می‌دهد",
+	"%s, please create one with \"mieru apply config <FILE>\" command":                                 "%s، لطفاً با دستور \"mieru apply config <FILE>\" آن را ایجاد کنید",
+	"mieru client is running":                                                                          "کلاینت mieru در حال اجراست",
+	"mieru client is stopped":                                                                          "کلاینت mieru متوقف شد",
+	"active profile: %s":                                                                               "پروفایل فعال: %s",
+	"proxy is disabled by a profile schedule":                                                          "پراکسی توسط زمان‌بندی پروفایل غیرفعال شده است",
+	"Preset %s is applied to profile %q. Restart mieru client to use it.":                              "پیش‌تنظیم %s روی پروفایل %q اعمال شد. برای استفاده از آن، کلاینت mieru را دوباره راه‌اندازی کنید.",
+	"unable to write to disk, data is kept in memory: %s":                                              "نوشتن روی دیسک ممکن نیست، داده‌ها در حافظه نگه داشته می‌شوند: %s",
+	"connection quality score: %d (round trip time %d ms, loss rate %.1f%%, dial failure rate %.1f%%)": "امتیاز کیفیت اتصال: %d (زمان رفت و برگشت %d میلی‌ثانیه، نرخ از دست رفتن بسته %.1f%%، نرخ شکست اتصال %.1f%%)",
+	"proxy server version: %s":                                                                         "نسخه سرور پراکسی: %s",
+	"proxy server version: unknown, the server is too old to advertise the version":                    "نسخه سرور پراکسی: نامشخص، سرور قدیمی‌تر از آن است که نسخه خود را اعلام کند",
+	"proxy server features: %s":                                                                        "قابلیت‌های سرور پراکسی: %s",
+	"proxy server doesn't support: %s. Upgrade the server to use these features.":                      "سرور پراکسی از این موارد پشتیبانی نمی‌کند: %s. برای استفاده از این قابلیت‌ها سرور را به‌روزرسانی کنید.",
+	"monitoring the connection to the proxy server every %v, press Ctrl+C to stop":                     "پایش اتصال به سرور پروکسی هر %v، برای توقف Ctrl+C را فشار دهید",
+	"probe succeeded in %d ms, round trip time %d ms, loss rate %.1f%%":                                "کاوش در %d میلی‌ثانیه موفق بود، زمان رفت و برگشت %d میلی‌ثانیه، نرخ از دست رفتن %.1f%%",
+	"probe failed: %s": "کاوش ناموفق بود: %s",
+	"last %v: %d/%d probes succeeded, average latency %d ms, maximum latency %d ms, average round trip time %d ms, average loss rate %.1f%%": "%v اخیر: %d/%d کاوش موفق بود، میانگین تأخیر %d میلی‌ثانیه، بیشینه تأخیر %d میلی‌ثانیه، میانگین زمان رفت و برگشت %d میلی‌ثانیه، میانگین نرخ از دست رفتن %.1f%%",
+	"Connected to %q after %v":                                                   "اتصال به %q پس از %v برقرار شد",
+	"DNS settings are applied to mieru client.":                                  "تنظیمات DNS روی کلاینت mieru اعمال شد.",
+	"HTTP proxy is already deleted from client config.":                          "پراکسی HTTP قبلاً از پیکربندی کلاینت حذف شده است.",
+	"HTTP proxy is deleted from client config.":                                  "پراکسی HTTP از پیکربندی کلاینت حذف شد.",
+	"socks5 user password authentication is already deleted from client config.": "احراز هویت نام کاربری و رمز عبور socks5 قبلاً از پیکربندی کلاینت حذف شده است.",
+	"socks5 user password authentication is deleted from client config.":         "احراز هویت نام کاربری و رمز عبور socks5 از پیکربندی کلاینت حذف شد.",
+	"heap profile is saved to %q":                                                "heap profile در %q ذخیره شد",
+	"bug report is saved to %q":                                                  "گزارش اشکال در %q ذخیره شد",
+	"CPU profile will be saved to %q":                                            "CPU profile در %q ذخیره خواهد شد",
+	"unable to connect to proxy server: %v":                                      "اتصال به سرور پراکسی ممکن نیست: %v",
+	"proxy server doesn't accept the user; check the user name, password, and that the system time of client and server is in sync: %v": "سرور پراکسی کاربر را نمی‌پذیرد؛ نام کاربری، رمز عبور و همگام بودن زمان سیستم کلاینت و سرور را بررسی کنید: %v",
+	"proxy server doesn't respond; the server may be unreachable, or the user name, password or system time is wrong: %v":               "سرور پراکسی پاسخ نمی‌دهد؛ ممکن است سرور در دسترس نباشد، یا نام کاربری، رمز عبور یا زمان سیستم اشتباه باشد: %v",
+	"none of the %d proxy server endpoints is reachable":                                                                                "هیچ‌یک از %d نقطه پایانی سرور پراکسی در دسترس نیست",
+
+	// mieru client setup wizard.
+	"This wizard creates a client profile step by step. Press Enter to accept the default value in brackets.": "این راهنما یک پروفایل کلاینت را گام به گام ایجاد می‌کند. برای پذیرفتن مقدار پیش‌فرض داخل کروشه، Enter را فشار دهید.",
+	"Profile name":                           "نام پروفایل",
+	"Proxy server IP address or domain name": "نشانی IP یا نام دامنه سرور پراکسی",
+	"Transport protocol (TCP, UDP or TLS)":   "پروتکل انتقال (TCP، UDP یا TLS)",
+	"Proxy server port":                      "پورت سرور پراکسی",
+	"TLS server name of the proxy server":    "نام سرور TLS سرور پراکسی",
+	"User name":                              "نام کاربری",
+	"Password":                               "رمز عبور",
+	"Preset (none, balanced, low-latency, low-bandwidth or paranoid)": "پیش‌تنظیم (none، balanced، low-latency، low-bandwidth یا paranoid)",
+	"Local socks5 proxy port":                             "پورت محلی پراکسی socks5",
+	"Local RPC port":                                      "پورت محلی RPC",
+	"Resolved %s to %v":                                   "%s به %v ترجمه شد",
+	"Unable to resolve %s: %v":                            "ترجمه %s ممکن نیست: %v",
+	"Connected to %s":                                     "به %s متصل شد",
+	"Unable to connect to %s: %v":                         "اتصال به %s ممکن نیست: %v",
+	"TLS handshake with %s succeeded":                     "دست‌دهی TLS با %s موفق بود",
+	"TLS handshake with %s failed: %v":                    "دست‌دهی TLS با %s ناموفق بود: %v",
+	"UDP port can't be checked before the client starts.": "پیش از شروع کلاینت نمی‌توان پورت UDP را بررسی کرد.",
+	"Continue anyway?":                                    "با این حال ادامه می‌دهید؟",
+	"Save the profile above?":                             "پروفایل بالا ذخیره شود؟",
+	"Profile %q is saved.":                                "پروفایل %q ذخیره شد.",
+	"Run \"mieru start\" to start the client, and \"mieru test\" to check the connection.": "برای شروع کلاینت \"mieru start\" و برای بررسی اتصال \"mieru test\" را اجرا کنید.",
+	"Setup is canceled.":                     "راه‌اندازی لغو شد.",
+	"A value is required.":                   "وارد کردن یک مقدار الزامی است.",
+	"Please answer yes or no.":               "لطفاً yes یا no پاسخ دهید.",
+	"Invalid transport protocol %q.":         "پروتکل انتقال %q نامعتبر است.",
+	"Invalid preset %q.":                     "پیش‌تنظیم %q نامعتبر است.",
+	"Port number must be between %d and %d.": "شماره پورت باید بین %d و %d باشد.",
+
+	// mita server commands.
+	"Show mita server help. If a command is provided, only show the help of matching commands.": "نمایش راهنمای سرور mita. اگر دستوری داده شود، فقط راهنمای دستورهای منطبق نمایش داده می‌شود.",
+	"Start mita server proxy service.":                                                                  "شروع سرویس پراکسی سرور mita.",
+	"Stop mita server proxy service.":                                                                   "توقف سرویس پراکسی سرور mita.",
+	"Stop mita server proxy service after connections finish.":                                          "توقف سرویس پراکسی سرور mita پس از پایان اتصال‌ها.",
+	"Reload mita server configuration without stopping proxy service.":                                  "بارگذاری مجدد پیکربندی سرور mita بدون توقف سرویس پراکسی.",
+	"Check mita server proxy service status.":                                                           "بررسی وضعیت سرویس پراکسی سرور mita.",
+	"Apply server configuration from JSON, YAML or TOML file.":                                          "اعمال پیکربندی سرور از فایل JSON، YAML یا TOML.",
+	"Show current server configuration. The format can be json, yaml or toml, and the default is json.": "نمایش پیکربندی فعلی سرور. قالب می‌تواند json، yaml یا toml باشد و پیش‌فرض json است.",
+	"Delete a user from server configuration.":                                                          "حذف یک کاربر از پیکربندی سرور.",
+	"Add a user to the running server. A random password is generated if it is not provided.":           "افزودن یک کاربر به سرور در حال اجرا. اگر گذرواژه ارائه نشود، یک گذرواژه تصادفی ساخته می‌شود.",
+	"Change the password of a user, or disable or enable a user, on the running server.":                "تغییر گذرواژه یک کاربر، یا غیرفعال یا فعال کردن یک کاربر، در سرور در حال اجرا.",
+	"Remove a user from the running server and close the connections of the user.":                      "حذف یک کاربر از سرور در حال اجرا و بستن اتصال‌های آن کاربر.",
+	"Add or update users from a CSV or JSON file.":                                                      "افزودن یا به‌روزرسانی کاربران از فایل CSV یا JSON.",
+	"Save users to a CSV or JSON file.":                                                                 "ذخیره کاربران در فایل CSV یا JSON.",
+	"Create a guest user that expires after the duration, e.g. 24h.":                                    "ایجاد کاربر مهمان که پس از مدت زمان داده‌شده منقضی می‌شود، برای نمونه 24h.",
+	"Get mita server metrics.":                                                                          "دریافت معیارهای سرور mita.",
+	"Get mita server connections.":                                                                      "دریافت اتصال‌های سرور mita.",
+	"Get the recent log of mita server. With --follow, keep printing the new log. With --level, only print the log at the level or more severe.": "دریافت لاگ‌های اخیر سرور mita. با --follow، لاگ‌های جدید به‌طور پیوسته چاپ می‌شوند. با --level، فقط لاگ‌های آن سطح یا شدیدتر چاپ می‌شوند.",
+	"Show mita server version.":      "نمایش نسخه سرور mita.",
+	"Check mita server update.":      "بررسی به‌روزرسانی سرور mita.",
+	"Run mita server in foreground.": "اجرای سرور mita در پیش‌زمینه.",
+	"Get the internal state of mita server sessions for debugging. If the session ID is not provided, all the sessions are dumped.": "دریافت وضعیت داخلی نشست‌های سرور mita برای اشکال‌زدایی. اگر شناسه نشست ارائه نشود، همه نشست‌ها خروجی داده می‌شوند.",
+	"Get mita server thread dump.":                                                                "دریافت thread dump سرور mita.",
+	"Get mita server heap profile and save results to the file.":                                  "دریافت heap profile سرور mita و ذخیره نتیجه در فایل.",
+	"Get mita server memory statistics.":                                                          "دریافت آمار حافظه سرور mita.",
+	"Get mita server egress rule statistics.":                                                     "دریافت آمار قوانین خروجی سرور mita.",
+	"Get the traffic of each user of mita server.":                                                "دریافت آمار ترافیک هر کاربر سرور mita.",
+	"Get the bytes and segments transferred by each user and each active session of mita server.": "دریافت بایت‌ها و سگمنت‌های منتقل‌شده توسط هر کاربر و هر نشست فعال سرور mita.",
+	"Get the destinations of a user that transfer the most bytes through mita server.":            "دریافت مقصدهای یک کاربر که بیشترین بایت را از طریق سرور mita منتقل می‌کنند.",
+	"Start mita server CPU profile and save results to the file.":                                 "شروع CPU profile سرور mita و ذخیره نتیجه در فایل.",
+	"Stop mita server CPU profile.":                                                               "توقف CPU profile سرور mita.",
+	"Run the command on a remote mita server with SSH. Input files are read from this computer.":  "اجرای دستور روی سرور mita راه دور با SSH. فایل‌های ورودی از این رایانه خوانده می‌شوند.",
+
+	// mita server messages.
+	"mita server proxy is running": "پراکسی سرور mita در حال اجراست",
+	"mita server proxy is started": "پراکسی سرور mita شروع شد",
+	"mita server proxy is stopped": "پراکسی سرور mita متوقف شد",
+	"mita server proxy is drained": "پراکسی سرور mita تخلیه شد",
+	"mita server is reloaded":      "سرور mita دوباره بارگذاری شد",
+	"mita server status is %q":     "وضعیت سرور mita %q است",
+
+	// mita users messages.
+	"%d users are imported":                     "%d کاربر وارد شد",
+	"Generated passwords:":                      "گذرواژه‌های ساخته‌شده:",
+	"%d users are exported to %q":               "%d کاربر به %q صادر شد",
+	"file %q must have .csv or .json extension": "پسوند فایل %q باید .csv یا .json باشد",
+	"Guest user: %s":                            "کاربر مهمان: %s",
+	"Password: %s":                              "گذرواژه: %s",
+	"Expire time: %s":                           "زمان انقضا: %s",
+	"Client URL: %s":                            "URL کلاینت: %s",
+	"User %q is added":                          "کاربر %q اضافه شد",
+	"User %q is updated":                        "کاربر %q به‌روزرسانی شد",
+	"User %q is removed":                        "کاربر %q حذف شد",
+
+	// Common errors.
+	stderror.AddUserFailedErr:                        "افزودن کاربر ناموفق بود: %w",
+	stderror.ClientConfigIsEmpty:                     "پیکربندی کلاینت mieru خالی است",
+	stderror.ClientConfigNotExist:                    "فایل پیکربندی کلاینت mieru وجود ندارد",
+	stderror.ClientGetActiveProfileFailedErr:         "دریافت پروفایل فعال کلاینت mieru ناموفق بود: %w",
+	stderror.ClientNotRunning:                        "کلاینت mieru در حال اجرا نیست",
+	stderror.ClientNotRunningErr:                     "کلاینت mieru در حال اجرا نیست: %w",
+	stderror.CreateBugReportFailedErr:                "ایجاد گزارش اشکال ناموفق بود: %w",
+	stderror.CreateClientLifecycleRPCClientFailedErr: "ایجاد کلاینت RPC چرخه عمر کلاینت mieru ناموفق بود: %w",
+	stderror.CreateEmptyServerConfigFailedErr:        "ایجاد فایل خالی پیکربندی سرور mita ناموفق بود: %w",
+	stderror.CreateServerConfigRPCClientFailedErr:    "ایجاد کلاینت RPC پیکربندی سرور mita ناموفق بود: %w",
+	stderror.CreateServerLifecycleRPCClientFailedErr: "ایجاد کلاینت RPC چرخه عمر سرور mita ناموفق بود: %w",
+	stderror.CreateSocks5ServerFailedErr:             "ایجاد سرور socks5 ناموفق بود: %w",
+	stderror.CreateTracerFailedErr:                   "ایجاد ردیاب ناموفق بود: %w",
+	stderror.DecodeHashedPasswordFailedErr:           "رمزگشایی رمز عبور هش‌شده ناموفق بود: %w",
+	stderror.DrainServerProxyFailedErr:               "تخلیه پراکسی سرور mita ناموفق بود: %w",
+	stderror.ExitFailedErr:                           "خروج از فرایند ناموفق بود: %w",
+	stderror.GetClientConfigFailedErr:                "دریافت پیکربندی کلاینت mieru ناموفق بود: %w",
+	stderror.GetConnectionsFailedErr:                 "دریافت اتصال‌ها ناموفق بود: %w",
+	stderror.GetDestinationStatsFailedErr:            "دریافت آمار مقصدها ناموفق بود: %w",
+	stderror.GetHeapProfileFailedErr:                 "دریافت heap profile ناموفق بود: %w",
+	stderror.GetLogsFailedErr:                        "دریافت لاگ‌ها ناموفق بود: %w",
+	stderror.GetManagementTokenFailedErr:             "دریافت توکن API مدیریت ناموفق بود: %w",
+	stderror.GetMemoryStatisticsFailedErr:            "دریافت آمار حافظه ناموفق بود: %w",
+	stderror.GetMetricsFailedErr:                     "دریافت معیارها ناموفق بود: %w",
+	stderror.GetRouteStatsFailedErr:                  "دریافت آمار مسیریابی ناموفق بود: %w",
+	stderror.GetServerConfigFailedErr:                "دریافت پیکربندی سرور mita ناموفق بود: %w",
+	stderror.GetServerStatusFailedErr:                "دریافت وضعیت سرور mita ناموفق بود: %w",
+	stderror.GetSessionStatesFailedErr:               "دریافت وضعیت نشست‌ها ناموفق بود: %w",
+	stderror.GetThreadDumpFailedErr:                  "دریافت thread dump ناموفق بود: %w",
+	stderror.GetTrafficFailedErr:                     "دریافت ترافیک ناموفق بود: %w",
+	stderror.GetUsersFailedErr:                       "دریافت کاربران ناموفق بود: %w",
+	stderror.GetUserStatsFailedErr:                   "دریافت آمار کاربران ناموفق بود: %w",
+	stderror.InvalidPortBindingsErr:                  "اتصال پورت نامعتبر است: %w",
+	stderror.InvalidTransportProtocol:                "پروتکل انتقال نامعتبر است",
+	stderror.IPAddressNotFound:                       "نشانی IP برای نام دامنه %q پیدا نشد",
+	stderror.LookupIPFailedErr:                       "جستجوی نشانی IP ناموفق بود: %w",
+	stderror.ParseIPFailed:                           "تجزیه نشانی IP ناموفق بود",
+	stderror.ReloadServerFailedErr:                   "بارگذاری مجدد سرور mita ناموفق بود: %w",
+	stderror.RemoveUserFailedErr:                     "حذف کاربر ناموفق بود: %w",
+	stderror.ServerNotRunningErr:                     "سرویس پس‌زمینه سرور mita در حال اجرا نیست: %w",
+	stderror.ServerNotRunningWithCommand:             "سرویس پس‌زمینه سرور mita در حال اجرا نیست؛ برای راه‌اندازی آن دستور \"sudo systemctl restart mita\" را اجرا کنید؛ اگر راه‌اندازی نشد، برای دیدن گزارش‌ها دستور \"sudo journalctl -e -u mita --no-pager\" را اجرا کنید",
+	stderror.ServerProxyNotRunningErr:                "پراکسی سرور mita در حال اجرا نیست: %w",
+	stderror.SetServerConfigFailedErr:                "تنظیم پیکربندی سرور mita ناموفق بود: %w",
+	stderror.StartClientFailedErr:                    "شروع کلاینت mieru ناموفق بود: %w",
+	stderror.StartCPUProfileFailedErr:                "شروع CPU profile ناموفق بود: %w",
+	stderror.StartServerProxyFailedErr:               "شروع پراکسی سرور mita ناموفق بود: %w",
+	stderror.StopServerProxyFailedErr:                "توقف پراکسی سرور mita ناموفق بود: %w",
+	stderror.StoreClientConfigFailedErr:              "ذخیره پیکربندی کلاینت mieru ناموفق بود: %w",
+	stderror.UpdateUserFailedErr:                     "به‌روزرسانی کاربر ناموفق بود: %w",
+	stderror.ValidateFullClientConfigFailedErr:       "اعتبارسنجی کامل پیکربندی کلاینت ناموفق بود: %w",
+	stderror.ValidateServerConfigPatchFailedErr:      "اعتبارسنجی وصله پیکربندی سرور ناموفق بود: %w",
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package i18n translates user-facing messages of mieru and mita.
+//
+// Messages are identified by their English text, so a message without
+// translation is shown in English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Locale is a language supported by mieru.
+type Locale string
+
+const (
+	English           Locale = "en"
+	SimplifiedChinese Locale = "zh_CN"
+	Farsi             Locale = "fa"
+)
+
+// catalogs maps a locale to the translation of English messages.
+var catalogs = map[Locale]map[string]string{
+	SimplifiedChinese: zhCNMessages,
+	Farsi:             faMessages,
+}
+
+var currentLocale atomic.Value
+
+func init() {
+	currentLocale.Store(English)
+}
+
+// SetLocale changes the language of messages.
+func SetLocale(l Locale) {
+	currentLocale.Store(l)
+}
+
+// CurrentLocale returns the language of messages.
+func CurrentLocale() Locale {
+	return currentLocale.Load().(Locale)
+}
+
+// ParseLocale returns the supported locale that matches the name,
+// like "zh_CN.UTF-8" or "fa-IR". It returns false if the language
+// is not supported.
+func ParseLocale(name string) (Locale, bool) {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ReplaceAll(name, "-", "_")
+	switch {
+	case name == "en" || strings.HasPrefix(name, "en_") || name == "c" || name == "posix":
+		return English, true
+	case name == "zh" || name == "zh_cn" || name == "zh_sg" || strings.HasPrefix(name, "zh_hans"):
+		return SimplifiedChinese, true
+	case name == "fa" || strings.HasPrefix(name, "fa_"):
+		return Farsi, true
+	default:
+		return English, false
+	}
+}
+
+// DetectLocale returns the locale from environment variables. The given
+// variables are checked first, followed by LC_ALL, LC_MESSAGES and LANG.
+// It returns English if no supported language is found.
+func DetectLocale(envNames ...string) Locale {
+	envNames = append(envNames, "LC_ALL", "LC_MESSAGES", "LANG")
+	for _, env := range envNames {
+		v, found := os.LookupEnv(env)
+		if !found || v == "" {
+			continue
+		}
+		// Like gettext, the first non-empty variable decides the language.
+		l, _ := ParseLocale(v)
+		return l
+	}
+	return English
+}
+
+// T returns the translation of the English message in current locale.
+func T(msg string) string {
+	if catalog, ok := catalogs[CurrentLocale()]; ok {
+		if translated, ok := catalog[msg]; ok {
+			return translated
+		}
+	}
+	return msg
+}
+
+// Sprintf formats the translation of the English format string.
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// Errorf is the same as fmt.Errorf, but the format string is translated.
+// Errors wrapped with %w can still be inspected by errors.Is and errors.As.
+func Errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(T(format), a...)
+}
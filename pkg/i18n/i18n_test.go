@@ -0,0 +1,135 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+var formatVerb = regexp.MustCompile(`%[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// sampleArgs returns arguments that match the verbs of the format string.
+func sampleArgs(format string) []interface{} {
+	var args []interface{}
+	for _, verb := range formatVerb.FindAllString(format, -1) {
+		switch verb[len(verb)-1] {
+		case '%':
+		case 'd':
+			args = append(args, 1)
+		case 'f':
+			args = append(args, 1.0)
+		case 'w':
+			args = append(args, errors.New("sample"))
+		default:
+			args = append(args, "sample")
+		}
+	}
+	return args
+}
+
+func TestParseLocale(t *testing.T) {
+	testCases := []struct {
+		name  string
+		want  Locale
+		found bool
+	}{
+		{"en_US.UTF-8", English, true},
+		{"C", English, true},
+		{"zh_CN.UTF-8", SimplifiedChinese, true},
+		{"zh-Hans", SimplifiedChinese, true},
+		{"zh", SimplifiedChinese, true},
+		{"fa_IR.UTF-8", Farsi, true},
+		{"fa", Farsi, true},
+		{"zh_TW.UTF-8", English, false},
+		{"de_DE", English, false},
+	}
+	for _, tc := range testCases {
+		got, found := ParseLocale(tc.name)
+		if got != tc.want || found != tc.found {
+			t.Errorf("ParseLocale(%q) = %v, %v, want %v, %v", tc.name, got, found, tc.want, tc.found)
+		}
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	t.Setenv("MIERU_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "fa_IR.UTF-8")
+	t.Setenv("LANG", "zh_CN.UTF-8")
+	if got := DetectLocale("MIERU_LANG"); got != Farsi {
+		t.Errorf("DetectLocale() = %v, want %v", got, Farsi)
+	}
+	t.Setenv("MIERU_LANG", "zh_CN")
+	if got := DetectLocale("MIERU_LANG"); got != SimplifiedChinese {
+		t.Errorf("DetectLocale() = %v, want %v", got, SimplifiedChinese)
+	}
+	t.Setenv("MIERU_LANG", "en")
+	if got := DetectLocale("MIERU_LANG"); got != English {
+		t.Errorf("DetectLocale() = %v, want %v", got, English)
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	for locale, catalog := range catalogs {
+		for _, other := range catalogs {
+			if len(catalog) != len(other) {
+				t.Errorf("Locale %v has %d messages, but another locale has %d messages", locale, len(catalog), len(other))
+			}
+		}
+		for msg, translated := range catalog {
+			args := sampleArgs(msg)
+			if got := fmt.Errorf(msg, args...).Error(); strings.Contains(got, "%!") {
+				t.Errorf("Message %q doesn't match sample arguments: %q", msg, got)
+			}
+			if got := fmt.Errorf(translated, args...).Error(); strings.Contains(got, "%!") {
+				t.Errorf("Locale %v translation of %q has wrong format verbs: %q", locale, msg, got)
+			}
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLocale(English)
+
+	if got := T("Commands:"); got != "Commands:" {
+		t.Errorf("T() = %q, want %q", got, "Commands:")
+	}
+	SetLocale(SimplifiedChinese)
+	if got := T("Commands:"); got != "命令：" {
+		t.Errorf("T() = %q, want %q", got, "命令：")
+	}
+	if got := T("message without translation"); got != "message without translation" {
+		t.Errorf("T() = %q, want %q", got, "message without translation")
+	}
+	if got, want := Sprintf("unexpected arguments %q after %q", "x", "mieru run"), "\"mieru run\" 之后有多余的参数 \"x\""; got != want {
+		t.Errorf("Sprintf() = %q, want %q", got, want)
+	}
+
+	SetLocale(Farsi)
+	err := Errorf(stderror.GetMetricsFailedErr, stderror.ErrTimeout)
+	if !errors.Is(err, stderror.ErrTimeout) {
+		t.Errorf("translated error doesn't wrap the original error")
+	}
+	if !strings.HasPrefix(err.Error(), "دریافت معیارها") {
+		t.Errorf("Errorf() = %q, want Farsi message", err.Error())
+	}
+}
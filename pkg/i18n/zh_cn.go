@@ -0,0 +1,255 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+import "github.com/enfein/mieru/v3/pkg/stderror"
+
+// zhCNMessages contains Simplified Chinese translations.
+var zhCNMessages = map[string]string{
+	// Command line parser and help.
+	"%q is not a valid command. Run \"%s help\" to get the list of supported commands": "%q 不是有效的命令。运行 \"%s help\" 获取支持的命令列表",
+	"unexpected arguments %q after %q":                                                 "%[2]q 之后有多余的参数 %[1]q",
+	"unsupported language %q, supported languages are en, zh_CN and fa":                "不支持的语言 %q，支持的语言有 en、zh_CN 和 fa",
+	"Usage: %s <COMMAND> [<ARGS>]":                                                     "用法：%s <命令> [<参数>]",
+	"Commands:":                                                                        "命令：",
+	"Commands for developers and experienced users:":                                   "面向开发者和高级用户的命令：",
+	"%s is provided more than once":                                                    "%s 被提供了多次",
+	"usage: %s %s <USER@HOST> <COMMAND>":                                               "用法：%s %s <USER@HOST> <COMMAND>",
+	"invalid SSH destination %q":                                                       "无效的 SSH 目标 %q",
+	"%s doesn't support %s":                                                            "%s 不支持 %s",
+	"SSH connection to %q failed":                                                      "SSH 连接到 %q 失败",
+	"remote command on %q failed with exit code %d":                                    "%q 上的远程命令失败，退出码为 %d",
+
+	// mieru client commands.
+	"Show mieru client help. If a command is provided, only show the help of matching commands.": "显示 mieru 客户端帮助。如果提供了命令，只显示匹配命令的帮助。",
+	"Start mieru client in background.":                              "在后台启动 mieru 客户端。",
+	"Stop mieru client.":                                             "停止 mieru 客户端。",
+	"Check mieru client status.":                                     "检查 mieru 客户端状态。",
+	"Test mieru client connection to the Internet via proxy server.": "测试 mieru 客户端通过代理服务器访问互联网的连接。",
+	"Connect to every proxy server in the active profile and show whether it responds. mieru client doesn't need to be running.":                                                       "连接当前设置档案中的每个代理服务器，并显示它是否响应。不需要运行 mieru 客户端。",
+	"Measure mieru client connection to the proxy server continuously and print a summary every minute. If a CSV or JSON file is provided, the measurements are appended to the file.": "持续测量 mieru 客户端到代理服务器的连接，并每分钟打印一次摘要。如果提供了 CSV 或 JSON 文件，测量结果会追加到该文件。",
+	"Create a client configuration profile interactively.":     "以交互方式创建客户端设置档案。",
+	"Apply client configuration from JSON, YAML or TOML file.": "从 JSON，YAML 或 TOML 文件应用客户端设置。",
+	"Use a preset of settings in a client configuration profile. Valid presets are balanced, low-latency, low-bandwidth and paranoid. If profile name is not provided, the active profile is used.": "在客户端设置档案中使用一组预设设置。可用的预设有 balanced、low-latency、low-bandwidth 和 paranoid。如果没有提供设置档案名称，则使用当前的设置档案。",
+	"Apply DNS settings in client configuration to the running mieru client.":                           "将客户端设置中的 DNS 设置应用到正在运行的 mieru 客户端。",
+	"Show current client configuration. The format can be json, yaml or toml, and the default is json.": "显示当前客户端设置。格式可以是 json，yaml 或 toml，默认是 json。",
+	"Import client configuration from URL.":                                                             "从 URL 导入客户端设置。",
+	"Export client configuration as URL.":                                                               "将客户端设置导出为 URL。",
+	"Encrypt the stored client configuration with a passphrase. The passphrase is read from MIERU_CONFIG_PASSPHRASE environment variable, or the file of MIERU_CONFIG_PASSPHRASE_FILE environment variable. Otherwise, it is typed in the terminal.": "用口令加密存储的客户端设置。口令从 MIERU_CONFIG_PASSPHRASE 环境变量，或者 MIERU_CONFIG_PASSPHRASE_FILE 环境变量指定的文件中读取。否则，需要在终端中输入口令。",
+	"Store the client configuration without encryption.": "不加密存储客户端设置。",
+	"Passphrase of client config: ":                      "客户端设置的口令：",
+	"New passphrase of client config: ":                  "客户端设置的新口令：",
+	"Type the passphrase again: ":                        "再次输入口令：",
+	"The passphrases don't match.":                       "两次输入的口令不一致。",
+	"Client configuration is already encrypted.":         "客户端设置已经加密。",
+	"Client configuration is encrypted. Provide the passphrase with MIERU_CONFIG_PASSPHRASE or MIERU_CONFIG_PASSPHRASE_FILE environment variable if the client is started without a terminal.": "客户端设置已加密。如果在没有终端的情况下启动客户端，请用 MIERU_CONFIG_PASSPHRASE 或 MIERU_CONFIG_PASSPHRASE_FILE 环境变量提供口令。",
+	"Client configuration is not encrypted.":                                      "客户端设置没有加密。",
+	"Client configuration is decrypted.":                                          "客户端设置已解密。",
+	"Delete an inactive client configuration profile.":                            "删除一个未使用的客户端设置档案。",
+	"Delete HTTP(S) proxy. Allow socks5 user password authentication to be used.": "删除 HTTP(S) 代理，以便使用 socks5 用户名密码认证。",
+	"Delete socks5 user password authentication. Allow HTTP(S) proxy to be used.": "删除 socks5 用户名密码认证，以便使用 HTTP(S) 代理。",
+	"Get mieru client metrics.":                                                   "获取 mieru 客户端指标。",
+	"Get mieru client connections.":                                               "获取 mieru 客户端连接。",
+	"Get the recent log of mieru client. With --follow, keep printing the new log. With --level, only print the log at the level or more severe.": "获取 mieru 客户端最近的日志。使用 --follow 时，持续打印新的日志。使用 --level 时，只打印该等级或者更严重的日志。",
+	"Get the destinations that transfer the most bytes through mieru client.":                                                                     "获取通过 mieru 客户端传输最多字节的目的地。",
+	"Get mieru client auto route statistics.":                                        "获取 mieru 客户端自动路由统计。",
+	"Show mieru client version.":                                                     "显示 mieru 客户端版本。",
+	"Check mieru client update.":                                                     "检查 mieru 客户端更新。",
+	"Package the crash reports of mieru client into the zip file.":                   "将 mieru 客户端的崩溃报告打包到 zip 文件中。",
+	"Print shell completion script. Supported shells are bash, zsh and fish.":        "打印 shell 自动补全脚本。支持的 shell 有 bash、zsh 和 fish。",
+	"unsupported shell %q, supported shells are bash, zsh and fish":                  "不支持的 shell %q，支持的 shell 有 bash、zsh 和 fish",
+	"no help is found for %q. Run \"%s help\" to get the list of supported commands": "没有找到 %q 的帮助。运行 \"%s help\" 获取支持的命令列表",
+	"Run mieru client in foreground.":                                                "在前台运行 mieru 客户端。",
+	"Get the internal state of mieru client sessions for debugging. If the session ID is not provided, all the sessions are dumped.": "获取 mieru 客户端会话的内部状态用于调试。如果没有提供会话 ID，则输出所有会话。",
+	"Get mieru client thread dump.":                                "获取 mieru 客户端线程转储。",
+	"Get mieru client heap profile and save results to the file.":  "获取 mieru 客户端堆内存分析并将结果保存到文件。",
+	"Get mieru client memory statistics.":                          "获取 mieru 客户端内存统计。",
+	"Start mieru client CPU profile and save results to the file.": "开始 mieru 客户端 CPU 分析并将结果保存到文件。",
+	"Stop mieru client CPU profile.":                               "停止 mieru 客户端 CPU 分析。",
+
+	// mieru client messages.
+	"mieru client is running, listening to socks5://0.0.0.0:%d":                                        "mieru 客户端正在运行，监听 socks5://0.0.0.0:%d",
+	"mieru client is running, listening to socks5://127.0.0.1:%d":                                      "mieru 客户端正在运行，监听 socks5://127.0.0.1:%d",
+	"mieru client is started, listening to socks5://0.0.0.0:%d":                                        "mieru 客户端已启动，监听 socks5://0.0.0.0:%d",
+	"mieru client is started, listening to socks5://127.0.0.1:%d":                                      "mieru 客户端已启动，监听 socks5://127.0.0.1:%d",
+	"%s, please create one with \"mieru apply config <FILE>\" command":                                 "%s，请使用 \"mieru apply config <FILE>\" 命令创建",
+	"mieru client is running":                                                                          "mieru 客户端正在运行",
+	"mieru client is stopped":                                                                          "mieru 客户端已停止",
+	"active profile: %s":                                                                               "当前设置档案：%s",
+	"proxy is disabled by a profile schedule":                                                          "代理已被设置档案时间表禁用",
+	"Preset %s is applied to profile %q. Restart mieru client to use it.":                              "预设 %s 已应用到设置档案 %q。重启 mieru 客户端以使用该预设。",
+	"unable to write to disk, data is kept in memory: %s":                                              "无法写入磁盘，数据暂存在内存中：%s",
+	"connection quality score: %d (round trip time %d ms, loss rate %.1f%%, dial failure rate %.1f%%)": "连接质量评分：%d（往返时间 %d 毫秒，丢包率 %.1f%%，连接失败率 %.1f%%）",
+	"proxy server version: %s":                                                                         "代理服务器版本：%s",
+	"proxy server version: unknown, the server is too old to advertise the version":                    "代理服务器版本：未知，服务器版本过旧，无法通告版本",
+	"proxy server features: %s":                                                                        "代理服务器支持的功能：%s",
+	"proxy server doesn't support: %s. Upgrade the server to use these features.":                      "代理服务器不支持：%s。请升级服务器以使用这些功能。",
+	"monitoring the connection to the proxy server every %v, press Ctrl+C to stop":                     "每 %v 监测一次到代理服务器的连接，按 Ctrl+C 停止",
+	"probe succeeded in %d ms, round trip time %d ms, loss rate %.1f%%":                                "探测在 %d 毫秒内成功，往返时间 %d 毫秒，丢包率 %.1f%%",
+	"probe failed: %s": "探测失败：%s",
+	"last %v: %d/%d probes succeeded, average latency %d ms, maximum latency %d ms, average round trip time %d ms, average loss rate %.1f%%": "最近 %v：%d/%d 次探测成功，平均延迟 %d 毫秒，最大延迟 %d 毫秒，平均往返时间 %d 毫秒，平均丢包率 %.1f%%",
+	"Connected to %q after %v":                                                   "在 %[2]v 后连接到 %[1]q",
+	"DNS settings are applied to mieru client.":                                  "DNS 设置已应用到 mieru 客户端。",
+	"HTTP proxy is already deleted from client config.":                          "HTTP 代理已经从客户端设置中删除。",
+	"HTTP proxy is deleted from client config.":                                  "HTTP 代理已从客户端设置中删除。",
+	"socks5 user password authentication is already deleted from client config.": "socks5 用户名密码认证已经从客户端设置中删除。",
+	"socks5 user password authentication is deleted from client config.":         "socks5 用户名密码认证已从客户端设置中删除。",
+	"heap profile is saved to %q":                                                "堆内存分析已保存到 %q",
+	"bug report is saved to %q":                                                  "错误报告已保存到 %q",
+	"CPU profile will be saved to %q":                                            "CPU 分析将保存到 %q",
+	"unable to connect to proxy server: %v":                                      "无法连接到代理服务器：%v",
+	"proxy server doesn't accept the user; check the user name, password, and that the system time of client and server is in sync: %v": "代理服务器不接受该用户；请检查用户名、密码，以及客户端和服务器的系统时间是否同步：%v",
+	"proxy server doesn't respond; the server may be unreachable, or the user name, password or system time is wrong: %v":               "代理服务器没有响应；服务器可能无法访问，或者用户名、密码或系统时间有误：%v",
+	"none of the %d proxy server endpoints is reachable":                                                                                "%d 个代理服务器端点都无法访问",
+
+	// mieru client setup wizard.
+	"This wizard creates a client profile step by step. Press Enter to accept the default value in brackets.": "这个向导会一步一步地创建客户端设置档案。按回车键使用方括号中的默认值。",
+	"Profile name":                           "设置档案名称",
+	"Proxy server IP address or domain name": "代理服务器的 IP 地址或域名",
+	"Transport protocol (TCP, UDP or TLS)":   "传输协议（TCP、UDP 或 TLS）",
+	"Proxy server port":                      "代理服务器端口",
+	"TLS server name of the proxy server":    "代理服务器的 TLS 服务器名称",
+	"User name":                              "用户名",
+	"Password":                               "密码",
+	"Preset (none, balanced, low-latency, low-bandwidth or paranoid)": "预设（none、balanced、low-latency、low-bandwidth 或 paranoid）",
+	"Local socks5 proxy port":                             "本地 socks5 代理端口",
+	"Local RPC port":                                      "本地 RPC 端口",
+	"Resolved %s to %v":                                   "%s 解析为 %v",
+	"Unable to resolve %s: %v":                            "无法解析 %s：%v",
+	"Connected to %s":                                     "已连接到 %s",
+	"Unable to connect to %s: %v":                         "无法连接到 %s：%v",
+	"TLS handshake with %s succeeded":                     "与 %s 的 TLS 握手成功",
+	"TLS handshake with %s failed: %v":                    "与 %s 的 TLS 握手失败：%v",
+	"UDP port can't be checked before the client starts.": "在客户端启动之前无法检查 UDP 端口。",
+	"Continue anyway?":                                    "仍然继续吗？",
+	"Save the profile above?":                             "保存上面的设置档案吗？",
+	"Profile %q is saved.":                                "设置档案 %q 已保存。",
+	"Run \"mieru start\" to start the client, and \"mieru test\" to check the connection.": "运行 \"mieru start\" 启动客户端，运行 \"mieru test\" 检查连接。",
+	"Setup is canceled.":                     "设置已取消。",
+	"A value is required.":                   "必须输入一个值。",
+	"Please answer yes or no.":               "请回答 yes 或 no。",
+	"Invalid transport protocol %q.":         "传输协议 %q 无效。",
+	"Invalid preset %q.":                     "预设 %q 无效。",
+	"Port number must be between %d and %d.": "端口号必须在 %d 和 %d 之间。",
+
+	// mita server commands.
+	"Show mita server help. If a command is provided, only show the help of matching commands.": "显示 mita 服务器帮助。如果提供了命令，只显示匹配命令的帮助。",
+	"Start mita server proxy service.":                                                                  "启动 mita 服务器代理服务。",
+	"Stop mita server proxy service.":                                                                   "停止 mita 服务器代理服务。",
+	"Stop mita server proxy service after connections finish.":                                          "在连接结束后停止 mita 服务器代理服务。",
+	"Reload mita server configuration without stopping proxy service.":                                  "在不停止代理服务的情况下重新加载 mita 服务器设置。",
+	"Check mita server proxy service status.":                                                           "检查 mita 服务器代理服务状态。",
+	"Apply server configuration from JSON, YAML or TOML file.":                                          "从 JSON，YAML 或 TOML 文件应用服务器设置。",
+	"Show current server configuration. The format can be json, yaml or toml, and the default is json.": "显示当前服务器设置。格式可以是 json，yaml 或 toml，默认是 json。",
+	"Delete a user from server configuration.":                                                          "从服务器设置中删除一个用户。",
+	"Add a user to the running server. A random password is generated if it is not provided.":           "向运行中的服务器添加一个用户。如果没有提供密码，会生成一个随机密码。",
+	"Change the password of a user, or disable or enable a user, on the running server.":                "在运行中的服务器上修改用户的密码，或者禁用或启用用户。",
+	"Remove a user from the running server and close the connections of the user.":                      "从运行中的服务器删除一个用户，并关闭该用户的连接。",
+	"Add or update users from a CSV or JSON file.":                                                      "从 CSV 或 JSON 文件添加或更新用户。",
+	"Save users to a CSV or JSON file.":                                                                 "将用户保存到 CSV 或 JSON 文件。",
+	"Create a guest user that expires after the duration, e.g. 24h.":                                    "创建一个在指定时长后过期的访客用户，例如 24h。",
+	"Get mita server metrics.":                                                                          "获取 mita 服务器指标。",
+	"Get mita server connections.":                                                                      "获取 mita 服务器连接。",
+	"Get the recent log of mita server. With --follow, keep printing the new log. With --level, only print the log at the level or more severe.": "获取 mita 服务器最近的日志。使用 --follow 时，持续打印新的日志。使用 --level 时，只打印该等级或者更严重的日志。",
+	"Show mita server version.":      "显示 mita 服务器版本。",
+	"Check mita server update.":      "检查 mita 服务器更新。",
+	"Run mita server in foreground.": "在前台运行 mita 服务器。",
+	"Get the internal state of mita server sessions for debugging. If the session ID is not provided, all the sessions are dumped.": "获取 mita 服务器会话的内部状态用于调试。如果没有提供会话 ID，则输出所有会话。",
+	"Get mita server thread dump.":                                                                "获取 mita 服务器线程转储。",
+	"Get mita server heap profile and save results to the file.":                                  "获取 mita 服务器堆内存分析并将结果保存到文件。",
+	"Get mita server memory statistics.":                                                          "获取 mita 服务器内存统计。",
+	"Get mita server egress rule statistics.":                                                     "获取 mita 服务器出站规则统计。",
+	"Get the traffic of each user of mita server.":                                                "获取 mita 服务器每个用户的流量统计。",
+	"Get the bytes and segments transferred by each user and each active session of mita server.": "获取 mita 服务器每个用户和每个活跃会话传输的字节数和分段数。",
+	"Get the destinations of a user that transfer the most bytes through mita server.":            "获取 mita 服务器中某个用户传输字节数最多的目的地。",
+	"Start mita server CPU profile and save results to the file.":                                 "开始 mita 服务器 CPU 分析并将结果保存到文件。",
+	"Stop mita server CPU profile.":                                                               "停止 mita 服务器 CPU 分析。",
+	"Run the command on a remote mita server with SSH. Input files are read from this computer.":  "通过 SSH 在远程 mita 服务器上运行命令。输入文件从本机读取。",
+
+	// mita server messages.
+	"mita server proxy is running": "mita 服务器代理正在运行",
+	"mita server proxy is started": "mita 服务器代理已启动",
+	"mita server proxy is stopped": "mita 服务器代理已停止",
+	"mita server proxy is drained": "mita 服务器代理已排空",
+	"mita server is reloaded":      "mita 服务器已重新加载",
+	"mita server status is %q":     "mita 服务器状态为 %q",
+
+	// mita users messages.
+	"%d users are imported":                     "已导入 %d 个用户",
+	"Generated passwords:":                      "生成的密码：",
+	"%d users are exported to %q":               "已将 %d 个用户导出到 %q",
+	"file %q must have .csv or .json extension": "文件 %q 的扩展名必须是 .csv 或 .json",
+	"Guest user: %s":                            "访客用户：%s",
+	"Password: %s":                              "密码：%s",
+	"Expire time: %s":                           "过期时间：%s",
+	"Client URL: %s":                            "客户端 URL：%s",
+	"User %q is added":                          "已添加用户 %q",
+	"User %q is updated":                        "已更新用户 %q",
+	"User %q is removed":                        "已删除用户 %q",
+
+	// Common errors.
+	stderror.AddUserFailedErr:                        "添加用户失败：%w",
+	stderror.ClientConfigIsEmpty:                     "mieru 客户端设置为空",
+	stderror.ClientConfigNotExist:                    "mieru 客户端设置文件不存在",
+	stderror.ClientGetActiveProfileFailedErr:         "mieru 客户端获取当前使用的设置档案失败：%w",
+	stderror.ClientNotRunning:                        "mieru 客户端没有运行",
+	stderror.ClientNotRunningErr:                     "mieru 客户端没有运行：%w",
+	stderror.CreateBugReportFailedErr:                "创建错误报告失败：%w",
+	stderror.CreateClientLifecycleRPCClientFailedErr: "创建 mieru 客户端生命周期 RPC 客户端失败：%w",
+	stderror.CreateEmptyServerConfigFailedErr:        "创建空的 mita 服务器设置文件失败：%w",
+	stderror.CreateServerConfigRPCClientFailedErr:    "创建 mita 服务器设置 RPC 客户端失败：%w",
+	stderror.CreateServerLifecycleRPCClientFailedErr: "创建 mita 服务器生命周期 RPC 客户端失败：%w",
+	stderror.CreateSocks5ServerFailedErr:             "创建 socks5 服务器失败：%w",
+	stderror.CreateTracerFailedErr:                   "创建追踪器失败：%w",
+	stderror.DecodeHashedPasswordFailedErr:           "解码哈希密码失败：%w",
+	stderror.DrainServerProxyFailedErr:               "排空 mita 服务器代理失败：%w",
+	stderror.ExitFailedErr:                           "进程退出失败：%w",
+	stderror.GetClientConfigFailedErr:                "获取 mieru 客户端设置失败：%w",
+	stderror.GetConnectionsFailedErr:                 "获取连接失败：%w",
+	stderror.GetDestinationStatsFailedErr:            "获取目的地统计失败：%w",
+	stderror.GetHeapProfileFailedErr:                 "获取堆内存分析失败：%w",
+	stderror.GetLogsFailedErr:                        "获取日志失败：%w",
+	stderror.GetManagementTokenFailedErr:             "获取管理 API 令牌失败：%w",
+	stderror.GetMemoryStatisticsFailedErr:            "获取内存统计失败：%w",
+	stderror.GetMetricsFailedErr:                     "获取指标失败：%w",
+	stderror.GetRouteStatsFailedErr:                  "获取路由统计失败：%w",
+	stderror.GetServerConfigFailedErr:                "获取 mita 服务器设置失败：%w",
+	stderror.GetServerStatusFailedErr:                "获取 mita 服务器状态失败：%w",
+	stderror.GetSessionStatesFailedErr:               "获取会话状态失败：%w",
+	stderror.GetThreadDumpFailedErr:                  "获取线程转储失败：%w",
+	stderror.GetTrafficFailedErr:                     "获取流量失败：%w",
+	stderror.GetUsersFailedErr:                       "获取用户失败：%w",
+	stderror.GetUserStatsFailedErr:                   "获取用户统计失败：%w",
+	stderror.InvalidPortBindingsErr:                  "端口绑定无效：%w",
+	stderror.InvalidTransportProtocol:                "传输协议无效",
+	stderror.IPAddressNotFound:                       "无法从域名 %q 找到 IP 地址",
+	stderror.LookupIPFailedErr:                       "查询 IP 地址失败：%w",
+	stderror.ParseIPFailed:                           "解析 IP 地址失败",
+	stderror.ReloadServerFailedErr:                   "重新加载 mita 服务器失败：%w",
+	stderror.RemoveUserFailedErr:                     "删除用户失败：%w",
+	stderror.ServerNotRunningErr:                     "mita 服务器守护进程没有运行：%w",
+	stderror.ServerNotRunningWithCommand:             "mita 服务器守护进程没有运行；请运行命令 \"sudo systemctl restart mita\" 启动服务器守护进程；如果无法启动，请运行命令 \"sudo journalctl -e -u mita --no-pager\" 查看日志",
+	stderror.ServerProxyNotRunningErr:                "mita 服务器代理没有运行：%w",
+	stderror.SetServerConfigFailedErr:                "更新 mita 服务器设置失败：%w",
+	stderror.StartClientFailedErr:                    "启动 mieru 客户端失败：%w",
+	stderror.StartCPUProfileFailedErr:                "开始 CPU 分析失败：%w",
+	stderror.StartServerProxyFailedErr:               "启动 mita 服务器代理失败：%w",
+	stderror.StopServerProxyFailedErr:                "停止 mita 服务器代理失败：%w",
+	stderror.StoreClientConfigFailedErr:              "保存 mieru 客户端设置失败：%w",
+	stderror.UpdateUserFailedErr:                     "更新用户失败：%w",
+	stderror.ValidateFullClientConfigFailedErr:       "验证完整客户端设置失败：%w",
+	stderror.ValidateServerConfigPatchFailedErr:      "验证服务器设置补丁失败：%w",
+}
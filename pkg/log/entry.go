@@ -278,6 +278,9 @@ func (entry *Entry) write() {
 	if _, err := entry.Logger.Out.Write(serialized); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
 	}
+	if entry.Logger == std {
+		stream.publish(entry.Level, serialized)
+	}
 }
 
 func (entry *Entry) Logf(level Level, format string, args ...interface{}) {
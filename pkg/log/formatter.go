@@ -2,6 +2,7 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
@@ -128,6 +129,66 @@ func (f *DaemonFormatter) Format(entry *Entry) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// JSONFormatter is a log formatter that prints each entry as a line of
+// JSON object, including the field data. It is suitable for daemon whose
+// log is processed by log collectors. FATAL log is also printed to stderr.
+type JSONFormatter struct {
+	NoTimestamp bool
+}
+
+func (f *JSONFormatter) Format(entry *Entry) ([]byte, error) {
+	data := make(Fields, len(entry.Data)+6)
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case error:
+			// Otherwise the error is encoded as an empty object.
+			data[k] = v.Error()
+		case fmt.Stringer:
+			data[k] = v.String()
+		default:
+			data[k] = v
+		}
+	}
+	// Don't let the field data overwrite the default fields.
+	for _, key := range []string{FieldKeyTime, FieldKeyLevel, FieldKeyMsg, FieldKeyFile, FieldKeyFunc} {
+		if v, ok := data[key]; ok {
+			data["fields."+key] = v
+			delete(data, key)
+		}
+	}
+	if !f.NoTimestamp {
+		data[FieldKeyTime] = entry.Time.Format(time.RFC3339Nano)
+	}
+	data[FieldKeyLevel] = strings.ToUpper(entry.Level.String())
+	data[FieldKeyMsg] = entry.Message
+	if entry.HasCaller() {
+		data[FieldKeyFile] = fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line)
+		data[FieldKeyFunc] = entry.Caller.Function
+	}
+	if LogPrefix != "" {
+		data["prefix"] = LogPrefix
+	}
+
+	var buf *bytes.Buffer
+	if entry.Buffer != nil {
+		buf = entry.Buffer
+	} else {
+		buf = &bytes.Buffer{}
+	}
+
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to encode log entry to JSON: %w", err)
+	}
+
+	if entry.Level == FatalLevel {
+		fmt.Fprint(os.Stderr, buf.String())
+	}
+
+	return buf.Bytes(), nil
+}
+
 // NilFormatter prints no log. It disables logging.
 type NilFormatter struct{}
 
@@ -17,6 +17,8 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -103,6 +105,50 @@ func TestDaemonFormatter(t *testing.T) {
 	buf.Reset()
 }
 
+func TestJSONFormatter(t *testing.T) {
+	SetFormatter(&JSONFormatter{})
+	SetLevel("TRACE")
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer func() {
+		SetFormatter(&CliFormatter{})
+		SetLevel("INFO")
+		SetOutput(os.Stdout)
+	}()
+
+	WithFields(Fields{
+		"session": uint32(12345),
+		"peer":    "192.0.2.1:443",
+		"msg":     "conflict",
+		"error":   errors.New("broken pipe"),
+		"idle":    3 * time.Second,
+	}).Warnf("This is a \"test\" message")
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("Log entry is not a single line: %q", buf.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, got["time"].(string)); err != nil {
+		t.Errorf("Invalid timestamp: %v, %v", got["time"], err)
+	}
+	want := map[string]any{
+		"level":      "WARNING",
+		"msg":        "This is a \"test\" message",
+		"session":    float64(12345),
+		"peer":       "192.0.2.1:443",
+		"fields.msg": "conflict",
+		"error":      "broken pipe",
+		"idle":       "3s",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got %q = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
 func TestNilFormatter(t *testing.T) {
 	SetFormatter(&NilFormatter{})
 	SetLevel("TRACE")
@@ -0,0 +1,167 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Storage components that can degrade to memory when the disk is full
+// or the directory is not writable.
+const (
+	StorageLog          = "log"
+	StorageMetrics      = "metrics"
+	StorageClientConfig = "client config"
+	StorageServerConfig = "server config"
+)
+
+// maxFallbackLogBytes is the maximum number of log bytes kept in memory
+// when the log file is not writable. Older lines are dropped first.
+const maxFallbackLogBytes = 1024 * 1024
+
+// fallbackLogRetryInterval is the minimum interval to retry writing
+// to the log file after a failure.
+const fallbackLogRetryInterval = 10 * time.Second
+
+var (
+	storageMu       sync.Mutex
+	storageFailures = make(map[string]string)
+)
+
+// ReportStorageFailure records that the given storage component can't
+// be written to disk and the data is kept in memory. A warning is printed
+// only once until the component recovers.
+func ReportStorageFailure(component string, err error) {
+	storageMu.Lock()
+	_, reported := storageFailures[component]
+	storageFailures[component] = err.Error()
+	storageMu.Unlock()
+	if !reported {
+		Warnf("Unable to write %s to disk, keeping it in memory until the disk is writable again: %v", component, err)
+	}
+}
+
+// ReportStorageRecovery records that the given storage component can be
+// written to disk again.
+func ReportStorageRecovery(component string) {
+	storageMu.Lock()
+	_, reported := storageFailures[component]
+	delete(storageFailures, component)
+	storageMu.Unlock()
+	if reported {
+		Infof("Writing %s to disk is recovered", component)
+	}
+}
+
+// StorageFailures returns the storage components that can't be written
+// to disk, with the last error of each component. The result is sorted.
+func StorageFailures() []string {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	res := make([]string, 0, len(storageFailures))
+	for component, err := range storageFailures {
+		res = append(res, fmt.Sprintf("%s: %s", component, err))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// fallbackWriter writes logs to a file. If the file is not writable,
+// logs are kept in memory and written to the file after it recovers.
+type fallbackWriter struct {
+	mu        sync.Mutex
+	out       io.WriteCloser
+	pending   [][]byte
+	size      int
+	lastRetry time.Time
+}
+
+var _ io.WriteCloser = &fallbackWriter{}
+
+// NewFallbackWriter returns a log writer that never fails. If writing to
+// out fails, logs are kept in memory, and a single warning is printed to
+// stderr. Logs in memory are written to out once it is writable again.
+func NewFallbackWriter(out io.WriteCloser) io.WriteCloser {
+	return &fallbackWriter{out: out}
+}
+
+func (w *fallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 && time.Since(w.lastRetry) >= fallbackLogRetryInterval {
+		w.flush()
+	}
+	if len(w.pending) == 0 {
+		_, err := w.out.Write(p)
+		if err == nil {
+			return len(p), nil
+		}
+		w.lastRetry = time.Now()
+		storageMu.Lock()
+		_, reported := storageFailures[StorageLog]
+		storageFailures[StorageLog] = err.Error()
+		storageMu.Unlock()
+		if !reported {
+			// Don't use the logger, which is writing to this writer.
+			fmt.Fprintf(os.Stderr, "Unable to write log to disk, keeping it in memory until the disk is writable again: %v\n", err)
+		}
+	}
+	w.keep(p)
+	return len(p), nil
+}
+
+func (w *fallbackWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flush()
+	return w.out.Close()
+}
+
+// keep stores a copy of p in memory. It must be called with the lock.
+func (w *fallbackWriter) keep(p []byte) {
+	b := make([]byte, len(p))
+	copy(b, p)
+	w.pending = append(w.pending, b)
+	w.size += len(b)
+	for w.size > maxFallbackLogBytes && len(w.pending) > 1 {
+		w.size -= len(w.pending[0])
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+}
+
+// flush writes the logs in memory to out. It must be called with the lock.
+func (w *fallbackWriter) flush() {
+	w.lastRetry = time.Now()
+	for len(w.pending) > 0 {
+		if _, err := w.out.Write(w.pending[0]); err != nil {
+			return
+		}
+		w.size -= len(w.pending[0])
+		w.pending[0] = nil
+		w.pending = w.pending[1:]
+	}
+	w.pending = nil
+	storageMu.Lock()
+	delete(storageFailures, StorageLog)
+	storageMu.Unlock()
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flakyWriter struct {
+	buf    bytes.Buffer
+	broken bool
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.broken {
+		return 0, fmt.Errorf("no space left on device")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *flakyWriter) Close() error {
+	return nil
+}
+
+func TestFallbackWriter(t *testing.T) {
+	out := &flakyWriter{}
+	w := NewFallbackWriter(out).(*fallbackWriter)
+	defer ReportStorageRecovery(StorageLog)
+
+	if _, err := w.Write([]byte("1\n")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	out.broken = true
+	for _, line := range []string{"2\n", "3\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() failed when the output is broken: %v", err)
+		}
+	}
+	failures := StorageFailures()
+	if len(failures) != 1 || !strings.HasPrefix(failures[0], StorageLog+": ") {
+		t.Errorf("StorageFailures() = %v, want a log failure", failures)
+	}
+
+	out.broken = false
+	w.lastRetry = time.Now().Add(-fallbackLogRetryInterval)
+	if _, err := w.Write([]byte("4\n")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if got := out.buf.String(); got != "1\n2\n3\n4\n" {
+		t.Errorf("output = %q, want %q", got, "1\n2\n3\n4\n")
+	}
+	if failures := StorageFailures(); len(failures) != 0 {
+		t.Errorf("StorageFailures() = %v, want empty", failures)
+	}
+}
+
+func TestFallbackWriterDropsOldLogs(t *testing.T) {
+	out := &flakyWriter{broken: true}
+	w := NewFallbackWriter(out).(*fallbackWriter)
+	defer ReportStorageRecovery(StorageLog)
+
+	line := []byte(strings.Repeat("a", 1023) + "\n")
+	for i := 0; i < 2*maxFallbackLogBytes/len(line); i++ {
+		w.Write(line)
+	}
+	if w.size > maxFallbackLogBytes {
+		t.Errorf("%d bytes are kept in memory, want at most %d", w.size, maxFallbackLogBytes)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+}
+
+func TestReportStorageFailure(t *testing.T) {
+	ReportStorageFailure(StorageMetrics, fmt.Errorf("read-only file system"))
+	ReportStorageFailure(StorageMetrics, fmt.Errorf("no space left on device"))
+	failures := StorageFailures()
+	if len(failures) != 1 || failures[0] != StorageMetrics+": no space left on device" {
+		t.Errorf("StorageFailures() = %v, want the last error", failures)
+	}
+	ReportStorageRecovery(StorageMetrics)
+	if failures := StorageFailures(); len(failures) != 0 {
+		t.Errorf("StorageFailures() = %v, want empty", failures)
+	}
+}
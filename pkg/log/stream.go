@@ -0,0 +1,124 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// recentLineCapacity is the number of recent log lines kept in memory.
+	recentLineCapacity = 256
+
+	// subscriberBuffer is the number of log lines buffered for a subscriber.
+	subscriberBuffer = 256
+)
+
+// Line is a formatted log entry of the standard logger.
+type Line struct {
+	Level Level
+	Text  string
+}
+
+// lineStream keeps the recent log lines and sends new log lines
+// to the subscribers.
+type lineStream struct {
+	mu          sync.Mutex
+	recent      []Line
+	next        int
+	subscribers map[*subscriber]struct{}
+	dropped     atomic.Int64
+}
+
+type subscriber struct {
+	level Level
+	ch    chan Line
+}
+
+var stream = &lineStream{
+	subscribers: make(map[*subscriber]struct{}),
+}
+
+// RecentLines returns the recent log lines at the level or more severe,
+// from the oldest to the newest.
+func RecentLines(level Level) []Line {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	lines := make([]Line, 0, len(stream.recent))
+	for i := 0; i < len(stream.recent); i++ {
+		line := stream.recent[(stream.next+i)%len(stream.recent)]
+		if line.Level <= level {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Subscribe returns a channel that receives the new log lines at the level
+// or more severe, and a function to cancel the subscription. The lines are
+// dropped if the subscriber can't keep up. The channel is closed after
+// the subscription is canceled.
+func Subscribe(level Level) (<-chan Line, func()) {
+	sub := &subscriber{
+		level: level,
+		ch:    make(chan Line, subscriberBuffer),
+	}
+	stream.mu.Lock()
+	stream.subscribers[sub] = struct{}{}
+	stream.mu.Unlock()
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			stream.mu.Lock()
+			delete(stream.subscribers, sub)
+			stream.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// DroppedLines returns the number of log lines not sent to the subscribers
+// because they can't keep up.
+func DroppedLines() int64 {
+	return stream.dropped.Load()
+}
+
+// publish stores the formatted log entry and sends it to the subscribers.
+func (s *lineStream) publish(level Level, serialized []byte) {
+	if len(serialized) == 0 {
+		return
+	}
+	line := Line{Level: level, Text: string(serialized)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.recent) < recentLineCapacity {
+		s.recent = append(s.recent, line)
+	} else {
+		s.recent[s.next] = line
+		s.next = (s.next + 1) % recentLineCapacity
+	}
+	for sub := range s.subscribers {
+		if line.Level > sub.level {
+			continue
+		}
+		select {
+		case sub.ch <- line:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	SetFormatter(&DaemonFormatter{})
+	SetLevel("TRACE")
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer func() {
+		SetFormatter(&CliFormatter{})
+		SetLevel("INFO")
+		SetOutput(os.Stdout)
+	}()
+
+	ch, cancel := Subscribe(InfoLevel)
+	Debugf("This is a debug message")
+	Warnf("This is a warning message")
+	select {
+	case line := <-ch:
+		if line.Level != WarnLevel || !strings.Contains(line.Text, "This is a warning message") {
+			t.Errorf("got line %v, want the warning message", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("subscriber didn't receive the log line")
+	}
+	select {
+	case line := <-ch:
+		t.Errorf("got unexpected line %v", line)
+	default:
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Errorf("channel is not closed after the subscription is canceled")
+	}
+	Errorf("This message is logged after the subscription is canceled")
+}
+
+func TestRecentLines(t *testing.T) {
+	SetFormatter(&DaemonFormatter{})
+	SetLevel("TRACE")
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer func() {
+		SetFormatter(&CliFormatter{})
+		SetLevel("INFO")
+		SetOutput(os.Stdout)
+	}()
+
+	for i := 0; i < recentLineCapacity+10; i++ {
+		Debugf("debug message %d", i)
+	}
+	Errorf("the last error message")
+	lines := RecentLines(TraceLevel)
+	if len(lines) != recentLineCapacity {
+		t.Fatalf("got %d recent lines, want %d", len(lines), recentLineCapacity)
+	}
+	if !strings.Contains(lines[len(lines)-1].Text, "the last error message") {
+		t.Errorf("the newest line is %q, want the last error message", lines[len(lines)-1].Text)
+	}
+	if !strings.HasSuffix(lines[0].Text, "debug message 11\n") {
+		t.Errorf("the oldest line is %q, want debug message 11", lines[0].Text)
+	}
+	lines = RecentLines(ErrorLevel)
+	if len(lines) == 0 || lines[len(lines)-1].Level != ErrorLevel {
+		t.Errorf("RecentLines(ErrorLevel) returned %v", lines)
+	}
+	for _, line := range lines {
+		if line.Level > ErrorLevel {
+			t.Errorf("RecentLines(ErrorLevel) returned line at level %v", line.Level)
+		}
+	}
+}
@@ -189,8 +189,16 @@ func DumpMetricsNow() error {
 	if err != nil {
 		return fmt.Errorf("proto.Marshal() failed: %w", err)
 	}
-	if err := os.WriteFile(metricsDumpFilePath, b, 0664); err != nil {
-		return fmt.Errorf("os.WriteFile(%q) failed: %w", metricsDumpFilePath, err)
+	// Write to a temporary file first, so a full disk doesn't
+	// leave a truncated dump that can't be loaded.
+	tmpPath := metricsDumpFilePath + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0664); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("os.WriteFile(%q) failed: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, metricsDumpFilePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("os.Rename(%q) failed: %w", tmpPath, err)
 	}
 	return nil
 }
@@ -219,8 +227,12 @@ func logMetricsLoop() {
 		case <-logTicker.C:
 			LogMetricsNow()
 			if metricsDump {
+				// Metrics are always kept in memory. Only warn once
+				// if they can't be persisted.
 				if err := DumpMetricsNow(); err != nil {
-					log.Warnf("DumpMetricsNow() failed: %v", err)
+					log.ReportStorageFailure(log.StorageMetrics, err)
+				} else {
+					log.ReportStorageRecovery(log.StorageMetrics)
 				}
 			}
 		case <-stopLogging:
@@ -0,0 +1,70 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ExpvarName is the name of mieru metrics published by expvar.
+const ExpvarName = "mieru"
+
+var publishExpvarOnce sync.Once
+
+// PublishExpvar publishes all the metrics by expvar, so they are
+// returned from "/debug/vars". The value is a JSON object that maps
+// a group name to the metrics in the group. Metrics are read when
+// the variable is requested. Calling it more than once has no effect.
+func PublishExpvar() {
+	publishExpvarOnce.Do(func() {
+		expvar.Publish(ExpvarName, expvar.Func(func() any {
+			return allMetricValues()
+		}))
+	})
+}
+
+// NewDebugHTTPServer returns a HTTP server that serves expvar,
+// including the metrics, at "/debug/vars".
+func NewDebugHTTPServer(addr string) *http.Server {
+	PublishExpvar()
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// allMetricValues returns the values of all the registered metrics.
+func allMetricValues() map[string]map[string]int64 {
+	values := make(map[string]map[string]int64)
+	metricMap.Range(func(k, v any) bool {
+		group := v.(*MetricGroup)
+		groupValues := make(map[string]int64)
+		group.metrics.Range(func(k, v any) bool {
+			m := v.(Metric)
+			groupValues[m.Name()] = m.Load()
+			return true
+		})
+		values[group.name] = groupValues
+		return true
+	})
+	return values
+}
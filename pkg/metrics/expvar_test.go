@@ -0,0 +1,53 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugHTTPServerExpvar(t *testing.T) {
+	counter := RegisterMetric("expvar test", "Requests", COUNTER)
+	counter.Add(3)
+
+	server := NewDebugHTTPServer("127.0.0.1:0")
+	req := httptest.NewRequest("GET", "/debug/vars", nil)
+	rec := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("got status code %d, want 200", rec.Code)
+	}
+
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	var metrics map[string]map[string]int64
+	if err := json.Unmarshal(vars[ExpvarName], &metrics); err != nil {
+		t.Fatalf("json.Unmarshal() of %q failed: %v", ExpvarName, err)
+	}
+	if got := metrics["expvar test"]["Requests"]; got != 3 {
+		t.Errorf("got metric value %d, want 3", got)
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Errorf("memstats is not published")
+	}
+
+	// Publish again doesn't panic.
+	PublishExpvar()
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultLatencyBounds are the upper bounds of the buckets of
+// a latency histogram.
+var DefaultLatencyBounds = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram counts durations in buckets. Each bucket is a COUNTER
+// in the metric group, so it is exported like other metrics.
+//
+// For a metric name "Write" and bounds [1ms, 10ms], the buckets are
+// "WriteUnder1ms", "WriteUnder10ms" and "WriteOver10ms".
+type Histogram struct {
+	bounds  []time.Duration
+	buckets []Metric
+}
+
+// RegisterHistogram registers a new histogram. The bounds must be
+// positive and in increasing order.
+func RegisterHistogram(groupName, metricName string, bounds []time.Duration) *Histogram {
+	if len(bounds) == 0 {
+		panic("histogram bounds are empty")
+	}
+	h := &Histogram{
+		bounds:  append([]time.Duration(nil), bounds...),
+		buckets: make([]Metric, 0, len(bounds)+1),
+	}
+	for i, bound := range bounds {
+		if bound <= 0 || (i > 0 && bound <= bounds[i-1]) {
+			panic(fmt.Sprintf("histogram bounds %v are not positive and increasing", bounds))
+		}
+		h.buckets = append(h.buckets, RegisterMetric(groupName, metricName+"Under"+durationName(bound), COUNTER))
+	}
+	h.buckets = append(h.buckets, RegisterMetric(groupName, metricName+"Over"+durationName(bounds[len(bounds)-1]), COUNTER))
+	return h
+}
+
+// Observe adds the duration to the bucket.
+func (h *Histogram) Observe(d time.Duration) {
+	for i, bound := range h.bounds {
+		if d < bound {
+			h.buckets[i].Add(1)
+			return
+		}
+	}
+	h.buckets[len(h.bounds)].Add(1)
+}
+
+// Counts returns the number of durations in each bucket.
+func (h *Histogram) Counts() []int64 {
+	counts := make([]int64, len(h.buckets))
+	for i, bucket := range h.buckets {
+		counts[i] = bucket.Load()
+	}
+	return counts
+}
+
+// durationName returns a short name of the duration
+// that can be used in a metric name.
+func durationName(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	case d%time.Millisecond == 0:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	case d%time.Microsecond == 0:
+		return fmt.Sprintf("%dus", d/time.Microsecond)
+	default:
+		return fmt.Sprintf("%dns", d)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := RegisterHistogram("histogram test", "Write", []time.Duration{500 * time.Microsecond, 10 * time.Millisecond, time.Second})
+	h.Observe(100 * time.Microsecond)
+	h.Observe(500 * time.Microsecond)
+	h.Observe(5 * time.Millisecond)
+	h.Observe(2 * time.Second)
+
+	if got, want := h.Counts(), []int64{1, 2, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Counts() = %v, want %v", got, want)
+	}
+	group := GetMetricGroupByName("histogram test")
+	if group == nil {
+		t.Fatalf("metric group is not registered")
+	}
+	for _, name := range []string{"WriteUnder500us", "WriteUnder10ms", "WriteUnder1s", "WriteOver1s"} {
+		if _, ok := group.GetMetric(name); !ok {
+			t.Errorf("metric %q is not registered", name)
+		}
+	}
+}
+
+func TestHistogramInvalidBounds(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterHistogram() didn't panic with decreasing bounds")
+		}
+	}()
+	RegisterHistogram("histogram test", "Invalid", []time.Duration{time.Second, time.Millisecond})
+}
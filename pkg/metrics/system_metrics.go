@@ -21,6 +21,40 @@ const (
 
 	UserMetricUploadBytes   = "UploadBytes"
 	UserMetricDownloadBytes = "DownloadBytes"
+
+	// Number of segments received from and sent to each user.
+	UserMetricUploadSegments   = "UploadSegments"
+	UserMetricDownloadSegments = "DownloadSegments"
+
+	// MetricGroup name format for each DNS server.
+	DNSMetricGroupFormat = "dns - %s"
+
+	// Number of DNS queries with a response.
+	DNSMetricSuccess = "Success"
+
+	// Number of DNS queries failed or with an error response code.
+	DNSMetricFailure = "Failure"
+
+	// Accumulated latency of DNS queries, in milliseconds.
+	DNSMetricLatencyMs = "LatencyMs"
+
+	// MetricGroup name format for each proxy server endpoint of client.
+	EndpointMetricGroupFormat = "endpoint - %s"
+
+	// 1 if the endpoint is the last one selected to open a connection.
+	EndpointMetricActive = "Active"
+
+	// 1 if the endpoint is blacklisted after failed handshakes.
+	EndpointMetricBlacklisted = "Blacklisted"
+
+	// Number of successful session handshakes.
+	EndpointMetricHandshakeSuccess = "HandshakeSuccess"
+
+	// Number of failed connection dials and session handshakes.
+	EndpointMetricHandshakeFailure = "HandshakeFailure"
+
+	// Moving average of handshake latency, in milliseconds.
+	EndpointMetricLatencyMs = "LatencyMs"
 )
 
 var (
@@ -0,0 +1,40 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !linux
+
+package protocol
+
+import (
+	"net"
+)
+
+// newBatchPacketConn returns the UDP connection itself,
+// because batch system calls and UDP offload are only supported on Linux.
+func newBatchPacketConn(conn *net.UDPConn, offload bool) net.PacketConn {
+	return conn
+}
+
+// enableUDPOffload does nothing because UDP offload is only
+// supported on Linux.
+func enableUDPOffload(conn net.PacketConn) bool {
+	return false
+}
+
+// udpConn returns the UDP connection wrapped by the packet connection.
+func udpConn(conn net.PacketConn) (*net.UDPConn, bool) {
+	c, ok := conn.(*net.UDPConn)
+	return c, ok
+}
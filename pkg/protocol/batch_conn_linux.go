@@ -0,0 +1,373 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+
+package protocol
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// packetBatchSize is the maximum number of packets
+	// read or written by one system call.
+	packetBatchSize = 32
+
+	// packetWriteQueueSize is the maximum number of packets
+	// waiting to be written.
+	packetWriteQueueSize = 4 * packetBatchSize
+
+	// maxGSOSegments is the maximum number of packets
+	// sent by one UDP segmentation offload message.
+	maxGSOSegments = 64
+
+	// maxGSOPayload is the maximum number of bytes
+	// sent by one UDP segmentation offload message.
+	maxGSOPayload = 60000
+
+	// groBufferSize is the size of a read buffer when generic receive
+	// offload is enabled. It can hold the largest coalesced packet.
+	groBufferSize = 65535
+)
+
+// batchPacketConn reads and writes UDP packets in batches with recvmmsg
+// and sendmmsg system calls, so the system call overhead doesn't dominate
+// the CPU time when the packet rate is high.
+//
+// Packets are written asynchronously. WriteTo returns after the packet
+// is queued, and the error of a failed write is returned by the next
+// WriteTo call.
+//
+// If UDP offload is enabled, consecutive packets to the same address
+// with the same size are sent as one UDP segmentation offload (GSO)
+// message, and packets coalesced by generic receive offload (GRO)
+// are split before they are returned.
+type batchPacketConn struct {
+	*net.UDPConn
+	pc *ipv4.PacketConn
+
+	rMu     sync.Mutex
+	rMsgs   []ipv4.Message
+	rNext   int
+	rCount  int
+	rOffset int // offset of the next packet in the current message
+	rSize   int // size of packets in the current message
+	gro     bool
+
+	gso        atomic.Bool
+	writeQueue chan batchWriteRequest
+	writeErr   atomic.Pointer[error]
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+type batchWriteRequest struct {
+	b    []byte
+	addr net.Addr
+}
+
+var _ net.PacketConn = &batchPacketConn{}
+
+// newBatchPacketConn wraps the UDP connection to read and write in batches.
+// If offload is true, UDP segmentation offload and generic receive offload
+// are used when the kernel supports them.
+func newBatchPacketConn(conn *net.UDPConn, offload bool) net.PacketConn {
+	c := &batchPacketConn{
+		UDPConn:    conn,
+		pc:         ipv4.NewPacketConn(conn),
+		rMsgs:      make([]ipv4.Message, packetBatchSize),
+		writeQueue: make(chan batchWriteRequest, packetWriteQueueSize),
+		done:       make(chan struct{}),
+	}
+	bufSize := maxPathMTU
+	if offload {
+		c.gro = enableGRO(conn)
+		if c.gro {
+			bufSize = groBufferSize
+		}
+		c.enableGSO()
+	}
+	for i := range c.rMsgs {
+		c.rMsgs[i].Buffers = [][]byte{make([]byte, bufSize)}
+		if c.gro {
+			c.rMsgs[i].OOB = make([]byte, unix.CmsgSpace(4))
+		}
+	}
+	go c.runWriter()
+	return c
+}
+
+// ReadFrom returns the next received packet. It only makes a system call
+// after all the packets received by the previous call are returned.
+func (c *batchPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	c.rMu.Lock()
+	defer c.rMu.Unlock()
+	if c.rNext >= c.rCount {
+		n, err := c.pc.ReadBatch(c.rMsgs, 0)
+		if err != nil {
+			return 0, nil, err
+		}
+		c.rNext = 0
+		c.rCount = n
+		c.rOffset = 0
+	}
+	msg := &c.rMsgs[c.rNext]
+	data := msg.Buffers[0][:msg.N]
+	if c.rOffset == 0 {
+		c.rSize = len(data)
+		if c.gro {
+			if size := groSegmentSize(msg.OOB[:msg.NN]); size > 0 {
+				c.rSize = size
+			}
+		}
+	}
+	end := mathext.Min(c.rOffset+c.rSize, len(data))
+	n := copy(b, data[c.rOffset:end])
+	c.rOffset = end
+	if c.rOffset >= len(data) {
+		c.rNext++
+		c.rOffset = 0
+	}
+	return n, msg.Addr, nil
+}
+
+// WriteTo queues a copy of the packet to write.
+func (c *batchPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.done:
+		return 0, net.ErrClosed
+	default:
+	}
+	if err := c.writeErr.Swap(nil); err != nil {
+		return 0, *err
+	}
+	buf := underlayBufferPool.Get(len(b))
+	copy(buf, b)
+	select {
+	case c.writeQueue <- batchWriteRequest{b: buf, addr: addr}:
+		return len(b), nil
+	case <-c.done:
+		underlayBufferPool.Put(buf)
+		return 0, net.ErrClosed
+	}
+}
+
+// Close stops the writer and closes the UDP connection.
+// Packets that are not written yet are dropped.
+func (c *batchPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.UDPConn.Close()
+}
+
+// enableGSO starts to send packets with UDP segmentation offload
+// if the kernel supports it. It returns true if GSO is enabled.
+func (c *batchPacketConn) enableGSO() bool {
+	if !supportsGSO(c.UDPConn) {
+		return false
+	}
+	c.gso.Store(true)
+	return true
+}
+
+// runWriter writes the queued packets until the connection is closed.
+// All the packets that are already queued are written by one system call.
+func (c *batchPacketConn) runWriter() {
+	reqs := make([]batchWriteRequest, 0, packetBatchSize)
+	msgs := make([]ipv4.Message, 0, packetBatchSize)
+	firsts := make([]int, 0, packetBatchSize)
+	for {
+		select {
+		case req := <-c.writeQueue:
+			reqs = append(reqs, req)
+		case <-c.done:
+			return
+		}
+	collect:
+		for len(reqs) < packetBatchSize {
+			select {
+			case req := <-c.writeQueue:
+				reqs = append(reqs, req)
+			default:
+				break collect
+			}
+		}
+
+		pendingReqs := reqs
+		pending, pendingFirsts := c.buildMessages(msgs, firsts, pendingReqs)
+		for len(pending) > 0 {
+			n, err := c.pc.WriteBatch(pending, 0)
+			if err == nil {
+				pending = pending[n:]
+				pendingFirsts = pendingFirsts[n:]
+				continue
+			}
+			n = mathext.Max(n, 0)
+			if len(pending[n].Buffers) > 1 {
+				// The network device may not support GSO.
+				// Send the remaining packets again without GSO.
+				log.Warnf("UDP segmentation offload is disabled for %v because of error: %v", c.LocalAddr(), err)
+				c.gso.Store(false)
+				pendingReqs = pendingReqs[pendingFirsts[n]:]
+				pending, pendingFirsts = c.buildMessages(msgs, firsts, pendingReqs)
+				continue
+			}
+			// The first packet not written is dropped.
+			c.writeErr.Store(&err)
+			pending = pending[n+1:]
+			pendingFirsts = pendingFirsts[n+1:]
+		}
+		written := msgs[:cap(msgs)]
+		for i := range written {
+			written[i] = ipv4.Message{}
+		}
+		for _, req := range reqs {
+			underlayBufferPool.Put(req.b)
+		}
+		reqs = reqs[:0]
+	}
+}
+
+// buildMessages converts the write requests to messages. If GSO is
+// enabled, consecutive packets to the same address with the same size
+// are put in one message, and the last packet of a message can be
+// shorter. It also returns the index of the first request of each message.
+func (c *batchPacketConn) buildMessages(msgs []ipv4.Message, firsts []int, reqs []batchWriteRequest) ([]ipv4.Message, []int) {
+	msgs = msgs[:0]
+	firsts = firsts[:0]
+	gso := c.gso.Load()
+	for i := 0; i < len(reqs); {
+		size := len(reqs[i].b)
+		total := size
+		j := i + 1
+		if gso && size > 0 {
+			for j < len(reqs) && j-i < maxGSOSegments && len(reqs[j-1].b) == size && len(reqs[j].b) <= size && total+len(reqs[j].b) <= maxGSOPayload && sameUDPAddr(reqs[i].addr, reqs[j].addr) {
+				total += len(reqs[j].b)
+				j++
+			}
+		}
+		msg := ipv4.Message{Addr: reqs[i].addr}
+		for k := i; k < j; k++ {
+			msg.Buffers = append(msg.Buffers, reqs[k].b)
+		}
+		if j-i > 1 {
+			msg.OOB = gsoControlMessage(size)
+		}
+		msgs = append(msgs, msg)
+		firsts = append(firsts, i)
+		i = j
+	}
+	return msgs, firsts
+}
+
+// enableUDPOffload starts to send packets with UDP segmentation offload
+// if the connection and the kernel support it.
+func enableUDPOffload(conn net.PacketConn) bool {
+	c, ok := conn.(*batchPacketConn)
+	if !ok {
+		return false
+	}
+	return c.enableGSO()
+}
+
+// supportsGSO returns true if the kernel supports UDP segmentation offload.
+func supportsGSO(conn *net.UDPConn) bool {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	supported := false
+	rawConn.Control(func(fd uintptr) {
+		_, err := unix.GetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_SEGMENT)
+		supported = err == nil
+	})
+	return supported
+}
+
+// enableGRO turns on generic receive offload of the socket.
+// It returns false if the kernel doesn't support it.
+func enableGRO(conn *net.UDPConn) bool {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	enabled := false
+	rawConn.Control(func(fd uintptr) {
+		enabled = unix.SetsockoptInt(int(fd), unix.IPPROTO_UDP, unix.UDP_GRO, 1) == nil
+	})
+	return enabled
+}
+
+// gsoControlMessage returns the control message to split the payload
+// into packets of the given size.
+func gsoControlMessage(size int) []byte {
+	b := make([]byte, unix.CmsgSpace(2))
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&b[0]))
+	h.Level = unix.IPPROTO_UDP
+	h.Type = unix.UDP_SEGMENT
+	h.SetLen(unix.CmsgLen(2))
+	*(*uint16)(unsafe.Pointer(&b[unix.CmsgLen(0)])) = uint16(size)
+	return b
+}
+
+// groSegmentSize returns the size of coalesced packets from the
+// control message, or 0 if packets are not coalesced.
+func groSegmentSize(oob []byte) int {
+	cmsgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0
+	}
+	for _, cmsg := range cmsgs {
+		if cmsg.Header.Level == unix.IPPROTO_UDP && cmsg.Header.Type == unix.UDP_GRO && len(cmsg.Data) >= 4 {
+			return int(*(*int32)(unsafe.Pointer(&cmsg.Data[0])))
+		}
+	}
+	return 0
+}
+
+// sameUDPAddr returns true if the two addresses are the same UDP address.
+func sameUDPAddr(a, b net.Addr) bool {
+	ua, ok := a.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	ub, ok := b.(*net.UDPAddr)
+	if !ok {
+		return false
+	}
+	return ua.Port == ub.Port && ua.Zone == ub.Zone && ua.IP.Equal(ub.IP)
+}
+
+// udpConn returns the UDP connection wrapped by the packet connection.
+func udpConn(conn net.PacketConn) (*net.UDPConn, bool) {
+	switch c := conn.(type) {
+	case *net.UDPConn:
+		return c, true
+	case *batchPacketConn:
+		return c.UDPConn, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBatchPacketConn(t *testing.T) {
+	for _, network := range []string{"udp4", "udp6"} {
+		for _, offload := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/offload=%v", network, offload), func(t *testing.T) {
+				ip := net.IPv4(127, 0, 0, 1)
+				if network == "udp6" {
+					ip = net.IPv6loopback
+				}
+				serverConn, err := net.ListenUDP(network, &net.UDPAddr{IP: ip})
+				if err != nil {
+					t.Skipf("ListenUDP() failed: %v", err)
+				}
+				clientConn, err := net.ListenUDP(network, &net.UDPAddr{IP: ip})
+				if err != nil {
+					t.Fatalf("ListenUDP() failed: %v", err)
+				}
+				server := newBatchPacketConn(serverConn, offload)
+				client := newBatchPacketConn(clientConn, offload)
+				defer server.Close()
+				defer client.Close()
+
+				// Send more packets than a single batch, and don't
+				// exceed the socket receive buffer. Some packets are
+				// shorter, so they end a GSO message.
+				n := 3 * packetBatchSize
+				packetSize := func(i int) int {
+					if i%7 == 6 {
+						return 50
+					}
+					return 100
+				}
+				for i := 0; i < n; i++ {
+					if _, err := client.WriteTo(bytes.Repeat([]byte{byte(i)}, packetSize(i)), server.LocalAddr()); err != nil {
+						t.Fatalf("WriteTo() failed: %v", err)
+					}
+				}
+				buf := make([]byte, maxPathMTU)
+				server.SetReadDeadline(time.Now().Add(5 * time.Second))
+				for i := 0; i < n; i++ {
+					size, addr, err := server.ReadFrom(buf)
+					if err != nil {
+						t.Fatalf("ReadFrom() failed: %v", err)
+					}
+					if size != packetSize(i) || buf[0] != byte(i) || buf[size-1] != byte(i) {
+						t.Fatalf("packet %d has size %d and content %d, want size %d and content %d", i, size, buf[0], packetSize(i), i)
+					}
+					if addr.String() != client.LocalAddr().String() {
+						t.Errorf("packet is from %v, want %v", addr, client.LocalAddr())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBatchPacketConnBuildMessages(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() failed: %v", err)
+	}
+	c := newBatchPacketConn(conn, false).(*batchPacketConn)
+	defer c.Close()
+	c.gso.Store(true)
+
+	addr1 := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	addr2 := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2}
+	reqs := []batchWriteRequest{
+		{make([]byte, 100), addr1},
+		{make([]byte, 100), addr1},
+		{make([]byte, 60), addr1},
+		{make([]byte, 100), addr1},
+		{make([]byte, 100), addr2},
+		{make([]byte, 120), addr2},
+	}
+	msgs, firsts := c.buildMessages(nil, nil, reqs)
+	wantBuffers := []int{3, 1, 1, 1}
+	wantFirsts := []int{0, 3, 4, 5}
+	if len(msgs) != len(wantBuffers) {
+		t.Fatalf("got %d messages, want %d", len(msgs), len(wantBuffers))
+	}
+	for i, msg := range msgs {
+		if len(msg.Buffers) != wantBuffers[i] || firsts[i] != wantFirsts[i] {
+			t.Errorf("message %d has %d packets from request %d, want %d packets from request %d", i, len(msg.Buffers), firsts[i], wantBuffers[i], wantFirsts[i])
+		}
+		if (len(msg.OOB) > 0) != (len(msg.Buffers) > 1) {
+			t.Errorf("message %d with %d packets has control message of %d bytes", i, len(msg.Buffers), len(msg.OOB))
+		}
+	}
+
+	c.gso.Store(false)
+	msgs, _ = c.buildMessages(nil, nil, reqs)
+	if len(msgs) != len(reqs) {
+		t.Errorf("got %d messages without GSO, want %d", len(msgs), len(reqs))
+	}
+}
+
+func TestBatchPacketConnWriteError(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() failed: %v", err)
+	}
+	c := newBatchPacketConn(conn, false)
+	defer c.Close()
+
+	// The packet is too large to send.
+	dst := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9}
+	if _, err := c.WriteTo(make([]byte, 70000), dst); err != nil {
+		t.Fatalf("WriteTo() failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := c.WriteTo([]byte{0}, dst); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("error of the failed write is not returned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	c.Close()
+	if _, err := c.WriteTo([]byte{0}, dst); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("WriteTo() after Close() returned %v, want %v", err, net.ErrClosed)
+	}
+	if _, ok := udpConn(c); !ok {
+		t.Errorf("udpConn() failed to unwrap the connection")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"sync"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+var (
+	// BufferPoolHits is the number of buffers reused from the pool.
+	BufferPoolHits = metrics.RegisterMetric("buffer pool", "Hits", metrics.COUNTER)
+
+	// BufferPoolMisses is the number of buffers allocated because
+	// the pool is empty or the size is too large.
+	BufferPoolMisses = metrics.RegisterMetric("buffer pool", "Misses", metrics.COUNTER)
+)
+
+// underlayBufferPool holds the buffers to read from and write to underlays,
+// and the buffers of received payload. It is shared by UDP and TCP underlays.
+var underlayBufferPool = newBufferPool(maxPathMTU, maxPDU+cipher.DefaultOverhead)
+
+// bufferPool recycles byte slices to reduce the memory allocation
+// at high packet rates. Buffers are grouped by capacity. A buffer
+// larger than the largest capacity is not pooled.
+type bufferPool struct {
+	sizes []int
+	pools []sync.Pool
+}
+
+// newBufferPool creates a buffer pool with the capacities in ascending order.
+func newBufferPool(sizes ...int) *bufferPool {
+	return &bufferPool{
+		sizes: sizes,
+		pools: make([]sync.Pool, len(sizes)),
+	}
+}
+
+// Get returns a buffer of length n. The content of the buffer is undefined.
+func (p *bufferPool) Get(n int) []byte {
+	for i, size := range p.sizes {
+		if n > size {
+			continue
+		}
+		if b, ok := p.pools[i].Get().(*[]byte); ok {
+			BufferPoolHits.Add(1)
+			return (*b)[:n]
+		}
+		BufferPoolMisses.Add(1)
+		return make([]byte, n, size)
+	}
+	BufferPoolMisses.Add(1)
+	return make([]byte, n)
+}
+
+// Put returns a buffer created by Get to the pool.
+// The buffer must not be used after Put.
+func (p *bufferPool) Put(b []byte) {
+	for i, size := range p.sizes {
+		if cap(b) == size {
+			b = b[:size]
+			p.pools[i].Put(&b)
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"testing"
+)
+
+func TestBufferPool(t *testing.T) {
+	p := newBufferPool(16, 64)
+	testCases := []struct {
+		n       int
+		wantCap int
+	}{
+		{0, 16},
+		{16, 16},
+		{17, 64},
+		{64, 64},
+		{65, 65},
+	}
+	for _, tc := range testCases {
+		b := p.Get(tc.n)
+		if len(b) != tc.n || cap(b) != tc.wantCap {
+			t.Errorf("Get(%d) returned len %d cap %d, want len %d cap %d", tc.n, len(b), cap(b), tc.n, tc.wantCap)
+		}
+		p.Put(b)
+	}
+
+	b := p.Get(10)
+	b[0] = 1
+	p.Put(b[:5])
+	b2 := p.Get(16)
+	if len(b2) != 16 || cap(b2) != 16 {
+		t.Errorf("Get(16) returned len %d cap %d, want len 16 cap 16", len(b2), cap(b2))
+	}
+}
+
+func TestSegmentReleaseAndDetach(t *testing.T) {
+	buf := underlayBufferPool.Get(4)
+	copy(buf, []byte{1, 2, 3, 4})
+	seg := &segment{payload: buf, buf: buf}
+	seg.detach()
+	if seg.buf != nil {
+		t.Errorf("pooled buffer is not removed after detach()")
+	}
+	if len(seg.payload) != 4 || seg.payload[3] != 4 {
+		t.Errorf("payload is %v after detach(), want [1 2 3 4]", seg.payload)
+	}
+	seg.release()
+	if seg.payload == nil {
+		t.Errorf("payload not from the pool is removed by release()")
+	}
+
+	buf = underlayBufferPool.Get(4)
+	seg = &segment{payload: buf, buf: buf}
+	seg.release()
+	if seg.buf != nil || seg.payload != nil {
+		t.Errorf("payload is still available after release()")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"github.com/enfein/mieru/v3/pkg/chaos"
+)
+
+// chaosFault returns the fault to inject before the segment is sent.
+// It is always chaos.None unless the binary is built with chaos build tag.
+func chaosFault(seg *segment) chaos.Fault {
+	if !chaos.Enabled {
+		return chaos.None
+	}
+	p := seg.Protocol()
+	isData := p == dataClientToServer || p == dataServerToClient
+	isAck := p == ackClientToServer || p == ackServerToClient
+	return chaos.OnWriteSegment(isData, isAck)
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build chaos
+
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"io"
+	mrand "math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/chaos"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func runChaosServer(t *testing.T, transport common.TransportProtocol) (port int, closeFunc func()) {
+	var err error
+	var serverAddr net.Addr
+	if transport == common.StreamTransport {
+		port, err = common.UnusedTCPPort()
+		serverAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	} else {
+		port, err = common.UnusedUDPPort()
+		serverAddr = &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	}
+	if err != nil {
+		t.Fatalf("find unused port failed: %v", err)
+	}
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, transport, serverAddr, nil)})
+	testServer := testtool.NewTestHelperServer()
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	go func() {
+		testServer.Serve(serverMux)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	return port, func() {
+		testServer.Close()
+		serverMux.Close()
+	}
+}
+
+func runChaosExchange(t *testing.T, conn net.Conn, rounds int) {
+	for i := 0; i < rounds; i++ {
+		payload := testtool.TestHelperGenRot13Input(mrand.Intn(maxPDU) + 1)
+		if _, err := conn.Write(payload); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		resp := make([]byte, len(payload))
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			t.Fatalf("io.ReadFull() failed: %v", err)
+		}
+		rot13, err := testtool.TestHelperRot13(resp)
+		if err != nil {
+			t.Fatalf("TestHelperRot13() failed: %v", err)
+		}
+		if !bytes.Equal(payload, rot13) {
+			t.Fatalf("Received unexpected response")
+		}
+	}
+}
+
+func TestChaosUDPUnderlayLostSegments(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("INFO")
+	defer chaos.Reset()
+	port, closeServer := runChaosServer(t, common.PacketTransport)
+	defer closeServer()
+
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})})
+	defer clientMux.Close()
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Open session request is not retransmitted, so faults are injected
+	// after the session is established.
+	runChaosExchange(t, conn, 1)
+	chaos.DropSegments(20)
+	chaos.DelayAcks(10, 50*time.Millisecond)
+	chaos.CorruptCiphertexts(5)
+	runChaosExchange(t, conn, 100)
+}
+
+func TestChaosKillUnderlay(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("INFO")
+	defer chaos.Reset()
+	port, closeServer := runChaosServer(t, common.StreamTransport)
+	defer closeServer()
+
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})})
+	defer clientMux.Close()
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("before")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	buf := make([]byte, 6)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	// The underlay is closed when it sends the next segment.
+	chaos.KillUnderlays(1)
+	conn.Write([]byte("killed"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(buf); err == nil {
+		t.Errorf("Read() succeeded after the underlay is killed")
+	}
+
+	// A new connection uses a new underlay.
+	time.Sleep(100 * time.Millisecond)
+	conn2, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() after the underlay is killed failed: %v", err)
+	}
+	defer conn2.Close()
+	if _, err := conn2.Write([]byte("aftera")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	conn2.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn2.Read(buf); err != nil {
+		t.Errorf("Read() from new connection failed: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"net"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+)
+
+var _ apicommon.ConnInfo = &Session{}
+
+// Transport implements apicommon.ConnInfo.
+func (s *Session) Transport() string {
+	return underlayTransportName(s.conn)
+}
+
+// UnderlayLocalAddr implements apicommon.ConnInfo.
+func (s *Session) UnderlayLocalAddr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// UnderlayRemoteAddr implements apicommon.ConnInfo.
+func (s *Session) UnderlayRemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+// Cipher implements apicommon.ConnInfo. Sessions that use hybrid key
+// exchange are encrypted again with the keys from the key exchange.
+func (s *Session) Cipher() string {
+	var name string
+	if cu, ok := s.conn.(cipherUnderlay); ok && cu.blockCipher() != nil {
+		name = cu.blockCipher().Algorithm().String()
+	} else {
+		name = "UNKNOWN"
+	}
+	if s.keyExchange == keyExchangeHybrid {
+		name += " with hybrid key exchange"
+	}
+	return name
+}
+
+// SessionID implements apicommon.ConnInfo.
+func (s *Session) SessionID() uint32 {
+	return s.id
+}
+
+// cipherUnderlay is implemented by underlays that know the block cipher
+// used by the sessions.
+type cipherUnderlay interface {
+	blockCipher() cipher.BlockCipher
+}
+
+var (
+	_ cipherUnderlay = &StreamUnderlay{}
+	_ cipherUnderlay = &PacketUnderlay{}
+)
+
+// blockCipher returns the block cipher of the client underlay,
+// or nil in a server underlay. All the candidates use the same algorithm.
+func (t *StreamUnderlay) blockCipher() cipher.BlockCipher {
+	if !t.isClient || len(t.candidates) == 0 {
+		return nil
+	}
+	return t.candidates[0]
+}
+
+// blockCipher returns the block cipher of the client underlay,
+// or nil in a server underlay.
+func (u *PacketUnderlay) blockCipher() cipher.BlockCipher {
+	if !u.isClient {
+		return nil
+	}
+	return u.block
+}
+
+// underlayTransportName returns one of "TCP", "UDP", "TLS" and "WebSocket".
+func underlayTransportName(underlay Underlay) string {
+	switch underlay.(type) {
+	case *WebSocketUnderlay:
+		return "WebSocket"
+	case *TLSUnderlay:
+		return "TLS"
+	case *StreamUnderlay:
+		return "TCP"
+	case *PacketUnderlay:
+		return "UDP"
+	default:
+		return "UNKNOWN"
+	}
+}
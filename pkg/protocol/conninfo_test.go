@@ -0,0 +1,90 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestSessionConnInfo(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	defer serverMux.Close()
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{
+			NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}),
+		})
+	defer clientMux.Close()
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+
+	info, ok := conn.(apicommon.ConnInfo)
+	if !ok {
+		t.Fatalf("%T doesn't implement apicommon.ConnInfo", conn)
+	}
+	if info.Transport() != "TCP" {
+		t.Errorf("Transport() = %q, want %q", info.Transport(), "TCP")
+	}
+	if info.UnderlayRemoteAddr().String() != serverProperties.LocalAddr().String() {
+		t.Errorf("UnderlayRemoteAddr() = %v, want %v", info.UnderlayRemoteAddr(), serverProperties.LocalAddr())
+	}
+	if common.IsNilNetAddr(info.UnderlayLocalAddr()) {
+		t.Errorf("UnderlayLocalAddr() is empty")
+	}
+	if info.Cipher() != "XChaCha20-Poly1305" {
+		t.Errorf("Cipher() = %q, want %q", info.Cipher(), "XChaCha20-Poly1305")
+	}
+	if info.SessionID() != conn.(*Session).id {
+		t.Errorf("SessionID() = %d, want %d", info.SessionID(), conn.(*Session).id)
+	}
+}
@@ -21,4 +21,8 @@ import "time"
 
 const (
 	outputLoopInterval = 1 * time.Millisecond
+
+	// initialReceiveRate is the receive rate in bytes per second
+	// assumed by a new session before any data is received.
+	initialReceiveRate = 4 * 1024 * 1024
 )
@@ -0,0 +1,190 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// Credential push lets the operator rotate the password of a user without
+// touching every client. When the user has a next password, the server
+// accepts both the current and the next password. If a client opens a
+// session with the current password, the server sends a credential push
+// with the next hashed password after the open session response. The
+// client uses the next password for new underlays, and reports it to the
+// application, which stores it to replace the current password.
+//
+// A credential push reuses sessionStruct with the following meaning:
+//   - sessionID: the session that is opened
+//   - payload: the next hashed password of the user
+//
+// The server only sends credential push if the client sets
+// clientFeatureCredentialPush in the open session request. The push is
+// protected by the current password, and it is not retransmitted. If it
+// is lost, the server sends it again with the next session.
+
+var (
+	// SessionCredentialPushes is the number of next passwords
+	// sent by the server.
+	SessionCredentialPushes = metrics.RegisterMetric("session", "CredentialPushes", metrics.COUNTER)
+
+	// SessionCredentialRenewals is the number of times the client
+	// switched to the next password pushed by the server.
+	SessionCredentialRenewals = metrics.RegisterMetric("session", "CredentialRenewals", metrics.COUNTER)
+)
+
+// credentialUnderlay is implemented by all the underlays.
+type credentialUnderlay interface {
+	// setCredential sets the password used by the client underlay,
+	// and the function that receives the next hashed password
+	// pushed by the server. The function can be nil.
+	setCredential(password []byte, handler func([]byte))
+
+	// credential returns the password used by the client underlay.
+	credential() []byte
+}
+
+var _ credentialUnderlay = &baseUnderlay{}
+
+func (b *baseUnderlay) setCredential(password []byte, handler func([]byte)) {
+	b.password = password
+	b.credentialHandler = handler
+}
+
+func (b *baseUnderlay) credential() []byte {
+	return b.password
+}
+
+// onCredentialPush passes the next hashed password to the handler.
+// It is only used by client.
+func (b *baseUnderlay) onCredentialPush(seg *segment) error {
+	if !b.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	defer seg.release()
+	if len(seg.payload) != sha256.Size {
+		return fmt.Errorf("next password size %d is unexpected", len(seg.payload))
+	}
+	if b.credentialHandler == nil {
+		// The client didn't ask for it.
+		return nil
+	}
+	b.credentialHandler(bytes.Clone(seg.payload))
+	return nil
+}
+
+// nextHashedPassword returns the next hashed password of the user.
+// It returns nil if the user doesn't have a next password.
+func nextHashedPassword(user *appctlpb.User) ([]byte, error) {
+	password, err := hex.DecodeString(user.GetNextHashedPassword())
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode next hashed password of user %q: %w", user.GetName(), err)
+	}
+	if len(password) == 0 && user.GetNextPassword() != "" {
+		password = cipher.HashPassword([]byte(user.GetNextPassword()), []byte(user.GetName()))
+	}
+	return password, nil
+}
+
+// nextPasswordUsed returns true if the session is authenticated
+// with the next password of the user.
+func (s *Session) nextPasswordUsed() bool {
+	block := s.block.Load()
+	if block == nil || *block == nil {
+		return false
+	}
+	return (*block).BlockContext().NextPassword
+}
+
+// maybePushCredential sends the next password of the user to the client,
+// if the client accepts it and still uses the current password.
+// It is only used by server.
+func (s *Session) maybePushCredential(req *sessionStruct) {
+	if s.isClient || req.clientFeatures&clientFeatureCredentialPush == 0 || s.nextPasswordUsed() {
+		return
+	}
+	user, ok := s.users[s.UserName()]
+	if !ok {
+		return
+	}
+	next, err := nextHashedPassword(user)
+	if err != nil {
+		log.Debugf("%v nextHashedPassword() failed: %v", s, err)
+		return
+	}
+	if len(next) == 0 {
+		return
+	}
+	seg := &segment{
+		metadata: &sessionStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(credentialPush),
+			},
+			sessionID:  s.id,
+			payloadLen: uint16(len(next)),
+		},
+		payload:   next,
+		transport: s.conn.TransportProtocol(),
+	}
+	switch s.conn.TransportProtocol() {
+	case common.StreamTransport:
+		err = s.conn.(*StreamUnderlay).writeOneSegment(seg)
+	case common.PacketTransport:
+		err = s.conn.(*PacketUnderlay).writeOneSegment(seg, s.RemoteAddr())
+	}
+	if err != nil {
+		log.Debugf("%v failed to push next password: %v", s, err)
+		return
+	}
+	SessionCredentialPushes.Add(1)
+	log.Debugf("%v pushed next password of user %s", s, s.UserName())
+}
+
+// credentialHandler returns the function that receives the next hashed
+// password pushed by the server, or nil if the client doesn't accept it.
+func (m *Mux) credentialHandler() func([]byte) {
+	if m.credHandler == nil {
+		return nil
+	}
+	return m.onCredentialPush
+}
+
+// onCredentialPush switches the client to the next hashed password
+// for new underlays, and calls the credential handler.
+func (m *Mux) onCredentialPush(hashedPassword []byte) {
+	m.mu.Lock()
+	if bytes.Equal(m.password, hashedPassword) {
+		// The password is already renewed.
+		m.mu.Unlock()
+		return
+	}
+	m.password = hashedPassword
+	userName, handler := m.username, m.credHandler
+	m.mu.Unlock()
+	SessionCredentialRenewals.Add(1)
+	log.Infof("Password of user %s is renewed by the proxy server", userName)
+	handler(userName, hashedPassword)
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// A session in datagram mode exchanges unreliable datagrams in addition to
+// the reliable byte stream. Datagrams are neither retransmitted nor ordered,
+// which suits UDP traffic like QUIC, WireGuard or games, where the inner
+// protocol handles loss. The client requests datagram mode in the open
+// session request, and the server confirms it in the open session response.
+// Datagram mode is only available with UDP transport.
+
+const (
+	// datagramQueueCapacity is the maximum number of received datagrams
+	// waiting to be read. New datagrams are dropped when the queue is full.
+	datagramQueueCapacity = 256
+)
+
+var (
+	// SessionDatagramsSent is the number of datagrams sent.
+	SessionDatagramsSent = metrics.RegisterMetric("session", "DatagramsSent", metrics.COUNTER)
+
+	// SessionDatagramsReceived is the number of datagrams received.
+	SessionDatagramsReceived = metrics.RegisterMetric("session", "DatagramsReceived", metrics.COUNTER)
+
+	// SessionDatagramsDropped is the number of received datagrams dropped
+	// because the application doesn't read them in time.
+	SessionDatagramsDropped = metrics.RegisterMetric("session", "DatagramsDropped", metrics.COUNTER)
+)
+
+// DatagramConn is a connection that can also exchange unreliable datagrams.
+type DatagramConn interface {
+	net.Conn
+
+	// DatagramEnabled returns true if both sides agree to exchange datagrams.
+	DatagramEnabled() bool
+
+	// ReadDatagram reads a single datagram. It returns io.ErrShortBuffer
+	// if the buffer is too small, and the datagram is discarded.
+	ReadDatagram(b []byte) (n int, err error)
+
+	// WriteDatagram sends b as a single datagram. It returns
+	// stderror.ErrOutOfRange if b doesn't fit into one packet.
+	WriteDatagram(b []byte) error
+}
+
+var _ DatagramConn = &Session{}
+
+// DatagramEnabled returns true if both sides agree to exchange datagrams.
+func (s *Session) DatagramEnabled() bool {
+	return s.datagramEnabled.Load()
+}
+
+// MaxDatagramSize returns the maximum number of bytes in a datagram.
+func (s *Session) MaxDatagramSize() int {
+	return s.fragmentSize()
+}
+
+// ReadDatagram reads a single datagram.
+func (s *Session) ReadDatagram(b []byte) (n int, err error) {
+	if !s.DatagramEnabled() {
+		return 0, stderror.ErrUnsupported
+	}
+	var timeC <-chan time.Time
+	if !s.readDeadline.IsZero() {
+		timeC = time.After(time.Until(s.readDeadline))
+	}
+	select {
+	case datagram := <-s.datagrams:
+		if len(datagram) > len(b) {
+			return 0, io.ErrShortBuffer
+		}
+		return copy(b, datagram), nil
+	case <-s.closedChan:
+		return 0, s.closedError(io.EOF)
+	case <-timeC:
+		return 0, stderror.ErrTimeout
+	}
+}
+
+// WriteDatagram sends b as a single datagram.
+func (s *Session) WriteDatagram(b []byte) error {
+	if !s.DatagramEnabled() {
+		return stderror.ErrUnsupported
+	}
+	if s.closeRequested.Load() || s.isStateAfter(sessionClosed, true) {
+		return s.closedError(io.ErrClosedPipe)
+	}
+	if len(b) > s.MaxDatagramSize() {
+		return stderror.ErrOutOfRange
+	}
+	s.touch()
+	protocol := datagramServerToClient
+	if s.isClient {
+		protocol = datagramClientToServer
+	}
+	seg := &segment{
+		metadata: &dataAckStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(protocol),
+			},
+			sessionID:  s.id,
+			payloadLen: uint16(len(b)),
+		},
+		payload:   make([]byte, len(b)),
+		transport: s.conn.TransportProtocol(),
+	}
+	copy(seg.payload, b)
+	if err := s.conn.(*PacketUnderlay).writeOneSegment(seg, s.RemoteAddr()); err != nil {
+		if stderror.IsNotReady(err) {
+			// The datagram is lost.
+			return nil
+		}
+		return err
+	}
+	SessionDatagramsSent.Add(1)
+	s.countSentSegment()
+	if !s.isClient && s.downloadBytes != nil {
+		s.downloadBytes.Add(int64(len(b)))
+	}
+	return nil
+}
+
+// maybeEnableDatagram enables datagram mode if it is requested by the open
+// session request, or confirmed by the open session response.
+func (s *Session) maybeEnableDatagram(seg *segment) {
+	if s.DatagramEnabled() || s.conn.TransportProtocol() != common.PacketTransport {
+		return
+	}
+	if seg.metadata.(*sessionStruct).statusCode != uint8(statusDatagram) {
+		return
+	}
+	if s.isClient && !s.datagram {
+		return
+	}
+	if s.datagrams == nil {
+		s.datagrams = make(chan []byte, datagramQueueCapacity)
+	}
+	s.datagramEnabled.Store(true)
+	log.Debugf("%v enabled datagram mode", s)
+}
+
+// inputDatagram delivers a received datagram to the application.
+func (s *Session) inputDatagram(seg *segment) error {
+	if !s.DatagramEnabled() {
+		return nil
+	}
+	SessionDatagramsReceived.Add(1)
+	if !s.isClient && s.uploadBytes != nil {
+		s.uploadBytes.Add(int64(len(seg.payload)))
+	}
+	select {
+	case s.datagrams <- seg.payload:
+	default:
+		SessionDatagramsDropped.Add(1)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// runDatagramEchoServer accepts sessions from the server mux. For each
+// session, it reads the greeting from the byte stream, then sends back
+// every datagram it receives.
+func runDatagramEchoServer(t *testing.T, serverMux *Mux) {
+	for {
+		conn, err := serverMux.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			greeting := make([]byte, 5)
+			if _, err := io.ReadFull(conn, greeting); err != nil {
+				t.Errorf("io.ReadFull() failed: %v", err)
+				return
+			}
+			dc := conn.(DatagramConn)
+			if !dc.DatagramEnabled() {
+				conn.Write([]byte("nodgm"))
+				return
+			}
+			conn.Write([]byte("ready"))
+			b := make([]byte, 2048)
+			for {
+				n, err := dc.ReadDatagram(b)
+				if err != nil {
+					return
+				}
+				if err := dc.WriteDatagram(b[:n]); err != nil {
+					t.Errorf("WriteDatagram() failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestDatagramSession(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverAddr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	defer serverMux.Close()
+	go runDatagramEchoServer(t, serverMux)
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, serverAddr)
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+	conn, err := clientMux.DialDatagramContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialDatagramContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	resp := make([]byte, 5)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if string(resp) != "ready" || !conn.DatagramEnabled() {
+		t.Fatalf("datagram mode is not enabled")
+	}
+
+	received := 0
+	b := make([]byte, 2048)
+	for i := 0; i < 10; i++ {
+		datagram := bytes.Repeat([]byte{byte(i)}, 1000)
+		if err := conn.WriteDatagram(datagram); err != nil {
+			t.Fatalf("WriteDatagram() failed: %v", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.ReadDatagram(b)
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(b[:n], datagram) {
+			t.Errorf("received datagram is different from the sent one")
+		}
+		received++
+	}
+	if received == 0 {
+		t.Errorf("no datagram is received")
+	}
+	if err := conn.WriteDatagram(make([]byte, 4096)); !errors.Is(err, stderror.ErrOutOfRange) {
+		t.Errorf("WriteDatagram() with large datagram got error %v, want %v", err, stderror.ErrOutOfRange)
+	}
+}
+
+func TestDatagramSessionWithTCP(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	defer serverMux.Close()
+	go runDatagramEchoServer(t, serverMux)
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, serverAddr)
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+	conn, err := clientMux.DialDatagramContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialDatagramContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	resp := make([]byte, 5)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if string(resp) != "nodgm" || conn.DatagramEnabled() {
+		t.Errorf("datagram mode is enabled with TCP transport")
+	}
+	if err := conn.WriteDatagram([]byte("hello")); !errors.Is(err, stderror.ErrUnsupported) {
+		t.Errorf("WriteDatagram() got error %v, want %v", err, stderror.ErrUnsupported)
+	}
+}
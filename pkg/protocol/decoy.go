@@ -0,0 +1,153 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// An active prober that connects to the TCP port of the proxy server and
+// sends random or replayed data sees the connection drained and closed,
+// which is unusual for a public service. If a decoy is configured,
+// a connection that fails authentication in the first segment is served
+// by the decoy instead, so the prober sees a plausible web server.
+
+const (
+	// decoyDialTimeout is the timeout to connect to the decoy service.
+	decoyDialTimeout = 5 * time.Second
+
+	// decoyReadTimeout is the timeout to receive a HTTP request
+	// when the decoy serves a static page.
+	decoyReadTimeout = 10 * time.Second
+
+	// decoyMaxRequestSize is the maximum size of a HTTP request header
+	// when the decoy serves a static page.
+	decoyMaxRequestSize = 16 * 1024
+)
+
+var (
+	// UnderlayDecoyConnections is the number of connections served by the decoy.
+	UnderlayDecoyConnections = metrics.RegisterMetric("underlay", "DecoyConnections", metrics.COUNTER)
+
+	// UnderlayDecoyErrors is the number of connections that the decoy failed to serve.
+	UnderlayDecoyErrors = metrics.RegisterMetric("underlay", "DecoyErrors", metrics.COUNTER)
+)
+
+// Decoy serves the TCP connections that fail authentication.
+type Decoy struct {
+	// Address of the decoy service in "host:port" format, typically a web
+	// server in localhost. The data received from the connection is relayed
+	// to this address.
+	Address string
+
+	// Page is the body of the HTTP response if Address is empty.
+	Page []byte
+}
+
+// enabled returns true if the decoy is configured.
+func (d *Decoy) enabled() bool {
+	return d != nil && (d.Address != "" || len(d.Page) > 0)
+}
+
+// serve serves the connection with the decoy. received is the data
+// already read from the connection.
+func (d *Decoy) serve(conn net.Conn, received []byte) error {
+	UnderlayDecoyConnections.Add(1)
+	var err error
+	if d.Address != "" {
+		err = d.relay(conn, received)
+	} else {
+		err = d.servePage(conn, received)
+	}
+	if err != nil {
+		UnderlayDecoyErrors.Add(1)
+	}
+	return err
+}
+
+// relay relays the connection to the decoy service.
+func (d *Decoy) relay(conn net.Conn, received []byte) error {
+	target, err := net.DialTimeout("tcp", d.Address, decoyDialTimeout)
+	if err != nil {
+		return fmt.Errorf("connect to decoy %s failed: %w", d.Address, err)
+	}
+	if _, err := target.Write(received); err != nil {
+		target.Close()
+		return fmt.Errorf("write to decoy %s failed: %w", d.Address, err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	common.BidiCopy(conn, target)
+	return nil
+}
+
+// servePage replies a HTTP request with the static page, or a bad request
+// response if the received data is not a HTTP request.
+func (d *Decoy) servePage(conn net.Conn, received []byte) error {
+	conn.SetReadDeadline(time.Now().Add(decoyReadTimeout))
+	req := append([]byte{}, received...)
+	buf := make([]byte, 4096)
+	for !bytes.Contains(req, []byte("\r\n\r\n")) && len(req) < decoyMaxRequestSize && isHTTPRequestLine(req) {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return fmt.Errorf("read HTTP request failed: %w", err)
+		}
+		req = append(req, buf[:n]...)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	var resp bytes.Buffer
+	if isHTTPRequestLine(req) && bytes.Contains(req, []byte("\r\n\r\n")) {
+		resp.WriteString("HTTP/1.1 200 OK\r\n")
+		resp.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+		resp.WriteString("Content-Length: " + strconv.Itoa(len(d.Page)) + "\r\n")
+		resp.WriteString("Connection: close\r\n\r\n")
+		if !bytes.HasPrefix(req, []byte("HEAD ")) {
+			resp.Write(d.Page)
+		}
+	} else {
+		body := "<html><body><h1>400 Bad Request</h1></body></html>\n"
+		resp.WriteString("HTTP/1.1 400 Bad Request\r\n")
+		resp.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+		resp.WriteString("Content-Length: " + strconv.Itoa(len(body)) + "\r\n")
+		resp.WriteString("Connection: close\r\n\r\n")
+		resp.WriteString(body)
+	}
+	if _, err := conn.Write(resp.Bytes()); err != nil {
+		return fmt.Errorf("write HTTP response failed: %w", err)
+	}
+	return nil
+}
+
+// isHTTPRequestLine returns true if the data may start with a HTTP/1 request
+// line, which is a method in upper case letters followed by a space.
+func isHTTPRequestLine(b []byte) bool {
+	for i, c := range b {
+		if c == ' ' {
+			return i > 0
+		}
+		if c < 'A' || c > 'Z' || i >= 16 {
+			return false
+		}
+	}
+	return true
+}
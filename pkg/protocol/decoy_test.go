@@ -0,0 +1,126 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+func TestDecoyAddress(t *testing.T) {
+	log.SetOutputToTest(t)
+	decoyListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer decoyListener.Close()
+	go func() {
+		conn, err := decoyListener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetServerDecoy(&Decoy{Address: decoyListener.Addr().String()})
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	defer serverMux.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	// The probe fails authentication, and the decoy echoes it back.
+	conn, err := net.Dial("tcp", serverProperties.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	probe := bytes.Repeat([]byte{0x5a}, 128)
+	if _, err := conn.Write(probe); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	got := make([]byte, len(probe))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("ReadFull() failed: %v", err)
+	}
+	if !bytes.Equal(got, probe) {
+		t.Errorf("data from decoy = %v, want %v", got, probe)
+	}
+}
+
+func TestDecoyPage(t *testing.T) {
+	page := []byte("<html><body>hello</body></html>")
+	decoy := &Decoy{Page: page}
+	testcases := []struct {
+		name     string
+		request  string
+		split    bool // the underlay has read a part of the request
+		status   string
+		wantPage bool
+	}{
+		{"get", "GET / HTTP/1.1\r\nHost: www.example.com\r\n\r\n", true, "HTTP/1.1 200 OK", true},
+		{"head", "HEAD / HTTP/1.1\r\nHost: www.example.com\r\n\r\n", true, "HTTP/1.1 200 OK", false},
+		{"not http", "\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03", false, "HTTP/1.1 400 Bad Request", false},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer client.Close()
+			req := []byte(tc.request)
+			n := len(req)
+			if tc.split {
+				n = len(req) / 2
+			}
+			go func() {
+				defer server.Close()
+				if err := decoy.serve(server, req[:n]); err != nil {
+					t.Errorf("serve() failed: %v", err)
+				}
+			}()
+			if n < len(req) {
+				if _, err := client.Write(req[n:]); err != nil {
+					t.Fatalf("Write() failed: %v", err)
+				}
+			}
+			resp, err := io.ReadAll(client)
+			if err != nil {
+				t.Fatalf("ReadAll() failed: %v", err)
+			}
+			if !bytes.HasPrefix(resp, []byte(tc.status+"\r\n")) {
+				t.Errorf("response = %q, want status %q", resp, tc.status)
+			}
+			if bytes.HasSuffix(resp, page) != tc.wantPage {
+				t.Errorf("response = %q, contains page is not %v", resp, tc.wantPage)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"sync"
+
+	"github.com/enfein/mieru/v3/pkg/mathext"
+)
+
+// egressScheduler decides which session can write data to the underlay.
+// When multiple sessions are waiting, they are served in weighted
+// round-robin order. In each round, a session sends at most as many
+// segments as its weight, so a session with a lot of data to send
+// doesn't starve other sessions sharing the same underlay.
+//
+// The zero value is ready to use.
+type egressScheduler struct {
+	mu      sync.Mutex
+	busy    bool                       // if a session is writing data
+	waiters map[uint32][]chan struct{} // Map<sessionID, waiters in FIFO order>
+	weights map[uint32]int             // Map<sessionID, number of segments to send in each round>
+	credits map[uint32]int             // Map<sessionID, number of segments left in the current round>
+	order   []uint32                   // session IDs to serve in round-robin order
+}
+
+// acquire blocks until the session is allowed to write data.
+// The weight is the number of segments the session can send
+// in each round. A weight smaller than 1 is treated as 1.
+func (e *egressScheduler) acquire(sessionID uint32, weight int) {
+	e.mu.Lock()
+	if !e.busy {
+		e.busy = true
+		e.mu.Unlock()
+		return
+	}
+	if e.waiters == nil {
+		e.waiters = make(map[uint32][]chan struct{})
+		e.weights = make(map[uint32]int)
+		e.credits = make(map[uint32]int)
+	}
+	ch := make(chan struct{})
+	if len(e.waiters[sessionID]) == 0 {
+		e.order = append(e.order, sessionID)
+	}
+	e.waiters[sessionID] = append(e.waiters[sessionID], ch)
+	e.weights[sessionID] = mathext.Max(weight, 1)
+	e.mu.Unlock()
+	<-ch
+}
+
+// release passes the right to write data to the next session.
+func (e *egressScheduler) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.order) == 0 {
+		e.busy = false
+		return
+	}
+	sessionID := e.order[0]
+	waiters := e.waiters[sessionID]
+	credit, ok := e.credits[sessionID]
+	if !ok {
+		// Start a new round of this session.
+		credit = e.weights[sessionID]
+	}
+	credit--
+	if len(waiters) > 1 {
+		e.waiters[sessionID] = waiters[1:]
+		if credit > 0 {
+			e.credits[sessionID] = credit
+		} else {
+			// Serve other sessions before the next segment of this session.
+			delete(e.credits, sessionID)
+			e.order = append(e.order[1:], sessionID)
+		}
+	} else {
+		e.order = e.order[1:]
+		delete(e.waiters, sessionID)
+		delete(e.weights, sessionID)
+		delete(e.credits, sessionID)
+	}
+	// The scheduler stays busy. The right is handed over to the waiter.
+	close(waiters[0])
+}
+
+// waiting returns the number of waiters of all sessions.
+func (e *egressScheduler) waiting() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := 0
+	for _, w := range e.waiters {
+		n += len(w)
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEgressSchedulerRoundRobin(t *testing.T) {
+	var e egressScheduler
+	e.acquire(0, 1)
+
+	// Session 1 has 3 segments to send, session 2 and 3 have 1 segment.
+	var mu sync.Mutex
+	var served []uint32
+	var wg sync.WaitGroup
+	for i, id := range []uint32{1, 1, 1, 2, 3} {
+		wg.Add(1)
+		go func(id uint32) {
+			defer wg.Done()
+			e.acquire(id, 1)
+			mu.Lock()
+			served = append(served, id)
+			mu.Unlock()
+			e.release()
+		}(id)
+		// Make sure the waiters are registered in order.
+		deadline := time.Now().Add(time.Second)
+		for e.waiting() != i+1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("waiter %d is not registered", i)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	e.release()
+	wg.Wait()
+
+	want := []uint32{1, 2, 3, 1, 1}
+	if len(served) != len(want) {
+		t.Fatalf("served %v, want %v", served, want)
+	}
+	for i := range want {
+		if served[i] != want[i] {
+			t.Fatalf("served %v, want %v", served, want)
+		}
+	}
+	if e.busy {
+		t.Errorf("scheduler is busy after all the sessions are served")
+	}
+}
+
+func TestEgressSchedulerWeighted(t *testing.T) {
+	var e egressScheduler
+	e.acquire(0, 1)
+
+	// Session 1 has weight 1, session 2 has weight 4.
+	// Both of them have 3 segments to send.
+	weights := map[uint32]int{1: 1, 2: 4}
+	var mu sync.Mutex
+	var served []uint32
+	var wg sync.WaitGroup
+	for i, id := range []uint32{1, 1, 1, 2, 2, 2} {
+		wg.Add(1)
+		go func(id uint32) {
+			defer wg.Done()
+			e.acquire(id, weights[id])
+			mu.Lock()
+			served = append(served, id)
+			mu.Unlock()
+			e.release()
+		}(id)
+		// Make sure the waiters are registered in order.
+		deadline := time.Now().Add(time.Second)
+		for e.waiting() != i+1 {
+			if time.Now().After(deadline) {
+				t.Fatalf("waiter %d is not registered", i)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	e.release()
+	wg.Wait()
+
+	want := []uint32{1, 2, 2, 2, 1, 1}
+	if len(served) != len(want) {
+		t.Fatalf("served %v, want %v", served, want)
+	}
+	for i := range want {
+		if served[i] != want[i] {
+			t.Fatalf("served %v, want %v", served, want)
+		}
+	}
+	if e.busy || len(e.credits) != 0 || len(e.weights) != 0 {
+		t.Errorf("scheduler state is not cleared after all the sessions are served")
+	}
+}
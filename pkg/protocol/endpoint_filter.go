@@ -0,0 +1,89 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+)
+
+// EndpointFilter restricts the proxy server endpoints that a client
+// session can use. A zero value doesn't restrict anything.
+type EndpointFilter struct {
+	// If it is not UnknownTransport, only the endpoints with this
+	// transport protocol are used.
+	TransportProtocol common.TransportProtocol
+
+	// If it is not empty, only the endpoints with one of these
+	// IP addresses are used.
+	ServerIPs []net.IP
+}
+
+// matchAddr returns true if the transport protocol and the remote address
+// are allowed by the filter.
+func (f EndpointFilter) matchAddr(transport common.TransportProtocol, addr net.Addr) bool {
+	if f.TransportProtocol != common.UnknownTransport && f.TransportProtocol != transport {
+		return false
+	}
+	if len(f.ServerIPs) == 0 {
+		return true
+	}
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	default:
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+		ip = net.ParseIP(host)
+	}
+	for _, serverIP := range f.ServerIPs {
+		if serverIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the endpoint or underlay is allowed by the filter.
+func (f EndpointFilter) match(p UnderlayProperties) bool {
+	return f.matchAddr(p.TransportProtocol(), p.RemoteAddr())
+}
+
+// endpointFilterKey is the context key of endpoint filter.
+type endpointFilterKey struct{}
+
+// WithEndpointFilter returns a context to dial a session that only
+// uses the proxy server endpoints allowed by the filter. Dial returns
+// an error if no endpoint is allowed.
+func WithEndpointFilter(ctx context.Context, filter EndpointFilter) context.Context {
+	return context.WithValue(ctx, endpointFilterKey{}, filter)
+}
+
+// endpointFilterFromContext returns the endpoint filter in the context,
+// or a zero value if the context doesn't have it.
+func endpointFilterFromContext(ctx context.Context) EndpointFilter {
+	if filter, ok := ctx.Value(endpointFilterKey{}).(EndpointFilter); ok {
+		return filter
+	}
+	return EndpointFilter{}
+}
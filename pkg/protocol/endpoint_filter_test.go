@@ -0,0 +1,68 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+)
+
+func TestEndpointFilterMatch(t *testing.T) {
+	tcpEndpoint := NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8964})
+	udpEndpoint := NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 8964})
+
+	testCases := []struct {
+		filter  EndpointFilter
+		wantTCP bool
+		wantUDP bool
+	}{
+		{EndpointFilter{}, true, true},
+		{EndpointFilter{TransportProtocol: common.StreamTransport}, true, false},
+		{EndpointFilter{TransportProtocol: common.PacketTransport}, false, true},
+		{EndpointFilter{ServerIPs: []net.IP{net.ParseIP("10.0.0.2")}}, false, true},
+		{EndpointFilter{ServerIPs: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}}, true, true},
+		{EndpointFilter{TransportProtocol: common.StreamTransport, ServerIPs: []net.IP{net.ParseIP("10.0.0.2")}}, false, false},
+	}
+	for _, tc := range testCases {
+		if got := tc.filter.match(tcpEndpoint); got != tc.wantTCP {
+			t.Errorf("%+v match(TCP endpoint) = %v, want %v", tc.filter, got, tc.wantTCP)
+		}
+		if got := tc.filter.match(udpEndpoint); got != tc.wantUDP {
+			t.Errorf("%+v match(UDP endpoint) = %v, want %v", tc.filter, got, tc.wantUDP)
+		}
+	}
+}
+
+func TestDialWithEndpointFilter(t *testing.T) {
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8964})})
+	defer clientMux.Close()
+
+	ctx := WithEndpointFilter(context.Background(), EndpointFilter{TransportProtocol: common.PacketTransport})
+	_, err := clientMux.DialContext(ctx)
+	if err == nil {
+		t.Fatalf("DialContext() succeeded without any matching endpoint")
+	}
+	if !strings.Contains(err.Error(), "no server endpoint matches") {
+		t.Errorf("DialContext() returned unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+const (
+	// endpointBlacklistMin is the time an endpoint is not used after
+	// the first failed handshake. It is doubled for each consecutive
+	// failure, up to endpointBlacklistMax.
+	endpointBlacklistMin = 30 * time.Second
+	endpointBlacklistMax = 10 * time.Minute
+)
+
+// EndpointPolicy decides which proxy server endpoint is used to open
+// a new underlay, if the client has multiple endpoints.
+type EndpointPolicy uint8
+
+const (
+	// RandomEndpoint picks an endpoint randomly.
+	RandomEndpoint EndpointPolicy = iota
+
+	// RoundRobinEndpoint picks the endpoints one after another.
+	RoundRobinEndpoint
+
+	// LowestLatencyEndpoint picks the endpoint with the lowest handshake
+	// latency. Endpoints that are not measured yet are tried first.
+	LowestLatencyEndpoint
+
+	// StickyEndpoint keeps using the same endpoint until it fails,
+	// then moves to the next endpoint in the configured order.
+	StickyEndpoint
+)
+
+func (p EndpointPolicy) String() string {
+	switch p {
+	case RandomEndpoint:
+		return "RANDOM"
+	case RoundRobinEndpoint:
+		return "ROUND_ROBIN"
+	case LowestLatencyEndpoint:
+		return "LOWEST_LATENCY"
+	case StickyEndpoint:
+		return "STICKY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// endpointKey identifies an endpoint in the selector and the metrics.
+func endpointKey(p UnderlayProperties) string {
+	return p.RemoteAddr().Network() + "/" + p.RemoteAddr().String()
+}
+
+// endpointHealth is the recent handshake result of an endpoint.
+type endpointHealth struct {
+	failures       int               // number of consecutive failures
+	blacklistUntil time.Time         // the endpoint is not used before this time
+	latency        time.Duration     // moving average of handshake latency, 0 if not measured
+	badPorts       map[int]time.Time // ports of a port range not used before the time
+
+	active      metrics.Metric
+	blacklisted metrics.Metric
+	success     metrics.Metric
+	failure     metrics.Metric
+	latencyMs   metrics.Metric
+}
+
+func newEndpointHealth(key string) *endpointHealth {
+	group := fmt.Sprintf(metrics.EndpointMetricGroupFormat, key)
+	return &endpointHealth{
+		active:      metrics.RegisterMetric(group, metrics.EndpointMetricActive, metrics.GAUGE),
+		blacklisted: metrics.RegisterMetric(group, metrics.EndpointMetricBlacklisted, metrics.GAUGE),
+		success:     metrics.RegisterMetric(group, metrics.EndpointMetricHandshakeSuccess, metrics.COUNTER),
+		failure:     metrics.RegisterMetric(group, metrics.EndpointMetricHandshakeFailure, metrics.COUNTER),
+		latencyMs:   metrics.RegisterMetric(group, metrics.EndpointMetricLatencyMs, metrics.GAUGE),
+	}
+}
+
+// endpointSelector picks the endpoint of a new client underlay with
+// the policy, and skips the endpoints that failed recently.
+type endpointSelector struct {
+	mu     sync.Mutex
+	policy EndpointPolicy
+	next   int    // next index of round robin
+	sticky string // key of the endpoint used by sticky policy
+	active string // key of the endpoint selected most recently
+	health map[string]*endpointHealth
+}
+
+func newEndpointSelector() *endpointSelector {
+	return &endpointSelector{
+		health: make(map[string]*endpointHealth),
+	}
+}
+
+func (s *endpointSelector) setPolicy(policy EndpointPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+}
+
+// getHealth returns the health of the endpoint.
+// This method MUST be called only when holding the mu lock.
+func (s *endpointSelector) getHealth(key string) *endpointHealth {
+	h, ok := s.health[key]
+	if !ok {
+		h = newEndpointHealth(key)
+		s.health[key] = h
+	}
+	return h
+}
+
+// pick returns one of the endpoints. The endpoints must not be empty.
+// Blacklisted endpoints are skipped, unless all the endpoints are
+// blacklisted.
+func (s *endpointSelector) pick(endpoints []UnderlayProperties) UnderlayProperties {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	candidates := make([]UnderlayProperties, 0, len(endpoints))
+	for _, p := range endpoints {
+		h := s.getHealth(endpointKey(p))
+		if now.Before(h.blacklistUntil) {
+			h.blacklisted.Store(1)
+		} else {
+			h.blacklisted.Store(0)
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = endpoints
+	}
+
+	var p UnderlayProperties
+	switch s.policy {
+	case RoundRobinEndpoint:
+		p = candidates[s.next%len(candidates)]
+		s.next++
+	case LowestLatencyEndpoint:
+		for _, c := range candidates {
+			latency := s.getHealth(endpointKey(c)).latency
+			if latency == 0 {
+				p = c
+				break
+			}
+			if p == nil || latency < s.getHealth(endpointKey(p)).latency {
+				p = c
+			}
+		}
+	case StickyEndpoint:
+		for _, c := range candidates {
+			if endpointKey(c) == s.sticky {
+				p = c
+				break
+			}
+		}
+		if p == nil {
+			p = candidates[0]
+			s.sticky = endpointKey(p)
+		}
+	default:
+		p = candidates[mrand.Intn(len(candidates))]
+	}
+
+	key := endpointKey(p)
+	if key != s.active {
+		if s.active != "" {
+			s.getHealth(s.active).active.Store(0)
+			log.Debugf("Active proxy endpoint is changed from %s to %s", s.active, key)
+		}
+		s.active = key
+		s.getHealth(key).active.Store(1)
+	}
+	return p
+}
+
+// onSuccess records a successful handshake with the endpoint.
+func (s *endpointSelector) onSuccess(key string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.getHealth(key)
+	h.failures = 0
+	h.blacklistUntil = time.Time{}
+	h.blacklisted.Store(0)
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration((1-qualityWeight)*float64(h.latency) + qualityWeight*float64(latency))
+	}
+	h.success.Add(1)
+	h.latencyMs.Store(h.latency.Milliseconds())
+}
+
+// onFailure records a failed dial or handshake with the endpoint,
+// and blacklists the endpoint for a while.
+func (s *endpointSelector) onFailure(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blacklist(key, s.getHealth(key))
+}
+
+// blacklist records a failure of the endpoint and blacklists it.
+// This method MUST be called only when holding the mu lock.
+func (s *endpointSelector) blacklist(key string, h *endpointHealth) {
+	h.failures++
+	d := endpointBlacklistMin
+	for i := 1; i < h.failures && d < endpointBlacklistMax; i++ {
+		d *= 2
+	}
+	if d > endpointBlacklistMax {
+		d = endpointBlacklistMax
+	}
+	h.blacklistUntil = time.Now().Add(d)
+	h.blacklisted.Store(1)
+	h.failure.Add(1)
+	if s.sticky == key {
+		s.sticky = ""
+	}
+	log.Debugf("Proxy endpoint %s is blacklisted for %v after %d failures", key, d, h.failures)
+}
+
+// trackHandshake reports the handshake result of a client session
+// to the endpoint selector and the event listener.
+func (m *Mux) trackHandshake(session *Session, underlay Underlay) {
+	eu, ok := underlay.(endpointUnderlay)
+	if !ok || eu.dialedEndpoint() == "" {
+		return
+	}
+	key, port := eu.dialedEndpoint(), eu.dialedPort()
+	event := m.underlayEvent(underlay, nil)
+	session.onHandshake = func(err error, latency time.Duration) {
+		if err != nil {
+			if port != 0 {
+				m.selector.onPortFailure(key, port)
+			} else {
+				m.selector.onFailure(key)
+			}
+			event.Reason = err
+			m.notifyHandshakeFailure(event)
+		} else {
+			m.selector.onSuccess(key, latency)
+		}
+	}
+}
+
+// endpointUnderlay is implemented by all the underlays.
+type endpointUnderlay interface {
+	setEndpoint(key string, port int)
+	dialedEndpoint() string
+	dialedPort() int
+}
+
+var _ endpointUnderlay = &baseUnderlay{}
+
+// setEndpoint sets the endpoint that is dialed. The port is 0 unless
+// the endpoint is a port range.
+func (b *baseUnderlay) setEndpoint(key string, port int) {
+	b.endpoint = key
+	b.endpointPort = port
+}
+
+func (b *baseUnderlay) dialedEndpoint() string {
+	return b.endpoint
+}
+
+func (b *baseUnderlay) dialedPort() int {
+	return b.endpointPort
+}
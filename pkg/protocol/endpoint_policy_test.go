@@ -0,0 +1,121 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+func testEndpoints(n int) []UnderlayProperties {
+	endpoints := make([]UnderlayProperties, 0, n)
+	for i := 0; i < n; i++ {
+		endpoints = append(endpoints, NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP(fmt.Sprintf("10.1.0.%d", i+1)), Port: 8964}))
+	}
+	return endpoints
+}
+
+func TestEndpointSelectorRoundRobin(t *testing.T) {
+	endpoints := testEndpoints(3)
+	s := newEndpointSelector()
+	s.setPolicy(RoundRobinEndpoint)
+	for i := 0; i < 6; i++ {
+		if got := s.pick(endpoints); got != endpoints[i%3] {
+			t.Errorf("pick() #%d = %v, want %v", i, got.RemoteAddr(), endpoints[i%3].RemoteAddr())
+		}
+	}
+
+	// Blacklisted endpoint is skipped.
+	s.onFailure(endpointKey(endpoints[1]))
+	for i := 0; i < 4; i++ {
+		if got := s.pick(endpoints); got == endpoints[1] {
+			t.Errorf("pick() returned blacklisted endpoint %v", got.RemoteAddr())
+		}
+	}
+}
+
+func TestEndpointSelectorLowestLatency(t *testing.T) {
+	endpoints := testEndpoints(3)
+	s := newEndpointSelector()
+	s.setPolicy(LowestLatencyEndpoint)
+	s.onSuccess(endpointKey(endpoints[0]), 80*time.Millisecond)
+	s.onSuccess(endpointKey(endpoints[1]), 20*time.Millisecond)
+
+	// Endpoint not measured yet is tried first.
+	if got := s.pick(endpoints); got != endpoints[2] {
+		t.Errorf("pick() = %v, want %v", got.RemoteAddr(), endpoints[2].RemoteAddr())
+	}
+	s.onSuccess(endpointKey(endpoints[2]), 50*time.Millisecond)
+	if got := s.pick(endpoints); got != endpoints[1] {
+		t.Errorf("pick() = %v, want %v", got.RemoteAddr(), endpoints[1].RemoteAddr())
+	}
+	s.onFailure(endpointKey(endpoints[1]))
+	if got := s.pick(endpoints); got != endpoints[2] {
+		t.Errorf("pick() = %v, want %v", got.RemoteAddr(), endpoints[2].RemoteAddr())
+	}
+}
+
+func TestEndpointSelectorSticky(t *testing.T) {
+	endpoints := testEndpoints(3)
+	s := newEndpointSelector()
+	s.setPolicy(StickyEndpoint)
+	for i := 0; i < 3; i++ {
+		if got := s.pick(endpoints); got != endpoints[0] {
+			t.Errorf("pick() = %v, want %v", got.RemoteAddr(), endpoints[0].RemoteAddr())
+		}
+	}
+
+	// Fail over to the next endpoint, and stay there after the first
+	// endpoint is recovered.
+	s.onFailure(endpointKey(endpoints[0]))
+	if got := s.pick(endpoints); got != endpoints[1] {
+		t.Errorf("pick() = %v, want %v", got.RemoteAddr(), endpoints[1].RemoteAddr())
+	}
+	s.onSuccess(endpointKey(endpoints[0]), 10*time.Millisecond)
+	if got := s.pick(endpoints); got != endpoints[1] {
+		t.Errorf("pick() = %v, want %v", got.RemoteAddr(), endpoints[1].RemoteAddr())
+	}
+
+	group := metrics.GetMetricGroupByName(fmt.Sprintf(metrics.EndpointMetricGroupFormat, endpointKey(endpoints[1])))
+	if group == nil {
+		t.Fatalf("metric group of endpoint %s is not found", endpointKey(endpoints[1]))
+	}
+	if active, ok := group.GetMetric(metrics.EndpointMetricActive); !ok || active.Load() != 1 {
+		t.Errorf("endpoint %s is not reported as active", endpointKey(endpoints[1]))
+	}
+}
+
+func TestEndpointSelectorAllBlacklisted(t *testing.T) {
+	endpoints := testEndpoints(2)
+	s := newEndpointSelector()
+	s.setPolicy(StickyEndpoint)
+	for i := 0; i < 3; i++ {
+		s.onFailure(endpointKey(endpoints[0]))
+	}
+	s.onFailure(endpointKey(endpoints[1]))
+	if got := s.pick(endpoints); got == nil {
+		t.Fatalf("pick() returned nil when all endpoints are blacklisted")
+	}
+	h := s.getHealth(endpointKey(endpoints[0]))
+	if d := time.Until(h.blacklistUntil); d <= 2*endpointBlacklistMin || d > 4*endpointBlacklistMin {
+		t.Errorf("blacklist time after 3 failures is %v, want about %v", d, 4*endpointBlacklistMin)
+	}
+}
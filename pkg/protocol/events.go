@@ -0,0 +1,160 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"errors"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+var (
+	// ErrSessionClosedByPeer is the reason of a session closed by the peer.
+	ErrSessionClosedByPeer = errors.New("session is closed by the peer")
+
+	// ErrSessionQuotaExhausted is the reason of a session closed by the
+	// proxy server because the user has exhausted the quota.
+	ErrSessionQuotaExhausted = errors.New("session is closed because the user has exhausted the quota")
+)
+
+// EventListener receives the events of a client mux. Any of the
+// callbacks can be nil. The callbacks are called from the goroutines
+// of the mux, so they must return quickly.
+type EventListener struct {
+	// OnUnderlayConnected is called after a connection to the proxy
+	// server is established.
+	OnUnderlayConnected func(UnderlayEvent)
+
+	// OnUnderlayClosed is called after a connection to the proxy
+	// server is closed.
+	OnUnderlayClosed func(UnderlayEvent)
+
+	// OnHandshakeFailure is called when the client is unable to
+	// connect to the proxy server, or the proxy server doesn't
+	// accept the session.
+	OnHandshakeFailure func(UnderlayEvent)
+
+	// OnSessionClosed is called after a session is closed.
+	OnSessionClosed func(SessionEvent)
+}
+
+// UnderlayEvent describes a connection to the proxy server.
+type UnderlayEvent struct {
+	// Server is the IP address and port of the proxy server.
+	Server string
+
+	// Transport is one of "TCP", "UDP", "TLS" and "WebSocket".
+	Transport string
+
+	// Reason is the error that closes the connection or fails the
+	// handshake. It is nil if the connection is connected, or closed
+	// by the client.
+	Reason error
+}
+
+// SessionEvent describes a session of the client.
+type SessionEvent struct {
+	// SessionID is the ID of the session.
+	SessionID uint32
+
+	// Server is the IP address and port of the proxy server.
+	Server string
+
+	// Transport is one of "TCP", "UDP", "TLS" and "WebSocket".
+	Transport string
+
+	// Reason is the error that closes the session. It is nil if the
+	// session is closed by the application.
+	Reason error
+}
+
+// SetClientEventListener registers the listener of the events of
+// the client mux. It panics if the mux is already started.
+func (m *Mux) SetClientEventListener(listener *EventListener) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set event listener in server mux")
+	}
+	if m.used {
+		panic("Can't set event listener after mux is used")
+	}
+	m.events = listener
+	if listener != nil {
+		log.Infof("Mux event listener is set")
+	}
+	return m
+}
+
+// underlayEvent returns the event of the underlay.
+func (m *Mux) underlayEvent(underlay Underlay, reason error) UnderlayEvent {
+	return UnderlayEvent{
+		Server:    underlay.RemoteAddr().String(),
+		Transport: underlayTransportName(underlay),
+		Reason:    reason,
+	}
+}
+
+func (m *Mux) notifyUnderlayConnected(event UnderlayEvent) {
+	if m.events != nil && m.events.OnUnderlayConnected != nil {
+		m.events.OnUnderlayConnected(event)
+	}
+}
+
+func (m *Mux) notifyUnderlayClosed(event UnderlayEvent) {
+	if m.events != nil && m.events.OnUnderlayClosed != nil {
+		m.events.OnUnderlayClosed(event)
+	}
+}
+
+func (m *Mux) notifyHandshakeFailure(event UnderlayEvent) {
+	if m.events != nil && m.events.OnHandshakeFailure != nil {
+		m.events.OnHandshakeFailure(event)
+	}
+}
+
+// onDialFailure reports the failure to connect to the endpoint
+// to the endpoint selector and the event listener. The port is 0
+// unless the endpoint is a port range.
+func (m *Mux) onDialFailure(p UnderlayProperties, port int, err error) {
+	if port != 0 {
+		m.selector.onPortFailure(endpointKey(p), port)
+	} else {
+		m.selector.onFailure(endpointKey(p))
+	}
+	m.notifyHandshakeFailure(UnderlayEvent{
+		Server:    p.RemoteAddr().String(),
+		Transport: m.transportName(p.TransportProtocol()),
+		Reason:    err,
+	})
+}
+
+// trackSessionClose reports the close of a client session
+// to the event listener.
+func (m *Mux) trackSessionClose(session *Session, underlay Underlay) {
+	if m.events == nil || m.events.OnSessionClosed == nil {
+		return
+	}
+	event := m.underlayEvent(underlay, nil)
+	session.onClose = func(reason error) {
+		m.events.OnSessionClosed(SessionEvent{
+			SessionID: session.id,
+			Server:    event.Server,
+			Transport: event.Transport,
+			Reason:    reason,
+		})
+	}
+}
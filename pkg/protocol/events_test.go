@@ -0,0 +1,126 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestEventListener(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	deadPort, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	defer serverMux.Close()
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	connected := make(chan UnderlayEvent, 4)
+	closed := make(chan UnderlayEvent, 4)
+	failed := make(chan UnderlayEvent, 4)
+	sessionClosed := make(chan SessionEvent, 4)
+	listener := &EventListener{
+		OnUnderlayConnected: func(e UnderlayEvent) { connected <- e },
+		OnUnderlayClosed:    func(e UnderlayEvent) { closed <- e },
+		OnHandshakeFailure:  func(e UnderlayEvent) { failed <- e },
+		OnSessionClosed:     func(e SessionEvent) { sessionClosed <- e },
+	}
+	newClientMux := func(port int) *Mux {
+		return NewMux(true).
+			SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+			SetClientEventListener(listener).
+			SetEndpoints([]UnderlayProperties{
+				NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}),
+			})
+	}
+	wait := func(name string, ch <-chan UnderlayEvent) UnderlayEvent {
+		select {
+		case e := <-ch:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s event is not received", name)
+		}
+		return UnderlayEvent{}
+	}
+
+	clientMux := newClientMux(port)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	e := wait("OnUnderlayConnected", connected)
+	if e.Server != serverProperties.LocalAddr().String() || e.Transport != "TCP" || e.Reason != nil {
+		t.Errorf("got connected event %+v", e)
+	}
+	if _, err := conn.Write([]byte("abc")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 3)); err != nil {
+		t.Fatalf("ReadFull() failed: %v", err)
+	}
+	conn.Close()
+	select {
+	case e := <-sessionClosed:
+		if e.Server != serverProperties.LocalAddr().String() || e.Reason != nil {
+			t.Errorf("got session closed event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("OnSessionClosed event is not received")
+	}
+	clientMux.Close()
+	wait("OnUnderlayClosed", closed)
+
+	deadMux := newClientMux(deadPort)
+	defer deadMux.Close()
+	if _, err := deadMux.DialContext(ctx); err == nil {
+		t.Fatalf("DialContext() to a dead port succeeded")
+	}
+	if e := wait("OnHandshakeFailure", failed); e.Reason == nil {
+		t.Errorf("handshake failure event has no reason")
+	}
+}
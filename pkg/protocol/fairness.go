@@ -0,0 +1,124 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+// Sessions sharing an underlay compete for it. A bulk session, like
+// a large download, can always fill the link, while an interactive
+// session, like SSH, sends a few bytes at a time and waits for the reply.
+// Fairness keeps the latency of the interactive session bounded
+// while the bulk session saturates the link:
+//
+//  1. The egress scheduler serves the sessions waiting to write
+//     in weighted round-robin order, so a small segment waits for
+//     at most one round of other sessions.
+//  2. The number of bytes written to a TCP connection but not sent yet
+//     is limited. Otherwise the kernel send buffer, which can hold
+//     megabytes of bulk data, is in front of every new segment,
+//     and the egress scheduler has nothing to schedule.
+
+const (
+	// DefaultMaxUnsentBytes is the default number of bytes that can be
+	// written to a TCP underlay but not sent yet.
+	DefaultMaxUnsentBytes = 128 * 1024
+
+	// MinMaxUnsentBytes is the minimum value of MaxUnsentBytes.
+	MinMaxUnsentBytes = 16 * 1024
+
+	// MaxMaxUnsentBytes is the maximum value of MaxUnsentBytes.
+	MaxMaxUnsentBytes = 16 * 1024 * 1024
+
+	// MaxPriorityWeight is the maximum number of segments a session
+	// can send in each round of egress scheduling.
+	MaxPriorityWeight = 64
+)
+
+// Fairness controls how sessions share an underlay.
+// The zero value uses the default settings.
+type Fairness struct {
+	// MaxUnsentBytes is the number of bytes that can be written to
+	// a TCP underlay but not sent yet. 0 means DefaultMaxUnsentBytes.
+	MaxUnsentBytes int
+
+	// HighPriorityWeight is the number of segments a high priority
+	// session sends in each round. 0 means the default weight.
+	HighPriorityWeight int
+
+	// LowPriorityWeight is the number of segments a low priority
+	// session sends in each round. 0 means the default weight.
+	LowPriorityWeight int
+}
+
+// maxUnsentBytes returns the limit of unsent bytes of TCP underlays.
+func (f Fairness) maxUnsentBytes() int {
+	if f.MaxUnsentBytes <= 0 {
+		return DefaultMaxUnsentBytes
+	}
+	return f.MaxUnsentBytes
+}
+
+// weight returns the number of segments a session with the priority
+// can send in each round of egress scheduling.
+func (f Fairness) weight(p SessionPriority) int {
+	switch {
+	case p == SessionPriorityHigh && f.HighPriorityWeight > 0:
+		return f.HighPriorityWeight
+	case p == SessionPriorityLow && f.LowPriorityWeight > 0:
+		return f.LowPriorityWeight
+	default:
+		return p.weight()
+	}
+}
+
+// fairnessUnderlay is an underlay that applies the fairness settings.
+type fairnessUnderlay interface {
+	setFairness(f Fairness)
+}
+
+var (
+	_ fairnessUnderlay = &StreamUnderlay{}
+	_ fairnessUnderlay = &PacketUnderlay{}
+)
+
+// setFairness sets the weights of egress scheduling.
+func (b *baseUnderlay) setFairness(f Fairness) {
+	b.fairness = f
+}
+
+// setFairness sets the weights of egress scheduling, and limits the
+// unsent bytes of the TCP connection. If the socket option is not
+// supported, the kernel send buffer is not limited.
+func (t *StreamUnderlay) setFairness(f Fairness) {
+	t.baseUnderlay.setFairness(f)
+	conn := t.conn
+	if tlsConn, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = tlsConn.NetConn()
+	}
+	sysConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return
+	}
+	if err := sockopts.ApplyNotSentLowat(sysConn, f.maxUnsentBytes()); err != nil {
+		log.Debugf("Unable to limit unsent bytes of %v: %v", t, err)
+	}
+}
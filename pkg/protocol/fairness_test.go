@@ -0,0 +1,241 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+// throttledDialer creates TCP connections that send data at a fixed
+// rate, like a slow link.
+type throttledDialer struct {
+	apicommon.NetDialer
+	bytesPerSecond int
+}
+
+func (d *throttledDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.NetDialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &throttledConn{Conn: conn, bytesPerSecond: d.bytesPerSecond}, nil
+}
+
+type throttledConn struct {
+	net.Conn
+	bytesPerSecond int
+}
+
+func (c *throttledConn) Write(b []byte) (int, error) {
+	time.Sleep(time.Duration(len(b)) * time.Second / time.Duration(c.bytesPerSecond))
+	return c.Conn.Write(b)
+}
+
+// echo writes the payload to the connection, and verifies the response
+// from the rot13 server.
+func echo(conn net.Conn, payload []byte) error {
+	if _, err := conn.Write(payload); err != nil {
+		return fmt.Errorf("Write() failed: %w", err)
+	}
+	resp := make([]byte, len(payload))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return fmt.Errorf("io.ReadFull() failed: %w", err)
+	}
+	rot13, err := testtool.TestHelperRot13(resp)
+	if err != nil {
+		return fmt.Errorf("TestHelperRot13() failed: %w", err)
+	}
+	if !bytes.Equal(payload, rot13) {
+		return fmt.Errorf("received unexpected response")
+	}
+	return nil
+}
+
+func TestFairnessWeight(t *testing.T) {
+	testCases := []struct {
+		fairness Fairness
+		priority SessionPriority
+		want     int
+	}{
+		{Fairness{}, SessionPriorityHigh, 4},
+		{Fairness{}, SessionPriorityNormal, 2},
+		{Fairness{}, SessionPriorityLow, 1},
+		{Fairness{HighPriorityWeight: 16}, SessionPriorityHigh, 16},
+		{Fairness{HighPriorityWeight: 16}, SessionPriorityNormal, 2},
+		{Fairness{LowPriorityWeight: 2}, SessionPriorityLow, 2},
+	}
+	for _, tc := range testCases {
+		if got := tc.fairness.weight(tc.priority); got != tc.want {
+			t.Errorf("%+v weight(%v) = %d, want %d", tc.fairness, tc.priority, got, tc.want)
+		}
+	}
+	if got := (Fairness{}).maxUnsentBytes(); got != DefaultMaxUnsentBytes {
+		t.Errorf("maxUnsentBytes() = %d, want %d", got, DefaultMaxUnsentBytes)
+	}
+}
+
+// TestSessionFairnessMixedWorkload verifies that the latency of an
+// interactive session stays bounded while bulk sessions saturate
+// the same underlay.
+func TestSessionFairnessMixedWorkload(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("INFO")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetFairness(Fairness{HighPriorityWeight: 8}).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	// The link from the client to the server is 4 MB/s.
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, serverAddr)
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(1).
+		SetClientUnderlayAffinity(time.Minute).
+		SetClientDialer(&throttledDialer{bytesPerSecond: 4 * 1024 * 1024}).
+		SetFairness(Fairness{HighPriorityWeight: 8}).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+
+	// All the sessions use the same underlay.
+	const affinityKey = "fairness.example.com:443"
+	lowCtx := WithSessionPriority(context.Background(), SessionPriorityLow)
+	highCtx := WithSessionPriority(context.Background(), SessionPriorityHigh)
+	var bulks []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := clientMux.DialContextWithAffinity(lowCtx, affinityKey)
+		if err != nil {
+			t.Fatalf("DialContextWithAffinity() failed: %v", err)
+		}
+		bulks = append(bulks, conn)
+	}
+	interactive, err := clientMux.DialContextWithAffinity(highCtx, affinityKey)
+	if err != nil {
+		t.Fatalf("DialContextWithAffinity() failed: %v", err)
+	}
+	for _, conn := range bulks {
+		if conn.(*Session).conn != interactive.(*Session).conn {
+			t.Fatalf("sessions are not put to the same underlay")
+		}
+	}
+
+	// Open the sessions with a round trip.
+	for _, conn := range append(bulks, interactive) {
+		if err := echo(conn, testtool.TestHelperGenRot13Input(64)); err != nil {
+			t.Fatalf("echo() failed: %v", err)
+		}
+	}
+
+	// Saturate the link with bulk sessions.
+	var received atomic.Int64
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for _, conn := range bulks {
+		wg.Add(2)
+		go func(conn net.Conn) {
+			defer wg.Done()
+			payload := testtool.TestHelperGenRot13Input(32 * 1024)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := conn.Write(payload); err != nil {
+					return
+				}
+			}
+		}(conn)
+		go func(conn net.Conn) {
+			defer wg.Done()
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := conn.Read(buf)
+				received.Add(int64(n))
+				if err != nil {
+					return
+				}
+			}
+		}(conn)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	// Measure the round trip time of the interactive session.
+	before := received.Load()
+	var rtts []time.Duration
+	for i := 0; i < 40; i++ {
+		start := time.Now()
+		if err := echo(interactive, testtool.TestHelperGenRot13Input(64)); err != nil {
+			t.Fatalf("echo() failed: %v", err)
+		}
+		rtts = append(rtts, time.Since(start))
+		time.Sleep(5 * time.Millisecond)
+	}
+	if received.Load() == before {
+		t.Errorf("bulk sessions didn't transfer data during the measurement")
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	p95 := rtts[len(rtts)*95/100]
+	t.Logf("interactive session round trip time: p50 %v, p95 %v, max %v", rtts[len(rtts)/2], p95, rtts[len(rtts)-1])
+	if p95 > 250*time.Millisecond {
+		t.Errorf("p95 round trip time of the interactive session is %v, want at most 250ms", p95)
+	}
+
+	close(done)
+	interactive.Close()
+	for _, conn := range bulks {
+		conn.Close()
+	}
+	if err := clientMux.Close(); err != nil {
+		t.Errorf("Close client mux failed: %v", err)
+	}
+	wg.Wait()
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
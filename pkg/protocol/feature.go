@@ -0,0 +1,166 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/version"
+)
+
+// ServerFeatures is a bitmap of the protocol features supported by
+// the proxy server. The server advertises it in the open session response,
+// which is authenticated by the cipher block of the user.
+type ServerFeatures uint32
+
+const (
+	// FeatureUDPAssociate means the server supports socks5 UDP associate.
+	FeatureUDPAssociate ServerFeatures = 1 << iota
+
+	// FeatureFEC means the server decodes forward error correction
+	// parity segments.
+	FeatureFEC
+
+	// FeaturePathMTUDiscovery means the server responds to path MTU probes.
+	FeaturePathMTUDiscovery
+
+	// FeatureSessionResumption means the server issues tokens to resume
+	// sessions from another client address.
+	FeatureSessionResumption
+
+	// FeatureDatagram means the server exchanges unreliable datagrams
+	// in a session.
+	FeatureDatagram
+
+	// FeatureHybridKeyExchange means the server supports X25519 and
+	// ML-KEM-768 hybrid key exchange of sessions.
+	FeatureHybridKeyExchange
+
+	// FeatureRekey means the server rotates the keys of a live
+	// TCP connection when the client asks for it.
+	FeatureRekey
+)
+
+// Client features are sent in the open session request.
+const (
+	// clientFeatureCredentialPush means the client accepts the next
+	// password pushed by the server.
+	clientFeatureCredentialPush uint8 = 1 << iota
+
+	// clientFeatureFEC means the client decodes forward error correction
+	// parity segments sent by the server.
+	clientFeatureFEC
+)
+
+// AllServerFeatures contains all the features known by this binary.
+// The server running this binary supports all of them, except hybrid
+// key exchange if the binary is built without ML-KEM.
+const AllServerFeatures = FeatureUDPAssociate | FeatureFEC | FeaturePathMTUDiscovery | FeatureSessionResumption | FeatureDatagram | FeatureHybridKeyExchange | FeatureRekey
+
+var serverFeatureNames = []struct {
+	feature ServerFeatures
+	name    string
+}{
+	{FeatureUDPAssociate, "UDP associate"},
+	{FeatureFEC, "forward error correction"},
+	{FeaturePathMTUDiscovery, "path MTU discovery"},
+	{FeatureSessionResumption, "session resumption"},
+	{FeatureDatagram, "datagram"},
+	{FeatureHybridKeyExchange, "hybrid key exchange"},
+	{FeatureRekey, "rekey"},
+}
+
+// Has returns true if all the given features are supported.
+func (f ServerFeatures) Has(features ServerFeatures) bool {
+	return f&features == features
+}
+
+// Names returns the names of known features in the bitmap.
+func (f ServerFeatures) Names() []string {
+	names := make([]string, 0)
+	for _, item := range serverFeatureNames {
+		if f.Has(item.feature) {
+			names = append(names, item.name)
+		}
+	}
+	return names
+}
+
+func (f ServerFeatures) String() string {
+	return "[" + strings.Join(f.Names(), ", ") + "]"
+}
+
+// ServerInfo describes the proxy server learned from the latest
+// open session response.
+type ServerInfo struct {
+	// Version is the version of the proxy server.
+	// It is empty if the server doesn't advertise the version,
+	// which means the server is older than the features bitmap.
+	Version string
+
+	// Features are supported by the proxy server.
+	Features ServerFeatures
+}
+
+// MissingFeatures returns the features known by this binary
+// but not supported by the proxy server.
+func (i ServerInfo) MissingFeatures() ServerFeatures {
+	return AllServerFeatures &^ i.Features
+}
+
+// clientServerInfo is the information of the proxy server
+// received by the client. It is nil before any session is opened.
+var clientServerInfo atomic.Pointer[ServerInfo]
+
+// localServerVersion returns the version of this binary in the wire format.
+func localServerVersion() [3]uint8 {
+	v, err := version.Parse(version.AppVersion)
+	if err != nil {
+		return [3]uint8{}
+	}
+	return [3]uint8{uint8(v.Major), uint8(v.Minor), uint8(v.Patch)}
+}
+
+// advertiseServerInfo adds the server version and features to the
+// open session response.
+func advertiseServerInfo(ss *sessionStruct) {
+	ss.serverVersion = localServerVersion()
+	features := AllServerFeatures
+	if !cipher.HybridKeyExchangeSupported {
+		features &^= FeatureHybridKeyExchange
+	}
+	ss.serverFeatures = uint32(features)
+}
+
+// takeServerInfo records the server version and features from the
+// open session response.
+func (s *Session) takeServerInfo(resp *segment) {
+	ss, ok := resp.metadata.(*sessionStruct)
+	if !ok || !s.isClient {
+		return
+	}
+	info := &ServerInfo{Features: ServerFeatures(ss.serverFeatures)}
+	if ss.serverVersion != [3]uint8{} {
+		info.Version = version.Version{
+			Major: int(ss.serverVersion[0]),
+			Minor: int(ss.serverVersion[1]),
+			Patch: int(ss.serverVersion[2]),
+		}.String()
+	}
+	clientServerInfo.Store(info)
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/version"
+)
+
+func TestServerFeatures(t *testing.T) {
+	f := FeatureUDPAssociate | FeatureDatagram
+	if !f.Has(FeatureDatagram) || f.Has(FeatureFEC) || f.Has(FeatureUDPAssociate|FeatureFEC) {
+		t.Errorf("Has() returned unexpected result for %v", f)
+	}
+	if got, want := f.Names(), []string{"UDP associate", "datagram"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	info := ServerInfo{Features: f}
+	if got, want := info.MissingFeatures(), FeatureFEC|FeaturePathMTUDiscovery|FeatureSessionResumption|FeatureHybridKeyExchange|FeatureRekey; got != want {
+		t.Errorf("MissingFeatures() = %v, want %v", got, want)
+	}
+	if got := (ServerInfo{Features: AllServerFeatures}).MissingFeatures(); got != 0 {
+		t.Errorf("MissingFeatures() = %v, want no missing feature", got)
+	}
+}
+
+func TestTakeServerInfo(t *testing.T) {
+	defer clientServerInfo.Store(nil)
+	s := &Session{isClient: true}
+
+	// The server advertises the version and features.
+	ss := &sessionStruct{baseStruct: baseStruct{protocol: uint8(openSessionResponse)}}
+	advertiseServerInfo(ss)
+	ss2 := &sessionStruct{}
+	if err := ss2.Unmarshal(ss.Marshal()); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	s.takeServerInfo(&segment{metadata: ss2})
+	info := clientServerInfo.Load()
+	if info == nil || info.Version != version.AppVersion || info.Features != AllServerFeatures {
+		t.Errorf("got server info %+v, want version %s and features %v", info, version.AppVersion, AllServerFeatures)
+	}
+
+	// An old server doesn't advertise anything.
+	s.takeServerInfo(&segment{metadata: &sessionStruct{baseStruct: baseStruct{protocol: uint8(openSessionResponse)}}})
+	info = clientServerInfo.Load()
+	if info == nil || info.Version != "" || info.Features != 0 {
+		t.Errorf("got server info %+v, want empty version and no feature", info)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// Forward error correction (FEC) protects a group of consecutive data
+// segments with one parity segment. The parity segment is the XOR of all
+// the data segments in the group, so the receiver is able to recover any
+// single lost data segment in the group without retransmission.
+//
+// A parity segment reuses dataAckStruct with the following meaning:
+//   - seq: sequence number of the first data segment in the group
+//   - unAckSeq: number of data segments in the group
+//   - windowSize: XOR of payload length of data segments
+//   - fragment: XOR of fragment number of data segments
+//   - payloadLen: length of the longest payload in the group
+//
+// The payload of the parity segment is the XOR of all the data payloads,
+// where shorter payloads are padded with zeros.
+//
+// A peer that doesn't know parity segments closes the underlay when it
+// receives one, so parity segments are only sent when the peer supports
+// FEC. The client learns it from FeatureFEC in the open session response,
+// and the server learns it from clientFeatureFEC in the open session
+// request.
+
+const (
+	// MaxFECGroupSize is the maximum number of data segments
+	// protected by a parity segment.
+	MaxFECGroupSize = 64
+
+	// fecDecoderWindow is the number of received data segments
+	// kept by the decoder to recover lost segments.
+	fecDecoderWindow = 4 * MaxFECGroupSize
+
+	// fecMaxPendingParity is the maximum number of parity segments
+	// waiting for more data segments.
+	fecMaxPendingParity = 16
+)
+
+var (
+	FECParitySent          = metrics.RegisterMetric("FEC", "ParitySent", metrics.COUNTER)
+	FECParityReceived      = metrics.RegisterMetric("FEC", "ParityReceived", metrics.COUNTER)
+	FECRecoveredData       = metrics.RegisterMetric("FEC", "RecoveredData", metrics.COUNTER)
+	FECUnrecoverableGroups = metrics.RegisterMetric("FEC", "UnrecoverableGroups", metrics.COUNTER)
+)
+
+// fecEncoder computes the parity of consecutive data segments.
+type fecEncoder struct {
+	groupSize int
+	protocol  protocolType // protocol of parity segment
+	sessionID uint32
+
+	firstSeq   uint32
+	count      int
+	fragment   uint8
+	lenXOR     uint16
+	payloadLen uint16
+	parity     []byte
+}
+
+func newFECEncoder(groupSize int, protocol protocolType, sessionID uint32) *fecEncoder {
+	return &fecEncoder{
+		groupSize: groupSize,
+		protocol:  protocol,
+		sessionID: sessionID,
+	}
+}
+
+// Add adds a data segment to the current group. It returns the parity
+// segment if the group is full.
+func (e *fecEncoder) Add(seg *segment) *segment {
+	das, ok := seg.metadata.(*dataAckStruct)
+	if !ok {
+		return nil
+	}
+	if e.count > 0 && das.seq != e.firstSeq+uint32(e.count) {
+		// Not consecutive. Discard the current group.
+		e.reset()
+	}
+	if e.count == 0 {
+		e.firstSeq = das.seq
+	}
+	e.count++
+	e.fragment ^= das.fragment
+	e.lenXOR ^= uint16(len(seg.payload))
+	if len(seg.payload) > len(e.parity) {
+		e.parity = append(e.parity, make([]byte, len(seg.payload)-len(e.parity))...)
+	}
+	xorBytes(e.parity, seg.payload)
+	if len(seg.payload) > int(e.payloadLen) {
+		e.payloadLen = uint16(len(seg.payload))
+	}
+	if e.count >= e.groupSize {
+		return e.Flush()
+	}
+	return nil
+}
+
+// Flush returns the parity segment of the current group,
+// even if the group is not full. It returns nil if the group is empty.
+func (e *fecEncoder) Flush() *segment {
+	if e.count == 0 {
+		return nil
+	}
+	seg := &segment{
+		metadata: &dataAckStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(e.protocol),
+			},
+			sessionID:  e.sessionID,
+			seq:        e.firstSeq,
+			unAckSeq:   uint32(e.count),
+			windowSize: e.lenXOR,
+			fragment:   e.fragment,
+			payloadLen: e.payloadLen,
+		},
+		payload:   e.parity,
+		transport: common.PacketTransport,
+	}
+	e.parity = nil
+	e.reset()
+	return seg
+}
+
+func (e *fecEncoder) reset() {
+	e.count = 0
+	e.fragment = 0
+	e.lenXOR = 0
+	e.payloadLen = 0
+	e.parity = e.parity[:0]
+}
+
+// fecDecoder recovers lost data segments from parity segments.
+type fecDecoder struct {
+	received map[uint32]*segment
+	pending  []*segment
+}
+
+func newFECDecoder() *fecDecoder {
+	return &fecDecoder{
+		received: make(map[uint32]*segment),
+	}
+}
+
+// AddData records a received data segment. It returns the data segments
+// recovered with pending parity segments.
+func (d *fecDecoder) AddData(seg *segment, nextRecv uint32) []*segment {
+	seq, err := seg.Seq()
+	if err != nil {
+		return nil
+	}
+	d.received[seq] = seg
+	d.prune(nextRecv)
+	if len(d.pending) == 0 {
+		return nil
+	}
+	var recovered []*segment
+	remaining := d.pending[:0]
+	for _, parity := range d.pending {
+		res, done := d.tryRecover(parity, nextRecv)
+		if res != nil {
+			recovered = append(recovered, res)
+		}
+		if !done {
+			remaining = append(remaining, parity)
+		}
+	}
+	d.pending = remaining
+	return recovered
+}
+
+// AddParity processes a parity segment. It returns the recovered
+// data segment, or nil.
+func (d *fecDecoder) AddParity(parity *segment, nextRecv uint32) *segment {
+	res, done := d.tryRecover(parity, nextRecv)
+	if !done {
+		if len(d.pending) >= fecMaxPendingParity {
+			d.pending = d.pending[1:]
+			FECUnrecoverableGroups.Add(1)
+		}
+		d.pending = append(d.pending, parity)
+	}
+	return res
+}
+
+// tryRecover returns the recovered data segment. done is true
+// if the parity segment is no longer needed.
+func (d *fecDecoder) tryRecover(parity *segment, nextRecv uint32) (recovered *segment, done bool) {
+	das := parity.metadata.(*dataAckStruct)
+	first := das.seq
+	count := das.unAckSeq
+	if count == 0 || count > MaxFECGroupSize {
+		return nil, true
+	}
+	if first+count <= nextRecv {
+		// All the data segments are already received.
+		return nil, true
+	}
+	var missing []uint32
+	var lastReceived *dataAckStruct
+	for seq := first; seq < first+count; seq++ {
+		seg, found := d.received[seq]
+		if !found {
+			if seq < nextRecv {
+				// The data segment is received but removed from the window.
+				// It is not possible to recover.
+				return nil, true
+			}
+			missing = append(missing, seq)
+			if len(missing) > 1 {
+				return nil, false
+			}
+			continue
+		}
+		lastReceived = seg.metadata.(*dataAckStruct)
+	}
+	if len(missing) == 0 {
+		return nil, true
+	}
+	if lastReceived == nil {
+		// Group size is 1. The parity is a copy of the data segment.
+		lastReceived = &dataAckStruct{}
+	}
+
+	// Recover the missing data segment.
+	payload := make([]byte, len(parity.payload))
+	copy(payload, parity.payload)
+	fragment := das.fragment
+	payloadLen := das.windowSize
+	for seq := first; seq < first+count; seq++ {
+		if seq == missing[0] {
+			continue
+		}
+		seg := d.received[seq]
+		xorBytes(payload, seg.payload)
+		fragment ^= seg.metadata.(*dataAckStruct).fragment
+		payloadLen ^= uint16(len(seg.payload))
+	}
+	if int(payloadLen) > len(payload) {
+		FECUnrecoverableGroups.Add(1)
+		return nil, true
+	}
+	var dataProtocol protocolType
+	if protocolType(das.protocol) == fecClientToServer {
+		dataProtocol = dataClientToServer
+	} else {
+		dataProtocol = dataServerToClient
+	}
+	recovered = &segment{
+		metadata: &dataAckStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(dataProtocol),
+			},
+			sessionID:  das.sessionID,
+			seq:        missing[0],
+			unAckSeq:   lastReceived.unAckSeq,
+			windowSize: lastReceived.windowSize,
+			fragment:   fragment,
+			payloadLen: payloadLen,
+		},
+		payload:   payload[:payloadLen],
+		transport: common.PacketTransport,
+	}
+	d.received[missing[0]] = recovered
+	FECRecoveredData.Add(1)
+	return recovered, true
+}
+
+// prune removes data segments that are out of the window.
+func (d *fecDecoder) prune(nextRecv uint32) {
+	if len(d.received) <= fecDecoderWindow {
+		return
+	}
+	for seq := range d.received {
+		if seq+fecDecoderWindow < nextRecv {
+			delete(d.received, seq)
+		}
+	}
+}
+
+// xorBytes sets dst[i] ^= src[i]. dst must not be shorter than src.
+func xorBytes(dst, src []byte) {
+	for i, v := range src {
+		dst[i] ^= v
+	}
+}
+
+// maybeEnableFEC allows the session to send parity segments,
+// if FEC is turned on and the peer supports it.
+func (s *Session) maybeEnableFEC(seg *segment) {
+	if s.fecGroupSize <= 0 || s.fecEnabled.Load() || s.conn.TransportProtocol() != common.PacketTransport {
+		return
+	}
+	ss, ok := seg.metadata.(*sessionStruct)
+	if !ok {
+		return
+	}
+	if s.isClient && !ServerFeatures(ss.serverFeatures).Has(FeatureFEC) {
+		return
+	}
+	if !s.isClient && ss.clientFeatures&clientFeatureFEC == 0 {
+		return
+	}
+	s.fecEnabled.Store(true)
+	log.Debugf("%v enabled forward error correction", s)
+}
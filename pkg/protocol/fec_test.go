@@ -0,0 +1,258 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func newTestDataSegment(seq uint32, fragment uint8, payload []byte) *segment {
+	return &segment{
+		metadata: &dataAckStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(dataClientToServer),
+			},
+			sessionID:  1,
+			seq:        seq,
+			windowSize: 256,
+			fragment:   fragment,
+			payloadLen: uint16(len(payload)),
+		},
+		payload:   payload,
+		transport: common.PacketTransport,
+	}
+}
+
+func TestFECRecoverOneLostSegment(t *testing.T) {
+	groupSize := 4
+	encoder := newFECEncoder(groupSize, fecClientToServer, 1)
+	var data []*segment
+	var parity *segment
+	for i := 0; i < groupSize; i++ {
+		seg := newTestDataSegment(uint32(10+i), uint8(groupSize-1-i), testtool.TestHelperGenRot13Input(100*(i+1)))
+		data = append(data, seg)
+		if p := encoder.Add(seg); p != nil {
+			parity = p
+		}
+	}
+	if parity == nil {
+		t.Fatalf("parity segment is not generated after %d data segments", groupSize)
+	}
+
+	for lost := 0; lost < groupSize; lost++ {
+		decoder := newFECDecoder()
+		nextRecv := uint32(10)
+		for i, seg := range data {
+			if i == lost {
+				continue
+			}
+			if uint32(i)+10 == nextRecv {
+				nextRecv++
+			}
+			decoder.AddData(seg, nextRecv)
+		}
+		recovered := decoder.AddParity(parity, nextRecv)
+		if recovered == nil {
+			t.Fatalf("segment %d is not recovered", lost)
+		}
+		want := data[lost]
+		if !bytes.Equal(recovered.payload, want.payload) {
+			t.Errorf("recovered payload of segment %d is not correct", lost)
+		}
+		seq, _ := recovered.Seq()
+		if seq != uint32(10+lost) {
+			t.Errorf("recovered seq = %d, want %d", seq, 10+lost)
+		}
+		if recovered.Fragment() != want.Fragment() {
+			t.Errorf("recovered fragment = %d, want %d", recovered.Fragment(), want.Fragment())
+		}
+		if recovered.Protocol() != dataClientToServer {
+			t.Errorf("recovered protocol = %v, want %v", recovered.Protocol(), dataClientToServer)
+		}
+	}
+}
+
+func TestFECParityBeforeData(t *testing.T) {
+	encoder := newFECEncoder(3, fecServerToClient, 1)
+	var data []*segment
+	for i := 0; i < 2; i++ {
+		seg := newTestDataSegment(uint32(i), 0, testtool.TestHelperGenRot13Input(50))
+		data = append(data, seg)
+		if encoder.Add(seg) != nil {
+			t.Fatalf("parity segment is generated before the group is full")
+		}
+	}
+	parity := encoder.Flush()
+	if parity == nil {
+		t.Fatalf("Flush() doesn't generate parity segment")
+	}
+	if encoder.Flush() != nil {
+		t.Errorf("Flush() generates parity segment from empty group")
+	}
+
+	// Parity segment arrives before the data segment.
+	decoder := newFECDecoder()
+	if decoder.AddParity(parity, 0) != nil {
+		t.Fatalf("AddParity() recovered segment without data segments")
+	}
+	recovered := decoder.AddData(data[1], 0)
+	if len(recovered) != 1 {
+		t.Fatalf("AddData() recovered %d segments, want 1", len(recovered))
+	}
+	if !bytes.Equal(recovered[0].payload, data[0].payload) {
+		t.Errorf("recovered payload is not correct")
+	}
+	if recovered[0].Protocol() != dataServerToClient {
+		t.Errorf("recovered protocol = %v, want %v", recovered[0].Protocol(), dataServerToClient)
+	}
+}
+
+func TestFECTwoLostSegments(t *testing.T) {
+	encoder := newFECEncoder(4, fecClientToServer, 1)
+	var parity *segment
+	var data []*segment
+	for i := 0; i < 4; i++ {
+		seg := newTestDataSegment(uint32(i), 0, testtool.TestHelperGenRot13Input(10))
+		data = append(data, seg)
+		parity = encoder.Add(seg)
+	}
+	decoder := newFECDecoder()
+	decoder.AddData(data[0], 1)
+	decoder.AddData(data[3], 1)
+	if decoder.AddParity(parity, 1) != nil {
+		t.Errorf("AddParity() recovered segment when two segments are lost")
+	}
+}
+
+func TestUDPUnderlayWithFEC(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetFECGroupSize(4)
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetFECGroupSize(4)
+	paritySent := FECParitySent.Load()
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	if FECParitySent.Load() <= paritySent {
+		t.Errorf("no parity segment is sent")
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestFECOnlySentToPeerWithFeature(t *testing.T) {
+	block, err := cipher.BlockCipherFromPassword(cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang")), true)
+	if err != nil {
+		t.Fatalf("cipher.BlockCipherFromPassword() failed: %v", err)
+	}
+	peer, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.ListenPacket() failed: %v", err)
+	}
+	defer peer.Close()
+
+	testCases := []struct {
+		name           string
+		serverFeatures ServerFeatures
+		wantParity     bool
+	}{
+		{"old server", 0, false},
+		{"server without FEC", AllServerFeatures &^ FeatureFEC, false},
+		{"server with FEC", AllServerFeatures, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("net.ListenPacket() failed: %v", err)
+			}
+			defer conn.Close()
+			s := NewSession(1, true, 1400, nil)
+			s.fecGroupSize = 4
+			s.conn = &PacketUnderlay{
+				baseUnderlay: *newBaseUnderlay(true, 1400),
+				conn:         conn,
+				serverAddr:   peer.LocalAddr(),
+				block:        block,
+			}
+			s.maybeEnableFEC(&segment{
+				metadata: &sessionStruct{
+					baseStruct:     baseStruct{protocol: uint8(openSessionResponse)},
+					serverFeatures: uint32(tc.serverFeatures),
+				},
+			})
+			for i := uint32(0); i < 8; i++ {
+				s.sendQueue.Insert(newTestDataSegment(i, 0, []byte{'a'}))
+			}
+
+			paritySent := FECParitySent.Load()
+			s.runOutputOncePacket(0)
+			if got := FECParitySent.Load() > paritySent; got != tc.wantParity {
+				t.Errorf("parity sent = %v, want %v", got, tc.wantParity)
+			}
+		})
+	}
+
+	// The server only sends parity if the client has clientFeatureFEC.
+	for _, clientFeatures := range []uint8{0, clientFeatureCredentialPush, clientFeatureFEC} {
+		s := NewSession(1, false, 1400, nil)
+		s.fecGroupSize = 4
+		s.conn = &PacketUnderlay{baseUnderlay: *newBaseUnderlay(false, 1400), conn: peer}
+		s.maybeEnableFEC(&segment{
+			metadata: &sessionStruct{
+				baseStruct:     baseStruct{protocol: uint8(openSessionRequest)},
+				clientFeatures: clientFeatures,
+			},
+		})
+		if got, want := s.fecEnabled.Load(), clientFeatures&clientFeatureFEC != 0; got != want {
+			t.Errorf("with client features %#x, FEC enabled = %v, want %v", clientFeatures, got, want)
+		}
+	}
+}
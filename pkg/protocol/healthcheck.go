@@ -0,0 +1,143 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"fmt"
+	mrand "math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// EndpointCheck is the result of checking a proxy server endpoint.
+type EndpointCheck struct {
+	// Endpoint is the proxy server endpoint that is checked.
+	Endpoint UnderlayProperties
+
+	// Transport is the transport of the connection to the endpoint,
+	// which is one of "TCP", "UDP", "TLS" and "WebSocket".
+	Transport string
+
+	// Reachable is true if the probe exchanged data with the proxy server.
+	Reachable bool
+
+	// RTT is the time from sending the probe to receiving the response,
+	// which includes the session handshake.
+	RTT time.Duration
+
+	// Err is the reason that the endpoint is not reachable.
+	Err error
+}
+
+// CheckEndpoints connects to every endpoint at the same time, and runs
+// the probe with a new session of each connection. The probe must wait
+// for a response from the proxy server. Existing underlays are not used,
+// and the new underlays are closed after the check. The results are in
+// the same order as the endpoints.
+func (m *Mux) CheckEndpoints(ctx context.Context, probe func(net.Conn) error) ([]EndpointCheck, error) {
+	if !m.isClient {
+		return nil, stderror.ErrInvalidOperation
+	}
+	m.mu.Lock()
+	if len(m.password) == 0 {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("client password is not set")
+	}
+	if len(m.endpoints) == 0 {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("no server listening endpoint found")
+	}
+	m.used = true
+	endpoints := m.endpoints
+	m.mu.Unlock()
+
+	results := make([]EndpointCheck, len(endpoints))
+	var wg sync.WaitGroup
+	for i, p := range endpoints {
+		wg.Add(1)
+		go func(i int, p UnderlayProperties) {
+			defer wg.Done()
+			results[i] = m.checkEndpoint(ctx, p, probe)
+		}(i, p)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (m *Mux) checkEndpoint(ctx context.Context, p UnderlayProperties, probe func(net.Conn) error) EndpointCheck {
+	res := EndpointCheck{
+		Endpoint:  p,
+		Transport: m.transportName(p.TransportProtocol()),
+	}
+	underlay, err := m.dialEndpoint(ctx, p)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer underlay.Close()
+
+	session := NewSession(mrand.Uint32(), true, underlay.MTU(), m.users)
+	if err := session.applyOptions(m.sessionOpts); err != nil {
+		res.Err = fmt.Errorf("applyOptions() failed: %w", err)
+		return res
+	}
+	m.trackHandshake(session, underlay)
+	if err := underlay.AddSession(session, nil); err != nil {
+		res.Err = fmt.Errorf("AddSession() failed: %v", err)
+		return res
+	}
+	var conn net.Conn = session
+	if m.sessionOpts.hybridKeyExchange {
+		conn = newHybridClientConn(session, underlay.(credentialUnderlay).credential())
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	start := time.Now()
+	if err := probe(conn); err != nil {
+		res.Err = err
+		return res
+	}
+	res.RTT = time.Since(start)
+	res.Reachable = true
+	return res
+}
+
+// transportName returns the name of the transport used to connect to
+// endpoints with the transport protocol.
+func (m *Mux) transportName(transport common.TransportProtocol) string {
+	switch transport {
+	case common.StreamTransport:
+		if m.websocket != nil {
+			return "WebSocket"
+		}
+		if m.tlsConfig != nil {
+			return "TLS"
+		}
+		return "TCP"
+	case common.PacketTransport:
+		return "UDP"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// Hybrid key exchange encrypts the byte stream of a session again with
+// keys that are only known by the client and the server of this session.
+// The keys are derived from a X25519 key agreement, a ML-KEM-768 key
+// encapsulation, and the password of the user. Traffic recorded today
+// can't be decrypted even if the password is leaked, or X25519 is broken
+// by a quantum computer in the future.
+//
+// The client requests hybrid key exchange with the keyExchange field of
+// the open session request, and the server confirms it in the open session
+// response. The client sends its key share as the first bytes of the
+// session, and the server responds with its key share. After that, each
+// direction is a sequence of records. A record is 2 bytes of ciphertext
+// length followed by the ciphertext. Datagrams are not encrypted again.
+
+// keyExchangeMethod decides how the keys of a session are derived.
+type keyExchangeMethod uint8
+
+const (
+	// keyExchangePSK only uses the block cipher derived from the password.
+	keyExchangePSK keyExchangeMethod = 0
+
+	// keyExchangeHybrid adds a X25519 and ML-KEM-768 hybrid key exchange.
+	keyExchangeHybrid keyExchangeMethod = 1
+)
+
+func (m keyExchangeMethod) String() string {
+	switch m {
+	case keyExchangePSK:
+		return "PSK"
+	case keyExchangeHybrid:
+		return "hybrid"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	// maxHybridRecordSize is the maximum plaintext size of a record.
+	maxHybridRecordSize = 16 * 1024
+)
+
+var (
+	// SessionHybridKeyExchanges is the number of sessions that
+	// finished hybrid key exchange.
+	SessionHybridKeyExchanges = metrics.RegisterMetric("session", "HybridKeyExchanges", metrics.COUNTER)
+
+	// SessionHybridKeyExchangeErrors is the number of sessions that
+	// failed hybrid key exchange.
+	SessionHybridKeyExchangeErrors = metrics.RegisterMetric("session", "HybridKeyExchangeErrors", metrics.COUNTER)
+)
+
+// acceptKeyExchange returns the key exchange method used by the server
+// when the client requests the given method.
+func acceptKeyExchange(requested uint8) keyExchangeMethod {
+	if keyExchangeMethod(requested) == keyExchangeHybrid && cipher.HybridKeyExchangeSupported {
+		return keyExchangeHybrid
+	}
+	return keyExchangePSK
+}
+
+// hybridConn encrypts and decrypts the byte stream of a session
+// with the keys from hybrid key exchange. The key exchange is done
+// with the first Read or Write.
+type hybridConn struct {
+	*Session
+
+	psk []byte // client only
+
+	handshakeMu  sync.Mutex
+	handshaked   bool
+	handshakeErr error
+	send         cipher.BlockCipher
+	recv         cipher.BlockCipher
+
+	rMu           sync.Mutex
+	unread        []byte
+	readDeadline  time.Time
+	wMu           sync.Mutex
+	writeDeadline time.Time
+}
+
+// newHybridClientConn returns a client connection that uses hybrid key
+// exchange. psk is the hashed password of the user.
+func newHybridClientConn(s *Session, psk []byte) *hybridConn {
+	s.keyExchange = keyExchangeHybrid
+	return &hybridConn{Session: s, psk: psk}
+}
+
+// newHybridServerConn returns a server connection that uses hybrid key
+// exchange. The pre-shared key is found from the user of the session.
+func newHybridServerConn(s *Session) *hybridConn {
+	return &hybridConn{Session: s}
+}
+
+// Read decrypts data from the session.
+func (c *hybridConn) Read(b []byte) (int, error) {
+	if err := c.handshake(); err != nil {
+		return 0, err
+	}
+	c.rMu.Lock()
+	defer c.rMu.Unlock()
+	for len(c.unread) == 0 {
+		var header [2]byte
+		if _, err := io.ReadFull(sessionReader{c}, header[:]); err != nil {
+			return 0, err
+		}
+		record := make([]byte, binary.BigEndian.Uint16(header[:]))
+		if _, err := io.ReadFull(sessionReader{c}, record); err != nil {
+			return 0, err
+		}
+		plaintext, err := c.recv.Decrypt(record)
+		if err != nil {
+			return 0, fmt.Errorf("decrypt hybrid record failed: %w", err)
+		}
+		c.unread = plaintext
+	}
+	n := copy(b, c.unread)
+	c.unread = c.unread[n:]
+	return n, nil
+}
+
+// Write encrypts data and writes it to the session.
+func (c *hybridConn) Write(b []byte) (int, error) {
+	if err := c.handshake(); err != nil {
+		return 0, err
+	}
+	c.wMu.Lock()
+	defer c.wMu.Unlock()
+	n := 0
+	for len(b) > 0 {
+		size := mathext.Min(len(b), maxHybridRecordSize)
+		ciphertext, err := c.send.Encrypt(b[:size])
+		if err != nil {
+			return n, fmt.Errorf("encrypt hybrid record failed: %w", err)
+		}
+		record := make([]byte, 2+len(ciphertext))
+		binary.BigEndian.PutUint16(record, uint16(len(ciphertext)))
+		copy(record[2:], ciphertext)
+		if !c.writeDeadline.IsZero() {
+			c.Session.SetWriteDeadline(c.writeDeadline)
+		}
+		if _, err := c.Session.Write(record); err != nil {
+			return n, err
+		}
+		n += size
+		b = b[size:]
+	}
+	return n, nil
+}
+
+// SetDeadline implements net.Conn.
+func (c *hybridConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline implements net.Conn. The deadline applies to all the
+// reads from the session until it is changed.
+func (c *hybridConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline = t
+	return c.Session.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn. The deadline applies to all the
+// writes to the session until it is changed.
+func (c *hybridConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline = t
+	return c.Session.SetWriteDeadline(t)
+}
+
+// handshake exchanges the key shares if it is not done yet.
+func (c *hybridConn) handshake() error {
+	c.handshakeMu.Lock()
+	defer c.handshakeMu.Unlock()
+	if c.handshaked {
+		return c.handshakeErr
+	}
+	c.handshaked = true
+	if c.isClient {
+		c.handshakeErr = c.clientHandshake()
+	} else {
+		c.handshakeErr = c.serverHandshake()
+	}
+	if c.handshakeErr != nil {
+		SessionHybridKeyExchangeErrors.Add(1)
+		log.Debugf("%v hybrid key exchange failed: %v", c.Session, c.handshakeErr)
+		c.Session.Close()
+		return c.handshakeErr
+	}
+	SessionHybridKeyExchanges.Add(1)
+	return nil
+}
+
+func (c *hybridConn) clientHandshake() error {
+	key, err := cipher.NewHybridClientKey()
+	if err != nil {
+		return err
+	}
+	if _, err := c.Session.Write(key.Share()); err != nil {
+		return err
+	}
+	serverShare := make([]byte, cipher.HybridServerShareSize)
+	if _, err := io.ReadFull(sessionReader{c}, serverShare); err != nil {
+		return fmt.Errorf("read server key share failed: %w", err)
+	}
+	c.send, c.recv, err = key.Finish(serverShare, c.psk)
+	return err
+}
+
+func (c *hybridConn) serverHandshake() error {
+	clientShare := make([]byte, cipher.HybridClientShareSize)
+	if _, err := io.ReadFull(sessionReader{c}, clientShare); err != nil {
+		return fmt.Errorf("read client key share failed: %w", err)
+	}
+	userName := c.UserName()
+	user, ok := c.users[userName]
+	if !ok {
+		return fmt.Errorf("user %q is not found", userName)
+	}
+	var psk []byte
+	var err error
+	if c.nextPasswordUsed() {
+		psk, err = nextHashedPassword(user)
+	} else {
+		psk, err = hashedPassword(user)
+	}
+	if err != nil {
+		return err
+	}
+	serverShare, send, recv, err := cipher.HybridServerFinish(clientShare, psk)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Session.Write(serverShare); err != nil {
+		return err
+	}
+	c.send, c.recv = send, recv
+	return nil
+}
+
+// sessionReader reads from the session of a hybridConn, and keeps
+// the read deadline of the hybridConn for each read.
+type sessionReader struct {
+	c *hybridConn
+}
+
+func (r sessionReader) Read(b []byte) (int, error) {
+	if !r.c.readDeadline.IsZero() {
+		r.c.Session.SetReadDeadline(r.c.readDeadline)
+	}
+	return r.c.Session.Read(b)
+}
+
+// hashedPassword returns the hashed password of the user.
+func hashedPassword(user *appctlpb.User) ([]byte, error) {
+	password, err := hex.DecodeString(user.GetHashedPassword())
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode hashed password of user %q: %w", user.GetName(), err)
+	}
+	if len(password) == 0 {
+		password = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
+	}
+	return password, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// integrityChecksumSize is the size of the checksum that replaces
+// the end of padding in integrity diagnostic mode.
+const integrityChecksumSize = 4
+
+var integrityTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// IntegrityVerified is the number of decrypted UDP packets that carry
+	// a valid integrity checksum, which shows the peer sends checksums.
+	IntegrityVerified = metrics.RegisterMetric("integrity", "Verified", metrics.COUNTER)
+
+	// IntegrityWrongKey is the number of UDP packets that arrive intact
+	// but can't be decrypted. The cause is usually a wrong user name,
+	// password, or system time.
+	IntegrityWrongKey = metrics.RegisterMetric("integrity", "WrongKey", metrics.COUNTER)
+
+	// IntegrityCorrupted is the number of UDP packets that can't be decrypted
+	// and don't match the integrity checksum. The packets are corrupted in
+	// transit, or the peer doesn't send checksums.
+	IntegrityCorrupted = metrics.RegisterMetric("integrity", "Corrupted", metrics.COUNTER)
+)
+
+// integrityPadding makes sure the padding has room for the checksum,
+// as long as the padding can be that long.
+func integrityPadding(padding []byte, maxLen int) []byte {
+	if len(padding) >= integrityChecksumSize || maxLen < integrityChecksumSize {
+		return padding
+	}
+	return append(padding, make([]byte, integrityChecksumSize-len(padding))...)
+}
+
+// putIntegrityChecksum writes the checksum of the packet to the end of
+// the packet. The end of the packet must be padding that is at least
+// integrityChecksumSize bytes.
+//
+// The checksum is not a secret. It is only used to tell whether a packet
+// that fails to decrypt is modified by the network.
+func putIntegrityChecksum(packet []byte) {
+	n := len(packet) - integrityChecksumSize
+	binary.BigEndian.PutUint32(packet[n:], crc32.Checksum(packet[:n], integrityTable))
+}
+
+// verifyIntegrityChecksum returns true if the end of the packet is the
+// checksum of the rest of the packet.
+func verifyIntegrityChecksum(packet []byte) bool {
+	n := len(packet) - integrityChecksumSize
+	if n < packetNonHeaderPosition {
+		return false
+	}
+	return binary.BigEndian.Uint32(packet[n:]) == crc32.Checksum(packet[:n], integrityTable)
+}
+
+// diagnoseDecryptFailure counts a packet that fails to decrypt as either
+// intact or corrupted, if integrity diagnostic mode is enabled.
+func (u *PacketUnderlay) diagnoseDecryptFailure(packet []byte, addr net.Addr) {
+	if !u.integrityDiag {
+		return
+	}
+	if verifyIntegrityChecksum(packet) {
+		IntegrityWrongKey.Add(1)
+		log.Debugf("%v received intact packet from %v that can't be decrypted, the key may be wrong", u, addr)
+	} else {
+		IntegrityCorrupted.Add(1)
+		log.Debugf("%v received packet from %v that doesn't match integrity checksum", u, addr)
+	}
+}
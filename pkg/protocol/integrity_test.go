@@ -0,0 +1,125 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/rng"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestIntegrityChecksum(t *testing.T) {
+	packet := make([]byte, packetNonHeaderPosition+64)
+	for i := range packet {
+		packet[i] = byte(rng.Intn(256))
+	}
+	putIntegrityChecksum(packet)
+	if !verifyIntegrityChecksum(packet) {
+		t.Fatalf("verifyIntegrityChecksum() = false, want true")
+	}
+	packet[10] ^= 0x01
+	if verifyIntegrityChecksum(packet) {
+		t.Errorf("verifyIntegrityChecksum() = true after a bit is flipped")
+	}
+	if verifyIntegrityChecksum(packet[:packetNonHeaderPosition]) {
+		t.Errorf("verifyIntegrityChecksum() = true with a short packet")
+	}
+}
+
+func TestIntegrityPadding(t *testing.T) {
+	if got := integrityPadding(nil, 255); len(got) != integrityChecksumSize {
+		t.Errorf("len(integrityPadding()) = %d, want %d", len(got), integrityChecksumSize)
+	}
+	if got := integrityPadding([]byte{1, 2}, 3); len(got) != 2 {
+		t.Errorf("integrityPadding() extends padding over max length")
+	}
+	if got := integrityPadding(make([]byte, 8), 255); len(got) != 8 {
+		t.Errorf("integrityPadding() changes padding that is long enough")
+	}
+}
+
+func TestDiagnoseDecryptFailure(t *testing.T) {
+	u := &PacketUnderlay{integrityDiag: true}
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8964}
+	packet := make([]byte, packetNonHeaderPosition+16)
+	putIntegrityChecksum(packet)
+
+	wrongKey := IntegrityWrongKey.Load()
+	corrupted := IntegrityCorrupted.Load()
+	u.diagnoseDecryptFailure(packet, addr)
+	if IntegrityWrongKey.Load() != wrongKey+1 {
+		t.Errorf("intact packet is not counted as wrong key")
+	}
+	packet[0] ^= 0xff
+	u.diagnoseDecryptFailure(packet, addr)
+	if IntegrityCorrupted.Load() != corrupted+1 {
+		t.Errorf("modified packet is not counted as corrupted")
+	}
+
+	u.integrityDiag = false
+	u.diagnoseDecryptFailure(packet, addr)
+	if IntegrityWrongKey.Load() != wrongKey+1 || IntegrityCorrupted.Load() != corrupted+1 {
+		t.Errorf("packet is diagnosed when integrity diagnostic is disabled")
+	}
+}
+
+func TestUDPUnderlayWithIntegrityDiagnostic(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetIntegrityDiagnostic(true)
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	verified := IntegrityVerified.Load()
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetIntegrityDiagnostic(true)
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+	if IntegrityVerified.Load() == verified {
+		t.Errorf("no packet with integrity checksum is received")
+	}
+}
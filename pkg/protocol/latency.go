@@ -0,0 +1,77 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// The latency histograms help to tell the jitter caused by an overloaded
+// local machine from the jitter of the network. A slow write means the
+// socket buffer is full or the kernel is busy. A slow segment means
+// the event loop is blocked, usually because a session doesn't
+// consume the received data quickly enough.
+var (
+	// UnderlayUDPWriteLatency is the time to write a packet to UDP socket.
+	UnderlayUDPWriteLatency = metrics.RegisterHistogram("latency", "UDPWrite", metrics.DefaultLatencyBounds)
+
+	// UnderlayTCPWriteLatency is the time to write a segment to TCP socket.
+	UnderlayTCPWriteLatency = metrics.RegisterHistogram("latency", "TCPWrite", metrics.DefaultLatencyBounds)
+
+	// UnderlayUDPSegmentLatency is the time the event loop of a packet
+	// underlay spends on a received segment.
+	UnderlayUDPSegmentLatency = metrics.RegisterHistogram("latency", "UDPSegment", metrics.DefaultLatencyBounds)
+
+	// UnderlayTCPSegmentLatency is the time the event loop of a stream
+	// underlay spends on a received segment.
+	UnderlayTCPSegmentLatency = metrics.RegisterHistogram("latency", "TCPSegment", metrics.DefaultLatencyBounds)
+)
+
+// segmentTimer measures the time the event loop spends on
+// each received segment.
+type segmentTimer struct {
+	histogram *metrics.Histogram
+	start     time.Time
+}
+
+// begin is called after a segment is received.
+func (t *segmentTimer) begin() {
+	t.start = time.Now()
+}
+
+// end is called before the event loop reads the next segment.
+// It does nothing if no segment is received since the last call.
+func (t *segmentTimer) end() {
+	if t.start.IsZero() {
+		return
+	}
+	t.histogram.Observe(time.Since(t.start))
+	t.start = time.Time{}
+}
+
+// writePacket writes the packet to the UDP socket and records the latency.
+func (u *PacketUnderlay) writePacket(b []byte, addr net.Addr) error {
+	start := time.Now()
+	if _, err := u.packetConn().WriteTo(b, addr); err != nil {
+		return fmt.Errorf("WriteTo() failed: %w", err)
+	}
+	UnderlayUDPWriteLatency.Observe(time.Since(start))
+	return nil
+}
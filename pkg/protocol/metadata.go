@@ -26,16 +26,27 @@ import (
 type protocolType byte
 
 const (
-	closeConnRequest     protocolType = 0
-	closeConnResponse    protocolType = 1
-	openSessionRequest   protocolType = 2
-	openSessionResponse  protocolType = 3
-	closeSessionRequest  protocolType = 4
-	closeSessionResponse protocolType = 5
-	dataClientToServer   protocolType = 6
-	dataServerToClient   protocolType = 7
-	ackClientToServer    protocolType = 8
-	ackServerToClient    protocolType = 9
+	closeConnRequest       protocolType = 0
+	closeConnResponse      protocolType = 1
+	openSessionRequest     protocolType = 2
+	openSessionResponse    protocolType = 3
+	closeSessionRequest    protocolType = 4
+	closeSessionResponse   protocolType = 5
+	dataClientToServer     protocolType = 6
+	dataServerToClient     protocolType = 7
+	ackClientToServer      protocolType = 8
+	ackServerToClient      protocolType = 9
+	fecClientToServer      protocolType = 10
+	fecServerToClient      protocolType = 11
+	mtuProbeRequest        protocolType = 12
+	mtuProbeResponse       protocolType = 13
+	resumeSessionRequest   protocolType = 14
+	resumeSessionResponse  protocolType = 15
+	datagramClientToServer protocolType = 16
+	datagramServerToClient protocolType = 17
+	rekeyRequest           protocolType = 18
+	rekeyResponse          protocolType = 19
+	credentialPush         protocolType = 20
 )
 
 func (p protocolType) Equals(other byte) bool {
@@ -64,6 +75,28 @@ func (p protocolType) String() string {
 		return "ackClientToServer"
 	case ackServerToClient:
 		return "ackServerToClient"
+	case fecClientToServer:
+		return "fecClientToServer"
+	case fecServerToClient:
+		return "fecServerToClient"
+	case mtuProbeRequest:
+		return "mtuProbeRequest"
+	case mtuProbeResponse:
+		return "mtuProbeResponse"
+	case resumeSessionRequest:
+		return "resumeSessionRequest"
+	case resumeSessionResponse:
+		return "resumeSessionResponse"
+	case datagramClientToServer:
+		return "datagramClientToServer"
+	case datagramServerToClient:
+		return "datagramServerToClient"
+	case rekeyRequest:
+		return "rekeyRequest"
+	case rekeyResponse:
+		return "rekeyResponse"
+	case credentialPush:
+		return "credentialPush"
 	default:
 		return "UNKNOWN"
 	}
@@ -74,6 +107,8 @@ type statusCode byte
 const (
 	statusOK             statusCode = 0
 	statusQuotaExhausted statusCode = 1
+	statusResumable      statusCode = 2
+	statusDatagram       statusCode = 3
 )
 
 func (c statusCode) String() string {
@@ -82,6 +117,10 @@ func (c statusCode) String() string {
 		return "OK"
 	case statusQuotaExhausted:
 		return "quotaExhausted"
+	case statusResumable:
+		return "resumable"
+	case statusDatagram:
+		return "datagram"
 	default:
 		return "UNKNOWN"
 	}
@@ -93,6 +132,12 @@ const (
 
 	// Maximum payload that cat be attached to open session request and open session response.
 	MaxSessionOpenPayload = 1024
+
+	// Maximum number of selective acknowledgement ranges carried by one ack.
+	maxSACKRanges = 8
+
+	// Number of bytes used by one selective acknowledgement range.
+	sackRangeLength = 8
 )
 
 // metadata defines the methods supported by all metadata.
@@ -131,6 +176,19 @@ type sessionStruct struct {
 	statusCode uint8  // byte 14: status of opening or closing session
 	payloadLen uint16 // byte 15 - 16: length of encapsulated payload, not including auth tag
 	suffixLen  uint8  // byte 17: length of suffix padding
+
+	// Only used by open session response.
+	serverFeatures uint32   // byte 18 - 21: features supported by the server
+	serverVersion  [3]uint8 // byte 22 - 24: major, minor and patch version of the server
+
+	// Only used by open session request.
+	priority uint8 // byte 25: priority of the session
+
+	// Used by open session request and response.
+	keyExchange uint8 // byte 26: key exchange method of the session
+
+	// Only used by open session request.
+	clientFeatures uint8 // byte 27: features supported by the client
 }
 
 func (ss *sessionStruct) Protocol() protocolType {
@@ -147,6 +205,11 @@ func (ss *sessionStruct) Marshal() []byte {
 	b[14] = ss.statusCode
 	binary.BigEndian.PutUint16(b[15:], ss.payloadLen)
 	b[17] = ss.suffixLen
+	binary.BigEndian.PutUint32(b[18:], ss.serverFeatures)
+	copy(b[22:25], ss.serverVersion[:])
+	b[25] = ss.priority
+	b[26] = ss.keyExchange
+	b[27] = ss.clientFeatures
 	return b
 }
 
@@ -155,7 +218,7 @@ func (ss *sessionStruct) Unmarshal(b []byte) error {
 	if len(b) != MetadataLength {
 		return fmt.Errorf("input bytes: %d, want %d", len(b), MetadataLength)
 	}
-	if !openSessionRequest.Equals(b[0]) && !openSessionResponse.Equals(b[0]) && !closeSessionRequest.Equals(b[0]) && !closeSessionResponse.Equals(b[0]) {
+	if !isSessionProtocol(protocolType(b[0])) {
 		return fmt.Errorf("invalid protocol %d", b[0])
 	}
 	originalTimestamp := binary.BigEndian.Uint32(b[2:])
@@ -175,6 +238,11 @@ func (ss *sessionStruct) Unmarshal(b []byte) error {
 	ss.statusCode = b[14]
 	ss.payloadLen = binary.BigEndian.Uint16(b[15:])
 	ss.suffixLen = b[17]
+	ss.serverFeatures = binary.BigEndian.Uint32(b[18:])
+	copy(ss.serverVersion[:], b[22:25])
+	ss.priority = b[25]
+	ss.keyExchange = b[26]
+	ss.clientFeatures = b[27]
 	return nil
 }
 
@@ -183,7 +251,7 @@ func (ss *sessionStruct) String() string {
 }
 
 func isSessionProtocol(p protocolType) bool {
-	return p == openSessionRequest || p == openSessionResponse || p == closeSessionRequest || p == closeSessionResponse
+	return p == openSessionRequest || p == openSessionResponse || p == closeSessionRequest || p == closeSessionResponse || p == mtuProbeRequest || p == mtuProbeResponse || p == resumeSessionRequest || p == resumeSessionResponse || p == rekeyRequest || p == rekeyResponse || p == credentialPush
 }
 
 func toSessionStruct(m metadata) (*sessionStruct, bool) {
@@ -204,6 +272,7 @@ type dataAckStruct struct {
 	prefixLen  uint8  // byte 21: length of prefix padding
 	payloadLen uint16 // byte 22 - 23: length of encapsulated payload, not including auth tag
 	suffixLen  uint8  // byte 24: length of suffix padding
+	sackCount  uint8  // byte 25: number of selective acknowledgement ranges in the payload of ack
 }
 
 func (das *dataAckStruct) Protocol() protocolType {
@@ -223,6 +292,7 @@ func (das *dataAckStruct) Marshal() []byte {
 	b[21] = das.prefixLen
 	binary.BigEndian.PutUint16(b[22:], das.payloadLen)
 	b[24] = das.suffixLen
+	b[25] = das.sackCount
 	return b
 }
 
@@ -231,7 +301,7 @@ func (das *dataAckStruct) Unmarshal(b []byte) error {
 	if len(b) != MetadataLength {
 		return fmt.Errorf("input bytes: %d, want %d", len(b), MetadataLength)
 	}
-	if !dataClientToServer.Equals(b[0]) && !dataServerToClient.Equals(b[0]) && !ackClientToServer.Equals(b[0]) && !ackServerToClient.Equals(b[0]) {
+	if !isDataAckProtocol(protocolType(b[0])) {
 		return fmt.Errorf("invalid protocol %d", b[0])
 	}
 	originalTimestamp := binary.BigEndian.Uint32(b[2:])
@@ -251,15 +321,70 @@ func (das *dataAckStruct) Unmarshal(b []byte) error {
 	das.prefixLen = b[21]
 	das.payloadLen = binary.BigEndian.Uint16(b[22:])
 	das.suffixLen = b[24]
+	das.sackCount = b[25]
 	return nil
 }
 
 func (das *dataAckStruct) String() string {
-	return fmt.Sprintf("dataAckStruct{protocol=%v, sessionID=%v, seq=%v, unAckSeq=%v, windowSize=%v, fragment=%v, prefixLen=%v, payloadLen=%v, suffixLen=%v}", protocolType(das.protocol), das.sessionID, das.seq, das.unAckSeq, das.windowSize, das.fragment, das.prefixLen, das.payloadLen, das.suffixLen)
+	return fmt.Sprintf("dataAckStruct{protocol=%v, sessionID=%v, seq=%v, unAckSeq=%v, windowSize=%v, fragment=%v, prefixLen=%v, payloadLen=%v, suffixLen=%v, sackCount=%v}", protocolType(das.protocol), das.sessionID, das.seq, das.unAckSeq, das.windowSize, das.fragment, das.prefixLen, das.payloadLen, das.suffixLen, das.sackCount)
+}
+
+// sackRange is a range of sequence numbers received by the peer
+// out of order. Both start and end are included.
+type sackRange struct {
+	start uint32
+	end   uint32
+}
+
+func (r sackRange) String() string {
+	return fmt.Sprintf("[%d, %d]", r.start, r.end)
+}
+
+// contains returns true if the sequence number is in the range.
+func (r sackRange) contains(seq uint32) bool {
+	return seq >= r.start && seq <= r.end
+}
+
+// marshalSACKRanges encodes the selective acknowledgement ranges
+// to the payload of ack. Each range uses 8 bytes.
+func marshalSACKRanges(ranges []sackRange) []byte {
+	b := make([]byte, sackRangeLength*len(ranges))
+	for i, r := range ranges {
+		binary.BigEndian.PutUint32(b[sackRangeLength*i:], r.start)
+		binary.BigEndian.PutUint32(b[sackRangeLength*i+4:], r.end)
+	}
+	return b
+}
+
+// unmarshalSACKRanges decodes count selective acknowledgement ranges
+// from the payload of ack.
+func unmarshalSACKRanges(b []byte, count int) ([]sackRange, error) {
+	if count > maxSACKRanges {
+		return nil, fmt.Errorf("number of SACK ranges %d exceed maximum value %d", count, maxSACKRanges)
+	}
+	if len(b) != sackRangeLength*count {
+		return nil, fmt.Errorf("SACK payload size %d, want %d", len(b), sackRangeLength*count)
+	}
+	ranges := make([]sackRange, 0, count)
+	for i := 0; i < count; i++ {
+		r := sackRange{
+			start: binary.BigEndian.Uint32(b[sackRangeLength*i:]),
+			end:   binary.BigEndian.Uint32(b[sackRangeLength*i+4:]),
+		}
+		if r.start > r.end {
+			return nil, fmt.Errorf("invalid SACK range %v", r)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
 }
 
 func isDataAckProtocol(p protocolType) bool {
-	return p == dataClientToServer || p == dataServerToClient || p == ackClientToServer || p == ackServerToClient
+	return p == dataClientToServer || p == dataServerToClient || p == ackClientToServer || p == ackServerToClient || p == fecClientToServer || p == fecServerToClient || p == datagramClientToServer || p == datagramServerToClient
+}
+
+func isDataProtocol(p protocolType) bool {
+	return p == dataClientToServer || p == dataServerToClient
 }
 
 func toDataAckStruct(m metadata) (*dataAckStruct, bool) {
@@ -26,11 +26,16 @@ func TestSessionStruct(t *testing.T) {
 		baseStruct: baseStruct{
 			protocol: uint8(closeSessionRequest),
 		},
-		sessionID:  mrand.Uint32(),
-		statusCode: uint8(mrand.Uint32()),
-		seq:        mrand.Uint32(),
-		payloadLen: uint16(mrand.Uint32()),
-		suffixLen:  uint8(mrand.Uint32()),
+		sessionID:      mrand.Uint32(),
+		statusCode:     uint8(mrand.Uint32()),
+		seq:            mrand.Uint32(),
+		payloadLen:     uint16(mrand.Uint32()),
+		suffixLen:      uint8(mrand.Uint32()),
+		serverFeatures: mrand.Uint32(),
+		serverVersion:  [3]uint8{uint8(mrand.Uint32()), uint8(mrand.Uint32()), uint8(mrand.Uint32())},
+		priority:       uint8(mrand.Uint32()),
+		keyExchange:    uint8(mrand.Uint32()),
+		clientFeatures: uint8(mrand.Uint32()),
 	}
 	b := s.Marshal()
 	s2 := &sessionStruct{}
@@ -58,6 +63,7 @@ func TestDataAckStruct(t *testing.T) {
 		prefixLen:  uint8(mrand.Uint32()),
 		payloadLen: uint16(mrand.Uint32()),
 		suffixLen:  uint8(mrand.Uint32()),
+		sackCount:  uint8(mrand.Uint32()),
 	}
 	b := s.Marshal()
 	s2 := &dataAckStruct{}
@@ -71,3 +77,29 @@ func TestDataAckStruct(t *testing.T) {
 		t.Errorf("Not equal:\n%s\n====\n%s", s.String(), s2.String())
 	}
 }
+
+func TestSACKRanges(t *testing.T) {
+	ranges := []sackRange{{start: 3, end: 3}, {start: 5, end: 9}, {start: 12, end: 20}}
+	b := marshalSACKRanges(ranges)
+	if len(b) != sackRangeLength*len(ranges) {
+		t.Fatalf("marshalSACKRanges() returned %d bytes, want %d", len(b), sackRangeLength*len(ranges))
+	}
+	ranges2, err := unmarshalSACKRanges(b, len(ranges))
+	if err != nil {
+		t.Fatalf("unmarshalSACKRanges() failed: %v", err)
+	}
+	if !reflect.DeepEqual(ranges, ranges2) {
+		t.Errorf("Not equal:\n%v\n====\n%v", ranges, ranges2)
+	}
+
+	if _, err := unmarshalSACKRanges(b, len(ranges)-1); err == nil {
+		t.Errorf("unmarshalSACKRanges() with wrong count returned no error")
+	}
+	if _, err := unmarshalSACKRanges(make([]byte, sackRangeLength*(maxSACKRanges+1)), maxSACKRanges+1); err == nil {
+		t.Errorf("unmarshalSACKRanges() with too many ranges returned no error")
+	}
+	invalid := marshalSACKRanges([]sackRange{{start: 10, end: 9}})
+	if _, err := unmarshalSACKRanges(invalid, 1); err == nil {
+		t.Errorf("unmarshalSACKRanges() with invalid range returned no error")
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	crand "crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// Session migration keeps UDP sessions alive when the network of the
+// client changes, for example from Wi-Fi to LTE. When the client stops
+// receiving acknowledgements, it moves the underlay to a new UDP
+// connection, and asks the server to send the sessions to the new address.
+// Sequence numbers and unacknowledged segments are not changed, so the
+// sessions continue from where they stopped.
+//
+// The client asks for a resumption token by setting statusResumable in
+// the open session request. The server returns the token as the payload
+// of the open session response, with statusResumable.
+//
+// A resume session request reuses sessionStruct with the following meaning:
+//   - sessionID: the session to resume
+//   - payload: the resumption token
+//
+// A resume session response reuses sessionStruct with the following meaning:
+//   - sessionID: the session that is resumed
+
+const (
+	// resumeTokenLength is the number of bytes in a resumption token.
+	resumeTokenLength = 16
+
+	// maxMigrationAttempts is the number of migrations to try before
+	// the client underlay is closed.
+	maxMigrationAttempts = 3
+)
+
+var (
+	// UnderlayMigrations is the number of times client underlays
+	// moved to a new UDP connection.
+	UnderlayMigrations = metrics.RegisterMetric("underlay", "Migrations", metrics.COUNTER)
+
+	// SessionResumed is the number of sessions resumed from a new address.
+	SessionResumed = metrics.RegisterMetric("session", "Resumed", metrics.COUNTER)
+
+	// SessionResumeRejected is the number of rejected resume session requests.
+	SessionResumeRejected = metrics.RegisterMetric("session", "ResumeRejected", metrics.COUNTER)
+)
+
+// issueResumeToken creates a new resumption token and attaches it to
+// the open session response. It is only used by server.
+func (s *Session) issueResumeToken(resp *segment) {
+	token := make([]byte, resumeTokenLength)
+	if _, err := crand.Read(token); err != nil {
+		log.Debugf("%v unable to create resumption token: %v", s, err)
+		return
+	}
+	s.resumeToken.Store(&token)
+	ss := resp.metadata.(*sessionStruct)
+	ss.statusCode = uint8(statusResumable)
+	ss.payloadLen = uint16(len(token))
+	resp.payload = token
+}
+
+// takeResumeToken removes the resumption token from the open session
+// response, so it is not delivered to the application. It is only used
+// by client.
+func (s *Session) takeResumeToken(resp *segment) {
+	ss, ok := resp.metadata.(*sessionStruct)
+	if !ok || ss.statusCode != uint8(statusResumable) {
+		return
+	}
+	if len(resp.payload) == resumeTokenLength {
+		resp.detach()
+		token := resp.payload
+		s.resumeToken.Store(&token)
+	}
+	ss.payloadLen = 0
+	resp.payload = nil
+}
+
+// isValidResumeRequest returns true if the request has the resumption
+// token of the session, and it is sent by the same user.
+func (s *Session) isValidResumeRequest(req *segment) bool {
+	token := s.resumeToken.Load()
+	if token == nil || subtle.ConstantTimeCompare(*token, req.payload) != 1 {
+		return false
+	}
+	if req.block == nil || s.block.Load() == nil {
+		return false
+	}
+	return req.block.BlockContext().UserName == (*s.block.Load()).BlockContext().UserName
+}
+
+// enableSessionMigration allows the client underlay to move to
+// a new UDP connection.
+func (u *PacketUnderlay) enableSessionMigration() {
+	if u.isClient {
+		u.sessionMigration = true
+	}
+}
+
+// migrate moves the client underlay to a new UDP connection, and sends
+// resume session requests of all the resumable sessions from the new address.
+func (u *PacketUnderlay) migrate() error {
+	if !u.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	var resumable []*Session
+	u.sessionMap.Range(func(k, v any) bool {
+		session := v.(*Session)
+		if session.resumeToken.Load() != nil {
+			resumable = append(resumable, session)
+		}
+		return true
+	})
+	if len(resumable) == 0 {
+		return fmt.Errorf("no resumable session")
+	}
+
+	conn, err := listenClientPacketConn(context.Background(), u.network, nil, u.dialer)
+	if err != nil {
+		return err
+	}
+	if u.pmtudEnabled {
+		if c, ok := udpConn(conn); ok {
+			if err := sockopts.ApplyDontFragment(c); err != nil {
+				conn.Close()
+				return fmt.Errorf("ApplyDontFragment() failed: %w", err)
+			}
+		}
+	}
+
+	// Wait for the ongoing write to finish before replacing the connection.
+	u.egress.acquire(0, SessionPriorityNormal.weight())
+	u.connMu.Lock()
+	oldConn := u.conn
+	u.conn = conn
+	if u.udpOffload {
+		enableUDPOffload(u.conn)
+	}
+	u.connMu.Unlock()
+	u.egress.release()
+	oldConn.Close()
+	u.lastMigration = time.Now()
+	UnderlayMigrations.Add(1)
+	log.Infof("%v moved to a new UDP connection", u)
+
+	for _, session := range resumable {
+		req := &segment{
+			metadata: &sessionStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(resumeSessionRequest),
+				},
+				sessionID:  session.id,
+				payloadLen: resumeTokenLength,
+			},
+			payload:   *session.resumeToken.Load(),
+			transport: common.PacketTransport,
+		}
+		if err := u.writeOneSegment(req, u.serverAddr); err != nil {
+			return fmt.Errorf("writeOneSegment() failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// onResumeSessionRequest sends the session to the new address of the client.
+func (u *PacketUnderlay) onResumeSessionRequest(seg *segment, remoteAddr net.Addr) error {
+	if u.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	sessionID := seg.metadata.(*sessionStruct).sessionID
+	v, found := u.sessionMap.Load(sessionID)
+	if !found {
+		log.Debugf("%v received resume session request, but session ID %d is not found", u, sessionID)
+		return nil
+	}
+	session := v.(*Session)
+	if !session.isValidResumeRequest(seg) {
+		SessionResumeRejected.Add(1)
+		log.Debugf("%v rejected resume session request of %v from %v", u, session, remoteAddr)
+		return nil
+	}
+	if session.RemoteAddr().String() != remoteAddr.String() {
+		log.Debugf("%v moved to %v", session, remoteAddr)
+		session.setRemoteAddr(remoteAddr)
+		SessionResumed.Add(1)
+	}
+	resp := &segment{
+		metadata: &sessionStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(resumeSessionResponse),
+			},
+			sessionID: sessionID,
+		},
+		transport: common.PacketTransport,
+		block:     seg.block,
+	}
+	return u.writeOneSegment(resp, remoteAddr)
+}
+
+// onResumeSessionResponse confirms the session is resumed.
+func (u *PacketUnderlay) onResumeSessionResponse(seg *segment) error {
+	if !u.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	sessionID := seg.metadata.(*sessionStruct).sessionID
+	if _, found := u.sessionMap.Load(sessionID); found {
+		log.Debugf("%v resumed session %d", u, sessionID)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+// udpRelay forwards packets between clients and a server. It can stop
+// forwarding packets of a client address to simulate a network change.
+type udpRelay struct {
+	conn       *net.UDPConn
+	serverAddr *net.UDPAddr
+
+	mu           sync.Mutex
+	upstreams    map[string]*net.UDPConn // Map<client address, connection to server>
+	blocked      map[string]bool
+	firstPackets map[string][]byte // Map<client address, first packet from the client>
+}
+
+func newUDPRelay(t *testing.T, serverAddr *net.UDPAddr) *udpRelay {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	r := &udpRelay{
+		conn:         conn,
+		serverAddr:   serverAddr,
+		upstreams:    make(map[string]*net.UDPConn),
+		blocked:      make(map[string]bool),
+		firstPackets: make(map[string][]byte),
+	}
+	go r.run()
+	return r
+}
+
+func (r *udpRelay) run() {
+	b := make([]byte, maxPathMTU)
+	for {
+		n, clientAddr, err := r.conn.ReadFromUDP(b)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		if r.blocked[clientAddr.String()] {
+			r.mu.Unlock()
+			continue
+		}
+		upstream, found := r.upstreams[clientAddr.String()]
+		if !found {
+			upstream, err = net.DialUDP("udp", nil, r.serverAddr)
+			if err != nil {
+				r.mu.Unlock()
+				continue
+			}
+			r.upstreams[clientAddr.String()] = upstream
+			r.firstPackets[clientAddr.String()] = append([]byte{}, b[:n]...)
+			go r.runUpstream(upstream, clientAddr)
+		}
+		r.mu.Unlock()
+		upstream.Write(b[:n])
+	}
+}
+
+func (r *udpRelay) runUpstream(upstream *net.UDPConn, clientAddr *net.UDPAddr) {
+	b := make([]byte, maxPathMTU)
+	for {
+		n, err := upstream.Read(b)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		blocked := r.blocked[clientAddr.String()]
+		r.mu.Unlock()
+		if !blocked {
+			r.conn.WriteToUDP(b[:n], clientAddr)
+		}
+	}
+}
+
+// blockAll stops forwarding packets of all the known client addresses.
+func (r *udpRelay) blockAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for addr := range r.upstreams {
+		r.blocked[addr] = true
+	}
+}
+
+// replayFirstPackets sends the first packet of each client to the server again,
+// from the same address as the client.
+func (r *udpRelay) replayFirstPackets() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for addr, upstream := range r.upstreams {
+		upstream.Write(r.firstPackets[addr])
+	}
+}
+
+func (r *udpRelay) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conn.Close()
+	for _, upstream := range r.upstreams {
+		upstream.Close()
+	}
+}
+
+func TestSessionMigration(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverAddr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	relay := newUDPRelay(t, serverAddr)
+	defer relay.Close()
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, relay.conn.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetClientSessionMigration(true)
+	defer clientMux.Close()
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	underlay := conn.(*Session).conn.(*PacketUnderlay)
+	underlay.ackStarvationTimeout = 500 * time.Millisecond
+	underlay.idleSessionTicker.Reset(100 * time.Millisecond)
+	underlay.readTimeout = 100 * time.Millisecond
+
+	echo := func() {
+		payload := testtool.TestHelperGenRot13Input(4096)
+		if _, err := conn.Write(payload); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		resp := make([]byte, len(payload))
+		conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			t.Fatalf("io.ReadFull() failed: %v", err)
+		}
+		rot13, err := testtool.TestHelperRot13(resp)
+		if err != nil {
+			t.Fatalf("TestHelperRot13() failed: %v", err)
+		}
+		if !bytes.Equal(payload, rot13) {
+			t.Fatalf("Received unexpected response")
+		}
+	}
+	echo()
+	if conn.(*Session).resumeToken.Load() == nil {
+		t.Fatalf("session doesn't have a resumption token")
+	}
+
+	// The network is changed. The old client address no longer works.
+	migrations := UnderlayMigrations.Load()
+	resumed := SessionResumed.Load()
+	relay.blockAll()
+	echo()
+	if UnderlayMigrations.Load() <= migrations {
+		t.Errorf("client underlay is not migrated")
+	}
+	if SessionResumed.Load() <= resumed {
+		t.Errorf("session is not resumed")
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// A broken path MTU discovery makes large TCP segments disappear, while
+// the TCP handshake and small segments still go through. From the point
+// of view of the sender, the connection is established and then stalls:
+// a write blocks because the peer never acknowledges the data.
+//
+// A stream underlay counts a stall when a write blocks longer than
+// streamStallThreshold after the underlay received data from the peer.
+// The stalled underlay starts to split writes into small pieces.
+// After streamStallsToClamp stalls to the same server, the client clamps
+// the TCP maximum segment size (MSS) of new underlays to that server,
+// so both the client and the server send small segments.
+
+const (
+	// streamStallThreshold is the time a write can block before
+	// it is counted as a stall.
+	streamStallThreshold = 5 * time.Second
+
+	// streamStallsToClamp is the number of stalls to the same server
+	// before the MSS of new underlays is clamped.
+	streamStallsToClamp = 2
+
+	// clampedMSS is the MSS used after the workaround is activated.
+	// It fits the smallest path MTU commonly seen on the Internet,
+	// including IPv6 and tunnel headers.
+	clampedMSS = 1200
+)
+
+var (
+	// UnderlayStreamStalls is the number of writes to stream underlays
+	// that blocked longer than streamStallThreshold.
+	UnderlayStreamStalls = metrics.RegisterMetric("underlay", "StreamStalls", metrics.COUNTER)
+
+	// UnderlayMSSClampActivations is the number of servers that the client
+	// started to clamp the MSS after repeated stalls.
+	UnderlayMSSClampActivations = metrics.RegisterMetric("underlay", "MSSClampActivations", metrics.COUNTER)
+)
+
+// mssClampRegistry counts stream underlay stalls of each server address.
+type mssClampRegistry struct {
+	mu     sync.Mutex
+	stalls map[string]int
+}
+
+var streamMSSClamp = &mssClampRegistry{stalls: make(map[string]int)}
+
+// onStall records a stall to the server address. It returns true if
+// the workaround is activated by this stall.
+func (r *mssClampRegistry) onStall(addr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stalls[addr]++
+	return r.stalls[addr] == streamStallsToClamp
+}
+
+// clamped returns true if new underlays to the server address
+// should clamp the MSS.
+func (r *mssClampRegistry) clamped(addr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stalls[addr] >= streamStallsToClamp
+}
+
+// streamDialControl returns the socket control of a new client stream
+// underlay to the server address. If the MSS should be clamped but the
+// socket option is not supported, segmentSize returns the size to split
+// writes to instead.
+func streamDialControl(addr string) (control sockopts.Control, segmentSize func() int) {
+	if !streamMSSClamp.clamped(addr) {
+		return sockopts.ReuseAddrPort(), func() int { return 0 }
+	}
+	split := 0
+	control = func(network, address string, conn syscall.RawConn) error {
+		if err := sockopts.ReuseAddrPort()(network, address, conn); err != nil {
+			return err
+		}
+		if err := sockopts.MaxSegment(clampedMSS)(network, address, conn); err != nil {
+			log.Debugf("Unable to clamp MSS of connection to %s: %v", address, err)
+			split = clampedMSS
+		}
+		return nil
+	}
+	return control, func() int { return split }
+}
+
+// writeConn writes the data to the connection of the stream underlay.
+// If a previous write stalled, the data is split into small pieces.
+// The caller must hold the egress of the underlay.
+func (t *StreamUnderlay) writeConn(b []byte) error {
+	start := time.Now()
+	if t.segmentSize > 0 {
+		for len(b) > 0 {
+			n := mathext.Min(len(b), t.segmentSize)
+			if _, err := t.conn.Write(b[:n]); err != nil {
+				return err
+			}
+			b = b[n:]
+		}
+	} else {
+		if _, err := t.conn.Write(b); err != nil {
+			return err
+		}
+	}
+	elapsed := time.Since(start)
+	UnderlayTCPWriteLatency.Observe(elapsed)
+	if elapsed >= streamStallThreshold && t.established.Load() {
+		t.onStall()
+	}
+	return nil
+}
+
+// onStall is called when a write of the stream underlay stalled.
+// The caller must hold the egress of the underlay.
+func (t *StreamUnderlay) onStall() {
+	UnderlayStreamStalls.Add(1)
+	if t.segmentSize == 0 {
+		log.Infof("%v write stalled, splitting data into segments of %d bytes", t, clampedMSS)
+		t.segmentSize = clampedMSS
+	}
+	if t.isClient && t.dialAddr != "" && streamMSSClamp.onStall(t.dialAddr) {
+		UnderlayMSSClampActivations.Add(1)
+		log.Infof("Repeated stalls to %s, clamping MSS of new connections to %d bytes", t.dialAddr, clampedMSS)
+	}
+}
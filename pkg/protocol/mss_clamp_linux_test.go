@@ -0,0 +1,47 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build linux
+
+package protocol
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestStreamDialControlSetsMaxSegment(t *testing.T) {
+	conn, segmentSize := dialClampedStream(t)
+	if segmentSize != 0 {
+		t.Errorf("segmentSize() = %d, want 0 when MSS is clamped", segmentSize)
+	}
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	if err != nil {
+		t.Fatalf("SyscallConn() failed: %v", err)
+	}
+	var mss int
+	var getErr error
+	rawConn.Control(func(fd uintptr) {
+		mss, getErr = unix.GetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_MAXSEG)
+	})
+	if getErr != nil {
+		t.Fatalf("GetsockoptInt() failed: %v", getErr)
+	}
+	if mss > clampedMSS {
+		t.Errorf("MSS = %d, want at most %d", mss, clampedMSS)
+	}
+}
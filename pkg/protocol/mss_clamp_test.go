@@ -0,0 +1,135 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+type recordWritesConn struct {
+	net.Conn
+	writes []int
+}
+
+func (c *recordWritesConn) Write(b []byte) (int, error) {
+	c.writes = append(c.writes, len(b))
+	return len(b), nil
+}
+
+func TestMSSClampRegistry(t *testing.T) {
+	r := &mssClampRegistry{stalls: make(map[string]int)}
+	addr := "127.0.0.1:8964"
+	for i := 1; i < streamStallsToClamp; i++ {
+		if r.onStall(addr) {
+			t.Fatalf("onStall() activated the workaround after %d stalls", i)
+		}
+	}
+	if r.clamped(addr) {
+		t.Fatalf("clamped() = true before the workaround is activated")
+	}
+	if !r.onStall(addr) {
+		t.Fatalf("onStall() didn't activate the workaround after %d stalls", streamStallsToClamp)
+	}
+	if !r.clamped(addr) {
+		t.Errorf("clamped() = false after the workaround is activated")
+	}
+	if r.onStall(addr) {
+		t.Errorf("onStall() activated the workaround twice")
+	}
+	if r.clamped("127.0.0.1:8965") {
+		t.Errorf("clamped() = true for another address")
+	}
+}
+
+func TestStreamUnderlayStallSplitsWrites(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	conn := &recordWritesConn{Conn: c1}
+	underlay := &StreamUnderlay{
+		baseUnderlay: *newBaseUnderlay(true, 1500),
+		conn:         conn,
+	}
+	before := UnderlayStreamStalls.Load()
+	underlay.onStall()
+	if got := UnderlayStreamStalls.Load() - before; got != 1 {
+		t.Errorf("StreamStalls increased by %d, want 1", got)
+	}
+	if err := underlay.writeConn(make([]byte, clampedMSS*2+100)); err != nil {
+		t.Fatalf("writeConn() failed: %v", err)
+	}
+	want := []int{clampedMSS, clampedMSS, 100}
+	if len(conn.writes) != len(want) {
+		t.Fatalf("writes = %v, want %v", conn.writes, want)
+	}
+	for i := range want {
+		if conn.writes[i] != want[i] {
+			t.Errorf("writes = %v, want %v", conn.writes, want)
+			break
+		}
+	}
+}
+
+// dialClampedStream dials a TCP listener after the stalls of the
+// listener address activate the MSS clamp workaround.
+func dialClampedStream(t *testing.T) (conn net.Conn, segmentSize int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := listener.Addr().String()
+
+	before := UnderlayMSSClampActivations.Load()
+	for i := 0; i < streamStallsToClamp; i++ {
+		(&StreamUnderlay{
+			baseUnderlay: *newBaseUnderlay(true, 1500),
+			dialAddr:     addr,
+		}).onStall()
+	}
+	if got := UnderlayMSSClampActivations.Load() - before; got != 1 {
+		t.Errorf("MSSClampActivations increased by %d, want 1", got)
+	}
+
+	control, size := streamDialControl(addr)
+	dialer := net.Dialer{Control: control}
+	conn, err = dialer.DialContext(context.Background(), "tcp", addr)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, size()
+}
+
+func TestStreamDialControlClampsMSS(t *testing.T) {
+	_, segmentSize := dialClampedStream(t)
+	// If the socket option is not supported, the writes are split.
+	if segmentSize != 0 && segmentSize != clampedMSS {
+		t.Errorf("segmentSize() = %d, want 0 or %d", segmentSize, clampedMSS)
+	}
+}
@@ -17,7 +17,7 @@ package protocol
 
 import (
 	"context"
-	"encoding/hex"
+	"crypto/tls"
 	"fmt"
 	"io"
 	mrand "math/rand"
@@ -25,6 +25,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
@@ -32,8 +33,11 @@ import (
 	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
 	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+	"github.com/enfein/mieru/v3/pkg/congestion"
+	"github.com/enfein/mieru/v3/pkg/crash"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/mathext"
+	"github.com/enfein/mieru/v3/pkg/replay"
 	"github.com/enfein/mieru/v3/pkg/stderror"
 )
 
@@ -46,22 +50,43 @@ type Mux struct {
 	// ---- common fields ----
 	isClient    bool
 	endpoints   []UnderlayProperties
-	underlays   []Underlay
+	pool        *UnderlayPool
 	chAccept    chan net.Conn
 	chAcceptErr chan error
-	resolver    apicommon.DNSResolver
+	resolver    atomic.Pointer[apicommon.DNSResolver]
 	used        bool
 	done        chan struct{}
 	mu          sync.Mutex
 	cleaner     *time.Ticker
+	websocket   *WebSocketConfig
+	tlsConfig   *tls.Config
+	sessionOpts sessionOptions
+	udpOffload  bool
+	integrity   bool
+	fairness    Fairness
+	draining    atomic.Bool // if set, new underlays and sessions are not created
 
 	// ---- client fields ----
-	username        string
-	password        []byte
-	multiplexFactor int
+	username         string
+	password         []byte
+	pathMTUDisc      bool
+	underlayIdleTime time.Duration
+	rekeyBytes       int64
+	rekeyInterval    time.Duration
+	credHandler      func(userName string, hashedPassword []byte)
+	selector         *endpointSelector
+	events           *EventListener
+	dialer           apicommon.Dialer
 
 	// ---- server fields ----
-	users map[string]*appctlpb.User
+	users         map[string]*appctlpb.User
+	userSet       atomic.Pointer[serverUserSet] // users and cipher candidates shared by underlays
+	listeners     []io.Closer                   // TCP listeners of the endpoints
+	sessionEngine SessionEngine                 // how the server runs sessions
+	engineWorkers int                           // number of workers of the event driven engine, 0 to use the default
+	engine        *eventEngine                  // created by Start if the event driven engine is used
+	decoy         *Decoy                        // serves TCP connections that fail authentication
+	sourceLimiter *sourceLimiter                // limits underlays and handshakes of each client IP
 }
 
 var _ net.Listener = &Mux{}
@@ -75,13 +100,15 @@ func NewMux(isClinet bool) *Mux {
 	}
 	mux := &Mux{
 		isClient:    isClinet,
-		underlays:   make([]Underlay, 0),
 		chAccept:    make(chan net.Conn, sessionChanCapacity),
 		chAcceptErr: make(chan error, 1), // non-blocking
-		resolver:    &net.Resolver{},
 		done:        make(chan struct{}),
 		cleaner:     time.NewTicker(idleUnderlayTickerInterval),
+		selector:    newEndpointSelector(),
 	}
+	mux.pool = newUnderlayPool(mux.newUnderlay)
+	var resolver apicommon.DNSResolver = &net.Resolver{}
+	mux.resolver.Store(&resolver)
 
 	// Run maintenance tasks in the background.
 	go func() {
@@ -89,10 +116,12 @@ func NewMux(isClinet bool) *Mux {
 			select {
 			case <-mux.cleaner.C:
 				mux.mu.Lock()
+				mux.pool.maintain(isClinet)
 				if isClinet {
-					mux.cleanUnderlay(true)
-				} else {
-					mux.cleanUnderlay(false)
+					clientQuality.sample()
+					if mux.used && !mux.draining.Load() {
+						mux.pool.fillSpares(context.Background())
+					}
 				}
 				mux.mu.Unlock()
 			case <-mux.done:
@@ -108,9 +137,17 @@ func NewMux(isClinet bool) *Mux {
 // If mux is started and new endpoints are added, mux also starts
 // to listen to those new endpoints. In that case, old endpoints
 // are not impacted.
+//
+// For a client mux, the endpoints are replaced, and new underlays
+// are dialed to the new endpoints. Existing underlays are not impacted.
 func (m *Mux) SetEndpoints(endpoints []UnderlayProperties) *Mux {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.isClient {
+		m.endpoints = endpoints
+		log.Infof("Mux now has %d endpoints", len(m.endpoints))
+		return m
+	}
 	new := m.newEndpoints(m.endpoints, endpoints)
 	if len(new) > 0 {
 		if m.used {
@@ -131,11 +168,11 @@ func (m *Mux) SetEndpoints(endpoints []UnderlayProperties) *Mux {
 	return m
 }
 
-// SetResolver updates the DNS resolver used by the mux.
+// SetResolver updates the DNS resolver used by the mux, even if mux is
+// already started. Underlays that are being created continue to use
+// the previous resolver.
 func (m *Mux) SetResolver(resolver apicommon.DNSResolver) *Mux {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.resolver = resolver
+	m.resolver.Store(&resolver)
 	log.Infof("Mux DNS resolver has been updated")
 	return m
 }
@@ -165,8 +202,522 @@ func (m *Mux) SetClientMultiplexFactor(n int) *Mux {
 	if m.used {
 		panic("Can't set multiplex factor after mux is used")
 	}
-	m.multiplexFactor = mathext.Max(n, 0)
-	log.Infof("Mux multiplexing factor is set to %d", m.multiplexFactor)
+	m.pool.setMultiplexFactor(n)
+	log.Infof("Mux multiplexing factor is set to %d", mathext.Max(n, 0))
+	return m
+}
+
+// SetClientPathMTUDiscovery enables path MTU discovery of UDP underlays.
+// The server must support path MTU discovery.
+// It panics if the mux is already started.
+func (m *Mux) SetClientPathMTUDiscovery(enable bool) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set path MTU discovery in server mux")
+	}
+	if m.used {
+		panic("Can't set path MTU discovery after mux is used")
+	}
+	m.pathMTUDisc = enable
+	if enable {
+		log.Infof("Mux path MTU discovery is enabled")
+	}
+	return m
+}
+
+// SetUDPOffload uses UDP segmentation offload to send packets, and
+// generic receive offload to receive packets in the server listeners,
+// if they are supported by the kernel. It only has effect on Linux.
+// It panics if the mux is already started.
+func (m *Mux) SetUDPOffload(enable bool) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set UDP offload after mux is used")
+	}
+	m.udpOffload = enable
+	if enable {
+		log.Infof("Mux UDP offload is enabled")
+	}
+	return m
+}
+
+// SetIntegrityDiagnostic appends a checksum to the padding of UDP packets,
+// and tells whether a packet that fails to decrypt is corrupted in transit
+// or encrypted with a different key. The result is shown by metrics.
+// The peer doesn't need to support it, but decryption failures can only be
+// diagnosed if the peer also enables it.
+// It panics if the mux is already started.
+func (m *Mux) SetIntegrityDiagnostic(enable bool) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set integrity diagnostic after mux is used")
+	}
+	m.integrity = enable
+	if enable {
+		log.Infof("Mux integrity diagnostic is enabled")
+	}
+	return m
+}
+
+// SetClientSessionMigration allows UDP sessions to continue from a new
+// UDP connection after the network is changed. The server must support
+// session migration. It panics if the mux is already started.
+func (m *Mux) SetClientSessionMigration(enable bool) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set session migration in server mux")
+	}
+	if m.used {
+		panic("Can't set session migration after mux is used")
+	}
+	m.sessionOpts.migration = enable
+	if enable {
+		log.Infof("Mux session migration is enabled")
+	}
+	return m
+}
+
+// SetClientHybridKeyExchange derives the keys of each session with
+// a X25519 and ML-KEM-768 hybrid key exchange in addition to the password,
+// so the recorded traffic can't be decrypted by a quantum computer later.
+// The server must support hybrid key exchange. It doesn't apply to
+// datagram sessions. It panics if the binary doesn't support ML-KEM.
+func (m *Mux) SetClientHybridKeyExchange(enable bool) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set hybrid key exchange in server mux")
+	}
+	if m.used {
+		panic("Can't set hybrid key exchange after mux is used")
+	}
+	if enable && !cipher.HybridKeyExchangeSupported {
+		panic(cipher.ErrHybridKeyExchangeNotSupported.Error())
+	}
+	m.sessionOpts.hybridKeyExchange = enable
+	if enable {
+		log.Infof("Mux hybrid key exchange is enabled")
+	}
+	return m
+}
+
+// SetClientRekey rotates the keys of a TCP underlay after the given number
+// of bytes are sent and received, or after the given interval, without
+// reconnecting. 0 means the limit is not used. The server must support
+// rekey. It doesn't apply to UDP underlays, which use the keys of the
+// current time. It panics if the mux is already started.
+func (m *Mux) SetClientRekey(bytes int64, interval time.Duration) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set rekey in server mux")
+	}
+	if m.used {
+		panic("Can't set rekey after mux is used")
+	}
+	m.rekeyBytes = mathext.Max(bytes, 0)
+	m.rekeyInterval = mathext.Max(interval, 0)
+	if m.rekeyBytes > 0 || m.rekeyInterval > 0 {
+		log.Infof("Mux rekey is set to every %d bytes or %v", m.rekeyBytes, m.rekeyInterval)
+	}
+	return m
+}
+
+// SetClientCredentialHandler accepts the next password of the user pushed
+// by the proxy server. After the next password is received, new underlays
+// use it, and the handler is called with the next hashed password, so the
+// application can store it. The handler must not block. The server must
+// support credential push. It panics if the mux is already started.
+func (m *Mux) SetClientCredentialHandler(handler func(userName string, hashedPassword []byte)) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set credential handler in server mux")
+	}
+	if m.used {
+		panic("Can't set credential handler after mux is used")
+	}
+	m.credHandler = handler
+	m.sessionOpts.credentialPush = handler != nil
+	if handler != nil {
+		log.Infof("Mux accepts the next password pushed by the server")
+	}
+	return m
+}
+
+// SetClientIdleTimeout closes a session if no data is sent or received
+// within sessionTimeout, and closes an underlay without sessions after
+// it is not used for underlayTimeout. Keeping idle underlays longer
+// saves handshakes when the application opens connections in bursts.
+// If sessionTimeout is 0, idle sessions are not closed. If underlayTimeout
+// is 0, the default value between 60 seconds and 120 seconds is used.
+// It panics if the mux is already started.
+func (m *Mux) SetClientIdleTimeout(sessionTimeout, underlayTimeout time.Duration) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set idle timeout in server mux")
+	}
+	if m.used {
+		panic("Can't set idle timeout after mux is used")
+	}
+	m.sessionOpts.idleTimeout = mathext.Max(sessionTimeout, 0)
+	m.underlayIdleTime = mathext.Max(underlayTimeout, 0)
+	if m.sessionOpts.idleTimeout > 0 || m.underlayIdleTime > 0 {
+		log.Infof("Mux idle timeout is set to %v for sessions and %v for underlays", m.sessionOpts.idleTimeout, m.underlayIdleTime)
+	}
+	return m
+}
+
+// SetClientUnderlayAffinity makes sessions dialed with the same affinity key
+// within ttl reuse the same underlay, as long as the underlay is still
+// usable. It has no effect if multiplexing is turned off.
+// It panics if the mux is already started.
+func (m *Mux) SetClientUnderlayAffinity(ttl time.Duration) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set underlay affinity in server mux")
+	}
+	if m.used {
+		panic("Can't set underlay affinity after mux is used")
+	}
+	m.pool.setAffinityTTL(ttl)
+	if ttl > 0 {
+		log.Infof("Mux underlay affinity is set to %v", ttl)
+	}
+	return m
+}
+
+// SetClientUnderlayPool limits the number of sessions per underlay and
+// the lifetime of a underlay, and keeps spare underlays dialed in advance.
+// It panics if the mux is already started.
+func (m *Mux) SetClientUnderlayPool(config UnderlayPoolConfig) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set underlay pool in server mux")
+	}
+	if m.used {
+		panic("Can't set underlay pool after mux is used")
+	}
+	m.pool.setConfig(config)
+	if config != (UnderlayPoolConfig{}) {
+		log.Infof("Mux underlay pool is set to %+v", config)
+	}
+	return m
+}
+
+// SetClientDialer replaces the dialer used to create the network
+// connections of underlays. If the dialer is nil, the standard library
+// is used. It panics if the mux is already started.
+func (m *Mux) SetClientDialer(dialer apicommon.Dialer) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set dialer in server mux")
+	}
+	if m.used {
+		panic("Can't set dialer after mux is used")
+	}
+	m.dialer = dialer
+	if dialer != nil {
+		log.Infof("Mux dialer is set to %T", dialer)
+	}
+	return m
+}
+
+// SetClientEndpointPolicy decides how the endpoint of a new underlay is
+// selected, if there are multiple server endpoints. Regardless of the
+// policy, endpoints that fail to handshake are not used for a while.
+// It panics if the mux is already started.
+func (m *Mux) SetClientEndpointPolicy(policy EndpointPolicy) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isClient {
+		panic("Can't set endpoint policy in server mux")
+	}
+	if m.used {
+		panic("Can't set endpoint policy after mux is used")
+	}
+	m.selector.setPolicy(policy)
+	log.Infof("Mux endpoint policy is set to %v", policy)
+	return m
+}
+
+// UnderlayPool returns the underlay pool of the mux.
+func (m *Mux) UnderlayPool() *UnderlayPool {
+	return m.pool
+}
+
+// SetWebSocket carries all the stream underlays with WebSocket.
+// It panics if the mux is already started.
+func (m *Mux) SetWebSocket(config *WebSocketConfig) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set WebSocket after mux is used")
+	}
+	m.websocket = config
+	if config != nil {
+		log.Infof("Mux stream underlays are carried by WebSocket")
+	}
+	return m
+}
+
+// SetTLS carries all the stream underlays with TLS. Client needs to set
+// the server name, and server needs to set the certificates.
+// It can't be used together with WebSocket, which has its own TLS config.
+// It panics if the mux is already started.
+func (m *Mux) SetTLS(config *tls.Config) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set TLS after mux is used")
+	}
+	m.tlsConfig = config
+	if config != nil {
+		log.Infof("Mux stream underlays are carried by TLS")
+	}
+	return m
+}
+
+// SetFECGroupSize sets the number of data segments protected by a parity
+// segment in packet underlays. 0 disables forward error correction.
+// It panics if the mux is already started.
+func (m *Mux) SetFECGroupSize(n int) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set FEC group size after mux is used")
+	}
+	m.sessionOpts.fecGroupSize = mathext.Max(0, mathext.Min(n, MaxFECGroupSize))
+	if m.sessionOpts.fecGroupSize > 0 {
+		log.Infof("Mux FEC group size is set to %d", m.sessionOpts.fecGroupSize)
+	}
+	return m
+}
+
+// SetRetransmissionLimit sets the maximum number of transmissions of
+// a segment, and the maximum time to wait for the acknowledgement of
+// a segment in packet underlays. If a segment is not acknowledged within
+// the limit, the session is closed with RetransmissionLimitError.
+// If maxCount is 0, the default limit is used. If maxTime is 0, there
+// is no time limit. It panics if the mux is already started.
+func (m *Mux) SetRetransmissionLimit(maxCount int, maxTime time.Duration) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set retransmission limit after mux is used")
+	}
+	m.sessionOpts.txCountLimit = mathext.Max(0, mathext.Min(maxCount, MaxTxCountLimit))
+	m.sessionOpts.txTimeLimit = mathext.Max(0, maxTime)
+	if m.sessionOpts.txCountLimit > 0 || m.sessionOpts.txTimeLimit > 0 {
+		log.Infof("Mux retransmission limit is set to %d transmissions and %v", m.sessionOpts.txCountLimit, m.sessionOpts.txTimeLimit)
+	}
+	return m
+}
+
+// SetFlowControl sets the maximum send window, the maximum receive window
+// and the buffer capacity of sessions, in number of segments. Larger values
+// are needed to fill links with high bandwidth-delay product. 0 means the
+// default value is used. The buffer capacity is raised to fit the windows.
+// It panics if the mux is already started.
+func (m *Mux) SetFlowControl(sendWindow, recvWindow, bufferCapacity int) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set flow control after mux is used")
+	}
+	clamp := func(n int) int {
+		if n <= 0 {
+			return 0
+		}
+		return mathext.Max(MinFlowControlWindow, mathext.Min(n, MaxFlowControlWindow))
+	}
+	m.sessionOpts.sendWindow = clamp(sendWindow)
+	m.sessionOpts.recvWindow = clamp(recvWindow)
+	m.sessionOpts.bufferCapacity = clamp(bufferCapacity)
+	if m.sessionOpts.sendWindow > 0 || m.sessionOpts.recvWindow > 0 || m.sessionOpts.bufferCapacity > 0 {
+		// The buffers must be able to hold a full window of segments.
+		windowOrDefault := func(n int) int {
+			if n == 0 {
+				return maxWindowSize
+			}
+			return n
+		}
+		m.sessionOpts.bufferCapacity = mathext.Max(m.sessionOpts.bufferCapacity, mathext.Max(windowOrDefault(m.sessionOpts.sendWindow), windowOrDefault(m.sessionOpts.recvWindow)))
+		log.Infof("Mux flow control is set to send window %d, receive window %d and buffer capacity %d", m.sessionOpts.sendWindow, m.sessionOpts.recvWindow, m.sessionOpts.bufferCapacity)
+	}
+	return m
+}
+
+// SetBandwidth sets the bandwidth of the link in bytes per second.
+// Before the receive rate of a session is measured, the receive window
+// is sized to fill the bandwidth-delay product of this bandwidth.
+// 0 means a default rate is used. It panics if the mux is already started.
+func (m *Mux) SetBandwidth(bytesPerSecond int64) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set bandwidth after mux is used")
+	}
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	m.sessionOpts.bandwidth = bytesPerSecond
+	if bytesPerSecond > 0 {
+		log.Infof("Mux bandwidth is set to %d bytes per second", bytesPerSecond)
+	}
+	return m
+}
+
+// SetFairness sets how sessions share an underlay. It panics if the mux
+// is already started.
+func (m *Mux) SetFairness(fairness Fairness) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set fairness after mux is used")
+	}
+	clampWeight := func(n int) int {
+		return mathext.Max(0, mathext.Min(n, MaxPriorityWeight))
+	}
+	if fairness.MaxUnsentBytes > 0 {
+		fairness.MaxUnsentBytes = mathext.Max(MinMaxUnsentBytes, mathext.Min(fairness.MaxUnsentBytes, MaxMaxUnsentBytes))
+	} else {
+		fairness.MaxUnsentBytes = 0
+	}
+	fairness.HighPriorityWeight = clampWeight(fairness.HighPriorityWeight)
+	fairness.LowPriorityWeight = clampWeight(fairness.LowPriorityWeight)
+	m.fairness = fairness
+	if fairness != (Fairness{}) {
+		log.Infof("Mux fairness is set to max unsent bytes %d, high priority weight %d and low priority weight %d", fairness.maxUnsentBytes(), fairness.weight(SessionPriorityHigh), fairness.weight(SessionPriorityLow))
+	}
+	return m
+}
+
+// SetServerSessionEngine sets how the server runs sessions. The event
+// driven engine uses the given number of workers, or a default number
+// if it is 0. It panics if the mux is a client or is already started.
+func (m *Mux) SetServerSessionEngine(engine SessionEngine, workers int) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClient {
+		panic("Can't set session engine in client mux")
+	}
+	if m.used {
+		panic("Can't set session engine after mux is used")
+	}
+	if engine != SessionEngineGoroutine && engine != SessionEngineEventDriven {
+		panic(fmt.Sprintf("Unsupported session engine %v", engine))
+	}
+	m.sessionEngine = engine
+	m.engineWorkers = mathext.Max(0, mathext.Min(workers, MaxEventEngineWorkers))
+	if engine != SessionEngineGoroutine {
+		log.Infof("Mux session engine is set to %v", engine)
+	}
+	return m
+}
+
+// SetServerDecoy serves the TCP connections that fail authentication in
+// the first segment with the decoy, instead of closing them. It doesn't
+// apply to WebSocket. It panics if the mux is a client or is already started.
+func (m *Mux) SetServerDecoy(decoy *Decoy) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClient {
+		panic("Can't set decoy in client mux")
+	}
+	if m.used {
+		panic("Can't set decoy after mux is used")
+	}
+	if !decoy.enabled() {
+		decoy = nil
+	}
+	m.decoy = decoy
+	if decoy != nil {
+		if decoy.Address != "" {
+			log.Infof("Mux decoy is set to %s", decoy.Address)
+		} else {
+			log.Infof("Mux decoy is set to a static page of %d bytes", len(decoy.Page))
+		}
+	}
+	return m
+}
+
+// SetServerSourceLimit limits the underlays and handshakes of each client
+// IP address. It panics if the mux is a client or is already started.
+func (m *Mux) SetServerSourceLimit(limit SourceLimit) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClient {
+		panic("Can't set source limit in client mux")
+	}
+	if m.used {
+		panic("Can't set source limit after mux is used")
+	}
+	m.sourceLimiter = newSourceLimiter(limit)
+	if m.sourceLimiter != nil {
+		l := m.sourceLimiter.limit
+		log.Infof("Mux source limit is set to %d underlays and %d handshakes per minute, penalty %v up to %v", l.MaxUnderlays, l.MaxHandshakesPerMinute, l.Penalty, l.MaxPenalty)
+	}
+	return m
+}
+
+// SetCongestionControl sets the congestion control algorithm used by
+// sessions in packet underlays. If the algorithm is not supported,
+// the default algorithm is used. It panics if the mux is already started.
+func (m *Mux) SetCongestionControl(algorithm congestion.Algorithm) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.used {
+		panic("Can't set congestion control after mux is used")
+	}
+	switch algorithm {
+	case "", congestion.AlgorithmBBR, congestion.AlgorithmCubic:
+	default:
+		log.Warnf("Congestion control algorithm %q is not supported. Use %s.", algorithm, congestion.DefaultAlgorithm)
+		algorithm = congestion.DefaultAlgorithm
+	}
+	m.sessionOpts.congestionControl = algorithm
+	if algorithm != "" {
+		log.Infof("Mux congestion control is set to %s", algorithm)
+	}
+	return m
+}
+
+// SetReplayCache sets the capacity, the expire interval and the eviction
+// policy of replay caches. 0 means the default value is used. The replay
+// caches are shared by all the server muxes in the process, and the
+// existing entries are removed if the setting is changed.
+// It panics if the mux is already started.
+func (m *Mux) SetReplayCache(capacity int, expireInterval time.Duration, policy replay.Policy) *Mux {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.isClient {
+		panic("Can't set replay cache in client mux")
+	}
+	if m.used {
+		panic("Can't set replay cache after mux is used")
+	}
+	if capacity <= 0 {
+		capacity = DefaultReplayCacheCapacity
+	}
+	capacity = mathext.Max(MinReplayCacheCapacity, mathext.Min(capacity, MaxReplayCacheCapacity))
+	if expireInterval <= 0 {
+		expireInterval = DefaultReplayCacheExpireInterval
+	}
+	packetReplayCache.Configure(capacity, expireInterval, policy)
+	streamReplayCache.Configure(capacity, expireInterval, policy)
+	openSessionReplayCache.Configure(mathext.Min(capacity, openSessionReplayCacheCapacity), expireInterval, policy)
+	if capacity != DefaultReplayCacheCapacity || expireInterval != DefaultReplayCacheExpireInterval || policy != replay.PolicyRotate {
+		log.Infof("Mux replay cache is set to capacity %d, expire interval %v and policy %v", capacity, expireInterval, policy)
+	}
 	return m
 }
 
@@ -177,14 +728,20 @@ func (m *Mux) SetServerUsers(users map[string]*appctlpb.User) *Mux {
 	if m.isClient {
 		panic("Can't set server users in client mux")
 	}
+	prev := m.users
 	m.users = users
+	m.userSet.Store(newServerUserSet(users))
 	if m.used {
-		// Update the users in UDPUnderlay.
-		// Don't update TCPUnderlay and Session, so existing connections still work.
-		for _, underlay := range m.underlays {
-			if udpUnderlay, ok := underlay.(*PacketUnderlay); ok {
-				udpUnderlay.users = m.users
+		// New connections use the new users immediately. Close the existing
+		// connections of users that are removed, disabled, or no longer
+		// accept the password. Connections of other users still work.
+		revoked := revokedUsers(prev, users)
+		if len(revoked) > 0 {
+			n := 0
+			for _, underlay := range m.pool.all() {
+				n += revokeUsers(underlay, revoked)
 			}
+			log.Infof("Closed %d sessions of %d revoked users", n, len(revoked))
 		}
 	}
 	return m
@@ -215,14 +772,60 @@ func (m *Mux) Close() error {
 	} else {
 		log.Infof("Closing server multiplexer")
 	}
-	for _, underlay := range m.underlays {
-		underlay.Close()
+	m.pool.closeAll()
+	if m.engine != nil {
+		m.engine.close()
 	}
-	m.underlays = make([]Underlay, 0)
 	close(m.done)
 	return nil
 }
 
+// Drain stops accepting new underlays and sessions, waits for the
+// existing sessions to finish until the context is done, then closes
+// the underlays. It returns the context error if some sessions are
+// closed by force. The mux still needs to be closed after this returns.
+//
+// A client mux stops dialing new sessions. Underlays created by
+// DialContextWithConn are not drained.
+func (m *Mux) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	select {
+	case <-m.done:
+		m.mu.Unlock()
+		return nil
+	default:
+	}
+	if m.isClient {
+		log.Infof("Draining client multiplexer")
+	} else {
+		log.Infof("Draining server multiplexer")
+	}
+	m.draining.Store(true)
+	listeners := m.listeners
+	m.listeners = nil
+	m.mu.Unlock()
+
+	for _, l := range listeners {
+		if err := l.Close(); err != nil {
+			log.Debugf("Close listener failed: %v", err)
+		}
+	}
+	underlays := m.pool.all()
+	errs := make(chan error, len(underlays))
+	for _, underlay := range underlays {
+		go func(underlay Underlay) {
+			errs <- underlay.Drain(ctx)
+		}(underlay)
+	}
+	var err error
+	for range underlays {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
 // Addr is not supported by Mux.
 func (m *Mux) Addr() net.Addr {
 	return common.NilNetAddr()
@@ -250,6 +853,9 @@ func (m *Mux) Start() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.used = true
+	if m.sessionEngine == SessionEngineEventDriven && m.engine == nil {
+		m.engine = newEventEngine(m.engineWorkers)
+	}
 	for _, p := range m.endpoints {
 		go m.acceptUnderlayLoop(context.Background(), p)
 	}
@@ -259,6 +865,30 @@ func (m *Mux) Start() error {
 // DialContext returns a network connection for the client to consume.
 // The connection may be a session established from an existing underlay.
 func (m *Mux) DialContext(ctx context.Context) (net.Conn, error) {
+	return m.DialContextWithAffinity(ctx, "")
+}
+
+// DialContextWithAffinity is similar to DialContext. If underlay affinity
+// is enabled, sessions dialed with the same non-empty key are put to the
+// same underlay, for example connections to the same destination.
+func (m *Mux) DialContextWithAffinity(ctx context.Context, key string) (net.Conn, error) {
+	return m.dial(ctx, key, false)
+}
+
+// DialDatagramContext returns a session that asks the server to exchange
+// unreliable datagrams in addition to the byte stream. The request is sent
+// with the first write to the session. Datagrams are only available if
+// the session uses UDP transport and the server supports datagram mode,
+// which is reported by DatagramEnabled() after the server responds.
+func (m *Mux) DialDatagramContext(ctx context.Context) (DatagramConn, error) {
+	conn, err := m.dial(ctx, "", true)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*Session), nil
+}
+
+func (m *Mux) dial(ctx context.Context, key string, datagram bool) (net.Conn, error) {
 	if !m.isClient {
 		return nil, stderror.ErrInvalidOperation
 	}
@@ -276,41 +906,61 @@ func (m *Mux) DialContext(ctx context.Context) (net.Conn, error) {
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.draining.Load() {
+		return nil, fmt.Errorf("mux is draining: %w", stderror.ErrNotRunning)
+	}
 	m.used = true
-	var err error
 
 	// Try to find a underlay for the session.
-	m.cleanUnderlay(true)
-	underlay := m.maybePickExistingUnderlay()
-	if underlay == nil {
-		underlay, err = m.newUnderlay(ctx)
-		if err != nil {
-			return nil, err
-		}
-		log.Debugf("Created new underlay %v", underlay)
-	} else {
-		log.Debugf("Reusing existing underlay %v", underlay)
-	}
-
-	if ok := underlay.Scheduler().IncPending(); !ok {
-		// This underlay can't be used. Create a new one.
-		underlay, err = m.newUnderlay(ctx)
-		if err != nil {
-			return nil, err
-		}
-		log.Debugf("Created yet another new underlay %v", underlay)
-		underlay.Scheduler().IncPending()
+	underlay, err := m.pool.get(ctx, key)
+	if err != nil {
+		return nil, err
 	}
 	defer func() {
 		underlay.Scheduler().DecPending()
 	}()
 	session := NewSession(mrand.Uint32(), true, underlay.MTU(), m.users)
+	if err := session.applyOptions(m.sessionOpts); err != nil {
+		return nil, fmt.Errorf("applyOptions() failed: %w", err)
+	}
+	session.datagram = datagram
+	session.setPriority(sessionPriorityFromContext(ctx))
+	m.trackHandshake(session, underlay)
+	m.trackSessionClose(session, underlay)
 	if err := underlay.AddSession(session, nil); err != nil {
 		return nil, fmt.Errorf("AddSession() failed: %v", err)
 	}
+	if m.sessionOpts.hybridKeyExchange && !datagram {
+		return newHybridClientConn(session, underlay.(credentialUnderlay).credential()), nil
+	}
 	return session, nil
 }
 
+// HybridKeyExchange returns true if the sessions dialed by the client mux
+// use hybrid key exchange. Datagram sessions don't use it.
+func (m *Mux) HybridKeyExchange() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessionOpts.hybridKeyExchange
+}
+
+// ConnectionQuality returns the recent quality of the connections
+// to the proxy server. It is only meaningful in the client mux.
+func (m *Mux) ConnectionQuality() ConnectionQuality {
+	return clientQuality.quality()
+}
+
+// ServerInfo returns the version and features of the proxy server learned
+// from the latest opened session. It returns false if no session is opened.
+// It is only meaningful in the client mux.
+func (m *Mux) ServerInfo() (ServerInfo, bool) {
+	info := clientServerInfo.Load()
+	if info == nil {
+		return ServerInfo{}, false
+	}
+	return *info, true
+}
+
 // DialContextWithConn returns a network connection for the client to consume.
 // The connection is a session established from a underlay constructed from
 // the given connection.
@@ -324,9 +974,12 @@ func (m *Mux) DialContextWithConn(ctx context.Context, conn net.Conn) (net.Conn,
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.draining.Load() {
+		return nil, fmt.Errorf("mux is draining: %w", stderror.ErrNotRunning)
+	}
 	m.used = true
 
-	m.cleanUnderlay(true)
+	m.pool.maintain(true)
 	block, err := cipher.BlockCipherFromPassword(m.password, false)
 	if err != nil {
 		return nil, fmt.Errorf("cipher.BlockCipherFromPassword() failed: %v", err)
@@ -339,17 +992,32 @@ func (m *Mux) DialContextWithConn(ctx context.Context, conn net.Conn) (net.Conn,
 	if err != nil {
 		return nil, fmt.Errorf("NewTCPUnderlayWithConn() failed: %v", err)
 	}
+	underlay.setFairness(m.fairness)
+	underlay.setCredential(m.password, m.credentialHandler())
+	if m.rekeyBytes > 0 || m.rekeyInterval > 0 {
+		underlay.enableRekey(m.rekeyBytes, m.rekeyInterval)
+	}
+	event := m.underlayEvent(underlay, nil)
+	m.notifyUnderlayConnected(event)
 	go func() {
+		defer crash.Recover("underlay event loop", func() { underlay.Close() })
 		err := underlay.RunEventLoop(ctx)
 		if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
 			log.Debugf("%v RunEventLoop(): %v", underlay, err)
 		}
 		underlay.Close()
+		event.Reason = err
+		m.notifyUnderlayClosed(event)
 	}()
 	session := NewSession(mrand.Uint32(), true, underlay.MTU(), m.users)
+	session.setPriority(sessionPriorityFromContext(ctx))
+	m.trackSessionClose(session, underlay)
 	if err := underlay.AddSession(session, nil); err != nil {
 		return nil, fmt.Errorf("AddSession() failed: %v", err)
 	}
+	if m.sessionOpts.hybridKeyExchange {
+		return newHybridClientConn(session, underlay.credential()), nil
+	}
 	return session, nil
 }
 
@@ -357,24 +1025,29 @@ func (m *Mux) DialContextWithConn(ctx context.Context, conn net.Conn) (net.Conn,
 // session info in a table format.
 func (m *Mux) ExportSessionInfoTable() []string {
 	header := SessionInfo{
-		ID:         "Session ID",
-		Protocol:   "Protocol",
-		LocalAddr:  "Local",
-		RemoteAddr: "Remote",
-		State:      "State",
-		RecvQBuf:   "Recv Q+Buf",
-		SendQBuf:   "Send Q+Buf",
-		LastRecv:   "Last Recv",
-		LastSend:   "Last Send",
+		ID:          "Session ID",
+		Protocol:    "Protocol",
+		LocalAddr:   "Local",
+		RemoteAddr:  "Remote",
+		State:       "State",
+		RecvQBuf:    "Recv Q+Buf",
+		SendQBuf:    "Send Q+Buf",
+		LastRecv:    "Last Recv",
+		LastSend:    "Last Send",
+		RTT:         "RTT (Dev)",
+		Retransmit:  "Retransmit",
+		InFlight:    "In Flight",
+		User:        "User",
+		Destination: "Destination",
+		Age:         "Age",
+		Bytes:       "Bytes In/Out",
 	}
 	info := []SessionInfo{header}
-	m.mu.Lock()
-	for _, underlay := range m.underlays {
+	for _, underlay := range m.pool.all() {
 		info = append(info, underlay.Sessions()...)
 	}
-	m.mu.Unlock()
 
-	var idLen, protocolLen, localAddrLen, remoteAddrLen, stateLen, recvQLen, sendQLen, lastRecvLen, lastSendLen int
+	var idLen, protocolLen, localAddrLen, remoteAddrLen, stateLen, recvQLen, sendQLen, lastRecvLen, lastSendLen, rttLen, retransmitLen, inFlightLen, userLen, destinationLen, ageLen, bytesLen int
 	for _, si := range info {
 		idLen = mathext.Max(idLen, len(si.ID))
 		protocolLen = mathext.Max(protocolLen, len(si.Protocol))
@@ -385,6 +1058,13 @@ func (m *Mux) ExportSessionInfoTable() []string {
 		sendQLen = mathext.Max(sendQLen, len(si.SendQBuf))
 		lastRecvLen = mathext.Max(lastRecvLen, len(si.LastRecv))
 		lastSendLen = mathext.Max(lastSendLen, len(si.LastSend))
+		rttLen = mathext.Max(rttLen, len(si.RTT))
+		retransmitLen = mathext.Max(retransmitLen, len(si.Retransmit))
+		inFlightLen = mathext.Max(inFlightLen, len(si.InFlight))
+		userLen = mathext.Max(userLen, len(si.User))
+		destinationLen = mathext.Max(destinationLen, len(si.Destination))
+		ageLen = mathext.Max(ageLen, len(si.Age))
+		bytesLen = mathext.Max(bytesLen, len(si.Bytes))
 	}
 	res := make([]string, 0)
 	delim := "  "
@@ -399,6 +1079,13 @@ func (m *Mux) ExportSessionInfoTable() []string {
 		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", sendQLen)+"s", si.SendQBuf))
 		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", lastRecvLen)+"s", si.LastRecv))
 		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", lastSendLen)+"s", si.LastSend))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", rttLen)+"s", si.RTT))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", retransmitLen)+"s", si.Retransmit))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", inFlightLen)+"s", si.InFlight))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", userLen)+"s", si.User))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", destinationLen)+"s", si.Destination))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", ageLen)+"s", si.Age))
+		line = append(line, fmt.Sprintf("%-"+fmt.Sprintf("%d", bytesLen)+"s", si.Bytes))
 		res = append(res, strings.Join(line, delim))
 	}
 	return res
@@ -433,21 +1120,29 @@ func (m *Mux) acceptUnderlayLoop(ctx context.Context, properties UnderlayPropert
 	network := properties.LocalAddr().Network()
 	switch network {
 	case "tcp", "tcp4", "tcp6":
-		tcpAddr, err := apicommon.ResolveTCPAddr(m.resolver, "tcp", laddr)
+		tcpAddr, err := apicommon.ResolveTCPAddr(*m.resolver.Load(), "tcp", laddr)
 		if err != nil {
 			m.chAcceptErr <- fmt.Errorf("ResolveTCPAddr() failed: %w", err)
 			return
 		}
-		rawListener, err := net.ListenTCP("tcp", tcpAddr)
-		if err != nil {
-			m.chAcceptErr <- fmt.Errorf("ListenTCP() failed: %w", err)
-			return
+		rawListener := common.TakeSystemdTCPListener(tcpAddr)
+		if rawListener != nil {
+			log.Infof("Use TCP listener %v from systemd socket activation", rawListener.Addr())
+		} else {
+			rawListener, err = net.ListenTCP("tcp", tcpAddr)
+			if err != nil {
+				m.chAcceptErr <- fmt.Errorf("ListenTCP() failed: %w", err)
+				return
+			}
 		}
 		if err := sockopts.ApplyTCPControls(rawListener); err != nil {
 			m.chAcceptErr <- fmt.Errorf("ApplyTCPControls() failed: %w", err)
 			return
 		}
 		log.Infof("Mux is listening to endpoint %s %s", network, laddr)
+		m.mu.Lock()
+		m.listeners = append(m.listeners, rawListener)
+		m.mu.Unlock()
 
 		acceptLoopDone := ctx.Done()
 		for {
@@ -455,54 +1150,72 @@ func (m *Mux) acceptUnderlayLoop(ctx context.Context, properties UnderlayPropert
 			case <-acceptLoopDone:
 				return
 			default:
-				underlay, err := m.acceptTCPUnderlay(rawListener, properties)
+				rawConn, err := rawListener.Accept()
 				if err != nil {
-					m.chAcceptErr <- err
+					if m.draining.Load() {
+						// The listener is closed by Drain.
+						return
+					}
+					m.chAcceptErr <- fmt.Errorf("Accept() underlay failed: %w", err)
 					return
 				}
-				log.Debugf("Created new server underlay %v", underlay)
-				m.mu.Lock()
-				m.underlays = append(m.underlays, underlay)
-				m.cleanUnderlay(false)
-				m.mu.Unlock()
-				UnderlayPassiveOpens.Add(1)
-				currEst := UnderlayCurrEstablished.Add(1)
-				maxConn := UnderlayMaxConn.Load()
-				if currEst > maxConn {
-					UnderlayMaxConn.Store(currEst)
-				}
-
-				go func(ctx context.Context, underlay Underlay) {
-					err := underlay.RunEventLoop(ctx)
-					if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
-						log.Debugf("%v RunEventLoop(): %v", underlay, err)
+				if ip := sourceIP(rawConn.RemoteAddr()); ip != nil && m.sourceLimiter != nil {
+					if !m.sourceLimiter.acquireUnderlay(ip, time.Now()) {
+						log.Debugf("Reject underlay from %v: source limit exceeded", rawConn.RemoteAddr())
+						rawConn.Close()
+						continue
 					}
-					underlay.Close()
-				}(ctx, underlay)
-
-				go func(ctx context.Context, underlay Underlay) {
-					for {
-						conn, err := underlay.Accept()
+					rawConn = &sourceConn{
+						Conn:    rawConn,
+						release: func() { m.sourceLimiter.releaseUnderlay(ip) },
+					}
+				}
+				if m.websocket != nil {
+					// Don't block the accept loop during the handshake.
+					go func(rawConn net.Conn) {
+						underlay, err := m.serverWrapWebSocketConn(rawConn, properties.MTU())
 						if err != nil {
-							if !stderror.IsEOF(err) && !stderror.IsClosed(err) {
-								log.Debugf("%v Accept(): %v", underlay, err)
-							}
-							break
+							UnderlayWebSocketHandshakeErrors.Add(1)
+							log.Debugf("Accept WebSocket underlay from %v failed: %v", rawConn.RemoteAddr(), err)
+							rawConn.Close()
+							return
 						}
-						select {
-						case m.chAccept <- conn:
-						case <-ctx.Done():
+						m.serveTCPUnderlay(ctx, underlay)
+					}(rawConn)
+					continue
+				}
+				if m.tlsConfig != nil {
+					// Don't block the accept loop during the handshake.
+					go func(rawConn net.Conn) {
+						tlsConn, err := serverTLSHandshake(rawConn, m.tlsConfig)
+						if err != nil {
+							UnderlayTLSHandshakeErrors.Add(1)
+							log.Debugf("Accept TLS underlay from %v failed: %v", rawConn.RemoteAddr(), err)
+							rawConn.Close()
 							return
 						}
-					}
-				}(ctx, underlay)
+						underlay := m.serverWrapTCPConn(tlsConn, properties.MTU())
+						underlay.decoy = m.decoy
+						m.serveTCPUnderlay(ctx, &TLSUnderlay{StreamUnderlay: underlay})
+					}(rawConn)
+					continue
+				}
+				underlay := m.serverWrapTCPConn(rawConn, properties.MTU())
+				underlay.decoy = m.decoy
+				m.serveTCPUnderlay(ctx, underlay)
 			}
 		}
 	case "udp", "udp4", "udp6":
-		conn, err := net.ListenUDP(network, properties.LocalAddr().(*net.UDPAddr))
-		if err != nil {
-			m.chAcceptErr <- fmt.Errorf("ListenUDP() failed: %w", err)
-			return
+		conn := common.TakeSystemdUDPConn(properties.LocalAddr().(*net.UDPAddr))
+		if conn != nil {
+			log.Infof("Use UDP socket %v from systemd socket activation", conn.LocalAddr())
+		} else {
+			var err error
+			conn, err = net.ListenUDP(network, properties.LocalAddr().(*net.UDPAddr))
+			if err != nil {
+				m.chAcceptErr <- fmt.Errorf("ListenUDP() failed: %w", err)
+				return
+			}
 		}
 		if err := sockopts.ApplyUDPControls(conn); err != nil {
 			m.chAcceptErr <- fmt.Errorf("ApplyUDPControls() failed: %w", err)
@@ -511,15 +1224,18 @@ func (m *Mux) acceptUnderlayLoop(ctx context.Context, properties UnderlayPropert
 		log.Infof("Mux is listening to endpoint %s %s", network, laddr)
 		underlay := &PacketUnderlay{
 			baseUnderlay:      *newBaseUnderlay(false, properties.MTU()),
-			conn:              conn,
+			conn:              newBatchPacketConn(conn, m.udpOffload),
 			idleSessionTicker: time.NewTicker(idleSessionTickerInterval),
-			users:             m.users,
+			readTimeout:       readOneSegmentTimeout,
+			userSet:           &m.userSet,
+			sourceLimiter:     m.sourceLimiter,
+			sessionOpts:       m.sessionOpts,
+			integrityDiag:     m.integrity,
 		}
-		log.Infof("Created new server underlay %v", underlay)
-		m.mu.Lock()
-		m.underlays = append(m.underlays, underlay)
-		m.cleanUnderlay(false)
-		m.mu.Unlock()
+		underlay.setFairness(m.fairness)
+		underlay.setSessionEngine(m.engine)
+		log.WithFields(underlayLogFields(underlay)).Infof("Created new server underlay %v", underlay)
+		m.pool.add(underlay)
 		UnderlayPassiveOpens.Add(1)
 		currEst := UnderlayCurrEstablished.Add(1)
 		maxConn := UnderlayMaxConn.Load()
@@ -528,6 +1244,7 @@ func (m *Mux) acceptUnderlayLoop(ctx context.Context, properties UnderlayPropert
 		}
 
 		go func(ctx context.Context, underlay Underlay) {
+			defer crash.Recover("underlay event loop", func() { underlay.Close() })
 			err := underlay.RunEventLoop(ctx)
 			if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
 				log.Debugf("%v RunEventLoop(): %v", underlay, err)
@@ -556,53 +1273,101 @@ func (m *Mux) acceptUnderlayLoop(ctx context.Context, properties UnderlayPropert
 	}
 }
 
-func (m *Mux) acceptTCPUnderlay(rawListener net.Listener, properties UnderlayProperties) (Underlay, error) {
-	rawConn, err := rawListener.Accept()
-	if err != nil {
-		return nil, fmt.Errorf("Accept() underlay failed: %w", err)
+// serveTCPUnderlay registers a new server underlay and runs it in the background.
+func (m *Mux) serveTCPUnderlay(ctx context.Context, underlay Underlay) {
+	underlay.(fairnessUnderlay).setFairness(m.fairness)
+	underlay.(engineUnderlay).setSessionEngine(m.engine)
+	log.WithFields(underlayLogFields(underlay)).Debugf("Created new server underlay %v", underlay)
+	m.pool.add(underlay)
+	UnderlayPassiveOpens.Add(1)
+	currEst := UnderlayCurrEstablished.Add(1)
+	maxConn := UnderlayMaxConn.Load()
+	if currEst > maxConn {
+		UnderlayMaxConn.Store(currEst)
 	}
-	return m.serverWrapTCPConn(rawConn, properties.MTU(), m.users), nil
-}
 
-func (m *Mux) serverWrapTCPConn(rawConn net.Conn, mtu int, users map[string]*appctlpb.User) Underlay {
-	var err error
-	var blocks []cipher.BlockCipher
-	for _, user := range users {
-		var password []byte
-		password, err = hex.DecodeString(user.GetHashedPassword())
-		if err != nil {
-			log.Debugf("Unable to decode hashed password %q from user %q", user.GetHashedPassword(), user.GetName())
-			continue
-		}
-		if len(password) == 0 {
-			password = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
-		}
-		blocksFromUser, err := cipher.BlockCipherListFromPassword(password, false)
-		if err != nil {
-			log.Debugf("Unable to create block cipher of user %q", user.GetName())
-			continue
+	go func(ctx context.Context, underlay Underlay) {
+		defer crash.Recover("underlay event loop", func() { underlay.Close() })
+		err := underlay.RunEventLoop(ctx)
+		if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
+			log.Debugf("%v RunEventLoop(): %v", underlay, err)
 		}
-		for _, block := range blocksFromUser {
-			block.SetBlockContext(cipher.BlockContext{
-				UserName: user.GetName(),
-			})
+		underlay.Close()
+	}(ctx, underlay)
+
+	go func(ctx context.Context, underlay Underlay) {
+		for {
+			conn, err := underlay.Accept()
+			if err != nil {
+				if !stderror.IsEOF(err) && !stderror.IsClosed(err) {
+					log.Debugf("%v Accept(): %v", underlay, err)
+				}
+				break
+			}
+			select {
+			case m.chAccept <- conn:
+			case <-ctx.Done():
+				return
+			}
 		}
-		blocks = append(blocks, blocksFromUser...)
+	}(ctx, underlay)
+}
+
+// serverWrapWebSocketConn finishes the TLS and WebSocket handshake,
+// and then creates a WebSocketUnderlay.
+func (m *Mux) serverWrapWebSocketConn(rawConn net.Conn, mtu int) (*WebSocketUnderlay, error) {
+	conn := rawConn
+	if m.websocket.TLSConfig != nil {
+		conn = tls.Server(rawConn, m.websocket.TLSConfig)
+	}
+	wsConn, err := serverWebSocketHandshake(conn, m.websocket.path())
+	if err != nil {
+		return nil, err
+	}
+	return &WebSocketUnderlay{StreamUnderlay: m.serverWrapTCPConn(wsConn, mtu)}, nil
+}
+
+func (m *Mux) serverWrapTCPConn(rawConn net.Conn, mtu int) *StreamUnderlay {
+	var candidates []cipher.BlockCipher
+	if set := m.userSet.Load(); set != nil {
+		candidates = set.candidates
 	}
 	return &StreamUnderlay{
 		baseUnderlay: *newBaseUnderlay(false, mtu),
 		conn:         rawConn,
-		candidates:   blocks,
-		users:        users,
+		candidates:   candidates,
+		userSet:      &m.userSet,
 	}
 }
 
 // newUnderlay returns a new underlay.
 // This method MUST be called only when holding the mu lock.
 func (m *Mux) newUnderlay(ctx context.Context) (Underlay, error) {
+	filter := endpointFilterFromContext(ctx)
+	endpoints := make([]UnderlayProperties, 0, len(m.endpoints))
+	for _, p := range m.endpoints {
+		if filter.match(p) {
+			endpoints = append(endpoints, p)
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no server endpoint matches %+v", filter)
+	}
+	return m.dialEndpoint(ctx, m.selector.pick(endpoints))
+}
+
+// dialEndpoint returns a new underlay connected to the endpoint.
+// The settings of the mux must not be changed when this is called.
+func (m *Mux) dialEndpoint(ctx context.Context, p UnderlayProperties) (Underlay, error) {
 	var underlay Underlay
-	i := mrand.Intn(len(m.endpoints))
-	p := m.endpoints[i]
+	resolver := *m.resolver.Load()
+	key := endpointKey(p)
+	raddr := p.RemoteAddr()
+	port := 0
+	if r, ok := raddr.(*PortRangeAddr); ok {
+		port = m.selector.pickPort(key, r)
+		raddr = r.addr(port)
+	}
 	switch p.TransportProtocol() {
 	case common.StreamTransport:
 		block, err := cipher.BlockCipherFromPassword(m.password, false)
@@ -612,9 +1377,30 @@ func (m *Mux) newUnderlay(ctx context.Context) (Underlay, error) {
 		block.SetBlockContext(cipher.BlockContext{
 			UserName: m.username,
 		})
-		underlay, err = NewStreamUnderlay(ctx, p.RemoteAddr().Network(), "", p.RemoteAddr().String(), p.MTU(), block, m.resolver)
-		if err != nil {
-			return nil, fmt.Errorf("NewTCPUnderlay() failed: %v", err)
+		if m.websocket != nil {
+			underlay, err = NewWebSocketUnderlay(ctx, raddr.Network(), "", raddr.String(), p.MTU(), block, resolver, m.dialer, m.websocket)
+			if err != nil {
+				UnderlayDialErrors.Add(1)
+				m.onDialFailure(p, port, err)
+				return nil, fmt.Errorf("NewWebSocketUnderlay() failed: %v", err)
+			}
+		} else if m.tlsConfig != nil {
+			underlay, err = NewTLSUnderlay(ctx, raddr.Network(), "", raddr.String(), p.MTU(), block, resolver, m.dialer, m.tlsConfig)
+			if err != nil {
+				UnderlayDialErrors.Add(1)
+				m.onDialFailure(p, port, err)
+				return nil, fmt.Errorf("NewTLSUnderlay() failed: %v", err)
+			}
+		} else {
+			underlay, err = NewStreamUnderlay(ctx, raddr.Network(), "", raddr.String(), p.MTU(), block, resolver, m.dialer)
+			if err != nil {
+				UnderlayDialErrors.Add(1)
+				m.onDialFailure(p, port, err)
+				return nil, fmt.Errorf("NewTCPUnderlay() failed: %v", err)
+			}
+		}
+		if m.rekeyBytes > 0 || m.rekeyInterval > 0 {
+			underlay.(rekeyUnderlay).enableRekey(m.rekeyBytes, m.rekeyInterval)
 		}
 	case common.PacketTransport:
 		block, err := cipher.BlockCipherFromPassword(m.password, true)
@@ -624,84 +1410,50 @@ func (m *Mux) newUnderlay(ctx context.Context) (Underlay, error) {
 		block.SetBlockContext(cipher.BlockContext{
 			UserName: m.username,
 		})
-		underlay, err = NewPacketUnderlay(ctx, p.RemoteAddr().Network(), "", p.RemoteAddr().String(), p.MTU(), block, m.resolver)
+		underlay, err = NewPacketUnderlay(ctx, raddr.Network(), "", raddr.String(), p.MTU(), block, resolver, m.dialer)
 		if err != nil {
+			UnderlayDialErrors.Add(1)
+			m.onDialFailure(p, port, err)
 			return nil, fmt.Errorf("NewUDPUnderlay() failed: %v", err)
 		}
+		if m.pathMTUDisc {
+			if err := underlay.(*PacketUnderlay).enablePathMTUDiscovery(); err != nil {
+				log.Warnf("Unable to enable path MTU discovery: %v", err)
+			}
+		}
+		if m.sessionOpts.migration {
+			underlay.(*PacketUnderlay).enableSessionMigration()
+		}
+		if m.udpOffload {
+			underlay.(*PacketUnderlay).enableUDPOffload()
+		}
+		underlay.(*PacketUnderlay).integrityDiag = m.integrity
 	default:
 		return nil, fmt.Errorf("unsupport transport protocol %v", p.TransportProtocol())
 	}
-	m.underlays = append(m.underlays, underlay)
+	if m.underlayIdleTime > 0 {
+		underlay.Scheduler().SetIdleTime(m.underlayIdleTime)
+	}
+	underlay.(fairnessUnderlay).setFairness(m.fairness)
+	underlay.(endpointUnderlay).setEndpoint(key, port)
+	underlay.(credentialUnderlay).setCredential(m.password, m.credentialHandler())
 	UnderlayActiveOpens.Add(1)
 	currEst := UnderlayCurrEstablished.Add(1)
 	maxConn := UnderlayMaxConn.Load()
 	if currEst > maxConn {
 		UnderlayMaxConn.Store(currEst)
 	}
+	event := m.underlayEvent(underlay, nil)
+	m.notifyUnderlayConnected(event)
 	go func() {
+		defer crash.Recover("underlay event loop", func() { underlay.Close() })
 		err := underlay.RunEventLoop(ctx)
 		if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
 			log.Debugf("%v RunEventLoop(): %v", underlay, err)
 		}
 		underlay.Close()
+		event.Reason = err
+		m.notifyUnderlayClosed(event)
 	}()
 	return underlay, nil
 }
-
-// maybePickExistingUnderlay returns either an existing underlay that
-// can be used by a session, or nil. In the later case a new underlay
-// should be created.
-// This method MUST be called only when holding the mu lock.
-func (m *Mux) maybePickExistingUnderlay() Underlay {
-	active := make([]Underlay, 0)
-	for _, underlay := range m.underlays {
-		select {
-		case <-underlay.Done():
-		default:
-			if !underlay.Scheduler().IsDisabled() {
-				active = append(active, underlay)
-			}
-		}
-	}
-
-	if m.multiplexFactor > 0 {
-		reuseUnderlayFactor := len(active) * m.multiplexFactor
-		n := mrand.Intn(reuseUnderlayFactor + 1)
-		if n < reuseUnderlayFactor {
-			return active[n/m.multiplexFactor]
-		}
-	}
-	return nil
-}
-
-// cleanUnderlay removes closed underlays.
-// This method MUST be called only when holding the mu lock.
-func (m *Mux) cleanUnderlay(alsoDisableIdleUnderlay bool) {
-	remaining := make([]Underlay, 0)
-	disable := 0
-	close := 0
-	for _, underlay := range m.underlays {
-		select {
-		case <-underlay.Done():
-		default:
-			if alsoDisableIdleUnderlay && underlay.SessionCount() == 0 {
-				if underlay.Scheduler().TryDisable() {
-					disable++
-				}
-			}
-			if underlay.SessionCount() == 0 && underlay.Scheduler().Idle() {
-				underlay.Close()
-				close++
-			} else {
-				remaining = append(remaining, underlay)
-			}
-		}
-	}
-	m.underlays = remaining
-	if disable > 0 {
-		log.Debugf("Mux disabled scheduling from %d underlays", disable)
-	}
-	if close > 0 {
-		log.Debugf("Mux cleaned %d underlays", close)
-	}
-}
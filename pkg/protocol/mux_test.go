@@ -23,14 +23,18 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	apicommon "github.com/enfein/mieru/v3/apis/common"
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/congestion"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/testtool"
+	"github.com/enfein/mieru/v3/pkg/version"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -41,12 +45,23 @@ var users = map[string]*appctlpb.User{
 	},
 }
 
-func runClient(t *testing.T, properties UnderlayProperties, username, password []byte, concurrent int) {
-	clientMux := NewMux(true).
+// roundsPerFeatureTest is the number of round trips of each connection
+// in tests of a single feature. It is smaller than the number of round
+// trips in the underlay tests to keep the tests fast.
+const roundsPerFeatureTest = 10
+
+func newTestClientMux(properties UnderlayProperties, username, password []byte) *Mux {
+	return NewMux(true).
 		SetClientUserNamePassword(string(username), cipher.HashPassword(password, username)).
 		SetClientMultiplexFactor(2).
 		SetEndpoints([]UnderlayProperties{properties})
+}
+
+func runClient(t *testing.T, properties UnderlayProperties, username, password []byte, concurrent int) {
+	runClientMux(t, newTestClientMux(properties, username, password), concurrent, 100)
+}
 
+func runClientMux(t *testing.T, clientMux *Mux, concurrent, rounds int) {
 	dialCtx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelFunc()
 
@@ -61,7 +76,7 @@ func runClient(t *testing.T, properties UnderlayProperties, username, password [
 				return
 			}
 			defer conn.Close()
-			for i := 0; i < 100; i++ {
+			for i := 0; i < rounds; i++ {
 				payloadSize := mrand.Intn(maxPDU) + 1
 				payload := testtool.TestHelperGenRot13Input(payloadSize)
 				if _, err := conn.Write(payload); err != nil {
@@ -87,6 +102,17 @@ func runClient(t *testing.T, properties UnderlayProperties, username, password [
 	}
 	wg.Wait()
 
+	// Wait for the responses of the closed sessions, so the server
+	// doesn't write to the underlays after the client mux is closed.
+	deadline := time.Now().Add(time.Second)
+	for len(clientMux.ExportSessionInfoTable()) > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if info, ok := clientMux.ServerInfo(); !ok || info.Version != version.AppVersion || info.Features != AllServerFeatures {
+		t.Errorf("ServerInfo() = %+v, %v, want version %s and features %v", info, ok, version.AppVersion, AllServerFeatures)
+	}
+
 	if err := clientMux.Close(); err != nil {
 		t.Errorf("Close client mux failed: %v", err)
 	}
@@ -220,6 +246,154 @@ func TestIPv6UDPUnderlay(t *testing.T) {
 	}
 }
 
+func TestUDPUnderlayWithCubic(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetCongestionControl(congestion.AlgorithmCubic)
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetCongestionControl(congestion.AlgorithmCubic)
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestUDPUnderlayWithOffload(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetUDPOffload(true)
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetUDPOffload(true)
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+type countingDialer struct {
+	apicommon.NetDialer
+	dials  atomic.Int32
+	listen atomic.Int32
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials.Add(1)
+	return d.NetDialer.DialContext(ctx, network, address)
+}
+
+func (d *countingDialer) ListenPacket(ctx context.Context, network, address string) (net.PacketConn, error) {
+	d.listen.Add(1)
+	return d.NetDialer.ListenPacket(ctx, network, address)
+}
+
+func TestClientDialer(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	tcpPort, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	udpPort, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{
+			NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: tcpPort}, nil),
+			NewUnderlayProperties(1400, common.PacketTransport, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: udpPort}, nil),
+		})
+	testServer := testtool.NewTestHelperServer()
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	for _, properties := range []UnderlayProperties{
+		NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: tcpPort}),
+		NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: udpPort}),
+	} {
+		dialer := &countingDialer{}
+		clientMux := NewMux(true).
+			SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+			SetClientMultiplexFactor(2).
+			SetClientDialer(dialer).
+			SetEndpoints([]UnderlayProperties{properties})
+		runClientMux(t, clientMux, 2, roundsPerFeatureTest)
+		if properties.TransportProtocol() == common.StreamTransport {
+			if dialer.dials.Load() == 0 || dialer.listen.Load() != 0 {
+				t.Errorf("got %d dials and %d listens, want dials only", dialer.dials.Load(), dialer.listen.Load())
+			}
+		} else {
+			if dialer.listen.Load() == 0 || dialer.dials.Load() != 0 {
+				t.Errorf("got %d dials and %d listens, want listens only", dialer.dials.Load(), dialer.listen.Load())
+			}
+		}
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
 func TestNewEndpoints(t *testing.T) {
 	cases := []struct {
 		old []UnderlayProperties
@@ -270,3 +444,614 @@ func TestNewEndpoints(t *testing.T) {
 		}
 	}
 }
+
+func TestClientSetEndpointsAfterUsed(t *testing.T) {
+	old := []UnderlayProperties{
+		NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 8964}),
+	}
+	new := []UnderlayProperties{
+		NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("192.0.2.2"), Port: 8964}),
+	}
+	mux := NewMux(true).SetEndpoints(old)
+	mux.used = true
+	mux.SetEndpoints(new)
+	if !reflect.DeepEqual(mux.endpoints, new) {
+		t.Errorf("got endpoints %v, want %v", mux.endpoints, new)
+	}
+}
+
+func TestUnderlayAffinity(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1500, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1500, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(1).
+		SetClientUnderlayAffinity(time.Minute).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	// Create a few underlays.
+	for i := 0; i < 8; i++ {
+		conn, err := clientMux.DialContext(context.Background())
+		if err != nil {
+			t.Fatalf("DialContext() failed: %v", err)
+		}
+		defer conn.Close()
+	}
+
+	var underlay Underlay
+	for i := 0; i < 10; i++ {
+		conn, err := clientMux.DialContextWithAffinity(context.Background(), "example.com:443")
+		if err != nil {
+			t.Fatalf("DialContextWithAffinity() failed: %v", err)
+		}
+		if underlay == nil {
+			underlay = conn.(*Session).conn
+		} else if conn.(*Session).conn != underlay {
+			t.Errorf("session %d is not put to the same underlay", i)
+		}
+		conn.Close()
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestMuxDrain(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	echo := func(conn net.Conn) error {
+		payload := testtool.TestHelperGenRot13Input(1024)
+		if _, err := conn.Write(payload); err != nil {
+			return err
+		}
+		resp := make([]byte, len(payload))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		defer conn.SetReadDeadline(time.Time{})
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	if err := echo(conn); err != nil {
+		t.Fatalf("echo before drain failed: %v", err)
+	}
+
+	drainErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		drainErr <- serverMux.Drain(ctx)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	// New sessions are not accepted.
+	dialCtx, cancelFunc := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFunc()
+	if newConn, err := clientMux.DialContext(dialCtx); err == nil {
+		if err := echo(newConn); err == nil {
+			t.Errorf("new session is accepted while draining")
+		}
+		newConn.Close()
+	}
+
+	// The existing session still works.
+	if err := echo(conn); err != nil {
+		t.Errorf("echo while draining failed: %v", err)
+	}
+	select {
+	case err := <-drainErr:
+		t.Fatalf("Drain() returned before the session is closed: %v", err)
+	default:
+	}
+
+	conn.Close()
+	select {
+	case err := <-drainErr:
+		if err != nil {
+			t.Errorf("Drain() failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Drain() didn't return after the session is closed")
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestMuxDrainDeadline(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	closed := UnderlayDrainClosedSessions.Load()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := serverMux.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Drain() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := UnderlayDrainClosedSessions.Load() - closed; got < 1 {
+		t.Errorf("got %d sessions closed by drain, want at least 1", got)
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestClientMuxDrain(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	defer serverMux.Close()
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	payload := testtool.TestHelperGenRot13Input(1024)
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, len(payload))); err != nil {
+		t.Fatalf("ReadFull() failed: %v", err)
+	}
+
+	drainErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		drainErr <- clientMux.Drain(ctx)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	// New sessions are not dialed.
+	if _, err := clientMux.DialContext(context.Background()); err == nil {
+		t.Errorf("new session is dialed while draining")
+	}
+
+	// The existing session still works.
+	if _, err := conn.Write(payload); err != nil {
+		t.Errorf("Write() while draining failed: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, len(payload))); err != nil {
+		t.Errorf("ReadFull() while draining failed: %v", err)
+	}
+	select {
+	case err := <-drainErr:
+		t.Fatalf("Drain() returned before the session is closed: %v", err)
+	default:
+	}
+
+	conn.Close()
+	select {
+	case err := <-drainErr:
+		if err != nil {
+			t.Errorf("Drain() failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Drain() didn't return after the session is closed")
+	}
+	for _, underlay := range clientMux.UnderlayPool().all() {
+		select {
+		case <-underlay.Done():
+		default:
+			t.Errorf("%v is not closed after drain", underlay)
+		}
+	}
+}
+
+func TestHybridKeyExchange(t *testing.T) {
+	if !cipher.HybridKeyExchangeSupported {
+		t.Skip("hybrid key exchange is not supported")
+	}
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	testcases := []struct {
+		name      string
+		transport common.TransportProtocol
+	}{
+		{"TCP", common.StreamTransport},
+		{"UDP", common.PacketTransport},
+	}
+	for _, tc := range testcases {
+		transport := tc.transport
+		t.Run(tc.name, func(t *testing.T) {
+			var port int
+			var err error
+			var serverAddr, clientAddr net.Addr
+			if transport == common.StreamTransport {
+				port, err = common.UnusedTCPPort()
+				serverAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+			} else {
+				port, err = common.UnusedUDPPort()
+				serverAddr = &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+			}
+			if err != nil {
+				t.Fatalf("failed to find an unused port: %v", err)
+			}
+			clientAddr = serverAddr
+			serverProperties := NewUnderlayProperties(1400, transport, serverAddr, nil)
+			serverMux := NewMux(false).
+				SetServerUsers(users).
+				SetEndpoints([]UnderlayProperties{serverProperties})
+			testServer := testtool.NewTestHelperServer()
+
+			if err := serverMux.Start(); err != nil {
+				t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			go func() {
+				if err := testServer.Serve(serverMux); err != nil {
+					t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+				}
+			}()
+			defer testServer.Close()
+			time.Sleep(100 * time.Millisecond)
+
+			exchanges := SessionHybridKeyExchanges.Load()
+			failures := SessionHybridKeyExchangeErrors.Load()
+			clientProperties := NewUnderlayProperties(1400, transport, nil, clientAddr)
+			clientMux := NewMux(true).
+				SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+				SetClientMultiplexFactor(2).
+				SetClientHybridKeyExchange(true).
+				SetEndpoints([]UnderlayProperties{clientProperties})
+			runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+			// Each session does the key exchange in the client and the server.
+			if got := SessionHybridKeyExchanges.Load() - exchanges; got != 8 {
+				t.Errorf("got %d hybrid key exchanges, want 8", got)
+			}
+			if got := SessionHybridKeyExchangeErrors.Load() - failures; got != 0 {
+				t.Errorf("got %d hybrid key exchange errors, want 0", got)
+			}
+			if err := serverMux.Close(); err != nil {
+				t.Errorf("Server mux close failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestRekey(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	rekeys := UnderlayRekeys.Load()
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, serverAddr)
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetClientRekey(64*1024, 0).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	// The client and the server switch the send key in each rekey.
+	got := UnderlayRekeys.Load() - rekeys
+	if got < 2 {
+		t.Errorf("got %d rekeys, want at least 2", got)
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestCredentialPush(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	serverUsers := map[string]*appctlpb.User{
+		"xiaochitang": {
+			Name:         proto.String("xiaochitang"),
+			Password:     proto.String("kuiranbudong"),
+			NextPassword: proto.String("xinmimalaile"),
+		},
+	}
+	nextPassword := cipher.HashPassword([]byte("xinmimalaile"), []byte("xiaochitang"))
+	testcases := []struct {
+		name      string
+		transport common.TransportProtocol
+	}{
+		{"TCP", common.StreamTransport},
+		{"UDP", common.PacketTransport},
+	}
+	for _, tc := range testcases {
+		transport := tc.transport
+		t.Run(tc.name, func(t *testing.T) {
+			var port int
+			var err error
+			var serverAddr net.Addr
+			if transport == common.StreamTransport {
+				port, err = common.UnusedTCPPort()
+				serverAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+			} else {
+				port, err = common.UnusedUDPPort()
+				serverAddr = &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+			}
+			if err != nil {
+				t.Fatalf("failed to find an unused port: %v", err)
+			}
+			serverMux := NewMux(false).
+				SetServerUsers(serverUsers).
+				SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, transport, serverAddr, nil)})
+			testServer := testtool.NewTestHelperServer()
+
+			if err := serverMux.Start(); err != nil {
+				t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			go func() {
+				if err := testServer.Serve(serverMux); err != nil {
+					t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+				}
+			}()
+			defer testServer.Close()
+			time.Sleep(100 * time.Millisecond)
+
+			// The client with the current password receives the next password.
+			var mu sync.Mutex
+			var renewed [][]byte
+			clientProperties := NewUnderlayProperties(1400, transport, nil, serverAddr)
+			clientMux := NewMux(true).
+				SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+				SetClientMultiplexFactor(2).
+				SetClientCredentialHandler(func(userName string, hashedPassword []byte) {
+					mu.Lock()
+					defer mu.Unlock()
+					if userName != "xiaochitang" {
+						t.Errorf("got user name %q, want %q", userName, "xiaochitang")
+					}
+					renewed = append(renewed, hashedPassword)
+				}).
+				SetEndpoints([]UnderlayProperties{clientProperties})
+			runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+			mu.Lock()
+			if len(renewed) != 1 || !bytes.Equal(renewed[0], nextPassword) {
+				t.Errorf("got renewed passwords %v, want [%v]", renewed, nextPassword)
+			}
+			mu.Unlock()
+
+			// The client with the next password doesn't receive it again.
+			pushes := SessionCredentialPushes.Load()
+			clientMux = NewMux(true).
+				SetClientUserNamePassword("xiaochitang", nextPassword).
+				SetClientMultiplexFactor(2).
+				SetClientCredentialHandler(func(userName string, hashedPassword []byte) {
+					t.Errorf("credential handler is called with the next password")
+				}).
+				SetEndpoints([]UnderlayProperties{clientProperties})
+			runClientMux(t, clientMux, 2, roundsPerFeatureTest)
+			if got := SessionCredentialPushes.Load() - pushes; got != 0 {
+				t.Errorf("got %d credential pushes, want 0", got)
+			}
+			if err := serverMux.Close(); err != nil {
+				t.Errorf("Server mux close failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetServerUsersAfterStart(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	testcases := []struct {
+		name      string
+		transport common.TransportProtocol
+	}{
+		{"TCP", common.StreamTransport},
+		{"UDP", common.PacketTransport},
+	}
+	for _, tc := range testcases {
+		transport := tc.transport
+		t.Run(tc.name, func(t *testing.T) {
+			var port int
+			var err error
+			var serverAddr net.Addr
+			if transport == common.StreamTransport {
+				port, err = common.UnusedTCPPort()
+				serverAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+			} else {
+				port, err = common.UnusedUDPPort()
+				serverAddr = &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+			}
+			if err != nil {
+				t.Fatalf("failed to find an unused port: %v", err)
+			}
+			serverMux := NewMux(false).
+				SetServerUsers(users).
+				SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, transport, serverAddr, nil)})
+			testServer := testtool.NewTestHelperServer()
+
+			if err := serverMux.Start(); err != nil {
+				t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			go func() {
+				if err := testServer.Serve(serverMux); err != nil {
+					t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+				}
+			}()
+			defer testServer.Close()
+			time.Sleep(100 * time.Millisecond)
+
+			// A new user can connect without restarting the server.
+			serverMux.SetServerUsers(map[string]*appctlpb.User{
+				"xiaochitang": users["xiaochitang"],
+				"dahuangya": {
+					Name:     proto.String("dahuangya"),
+					Password: proto.String("guagua"),
+				},
+			})
+			clientProperties := NewUnderlayProperties(1400, transport, nil, serverAddr)
+			runClientMux(t, newTestClientMux(clientProperties, []byte("dahuangya"), []byte("guagua")), 2, roundsPerFeatureTest)
+
+			// The connection of a removed user is closed.
+			clientMux := NewMux(true).
+				SetClientUserNamePassword("dahuangya", cipher.HashPassword([]byte("guagua"), []byte("dahuangya"))).
+				SetEndpoints([]UnderlayProperties{clientProperties})
+			defer clientMux.Close()
+			dialCtx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelFunc()
+			conn, err := clientMux.DialContext(dialCtx)
+			if err != nil {
+				t.Fatalf("DialContext() failed: %v", err)
+			}
+			defer conn.Close()
+			payload := testtool.TestHelperGenRot13Input(64)
+			if _, err := conn.Write(payload); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			resp := make([]byte, len(payload))
+			if _, err := io.ReadFull(conn, resp); err != nil {
+				t.Fatalf("io.ReadFull() failed: %v", err)
+			}
+			serverMux.SetServerUsers(users)
+			time.Sleep(100 * time.Millisecond)
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			conn.Write(payload)
+			if _, err := io.ReadFull(conn, resp); err == nil {
+				t.Errorf("connection of removed user is not closed")
+			}
+			if err := serverMux.Close(); err != nil {
+				t.Errorf("Server mux close failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	mrand "math/rand"
+	"net"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/common/sockopts"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// Path MTU discovery (PMTUD) finds the largest packet that can be sent
+// to the server without fragmentation. The client sends padded probe
+// packets with the DF bit, and the server responds to each probe it
+// receives. The probe size is adjusted with a binary search.
+//
+// A MTU probe request reuses sessionStruct with the following meaning:
+//   - sessionID: probe ID
+//   - seq: the largest MTU confirmed by previous probes, or 0
+//   - payload: filler to make the packet as large as the probe size
+//
+// A MTU probe response reuses sessionStruct with the following meaning:
+//   - sessionID: probe ID of the request
+//   - seq: size of the request packet received by the server
+//
+// The server uses the confirmed MTU to send packets to the client,
+// so both directions don't need fragmentation.
+
+const (
+	// minPathMTU is the MTU that is assumed to work on every path.
+	minPathMTU = 1280
+
+	// maxPathMTU is the largest packet the underlay is able to receive.
+	maxPathMTU = 1500
+
+	// The binary search stops when the search range is smaller than this.
+	pathMTUSearchPrecision = 8
+
+	// Number of times to send a probe before it is considered lost.
+	mtuProbeAttempts = 3
+
+	// Time to wait for the response of a probe.
+	mtuProbeTimeout = time.Second
+
+	// The client runs the discovery again after this duration,
+	// because the path may change.
+	pathMTURefreshInterval = 10 * time.Minute
+
+	// The server forgets the MTU of a client after this duration.
+	peerPathMTUTimeout = 3 * pathMTURefreshInterval
+)
+
+var (
+	PMTUDProbeSent      = metrics.RegisterMetric("PMTUD", "ProbeSent", metrics.COUNTER)
+	PMTUDProbeLost      = metrics.RegisterMetric("PMTUD", "ProbeLost", metrics.COUNTER)
+	PMTUDProbeResponded = metrics.RegisterMetric("PMTUD", "ProbeResponded", metrics.COUNTER)
+)
+
+// peerPathMTU is the path MTU of a client confirmed by the client.
+type peerPathMTU struct {
+	mtu     int
+	updated time.Time
+}
+
+// enablePathMTUDiscovery sets the DF bit of packets sent by the client
+// underlay, and starts the discovery when the event loop is running.
+func (u *PacketUnderlay) enablePathMTUDiscovery() error {
+	if !u.isClient {
+		return nil
+	}
+	conn, ok := udpConn(u.packetConn())
+	if !ok {
+		return stderror.ErrUnsupported
+	}
+	if err := sockopts.ApplyDontFragment(conn); err != nil {
+		return err
+	}
+	// Start with a MTU that is safe to use before any probe succeeds.
+	u.clientPathMTU.Store(int32(minPathMTU))
+	u.mtuProbeResponses = make(chan *segment, mtuProbeAttempts)
+	u.pmtudEnabled = true
+	return nil
+}
+
+// pathMTU returns the MTU to send packets to the address.
+func (u *PacketUnderlay) pathMTU(addr net.Addr) int {
+	if u.isClient {
+		if mtu := u.clientPathMTU.Load(); mtu > 0 {
+			return int(mtu)
+		}
+		return u.mtu
+	}
+	if addr != nil {
+		if v, found := u.peerMTU.Load(addr.String()); found {
+			return v.(peerPathMTU).mtu
+		}
+	}
+	return u.mtu
+}
+
+// runPathMTUDiscovery periodically searches the path MTU until
+// the underlay is closed.
+func (u *PacketUnderlay) runPathMTUDiscovery(ctx context.Context) {
+	for {
+		mtu := u.searchPathMTU()
+		if mtu == 0 {
+			log.Warnf("%v didn't receive response of MTU probe. Server may not support path MTU discovery.", u)
+		} else {
+			if int32(mtu) != u.clientPathMTU.Swap(int32(mtu)) {
+				log.Infof("%v path MTU is set to %d", u, mtu)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.done:
+			return
+		case <-time.After(pathMTURefreshInterval):
+		}
+	}
+}
+
+// searchPathMTU returns the largest MTU confirmed by the server.
+// It returns 0 if no probe is responded.
+func (u *PacketUnderlay) searchPathMTU() int {
+	if !u.probePathMTU(minPathMTU, 0) {
+		return 0
+	}
+	low, high := minPathMTU, maxPathMTU
+	for high-low >= pathMTUSearchPrecision {
+		mid := (low + high + 1) / 2
+		if u.probePathMTU(mid, low) {
+			low = mid
+			// Use the larger MTU before the search is completed.
+			if int32(low) > u.clientPathMTU.Load() {
+				u.clientPathMTU.Store(int32(low))
+			}
+		} else {
+			high = mid - 1
+		}
+		select {
+		case <-u.done:
+			return 0
+		default:
+		}
+	}
+	// Let the server know the result.
+	u.probePathMTU(low, low)
+	return low
+}
+
+// probePathMTU returns true if a probe packet with the given size
+// is responded by the server.
+func (u *PacketUnderlay) probePathMTU(size, confirmed int) bool {
+	for i := 0; i < mtuProbeAttempts; i++ {
+		probeID := mrand.Uint32()
+		probe := &segment{
+			metadata: &sessionStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(mtuProbeRequest),
+				},
+				sessionID:  probeID,
+				seq:        uint32(confirmed),
+				payloadLen: uint16(size - packetOverhead),
+			},
+			payload:   make([]byte, size-packetOverhead),
+			transport: common.PacketTransport,
+		}
+		PMTUDProbeSent.Add(1)
+		if err := u.writeOneSegment(probe, u.serverAddr); err != nil {
+			// The probe is larger than the MTU of local network interface.
+			log.Debugf("%v failed to send MTU probe with %d bytes: %v", u, size, err)
+			PMTUDProbeLost.Add(1)
+			return false
+		}
+		if u.waitMTUProbeResponse(probeID, size) {
+			return true
+		}
+		PMTUDProbeLost.Add(1)
+	}
+	return false
+}
+
+// waitMTUProbeResponse returns true if the response of the probe
+// is received before timeout.
+func (u *PacketUnderlay) waitMTUProbeResponse(probeID uint32, size int) bool {
+	timer := time.NewTimer(mtuProbeTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case seg := <-u.mtuProbeResponses:
+			ss := seg.metadata.(*sessionStruct)
+			if ss.sessionID == probeID && int(ss.seq) == size {
+				return true
+			}
+			// This is a late response of a previous probe.
+		case <-timer.C:
+			return false
+		case <-u.done:
+			return false
+		}
+	}
+}
+
+// onMTUProbeRequest responds to the MTU probe, and records the MTU
+// confirmed by the client.
+func (u *PacketUnderlay) onMTUProbeRequest(seg *segment, remoteAddr net.Addr) error {
+	if u.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	ss := seg.metadata.(*sessionStruct)
+	if confirmed := int(ss.seq); confirmed >= minPathMTU && confirmed <= maxPathMTU {
+		u.peerMTU.Store(remoteAddr.String(), peerPathMTU{mtu: confirmed, updated: time.Now()})
+	}
+	resp := &segment{
+		metadata: &sessionStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(mtuProbeResponse),
+			},
+			sessionID: ss.sessionID,
+			seq:       uint32(packetOverhead + len(seg.payload) + int(ss.suffixLen)),
+		},
+		transport: common.PacketTransport,
+		block:     seg.block,
+	}
+	PMTUDProbeResponded.Add(1)
+	return u.writeOneSegment(resp, remoteAddr)
+}
+
+// onMTUProbeResponse passes the response to the discovery.
+func (u *PacketUnderlay) onMTUProbeResponse(seg *segment) error {
+	if !u.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	if !u.pmtudEnabled {
+		return nil
+	}
+	select {
+	case u.mtuProbeResponses <- seg:
+	default:
+		// Drop the response if nobody is waiting for it.
+	}
+	return nil
+}
+
+// cleanPeerPathMTU removes the MTU of clients that stopped probing.
+func (u *PacketUnderlay) cleanPeerPathMTU() {
+	u.peerMTU.Range(func(k, v any) bool {
+		if time.Since(v.(peerPathMTU).updated) > peerPathMTUTimeout {
+			u.peerMTU.Delete(k)
+		}
+		return true
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestUDPUnderlayWithPathMTUDiscovery(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetClientPathMTUDiscovery(true)
+	defer clientMux.Close()
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+
+	// The MTU of loopback interface is large enough for any probe.
+	clientMux.mu.Lock()
+	clientUnderlay := clientMux.pool.all()[0].(*PacketUnderlay)
+	clientMux.mu.Unlock()
+	serverMux.mu.Lock()
+	serverUnderlay := serverMux.pool.all()[0].(*PacketUnderlay)
+	serverMux.mu.Unlock()
+	want := maxPathMTU - pathMTUSearchPrecision
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		serverMTU := 0
+		serverUnderlay.peerMTU.Range(func(k, v any) bool {
+			serverMTU = v.(peerPathMTU).mtu
+			return false
+		})
+		if clientUnderlay.MTU() > want && serverMTU > want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("path MTU is not discovered, client MTU = %d", clientUnderlay.MTU())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Data is sent with the discovered MTU.
+	payload := testtool.TestHelperGenRot13Input(16 * 1024)
+	if _, err := conn.Write(payload); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	resp := make([]byte, len(payload))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
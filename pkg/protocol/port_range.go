@@ -0,0 +1,113 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	mrand "math/rand"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+const (
+	// portRangePickAttempts is the number of random ports tried to find
+	// a port that is not blacklisted.
+	portRangePickAttempts = 16
+
+	// portRangeBlacklistFailures is the number of consecutive failures
+	// to blacklist the whole port range. A single failure only
+	// blacklists the port.
+	portRangeBlacklistFailures = 3
+)
+
+// PortRangeAddr is the remote address of a proxy server endpoint that
+// listens to a range of ports. The client picks a random port from the
+// range for each new underlay, so blocking one port doesn't block
+// the whole endpoint.
+type PortRangeAddr struct {
+	// Net is "tcp" or "udp".
+	Net string
+
+	IP net.IP
+
+	// Begin and End are the first and the last port of the range.
+	Begin int
+	End   int
+}
+
+var _ net.Addr = &PortRangeAddr{}
+
+func (a *PortRangeAddr) Network() string {
+	return a.Net
+}
+
+func (a *PortRangeAddr) String() string {
+	return net.JoinHostPort(a.IP.String(), strconv.Itoa(a.Begin)+"-"+strconv.Itoa(a.End))
+}
+
+// addr returns the address of one port of the range.
+func (a *PortRangeAddr) addr(port int) net.Addr {
+	switch a.Net {
+	case "udp", "udp4", "udp6":
+		return &net.UDPAddr{IP: a.IP, Port: port}
+	default:
+		return &net.TCPAddr{IP: a.IP, Port: port}
+	}
+}
+
+// pickPort returns a random port of the port range endpoint,
+// and skips the ports that failed recently if possible.
+func (s *endpointSelector) pickPort(key string, r *PortRangeAddr) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.getHealth(key)
+	now := time.Now()
+	for port, until := range h.badPorts {
+		if !now.Before(until) {
+			delete(h.badPorts, port)
+		}
+	}
+	port := r.Begin + mrand.Intn(r.End-r.Begin+1)
+	for i := 1; i < portRangePickAttempts; i++ {
+		if _, bad := h.badPorts[port]; !bad {
+			break
+		}
+		port = r.Begin + mrand.Intn(r.End-r.Begin+1)
+	}
+	return port
+}
+
+// onPortFailure records a failed dial or handshake with one port of
+// a port range endpoint. The port is not used for a while. The whole
+// endpoint is blacklisted after consecutive failures.
+func (s *endpointSelector) onPortFailure(key string, port int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.getHealth(key)
+	if h.badPorts == nil {
+		h.badPorts = make(map[int]time.Time)
+	}
+	h.badPorts[port] = time.Now().Add(endpointBlacklistMin)
+	if h.failures+1 >= portRangeBlacklistFailures {
+		s.blacklist(key, h)
+		return
+	}
+	h.failures++
+	h.failure.Add(1)
+	log.Debugf("Port %d of proxy endpoint %s is not used for %v", port, key, endpointBlacklistMin)
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestPortRangeAddr(t *testing.T) {
+	testCases := []struct {
+		addr *PortRangeAddr
+		want string
+	}{
+		{&PortRangeAddr{Net: "udp", IP: net.ParseIP("10.1.0.1"), Begin: 20000, End: 21000}, "10.1.0.1:20000-21000"},
+		{&PortRangeAddr{Net: "tcp", IP: net.ParseIP("2001:db8::1"), Begin: 8000, End: 9000}, "[2001:db8::1]:8000-9000"},
+	}
+	for _, tc := range testCases {
+		if got := tc.addr.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+	if _, ok := testCases[0].addr.addr(20001).(*net.UDPAddr); !ok {
+		t.Errorf("addr() of a UDP port range is not a UDP address")
+	}
+	if _, ok := testCases[1].addr.addr(8001).(*net.TCPAddr); !ok {
+		t.Errorf("addr() of a TCP port range is not a TCP address")
+	}
+}
+
+func TestEndpointSelectorPortRange(t *testing.T) {
+	r := &PortRangeAddr{Net: "udp", IP: net.ParseIP("10.2.0.1"), Begin: 20000, End: 20001}
+	endpoints := []UnderlayProperties{NewUnderlayProperties(1400, common.PacketTransport, nil, r)}
+	key := endpointKey(endpoints[0])
+	s := newEndpointSelector()
+	for i := 0; i < 10; i++ {
+		if port := s.pickPort(key, r); port < r.Begin || port > r.End {
+			t.Fatalf("pickPort() = %d, want a port in [%d, %d]", port, r.Begin, r.End)
+		}
+	}
+
+	// A failed port is skipped, and the endpoint is still used.
+	s.onPortFailure(key, 20000)
+	for i := 0; i < 10; i++ {
+		if port := s.pickPort(key, r); port != 20001 {
+			t.Errorf("pickPort() = %d, want 20001", port)
+		}
+	}
+	s.onPortFailure(key, 20001)
+	if h := s.getHealth(key); !h.blacklistUntil.IsZero() {
+		t.Errorf("endpoint is blacklisted after %d port failures", h.failures)
+	}
+
+	// The endpoint is blacklisted after consecutive failures.
+	s.onPortFailure(key, 20000)
+	if h := s.getHealth(key); h.blacklistUntil.IsZero() {
+		t.Errorf("endpoint is not blacklisted after %d port failures", h.failures)
+	}
+	s.onSuccess(key, 10*time.Millisecond)
+	if h := s.getHealth(key); h.failures != 0 || !h.blacklistUntil.IsZero() {
+		t.Errorf("endpoint health is not reset after a success")
+	}
+}
+
+// unusedPortRange returns n consecutive ports that are not used.
+func unusedPortRange(t *testing.T, n int) int {
+	for i := 0; i < 10; i++ {
+		begin, err := common.UnusedUDPPort()
+		if err != nil {
+			t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+		}
+		if begin+n-1 > 65535 {
+			continue
+		}
+		ok := true
+		for port := begin; port < begin+n && ok; port++ {
+			tcp, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			if err != nil {
+				ok = false
+				break
+			}
+			tcp.Close()
+			udp, err := net.ListenPacket("udp", fmt.Sprintf("127.0.0.1:%d", port))
+			if err != nil {
+				ok = false
+				break
+			}
+			udp.Close()
+		}
+		if ok {
+			return begin
+		}
+	}
+	t.Fatalf("unable to find %d consecutive unused ports", n)
+	return 0
+}
+
+func TestPortRangeUnderlay(t *testing.T) {
+	log.SetOutputToTest(t)
+	testCases := []struct {
+		name      string
+		network   string
+		transport common.TransportProtocol
+	}{
+		{"TCP", "tcp", common.StreamTransport},
+		{"UDP", "udp", common.PacketTransport},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			begin := unusedPortRange(t, 3)
+			r := &PortRangeAddr{Net: tc.network, IP: net.ParseIP("127.0.0.1"), Begin: begin, End: begin + 2}
+			serverEndpoints := make([]UnderlayProperties, 0)
+			for port := r.Begin; port <= r.End; port++ {
+				serverEndpoints = append(serverEndpoints, NewUnderlayProperties(1400, tc.transport, r.addr(port), nil))
+			}
+			serverMux := NewMux(false).
+				SetServerUsers(users).
+				SetEndpoints(serverEndpoints)
+			testServer := testtool.NewTestHelperServer()
+			if err := serverMux.Start(); err != nil {
+				t.Fatalf("Start() failed: %v", err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			go func() {
+				if err := testServer.Serve(serverMux); err != nil {
+					t.Errorf("Serve() failed: %v", err)
+				}
+			}()
+			defer testServer.Close()
+			time.Sleep(100 * time.Millisecond)
+
+			clientProperties := NewUnderlayProperties(1400, tc.transport, nil, r)
+			runClientMux(t, newTestClientMux(clientProperties, []byte("xiaochitang"), []byte("kuiranbudong")), 4, roundsPerFeatureTest)
+			if err := serverMux.Close(); err != nil {
+				t.Errorf("Server mux close failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"fmt"
+)
+
+// SessionPriority decides how much a session can write to an underlay
+// shared with other sessions. When sessions are waiting to write,
+// a session with a higher priority sends more segments in each round.
+type SessionPriority uint8
+
+const (
+	// SessionPriorityNormal is the default priority.
+	SessionPriorityNormal SessionPriority = 0
+
+	// SessionPriorityLow is for bulk transfer that should not slow down
+	// other sessions, like downloading a large file.
+	SessionPriorityLow SessionPriority = 1
+
+	// SessionPriorityHigh is for interactive sessions that are sensitive
+	// to latency, like SSH or web browsing.
+	SessionPriorityHigh SessionPriority = 2
+)
+
+func (p SessionPriority) String() string {
+	switch p {
+	case SessionPriorityNormal:
+		return "NORMAL"
+	case SessionPriorityLow:
+		return "LOW"
+	case SessionPriorityHigh:
+		return "HIGH"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", uint8(p))
+	}
+}
+
+// weight returns the number of segments the session can send in each
+// round of egress scheduling. An unknown priority has the normal weight.
+func (p SessionPriority) weight() int {
+	switch p {
+	case SessionPriorityLow:
+		return 1
+	case SessionPriorityHigh:
+		return 4
+	default:
+		return 2
+	}
+}
+
+// sessionPriorityKey is the context key of session priority.
+type sessionPriorityKey struct{}
+
+// WithSessionPriority returns a context to dial a session with
+// the priority. The priority is sent to the proxy server, so it
+// also applies to the data sent from the server.
+func WithSessionPriority(ctx context.Context, priority SessionPriority) context.Context {
+	return context.WithValue(ctx, sessionPriorityKey{}, priority)
+}
+
+// sessionPriorityFromContext returns the session priority in the context,
+// or SessionPriorityNormal if the context doesn't have it.
+func sessionPriorityFromContext(ctx context.Context) SessionPriority {
+	if priority, ok := ctx.Value(sessionPriorityKey{}).(SessionPriority); ok {
+		return priority
+	}
+	return SessionPriorityNormal
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// qualityWeight is the weight of the latest sample when computing
+	// the moving average of loss rate and dial failure rate.
+	qualityWeight = 0.2
+)
+
+// ConnectionQuality describes the recent quality of the connections
+// from the client to the proxy server.
+type ConnectionQuality struct {
+	// Score is a number from 0 to 100. Higher is better.
+	Score int
+
+	// LossRate is the ratio of UDP segments sent again, from 0 to 1.
+	LossRate float64
+
+	// SmoothedRTT is the moving average of round trip time.
+	SmoothedRTT time.Duration
+
+	// RTTVariance is the moving average of round trip time deviation.
+	RTTVariance time.Duration
+
+	// DialFailureRate is the ratio of failed underlay dials, from 0 to 1.
+	DialFailureRate float64
+}
+
+// qualityTracker summarizes the measurements of client sessions and
+// underlays. It is sampled periodically by the client mux.
+type qualityTracker struct {
+	mu sync.Mutex
+
+	smoothedRTT     time.Duration
+	rttVariance     time.Duration
+	lossRate        float64
+	dialFailureRate float64
+	hasLossRate     bool
+	hasDialRate     bool
+
+	// Metric values at the previous sample.
+	lastSent          int64
+	lastRetransmitted int64
+	lastDials         int64
+	lastDialErrors    int64
+}
+
+var clientQuality = &qualityTracker{}
+
+// onRTT updates the round trip time with a new sample, as RFC 6298.
+func (q *qualityTracker) onRTT(sample time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.smoothedRTT == 0 {
+		q.smoothedRTT = sample
+		q.rttVariance = sample / 2
+		return
+	}
+	deviation := q.smoothedRTT - sample
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	q.rttVariance = (3*q.rttVariance + deviation) / 4
+	q.smoothedRTT = (7*q.smoothedRTT + sample) / 8
+}
+
+// sample updates the loss rate and dial failure rate with the metrics
+// collected since the previous sample.
+func (q *qualityTracker) sample() {
+	q.update(SessionSegmentsSent.Load(),
+		SessionSegmentsRetransmitted.Load(),
+		UnderlayActiveOpens.Load()+UnderlayDialErrors.Load(),
+		UnderlayDialErrors.Load()+UnderlayDead.Load())
+}
+
+// update is the same as sample, with the given metric values.
+func (q *qualityTracker) update(sent, retransmitted, dials, dialErrors int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if sent > q.lastSent {
+		rate := math.Min(float64(retransmitted-q.lastRetransmitted)/float64(sent-q.lastSent), 1)
+		q.lossRate = movingAverage(q.lossRate, rate, q.hasLossRate)
+		q.hasLossRate = true
+	}
+	if dials > q.lastDials {
+		rate := math.Min(float64(dialErrors-q.lastDialErrors)/float64(dials-q.lastDials), 1)
+		q.dialFailureRate = movingAverage(q.dialFailureRate, rate, q.hasDialRate)
+		q.hasDialRate = true
+	}
+	q.lastSent = sent
+	q.lastRetransmitted = retransmitted
+	q.lastDials = dials
+	q.lastDialErrors = dialErrors
+}
+
+// quality returns the current connection quality.
+func (q *qualityTracker) quality() ConnectionQuality {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return ConnectionQuality{
+		Score:           qualityScore(q.lossRate, q.smoothedRTT, q.rttVariance, q.dialFailureRate),
+		LossRate:        q.lossRate,
+		SmoothedRTT:     q.smoothedRTT,
+		RTTVariance:     q.rttVariance,
+		DialFailureRate: q.dialFailureRate,
+	}
+}
+
+func movingAverage(average, sample float64, initialized bool) float64 {
+	if !initialized {
+		return sample
+	}
+	return (1-qualityWeight)*average + qualityWeight*sample
+}
+
+// qualityScore computes a score from 0 to 100. Loss and failed dials
+// impact the score the most, followed by high and unstable latency.
+func qualityScore(lossRate float64, rtt, rttVariance time.Duration, dialFailureRate float64) int {
+	score := 100.0
+	score -= math.Min(lossRate*200, 50)
+	if rtt > 100*time.Millisecond {
+		score -= math.Min(float64(rtt-100*time.Millisecond)/float64(10*time.Millisecond), 20)
+	}
+	if rtt > 0 {
+		score -= math.Min(float64(rttVariance)/float64(rtt)*20, 15)
+	}
+	score -= dialFailureRate * 100
+	return int(math.Round(math.Max(score, 0)))
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQualityScore(t *testing.T) {
+	testCases := []struct {
+		name            string
+		lossRate        float64
+		rtt             time.Duration
+		rttVariance     time.Duration
+		dialFailureRate float64
+		want            int
+	}{
+		{"no measurement", 0, 0, 0, 0, 100},
+		{"good network", 0, 50 * time.Millisecond, 5 * time.Millisecond, 0, 98},
+		{"packet loss", 0.1, 50 * time.Millisecond, 0, 0, 80},
+		{"heavy packet loss", 0.5, 50 * time.Millisecond, 0, 0, 50},
+		{"high latency", 0, 300 * time.Millisecond, 0, 0, 80},
+		{"unstable latency", 0, 100 * time.Millisecond, 100 * time.Millisecond, 0, 85},
+		{"dial failures", 0, 50 * time.Millisecond, 0, 0.5, 50},
+		{"server unreachable", 0, 0, 0, 1, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := qualityScore(tc.lossRate, tc.rtt, tc.rttVariance, tc.dialFailureRate); got != tc.want {
+				t.Errorf("qualityScore() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQualityTrackerUpdate(t *testing.T) {
+	q := &qualityTracker{}
+	q.onRTT(100 * time.Millisecond)
+	q.onRTT(100 * time.Millisecond)
+	q.update(100, 10, 1, 0)
+	quality := q.quality()
+	if quality.LossRate < 0.099 || quality.LossRate > 0.101 {
+		t.Errorf("LossRate = %f, want 0.1", quality.LossRate)
+	}
+	if quality.SmoothedRTT != 100*time.Millisecond {
+		t.Errorf("SmoothedRTT = %v, want 100ms", quality.SmoothedRTT)
+	}
+
+	// Loss rate moves slowly to the recent value.
+	q.update(200, 10, 1, 0)
+	quality = q.quality()
+	if quality.LossRate < 0.079 || quality.LossRate > 0.081 {
+		t.Errorf("LossRate = %f, want 0.08", quality.LossRate)
+	}
+
+	// 2 of the 4 recent dials failed.
+	q.update(200, 10, 5, 2)
+	quality = q.quality()
+	if quality.DialFailureRate < 0.099 || quality.DialFailureRate > 0.101 {
+		t.Errorf("DialFailureRate = %f, want 0.1", quality.DialFailureRate)
+	}
+}
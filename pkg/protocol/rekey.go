@@ -0,0 +1,165 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// Rekey rotates the keys of a live stream underlay, so a long connection
+// doesn't encrypt all the data with the same key. After the client sends
+// a number of bytes, or after some time, it sends a rekey request and
+// starts to use the next key. The server switches the receive key after
+// the request, responds with a rekey response and starts to use the next
+// key. The client switches the receive key after the response.
+//
+// The next key is derived from the current key and a random salt with
+// cipher.NextBlockCipher(), and the implicit nonce continues to increase.
+//
+// A rekey request or response reuses sessionStruct with the following
+// meaning:
+//   - sessionID: 0
+//   - payload: the salt
+//
+// The client only sends rekey requests if the server advertises
+// FeatureRekey. Packet underlays don't rekey, because each packet is
+// encrypted with a key of the current time.
+
+const (
+	// rekeySaltLength is the number of bytes in a rekey salt.
+	rekeySaltLength = 32
+)
+
+var (
+	// UnderlayRekeys is the number of times underlays switched
+	// to the next key to send data.
+	UnderlayRekeys = metrics.RegisterMetric("underlay", "Rekeys", metrics.COUNTER)
+)
+
+// rekeyUnderlay is implemented by stream underlays,
+// including TLS and WebSocket underlays.
+type rekeyUnderlay interface {
+	enableRekey(bytes int64, interval time.Duration)
+}
+
+var _ rekeyUnderlay = &StreamUnderlay{}
+
+// enableRekey rotates the keys of the client underlay after the given
+// number of bytes are sent and received, or after the given interval.
+// 0 means the limit is not used.
+func (t *StreamUnderlay) enableRekey(bytes int64, interval time.Duration) {
+	if !t.isClient {
+		return
+	}
+	t.rekeyBytes = mathext.Max(bytes, 0)
+	t.rekeyInterval = mathext.Max(interval, 0)
+	t.lastRekey = time.Now()
+}
+
+// maybeRekey sends a rekey request if the limit is reached.
+// The caller must hold the egress of the underlay.
+func (t *StreamUnderlay) maybeRekey() error {
+	if !t.isClient || !t.peerRekey.Load() {
+		return nil
+	}
+	if (t.rekeyBytes <= 0 || t.rekeyCounter.Load() < t.rekeyBytes) && (t.rekeyInterval <= 0 || time.Since(t.lastRekey) < t.rekeyInterval) {
+		return nil
+	}
+	if err := t.rekey(rekeyRequest); err != nil {
+		return err
+	}
+	t.rekeyCounter.Store(0)
+	t.lastRekey = time.Now()
+	return nil
+}
+
+// rekey sends a rekey segment with the current key, then switches to the
+// next key to send data. The caller must hold the egress of the underlay.
+func (t *StreamUnderlay) rekey(p protocolType) error {
+	salt := make([]byte, rekeySaltLength)
+	if _, err := crand.Read(salt); err != nil {
+		return fmt.Errorf("unable to create rekey salt: %w", err)
+	}
+	seg := &segment{
+		metadata: &sessionStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(p),
+			},
+			payloadLen: uint16(len(salt)),
+		},
+		payload:   salt,
+		transport: common.StreamTransport,
+	}
+	if err := t.writeSegment(seg); err != nil {
+		return err
+	}
+	next, err := cipher.NextBlockCipher(t.send, salt)
+	if err != nil {
+		return fmt.Errorf("cipher.NextBlockCipher() failed: %w", err)
+	}
+	t.send = next
+	UnderlayRekeys.Add(1)
+	log.Debugf("%v switched to the next key to send data", t)
+	return nil
+}
+
+// onRekeyRequest switches to the next key to receive data,
+// and asks the client to do the same. It is only used by server.
+func (t *StreamUnderlay) onRekeyRequest(seg *segment) error {
+	if t.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	if err := t.switchRecvKey(seg); err != nil {
+		return err
+	}
+	t.egress.acquire(0, t.egressWeight(0))
+	defer t.egress.release()
+	return t.rekey(rekeyResponse)
+}
+
+// onRekeyResponse switches to the next key to receive data.
+// It is only used by client.
+func (t *StreamUnderlay) onRekeyResponse(seg *segment) error {
+	if !t.isClient {
+		return stderror.ErrInvalidOperation
+	}
+	return t.switchRecvKey(seg)
+}
+
+// switchRecvKey derives the next key to receive data from the salt
+// in the rekey segment.
+func (t *StreamUnderlay) switchRecvKey(seg *segment) error {
+	defer seg.release()
+	if len(seg.payload) != rekeySaltLength {
+		return fmt.Errorf("rekey salt size %d is unexpected", len(seg.payload))
+	}
+	next, err := cipher.NextBlockCipher(t.recv, seg.payload)
+	if err != nil {
+		return fmt.Errorf("cipher.NextBlockCipher() failed: %w", err)
+	}
+	t.recv = next
+	log.Debugf("%v switched to the next key to receive data", t)
+	return nil
+}
@@ -32,7 +32,8 @@ var (
 type ScheduleController struct {
 	pending          int // number of pending sessions going to be scheduled
 	lastScheduleTime time.Time
-	disableTime      time.Time // if set, scheduling to the underlay is disabled after this time
+	disableTime      time.Time     // if set, scheduling to the underlay is disabled after this time
+	idleTime         time.Duration // if set, it replaces scheduleIdleTime
 	mu               sync.Mutex
 }
 
@@ -57,6 +58,13 @@ func (c *ScheduleController) DecPending() {
 	c.lastScheduleTime = time.Now()
 }
 
+// Pending returns the number of pending sessions.
+func (c *ScheduleController) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending
+}
+
 // IsDisabled returns true if scheduling new sessions to the underlay is disabled.
 func (c *ScheduleController) IsDisabled() bool {
 	c.mu.Lock()
@@ -68,7 +76,26 @@ func (c *ScheduleController) IsDisabled() bool {
 func (c *ScheduleController) Idle() bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return !c.disableTime.IsZero() && time.Since(c.lastScheduleTime) > scheduleIdleTime && time.Since(c.disableTime) > scheduleIdleTime
+	idleTime := c.idleDuration()
+	return !c.disableTime.IsZero() && time.Since(c.lastScheduleTime) > idleTime && time.Since(c.disableTime) > idleTime
+}
+
+// SetIdleTime sets how long the underlay can stay without new sessions
+// before it is considered idle. If d is 0, a default value between
+// 60 seconds and 120 seconds is used.
+func (c *ScheduleController) SetIdleTime(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idleTime = d
+}
+
+// idleDuration returns the time to consider the underlay idle.
+// This method MUST be called only when holding the mu lock.
+func (c *ScheduleController) idleDuration() time.Duration {
+	if c.idleTime > 0 {
+		return c.idleTime
+	}
+	return scheduleIdleTime
 }
 
 // TryDisable tries to disable scheduling new sessions.
@@ -81,20 +108,34 @@ func (c *ScheduleController) TryDisable() (successful bool) {
 	if c.pending > 0 {
 		return false
 	}
-	if !c.lastScheduleTime.IsZero() && time.Since(c.lastScheduleTime) < scheduleIdleTime {
+	if !c.lastScheduleTime.IsZero() && time.Since(c.lastScheduleTime) < c.idleDuration() {
 		return false
 	}
 	c.disableTime = time.Now()
 	return true
 }
 
+// Disable stops scheduling new sessions to the underlay immediately.
+func (c *ScheduleController) Disable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.disableTime.IsZero() || now.Before(c.disableTime) {
+		c.disableTime = now
+	}
+}
+
 // SetRemainingTime disables the scheduler after the given duration.
 // Do nothing if the scheduler has already been disabled.
+// If the scheduler is going to be disabled, the earlier time is kept.
 func (c *ScheduleController) SetRemainingTime(d time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if d <= 0 || !c.disableTime.IsZero() {
+	if d <= 0 {
 		return
 	}
-	c.disableTime = time.Now().Add(d)
+	disableTime := time.Now().Add(d)
+	if c.disableTime.IsZero() || disableTime.Before(c.disableTime) {
+		c.disableTime = disableTime
+	}
 }
@@ -32,8 +32,11 @@ const (
 	// Maximum protocol data unit to write without split to chunks.
 	maxPDU = 32 * 1024
 
-	// Maxinum number of transmissions before marking the session as dead.
-	txCountLimit = 20
+	// Default maxinum number of transmissions before marking the session as dead.
+	defaultTxCountLimit = 20
+
+	// Maximum value of transmission count limit.
+	MaxTxCountLimit = 255
 
 	// Format to print segment TX time.
 	segmentTimeFormat = "15:04:05.999"
@@ -57,9 +60,35 @@ type segment struct {
 	transport common.TransportProtocol // transport protocol
 	ackCount  byte                     // number of acknowledges before the next transmission
 	txCount   byte                     // number of transmission times
+	firstTx   time.Time                // first transmission time
 	txTime    time.Time                // most recent transmission time
 	txTimeout time.Duration            // need to receive ACK within this duration
 	block     cipher.BlockCipher       // cipher block to encrypt or decrypt the payload
+	buf       []byte                   // pooled buffer that holds the payload, nil if the payload is not pooled
+}
+
+// release returns the pooled buffer of the payload.
+// The payload must not be used after release.
+func (s *segment) release() {
+	if s.buf == nil {
+		return
+	}
+	underlayBufferPool.Put(s.buf)
+	s.buf = nil
+	s.payload = nil
+}
+
+// detach copies the payload out of the pooled buffer,
+// so the payload is still valid after the segment is released.
+func (s *segment) detach() {
+	if s.buf == nil {
+		return
+	}
+	payload := make([]byte, len(s.payload))
+	copy(payload, s.payload)
+	underlayBufferPool.Put(s.buf)
+	s.buf = nil
+	s.payload = payload
 }
 
 // Protocol returns the protocol of the segment.
@@ -138,6 +167,7 @@ type segmentTree struct {
 	mu                sync.Mutex
 	chanEmptyEvent    chan struct{}
 	chanNotEmptyEvent chan struct{}
+	onInsert          func() // called without holding mu after a new segment is inserted, can be nil
 }
 
 func newSegmentTree(capacity int) *segmentTree {
@@ -160,10 +190,10 @@ func (t *segmentTree) Insert(seg *segment) (ok bool) {
 	t.checkSeq(seg)
 	t.checkProtocolType(seg)
 	t.mu.Lock()
-	defer t.mu.Unlock()
 
 	if t.tr.Len() >= t.cap {
 		t.notifyNotEmpty()
+		t.mu.Unlock()
 		return false
 	}
 	prev, replace := t.tr.ReplaceOrInsert(seg)
@@ -174,6 +204,10 @@ func (t *segmentTree) Insert(seg *segment) (ok bool) {
 	} else {
 		t.notifyNotEmpty()
 	}
+	t.mu.Unlock()
+	if t.onInsert != nil {
+		t.onInsert()
+	}
 	return true
 }
 
@@ -236,6 +270,35 @@ func (t *segmentTree) DeleteMinIf(si segmentIterator) (*segment, bool) {
 	return seg, shouldDelete
 }
 
+// DeleteIf removes all the items from the tree that
+// the input function returns true.
+// It returns the deleted items in ascending order.
+func (t *segmentTree) DeleteIf(si segmentIterator) []*segment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var deleted []*segment
+	t.tr.Ascend(func(seg *segment) bool {
+		if si(seg) {
+			deleted = append(deleted, seg)
+		}
+		return true
+	})
+	for _, seg := range deleted {
+		if _, ok := t.tr.Delete(seg); !ok {
+			panic("segmentTree.Delete() failed to find the segment")
+		}
+	}
+	if len(deleted) > 0 {
+		if t.tr.Len() > 0 {
+			t.notifyNotEmpty()
+		} else {
+			t.notifyEmpty()
+		}
+	}
+	return deleted
+}
+
 // DeleteAll clears all the items from the tree.
 func (t *segmentTree) DeleteAll() {
 	t.mu.Lock()
@@ -271,6 +271,42 @@ func TestSegmentTreeDeleteMinIf(t *testing.T) {
 	}
 }
 
+func TestSegmentTreeDeleteIf(t *testing.T) {
+	st := newSegmentTree(10)
+	for i := 1; i <= 5; i++ {
+		seg := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataClientToServer),
+				},
+				seq: uint32(i * 100),
+			},
+		}
+		if !st.Insert(seg) {
+			t.Fatalf("Insert segment failed")
+		}
+	}
+
+	deleted := st.DeleteIf(func(s *segment) bool {
+		seq, _ := s.Seq()
+		return seq == 200 || seq == 400
+	})
+	if len(deleted) != 2 {
+		t.Fatalf("got %d deleted segments, want %d", len(deleted), 2)
+	}
+	for i, want := range []uint32{200, 400} {
+		if seq, _ := deleted[i].Seq(); seq != want {
+			t.Errorf("deleted[%d] seq = %d, want %d", i, seq, want)
+		}
+	}
+	if st.Len() != 3 {
+		t.Errorf("got Len() = %v, want %v", st.Len(), 3)
+	}
+	if deleted := st.DeleteIf(func(s *segment) bool { return false }); len(deleted) != 0 {
+		t.Errorf("got %d deleted segments, want %d", len(deleted), 0)
+	}
+}
+
 func TestSegmentTreeAscend(t *testing.T) {
 	st := newSegmentTree(3)
 	seg1 := &segment{
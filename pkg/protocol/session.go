@@ -42,6 +42,14 @@ const (
 	minWindowSize = 16
 	maxWindowSize = segmentTreeCapacity
 
+	// MinFlowControlWindow is the minimum value of a configured
+	// flow control window or buffer capacity, in number of segments.
+	MinFlowControlWindow = minWindowSize
+
+	// MaxFlowControlWindow is the maximum value of a configured
+	// flow control window or buffer capacity, in number of segments.
+	MaxFlowControlWindow = math.MaxUint16
+
 	serverRespTimeout        = 10 * time.Second
 	sessionHeartbeatInterval = 5 * time.Second
 
@@ -51,6 +59,56 @@ const (
 	maxBackOffMultiplier     = 20.0
 )
 
+var (
+	// SessionStandaloneAcks is the number of ack-only packets sent.
+	SessionStandaloneAcks = metrics.RegisterMetric("session", "StandaloneAcks", metrics.COUNTER)
+
+	// SessionPiggybackedAcks is the number of ack-only packets not sent
+	// because the acknowledgement is carried by data segments.
+	SessionPiggybackedAcks = metrics.RegisterMetric("session", "PiggybackedAcks", metrics.COUNTER)
+
+	// SessionSelectiveAcks is the number of ack-only packets sent
+	// with selective acknowledgement ranges.
+	SessionSelectiveAcks = metrics.RegisterMetric("session", "SelectiveAcks", metrics.COUNTER)
+
+	// SessionSACKedSegments is the number of UDP segments removed from
+	// the send buffer by selective acknowledgement.
+	SessionSACKedSegments = metrics.RegisterMetric("session", "SACKedSegments", metrics.COUNTER)
+
+	// SessionIdleClosed is the number of sessions closed because
+	// no data is sent or received within the idle timeout.
+	SessionIdleClosed = metrics.RegisterMetric("session", "IdleClosed", metrics.COUNTER)
+
+	// SessionRetransmissionLimitExceeded is the number of sessions closed
+	// because a segment is not acknowledged within the retransmission limit.
+	SessionRetransmissionLimitExceeded = metrics.RegisterMetric("session", "RetransmissionLimitExceeded", metrics.COUNTER)
+
+	// SessionSegmentsSent is the number of UDP segments sent for the first time.
+	SessionSegmentsSent = metrics.RegisterMetric("session", "SegmentsSent", metrics.COUNTER)
+
+	// SessionSegmentsRetransmitted is the number of UDP segments sent again
+	// because they are lost or not acknowledged in time.
+	SessionSegmentsRetransmitted = metrics.RegisterMetric("session", "SegmentsRetransmitted", metrics.COUNTER)
+)
+
+// RetransmissionLimitError is returned by a session after a segment is not
+// acknowledged within the retransmission limit. The session is closed.
+type RetransmissionLimitError struct {
+	SessionID uint32        // ID of the broken session
+	Seq       uint32        // sequence number of the segment not acknowledged
+	TxCount   int           // number of transmissions of the segment
+	Elapsed   time.Duration // time since the first transmission of the segment
+}
+
+func (e *RetransmissionLimitError) Error() string {
+	return fmt.Sprintf("session %d is broken: segment %d is not acknowledged after %d transmissions in %v", e.SessionID, e.Seq, e.TxCount, e.Elapsed.Truncate(time.Millisecond))
+}
+
+// Unwrap returns stderror.ErrDisconnected.
+func (e *RetransmissionLimitError) Unwrap() error {
+	return stderror.ErrDisconnected
+}
+
 type sessionState byte
 
 const (
@@ -82,7 +140,7 @@ type Session struct {
 	id         uint32                    // session ID number
 	isClient   bool                      // if this session is owned by client
 	mtu        int                       // L2 maxinum transmission unit
-	remoteAddr net.Addr                  // specify remote network address, used by UDP
+	remoteAddr atomic.Pointer[net.Addr]  // specify remote network address, used by UDP
 	state      sessionState              // session state
 	status     statusCode                // session status
 	users      map[string]*appctlpb.User // all registered users
@@ -109,13 +167,69 @@ type Session struct {
 	ackOnDataRecv atomic.Bool // whether ack should be sent due to receive of new data
 	unreadBuf     []byte      // payload removed from the recvQueue that haven't been read by application
 
-	uploadBytes   metrics.Metric // number of bytes from client to server, only used by server
-	downloadBytes metrics.Metric // number of bytes from server to client, only used by server
+	uploadBytes      metrics.Metric                  // number of bytes from client to server, only used by server
+	downloadBytes    metrics.Metric                  // number of bytes from server to client, only used by server
+	uploadSegments   metrics.Metric                  // number of segments from client to server, only used by server
+	downloadSegments metrics.Metric                  // number of segments from server to client, only used by server
+	rateLimiter      atomic.Pointer[userRateLimiter] // limits the speed of the user, only used by server
 
 	rttStat             *congestion.RTTStats
 	legacysendAlgorithm *congestion.CubicSendAlgorithm
-	sendAlgorithm       *congestion.BBRSender
+	sendAlgorithm       congestion.CongestionController
 	remoteWindowSize    uint16
+	maxRecvWindowSize   int // maximum receive window advertised to the peer
+	recvRate            *receiveRateEstimator
+	smoothedRTT         atomic.Int64  // copy of smoothed round trip time for statistics
+	rttVariance         atomic.Int64  // copy of round trip time mean deviation for statistics
+	retransmissions     atomic.Uint64 // number of segments sent again
+
+	rttSamples     [rttSampleCount]atomic.Int64 // most recent round trip time samples, for debugging
+	rttSampleIndex atomic.Uint32                // number of round trip time samples recorded
+
+	idleTimeout  time.Duration // close the session if no data is sent or received within this duration, 0 to disable
+	lastActivity atomic.Int64  // last time in unix nanoseconds when data is sent or received
+
+	txCountLimit int                   // maximum number of transmissions of a segment
+	txTimeLimit  time.Duration         // maximum time to wait for the acknowledgement of a segment, 0 to disable
+	brokenErr    atomic.Pointer[error] // the reason that breaks the session
+
+	migration   bool                   // client asks the server for a resumption token
+	resumeToken atomic.Pointer[[]byte] // token to resume the session from another address, nil if not resumable
+
+	priority atomic.Uint32 // SessionPriority of the session, decided by the client
+
+	keyExchange keyExchangeMethod // decided by the client, the server may fall back to PSK
+
+	credentialPush bool // client accepts the next password pushed by the server
+
+	datagram        bool        // client asks the server to exchange datagrams
+	datagramEnabled atomic.Bool // both sides agree to exchange datagrams
+	datagrams       chan []byte // received datagrams
+
+	handshakeStart    atomic.Int64                           // unix nanoseconds when open session request is sent, only used by client
+	handshakeReported atomic.Bool                            // the handshake result has been reported
+	onHandshake       func(err error, latency time.Duration) // reports the handshake result to the client mux, can be nil
+
+	onClose     func(reason error)    // reports the close of the session to the client mux, can be nil
+	closeReason atomic.Pointer[error] // the reason that the peer closes the session
+
+	createTime   time.Time              // when the session is created
+	destination  atomic.Pointer[string] // proxy destination requested through the session, for statistics
+	bytesRead    atomic.Uint64          // number of bytes read by the application
+	bytesWritten atomic.Uint64          // number of bytes written by the application
+	segmentsRecv atomic.Uint64          // number of segments received from the underlay
+	segmentsSent atomic.Uint64          // number of segments sent to the underlay
+
+	fecGroupSize int         // number of data segments protected by a parity segment, 0 to disable
+	fecEnabled   atomic.Bool // the peer supports FEC, so parity segments can be sent
+	fecEncoder   *fecEncoder // only used by output
+	fecDecoder   *fecDecoder // only used by input
+
+	engine            *eventEngine // runs the session if not nil, otherwise the session runs its own goroutines
+	engineIn          engineTask   // input task of the event driven engine
+	engineOut         engineTask   // output task of the event driven engine
+	engineIdleClosing bool         // the output task is closing the idle session
+	engineFinished    atomic.Int32 // number of finished tasks of the event driven engine
 
 	wg    sync.WaitGroup
 	rLock sync.Mutex // serialize read from application
@@ -123,6 +237,22 @@ type Session struct {
 	sLock sync.Mutex // serialize the state transition
 }
 
+// sessionOptions are applied to the sessions created by a mux.
+type sessionOptions struct {
+	congestionControl congestion.Algorithm // empty to use the default algorithm
+	fecGroupSize      int                  // 0 to disable FEC
+	txCountLimit      int                  // 0 to use the default limit
+	sendWindow        int                  // 0 to use the default window
+	recvWindow        int                  // 0 to use the default window
+	bufferCapacity    int                  // 0 to use the default capacity
+	bandwidth         int64                // bytes per second, 0 to use initialReceiveRate
+	txTimeLimit       time.Duration        // 0 to disable
+	migration         bool                 // client only: make the session resumable
+	idleTimeout       time.Duration        // 0 to disable
+	hybridKeyExchange bool                 // client only: add hybrid key exchange to sessions
+	credentialPush    bool                 // client only: accept the next password pushed by the server
+}
+
 // Session must implement net.Conn interface.
 var _ net.Conn = &Session{}
 
@@ -157,6 +287,10 @@ func NewSession(id uint32, isClient bool, mtu int, users map[string]*appctlpb.Us
 		legacysendAlgorithm: congestion.NewCubicSendAlgorithm(minWindowSize, maxWindowSize),
 		sendAlgorithm:       congestion.NewBBRSender(fmt.Sprintf("%d", id), rttStat),
 		remoteWindowSize:    minWindowSize,
+		maxRecvWindowSize:   maxWindowSize,
+		recvRate:            newReceiveRateEstimator(initialReceiveRate),
+		txCountLimit:        defaultTxCountLimit,
+		createTime:          time.Now(),
 	}
 }
 
@@ -174,6 +308,7 @@ func (s *Session) Read(b []byte) (n int, err error) {
 	defer s.rLock.Unlock()
 	defer func() {
 		s.readDeadline = time.Time{}
+		s.bytesRead.Add(uint64(n))
 	}()
 	if log.IsLevelEnabled(log.TraceLevel) {
 		log.Tracef("%v trying to read %d bytes", s, len(b))
@@ -193,6 +328,7 @@ func (s *Session) Read(b []byte) (n int, err error) {
 		if !s.isClient && s.uploadBytes != nil {
 			s.uploadBytes.Add(int64(n))
 		}
+		s.waitUploadLimit(n)
 		return n, nil
 	}
 
@@ -217,6 +353,7 @@ func (s *Session) Read(b []byte) (n int, err error) {
 					s.unreadBuf = make([]byte, 0)
 				}
 				s.unreadBuf = append(s.unreadBuf, seg.payload...)
+				seg.release()
 			}
 			if len(s.unreadBuf) > 0 {
 				break
@@ -225,9 +362,9 @@ func (s *Session) Read(b []byte) (n int, err error) {
 			// Wait for incoming segments.
 			select {
 			case <-s.closedChan:
-				return 0, io.EOF
+				return 0, s.closedError(io.EOF)
 			case <-s.inputErr:
-				return 0, io.ErrUnexpectedEOF
+				return 0, s.closedError(io.ErrUnexpectedEOF)
 			case <-timeC:
 				return 0, stderror.ErrTimeout
 			case <-s.recvQueue.chanNotEmptyEvent:
@@ -248,24 +385,27 @@ func (s *Session) Read(b []byte) (n int, err error) {
 	if !s.isClient && s.uploadBytes != nil {
 		s.uploadBytes.Add(int64(n))
 	}
+	s.waitUploadLimit(n)
 	return n, nil
 }
 
 // Write stores the data to send queue.
 func (s *Session) Write(b []byte) (n int, err error) {
 	if s.closeRequested.Load() {
-		return 0, io.ErrClosedPipe
+		return 0, s.closedError(io.ErrClosedPipe)
 	}
 
 	if s.isStateBefore(sessionAttached, false) {
 		return 0, fmt.Errorf("%v is not ready for Write()", s)
 	}
 	if s.isStateAfter(sessionClosed, true) {
-		return 0, io.ErrClosedPipe
+		return 0, s.closedError(io.ErrClosedPipe)
 	}
 	defer func() {
 		s.writeDeadline = time.Time{}
+		s.bytesWritten.Add(uint64(n))
 	}()
+	s.touch()
 
 	if s.isClient && s.isState(sessionAttached) {
 		// Before the first write, client needs to send open session request.
@@ -281,11 +421,28 @@ func (s *Session) Write(b []byte) (n int, err error) {
 			transport: s.conn.TransportProtocol(),
 		}
 		s.nextSend++
-		if len(b) <= MaxSessionOpenPayload {
-			seg.metadata.(*sessionStruct).payloadLen = uint16(len(b))
-			seg.payload = make([]byte, len(b))
-			copy(seg.payload, b)
+		seg.metadata.(*sessionStruct).priority = uint8(s.Priority())
+		seg.metadata.(*sessionStruct).keyExchange = uint8(s.keyExchange)
+		if s.credentialPush {
+			seg.metadata.(*sessionStruct).clientFeatures |= clientFeatureCredentialPush
 		}
+		if s.conn.TransportProtocol() == common.PacketTransport {
+			seg.metadata.(*sessionStruct).clientFeatures |= clientFeatureFEC
+		}
+		if s.datagram && s.conn.TransportProtocol() == common.PacketTransport {
+			// Ask the server to exchange datagrams.
+			seg.metadata.(*sessionStruct).statusCode = uint8(statusDatagram)
+		} else if s.migration && s.conn.TransportProtocol() == common.PacketTransport {
+			// Ask the server for a resumption token.
+			seg.metadata.(*sessionStruct).statusCode = uint8(statusResumable)
+		}
+		// Attach the beginning of data to open session request,
+		// so the server can forward it without waiting for a round trip.
+		earlyDataLen := mathext.Min(len(b), MaxSessionOpenPayload)
+		seg.metadata.(*sessionStruct).payloadLen = uint16(earlyDataLen)
+		seg.payload = make([]byte, earlyDataLen)
+		copy(seg.payload, b)
+		s.handshakeStart.Store(time.Now().UnixNano())
 		if log.IsLevelEnabled(log.TraceLevel) {
 			log.Tracef("%v writing %d bytes with open session request", s, len(seg.payload))
 		}
@@ -295,17 +452,29 @@ func (s *Session) Write(b []byte) (n int, err error) {
 		} else {
 			s.oLock.Unlock()
 		}
-		if len(seg.payload) > 0 {
-			return len(seg.payload), nil
+		if earlyDataLen == len(b) {
+			return earlyDataLen, nil
+		}
+		n, err = s.writeData(b[earlyDataLen:])
+		if err != nil {
+			return earlyDataLen, err
 		}
+		return earlyDataLen + n, nil
 	}
+	return s.writeData(b)
+}
 
+// writeData splits the data into chunks and stores them to send queue.
+func (s *Session) writeData(b []byte) (n int, err error) {
 	n = len(b)
 	if log.IsLevelEnabled(log.TraceLevel) {
 		log.Tracef("%v writing %d bytes", s, n)
 	}
 	for len(b) > 0 {
 		sizeToSend := mathext.Min(len(b), maxPDU)
+		if limiter := s.rateLimiter.Load(); limiter != nil {
+			limiter.download.wait(sizeToSend, s.closedChan)
+		}
 		if _, err = s.writeChunk(b[:sizeToSend]); err != nil {
 			return 0, err
 		}
@@ -330,12 +499,16 @@ func (s *Session) LocalAddr() net.Addr {
 }
 
 func (s *Session) RemoteAddr() net.Addr {
-	if !common.IsNilNetAddr(s.remoteAddr) {
-		return s.remoteAddr
+	if addr := s.remoteAddr.Load(); addr != nil && !common.IsNilNetAddr(*addr) {
+		return *addr
 	}
 	return s.conn.RemoteAddr()
 }
 
+func (s *Session) setRemoteAddr(addr net.Addr) {
+	s.remoteAddr.Store(&addr)
+}
+
 // SetDeadline implements net.Conn.
 func (s *Session) SetDeadline(t time.Time) error {
 	s.readDeadline = t
@@ -355,16 +528,71 @@ func (s *Session) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+// UserName returns the name of user that owns this session.
+// It returns an empty string if the user is not known yet.
+func (s *Session) UserName() string {
+	block := s.block.Load()
+	if block == nil || *block == nil {
+		return ""
+	}
+	return (*block).BlockContext().UserName
+}
+
+// Destination returns the proxy destination requested through the session,
+// or an empty string if it is unknown.
+func (s *Session) Destination() string {
+	dst := s.destination.Load()
+	if dst == nil {
+		return ""
+	}
+	return *dst
+}
+
+// SetDestination records the proxy destination requested through the session.
+func (s *Session) SetDestination(dst string) {
+	s.destination.Store(&dst)
+}
+
+// logFields returns the structured log fields of the session.
+func (s *Session) logFields() log.Fields {
+	fields := log.Fields{}
+	if s.conn != nil {
+		fields = underlayLogFields(s.conn)
+		if addr := s.RemoteAddr(); !common.IsNilNetAddr(addr) {
+			fields["peer"] = addr.String()
+		}
+	}
+	fields["session"] = s.id
+	if user := s.UserName(); user != "" {
+		fields["user"] = user
+	}
+	return fields
+}
+
 // ToSessionInfo creates related SessionInfo structure.
 func (s *Session) ToSessionInfo() SessionInfo {
 	info := SessionInfo{
-		ID:         fmt.Sprintf("%d", s.id),
-		LocalAddr:  s.LocalAddr().String(),
-		RemoteAddr: s.RemoteAddr().String(),
-		State:      s.state.String(),
-		RecvQBuf:   fmt.Sprintf("%d+%d", s.recvQueue.Len(), s.recvBuf.Len()),
-		SendQBuf:   fmt.Sprintf("%d+%d", s.sendQueue.Len(), s.sendBuf.Len()),
-		LastSend:   fmt.Sprintf("%v (%d)", time.Since(s.lastTXTime).Truncate(time.Second), s.nextSend-1),
+		ID:          fmt.Sprintf("%d", s.id),
+		LocalAddr:   s.LocalAddr().String(),
+		RemoteAddr:  s.RemoteAddr().String(),
+		State:       s.state.String(),
+		RecvQBuf:    fmt.Sprintf("%d+%d", s.recvQueue.Len(), s.recvBuf.Len()),
+		SendQBuf:    fmt.Sprintf("%d+%d", s.sendQueue.Len(), s.sendBuf.Len()),
+		LastSend:    fmt.Sprintf("%v (%d)", time.Since(s.lastTXTime).Truncate(time.Second), s.nextSend-1),
+		User:        s.UserName(),
+		Destination: s.Destination(),
+		Age:         time.Since(s.createTime).Truncate(time.Second).String(),
+		Stats:       s.Stats(),
+	}
+	info.RTT = fmt.Sprintf("%v (%v)", info.Stats.SmoothedRTT.Truncate(time.Millisecond), info.Stats.RTTVariance.Truncate(time.Millisecond))
+	info.Retransmit = fmt.Sprintf("%d", info.Stats.Retransmissions)
+	info.InFlight = fmt.Sprintf("%d", info.Stats.BytesInFlight)
+	info.Bytes = fmt.Sprintf("%d/%d", info.Stats.BytesRead, info.Stats.BytesWritten)
+	if info.User == "" {
+		info.User = "-"
+	}
+	if info.Destination == "" {
+		info.Destination = "-"
 	}
 	if _, ok := s.conn.(*StreamUnderlay); ok {
 		info.Protocol = "TCP"
@@ -378,6 +606,35 @@ func (s *Session) ToSessionInfo() SessionInfo {
 	return info
 }
 
+// Priority returns the priority of the session.
+func (s *Session) Priority() SessionPriority {
+	return SessionPriority(s.priority.Load())
+}
+
+// setPriority changes the priority of the session.
+func (s *Session) setPriority(priority SessionPriority) {
+	s.priority.Store(uint32(priority))
+}
+
+// Stats returns the transmission statistics of the session.
+func (s *Session) Stats() SessionStats {
+	var bytesInFlight int64
+	s.sendBuf.Ascend(func(iter *segment) bool {
+		bytesInFlight += int64(packetOverhead + len(iter.payload))
+		return true
+	})
+	return SessionStats{
+		SmoothedRTT:     time.Duration(s.smoothedRTT.Load()),
+		RTTVariance:     time.Duration(s.rttVariance.Load()),
+		Retransmissions: s.retransmissions.Load(),
+		BytesInFlight:   bytesInFlight,
+		BytesRead:       s.bytesRead.Load(),
+		BytesWritten:    s.bytesWritten.Load(),
+		SegmentsRecv:    s.segmentsRecv.Load(),
+		SegmentsSent:    s.segmentsSent.Load(),
+	}
+}
+
 func (s *Session) isState(target sessionState) bool {
 	s.sLock.Lock()
 	defer s.sLock.Unlock()
@@ -431,7 +688,7 @@ func (s *Session) writeChunk(b []byte) (n int, err error) {
 
 	// Determine number of fragments to write.
 	nFragment := 1
-	fragmentSize := MaxFragmentSize(s.mtu, s.conn.TransportProtocol())
+	fragmentSize := s.fragmentSize()
 	if len(b) > fragmentSize {
 		nFragment = (len(b)-1)/fragmentSize + 1
 	}
@@ -442,10 +699,10 @@ func (s *Session) writeChunk(b []byte) (n int, err error) {
 		select {
 		case <-s.closedChan:
 			s.oLock.Unlock()
-			return 0, io.EOF
+			return 0, s.closedError(io.EOF)
 		case <-s.outputErr:
 			s.oLock.Unlock()
-			return 0, io.ErrClosedPipe
+			return 0, s.closedError(io.ErrClosedPipe)
 		case <-timeC:
 			s.oLock.Unlock()
 			return 0, stderror.ErrTimeout
@@ -467,7 +724,7 @@ func (s *Session) writeChunk(b []byte) (n int, err error) {
 				sessionID:  s.id,
 				seq:        s.nextSend,
 				unAckSeq:   s.nextRecv,
-				windowSize: uint16(mathext.Max(0, int(s.legacysendAlgorithm.CongestionWindowSize())-s.recvBuf.Len())),
+				windowSize: s.receiveWindowSize(),
 				fragment:   uint8(i),
 				payloadLen: uint16(partLen),
 			},
@@ -518,7 +775,7 @@ func (s *Session) runInputLoop(ctx context.Context) error {
 			if err := s.input(seg); err != nil {
 				err = fmt.Errorf("input() failed: %w", err)
 				log.Debugf("%v %v", s, err)
-				s.inputErr <- err
+				s.notifyInputErr(err)
 				s.closeWithError(err)
 				return err
 			}
@@ -529,6 +786,7 @@ func (s *Session) runInputLoop(ctx context.Context) error {
 func (s *Session) runOutputLoop(ctx context.Context) error {
 	ticker := time.NewTicker(outputLoopInterval)
 	defer ticker.Stop()
+	idleClosing := false
 	for {
 		select {
 		case <-ctx.Done():
@@ -536,28 +794,44 @@ func (s *Session) runOutputLoop(ctx context.Context) error {
 		case <-s.closedChan:
 			return nil
 		case <-ticker.C:
+			if !idleClosing && s.isIdle() {
+				log.WithFields(s.logFields()).Debugf("%v is idle for %v", s, s.idleTimeout)
+				SessionIdleClosed.Add(1)
+				idleClosing = true
+				// Close the session in another goroutine, because
+				// graceful close waits for the output loop.
+				go s.Close()
+			}
 		case <-s.sendQueue.chanNotEmptyEvent:
 		}
 
 		switch s.conn.TransportProtocol() {
 		case common.StreamTransport:
-			s.runOutputOnceStream()
+			s.runOutputOnceStream(0)
 		case common.PacketTransport:
-			s.runOutputOncePacket()
+			s.runOutputOncePacket(0)
 		default:
 			err := fmt.Errorf("unsupported transport protocol %v", s.conn.TransportProtocol())
 			log.Debugf("%v %v", s, err)
-			s.outputErr <- err
+			s.notifyOutputErr(err)
 			s.closeWithError(err)
 		}
 	}
 }
 
-func (s *Session) runOutputOnceStream() {
+// runOutputOnceStream sends the segments in sendQueue. If maxSegments is
+// positive, at most maxSegments segments are sent. It returns true if
+// it stops because of maxSegments.
+func (s *Session) runOutputOnceStream(maxSegments int) (limited bool) {
+	// To avoid deadlock, session can't be closed while holding oLock.
+	var closeSessionReason error
+	sent := 0
 	s.oLock.Lock()
-	defer s.oLock.Unlock()
-
 	for {
+		if maxSegments > 0 && sent >= maxSegments {
+			limited = s.sendQueue.Len() > 0
+			break
+		}
 		seg, ok := s.sendQueue.DeleteMin()
 		if !ok {
 			break
@@ -565,53 +839,104 @@ func (s *Session) runOutputOnceStream() {
 		if err := s.output(seg, nil); err != nil {
 			err = fmt.Errorf("output() failed: %w", err)
 			log.Debugf("%v %v", s, err)
-			s.outputErr <- err
-			s.closeWithError(err)
+			s.notifyOutputErr(err)
+			closeSessionReason = err
 			break
 		}
+		sent++
+	}
+	s.oLock.Unlock()
+	if closeSessionReason != nil {
+		s.closeWithError(closeSessionReason)
+		return false
 	}
+	return limited
 }
 
-func (s *Session) runOutputOncePacket() {
+// runOutputOncePacket retransmits lost segments, sends the segments in
+// sendQueue allowed by the congestion control, and sends acknowledgement
+// and heartbeat. If maxSegments is positive, at most maxSegments data
+// segments are retransmitted or sent. It returns true if it stops because
+// of maxSegments.
+func (s *Session) runOutputOncePacket(maxSegments int) (limited bool) {
+	if s.fecEncoder == nil && s.fecEnabled.Load() {
+		if s.isClient {
+			s.fecEncoder = newFECEncoder(s.fecGroupSize, fecClientToServer, s.id)
+		} else {
+			s.fecEncoder = newFECEncoder(s.fecGroupSize, fecServerToClient, s.id)
+		}
+	}
+
 	var closeSessionReason error
 	hasLoss := false
 	hasTimeout := false
 	var bytesInFlight int64
+	hasPiggybackedAck := false // some data segment carries the latest acknowledgement
+	var piggybackedAck uint32
+	var lostPackets []congestion.LostPacketInfo
+	sent := 0
 
 	// Resend segments in sendBuf.
 	// To avoid deadlock, session can't be closed inside Ascend().
 	s.oLock.Lock()
 	s.sendBuf.Ascend(func(iter *segment) bool {
 		bytesInFlight += int64(packetOverhead + len(iter.payload))
-		if iter.txCount >= txCountLimit {
-			err := fmt.Errorf("too many retransmission of %v", iter)
-			log.Debugf("%v is unhealthy: %v", s, err)
-			s.outputErr <- err
+		// A resumable session may be waiting for the peer to migrate,
+		// so the underlay decides when it is dead.
+		txCountExceeded := int(iter.txCount) >= s.txCountLimit && s.resumeToken.Load() == nil
+		if txCountExceeded || (s.txTimeLimit > 0 && time.Since(iter.firstTx) > s.txTimeLimit) {
+			seq, _ := iter.Seq()
+			err := &RetransmissionLimitError{
+				SessionID: s.id,
+				Seq:       seq,
+				TxCount:   int(iter.txCount),
+				Elapsed:   time.Since(iter.firstTx),
+			}
+			log.WithFields(s.logFields()).Debugf("%v is unhealthy: %v", s, err)
+			SessionRetransmissionLimitExceeded.Add(1)
+			s.setBrokenError(err)
+			s.notifyOutputErr(err)
 			closeSessionReason = err
 			return false
 		}
 		if (iter.ackCount >= earlyRetransmission && iter.txCount <= earlyRetransmissionLimit) || time.Since(iter.txTime) > iter.txTimeout {
+			if maxSegments > 0 && sent >= maxSegments {
+				// Retransmit the remaining segments in the next run.
+				limited = true
+				return false
+			}
 			if iter.ackCount >= earlyRetransmission {
 				hasLoss = true
+				seq, _ := iter.Seq()
+				lostPackets = append(lostPackets, congestion.LostPacketInfo{
+					PacketNumber: int64(seq),
+					BytesLost:    int64(packetOverhead + len(iter.payload)),
+				})
 			} else {
 				hasTimeout = true
 			}
 			iter.ackCount = 0
 			iter.txCount++
 			iter.txTime = time.Now()
+			SessionSegmentsRetransmitted.Add(1)
+			s.retransmissions.Add(1)
 			iter.txTimeout = s.rttStat.RTO() * time.Duration(mathext.Min(math.Pow(txTimeoutBackOff, float64(iter.txCount)), maxBackOffMultiplier))
 			if isDataAckProtocol(iter.metadata.Protocol()) {
 				das, _ := toDataAckStruct(iter.metadata)
 				das.unAckSeq = s.nextRecv
+				das.windowSize = s.receiveWindowSize()
+				hasPiggybackedAck = true
+				piggybackedAck = das.unAckSeq
 			}
 			if err := s.output(iter, s.RemoteAddr()); err != nil {
 				err = fmt.Errorf("output() failed: %w", err)
 				log.Debugf("%v %v", s, err)
-				s.outputErr <- err
+				s.notifyOutputErr(err)
 				closeSessionReason = err
 				return false
 			}
 			bytesInFlight += int64(packetOverhead + len(iter.payload))
+			sent++
 			return true
 		}
 		return true
@@ -623,11 +948,21 @@ func (s *Session) runOutputOncePacket() {
 	if hasLoss || hasTimeout {
 		s.legacysendAlgorithm.OnLoss() // OnTimeout() is too aggressive.
 	}
+	if len(lostPackets) > 0 {
+		// Only report the loss detected by acknowledgement.
+		// Retransmission timeout may be spurious.
+		s.sendAlgorithm.OnCongestionEvent(bytesInFlight, time.Now(), nil, lostPackets)
+	}
 
 	// Send new segments in sendQueue.
 	if s.sendQueue.Len() > 0 {
 		s.oLock.Lock()
 		for {
+			if maxSegments > 0 && sent >= maxSegments {
+				s.oLock.Unlock()
+				limited = true
+				break
+			}
 			seg, deleted := s.sendQueue.DeleteMinIf(func(iter *segment) bool {
 				return s.sendAlgorithm.CanSend(bytesInFlight, int64(packetOverhead+len(iter.payload)))
 			})
@@ -638,16 +973,21 @@ func (s *Session) runOutputOncePacket() {
 
 			seg.txCount++
 			seg.txTime = time.Now()
+			seg.firstTx = seg.txTime
+			SessionSegmentsSent.Add(1)
 			seg.txTimeout = s.rttStat.RTO() * time.Duration(mathext.Min(math.Pow(txTimeoutBackOff, float64(seg.txCount)), maxBackOffMultiplier))
 			if isDataAckProtocol(seg.metadata.Protocol()) {
 				das, _ := toDataAckStruct(seg.metadata)
 				das.unAckSeq = s.nextRecv
+				das.windowSize = s.receiveWindowSize()
+				hasPiggybackedAck = true
+				piggybackedAck = das.unAckSeq
 			}
 			if !s.sendBuf.Insert(seg) {
 				s.oLock.Unlock()
 				err := fmt.Errorf("output() failed: insert %v to send buffer failed", seg)
 				log.Debugf("%v %v", s, err)
-				s.outputErr <- err
+				s.notifyOutputErr(err)
 				s.closeWithError(err)
 				break
 			}
@@ -655,7 +995,7 @@ func (s *Session) runOutputOncePacket() {
 				s.oLock.Unlock()
 				err = fmt.Errorf("output() failed: %w", err)
 				log.Debugf("%v %v", s, err)
-				s.outputErr <- err
+				s.notifyOutputErr(err)
 				s.closeWithError(err)
 				break
 			} else {
@@ -664,21 +1004,43 @@ func (s *Session) runOutputOncePacket() {
 					s.oLock.Unlock()
 					err = fmt.Errorf("failed to get sequence number from %v: %w", seg, err)
 					log.Debugf("%v %v", s, err)
-					s.outputErr <- err
+					s.notifyOutputErr(err)
 					s.closeWithError(err)
 					break
 				}
 				newBytesInFlight := int64(packetOverhead + len(seg.payload))
 				s.sendAlgorithm.OnPacketSent(time.Now(), bytesInFlight, int64(seq), newBytesInFlight, true)
 				bytesInFlight += newBytesInFlight
+				sent++
+				if s.fecEncoder != nil {
+					if parity := s.fecEncoder.Add(seg); parity != nil {
+						s.outputParity(parity)
+					}
+				}
 			}
 		}
 	} else {
 		s.sendAlgorithm.OnApplicationLimited(bytesInFlight)
 	}
 
+	// Protect the last data segments sent if there is nothing more to send.
+	if s.fecEncoder != nil && s.sendQueue.Len() == 0 {
+		s.oLock.Lock()
+		if parity := s.fecEncoder.Flush(); parity != nil {
+			s.outputParity(parity)
+		}
+		s.oLock.Unlock()
+	}
+
 	// Send ACK or heartbeat if needed.
+	// If data segments sent above already carry the latest acknowledgement,
+	// don't send a standalone ACK. Segments received out of order can only
+	// be reported by a standalone ACK.
 	exceedHeartbeatInterval := time.Since(s.lastTXTime) > sessionHeartbeatInterval
+	if s.ackOnDataRecv.Load() && !exceedHeartbeatInterval && hasPiggybackedAck && piggybackedAck == s.nextRecv && s.recvBuf.Len() == 0 {
+		s.ackOnDataRecv.Store(false)
+		SessionPiggybackedAcks.Add(1)
+	}
 	if s.ackOnDataRecv.Load() || exceedHeartbeatInterval {
 		baseStruct := baseStruct{}
 		if s.isClient {
@@ -693,15 +1055,22 @@ func (s *Session) runOutputOncePacket() {
 				sessionID:  s.id,
 				seq:        uint32(mathext.Max(0, int(s.nextSend)-1)),
 				unAckSeq:   s.nextRecv,
-				windowSize: uint16(mathext.Max(0, int(s.legacysendAlgorithm.CongestionWindowSize())-s.recvBuf.Len())),
+				windowSize: s.receiveWindowSize(),
 			},
 			transport: s.conn.TransportProtocol(),
 		}
+		if ranges := s.sackRanges(); len(ranges) > 0 {
+			das := ackSeg.metadata.(*dataAckStruct)
+			ackSeg.payload = marshalSACKRanges(ranges)
+			das.payloadLen = uint16(len(ackSeg.payload))
+			das.sackCount = uint8(len(ranges))
+			SessionSelectiveAcks.Add(1)
+		}
 		if err := s.output(ackSeg, s.RemoteAddr()); err != nil {
 			s.oLock.Unlock()
 			err = fmt.Errorf("output() failed: %w", err)
 			log.Debugf("%v %v", s, err)
-			s.outputErr <- err
+			s.notifyOutputErr(err)
 			s.closeWithError(err)
 		} else {
 			seq, err := ackSeg.Seq()
@@ -709,10 +1078,11 @@ func (s *Session) runOutputOncePacket() {
 				s.oLock.Unlock()
 				err = fmt.Errorf("failed to get sequence number from %v: %w", ackSeg, err)
 				log.Debugf("%v %v", s, err)
-				s.outputErr <- err
+				s.notifyOutputErr(err)
 				s.closeWithError(err)
 			} else {
 				s.oLock.Unlock()
+				SessionStandaloneAcks.Add(1)
 				newBytesInFlight := int64(packetOverhead + len(ackSeg.payload))
 				s.sendAlgorithm.OnPacketSent(time.Now(), bytesInFlight, int64(seq), newBytesInFlight, true)
 				bytesInFlight += newBytesInFlight
@@ -720,6 +1090,7 @@ func (s *Session) runOutputOncePacket() {
 		}
 		s.ackOnDataRecv.Store(false)
 	}
+	return limited
 }
 
 // input reads incoming packets from network and assemble
@@ -727,11 +1098,11 @@ func (s *Session) runOutputOncePacket() {
 func (s *Session) input(seg *segment) error {
 	protocol := seg.Protocol()
 	if s.isClient {
-		if protocol != openSessionResponse && protocol != dataServerToClient && protocol != ackServerToClient && protocol != closeSessionRequest && protocol != closeSessionResponse {
+		if protocol != openSessionResponse && protocol != dataServerToClient && protocol != ackServerToClient && protocol != fecServerToClient && protocol != datagramServerToClient && protocol != closeSessionRequest && protocol != closeSessionResponse {
 			return stderror.ErrInvalidArgument
 		}
 	} else {
-		if protocol != openSessionRequest && protocol != dataClientToServer && protocol != ackClientToServer && protocol != closeSessionRequest && protocol != closeSessionResponse {
+		if protocol != openSessionRequest && protocol != dataClientToServer && protocol != ackClientToServer && protocol != fecClientToServer && protocol != datagramClientToServer && protocol != closeSessionRequest && protocol != closeSessionResponse {
 			return stderror.ErrInvalidArgument
 		}
 	}
@@ -762,14 +1133,49 @@ func (s *Session) input(seg *segment) error {
 			if s.downloadBytes == nil {
 				s.downloadBytes = metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, (*s.block.Load()).BlockContext().UserName), metrics.UserMetricDownloadBytes, metrics.COUNTER_TIME_SERIES)
 			}
+			if s.uploadSegments == nil {
+				s.uploadSegments = metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, (*s.block.Load()).BlockContext().UserName), metrics.UserMetricUploadSegments, metrics.COUNTER)
+			}
+			if s.downloadSegments == nil {
+				s.downloadSegments = metrics.RegisterMetric(fmt.Sprintf(metrics.UserMetricGroupFormat, (*s.block.Load()).BlockContext().UserName), metrics.UserMetricDownloadSegments, metrics.COUNTER)
+			}
 		}
 	}
 
 	s.lastRXTime = time.Now()
+	s.segmentsRecv.Add(1)
+	if !s.isClient && s.uploadSegments != nil {
+		s.uploadSegments.Add(1)
+	}
+	if protocol == openSessionResponse {
+		s.takeResumeToken(seg)
+		s.takeServerInfo(seg)
+		if accepted := keyExchangeMethod(seg.metadata.(*sessionStruct).keyExchange); accepted != s.keyExchange {
+			err := fmt.Errorf("server doesn't accept %v key exchange", s.keyExchange)
+			s.reportHandshake(err)
+			return err
+		}
+		s.reportHandshake(nil)
+	}
+	if protocol == openSessionRequest {
+		s.setPriority(SessionPriority(seg.metadata.(*sessionStruct).priority))
+	}
+	if protocol == openSessionRequest || protocol == openSessionResponse {
+		s.maybeEnableDatagram(seg)
+		s.maybeEnableFEC(seg)
+	}
+	if protocol == openSessionRequest || protocol == openSessionResponse || protocol == dataServerToClient || protocol == dataClientToServer || protocol == datagramServerToClient || protocol == datagramClientToServer {
+		s.touch()
+	}
+	if protocol == datagramServerToClient || protocol == datagramClientToServer {
+		return s.inputDatagram(seg)
+	}
 	if protocol == openSessionRequest || protocol == openSessionResponse || protocol == dataServerToClient || protocol == dataClientToServer {
 		return s.inputData(seg)
 	} else if protocol == ackServerToClient || protocol == ackClientToServer {
 		return s.inputAck(seg)
+	} else if protocol == fecServerToClient || protocol == fecClientToServer {
+		return s.inputFEC(seg)
 	} else if protocol == closeSessionRequest || protocol == closeSessionResponse {
 		return s.inputClose(seg)
 	}
@@ -777,6 +1183,7 @@ func (s *Session) input(seg *segment) error {
 }
 
 func (s *Session) inputData(seg *segment) error {
+	s.recvRate.OnReceive(time.Now(), packetOverhead+len(seg.payload), s.rttStat.SmoothedRTT())
 	switch s.conn.TransportProtocol() {
 	case common.StreamTransport:
 		// Deliver the segment directly to recvQueue.
@@ -802,7 +1209,7 @@ func (s *Session) inputData(seg *segment) error {
 				if !deleted {
 					break
 				}
-				s.rttStat.UpdateRTT(time.Since(seg2.txTime))
+				s.updateRTT(time.Since(seg2.txTime))
 				s.legacysendAlgorithm.OnAck()
 				seq, _ := seg2.Seq()
 				ackedPackets = append(ackedPackets, congestion.AckedPacketInfo{
@@ -815,35 +1222,26 @@ func (s *Session) inputData(seg *segment) error {
 				s.sendAlgorithm.OnCongestionEvent(priorInFlight, time.Now(), ackedPackets, nil)
 			}
 			s.remoteWindowSize = das.windowSize
+			// The acknowledgement piggybacked by data segment is not
+			// counted as a duplicate ACK. The peer sends data segments
+			// with the same unAckSeq when nothing is lost.
 		}
 
-		// Deliver the segment to recvBuf.
-		if !s.recvBuf.Insert(seg) {
-			return fmt.Errorf("insert %v to receive buffer failed", seg)
+		if s.fecDecoder != nil && isDataProtocol(seg.metadata.Protocol()) {
+			// The FEC decoder keeps the segment after it is read
+			// by the application.
+			seg.detach()
 		}
-
-		// Move recvBuf to recvQueue.
-		for {
-			seg3, deleted := s.recvBuf.DeleteMinIf(func(iter *segment) bool {
-				seq, _ := iter.Seq()
-				return seq <= s.nextRecv
-			})
-			if seg3 == nil || !deleted {
-				break
-			}
-			seq, _ := seg3.Seq()
-			if seq == s.nextRecv {
-				if !s.recvQueue.Insert(seg3) {
-					return fmt.Errorf("insert %v to receive queue failed", seg3)
-				}
-				s.nextRecv++
-				das, ok := seg3.metadata.(*dataAckStruct)
-				if ok {
-					s.remoteWindowSize = das.windowSize
+		if err := s.deliverPacketData(seg); err != nil {
+			return err
+		}
+		if s.fecDecoder != nil && isDataProtocol(seg.metadata.Protocol()) {
+			for _, recovered := range s.fecDecoder.AddData(seg, s.nextRecv) {
+				if err := s.deliverPacketData(recovered); err != nil {
+					return err
 				}
 			}
 		}
-		s.ackOnDataRecv.Store(true)
 	default:
 		return fmt.Errorf("unsupported transport protocol %v", s.conn.TransportProtocol())
 	}
@@ -866,11 +1264,16 @@ func (s *Session) inputData(seg *segment) error {
 				}
 				if !quotaOK {
 					s.status = statusQuotaExhausted
-					log.Debugf("Closing %v because user %s used all the quota", s, userName)
+					log.WithFields(s.logFields()).Debugf("Closing %v because user %s used all the quota", s, userName)
 					s.oLock.Unlock()
-					s.Close()
+					s.closeFromInput()
 					return nil
 				}
+				if user, found := s.users[userName]; found {
+					if limiter := rateLimiterOf(user); limiter != nil {
+						s.rateLimiter.Store(limiter)
+					}
+				}
 			}
 			seg4 := &segment{
 				metadata: &sessionStruct{
@@ -882,6 +1285,14 @@ func (s *Session) inputData(seg *segment) error {
 				},
 				transport: s.conn.TransportProtocol(),
 			}
+			advertiseServerInfo(seg4.metadata.(*sessionStruct))
+			seg4.metadata.(*sessionStruct).keyExchange = uint8(s.keyExchange)
+			if seg.metadata.(*sessionStruct).statusCode == uint8(statusResumable) && s.conn.TransportProtocol() == common.PacketTransport {
+				s.issueResumeToken(seg4)
+			}
+			if s.datagramEnabled.Load() {
+				seg4.metadata.(*sessionStruct).statusCode = uint8(statusDatagram)
+			}
 			s.nextSend++
 			if log.IsLevelEnabled(log.TraceLevel) {
 				log.Tracef("%v writing open session response", s)
@@ -892,12 +1303,36 @@ func (s *Session) inputData(seg *segment) error {
 			} else {
 				s.oLock.Unlock()
 				s.forwardStateTo(sessionEstablished)
+				s.maybePushCredential(seg.metadata.(*sessionStruct))
 			}
 		}
 	}
 	return nil
 }
 
+// sackRanges returns the ranges of segments in recvBuf that are received
+// out of order. At most maxSACKRanges ranges with the lowest sequence
+// numbers are returned.
+func (s *Session) sackRanges() []sackRange {
+	var ranges []sackRange
+	s.recvBuf.Ascend(func(iter *segment) bool {
+		seq, _ := iter.Seq()
+		if seq <= s.nextRecv {
+			return true
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].end+1 == seq {
+			ranges[n-1].end = seq
+			return true
+		}
+		if len(ranges) >= maxSACKRanges {
+			return false
+		}
+		ranges = append(ranges, sackRange{start: seq, end: seq})
+		return true
+	})
+	return ranges
+}
+
 func (s *Session) inputAck(seg *segment) error {
 	switch s.conn.TransportProtocol() {
 	case common.StreamTransport:
@@ -921,7 +1356,7 @@ func (s *Session) inputAck(seg *segment) error {
 			if !deleted {
 				break
 			}
-			s.rttStat.UpdateRTT(time.Since(seg2.txTime))
+			s.updateRTT(time.Since(seg2.txTime))
 			s.legacysendAlgorithm.OnAck()
 			seq, _ := seg2.Seq()
 			ackedPackets = append(ackedPackets, congestion.AckedPacketInfo{
@@ -930,18 +1365,64 @@ func (s *Session) inputAck(seg *segment) error {
 				ReceiveTimestamp: time.Now(),
 			})
 		}
+		advanced := len(ackedPackets) > 0
+
+		// Delete segments received by the peer out of order from sendBuf.
+		var ranges []sackRange
+		if das.sackCount > 0 {
+			var err error
+			ranges, err = unmarshalSACKRanges(seg.payload, int(das.sackCount))
+			if err != nil {
+				log.Debugf("%v ignored selective acknowledgement in %v: %v", s, seg, err)
+				ranges = nil
+			}
+		}
+		seg.release()
+		var highestSACK uint32
+		if len(ranges) > 0 {
+			sacked := s.sendBuf.DeleteIf(func(iter *segment) bool {
+				seq, _ := iter.Seq()
+				for _, r := range ranges {
+					if r.contains(seq) {
+						return true
+					}
+				}
+				return false
+			})
+			for _, seg2 := range sacked {
+				s.updateRTT(time.Since(seg2.txTime))
+				s.legacysendAlgorithm.OnAck()
+				seq, _ := seg2.Seq()
+				ackedPackets = append(ackedPackets, congestion.AckedPacketInfo{
+					PacketNumber:     int64(seq),
+					BytesAcked:       int64(packetOverhead + len(seg2.payload)),
+					ReceiveTimestamp: time.Now(),
+				})
+			}
+			SessionSACKedSegments.Add(int64(len(sacked)))
+			for _, r := range ranges {
+				highestSACK = mathext.Max(highestSACK, r.end)
+			}
+		}
 		if len(ackedPackets) > 0 {
 			s.sendAlgorithm.OnCongestionEvent(priorInFlight, time.Now(), ackedPackets, nil)
 		}
 		s.remoteWindowSize = das.windowSize
 
 		// Update acknowledge count.
+		// It is a duplicate ACK only if unAckSeq doesn't advance and
+		// the peer has received some segments out of order.
+		// Segments below the highest selective acknowledgement are missing
+		// in the peer, so they are retransmitted without waiting for timeout.
+		if advanced || len(ranges) == 0 {
+			return nil
+		}
 		s.sendBuf.Ascend(func(iter *segment) bool {
 			seq, _ := iter.Seq()
-			if seq > unAckSeq {
+			if seq > unAckSeq && seq >= highestSACK {
 				return false
 			}
-			if seq == unAckSeq {
+			if seq == unAckSeq || seq < highestSACK {
 				iter.ackCount++
 			}
 			return true
@@ -952,6 +1433,57 @@ func (s *Session) inputAck(seg *segment) error {
 	}
 }
 
+// inputFEC recovers lost data segment from the parity segment.
+func (s *Session) inputFEC(seg *segment) error {
+	if s.conn.TransportProtocol() != common.PacketTransport {
+		return fmt.Errorf("parity segment is not supported by transport protocol %v", s.conn.TransportProtocol())
+	}
+	FECParityReceived.Add(1)
+	if s.fecDecoder == nil {
+		s.fecDecoder = newFECDecoder()
+	}
+	if recovered := s.fecDecoder.AddParity(seg, s.nextRecv); recovered != nil {
+		if log.IsLevelEnabled(log.TraceLevel) {
+			log.Tracef("%v recovered %v", s, recovered)
+		}
+		return s.deliverPacketData(recovered)
+	}
+	return nil
+}
+
+// deliverPacketData inserts the segment to recvBuf, and moves
+// the segments that are ready to read to recvQueue.
+func (s *Session) deliverPacketData(seg *segment) error {
+	// Deliver the segment to recvBuf.
+	if !s.recvBuf.Insert(seg) {
+		return fmt.Errorf("insert %v to receive buffer failed", seg)
+	}
+
+	// Move recvBuf to recvQueue.
+	for {
+		seg3, deleted := s.recvBuf.DeleteMinIf(func(iter *segment) bool {
+			seq, _ := iter.Seq()
+			return seq <= s.nextRecv
+		})
+		if seg3 == nil || !deleted {
+			break
+		}
+		seq, _ := seg3.Seq()
+		if seq == s.nextRecv {
+			if !s.recvQueue.Insert(seg3) {
+				return fmt.Errorf("insert %v to receive queue failed", seg3)
+			}
+			s.nextRecv++
+			das, ok := seg3.metadata.(*dataAckStruct)
+			if ok {
+				s.remoteWindowSize = das.windowSize
+			}
+		}
+	}
+	s.ackOnDataRecv.Store(true)
+	return nil
+}
+
 func (s *Session) inputClose(seg *segment) error {
 	s.oLock.Lock()
 	if seg.metadata.Protocol() == closeSessionRequest {
@@ -970,23 +1502,26 @@ func (s *Session) inputClose(seg *segment) error {
 		}
 		s.nextSend++
 		// The response will not retry if it is not delivered.
+		// The remote may close the underlay right after the request,
+		// so failing to send the response doesn't break the session.
 		if err := s.output(seg2, s.RemoteAddr()); err != nil {
-			s.oLock.Unlock()
-			return fmt.Errorf("output() failed: %v", err)
+			log.Debugf("%v failed to send close session response: %v", s, err)
 		}
 		// Immediately shutdown event loop.
 		if seg.metadata.(*sessionStruct).statusCode == uint8(statusQuotaExhausted) {
 			log.Infof("Remote requested to shut down the session because user has exhausted quota")
+			s.setCloseReason(ErrSessionQuotaExhausted)
 		} else {
 			log.Debugf("Remote requested to shut down %v", s)
+			s.setCloseReason(ErrSessionClosedByPeer)
 		}
 		s.oLock.Unlock()
-		s.Close()
+		s.closeFromInput()
 	} else if seg.metadata.Protocol() == closeSessionResponse {
 		// Immediately shutdown event loop.
 		log.Debugf("Remote received the request from %v to shut down", s)
 		s.oLock.Unlock()
-		s.Close()
+		s.closeFromInput()
 	}
 	return nil
 }
@@ -1013,21 +1548,184 @@ func (s *Session) output(seg *segment, remoteAddr net.Addr) error {
 	}
 	s.lastSend, _ = seg.Seq()
 	s.lastTXTime = time.Now()
+	s.countSentSegment()
 	return nil
 }
 
+// countSentSegment updates the counters after a segment is sent.
+func (s *Session) countSentSegment() {
+	s.segmentsSent.Add(1)
+	if !s.isClient && s.downloadSegments != nil {
+		s.downloadSegments.Add(1)
+	}
+}
+
+// outputParity sends a parity segment. The parity segment is not
+// retransmitted, and the error is ignored. The caller must hold oLock.
+func (s *Session) outputParity(seg *segment) {
+	if err := s.conn.(*PacketUnderlay).writeOneSegment(seg, s.RemoteAddr()); err != nil {
+		if log.IsLevelEnabled(log.TraceLevel) {
+			log.Tracef("%v failed to send parity segment: %v", s, err)
+		}
+		return
+	}
+	FECParitySent.Add(1)
+	s.countSentSegment()
+}
+
+// fragmentSize returns the maximum payload size in a fragment.
+func (s *Session) fragmentSize() int {
+	if u, ok := s.conn.(*PacketUnderlay); ok {
+		// The MTU may be updated by path MTU discovery.
+		return MaxFragmentSize(u.pathMTU(s.RemoteAddr()), common.PacketTransport)
+	}
+	return MaxFragmentSize(s.mtu, s.conn.TransportProtocol())
+}
+
+// receiveWindowSize returns the number of segments the session is able to
+// receive. The window is large enough to fill the bandwidth-delay product,
+// so the peer is not limited by the window in long fat networks.
+func (s *Session) receiveWindowSize() uint16 {
+	rtt := s.rttStat.SmoothedRTT()
+	if rtt == 0 {
+		rtt = initialWindowRTT
+	}
+	window := bdpWindowSize(s.recvRate.Rate(), rtt, s.mtu, s.maxRecvWindowSize)
+	window = mathext.Max(window, int(s.legacysendAlgorithm.CongestionWindowSize()))
+	window = mathext.Min(window, s.maxRecvWindowSize)
+	return uint16(mathext.Max(0, window-s.recvBuf.Len()))
+}
+
+// applyOptions applies the options to the session.
+// It must be called before the session is used.
+func (s *Session) applyOptions(opts sessionOptions) error {
+	if opts.bufferCapacity > 0 {
+		s.sendQueue = newSegmentTree(opts.bufferCapacity)
+		s.sendBuf = newSegmentTree(opts.bufferCapacity)
+		s.recvBuf = newSegmentTree(opts.bufferCapacity)
+		s.recvQueue = newSegmentTree(opts.bufferCapacity)
+	}
+	sendWindow := maxWindowSize
+	if opts.sendWindow > 0 {
+		sendWindow = opts.sendWindow
+		s.legacysendAlgorithm = congestion.NewCubicSendAlgorithm(minWindowSize, uint32(sendWindow))
+	}
+	if opts.congestionControl != "" || opts.sendWindow > 0 {
+		controller, err := congestion.NewCongestionController(opts.congestionControl, fmt.Sprintf("%d", s.id), s.rttStat, minWindowSize, uint32(sendWindow))
+		if err != nil {
+			return err
+		}
+		s.sendAlgorithm = controller
+	}
+	if opts.recvWindow > 0 {
+		s.maxRecvWindowSize = opts.recvWindow
+	}
+	if opts.bandwidth > 0 {
+		s.recvRate = newReceiveRateEstimator(opts.bandwidth)
+	}
+	s.fecGroupSize = opts.fecGroupSize
+	if opts.txCountLimit > 0 {
+		s.txCountLimit = opts.txCountLimit
+	}
+	s.txTimeLimit = opts.txTimeLimit
+	s.migration = opts.migration
+	s.credentialPush = opts.credentialPush
+	s.idleTimeout = opts.idleTimeout
+	s.touch()
+	return nil
+}
+
+// touch records that data is sent or received by the session.
+func (s *Session) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// isIdle returns true if the idle timeout is enabled and
+// no data is sent or received within the timeout.
+func (s *Session) isIdle() bool {
+	return s.idleTimeout > 0 && time.Since(time.Unix(0, s.lastActivity.Load())) > s.idleTimeout
+}
+
+// reportHandshake reports the result of the open session request
+// once. It does nothing if the request is not sent.
+func (s *Session) reportHandshake(err error) {
+	if s.onHandshake == nil || s.handshakeStart.Load() == 0 {
+		return
+	}
+	if !s.handshakeReported.CompareAndSwap(false, true) {
+		return
+	}
+	s.onHandshake(err, time.Since(time.Unix(0, s.handshakeStart.Load())))
+}
+
+// updateRTT adds a round trip time sample of the session.
+func (s *Session) updateRTT(sample time.Duration) {
+	s.rttStat.UpdateRTT(sample)
+	s.rttSamples[s.rttSampleIndex.Add(1)%rttSampleCount].Store(int64(sample))
+	s.smoothedRTT.Store(int64(s.rttStat.SmoothedRTT()))
+	s.rttVariance.Store(int64(s.rttStat.MeanDeviation()))
+	if s.isClient {
+		clientQuality.onRTT(sample)
+	}
+}
+
+// closedError returns the error reported to the application after
+// the session is closed. If the session is broken, the cause is returned
+// instead of defaultErr.
+func (s *Session) closedError(defaultErr error) error {
+	if err := s.brokenErr.Load(); err != nil {
+		return *err
+	}
+	return defaultErr
+}
+
+// notifyInputErr tells Read() that the input is broken. It doesn't block
+// if the error is already reported.
+func (s *Session) notifyInputErr(err error) {
+	select {
+	case s.inputErr <- err:
+	default:
+	}
+}
+
+// notifyOutputErr tells Write() that the output is broken. It doesn't
+// block if the error is already reported.
+func (s *Session) notifyOutputErr(err error) {
+	select {
+	case s.outputErr <- err:
+	default:
+	}
+}
+
+// setBrokenError records the reason that breaks the session.
+// Only the first reason is recorded.
+func (s *Session) setBrokenError(err error) {
+	s.brokenErr.CompareAndSwap(nil, &err)
+}
+
+// setCloseReason records the reason that the peer closes the session.
+// Only the first reason is recorded.
+func (s *Session) setCloseReason(err error) {
+	s.closeReason.CompareAndSwap(nil, &err)
+}
+
 func (s *Session) closeWithError(err error) error {
 	if !s.closeRequested.CompareAndSwap(false, true) {
 		// This function has been called before.
 		return nil
 	}
 
+	if err != nil {
+		// The server didn't respond to the open session request.
+		s.reportHandshake(err)
+	}
+
 	var gracefulClose bool
 	if err == nil {
-		log.Debugf("Closing %v", s)
+		log.WithFields(s.logFields()).Debugf("Closing %v", s)
 		gracefulClose = true
 	} else {
-		log.Debugf("Closing %v with error %v", s, err)
+		log.WithFields(s.logFields()).Debugf("Closing %v with error %v", s, err)
 	}
 	if s.isState(sessionAttached) || s.isState(sessionEstablished) {
 		// Send closeSessionRequest, but don't wait for closeSessionResponse,
@@ -1079,8 +1777,19 @@ func (s *Session) closeWithError(err error) error {
 	s.sendBuf.DeleteAll()
 	s.forwardStateTo(sessionClosed)
 	close(s.closedChan)
-	log.Debugf("Closed %v", s)
+	s.notifyEngineClosed()
+	log.WithFields(s.logFields()).Debugf("Closed %v", s)
 	metrics.CurrEstablished.Add(-1)
+	if s.onClose != nil {
+		if err == nil {
+			if reason := s.closeReason.Load(); reason != nil {
+				err = *reason
+			} else {
+				err = s.closedError(nil)
+			}
+		}
+		s.onClose(err)
+	}
 	return nil
 }
 
@@ -1092,29 +1801,29 @@ func (s *Session) checkQuota(userName string) (ok bool, err error) {
 	if !found {
 		return true, fmt.Errorf("user %s is not found", userName)
 	}
+	if user.GetDisabled() {
+		return false, fmt.Errorf("user %s is disabled", userName)
+	}
+	if user.ExpireTime != nil {
+		expireTime, err := time.Parse(time.RFC3339, user.GetExpireTime())
+		if err != nil {
+			return true, fmt.Errorf("user %s has invalid expire time: %w", userName, err)
+		}
+		if !time.Now().Before(expireTime) {
+			return false, fmt.Errorf("user %s is expired", userName)
+		}
+	}
 	if len(user.GetQuotas()) == 0 {
 		return true, nil
 	}
 
-	metricGroupName := fmt.Sprintf(metrics.UserMetricGroupFormat, userName)
-	metricGroup := metrics.GetMetricGroupByName(metricGroupName)
-	if metricGroup == nil {
-		return true, fmt.Errorf("metric group %s is not found", metricGroupName)
-	}
-	uploadBytes, found := metricGroup.GetMetric(metrics.UserMetricUploadBytes)
-	if !found {
-		return true, fmt.Errorf("metric %s in group %s is not found", metrics.UserMetricUploadBytes, metricGroupName)
-	}
-	downloadBytes, found := metricGroup.GetMetric(metrics.UserMetricDownloadBytes)
-	if !found {
-		return true, fmt.Errorf("metric %s in group %s is not found", metrics.UserMetricDownloadBytes, metricGroupName)
-	}
+	now := time.Now()
 	for _, quota := range user.GetQuotas() {
-		now := time.Now()
-		then := now.Add(-time.Duration(quota.GetDays()) * 24 * time.Hour)
-		totalBytes := uploadBytes.(*metrics.Counter).DeltaBetween(then, now)
-		totalBytes += downloadBytes.(*metrics.Counter).DeltaBetween(then, now)
-		if totalBytes/1048576 > int64(quota.GetMegabytes()) {
+		usedBytes, err := QuotaUsedBytes(userName, quota, now)
+		if err != nil {
+			return true, err
+		}
+		if usedBytes/1048576 > int64(quota.GetMegabytes()) {
 			return false, nil
 		}
 	}
@@ -1123,13 +1832,36 @@ func (s *Session) checkQuota(userName string) (ok bool, err error) {
 
 // SessionInfo provides a string representation of a Session.
 type SessionInfo struct {
-	ID         string
-	Protocol   string
-	LocalAddr  string
-	RemoteAddr string
-	State      string
-	RecvQBuf   string
-	SendQBuf   string
-	LastRecv   string
-	LastSend   string
+	ID          string
+	Protocol    string
+	LocalAddr   string
+	RemoteAddr  string
+	State       string
+	RecvQBuf    string
+	SendQBuf    string
+	LastRecv    string
+	LastSend    string
+	RTT         string
+	Retransmit  string
+	InFlight    string
+	User        string
+	Destination string
+	Age         string
+	Bytes       string
+	Stats       SessionStats
+}
+
+// SessionStats contains the transmission statistics of a Session.
+// A high retransmission count indicates packet loss, while a large
+// round trip time with few retransmissions indicates throttling
+// or queuing in the network.
+type SessionStats struct {
+	SmoothedRTT     time.Duration // smoothed round trip time
+	RTTVariance     time.Duration // mean deviation of round trip time
+	Retransmissions uint64        // number of segments sent again
+	BytesInFlight   int64         // bytes sent but not acknowledged
+	BytesRead       uint64        // bytes read by the application
+	BytesWritten    uint64        // bytes written by the application
+	SegmentsRecv    uint64        // segments received from the underlay
+	SegmentsSent    uint64        // segments sent to the underlay
 }
@@ -0,0 +1,500 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/crash"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// SessionEngine decides how sessions process received segments and
+// send queued segments.
+type SessionEngine uint8
+
+const (
+	// SessionEngineGoroutine runs an input goroutine and an output
+	// goroutine for each session. This is the default.
+	SessionEngineGoroutine SessionEngine = 0
+
+	// SessionEngineEventDriven runs sessions with a shared pool of
+	// workers. A session only uses a worker when it has an event to
+	// process, so a server with a lot of idle sessions uses much
+	// less memory.
+	SessionEngineEventDriven SessionEngine = 1
+)
+
+func (e SessionEngine) String() string {
+	switch e {
+	case SessionEngineGoroutine:
+		return "GOROUTINE"
+	case SessionEngineEventDriven:
+		return "EVENT_DRIVEN"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", uint8(e))
+	}
+}
+
+const (
+	// MaxEventEngineWorkers is the maximum number of workers
+	// of the event driven session engine.
+	MaxEventEngineWorkers = 65536
+
+	// eventEngineWorkersPerCPU is the default number of workers per CPU.
+	// A worker is blocked when the underlay is slow to write,
+	// so there are more workers than CPUs.
+	eventEngineWorkersPerCPU = 16
+
+	// eventEngineSweepInterval is the interval to run the output of
+	// all the sessions, to send heartbeats and close idle sessions.
+	eventEngineSweepInterval = 500 * time.Millisecond
+
+	// eventEngineBatchSegments is the maximum number of segments a task
+	// processes or sends each time it runs, so a busy session doesn't
+	// hold a worker for long. The task is queued again if there is
+	// more work to do.
+	eventEngineBatchSegments = 64
+)
+
+var (
+	// EventEngineSessions is the number of sessions run by
+	// the event driven session engine.
+	EventEngineSessions = metrics.RegisterMetric("session engine", "Sessions", metrics.GAUGE)
+
+	// EventEngineQueueLength is the number of session tasks
+	// waiting for a worker.
+	EventEngineQueueLength = metrics.RegisterMetric("session engine", "QueueLength", metrics.GAUGE)
+
+	// EventEngineTasks is the number of session tasks run by workers.
+	EventEngineTasks = metrics.RegisterMetric("session engine", "Tasks", metrics.COUNTER)
+
+	// EventEngineReceiveOverflows is the number of received segments
+	// that can't be passed to the session immediately because the
+	// session doesn't process them fast enough.
+	EventEngineReceiveOverflows = metrics.RegisterMetric("session engine", "ReceiveOverflows", metrics.COUNTER)
+)
+
+// engineUnderlay is implemented by underlays that can run sessions
+// with the event driven session engine.
+type engineUnderlay interface {
+	setSessionEngine(e *eventEngine)
+}
+
+var (
+	_ engineUnderlay = &StreamUnderlay{}
+	_ engineUnderlay = &PacketUnderlay{}
+)
+
+// setSessionEngine runs the sessions added after this call with
+// the event driven engine. A nil engine runs each session with
+// its own goroutines.
+func (b *baseUnderlay) setSessionEngine(e *eventEngine) {
+	b.engine = e
+}
+
+// engineTaskType is the type of work the event driven engine does
+// for a session.
+type engineTaskType uint8
+
+const (
+	// engineInput processes the segments received by the session.
+	engineInput engineTaskType = iota
+
+	// engineOutput sends queued segments, retransmits lost segments,
+	// and sends acknowledgements and heartbeats.
+	engineOutput
+)
+
+// engineTask is the state of one type of work of a session.
+// Like the goroutine engine, the input and output of a session
+// can run at the same time, but each of them runs in one worker
+// at a time.
+type engineTask struct {
+	mu     sync.Mutex // held by the worker running the task
+	queued bool       // the task is waiting for a worker, protected by eventEngine.mu
+	done   bool       // the task is finished after the session is closed, protected by mu
+}
+
+type engineJob struct {
+	session  *Session
+	taskType engineTaskType
+}
+
+// eventEngine runs sessions with a shared pool of workers.
+// Each session is a state machine driven by events: a segment is
+// received, a segment is queued to send, a retransmission timer
+// expires, or the session is closed.
+type eventEngine struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []engineJob           // jobs waiting for a worker in FIFO order
+	sessions map[*Session]struct{} // all the running sessions
+	timers   map[*Session]struct{} // sessions that have segments to send or acknowledge
+	closed   bool
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// defaultEventEngineWorkers returns the default number of workers.
+func defaultEventEngineWorkers() int {
+	return runtime.GOMAXPROCS(0) * eventEngineWorkersPerCPU
+}
+
+// newEventEngine creates a new event driven session engine
+// and starts the workers.
+func newEventEngine(workers int) *eventEngine {
+	if workers <= 0 {
+		workers = defaultEventEngineWorkers()
+	}
+	e := &eventEngine{
+		sessions: make(map[*Session]struct{}),
+		timers:   make(map[*Session]struct{}),
+		done:     make(chan struct{}),
+	}
+	e.cond = sync.NewCond(&e.mu)
+	e.wg.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer e.wg.Done()
+			e.runWorker()
+		}()
+	}
+	go func() {
+		defer e.wg.Done()
+		e.runTimers()
+	}()
+	log.Infof("Started event driven session engine with %d workers", workers)
+	return e
+}
+
+// close stops the workers. Sessions that are still running are
+// not processed anymore, so they should be closed before this.
+func (e *eventEngine) close() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	close(e.done)
+	e.cond.Broadcast()
+	e.mu.Unlock()
+	e.wg.Wait()
+}
+
+// register starts to run the session. It replaces the input and
+// output goroutines of the session.
+func (e *eventEngine) register(s *Session) {
+	s.engine = e
+	s.sendQueue.onInsert = func() { e.schedule(s, engineOutput) }
+	s.wg.Add(2)
+	e.mu.Lock()
+	e.sessions[s] = struct{}{}
+	EventEngineSessions.Store(int64(len(e.sessions)))
+	e.mu.Unlock()
+	e.schedule(s, engineInput)
+	e.schedule(s, engineOutput)
+}
+
+// unregister stops running the session after both tasks are done.
+func (e *eventEngine) unregister(s *Session) {
+	e.mu.Lock()
+	delete(e.sessions, s)
+	delete(e.timers, s)
+	EventEngineSessions.Store(int64(len(e.sessions)))
+	e.mu.Unlock()
+}
+
+// schedule queues a task of the session. It doesn't block.
+// A task that is already queued is not queued again.
+func (e *eventEngine) schedule(s *Session, taskType engineTaskType) {
+	task := s.engineTask(taskType)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed || task.queued {
+		return
+	}
+	task.queued = true
+	e.queue = append(e.queue, engineJob{session: s, taskType: taskType})
+	EventEngineQueueLength.Store(int64(len(e.queue)))
+	e.cond.Signal()
+}
+
+// setTimer adds or removes the session from the sessions that
+// run the output every outputLoopInterval.
+func (e *eventEngine) setTimer(s *Session, enable bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.sessions[s]; !ok {
+		return
+	}
+	if enable {
+		e.timers[s] = struct{}{}
+	} else {
+		delete(e.timers, s)
+	}
+}
+
+func (e *eventEngine) runWorker() {
+	for {
+		e.mu.Lock()
+		for len(e.queue) == 0 && !e.closed {
+			e.cond.Wait()
+		}
+		if e.closed {
+			e.mu.Unlock()
+			return
+		}
+		job := e.queue[0]
+		e.queue[0] = engineJob{}
+		e.queue = e.queue[1:]
+		EventEngineQueueLength.Store(int64(len(e.queue)))
+		// Events after this point queue the task again.
+		job.session.engineTask(job.taskType).queued = false
+		e.mu.Unlock()
+
+		EventEngineTasks.Add(1)
+		e.run(job)
+	}
+}
+
+// run runs a task of the session in the current worker.
+func (e *eventEngine) run(job engineJob) {
+	s := job.session
+	task := s.engineTask(job.taskType)
+	task.mu.Lock()
+	defer task.mu.Unlock()
+	if task.done {
+		return
+	}
+	var finished, more bool
+	func() {
+		switch job.taskType {
+		case engineInput:
+			defer crash.Recover("session input loop", func() { go s.Close() })
+			finished, more = s.runInputOnce(eventEngineBatchSegments)
+		case engineOutput:
+			defer crash.Recover("session output loop", func() { go s.Close() })
+			finished, more = s.runOutputOnce(eventEngineBatchSegments)
+		}
+	}()
+	if finished {
+		task.done = true
+		if s.engineFinished.Add(1) == 2 {
+			e.unregister(s)
+		}
+		s.wg.Done()
+		return
+	}
+	if more {
+		// Continue after the tasks already waiting for a worker.
+		e.schedule(s, job.taskType)
+	}
+	if job.taskType == engineInput {
+		// Send the acknowledgement of received segments.
+		e.schedule(s, engineOutput)
+	} else {
+		e.setTimer(s, s.sendQueue.Len() > 0 || s.sendBuf.Len() > 0)
+	}
+}
+
+// runTimers runs the output of sessions waiting to retransmit or send
+// segments every outputLoopInterval, and the output of all the sessions
+// every eventEngineSweepInterval.
+func (e *eventEngine) runTimers() {
+	ticker := time.NewTicker(outputLoopInterval)
+	defer ticker.Stop()
+	lastSweep := time.Now()
+	var sessions []*Session
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		}
+		sweep := time.Since(lastSweep) >= eventEngineSweepInterval
+		if sweep {
+			lastSweep = time.Now()
+		}
+		sessions = sessions[:0]
+		e.mu.Lock()
+		if sweep {
+			for s := range e.sessions {
+				sessions = append(sessions, s)
+			}
+		} else {
+			for s := range e.timers {
+				sessions = append(sessions, s)
+			}
+		}
+		e.mu.Unlock()
+		for _, s := range sessions {
+			e.schedule(s, engineOutput)
+		}
+	}
+}
+
+// engineTask returns the state of a type of task of the session.
+func (s *Session) engineTask(taskType engineTaskType) *engineTask {
+	if taskType == engineInput {
+		return &s.engineIn
+	}
+	return &s.engineOut
+}
+
+// startLoops starts to process the session, either with the event
+// driven engine of the underlay, or with the session's own goroutines.
+func (s *Session) startLoops(engine *eventEngine) {
+	if engine != nil {
+		engine.register(s)
+		return
+	}
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		defer crash.Recover("session input loop", func() { go s.Close() })
+		if err := s.runInputLoop(context.Background()); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
+			log.Debugf("%v runInputLoop(): %v", s, err)
+		}
+	}()
+	go func() {
+		defer s.wg.Done()
+		defer crash.Recover("session output loop", func() { go s.Close() })
+		if err := s.runOutputLoop(context.Background()); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
+			log.Debugf("%v runOutputLoop(): %v", s, err)
+		}
+	}()
+}
+
+// deliver passes a segment received by the underlay to the session.
+// If the session doesn't process the received segments fast enough,
+// a datagram is dropped, because datagrams are not reliable. Other
+// segments wait until the session has room for them, which pauses
+// the underlay read and pushes back the peer.
+func (s *Session) deliver(seg *segment) {
+	if s.engine == nil {
+		s.recvChan <- seg
+		return
+	}
+	select {
+	case s.recvChan <- seg:
+		s.engine.schedule(s, engineInput)
+		return
+	default:
+	}
+	EventEngineReceiveOverflows.Add(1)
+	p := seg.metadata.Protocol()
+	if p == datagramClientToServer || p == datagramServerToClient {
+		seg.release()
+		return
+	}
+	s.engine.schedule(s, engineInput)
+	select {
+	case s.recvChan <- seg:
+		// The input task may finish before the segment is added.
+		s.engine.schedule(s, engineInput)
+	case <-s.closedChan:
+		seg.release()
+	}
+}
+
+// runInputOnce processes at most maxSegments received segments of the
+// session without blocking. It returns true in finished if the session
+// is closed, and true in more if there are more segments to process.
+func (s *Session) runInputOnce(maxSegments int) (finished, more bool) {
+	for i := 0; i < maxSegments; i++ {
+		select {
+		case <-s.closedChan:
+			return true, false
+		default:
+		}
+		select {
+		case seg := <-s.recvChan:
+			if err := s.input(seg); err != nil {
+				err = fmt.Errorf("input() failed: %w", err)
+				log.Debugf("%v %v", s, err)
+				s.notifyInputErr(err)
+				s.closeWithError(err)
+				return true, false
+			}
+		default:
+			return false, false
+		}
+	}
+	return false, len(s.recvChan) > 0
+}
+
+// runOutputOnce sends at most maxSegments queued segments of the session,
+// and closes the session if it is idle. It returns true in finished if
+// the session is closed, and true in more if there are more segments
+// that can be sent now.
+func (s *Session) runOutputOnce(maxSegments int) (finished, more bool) {
+	select {
+	case <-s.closedChan:
+		return true, false
+	default:
+	}
+	if !s.engineIdleClosing && s.isIdle() {
+		log.Debugf("%v is idle for %v", s, s.idleTimeout)
+		SessionIdleClosed.Add(1)
+		s.engineIdleClosing = true
+		// Close the session in another goroutine, because
+		// graceful close waits for the output.
+		go s.Close()
+	}
+	switch s.conn.TransportProtocol() {
+	case common.StreamTransport:
+		more = s.runOutputOnceStream(maxSegments)
+	case common.PacketTransport:
+		more = s.runOutputOncePacket(maxSegments)
+	default:
+		err := fmt.Errorf("unsupported transport protocol %v", s.conn.TransportProtocol())
+		log.Debugf("%v %v", s, err)
+		s.notifyOutputErr(err)
+		s.closeWithError(err)
+		return true, false
+	}
+	return false, more
+}
+
+// closeFromInput closes the session after the input receives a close
+// session request or response. The event driven engine closes the
+// session in another goroutine, because graceful close waits for
+// the output, and the worker shouldn't be blocked.
+func (s *Session) closeFromInput() {
+	if s.engine != nil {
+		go s.Close()
+		return
+	}
+	s.Close()
+}
+
+// notifyEngineClosed wakes up the tasks of the closed session,
+// so they can finish.
+func (s *Session) notifyEngineClosed() {
+	if s.engine != nil {
+		s.engine.schedule(s, engineInput)
+		s.engine.schedule(s, engineOutput)
+	}
+}
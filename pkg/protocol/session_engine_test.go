@@ -0,0 +1,321 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+// startEngineTestServer starts a rot13 server that runs sessions with
+// the given engine. It returns the server mux and the properties of
+// the client.
+func startEngineTestServer(tb testing.TB, transport common.TransportProtocol, engine SessionEngine) (*Mux, *testtool.TestHelperServer, UnderlayProperties) {
+	var serverAddr net.Addr
+	if transport == common.StreamTransport {
+		port, err := common.UnusedTCPPort()
+		if err != nil {
+			tb.Fatalf("common.UnusedTCPPort() failed: %v", err)
+		}
+		serverAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	} else {
+		port, err := common.UnusedUDPPort()
+		if err != nil {
+			tb.Fatalf("common.UnusedUDPPort() failed: %v", err)
+		}
+		serverAddr = &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	}
+	serverProperties := NewUnderlayProperties(1400, transport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetServerSessionEngine(engine, 0).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		tb.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			tb.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	return serverMux, testServer, NewUnderlayProperties(1400, transport, nil, serverAddr)
+}
+
+func TestSessionEngineEventDriven(t *testing.T) {
+	testCases := []struct {
+		name      string
+		transport common.TransportProtocol
+	}{
+		{"TCP", common.StreamTransport},
+		{"UDP", common.PacketTransport},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			log.SetOutputToTest(t)
+			log.SetLevel("DEBUG")
+			serverMux, testServer, clientProperties := startEngineTestServer(t, tc.transport, SessionEngineEventDriven)
+			defer testServer.Close()
+
+			runClientMux(t, newTestClientMux(clientProperties, []byte("xiaochitang"), []byte("kuiranbudong")), 4, roundsPerFeatureTest)
+			if err := serverMux.Close(); err != nil {
+				t.Errorf("Server mux close failed: %v", err)
+			}
+			serverMux.engine.mu.Lock()
+			remaining := len(serverMux.engine.sessions)
+			serverMux.engine.mu.Unlock()
+			if remaining != 0 {
+				t.Errorf("%d sessions are still registered in the engine after the server is closed", remaining)
+			}
+		})
+	}
+}
+
+func TestSetServerSessionEngineInClient(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("SetServerSessionEngine() didn't panic in client mux")
+		}
+	}()
+	NewMux(true).SetServerSessionEngine(SessionEngineEventDriven, 0)
+}
+
+func TestEventEngineDeliverBackpressure(t *testing.T) {
+	testCases := []struct {
+		name      string
+		conn      Underlay
+		protocol  protocolType
+		wantBlock bool
+	}{
+		{"TCP data", &StreamUnderlay{}, dataClientToServer, true},
+		{"UDP data", &PacketUnderlay{}, dataClientToServer, true},
+		{"UDP datagram", &PacketUnderlay{}, datagramClientToServer, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The engine has no worker, so the received segments are not processed.
+			e := &eventEngine{
+				sessions: make(map[*Session]struct{}),
+				timers:   make(map[*Session]struct{}),
+				done:     make(chan struct{}),
+			}
+			e.cond = sync.NewCond(&e.mu)
+			s := NewSession(1, false, 1400, nil)
+			s.conn = tc.conn
+			s.engine = e
+
+			before := EventEngineReceiveOverflows.Load()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < segmentChanCapacity+10; i++ {
+					s.deliver(&segment{
+						metadata: &dataAckStruct{
+							baseStruct: baseStruct{protocol: uint8(tc.protocol)},
+							sessionID:  1,
+						},
+						transport: tc.conn.TransportProtocol(),
+					})
+				}
+			}()
+			select {
+			case <-done:
+				if tc.wantBlock {
+					t.Fatalf("deliver() doesn't wait when receive channel is full")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if !tc.wantBlock {
+					t.Fatalf("deliver() is blocked")
+				}
+			}
+			if tc.wantBlock {
+				// The blocked segments are delivered when the session has room.
+				for i := 0; i < 10; i++ {
+					<-s.recvChan
+				}
+				select {
+				case <-done:
+				case <-time.After(time.Second):
+					t.Fatalf("deliver() is still blocked")
+				}
+			}
+			if got := EventEngineReceiveOverflows.Load() - before; got < 1 || got > 10 {
+				t.Errorf("ReceiveOverflows increased by %d, want [1, 10]", got)
+			}
+			if len(s.recvChan) != segmentChanCapacity {
+				t.Errorf("got %d segments in receive channel, want %d", len(s.recvChan), segmentChanCapacity)
+			}
+			select {
+			case <-s.closedChan:
+				t.Errorf("session is closed")
+			default:
+			}
+		})
+	}
+}
+
+func TestRunInputOnceLimit(t *testing.T) {
+	s := NewSession(1, false, 1400, nil)
+	s.conn = &StreamUnderlay{}
+	for i := 0; i < eventEngineBatchSegments+10; i++ {
+		s.recvChan <- &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{protocol: uint8(ackClientToServer)},
+				sessionID:  1,
+			},
+			transport: common.StreamTransport,
+		}
+	}
+	if finished, more := s.runInputOnce(eventEngineBatchSegments); finished || !more {
+		t.Errorf("runInputOnce() = %v, %v, want false, true", finished, more)
+	}
+	if len(s.recvChan) != 10 {
+		t.Errorf("got %d segments in receive channel, want 10", len(s.recvChan))
+	}
+	if finished, more := s.runInputOnce(eventEngineBatchSegments); finished || more {
+		t.Errorf("runInputOnce() = %v, %v, want false, false", finished, more)
+	}
+	if len(s.recvChan) != 0 {
+		t.Errorf("got %d segments in receive channel, want 0", len(s.recvChan))
+	}
+}
+
+func TestRunOutputOnceStreamLimit(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	conn := &recordWritesConn{Conn: c1}
+	block, err := cipher.BlockCipherFromPassword(cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang")), false)
+	if err != nil {
+		t.Fatalf("cipher.BlockCipherFromPassword() failed: %v", err)
+	}
+	block.SetBlockContext(cipher.BlockContext{UserName: "xiaochitang"})
+	s := NewSession(1, true, 1400, nil)
+	s.conn = &StreamUnderlay{
+		baseUnderlay: *newBaseUnderlay(true, 1400),
+		conn:         conn,
+		send:         block,
+	}
+	for i := 0; i < 10; i++ {
+		s.sendQueue.Insert(&segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{protocol: uint8(dataClientToServer)},
+				sessionID:  1,
+				seq:        uint32(i),
+				payloadLen: 1,
+			},
+			payload:   []byte{'a'},
+			transport: common.StreamTransport,
+		})
+	}
+
+	if limited := s.runOutputOnceStream(4); !limited {
+		t.Errorf("runOutputOnceStream(4) = false, want true")
+	}
+	if len(conn.writes) != 4 || s.sendQueue.Len() != 6 {
+		t.Errorf("got %d writes and %d segments in send queue, want 4 and 6", len(conn.writes), s.sendQueue.Len())
+	}
+	if limited := s.runOutputOnceStream(0); limited {
+		t.Errorf("runOutputOnceStream(0) = true, want false")
+	}
+	if len(conn.writes) != 10 || s.sendQueue.Len() != 0 {
+		t.Errorf("got %d writes and %d segments in send queue, want 10 and 0", len(conn.writes), s.sendQueue.Len())
+	}
+}
+
+// benchmarkSessionEngine opens many idle sessions, then measures the
+// round trip time of the sessions. It reports the memory and goroutines
+// used by each session, which include the client side of the session
+// and the rot13 handler that are the same for all the engines.
+func benchmarkSessionEngine(b *testing.B, engine SessionEngine) {
+	const sessions = 500
+	log.SetLevel("WARN")
+	serverMux, testServer, clientProperties := startEngineTestServer(b, common.StreamTransport, engine)
+	defer testServer.Close()
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	goroutinesBefore := runtime.NumGoroutine()
+	conns := make([]net.Conn, 0, sessions)
+	for i := 0; i < sessions; i++ {
+		conn, err := clientMux.DialContext(context.Background())
+		if err != nil {
+			b.Fatalf("DialContext() failed: %v", err)
+		}
+		if err := echo(conn, testtool.TestHelperGenRot13Input(64)); err != nil {
+			b.Fatalf("echo() failed: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	time.Sleep(100 * time.Millisecond)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	goroutinesAfter := runtime.NumGoroutine()
+
+	payload := testtool.TestHelperGenRot13Input(1024)
+	rtts := make([]time.Duration, 0, b.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		if err := echo(conns[i%sessions], payload); err != nil {
+			b.Fatalf("echo() failed: %v", err)
+		}
+		rtts = append(rtts, time.Since(start))
+	}
+	b.StopTimer()
+
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	b.ReportMetric(float64(after.HeapInuse+after.StackInuse-before.HeapInuse-before.StackInuse)/sessions, "mem-B/session")
+	b.ReportMetric(float64(goroutinesAfter-goroutinesBefore)/sessions, "goroutines/session")
+	b.ReportMetric(float64(rtts[len(rtts)/2].Microseconds()), "p50-us")
+	b.ReportMetric(float64(rtts[len(rtts)*99/100].Microseconds()), "p99-us")
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	if err := clientMux.Close(); err != nil {
+		b.Errorf("Close client mux failed: %v", err)
+	}
+	if err := serverMux.Close(); err != nil {
+		b.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func BenchmarkSessionEngineGoroutine(b *testing.B) {
+	benchmarkSessionEngine(b, SessionEngineGoroutine)
+}
+
+func BenchmarkSessionEngineEventDriven(b *testing.B) {
+	benchmarkSessionEngine(b, SessionEngineEventDriven)
+}
@@ -0,0 +1,262 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/mathext"
+)
+
+const (
+	// rttSampleCount is the number of recent round trip time samples
+	// kept by a session for debugging.
+	rttSampleCount = 16
+
+	// maxDumpedSegments is the maximum number of segments of each
+	// queue or buffer included in a SessionState.
+	maxDumpedSegments = 64
+)
+
+// SessionState is a snapshot of the internal state of a Session.
+// It is used to debug sessions that stall or make slow progress.
+type SessionState struct {
+	ID         uint32    `json:"id"`
+	IsClient   bool      `json:"isClient"`
+	Protocol   string    `json:"protocol"`
+	LocalAddr  string    `json:"localAddr"`
+	RemoteAddr string    `json:"remoteAddr"`
+	State      string    `json:"state"`
+	Status     string    `json:"status"`
+	MTU        int       `json:"mtu"`
+	DumpTime   time.Time `json:"dumpTime"`
+	Error      string    `json:"error,omitempty"`
+
+	NextSend uint32 `json:"nextSend"`
+	NextRecv uint32 `json:"nextRecv"`
+	LastSend uint32 `json:"lastSend"`
+
+	SendQueue SegmentQueueState `json:"sendQueue"`
+	SendBuf   SegmentQueueState `json:"sendBuf"`
+	RecvBuf   SegmentQueueState `json:"recvBuf"`
+	RecvQueue SegmentQueueState `json:"recvQueue"`
+
+	// Ranges of sequence numbers sent but not acknowledged.
+	UnackedRanges []string `json:"unackedRanges"`
+
+	// Ranges of sequence numbers received out of order.
+	OutOfOrderRanges []string `json:"outOfOrderRanges"`
+
+	UnreadBytes      int    `json:"unreadBytes"`
+	RemoteWindowSize uint16 `json:"remoteWindowSize"`
+	CongestionWindow int64  `json:"congestionWindow"`
+	PacingRate       int64  `json:"pacingRate"`
+	Retransmissions  uint64 `json:"retransmissions"`
+
+	Timers SessionTimerState `json:"timers"`
+	RTT    SessionRTTState   `json:"rtt"`
+}
+
+// SegmentQueueState is a snapshot of a queue or buffer of segments.
+// At most maxDumpedSegments segments with the lowest sequence numbers
+// are included.
+type SegmentQueueState struct {
+	Len      int            `json:"len"`
+	Segments []SegmentState `json:"segments,omitempty"`
+}
+
+// SegmentState is a snapshot of a segment.
+type SegmentState struct {
+	Protocol   string    `json:"protocol"`
+	Seq        uint32    `json:"seq"`
+	PayloadLen int       `json:"payloadLen"`
+	TxCount    int       `json:"txCount,omitempty"`
+	AckCount   int       `json:"ackCount,omitempty"`
+	FirstTx    time.Time `json:"firstTx,omitempty"`
+	TxTime     time.Time `json:"txTime,omitempty"`
+	TxTimeout  string    `json:"txTimeout,omitempty"`
+}
+
+// SessionTimerState is a snapshot of the timers of a session.
+type SessionTimerState struct {
+	LastRecv     time.Time `json:"lastRecv"`
+	LastSend     time.Time `json:"lastSend"`
+	LastActivity time.Time `json:"lastActivity"`
+	IdleTimeout  string    `json:"idleTimeout"`
+	TxTimeLimit  string    `json:"txTimeLimit"`
+	TxCountLimit int       `json:"txCountLimit"`
+}
+
+// SessionRTTState is a snapshot of the round trip time estimation
+// of a session. Samples are ordered from the oldest to the newest.
+type SessionRTTState struct {
+	Smoothed  string   `json:"smoothed"`
+	Variance  string   `json:"variance"`
+	Min       string   `json:"min"`
+	Latest    string   `json:"latest"`
+	RTO       string   `json:"rto"`
+	Samples   []string `json:"samples"`
+	SampleNum uint32   `json:"sampleNum"`
+}
+
+// State returns a snapshot of the internal state of the session.
+func (s *Session) State() SessionState {
+	state := SessionState{
+		ID:       s.id,
+		IsClient: s.isClient,
+		MTU:      s.mtu,
+		DumpTime: time.Now(),
+	}
+	switch s.conn.(type) {
+	case *StreamUnderlay:
+		state.Protocol = "TCP"
+	case *PacketUnderlay:
+		state.Protocol = "UDP"
+	default:
+		state.Protocol = "UNKNOWN"
+	}
+	if s.conn != nil {
+		state.LocalAddr = s.LocalAddr().String()
+		state.RemoteAddr = s.RemoteAddr().String()
+	}
+	s.sLock.Lock()
+	state.State = s.state.String()
+	state.Status = s.status.String()
+	s.sLock.Unlock()
+	if err := s.brokenErr.Load(); err != nil {
+		state.Error = (*err).Error()
+	}
+
+	// Hold oLock to take a consistent view of the send side.
+	s.oLock.Lock()
+	state.NextSend = s.nextSend
+	state.LastSend = s.lastSend
+	state.SendQueue = dumpSegmentTree(s.sendQueue)
+	state.SendBuf = dumpSegmentTree(s.sendBuf)
+	state.UnackedRanges = seqRanges(s.sendBuf)
+	state.RemoteWindowSize = s.remoteWindowSize
+	if s.sendAlgorithm != nil {
+		state.CongestionWindow = s.sendAlgorithm.CongestionWindow()
+		state.PacingRate = s.sendAlgorithm.PacingRate()
+	} else {
+		state.CongestionWindow = int64(s.legacysendAlgorithm.CongestionWindowSize())
+	}
+	state.Timers.LastSend = s.lastTXTime
+	s.oLock.Unlock()
+
+	state.NextRecv = s.nextRecv
+	state.RecvBuf = dumpSegmentTree(s.recvBuf)
+	state.RecvQueue = dumpSegmentTree(s.recvQueue)
+	for _, r := range s.sackRanges() {
+		state.OutOfOrderRanges = append(state.OutOfOrderRanges, r.String())
+	}
+	state.UnreadBytes = len(s.unreadBuf)
+	state.Retransmissions = s.retransmissions.Load()
+
+	state.Timers.LastRecv = s.lastRXTime
+	state.Timers.LastActivity = time.Unix(0, s.lastActivity.Load())
+	state.Timers.IdleTimeout = s.idleTimeout.String()
+	state.Timers.TxTimeLimit = s.txTimeLimit.String()
+	state.Timers.TxCountLimit = s.txCountLimit
+
+	state.RTT = SessionRTTState{
+		Smoothed:  time.Duration(s.smoothedRTT.Load()).String(),
+		Variance:  time.Duration(s.rttVariance.Load()).String(),
+		Min:       s.rttStat.MinRTT().String(),
+		Latest:    s.rttStat.LatestRTT().String(),
+		RTO:       s.rttStat.RTO().String(),
+		SampleNum: s.rttSampleIndex.Load(),
+	}
+	n := state.RTT.SampleNum
+	for i := n - uint32(mathext.Min(int(n), rttSampleCount)) + 1; i <= n; i++ {
+		state.RTT.Samples = append(state.RTT.Samples, time.Duration(s.rttSamples[i%rttSampleCount].Load()).String())
+	}
+	return state
+}
+
+// dumpSegmentTree returns a snapshot of the segments in the tree.
+func dumpSegmentTree(t *segmentTree) SegmentQueueState {
+	res := SegmentQueueState{Len: t.Len()}
+	t.Ascend(func(iter *segment) bool {
+		if len(res.Segments) >= maxDumpedSegments {
+			return false
+		}
+		seq, _ := iter.Seq()
+		ss := SegmentState{
+			Protocol:   iter.Protocol().String(),
+			Seq:        seq,
+			PayloadLen: len(iter.payload),
+			TxCount:    int(iter.txCount),
+			AckCount:   int(iter.ackCount),
+			FirstTx:    iter.firstTx,
+			TxTime:     iter.txTime,
+		}
+		if iter.txTimeout > 0 {
+			ss.TxTimeout = iter.txTimeout.String()
+		}
+		res.Segments = append(res.Segments, ss)
+		return true
+	})
+	return res
+}
+
+// seqRanges returns the ranges of consecutive sequence numbers
+// in the tree.
+func seqRanges(t *segmentTree) []string {
+	var ranges []sackRange
+	t.Ascend(func(iter *segment) bool {
+		seq, err := iter.Seq()
+		if err != nil {
+			return true
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].end+1 == seq {
+			ranges[n-1].end = seq
+			return true
+		}
+		ranges = append(ranges, sackRange{start: seq, end: seq})
+		return true
+	})
+	res := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		res = append(res, r.String())
+	}
+	return res
+}
+
+// SessionStates returns snapshots of the internal state of the sessions.
+// If sessionID is 0, all the sessions are returned. Otherwise, only the
+// session with the ID is returned.
+func (b *baseUnderlay) SessionStates(sessionID uint32) []SessionState {
+	res := make([]SessionState, 0)
+	b.sessionMap.Range(func(k, v any) bool {
+		s := v.(*Session)
+		if sessionID == 0 || s.id == sessionID {
+			res = append(res, s.State())
+		}
+		return true
+	})
+	return res
+}
+
+// ExportSessionStates returns snapshots of the internal state of the
+// sessions. If sessionID is 0, all the sessions are returned.
+func (m *Mux) ExportSessionStates(sessionID uint32) []SessionState {
+	res := make([]SessionState, 0)
+	for _, underlay := range m.pool.all() {
+		res = append(res, underlay.SessionStates(sessionID)...)
+	}
+	return res
+}
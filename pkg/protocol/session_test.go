@@ -0,0 +1,571 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSessionRetransmissionLimit(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+
+	// The server never responds.
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	defer server.Close()
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, server.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetRetransmissionLimit(0, 200*time.Millisecond)
+	defer clientMux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	start := time.Now()
+	_, err = conn.Read(make([]byte, 16))
+	var limitErr *RetransmissionLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Read() got error %v, want RetransmissionLimitError", err)
+	}
+	if !errors.Is(err, stderror.ErrDisconnected) {
+		t.Errorf("errors.Is(%v, stderror.ErrDisconnected) = false", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("session is closed after %v, want about 200ms", elapsed)
+	}
+	if _, err := conn.Write([]byte("hello")); !errors.As(err, &limitErr) {
+		t.Errorf("Write() after session is broken got error %v, want RetransmissionLimitError", err)
+	}
+}
+
+func TestSessionCheckQuotaExpiredUser(t *testing.T) {
+	users := map[string]*appctlpb.User{
+		"active": {
+			Name:       proto.String("active"),
+			ExpireTime: proto.String(time.Now().Add(time.Hour).Format(time.RFC3339)),
+		},
+		"expired": {
+			Name:       proto.String("expired"),
+			ExpireTime: proto.String(time.Now().Add(-time.Hour).Format(time.RFC3339)),
+		},
+	}
+	s := NewSession(1, false, 1500, users)
+	if ok, err := s.checkQuota("active"); !ok {
+		t.Errorf("checkQuota() of active user = false, %v", err)
+	}
+	if ok, _ := s.checkQuota("expired"); ok {
+		t.Errorf("checkQuota() of expired user = true")
+	}
+}
+
+func TestSessionStats(t *testing.T) {
+	s := NewSession(1, true, 1400, nil)
+	s.updateRTT(100 * time.Millisecond)
+	seg := &segment{
+		metadata: &dataAckStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(dataClientToServer),
+			},
+			sessionID: 1,
+		},
+		payload: make([]byte, 100),
+	}
+	if !s.sendBuf.Insert(seg) {
+		t.Fatalf("Insert() failed")
+	}
+
+	stats := s.Stats()
+	if stats.SmoothedRTT != 100*time.Millisecond {
+		t.Errorf("SmoothedRTT = %v, want %v", stats.SmoothedRTT, 100*time.Millisecond)
+	}
+	if stats.RTTVariance != 50*time.Millisecond {
+		t.Errorf("RTTVariance = %v, want %v", stats.RTTVariance, 50*time.Millisecond)
+	}
+	if stats.BytesInFlight != int64(packetOverhead+100) {
+		t.Errorf("BytesInFlight = %d, want %d", stats.BytesInFlight, packetOverhead+100)
+	}
+}
+
+func TestSessionDestination(t *testing.T) {
+	s := NewSession(1, false, 1400, nil)
+	if got := s.Destination(); got != "" {
+		t.Errorf("Destination() = %q, want empty", got)
+	}
+	s.SetDestination("example.com:443")
+	if got := s.Destination(); got != "example.com:443" {
+		t.Errorf("Destination() = %q, want %q", got, "example.com:443")
+	}
+	s.bytesRead.Add(10)
+	s.bytesWritten.Add(20)
+	stats := s.Stats()
+	if stats.BytesRead != 10 || stats.BytesWritten != 20 {
+		t.Errorf("BytesRead, BytesWritten = %d, %d, want 10, 20", stats.BytesRead, stats.BytesWritten)
+	}
+}
+
+func TestSessionState(t *testing.T) {
+	s := NewSession(1, true, 1400, nil)
+	for i := 1; i <= rttSampleCount+4; i++ {
+		s.updateRTT(time.Duration(i) * time.Millisecond)
+	}
+	s.nextSend = 6
+	for _, seq := range []uint32{1, 2, 3, 5} {
+		seg := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataClientToServer),
+				},
+				sessionID: 1,
+				seq:       seq,
+			},
+			payload: make([]byte, 10),
+			txCount: 1,
+		}
+		if !s.sendBuf.Insert(seg) {
+			t.Fatalf("Insert() failed")
+		}
+	}
+
+	state := s.State()
+	if state.ID != 1 || state.NextSend != 6 || state.State != sessionInit.String() {
+		t.Errorf("State() = %+v, want ID 1, NextSend 6 and state %v", state, sessionInit)
+	}
+	if state.SendBuf.Len != 4 || len(state.SendBuf.Segments) != 4 {
+		t.Errorf("SendBuf = %+v, want 4 segments", state.SendBuf)
+	}
+	if want := []string{"[1, 3]", "[5, 5]"}; !reflect.DeepEqual(state.UnackedRanges, want) {
+		t.Errorf("UnackedRanges = %v, want %v", state.UnackedRanges, want)
+	}
+	if len(state.RTT.Samples) != rttSampleCount {
+		t.Fatalf("got %d RTT samples, want %d", len(state.RTT.Samples), rttSampleCount)
+	}
+	if got, want := state.RTT.Samples[rttSampleCount-1], (rttSampleCount+4)*time.Millisecond; got != want.String() {
+		t.Errorf("newest RTT sample = %s, want %v", got, want)
+	}
+	if got, want := state.RTT.Samples[0], 5*time.Millisecond; got != want.String() {
+		t.Errorf("oldest RTT sample = %s, want %v", got, want)
+	}
+	if _, err := json.Marshal(state); err != nil {
+		t.Errorf("json.Marshal() failed: %v", err)
+	}
+}
+
+func TestSessionSACKRanges(t *testing.T) {
+	s := NewSession(1, true, 1400, nil)
+	s.nextRecv = 10
+	for _, seq := range []uint32{12, 13, 14, 16, 20, 21} {
+		seg := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataServerToClient),
+				},
+				sessionID: 1,
+				seq:       seq,
+			},
+		}
+		if !s.recvBuf.Insert(seg) {
+			t.Fatalf("Insert() failed")
+		}
+	}
+	want := []sackRange{{start: 12, end: 14}, {start: 16, end: 16}, {start: 20, end: 21}}
+	if got := s.sackRanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sackRanges() = %v, want %v", got, want)
+	}
+
+	// Only the lowest ranges are reported.
+	for i := 0; i < maxSACKRanges; i++ {
+		seg := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataServerToClient),
+				},
+				sessionID: 1,
+				seq:       uint32(100 + 2*i),
+			},
+		}
+		if !s.recvBuf.Insert(seg) {
+			t.Fatalf("Insert() failed")
+		}
+	}
+	got := s.sackRanges()
+	if len(got) != maxSACKRanges {
+		t.Fatalf("sackRanges() returned %d ranges, want %d", len(got), maxSACKRanges)
+	}
+	if !reflect.DeepEqual(got[:3], want) {
+		t.Errorf("sackRanges() = %v, want prefix %v", got, want)
+	}
+}
+
+func TestSessionInputSelectiveAck(t *testing.T) {
+	s := NewSession(1, true, 1400, nil)
+	s.conn = &PacketUnderlay{}
+	for seq := uint32(0); seq < 10; seq++ {
+		seg := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataClientToServer),
+				},
+				sessionID: 1,
+				seq:       seq,
+			},
+			payload: make([]byte, 100),
+			txTime:  time.Now(),
+		}
+		if !s.sendBuf.Insert(seg) {
+			t.Fatalf("Insert() failed")
+		}
+	}
+
+	// The peer received segment 0 - 2, 4 - 5 and 8.
+	ranges := []sackRange{{start: 4, end: 5}, {start: 8, end: 8}}
+	payload := marshalSACKRanges(ranges)
+	newAck := func() *segment {
+		return &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(ackServerToClient),
+				},
+				sessionID:  1,
+				unAckSeq:   3,
+				windowSize: minWindowSize,
+				payloadLen: uint16(len(payload)),
+				sackCount:  uint8(len(ranges)),
+			},
+			payload:   append([]byte{}, payload...),
+			transport: common.PacketTransport,
+		}
+	}
+	checkSendBuf := func(wantAckCounts map[uint32]byte) {
+		t.Helper()
+		var remaining []uint32
+		ackCounts := make(map[uint32]byte)
+		s.sendBuf.Ascend(func(iter *segment) bool {
+			seq, _ := iter.Seq()
+			remaining = append(remaining, seq)
+			ackCounts[seq] = iter.ackCount
+			return true
+		})
+		if want := []uint32{3, 6, 7, 9}; !reflect.DeepEqual(remaining, want) {
+			t.Errorf("segments in send buffer = %v, want %v", remaining, want)
+		}
+		for seq, want := range wantAckCounts {
+			if ackCounts[seq] != want {
+				t.Errorf("ackCount of segment %d = %d, want %d", seq, ackCounts[seq], want)
+			}
+		}
+	}
+
+	// The first ACK advances unAckSeq, so it is not a duplicate ACK.
+	if err := s.inputAck(newAck()); err != nil {
+		t.Fatalf("inputAck() failed: %v", err)
+	}
+	checkSendBuf(map[uint32]byte{3: 0, 6: 0, 7: 0, 9: 0})
+
+	// The same ACK again is a duplicate ACK.
+	if err := s.inputAck(newAck()); err != nil {
+		t.Fatalf("inputAck() failed: %v", err)
+	}
+	checkSendBuf(map[uint32]byte{3: 1, 6: 1, 7: 1, 9: 0})
+}
+
+func TestSessionNoDuplicateAckWithoutLoss(t *testing.T) {
+	s := NewSession(1, true, 1400, nil)
+	s.conn = &PacketUnderlay{}
+	for seq := uint32(0); seq < 10; seq++ {
+		seg := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataClientToServer),
+				},
+				sessionID: 1,
+				seq:       seq,
+			},
+			payload: make([]byte, 100),
+			txTime:  time.Now(),
+		}
+		if !s.sendBuf.Insert(seg) {
+			t.Fatalf("Insert() failed")
+		}
+	}
+
+	// The peer sends data in the other direction without loss.
+	// Every data segment and ACK carries the same unAckSeq,
+	// because the peer has not received the next segment yet.
+	for seq := uint32(0); seq < 10; seq++ {
+		data := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(dataServerToClient),
+				},
+				sessionID:  1,
+				seq:        seq,
+				unAckSeq:   5,
+				windowSize: minWindowSize,
+				payloadLen: 1,
+			},
+			payload:   []byte{'a'},
+			transport: common.PacketTransport,
+		}
+		if err := s.inputData(data); err != nil {
+			t.Fatalf("inputData() failed: %v", err)
+		}
+		ack := &segment{
+			metadata: &dataAckStruct{
+				baseStruct: baseStruct{
+					protocol: uint8(ackServerToClient),
+				},
+				sessionID:  1,
+				unAckSeq:   5,
+				windowSize: minWindowSize,
+			},
+			transport: common.PacketTransport,
+		}
+		if err := s.inputAck(ack); err != nil {
+			t.Fatalf("inputAck() failed: %v", err)
+		}
+	}
+	s.sendBuf.Ascend(func(iter *segment) bool {
+		if iter.ackCount != 0 {
+			seq, _ := iter.Seq()
+			t.Errorf("ackCount of segment %d = %d, want 0", seq, iter.ackCount)
+		}
+		return true
+	})
+}
+
+func TestSessionOpenEarlyData(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+
+	// The server never responds.
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	defer server.Close()
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, server.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	data := make([]byte, 4096)
+	if n, err := conn.Write(data); err != nil || n != len(data) {
+		t.Fatalf("Write() = %d, %v, want %d, nil", n, err, len(data))
+	}
+
+	session := conn.(*Session)
+	session.oLock.Lock()
+	defer session.oLock.Unlock()
+	var openRequest *segment
+	findOpenRequest := func(iter *segment) bool {
+		if iter.Protocol() == openSessionRequest {
+			openRequest = iter
+			return false
+		}
+		return true
+	}
+	session.sendQueue.Ascend(findOpenRequest)
+	if openRequest == nil {
+		session.sendBuf.Ascend(findOpenRequest)
+	}
+	if openRequest == nil {
+		t.Fatalf("open session request is not found")
+	}
+	if len(openRequest.payload) != MaxSessionOpenPayload {
+		t.Errorf("open session request carries %d bytes, want %d", len(openRequest.payload), MaxSessionOpenPayload)
+	}
+}
+
+func TestSessionPriority(t *testing.T) {
+	// The server never responds.
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	defer server.Close()
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, server.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(WithSessionPriority(ctx, SessionPriorityHigh))
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	session := conn.(*Session)
+	if session.Priority() != SessionPriorityHigh {
+		t.Errorf("Priority() = %v, want %v", session.Priority(), SessionPriorityHigh)
+	}
+	if got := session.conn.(*PacketUnderlay).egressWeight(session.id); got != SessionPriorityHigh.weight() {
+		t.Errorf("egressWeight() = %d, want %d", got, SessionPriorityHigh.weight())
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	// The priority is sent to the server with open session request.
+	session.oLock.Lock()
+	defer session.oLock.Unlock()
+	var openRequest *segment
+	findOpenRequest := func(iter *segment) bool {
+		if iter.Protocol() == openSessionRequest {
+			openRequest = iter
+			return false
+		}
+		return true
+	}
+	session.sendQueue.Ascend(findOpenRequest)
+	if openRequest == nil {
+		session.sendBuf.Ascend(findOpenRequest)
+	}
+	if openRequest == nil {
+		t.Fatalf("open session request is not found")
+	}
+	if got := SessionPriority(openRequest.metadata.(*sessionStruct).priority); got != SessionPriorityHigh {
+		t.Errorf("open session request has priority %v, want %v", got, SessionPriorityHigh)
+	}
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	// The server never responds.
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	defer server.Close()
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, server.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetClientIdleTimeout(200*time.Millisecond, 0)
+	defer clientMux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	session := conn.(*Session)
+	if session.isIdle() {
+		t.Errorf("isIdle() = true right after the session is created")
+	}
+
+	idleClosed := SessionIdleClosed.Load()
+	select {
+	case <-session.closedChan:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("idle session is not closed")
+	}
+	if got := SessionIdleClosed.Load(); got <= idleClosed {
+		t.Errorf("SessionIdleClosed = %d, want > %d", got, idleClosed)
+	}
+}
+
+func TestSessionFlowControl(t *testing.T) {
+	mux := NewMux(true).SetFlowControl(10000, 20000, 0)
+	opts := mux.sessionOpts
+	if opts.bufferCapacity != 20000 {
+		t.Errorf("buffer capacity = %d, want %d", opts.bufferCapacity, 20000)
+	}
+
+	s := NewSession(1, true, 1400, nil)
+	if err := s.applyOptions(opts); err != nil {
+		t.Fatalf("applyOptions() failed: %v", err)
+	}
+	if s.sendBuf.Remaining() != 20000 || s.recvBuf.Remaining() != 20000 {
+		t.Errorf("buffer capacity = %d and %d, want %d", s.sendBuf.Remaining(), s.recvBuf.Remaining(), 20000)
+	}
+
+	// Receive 500 Mbps with 200 ms round trip time.
+	s.recvRate = newReceiveRateEstimator(500 * 1000 * 1000 / 8)
+	if got := s.receiveWindowSize(); got <= maxWindowSize {
+		t.Errorf("receiveWindowSize() = %d, want more than %d", got, maxWindowSize)
+	}
+	s.maxRecvWindowSize = maxWindowSize
+	if got := s.receiveWindowSize(); got != maxWindowSize {
+		t.Errorf("receiveWindowSize() = %d, want %d", got, maxWindowSize)
+	}
+}
+
+func TestSessionBandwidth(t *testing.T) {
+	// Without the bandwidth, the initial receive rate is used.
+	s := NewSession(1, true, 1400, nil)
+	if err := s.applyOptions(NewMux(true).sessionOpts); err != nil {
+		t.Fatalf("applyOptions() failed: %v", err)
+	}
+	if got := s.recvRate.Rate(); got != initialReceiveRate {
+		t.Errorf("receive rate = %d, want %d", got, initialReceiveRate)
+	}
+	defaultWindow := s.receiveWindowSize()
+
+	// 200 Mbps with 200 ms round trip time fills more segments.
+	mux := NewMux(true).SetFlowControl(0, MaxFlowControlWindow, 0).SetBandwidth(200 * 1000 * 1000 / 8)
+	s = NewSession(2, true, 1400, nil)
+	if err := s.applyOptions(mux.sessionOpts); err != nil {
+		t.Fatalf("applyOptions() failed: %v", err)
+	}
+	if got := s.recvRate.Rate(); got != 200*1000*1000/8 {
+		t.Errorf("receive rate = %d, want %d", got, 200*1000*1000/8)
+	}
+	want := bdpWindowSize(200*1000*1000/8, initialWindowRTT, 1400, MaxFlowControlWindow)
+	if got := s.receiveWindowSize(); int(got) != want || got <= defaultWindow {
+		t.Errorf("receiveWindowSize() = %d, want %d and more than %d", got, want, defaultWindow)
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// A client IP address that opens too many underlays at the same time, or
+// starts too many handshakes in a short time, is blocked for a while. The
+// penalty doubles each time the IP address exceeds the limits again, so a
+// prober that keeps trying is blocked for longer and longer, while a client
+// that exceeds the limits by accident is soon allowed again.
+
+const (
+	// DefaultSourcePenalty is the penalty of the first violation
+	// if it is not set.
+	DefaultSourcePenalty = 10 * time.Second
+
+	// DefaultSourceMaxPenalty is the maximum penalty if it is not set.
+	DefaultSourceMaxPenalty = time.Hour
+
+	// sourceHandshakeWindow is the window to count the handshake attempts.
+	sourceHandshakeWindow = time.Minute
+
+	// sourceCleanInterval is the interval to remove idle source IP addresses.
+	sourceCleanInterval = time.Minute
+)
+
+var (
+	// UnderlaySourceRejects is the number of connections and handshake
+	// packets rejected by the source limits.
+	UnderlaySourceRejects = metrics.RegisterMetric("underlay", "SourceRejects", metrics.COUNTER)
+
+	// UnderlaySourcePenalties is the number of times a source IP address
+	// is blocked.
+	UnderlaySourcePenalties = metrics.RegisterMetric("underlay", "SourcePenalties", metrics.COUNTER)
+
+	// UnderlaySourceBlocked is the number of source IP addresses
+	// that are blocked.
+	UnderlaySourceBlocked = metrics.RegisterMetric("underlay", "SourceBlocked", metrics.GAUGE)
+)
+
+// SourceLimit limits the resources used by each client IP address
+// in a server mux. A zero value means no limit.
+type SourceLimit struct {
+	// MaxUnderlays is the maximum number of concurrent TCP underlays.
+	MaxUnderlays int
+
+	// MaxHandshakesPerMinute is the maximum number of TCP connections and
+	// UDP handshake packets in a minute.
+	MaxHandshakesPerMinute int
+
+	// Penalty is how long the IP address is blocked after it exceeds
+	// a limit for the first time. It doubles on each violation that
+	// follows within MaxPenalty, up to MaxPenalty.
+	Penalty time.Duration
+
+	// MaxPenalty is the maximum time the IP address is blocked.
+	MaxPenalty time.Duration
+}
+
+// enabled returns true if any limit is set.
+func (l SourceLimit) enabled() bool {
+	return l.MaxUnderlays > 0 || l.MaxHandshakesPerMinute > 0
+}
+
+// sourceState is the usage of a client IP address.
+type sourceState struct {
+	underlays     int
+	windowStart   time.Time
+	handshakes    int
+	violations    int
+	lastViolation time.Time
+	blockedUntil  time.Time
+}
+
+// sourceLimiter enforces the SourceLimit. A nil sourceLimiter doesn't
+// limit anything.
+type sourceLimiter struct {
+	limit     SourceLimit
+	mu        sync.Mutex
+	sources   map[string]*sourceState
+	lastClean time.Time
+}
+
+func newSourceLimiter(limit SourceLimit) *sourceLimiter {
+	if !limit.enabled() {
+		return nil
+	}
+	if limit.Penalty <= 0 {
+		limit.Penalty = DefaultSourcePenalty
+	}
+	if limit.MaxPenalty < limit.Penalty {
+		limit.MaxPenalty = DefaultSourceMaxPenalty
+		if limit.MaxPenalty < limit.Penalty {
+			limit.MaxPenalty = limit.Penalty
+		}
+	}
+	return &sourceLimiter{
+		limit:   limit,
+		sources: make(map[string]*sourceState),
+	}
+}
+
+// acquireUnderlay returns true if a new TCP underlay from the IP address
+// is allowed. The caller must call releaseUnderlay after the underlay
+// is closed if it returns true.
+func (l *sourceLimiter) acquireUnderlay(ip net.IP, now time.Time) bool {
+	if l == nil || ip == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.admit(ip, now)
+	if !ok {
+		return false
+	}
+	if l.limit.MaxUnderlays > 0 && s.underlays >= l.limit.MaxUnderlays {
+		l.penalize(s, now)
+		return false
+	}
+	s.underlays++
+	return true
+}
+
+// releaseUnderlay releases a TCP underlay from the IP address.
+func (l *sourceLimiter) releaseUnderlay(ip net.IP) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, found := l.sources[ip.String()]; found && s.underlays > 0 {
+		s.underlays--
+	}
+}
+
+// allowHandshake returns true if a UDP handshake packet from the IP address
+// is allowed.
+func (l *sourceLimiter) allowHandshake(ip net.IP, now time.Time) bool {
+	if l == nil || ip == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.admit(ip, now)
+	return ok
+}
+
+// admit counts a handshake attempt from the IP address. It returns false
+// if the IP address is blocked or exceeds the handshake limit.
+// The caller must hold mu.
+func (l *sourceLimiter) admit(ip net.IP, now time.Time) (*sourceState, bool) {
+	if now.Sub(l.lastClean) >= sourceCleanInterval {
+		l.clean(now)
+	}
+	key := ip.String()
+	s, found := l.sources[key]
+	if !found {
+		s = &sourceState{windowStart: now}
+		l.sources[key] = s
+	}
+	if now.Before(s.blockedUntil) {
+		UnderlaySourceRejects.Add(1)
+		return s, false
+	}
+	if now.Sub(s.windowStart) >= sourceHandshakeWindow {
+		s.windowStart = now
+		s.handshakes = 0
+	}
+	s.handshakes++
+	if l.limit.MaxHandshakesPerMinute > 0 && s.handshakes > l.limit.MaxHandshakesPerMinute {
+		l.penalize(s, now)
+		return s, false
+	}
+	return s, true
+}
+
+// penalize blocks the IP address. The caller must hold mu.
+func (l *sourceLimiter) penalize(s *sourceState, now time.Time) {
+	UnderlaySourceRejects.Add(1)
+	UnderlaySourcePenalties.Add(1)
+	if now.Sub(s.lastViolation) > l.limit.MaxPenalty {
+		s.violations = 0
+	}
+	penalty := l.limit.Penalty
+	for i := 0; i < s.violations && penalty < l.limit.MaxPenalty; i++ {
+		penalty *= 2
+	}
+	if penalty > l.limit.MaxPenalty {
+		penalty = l.limit.MaxPenalty
+	}
+	s.violations++
+	s.lastViolation = now
+	s.blockedUntil = now.Add(penalty)
+	s.windowStart = s.blockedUntil
+	s.handshakes = 0
+}
+
+// clean removes the IP addresses that don't need to be remembered,
+// and updates the number of blocked IP addresses. The caller must hold mu.
+func (l *sourceLimiter) clean(now time.Time) {
+	l.lastClean = now
+	blocked := 0
+	for key, s := range l.sources {
+		if now.Before(s.blockedUntil) {
+			blocked++
+			continue
+		}
+		if s.underlays == 0 && now.Sub(s.windowStart) >= sourceHandshakeWindow && now.Sub(s.lastViolation) > l.limit.MaxPenalty {
+			delete(l.sources, key)
+		}
+	}
+	UnderlaySourceBlocked.Store(int64(blocked))
+}
+
+// sourceIP returns the IP address of a TCP or UDP address,
+// or nil if it is not supported.
+func sourceIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	default:
+		return nil
+	}
+}
+
+// sourceConn releases the underlay of the source IP address
+// when the connection is closed.
+type sourceConn struct {
+	net.Conn
+	release func()
+	once    sync.Once
+}
+
+func (c *sourceConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// NetConn returns the underlying connection.
+func (c *sourceConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// SyscallConn implements syscall.Conn, so socket options can be applied.
+func (c *sourceConn) SyscallConn() (syscall.RawConn, error) {
+	if sc, ok := c.Conn.(syscall.Conn); ok {
+		return sc.SyscallConn()
+	}
+	return nil, stderror.ErrUnsupported
+}
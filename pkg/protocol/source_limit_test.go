@@ -0,0 +1,118 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSourceLimiterDisabled(t *testing.T) {
+	if l := newSourceLimiter(SourceLimit{}); l != nil {
+		t.Fatalf("newSourceLimiter() returns a limiter without limits")
+	}
+	var l *sourceLimiter
+	ip := net.ParseIP("192.0.2.1")
+	if !l.acquireUnderlay(ip, time.Now()) {
+		t.Errorf("nil limiter rejects underlay")
+	}
+	if !l.allowHandshake(ip, time.Now()) {
+		t.Errorf("nil limiter rejects handshake")
+	}
+	l.releaseUnderlay(ip)
+}
+
+func TestSourceLimiterUnderlays(t *testing.T) {
+	l := newSourceLimiter(SourceLimit{
+		MaxUnderlays: 2,
+		Penalty:      10 * time.Second,
+		MaxPenalty:   time.Minute,
+	})
+	ip := net.ParseIP("192.0.2.1")
+	other := net.ParseIP("2001:db8::1")
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if !l.acquireUnderlay(ip, now) {
+			t.Fatalf("underlay %d is rejected", i)
+		}
+	}
+	if l.acquireUnderlay(ip, now) {
+		t.Fatalf("underlay above the limit is allowed")
+	}
+	if !l.acquireUnderlay(other, now) {
+		t.Errorf("underlay from another IP is rejected")
+	}
+
+	// The IP is blocked during the penalty even after an underlay is released.
+	l.releaseUnderlay(ip)
+	if l.acquireUnderlay(ip, now.Add(5*time.Second)) {
+		t.Errorf("underlay is allowed during the penalty")
+	}
+	if !l.acquireUnderlay(ip, now.Add(10*time.Second)) {
+		t.Errorf("underlay is rejected after the penalty")
+	}
+}
+
+func TestSourceLimiterHandshakePenalty(t *testing.T) {
+	l := newSourceLimiter(SourceLimit{
+		MaxHandshakesPerMinute: 3,
+		Penalty:                10 * time.Second,
+		MaxPenalty:             30 * time.Second,
+	})
+	ip := net.ParseIP("192.0.2.1")
+	now := time.Now()
+
+	// Each violation doubles the penalty, up to the maximum.
+	for _, penalty := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		for i := 0; i < 3; i++ {
+			if !l.allowHandshake(ip, now) {
+				t.Fatalf("handshake %d is rejected", i)
+			}
+		}
+		if l.allowHandshake(ip, now) {
+			t.Fatalf("handshake above the limit is allowed")
+		}
+		if l.allowHandshake(ip, now.Add(penalty-time.Second)) {
+			t.Errorf("handshake is allowed before the penalty %v ends", penalty)
+		}
+		now = now.Add(penalty)
+	}
+
+	// Violations are forgotten after a quiet period.
+	now = now.Add(time.Minute)
+	for i := 0; i < 4; i++ {
+		l.allowHandshake(ip, now)
+	}
+	if !l.allowHandshake(ip, now.Add(10*time.Second)) {
+		t.Errorf("penalty is not reset after a quiet period")
+	}
+}
+
+func TestSourceLimiterClean(t *testing.T) {
+	l := newSourceLimiter(SourceLimit{MaxHandshakesPerMinute: 1})
+	now := time.Now()
+	l.allowHandshake(net.ParseIP("192.0.2.1"), now)
+	l.allowHandshake(net.ParseIP("192.0.2.2"), now)
+	l.allowHandshake(net.ParseIP("192.0.2.2"), now)
+	l.mu.Lock()
+	l.clean(now.Add(2 * time.Minute))
+	n := len(l.sources)
+	l.mu.Unlock()
+	if n != 1 {
+		t.Errorf("got %d sources after clean, want 1", n)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+const (
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+var (
+	UnderlayTLSHandshakeErrors = metrics.RegisterMetric("underlay", "TLSHandshakeErrors", metrics.COUNTER)
+)
+
+// tlsNextProtos are the application protocols offered in the handshake,
+// the same as a web browser.
+var tlsNextProtos = []string{"h2", "http/1.1"}
+
+// TLSUnderlay is a StreamUnderlay that sends and receives segments inside
+// TLS application data records. To an observer, the connection looks like
+// a HTTPS connection to the server.
+type TLSUnderlay struct {
+	*StreamUnderlay
+}
+
+var _ Underlay = &TLSUnderlay{}
+
+// NewTLSUnderlay connects to the remote address "raddr" on the network,
+// finishes the TLS handshake, and then uses the connection to transfer
+// encrypted segments.
+//
+// This function is only used by proxy client.
+func NewTLSUnderlay(ctx context.Context, network, laddr, raddr string, mtu int, block cipher.BlockCipher, resolver apicommon.DNSResolver, dialer apicommon.Dialer, config *tls.Config) (*TLSUnderlay, error) {
+	if config == nil {
+		return nil, fmt.Errorf("TLS config is nil")
+	}
+	t, err := NewStreamUnderlay(ctx, network, laddr, raddr, mtu, block, resolver, dialer)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(t.conn, camouflageTLSConfig(config))
+	handshakeCtx, cancel := context.WithTimeout(ctx, tlsHandshakeTimeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+		UnderlayTLSHandshakeErrors.Add(1)
+		t.conn.Close()
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	t.conn = tlsConn
+	return &TLSUnderlay{StreamUnderlay: t}, nil
+}
+
+// serverTLSHandshake finishes the TLS handshake of an accepted connection.
+func serverTLSHandshake(rawConn net.Conn, config *tls.Config) (*tls.Conn, error) {
+	tlsConn := tls.Server(rawConn, camouflageTLSConfig(config))
+	ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	return tlsConn, nil
+}
+
+// camouflageTLSConfig returns a copy of the TLS config that negotiates
+// TLS 1.3 and offers the same application protocols as a web browser,
+// unless they are set by the caller.
+func camouflageTLSConfig(config *tls.Config) *tls.Config {
+	c := config.Clone()
+	if c.MinVersion == 0 {
+		c.MinVersion = tls.VersionTLS12
+	}
+	if len(c.NextProtos) == 0 {
+		c.NextProtos = tlsNextProtos
+	}
+	if len(c.CurvePreferences) == 0 {
+		c.CurvePreferences = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}
+	}
+	return c
+}
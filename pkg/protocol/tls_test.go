@@ -0,0 +1,116 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestTLSUnderlay(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	cert, pool := newTestCertificate(t, "www.example.com")
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetTLS(&tls.Config{Certificates: []tls.Certificate{cert}})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetTLS(&tls.Config{ServerName: "www.example.com", RootCAs: pool})
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestTLSUnderlayClientHello(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer listener.Close()
+	hello := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b := make([]byte, 5)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return
+		}
+		record := make([]byte, int(b[3])<<8|int(b[4]))
+		if _, err := io.ReadFull(conn, record); err != nil {
+			return
+		}
+		hello <- append(b, record...)
+	}()
+
+	block, err := cipher.BlockCipherFromPassword(cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang")), false)
+	if err != nil {
+		t.Fatalf("cipher.BlockCipherFromPassword() failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := NewTLSUnderlay(ctx, "tcp", "", listener.Addr().String(), 1400, block, &net.Resolver{}, nil, &tls.Config{ServerName: "www.example.com"}); err == nil {
+		t.Fatalf("NewTLSUnderlay() succeeded without server response")
+	}
+
+	b := <-hello
+	// Content type handshake, followed by handshake type client hello.
+	if b[0] != 0x16 || b[5] != 0x01 {
+		t.Fatalf("first record is not a TLS client hello: %x", b[:6])
+	}
+	for _, want := range [][]byte{[]byte("www.example.com"), []byte("h2"), []byte("http/1.1")} {
+		if !bytes.Contains(b, want) {
+			t.Errorf("client hello doesn't contain %q", want)
+		}
+	}
+}
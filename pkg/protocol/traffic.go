@@ -0,0 +1,85 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+// SessionTraffic is the number of bytes and segments transferred
+// by a session since it is created.
+type SessionTraffic struct {
+	ID           uint32
+	UserName     string
+	BytesRead    uint64 // bytes read by the application
+	BytesWritten uint64 // bytes written by the application
+	SegmentsRecv uint64 // segments received from the underlay
+	SegmentsSent uint64 // segments sent to the underlay
+}
+
+// Traffic returns the number of bytes and segments transferred by the session.
+func (s *Session) Traffic() SessionTraffic {
+	return SessionTraffic{
+		ID:           s.id,
+		UserName:     s.UserName(),
+		BytesRead:    s.bytesRead.Load(),
+		BytesWritten: s.bytesWritten.Load(),
+		SegmentsRecv: s.segmentsRecv.Load(),
+		SegmentsSent: s.segmentsSent.Load(),
+	}
+}
+
+func (b *baseUnderlay) SessionTraffic() []SessionTraffic {
+	res := make([]SessionTraffic, 0)
+	b.sessionMap.Range(func(k, v any) bool {
+		res = append(res, v.(*Session).Traffic())
+		return true
+	})
+	return res
+}
+
+// ExportSessionTraffic returns the traffic of all the sessions,
+// sorted by session ID.
+func (m *Mux) ExportSessionTraffic() []SessionTraffic {
+	res := make([]SessionTraffic, 0)
+	for _, underlay := range m.pool.all() {
+		res = append(res, underlay.SessionTraffic()...)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// UserTrafficSegments returns the number of segments received from and
+// sent to the user since the counters are created. The counters are kept
+// after restart if metrics dump is enabled.
+func UserTrafficSegments(userName string) (uploadSegments, downloadSegments int64) {
+	metricGroup := metrics.GetMetricGroupByName(fmt.Sprintf(metrics.UserMetricGroupFormat, userName))
+	if metricGroup == nil {
+		return 0, 0
+	}
+	if upload, found := metricGroup.GetMetric(metrics.UserMetricUploadSegments); found {
+		uploadSegments = upload.Load()
+	}
+	if download, found := metricGroup.GetMetric(metrics.UserMetricDownloadSegments); found {
+		downloadSegments = download.Load()
+	}
+	return
+}
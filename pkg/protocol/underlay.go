@@ -17,10 +17,14 @@ package protocol
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"time"
 
 	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
 )
 
 var (
@@ -30,8 +34,35 @@ var (
 	UnderlayCurrEstablished = metrics.RegisterMetric("underlay", "CurrEstablished", metrics.GAUGE)
 	UnderlayMalformedUDP    = metrics.RegisterMetric("underlay", "UnderlayMalformedUDP", metrics.COUNTER)
 	UnderlayUnsolicitedUDP  = metrics.RegisterMetric("underlay", "UnsolicitedUDP", metrics.COUNTER)
+	UnderlayDead            = metrics.RegisterMetric("underlay", "Dead", metrics.COUNTER)
+	UnderlayDialErrors      = metrics.RegisterMetric("underlay", "DialErrors", metrics.COUNTER)
+
+	// UnderlayDrainRejectedSessions is the number of new sessions
+	// rejected because the underlay is draining.
+	UnderlayDrainRejectedSessions = metrics.RegisterMetric("underlay", "DrainRejectedSessions", metrics.COUNTER)
+
+	// UnderlayDrainClosedSessions is the number of sessions closed
+	// because they are not finished before the drain deadline.
+	UnderlayDrainClosedSessions = metrics.RegisterMetric("underlay", "DrainClosedSessions", metrics.COUNTER)
 )
 
+// UnderlayDeadError is returned by a session after the underlay is closed,
+// because nothing is received from the peer while some data is not
+// acknowledged.
+type UnderlayDeadError struct {
+	Underlay string        // description of the dead underlay
+	Silence  time.Duration // time since the last segment is received
+}
+
+func (e *UnderlayDeadError) Error() string {
+	return fmt.Sprintf("%s is dead: nothing is received in %v while some data is not acknowledged", e.Underlay, e.Silence.Truncate(time.Second))
+}
+
+// Unwrap returns stderror.ErrDisconnected.
+func (e *UnderlayDeadError) Unwrap() error {
+	return stderror.ErrDisconnected
+}
+
 // UnderlayProperties defines network properties of a underlay.
 type UnderlayProperties interface {
 	// Maximum transission unit of this network connection
@@ -48,6 +79,18 @@ type UnderlayProperties interface {
 	RemoteAddr() net.Addr
 }
 
+// underlayLogFields returns the structured log fields of the underlay.
+func underlayLogFields(u UnderlayProperties) log.Fields {
+	fields := log.Fields{
+		"underlay": fmt.Sprintf("%v", u),
+		"protocol": u.TransportProtocol().String(),
+	}
+	if addr := u.RemoteAddr(); !common.IsNilNetAddr(addr) {
+		fields["peer"] = addr.String()
+	}
+	return fields
+}
+
 // Underlay contains methods implemented by a underlay network connection.
 type Underlay interface {
 	// Accept incoming sessions.
@@ -71,6 +114,13 @@ type Underlay interface {
 	// Returns detailed information of all the sessions.
 	Sessions() []SessionInfo
 
+	// Returns snapshots of the internal state of the sessions.
+	// If the session ID is 0, all the sessions are returned.
+	SessionStates(uint32) []SessionState
+
+	// Returns the number of bytes and segments transferred by the sessions.
+	SessionTraffic() []SessionTraffic
+
 	// Run event loop.
 	// The underlay needs to be closed when this returns.
 	RunEventLoop(context.Context) error
@@ -80,6 +130,11 @@ type Underlay interface {
 
 	// Indicate the underlay is closed.
 	Done() chan struct{}
+
+	// Stop accepting new sessions, wait for the existing sessions
+	// to finish until the context is done, then close the underlay.
+	// It returns the context error if sessions are closed by force.
+	Drain(context.Context) error
 }
 
 // underlayDescriptor implements UnderlayProperties.
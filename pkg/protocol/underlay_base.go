@@ -21,13 +21,23 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
 	"github.com/enfein/mieru/v3/pkg/stderror"
 )
 
-const sessionChanCapacity = 64
+const (
+	sessionChanCapacity = 64
+
+	// drainCheckInterval is the interval to check if all the sessions
+	// are finished when the underlay is draining.
+	drainCheckInterval = 100 * time.Millisecond
+)
 
 // baseUnderlay contains a partial implementation of underlay.
 type baseUnderlay struct {
@@ -38,11 +48,18 @@ type baseUnderlay struct {
 	sessionMap    sync.Map      // Map<sessionID, *Session>
 	readySessions chan *Session // sessions that completed handshake and ready for consume
 
-	sendMutex  sync.Mutex // protect writing data to the connection
-	closeMutex sync.Mutex // protect closing the connection
+	egress     egressScheduler // schedule writing data to the connection
+	fairness   Fairness        // weights of egress scheduling
+	engine     *eventEngine    // runs the sessions if not nil
+	closeMutex sync.Mutex      // protect closing the connection
+	draining   atomic.Bool     // if set, new sessions are rejected
 
 	// ---- client fields ----
-	scheduler *ScheduleController
+	scheduler         *ScheduleController
+	endpoint          string       // key of the proxy server endpoint that is dialed
+	endpointPort      int          // port dialed from a port range endpoint, 0 otherwise
+	password          []byte       // password used by the underlay
+	credentialHandler func([]byte) // receives the next hashed password pushed by the server
 }
 
 var (
@@ -63,6 +80,9 @@ func newBaseUnderlay(isClient bool, mtu int) *baseUnderlay {
 func (b *baseUnderlay) Accept() (net.Conn, error) {
 	select {
 	case session := <-b.readySessions:
+		if session.keyExchange == keyExchangeHybrid {
+			return newHybridServerConn(session), nil
+		}
 		return session, nil
 	case <-b.done:
 		return nil, io.ErrClosedPipe
@@ -132,7 +152,7 @@ func (b *baseUnderlay) AddSession(s *Session, remoteAddr net.Addr) error {
 		return stderror.ErrAlreadyExist
 	}
 	s.conn = b
-	s.remoteAddr = remoteAddr
+	s.setRemoteAddr(remoteAddr)
 	s.forwardStateTo(sessionAttached)
 
 	if s.isClient {
@@ -148,6 +168,14 @@ func (b *baseUnderlay) AddSession(s *Session, remoteAddr net.Addr) error {
 	return nil
 }
 
+// egressWeight returns the weight of the session in egress scheduling.
+func (b *baseUnderlay) egressWeight(sessionID uint32) int {
+	if s, ok := b.sessionMap.Load(sessionID); ok {
+		return b.fairness.weight(s.(*Session).Priority())
+	}
+	return b.fairness.weight(SessionPriorityNormal)
+}
+
 func (b *baseUnderlay) RemoveSession(s *Session) error {
 	if s == nil {
 		return stderror.ErrNullPointer
@@ -194,3 +222,58 @@ func (b *baseUnderlay) Scheduler() *ScheduleController {
 func (b *baseUnderlay) Done() chan struct{} {
 	return b.done
 }
+
+func (b *baseUnderlay) Drain(ctx context.Context) error {
+	return drainUnderlay(ctx, b, b)
+}
+
+// drainUnderlay stops accepting new sessions of the underlay u, waits for
+// the existing sessions to finish until ctx is done, then closes u.
+// b is the base of u.
+func drainUnderlay(ctx context.Context, u Underlay, b *baseUnderlay) error {
+	if !b.draining.Swap(true) {
+		log.Infof("Draining %v with %d sessions", u, b.SessionCount())
+	}
+	if b.isClient {
+		b.scheduler.Disable()
+	}
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for b.SessionCount() > 0 {
+		select {
+		case <-ticker.C:
+		case <-b.done:
+			return nil
+		case <-ctx.Done():
+			n := b.SessionCount()
+			if n > 0 {
+				UnderlayDrainClosedSessions.Add(int64(n))
+				log.Infof("Closing %v with %d unfinished sessions after drain: %v", u, n, ctx.Err())
+			}
+			u.Close()
+			return ctx.Err()
+		}
+	}
+	return u.Close()
+}
+
+// isDraining returns true if the underlay doesn't accept new sessions.
+func (b *baseUnderlay) isDraining() bool {
+	return b.draining.Load()
+}
+
+// newCloseSessionRequest returns a segment that requests the peer
+// to close the session.
+func newCloseSessionRequest(sessionID uint32, transport common.TransportProtocol, block cipher.BlockCipher) *segment {
+	return &segment{
+		metadata: &sessionStruct{
+			baseStruct: baseStruct{
+				protocol: uint8(closeSessionRequest),
+			},
+			sessionID:  sessionID,
+			statusCode: uint8(statusOK),
+		},
+		transport: transport,
+		block:     block,
+	}
+}
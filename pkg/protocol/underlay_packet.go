@@ -21,10 +21,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/chaos"
 	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
 	"github.com/enfein/mieru/v3/pkg/common/sockopts"
@@ -41,24 +44,82 @@ const (
 	idleSessionTickerInterval = 5 * time.Second
 	idleSessionTimeout        = time.Minute
 
+	// If nothing is received from the server within this duration
+	// while some data is not acknowledged, the client underlay is dead.
+	defaultAckStarvationTimeout = 15 * time.Second
+
 	readOneSegmentTimeout = 5 * time.Second
 )
 
-var packetReplayCache = replay.NewCache(4*1024*1024, cipher.KeyRefreshInterval*3)
+const (
+	// DefaultReplayCacheCapacity is the default maximum number of entries
+	// in the replay caches of packet and stream underlays.
+	DefaultReplayCacheCapacity = 4 * 1024 * 1024
+
+	// MinReplayCacheCapacity is the minimum configurable capacity
+	// of replay caches.
+	MinReplayCacheCapacity = 1024
+
+	// MaxReplayCacheCapacity is the maximum configurable capacity
+	// of replay caches.
+	MaxReplayCacheCapacity = 64 * 1024 * 1024
+
+	// DefaultReplayCacheExpireInterval is the default time to remember
+	// a packet in replay caches.
+	DefaultReplayCacheExpireInterval = cipher.KeyRefreshInterval * 3
+)
+
+var packetReplayCache = replay.NewCache(DefaultReplayCacheCapacity, DefaultReplayCacheExpireInterval)
+
+// openSessionReplayCache detects open session requests that are sent again,
+// regardless of the source address. The request may carry early data that
+// the server forwards immediately, so it must never be accepted twice.
+var openSessionReplayCache = replay.NewCache(openSessionReplayCacheCapacity, DefaultReplayCacheExpireInterval)
+
+const openSessionReplayCacheCapacity = 1024 * 1024
+
+// serverSourceCache remembers the user of each client IP address,
+// so the server tries the cipher blocks of that user first.
+// It is shared by packet and stream underlays.
+var serverSourceCache = cipher.NewSourceCache(serverSourceCacheCapacity)
+
+const serverSourceCacheCapacity = 64 * 1024
 
 type PacketUnderlay struct {
 	// ---- common fields ----
 	baseUnderlay
-	conn net.PacketConn
+	conn   net.PacketConn
+	connMu sync.RWMutex // protect replacing conn
+
+	integrityDiag bool // append integrity checksum to packets, and diagnose decryption failures
 
 	idleSessionTicker *time.Ticker
+	readTimeout       time.Duration // the event loop wakes up at least once in this time
 
 	// ---- client fields ----
+	network    string
 	serverAddr net.Addr
+	dialer     apicommon.Dialer // creates UDP connections if not nil
 	block      cipher.BlockCipher
+	lastRXTime time.Time // last time a segment is received from the server
+
+	ackStarvationTimeout time.Duration
+
+	pmtudEnabled      bool
+	clientPathMTU     atomic.Int32  // MTU found by path MTU discovery, 0 if unknown
+	mtuProbeResponses chan *segment // responses of MTU probes
+
+	sessionMigration  bool
+	lastMigration     time.Time // last time the client moved to a new UDP connection
+	migrationAttempts int       // number of migrations without receiving anything
+
+	udpOffload bool // send packets with UDP segmentation offload if supported
 
 	// ---- server fields ----
-	users map[string]*appctlpb.User
+	userSet       *atomic.Pointer[serverUserSet]
+	sourceLimiter *sourceLimiter // limits handshakes of each client IP
+	sessionOpts   sessionOptions
+	peerMTU       sync.Map // Map<client address, peerPathMTU>
 }
 
 var _ Underlay = &PacketUnderlay{}
@@ -66,9 +127,10 @@ var _ Underlay = &PacketUnderlay{}
 // NewPacketUnderlay connects to the remote address "raddr" on the network
 // with packet encryption. If "laddr" is empty, an automatic address is used.
 // "block" is the block encryption algorithm to encrypt packets.
+// If "dialer" is not nil, it creates the connection.
 //
 // This function is only used by proxy client.
-func NewPacketUnderlay(ctx context.Context, network, laddr, raddr string, mtu int, block cipher.BlockCipher, resolver apicommon.DNSResolver) (*PacketUnderlay, error) {
+func NewPacketUnderlay(ctx context.Context, network, laddr, raddr string, mtu int, block cipher.BlockCipher, resolver apicommon.DNSResolver, dialer apicommon.Dialer) (*PacketUnderlay, error) {
 	switch network {
 	case "udp", "udp4", "udp6":
 	default:
@@ -90,19 +152,22 @@ func NewPacketUnderlay(ctx context.Context, network, laddr, raddr string, mtu in
 		return nil, fmt.Errorf("ResolveUDPAddr() failed: %w", err)
 	}
 
-	conn, err := net.ListenUDP(network, localAddr)
+	conn, err := listenClientPacketConn(ctx, network, localAddr, dialer)
 	if err != nil {
-		return nil, fmt.Errorf("net.ListenUDP() failed: %w", err)
-	}
-	if err := sockopts.ApplyUDPControls(conn); err != nil {
-		return nil, fmt.Errorf("ApplyUDPControls() failed: %w", err)
+		return nil, err
 	}
 	u := &PacketUnderlay{
 		baseUnderlay:      *newBaseUnderlay(true, mtu),
 		conn:              conn,
+		dialer:            dialer,
 		idleSessionTicker: time.NewTicker(idleSessionTickerInterval),
+		readTimeout:       readOneSegmentTimeout,
+		network:           network,
 		serverAddr:        remoteAddr,
 		block:             block,
+		lastRXTime:        time.Now(),
+
+		ackStarvationTimeout: defaultAckStarvationTimeout,
 	}
 	// The block cipher expires after this time.
 	u.scheduler.SetRemainingTime(cipher.KeyRefreshInterval / 2)
@@ -110,11 +175,11 @@ func NewPacketUnderlay(ctx context.Context, network, laddr, raddr string, mtu in
 }
 
 func (u *PacketUnderlay) String() string {
-	if u.conn == nil {
+	if u.packetConn() == nil {
 		return "PacketUnderlay{}"
 	}
 	if u.isClient {
-		return fmt.Sprintf("PacketUnderlay{local=%v, remote=%v, mtu=%v}", u.LocalAddr(), u.RemoteAddr(), u.mtu)
+		return fmt.Sprintf("PacketUnderlay{local=%v, remote=%v, mtu=%v}", u.LocalAddr(), u.RemoteAddr(), u.MTU())
 	} else {
 		return fmt.Sprintf("PacketUnderlay{local=%v, mtu=%v}", u.LocalAddr(), u.mtu)
 	}
@@ -132,7 +197,41 @@ func (u *PacketUnderlay) Close() error {
 	log.Debugf("Closing %v", u)
 	u.idleSessionTicker.Stop()
 	u.baseUnderlay.Close()
-	return u.conn.Close()
+	return u.packetConn().Close()
+}
+
+// Drain implements Underlay.
+func (u *PacketUnderlay) Drain(ctx context.Context) error {
+	return drainUnderlay(ctx, u, &u.baseUnderlay)
+}
+
+// MTU returns the MTU found by path MTU discovery if it is enabled.
+// Otherwise, it returns the MTU from the configuration.
+func (u *PacketUnderlay) MTU() int {
+	if u.isClient {
+		return u.pathMTU(nil)
+	}
+	return u.mtu
+}
+
+// enableUDPOffload sends packets of the client underlay with
+// UDP segmentation offload if it is supported.
+func (u *PacketUnderlay) enableUDPOffload() {
+	if !u.isClient {
+		return
+	}
+	u.udpOffload = true
+	if !enableUDPOffload(u.packetConn()) {
+		log.Debugf("%v UDP segmentation offload is not supported", u)
+	}
+}
+
+// packetConn returns the UDP connection of the underlay.
+// The client may replace the connection during session migration.
+func (u *PacketUnderlay) packetConn() net.PacketConn {
+	u.connMu.RLock()
+	defer u.connMu.RUnlock()
+	return u.conn
 }
 
 func (u *PacketUnderlay) TransportProtocol() common.TransportProtocol {
@@ -140,7 +239,7 @@ func (u *PacketUnderlay) TransportProtocol() common.TransportProtocol {
 }
 
 func (u *PacketUnderlay) LocalAddr() net.Addr {
-	return u.conn.LocalAddr()
+	return u.packetConn().LocalAddr()
 }
 
 func (u *PacketUnderlay) RemoteAddr() net.Addr {
@@ -158,19 +257,7 @@ func (u *PacketUnderlay) AddSession(s *Session, remoteAddr net.Addr) error {
 	close(s.ready)
 	log.Debugf("Adding session %d to %v", s.id, u)
 
-	s.wg.Add(2)
-	go func() {
-		if err := s.runInputLoop(context.Background()); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
-			log.Debugf("%v runInputLoop(): %v", s, err)
-		}
-		s.wg.Done()
-	}()
-	go func() {
-		if err := s.runOutputLoop(context.Background()); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
-			log.Debugf("%v runOutputLoop(): %v", s, err)
-		}
-		s.wg.Done()
-	}()
+	s.startLoops(u.engine)
 	return nil
 }
 
@@ -178,8 +265,13 @@ func (u *PacketUnderlay) RunEventLoop(ctx context.Context) error {
 	if u.conn == nil {
 		return stderror.ErrNullPointer
 	}
+	if u.pmtudEnabled {
+		go u.runPathMTUDiscovery(ctx)
+	}
 
+	timer := segmentTimer{histogram: UnderlayUDPSegmentLatency}
 	for {
+		timer.end()
 		select {
 		case <-ctx.Done():
 			return nil
@@ -205,6 +297,13 @@ func (u *PacketUnderlay) RunEventLoop(ctx context.Context) error {
 				}
 				return true
 			})
+			if u.isClient {
+				if err := u.checkAckStarvation(); err != nil {
+					return err
+				}
+			} else {
+				u.cleanPeerPathMTU()
+			}
 		default:
 		}
 		seg, addr, err := u.readOneSegment()
@@ -214,6 +313,10 @@ func (u *PacketUnderlay) RunEventLoop(ctx context.Context) error {
 			}
 			return fmt.Errorf("readOneSegment() failed: %w", err)
 		}
+		timer.begin()
+		if u.isClient {
+			u.lastRXTime = time.Now()
+		}
 		if log.IsLevelEnabled(log.TraceLevel) {
 			log.Tracef("%v received %v from peer %v", u, seg, addr)
 		}
@@ -231,6 +334,29 @@ func (u *PacketUnderlay) RunEventLoop(ctx context.Context) error {
 				if err := u.onCloseSession(seg); err != nil {
 					return fmt.Errorf("onCloseSession() failed: %w", err)
 				}
+			case mtuProbeRequest:
+				if err := u.onMTUProbeRequest(seg, addr); err != nil {
+					log.Debugf("%v onMTUProbeRequest() failed: %v", u, err)
+				}
+			case mtuProbeResponse:
+				if err := u.onMTUProbeResponse(seg); err != nil {
+					return fmt.Errorf("onMTUProbeResponse() failed: %w", err)
+				}
+			case resumeSessionRequest:
+				if err := u.onResumeSessionRequest(seg, addr); err != nil {
+					log.Debugf("%v onResumeSessionRequest() failed: %v", u, err)
+				}
+			case resumeSessionResponse:
+				if err := u.onResumeSessionResponse(seg); err != nil {
+					return fmt.Errorf("onResumeSessionResponse() failed: %w", err)
+				}
+			case rekeyRequest, rekeyResponse:
+				// Rekey is not supported by packet underlay.
+				log.Debugf("%v ignored %v", u, seg)
+			case credentialPush:
+				if err := u.onCredentialPush(seg); err != nil {
+					return fmt.Errorf("onCredentialPush() failed: %w", err)
+				}
 			default:
 				panic(fmt.Sprintf("Protocol %d is a session protocol but not recognized by packet underlay", seg.metadata.Protocol()))
 			}
@@ -260,13 +386,67 @@ func (u *PacketUnderlay) RunEventLoop(ctx context.Context) error {
 				}
 				continue
 			}
-			session.(*Session).recvChan <- seg
+			session.(*Session).deliver(seg)
 		} else {
 			log.Debugf("Ignore unknown protocol %d", seg.metadata.Protocol())
 		}
 	}
 }
 
+// checkAckStarvation closes the client underlay if nothing is received
+// from the server for a long time after some data is sent. This happens
+// when the NAT mapping is silently dropped. The sessions are closed with
+// UnderlayDeadError, so the application can dial again with a new underlay.
+//
+// If session migration is enabled, the client first moves to a new UDP
+// connection and resumes the sessions from the new address.
+func (u *PacketUnderlay) checkAckStarvation() error {
+	if !u.lastMigration.IsZero() && u.lastRXTime.After(u.lastMigration) {
+		u.migrationAttempts = 0
+	}
+	var oldestTx time.Time
+	u.sessionMap.Range(func(k, v any) bool {
+		session := v.(*Session)
+		session.sendBuf.Ascend(func(iter *segment) bool {
+			if !iter.firstTx.IsZero() && (oldestTx.IsZero() || iter.firstTx.Before(oldestTx)) {
+				oldestTx = iter.firstTx
+			}
+			return false
+		})
+		return true
+	})
+	if oldestTx.IsZero() || time.Since(oldestTx) < u.ackStarvationTimeout || u.lastRXTime.After(oldestTx) {
+		return nil
+	}
+	if u.sessionMigration && u.migrationAttempts < maxMigrationAttempts {
+		if time.Since(u.lastMigration) < u.ackStarvationTimeout {
+			// Wait for the result of the previous migration.
+			return nil
+		}
+		u.migrationAttempts++
+		if err := u.migrate(); err != nil {
+			log.Debugf("%v migrate() failed: %v", u, err)
+		} else {
+			return nil
+		}
+	}
+
+	err := &UnderlayDeadError{
+		Underlay: u.String(),
+		Silence:  time.Since(u.lastRXTime),
+	}
+	log.Warnf("%v", err)
+	UnderlayDead.Add(1)
+	u.sessionMap.Range(func(k, v any) bool {
+		session := v.(*Session)
+		session.setBrokenError(err)
+		session.closeWithError(err)
+		return true
+	})
+	u.Close()
+	return err
+}
+
 func (u *PacketUnderlay) onOpenSessionRequest(seg *segment, remoteAddr net.Addr) error {
 	if u.isClient {
 		return stderror.ErrInvalidOperation
@@ -283,9 +463,18 @@ func (u *PacketUnderlay) onOpenSessionRequest(seg *segment, remoteAddr net.Addr)
 		log.Debugf("%v received open session request, but session ID %d is already used", u, sessionID)
 		return nil
 	}
-	session := NewSession(sessionID, false, u.MTU(), u.users)
+	if u.isDraining() {
+		UnderlayDrainRejectedSessions.Add(1)
+		log.Debugf("%v rejected session %d because it is draining", u, sessionID)
+		return u.writeOneSegment(newCloseSessionRequest(sessionID, u.TransportProtocol(), seg.block), remoteAddr)
+	}
+	session := NewSession(sessionID, false, u.MTU(), loadServerUsers(u.userSet))
+	if err := session.applyOptions(u.sessionOpts); err != nil {
+		return fmt.Errorf("applyOptions() failed: %w", err)
+	}
+	session.keyExchange = acceptKeyExchange(seg.metadata.(*sessionStruct).keyExchange)
 	u.AddSession(session, remoteAddr)
-	session.recvChan <- seg
+	session.deliver(seg)
 	u.readySessions <- session
 	return nil
 }
@@ -300,7 +489,7 @@ func (u *PacketUnderlay) onOpenSessionResponse(seg *segment) error {
 	if !found {
 		return fmt.Errorf("session ID %d is not found", sessionID)
 	}
-	session.(*Session).recvChan <- seg
+	session.(*Session).deliver(seg)
 	return nil
 }
 
@@ -315,7 +504,7 @@ func (u *PacketUnderlay) onCloseSession(seg *segment) error {
 		return nil
 	}
 	s := session.(*Session)
-	s.recvChan <- seg
+	s.deliver(seg)
 	s.wg.Wait()
 	u.RemoveSession(s)
 	return nil
@@ -325,6 +514,10 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 	var n int
 	var addr net.Addr
 	var err error
+	// Peer may select a different MTU.
+	// Use the largest possible value here to avoid error.
+	buf := underlayBufferPool.Get(maxPathMTU)
+	defer underlayBufferPool.Put(buf)
 	for {
 		select {
 		case <-u.done:
@@ -332,12 +525,11 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 		default:
 		}
 
-		common.SetReadTimeout(u.conn, readOneSegmentTimeout)
-		defer common.SetReadTimeout(u.conn, 0)
-		// Peer may select a different MTU.
-		// Use the largest possible value here to avoid error.
-		b := make([]byte, 1500)
-		n, addr, err = u.conn.ReadFrom(b)
+		conn := u.packetConn()
+		common.SetReadTimeout(conn, u.readTimeout)
+		defer common.SetReadTimeout(conn, 0)
+		b := buf
+		n, addr, err = conn.ReadFrom(b)
 		if err != nil {
 			if stderror.IsTimeout(err) {
 				return nil, nil, stderror.ErrTimeout
@@ -385,6 +577,7 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 				if log.IsLevelEnabled(log.TraceLevel) {
 					log.Tracef("%v Decrypt() failed with packet from %v", u, addr)
 				}
+				u.diagnoseDecryptFailure(b, addr)
 				continue
 			}
 		} else {
@@ -404,25 +597,37 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 				}
 				return true
 			})
+			if !decrypted && !u.sourceLimiter.allowHandshake(sourceIP(addr), time.Now()) {
+				if log.IsLevelEnabled(log.TraceLevel) {
+					log.Tracef("%v dropped packet from %v: source limit exceeded", u, addr)
+				}
+				continue
+			}
 			if !decrypted {
-				// This is a new session. Try all registered users.
-				for _, user := range u.users {
-					var password []byte
-					password, err = hex.DecodeString(user.GetHashedPassword())
-					if err != nil {
-						log.Debugf("Unable to decode hashed password %q from user %q", user.GetHashedPassword(), user.GetName())
-						continue
-					}
-					if len(password) == 0 {
-						password = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
+				// This is a new session. Try the user last seen from
+				// this address first, then all registered users.
+				users := loadServerUsers(u.userSet)
+				cachedUserName, cached := serverSourceCache.Get(addr)
+				if cached {
+					if user, found := users[cachedUserName]; found && !user.GetDisabled() {
+						blockCipher, decryptedMeta, err = tryUserDecrypt(encryptedMeta, user)
+						decrypted = err == nil
 					}
-					blockCipher, decryptedMeta, err = cipher.TryDecrypt(encryptedMeta, password, true)
-					if err == nil {
-						decrypted = true
-						blockCipher.SetBlockContext(cipher.BlockContext{
-							UserName: user.GetName(),
-						})
-						break
+				}
+				if decrypted {
+					cipher.ServerSourceCacheHit.Add(1)
+				} else {
+					cipher.ServerSourceCacheMiss.Add(1)
+					for _, user := range users {
+						if user.GetDisabled() || (cached && user.GetName() == cachedUserName) {
+							continue
+						}
+						blockCipher, decryptedMeta, err = tryUserDecrypt(encryptedMeta, user)
+						if err == nil {
+							decrypted = true
+							serverSourceCache.Put(addr, user.GetName())
+							break
+						}
 					}
 				}
 			}
@@ -433,6 +638,7 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 				} else if log.IsLevelEnabled(log.TraceLevel) {
 					log.Tracef("%v TryDecrypt() failed with packet from %v", u, addr)
 				}
+				u.diagnoseDecryptFailure(b, addr)
 				continue
 			} else {
 				if blockCipher == nil {
@@ -448,6 +654,9 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 		if len(decryptedMeta) != MetadataLength {
 			return nil, nil, fmt.Errorf("decrypted metadata size %d is unexpected", len(decryptedMeta))
 		}
+		if u.integrityDiag && verifyIntegrityChecksum(b) {
+			IntegrityVerified.Add(1)
+		}
 
 		// Read payload and construct segment.
 		var seg *segment
@@ -462,8 +671,14 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 					continue
 				}
 			}
+			if !u.isClient && ss.Protocol() == openSessionRequest && openSessionReplayCache.IsDuplicate(nonce, replay.EmptyTag) {
+				replay.NewSessionDecrypted.Add(1)
+				log.Debugf("found possible replay attack of open session request in %v from %v", u, addr)
+				continue
+			}
 			seg, err = u.readSessionSegment(ss, nonce, b[packetNonHeaderPosition:], blockCipher)
 			if err != nil {
+				u.diagnoseDecryptFailure(b, addr)
 				if u.isClient {
 					return nil, nil, err
 				} else {
@@ -487,6 +702,7 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 			}
 			seg, err = u.readDataAckSegment(das, nonce, b[packetNonHeaderPosition:], blockCipher)
 			if err != nil {
+				u.diagnoseDecryptFailure(b, addr)
 				if u.isClient {
 					return nil, nil, err
 				} else {
@@ -499,12 +715,50 @@ func (u *PacketUnderlay) readOneSegment() (*segment, net.Addr, error) {
 			}
 			return seg, addr, nil
 		}
-		return nil, nil, fmt.Errorf("unable to handle protocol %d", p)
+		if u.isClient {
+			return nil, nil, fmt.Errorf("unable to handle protocol %d", p)
+		}
+		// The client may run a newer version.
+		log.Debugf("%v unable to handle protocol %d from %v", u, p, addr)
 	}
 }
 
+// tryUserDecrypt tries to decrypt the data with the cipher blocks of the user.
+func tryUserDecrypt(data []byte, user *appctlpb.User) (cipher.BlockCipher, []byte, error) {
+	password, err := hex.DecodeString(user.GetHashedPassword())
+	if err != nil {
+		log.Debugf("Unable to decode hashed password %q from user %q", user.GetHashedPassword(), user.GetName())
+		return nil, nil, err
+	}
+	if len(password) == 0 {
+		password = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
+	}
+	blockCipher, decrypted, err := cipher.TryDecrypt(data, password, true)
+	if err == nil {
+		blockCipher.SetBlockContext(cipher.BlockContext{
+			UserName: user.GetName(),
+		})
+		return blockCipher, decrypted, nil
+	}
+
+	// Try the next password of the user.
+	next, nextErr := nextHashedPassword(user)
+	if nextErr != nil || len(next) == 0 {
+		return nil, nil, err
+	}
+	blockCipher, decrypted, err = cipher.TryDecrypt(data, next, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	blockCipher.SetBlockContext(cipher.BlockContext{
+		UserName:     user.GetName(),
+		NextPassword: true,
+	})
+	return blockCipher, decrypted, nil
+}
+
 func (u *PacketUnderlay) readSessionSegment(ss *sessionStruct, nonce, remaining []byte, blockCipher cipher.BlockCipher) (*segment, error) {
-	var decryptedPayload []byte
+	var decryptedPayload, buf []byte
 	var err error
 
 	if ss.payloadLen > 0 {
@@ -519,7 +773,8 @@ func (u *PacketUnderlay) readSessionSegment(ss *sessionStruct, nonce, remaining
 			}
 		}
 		encryptedPayload := remaining[:ss.payloadLen+cipher.DefaultOverhead]
-		decryptedPayload, err = blockCipher.DecryptWithNonce(encryptedPayload, nonce)
+		buf = underlayBufferPool.Get(int(ss.payloadLen))
+		decryptedPayload, err = blockCipher.DecryptWithNonceTo(buf[:0], encryptedPayload, nonce)
 		if u.isClient {
 			cipher.ClientDirectDecrypt.Add(1)
 		} else {
@@ -531,9 +786,11 @@ func (u *PacketUnderlay) readSessionSegment(ss *sessionStruct, nonce, remaining
 			} else {
 				cipher.ServerFailedDirectDecrypt.Add(1)
 			}
-			return nil, fmt.Errorf("DecryptWithNonce() failed: %w", err)
+			underlayBufferPool.Put(buf)
+			return nil, fmt.Errorf("DecryptWithNonceTo() failed: %w", err)
 		}
 		if int(ss.payloadLen)+cipher.DefaultOverhead+int(ss.suffixLen) != len(remaining) {
+			underlayBufferPool.Put(buf)
 			return nil, fmt.Errorf("padding: size not match")
 		}
 	} else {
@@ -546,11 +803,12 @@ func (u *PacketUnderlay) readSessionSegment(ss *sessionStruct, nonce, remaining
 		metadata:  ss,
 		payload:   decryptedPayload,
 		transport: common.PacketTransport,
+		buf:       buf,
 	}, nil
 }
 
 func (u *PacketUnderlay) readDataAckSegment(das *dataAckStruct, nonce, remaining []byte, blockCipher cipher.BlockCipher) (*segment, error) {
-	var decryptedPayload []byte
+	var decryptedPayload, buf []byte
 	var err error
 
 	if das.prefixLen > 0 {
@@ -568,7 +826,8 @@ func (u *PacketUnderlay) readDataAckSegment(das *dataAckStruct, nonce, remaining
 			}
 		}
 		encryptedPayload := remaining[:das.payloadLen+cipher.DefaultOverhead]
-		decryptedPayload, err = blockCipher.DecryptWithNonce(encryptedPayload, nonce)
+		buf = underlayBufferPool.Get(int(das.payloadLen))
+		decryptedPayload, err = blockCipher.DecryptWithNonceTo(buf[:0], encryptedPayload, nonce)
 		if u.isClient {
 			cipher.ClientDirectDecrypt.Add(1)
 		} else {
@@ -580,9 +839,11 @@ func (u *PacketUnderlay) readDataAckSegment(das *dataAckStruct, nonce, remaining
 			} else {
 				cipher.ServerFailedDirectDecrypt.Add(1)
 			}
-			return nil, fmt.Errorf("DecryptWithNonce() failed: %w", err)
+			underlayBufferPool.Put(buf)
+			return nil, fmt.Errorf("DecryptWithNonceTo() failed: %w", err)
 		}
 		if int(das.payloadLen)+cipher.DefaultOverhead+int(das.suffixLen) != len(remaining) {
+			underlayBufferPool.Put(buf)
 			return nil, fmt.Errorf("padding: size not match")
 		}
 	} else {
@@ -595,6 +856,7 @@ func (u *PacketUnderlay) readDataAckSegment(das *dataAckStruct, nonce, remaining
 		metadata:  das,
 		payload:   decryptedPayload,
 		transport: common.PacketTransport,
+		buf:       buf,
 	}, nil
 }
 
@@ -606,8 +868,17 @@ func (u *PacketUnderlay) writeOneSegment(seg *segment, addr net.Addr) error {
 		return fmt.Errorf("can't write to %v, server address is %v", addr, u.serverAddr)
 	}
 
-	u.sendMutex.Lock()
-	defer u.sendMutex.Unlock()
+	switch chaosFault(seg) {
+	case chaos.Drop:
+		return nil
+	case chaos.Kill:
+		go u.Close()
+		return fmt.Errorf("%v is killed by chaos fault: %w", u, stderror.ErrDisconnected)
+	}
+
+	sessionID, _ := seg.SessionID()
+	u.egress.acquire(sessionID, u.egressWeight(sessionID))
+	defer u.egress.release()
 
 	var blockCipher cipher.BlockCipher
 	if u.isClient {
@@ -642,11 +913,19 @@ func (u *PacketUnderlay) writeOneSegment(seg *segment, addr net.Addr) error {
 		panic(fmt.Sprintf("%v cipher block is not ready", u))
 	}
 
+	mtu := u.pathMTU(addr)
 	if ss, ok := toSessionStruct(seg.metadata); ok {
-		maxPaddingSize := MaxPaddingSize(u.mtu, u.TransportProtocol(), int(ss.payloadLen), 0)
-		padding := newPadding(
-			buildRecommendedPaddingOpts(maxPaddingSize, packetOverhead+int(ss.payloadLen), blockCipher.BlockContext().UserName),
-		)
+		var padding []byte
+		if ss.Protocol() != mtuProbeRequest {
+			// The size of MTU probe must be exact.
+			maxPaddingSize := MaxPaddingSize(mtu, u.TransportProtocol(), int(ss.payloadLen), 0)
+			padding = newPadding(
+				buildRecommendedPaddingOpts(maxPaddingSize, packetOverhead+int(ss.payloadLen), blockCipher.BlockContext().UserName),
+			)
+			if u.integrityDiag {
+				padding = integrityPadding(padding, maxPaddingSize)
+			}
+		}
 		ss.suffixLen = uint8(len(padding))
 		if log.IsLevelEnabled(log.TraceLevel) {
 			log.Tracef("%v is sending %v", u, seg)
@@ -667,8 +946,12 @@ func (u *PacketUnderlay) writeOneSegment(seg *segment, addr net.Addr) error {
 			dataToSend = append(dataToSend, encryptedPayload...)
 		}
 		dataToSend = append(dataToSend, padding...)
-		if _, err := u.conn.WriteTo(dataToSend, addr); err != nil {
-			return fmt.Errorf("WriteTo() failed: %w", err)
+		if u.integrityDiag && len(padding) >= integrityChecksumSize {
+			putIntegrityChecksum(dataToSend)
+		}
+		chaos.OnWriteCiphertext(dataToSend[:len(encryptedMetadata)])
+		if err := u.writePacket(dataToSend, addr); err != nil {
+			return err
 		}
 		if u.isClient {
 			metrics.UploadBytes.Add(int64(len(dataToSend)))
@@ -678,13 +961,17 @@ func (u *PacketUnderlay) writeOneSegment(seg *segment, addr net.Addr) error {
 		metrics.OutputPaddingBytes.Add(int64(len(padding)))
 	} else if das, ok := toDataAckStruct(seg.metadata); ok {
 		padding1 := newPadding(paddingOpts{
-			maxLen: MaxPaddingSize(u.mtu, u.TransportProtocol(), int(das.payloadLen), 0),
+			maxLen: MaxPaddingSize(mtu, u.TransportProtocol(), int(das.payloadLen), 0),
 			ascii:  &asciiPaddingOpts{},
 		})
+		maxPadding2Size := MaxPaddingSize(mtu, u.TransportProtocol(), int(das.payloadLen), len(padding1))
 		padding2 := newPadding(paddingOpts{
-			maxLen: MaxPaddingSize(u.mtu, u.TransportProtocol(), int(das.payloadLen), len(padding1)),
+			maxLen: maxPadding2Size,
 			ascii:  &asciiPaddingOpts{},
 		})
+		if u.integrityDiag {
+			padding2 = integrityPadding(padding2, maxPadding2Size)
+		}
 		das.prefixLen = uint8(len(padding1))
 		das.suffixLen = uint8(len(padding2))
 		if log.IsLevelEnabled(log.TraceLevel) {
@@ -706,8 +993,12 @@ func (u *PacketUnderlay) writeOneSegment(seg *segment, addr net.Addr) error {
 			dataToSend = append(dataToSend, encryptedPayload...)
 		}
 		dataToSend = append(dataToSend, padding2...)
-		if _, err := u.conn.WriteTo(dataToSend, addr); err != nil {
-			return fmt.Errorf("WriteTo() failed: %w", err)
+		if u.integrityDiag && len(padding2) >= integrityChecksumSize {
+			putIntegrityChecksum(dataToSend)
+		}
+		chaos.OnWriteCiphertext(dataToSend[:len(encryptedMetadata)])
+		if err := u.writePacket(dataToSend, addr); err != nil {
+			return err
 		}
 		if u.isClient {
 			metrics.UploadBytes.Add(int64(len(dataToSend)))
@@ -721,3 +1012,31 @@ func (u *PacketUnderlay) writeOneSegment(seg *segment, addr net.Addr) error {
 	}
 	return nil
 }
+
+// listenClientPacketConn returns a new UDP connection of the client
+// underlay. If the dialer is not nil, it creates the connection.
+func listenClientPacketConn(ctx context.Context, network string, localAddr *net.UDPAddr, dialer apicommon.Dialer) (net.PacketConn, error) {
+	if dialer != nil {
+		var laddr string
+		if localAddr != nil {
+			laddr = localAddr.String()
+		}
+		conn, err := dialer.ListenPacket(ctx, network, laddr)
+		if err != nil {
+			return nil, fmt.Errorf("ListenPacket() failed: %w", err)
+		}
+		if udpConn, ok := conn.(*net.UDPConn); ok {
+			return newBatchPacketConn(udpConn, false), nil
+		}
+		return conn, nil
+	}
+	conn, err := net.ListenUDP(network, localAddr)
+	if err != nil {
+		return nil, fmt.Errorf("net.ListenUDP() failed: %w", err)
+	}
+	if err := sockopts.ApplyUDPControls(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ApplyUDPControls() failed: %w", err)
+	}
+	return newBatchPacketConn(conn, false), nil
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/replay"
+)
+
+func TestUnderlayAckStarvation(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+
+	// The server never responds.
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	defer server.Close()
+
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, server.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	conn, err := clientMux.DialContext(ctx)
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	underlay := conn.(*Session).conn.(*PacketUnderlay)
+	underlay.ackStarvationTimeout = 100 * time.Millisecond
+
+	if err := underlay.checkAckStarvation(); err != nil {
+		t.Fatalf("checkAckStarvation() got error %v before data is sent", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	var deadErr *UnderlayDeadError
+	if err := underlay.checkAckStarvation(); !errors.As(err, &deadErr) {
+		t.Fatalf("checkAckStarvation() got error %v, want UnderlayDeadError", err)
+	}
+	select {
+	case <-underlay.Done():
+	default:
+		t.Errorf("dead underlay is not closed")
+	}
+	if _, err := conn.Read(make([]byte, 16)); !errors.As(err, &deadErr) {
+		t.Errorf("Read() got error %v, want UnderlayDeadError", err)
+	}
+}
+
+func TestUnderlayOpenSessionReplay(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedUDPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedUDPPort() failed: %v", err)
+	}
+	serverAddr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverProperties := NewUnderlayProperties(1400, common.PacketTransport, serverAddr, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	defer serverMux.Close()
+	accepted := make(chan net.Conn, 4)
+	go func() {
+		for {
+			conn, err := serverMux.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	relay := newUDPRelay(t, serverAddr)
+	defer relay.Close()
+	clientProperties := NewUnderlayProperties(1400, common.PacketTransport, nil, relay.conn.LocalAddr())
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	var serverConn net.Conn
+	select {
+	case serverConn = <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("session is not accepted by the server")
+	}
+	b := make([]byte, 5)
+	if _, err := io.ReadFull(serverConn, b); err != nil || string(b) != "hello" {
+		t.Fatalf("io.ReadFull() = %q, %v, want %q", b, err, "hello")
+	}
+	conn.Close()
+	serverConn.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	// The server must not accept the open session request again,
+	// even after the session is closed.
+	replays := replay.NewSessionDecrypted.Load()
+	relay.replayFirstPackets()
+	deadline := time.Now().Add(5 * time.Second)
+	for replay.NewSessionDecrypted.Load() <= replays && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if replay.NewSessionDecrypted.Load() <= replays {
+		t.Errorf("replay is not detected")
+	}
+	select {
+	case <-accepted:
+		t.Errorf("replayed open session request is accepted")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
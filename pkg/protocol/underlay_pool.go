@@ -0,0 +1,357 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/mathext"
+)
+
+// MaxSpareUnderlays is the maximum number of spare underlays in a pool.
+const MaxSpareUnderlays = 8
+
+// UnderlayPoolConfig controls how the underlay pool reuses underlays.
+type UnderlayPoolConfig struct {
+	// MaxSessionsPerUnderlay is the maximum number of sessions carried by
+	// a underlay at the same time. 0 means no limit.
+	MaxSessionsPerUnderlay int
+
+	// MaxUnderlayLifetime stops scheduling new sessions to a underlay
+	// after it has been used for this duration. The underlay is closed
+	// after all the existing sessions finish. 0 means no limit.
+	MaxUnderlayLifetime time.Duration
+
+	// SpareUnderlays is the number of idle underlays dialed in advance.
+	SpareUnderlays int
+}
+
+// UnderlayPool owns the underlays of a mux. In the client, it decides
+// which underlay a new session is scheduled to, dials new underlays
+// when needed, and retires underlays that can't take more sessions.
+type UnderlayPool struct {
+	config          UnderlayPoolConfig
+	multiplexFactor int
+	affinityTTL     time.Duration
+	affinity        map[string]underlayAffinity // Map<affinity key, underlay>
+	underlays       []Underlay
+	spares          map[Underlay]struct{} // underlays that never carry a session
+	dialFunc        func(context.Context) (Underlay, error)
+	mu              sync.Mutex
+}
+
+// underlayAffinity records the underlay recently used by an affinity key.
+type underlayAffinity struct {
+	underlay Underlay
+	expire   time.Time
+}
+
+// newUnderlayPool creates a new underlay pool. The dial function
+// creates a new client underlay and runs it in the background.
+func newUnderlayPool(dialFunc func(context.Context) (Underlay, error)) *UnderlayPool {
+	return &UnderlayPool{
+		underlays: make([]Underlay, 0),
+		spares:    make(map[Underlay]struct{}),
+		dialFunc:  dialFunc,
+	}
+}
+
+// Len returns the number of underlays in the pool.
+func (p *UnderlayPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.underlays)
+}
+
+// SpareCount returns the number of spare underlays in the pool.
+func (p *UnderlayPool) SpareCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.spares)
+}
+
+// all returns a copy of the underlays in the pool.
+func (p *UnderlayPool) all() []Underlay {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	res := make([]Underlay, len(p.underlays))
+	copy(res, p.underlays)
+	return res
+}
+
+// add puts a underlay accepted by the server into the pool.
+func (p *UnderlayPool) add(underlay Underlay) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.underlays = append(p.underlays, underlay)
+	p.clean(false)
+}
+
+// closeAll closes all the underlays and empties the pool.
+func (p *UnderlayPool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, underlay := range p.underlays {
+		underlay.Close()
+	}
+	p.underlays = make([]Underlay, 0)
+	p.spares = make(map[Underlay]struct{})
+	p.affinity = nil
+	if p.affinityTTL > 0 {
+		p.affinity = make(map[string]underlayAffinity)
+	}
+}
+
+// get returns a underlay that a new client session can be scheduled to.
+// The number of pending sessions of the returned underlay is increased
+// by 1, and the caller must decrease it after the session is added.
+func (p *UnderlayPool) get(ctx context.Context, key string) (Underlay, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var err error
+
+	p.clean(true)
+	filter := endpointFilterFromContext(ctx)
+	underlay := p.pickAffinity(key, filter)
+	if underlay == nil {
+		underlay = p.maybePickExisting(filter)
+	}
+	if underlay == nil {
+		underlay = p.pickSpare(filter)
+	}
+	if underlay == nil {
+		underlay, err = p.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		log.WithFields(underlayLogFields(underlay)).Debugf("Created new underlay %v", underlay)
+	} else {
+		log.Debugf("Reusing existing underlay %v", underlay)
+	}
+
+	if ok := underlay.Scheduler().IncPending(); !ok {
+		// This underlay can't be used. Create a new one.
+		underlay, err = p.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("Created yet another new underlay %v", underlay)
+		underlay.Scheduler().IncPending()
+	}
+	delete(p.spares, underlay)
+	if key != "" && p.affinity != nil && p.multiplexFactor > 0 {
+		p.affinity[key] = underlayAffinity{
+			underlay: underlay,
+			expire:   time.Now().Add(p.affinityTTL),
+		}
+	}
+	return underlay, nil
+}
+
+// fillSpares dials new underlays until the number of spare underlays
+// reaches the configured value. The pool is not locked while dialing.
+func (p *UnderlayPool) fillSpares(ctx context.Context) {
+	p.mu.Lock()
+	missing := p.config.SpareUnderlays - len(p.spares)
+	p.mu.Unlock()
+	for i := 0; i < missing; i++ {
+		underlay, err := p.dialFunc(ctx)
+		if err != nil {
+			log.Debugf("Failed to create spare underlay: %v", err)
+			return
+		}
+		p.mu.Lock()
+		p.track(underlay)
+		p.spares[underlay] = struct{}{}
+		p.mu.Unlock()
+		log.Debugf("Created spare underlay %v", underlay)
+	}
+}
+
+// dial creates a new underlay and puts it into the pool.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) dial(ctx context.Context) (Underlay, error) {
+	underlay, err := p.dialFunc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.track(underlay)
+	return underlay, nil
+}
+
+// track puts a new client underlay into the pool.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) track(underlay Underlay) {
+	if p.config.MaxUnderlayLifetime > 0 {
+		underlay.Scheduler().SetRemainingTime(p.config.MaxUnderlayLifetime)
+	}
+	p.underlays = append(p.underlays, underlay)
+}
+
+// usable returns true if a new session can be scheduled to the underlay.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) usable(underlay Underlay) bool {
+	select {
+	case <-underlay.Done():
+		return false
+	default:
+	}
+	if underlay.Scheduler().IsDisabled() {
+		return false
+	}
+	if p.config.MaxSessionsPerUnderlay > 0 && underlay.SessionCount()+underlay.Scheduler().Pending() >= p.config.MaxSessionsPerUnderlay {
+		return false
+	}
+	return true
+}
+
+// maybePickExisting returns either an existing underlay that
+// can be used by a session, or nil. In the later case a new underlay
+// should be created.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) maybePickExisting(filter EndpointFilter) Underlay {
+	active := make([]Underlay, 0)
+	for _, underlay := range p.underlays {
+		if _, ok := p.spares[underlay]; ok {
+			continue
+		}
+		if p.usable(underlay) && filter.match(underlay) {
+			active = append(active, underlay)
+		}
+	}
+
+	if p.multiplexFactor > 0 {
+		reuseUnderlayFactor := len(active) * p.multiplexFactor
+		n := mrand.Intn(reuseUnderlayFactor + 1)
+		if n < reuseUnderlayFactor {
+			return active[n/p.multiplexFactor]
+		}
+	}
+	return nil
+}
+
+// pickSpare returns a spare underlay, or nil if there is no spare
+// underlay that can be used.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) pickSpare(filter EndpointFilter) Underlay {
+	for underlay := range p.spares {
+		if p.usable(underlay) && filter.match(underlay) {
+			return underlay
+		}
+	}
+	return nil
+}
+
+// pickAffinity returns the underlay recently used by the
+// affinity key, or nil if it is not found or no longer usable.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) pickAffinity(key string, filter EndpointFilter) Underlay {
+	if key == "" || p.affinity == nil || p.multiplexFactor == 0 {
+		return nil
+	}
+	a, found := p.affinity[key]
+	if !found {
+		return nil
+	}
+	if time.Now().After(a.expire) || !p.usable(a.underlay) {
+		delete(p.affinity, key)
+		return nil
+	}
+	if !filter.match(a.underlay) {
+		return nil
+	}
+	return a.underlay
+}
+
+// maintain removes closed underlays and expired underlay affinity.
+func (p *UnderlayPool) maintain(isClient bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clean(isClient)
+	for key, a := range p.affinity {
+		if time.Now().After(a.expire) {
+			delete(p.affinity, key)
+		}
+	}
+}
+
+// clean removes closed underlays.
+// This method MUST be called only when holding the mu lock.
+func (p *UnderlayPool) clean(alsoDisableIdleUnderlay bool) {
+	remaining := make([]Underlay, 0)
+	disable := 0
+	close := 0
+	for _, underlay := range p.underlays {
+		select {
+		case <-underlay.Done():
+			delete(p.spares, underlay)
+		default:
+			_, spare := p.spares[underlay]
+			if alsoDisableIdleUnderlay && !spare && underlay.SessionCount() == 0 {
+				if underlay.Scheduler().TryDisable() {
+					disable++
+				}
+			}
+			if underlay.SessionCount() == 0 && underlay.Scheduler().Idle() {
+				underlay.Close()
+				delete(p.spares, underlay)
+				close++
+			} else {
+				remaining = append(remaining, underlay)
+			}
+		}
+	}
+	p.underlays = remaining
+	if disable > 0 {
+		log.Debugf("Mux disabled scheduling from %d underlays", disable)
+	}
+	if close > 0 {
+		log.Debugf("Mux cleaned %d underlays", close)
+	}
+}
+
+// setMultiplexFactor sets how frequent a underlay is reused.
+func (p *UnderlayPool) setMultiplexFactor(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.multiplexFactor = mathext.Max(n, 0)
+}
+
+// setAffinityTTL sets how long a affinity key sticks to a underlay.
+func (p *UnderlayPool) setAffinityTTL(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.affinityTTL = mathext.Max(ttl, 0)
+	p.affinity = nil
+	if p.affinityTTL > 0 {
+		p.affinity = make(map[string]underlayAffinity)
+	}
+}
+
+// setConfig updates the pool configuration.
+func (p *UnderlayPool) setConfig(config UnderlayPoolConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = UnderlayPoolConfig{
+		MaxSessionsPerUnderlay: mathext.Max(config.MaxSessionsPerUnderlay, 0),
+		MaxUnderlayLifetime:    mathext.Max(config.MaxUnderlayLifetime, 0),
+		SpareUnderlays:         mathext.Min(mathext.Max(config.SpareUnderlays, 0), MaxSpareUnderlays),
+	}
+}
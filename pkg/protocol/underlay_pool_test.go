@@ -0,0 +1,151 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestUnderlayPool(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	serverProperties := NewUnderlayProperties(1500, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1500, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(3).
+		SetClientUnderlayPool(UnderlayPoolConfig{
+			MaxSessionsPerUnderlay: 2,
+			MaxUnderlayLifetime:    time.Minute,
+			SpareUnderlays:         2,
+		}).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer clientMux.Close()
+	pool := clientMux.UnderlayPool()
+
+	// Each underlay carries at most 2 sessions.
+	for i := 0; i < 6; i++ {
+		conn, err := clientMux.DialContext(context.Background())
+		if err != nil {
+			t.Fatalf("DialContext() failed: %v", err)
+		}
+		defer conn.Close()
+	}
+	if pool.Len() < 3 {
+		t.Errorf("got %d underlays, want at least 3", pool.Len())
+	}
+	for _, underlay := range pool.all() {
+		if underlay.SessionCount() > 2 {
+			t.Errorf("underlay %v has %d sessions, want at most 2", underlay, underlay.SessionCount())
+		}
+		if underlay.Scheduler().IsDisabled() {
+			t.Errorf("underlay %v is disabled before its lifetime ends", underlay)
+		}
+	}
+
+	// A new session takes a spare underlay.
+	spareMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(0).
+		SetClientUnderlayPool(UnderlayPoolConfig{
+			SpareUnderlays: 2,
+		}).
+		SetEndpoints([]UnderlayProperties{clientProperties})
+	defer spareMux.Close()
+	pool = spareMux.UnderlayPool()
+	spareMux.mu.Lock()
+	pool.fillSpares(context.Background())
+	spareMux.mu.Unlock()
+	if pool.SpareCount() != 2 {
+		t.Fatalf("got %d spare underlays, want 2", pool.SpareCount())
+	}
+	n := pool.Len()
+	conn, err := spareMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+	defer conn.Close()
+	if pool.SpareCount() != 1 {
+		t.Errorf("got %d spare underlays, want 1", pool.SpareCount())
+	}
+	if pool.Len() != n {
+		t.Errorf("got %d underlays, want %d", pool.Len(), n)
+	}
+
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestScheduleControllerRemainingTime(t *testing.T) {
+	c := &ScheduleController{}
+	c.SetRemainingTime(time.Hour)
+	c.SetRemainingTime(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if !c.IsDisabled() {
+		t.Errorf("scheduler is not disabled after the shorter remaining time")
+	}
+	if c.IncPending() {
+		t.Errorf("IncPending() succeeded after the scheduler is disabled")
+	}
+}
+
+func TestScheduleControllerIdleTime(t *testing.T) {
+	c := &ScheduleController{}
+	c.SetIdleTime(10 * time.Millisecond)
+	c.IncPending()
+	c.DecPending()
+	if c.TryDisable() {
+		t.Errorf("TryDisable() succeeded right after a session is scheduled")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !c.TryDisable() {
+		t.Fatalf("TryDisable() failed after the idle time")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !c.Idle() {
+		t.Errorf("Idle() = false after the idle time")
+	}
+}
@@ -20,13 +20,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync/atomic"
 	"time"
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
-	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/chaos"
 	"github.com/enfein/mieru/v3/pkg/cipher"
 	"github.com/enfein/mieru/v3/pkg/common"
-	"github.com/enfein/mieru/v3/pkg/common/sockopts"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
 	"github.com/enfein/mieru/v3/pkg/replay"
@@ -38,7 +38,7 @@ const (
 	streamOverhead = MetadataLength + cipher.DefaultOverhead*2
 )
 
-var streamReplayCache = replay.NewCache(4*1024*1024, cipher.KeyRefreshInterval*3)
+var streamReplayCache = replay.NewCache(DefaultReplayCacheCapacity, DefaultReplayCacheExpireInterval)
 
 type StreamUnderlay struct {
 	baseUnderlay
@@ -51,8 +51,22 @@ type StreamUnderlay struct {
 	// When isClient is true, there must be exactly 1 element in the slice.
 	candidates []cipher.BlockCipher
 
+	established atomic.Bool // received at least one segment from the peer
+	segmentSize int         // split writes to this size if not 0, protected by egress
+
 	// ---- server fields ----
-	users map[string]*appctlpb.User
+	userSet         *atomic.Pointer[serverUserSet]
+	user            atomic.Value // string, name of the user authenticated by the first segment
+	decoy           *Decoy       // serves the connection if the first segment fails authentication
+	unauthenticated []byte       // data of the first segment that fails authentication
+
+	// ---- client fields ----
+	dialAddr      string        // server address used to count stalls
+	rekeyBytes    int64         // rotate the keys after this number of bytes, 0 means disabled
+	rekeyInterval time.Duration // rotate the keys after this time, 0 means disabled
+	rekeyCounter  atomic.Int64  // number of bytes sent and received with the current keys
+	lastRekey     time.Time     // time of the last rekey, protected by egress
+	peerRekey     atomic.Bool   // the server supports rekey
 }
 
 var _ Underlay = &StreamUnderlay{}
@@ -60,9 +74,10 @@ var _ Underlay = &StreamUnderlay{}
 // NewStreamUnderlay connects to the remote address "raddr" on the network
 // with packet encryption. If "laddr" is empty, an automatic address is used.
 // "block" is the block encryption algorithm to encrypt packets.
+// If "dialer" is not nil, it creates the connection, and "laddr" is ignored.
 //
 // This function is only used by proxy client.
-func NewStreamUnderlay(ctx context.Context, network, laddr, raddr string, mtu int, block cipher.BlockCipher, resolver apicommon.DNSResolver) (*StreamUnderlay, error) {
+func NewStreamUnderlay(ctx context.Context, network, laddr, raddr string, mtu int, block cipher.BlockCipher, resolver apicommon.DNSResolver, dialer apicommon.Dialer) (*StreamUnderlay, error) {
 	switch network {
 	case "tcp", "tcp4", "tcp6":
 	default:
@@ -71,25 +86,43 @@ func NewStreamUnderlay(ctx context.Context, network, laddr, raddr string, mtu in
 	if block.IsStateless() {
 		return nil, fmt.Errorf("stream underlay block cipher must be stateful")
 	}
-	dialer := net.Dialer{
-		Control: sockopts.ReuseAddrPort(),
-	}
-	if laddr != "" {
-		tcpLocalAddr, err := apicommon.ResolveTCPAddr(resolver, network, laddr)
+	var conn net.Conn
+	var segmentSize int
+	if dialer != nil {
+		var err error
+		conn, err = dialer.DialContext(ctx, network, raddr)
 		if err != nil {
-			return nil, fmt.Errorf("ResolveTCPAddr() failed: %w", err)
+			return nil, fmt.Errorf("DialContext() failed: %w", err)
 		}
-		dialer.LocalAddr = tcpLocalAddr
-	}
-
-	conn, err := dialer.DialContext(ctx, network, raddr)
-	if err != nil {
-		return nil, fmt.Errorf("DialContext() failed: %w", err)
+		if streamMSSClamp.clamped(raddr) {
+			// The MSS of a custom dialer can't be clamped.
+			segmentSize = clampedMSS
+		}
+	} else {
+		control, clampSegmentSize := streamDialControl(raddr)
+		netDialer := net.Dialer{
+			Control: control,
+		}
+		if laddr != "" {
+			tcpLocalAddr, err := apicommon.ResolveTCPAddr(resolver, network, laddr)
+			if err != nil {
+				return nil, fmt.Errorf("ResolveTCPAddr() failed: %w", err)
+			}
+			netDialer.LocalAddr = tcpLocalAddr
+		}
+		var err error
+		conn, err = netDialer.DialContext(ctx, network, raddr)
+		if err != nil {
+			return nil, fmt.Errorf("DialContext() failed: %w", err)
+		}
+		segmentSize = clampSegmentSize()
 	}
 	t := &StreamUnderlay{
 		baseUnderlay: *newBaseUnderlay(true, mtu),
 		conn:         conn,
 		candidates:   []cipher.BlockCipher{block},
+		segmentSize:  segmentSize,
+		dialAddr:     raddr,
 	}
 	return t, nil
 }
@@ -115,6 +148,13 @@ func (t *StreamUnderlay) String() string {
 	return fmt.Sprintf("StreamUnderlay{local=%v, remote=%v, mtu=%v}", t.conn.LocalAddr(), t.conn.RemoteAddr(), t.mtu)
 }
 
+// userName returns the name of user authenticated by the server underlay,
+// or an empty string if the underlay is not authenticated.
+func (t *StreamUnderlay) userName() string {
+	name, _ := t.user.Load().(string)
+	return name
+}
+
 func (t *StreamUnderlay) Close() error {
 	t.closeMutex.Lock()
 	defer t.closeMutex.Unlock()
@@ -129,6 +169,11 @@ func (t *StreamUnderlay) Close() error {
 	return t.conn.Close()
 }
 
+// Drain implements Underlay.
+func (t *StreamUnderlay) Drain(ctx context.Context) error {
+	return drainUnderlay(ctx, t, &t.baseUnderlay)
+}
+
 func (t *StreamUnderlay) Addr() net.Addr {
 	return t.LocalAddr()
 }
@@ -159,19 +204,7 @@ func (t *StreamUnderlay) AddSession(s *Session, remoteAddr net.Addr) error {
 	close(s.ready)
 	log.Debugf("Adding session %d to %v", s.id, t)
 
-	s.wg.Add(2)
-	go func() {
-		if err := s.runInputLoop(context.Background()); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
-			log.Debugf("%v runInputLoop(): %v", s, err)
-		}
-		s.wg.Done()
-	}()
-	go func() {
-		if err := s.runOutputLoop(context.Background()); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
-			log.Debugf("%v runOutputLoop(): %v", s, err)
-		}
-		s.wg.Done()
-	}()
+	s.startLoops(t.engine)
 	return nil
 }
 
@@ -180,7 +213,9 @@ func (t *StreamUnderlay) RunEventLoop(ctx context.Context) error {
 		return stderror.ErrNullPointer
 	}
 
+	timer := segmentTimer{histogram: UnderlayTCPSegmentLatency}
 	for {
+		timer.end()
 		select {
 		case <-ctx.Done():
 			return nil
@@ -198,10 +233,18 @@ func (t *StreamUnderlay) RunEventLoop(ctx context.Context) error {
 				panic(fmt.Sprintf("%v got unexpected error type UNKNOWN_ERROR", t))
 			}
 			if errType == stderror.CRYPTO_ERROR || errType == stderror.REPLAY_ERROR {
-				t.drainAfterError()
+				if t.decoy != nil && t.unauthenticated != nil {
+					if err := t.decoy.serve(t.conn, t.unauthenticated); err != nil {
+						log.Debugf("%v decoy failed: %v", t, err)
+					}
+				} else {
+					t.drainAfterError()
+				}
 			}
 			return fmt.Errorf("readOneSegment() failed: %w", err)
 		}
+		timer.begin()
+		t.established.Store(true)
 		if log.IsLevelEnabled(log.TraceLevel) {
 			log.Tracef("%v received %v", t, seg)
 		}
@@ -219,6 +262,21 @@ func (t *StreamUnderlay) RunEventLoop(ctx context.Context) error {
 				if err := t.onCloseSession(seg); err != nil {
 					return fmt.Errorf("onCloseSession() failed: %w", err)
 				}
+			case mtuProbeRequest, mtuProbeResponse, resumeSessionRequest, resumeSessionResponse:
+				// Path MTU discovery and session migration are not supported by stream underlay.
+				log.Debugf("%v ignored %v", t, seg)
+			case rekeyRequest:
+				if err := t.onRekeyRequest(seg); err != nil {
+					return fmt.Errorf("onRekeyRequest() failed: %w", err)
+				}
+			case rekeyResponse:
+				if err := t.onRekeyResponse(seg); err != nil {
+					return fmt.Errorf("onRekeyResponse() failed: %w", err)
+				}
+			case credentialPush:
+				if err := t.onCredentialPush(seg); err != nil {
+					return fmt.Errorf("onCredentialPush() failed: %w", err)
+				}
 			default:
 				panic(fmt.Sprintf("Protocol %d is a session protocol but not recognized by stream underlay", seg.metadata.Protocol()))
 			}
@@ -245,7 +303,7 @@ func (t *StreamUnderlay) RunEventLoop(ctx context.Context) error {
 				}
 				continue
 			}
-			session.(*Session).recvChan <- seg
+			session.(*Session).deliver(seg)
 		} else {
 			log.Debugf("Ignore unknown protocol %d", seg.metadata.Protocol())
 		}
@@ -267,9 +325,15 @@ func (t *StreamUnderlay) onOpenSessionRequest(seg *segment) error {
 	if found {
 		return fmt.Errorf("%v received open session request, but session ID %d is already used", t, sessionID)
 	}
-	session := NewSession(sessionID, false, t.MTU(), t.users)
+	if t.isDraining() {
+		UnderlayDrainRejectedSessions.Add(1)
+		log.Debugf("%v rejected session %d because it is draining", t, sessionID)
+		return t.writeOneSegment(newCloseSessionRequest(sessionID, t.TransportProtocol(), nil))
+	}
+	session := NewSession(sessionID, false, t.MTU(), loadServerUsers(t.userSet))
+	session.keyExchange = acceptKeyExchange(seg.metadata.(*sessionStruct).keyExchange)
 	t.AddSession(session, nil)
-	session.recvChan <- seg
+	session.deliver(seg)
 	t.readySessions <- session
 	return nil
 }
@@ -279,12 +343,15 @@ func (t *StreamUnderlay) onOpenSessionResponse(seg *segment) error {
 		return stderror.ErrInvalidOperation
 	}
 
-	sessionID := seg.metadata.(*sessionStruct).sessionID
-	session, found := t.sessionMap.Load(sessionID)
+	ss := seg.metadata.(*sessionStruct)
+	if ServerFeatures(ss.serverFeatures).Has(FeatureRekey) {
+		t.peerRekey.Store(true)
+	}
+	session, found := t.sessionMap.Load(ss.sessionID)
 	if !found {
-		return fmt.Errorf("session ID %d is not found", sessionID)
+		return fmt.Errorf("session ID %d is not found", ss.sessionID)
 	}
-	session.(*Session).recvChan <- seg
+	session.(*Session).deliver(seg)
 	return nil
 }
 
@@ -299,7 +366,7 @@ func (t *StreamUnderlay) onCloseSession(seg *segment) error {
 		return nil
 	}
 	s := session.(*Session)
-	s.recvChan <- seg
+	s.deliver(seg)
 	s.wg.Wait()
 	t.RemoveSession(s)
 	return nil
@@ -326,6 +393,7 @@ func (t *StreamUnderlay) readOneSegment() (*segment, error) {
 	} else {
 		metrics.UploadBytes.Add(int64(len(encryptedMeta)))
 	}
+	t.rekeyCounter.Add(int64(len(encryptedMeta)))
 	isNewSessionReplay := false
 	if streamReplayCache.IsDuplicate(encryptedMeta[:cipher.DefaultOverhead], replay.EmptyTag) {
 		if firstRead {
@@ -343,10 +411,11 @@ func (t *StreamUnderlay) readOneSegment() (*segment, error) {
 	}
 	if t.recv == nil {
 		var peerBlock cipher.BlockCipher
-		peerBlock, decryptedMeta, err = cipher.SelectDecrypt(encryptedMeta, cipher.CloneBlockCiphers(t.candidates))
+		peerBlock, decryptedMeta, err = serverSourceCache.SelectDecrypt(t.conn.RemoteAddr(), encryptedMeta, cipher.CloneBlockCiphers(t.candidates))
 		cipher.ServerIterateDecrypt.Add(1)
 		if err != nil {
 			cipher.ServerFailedIterateDecrypt.Add(1)
+			t.unauthenticated = encryptedMeta
 			if isNewSessionReplay {
 				err = fmt.Errorf("found possible replay attack in %v", t)
 				return nil, stderror.WrapErrorWithType(err, stderror.REPLAY_ERROR)
@@ -356,10 +425,12 @@ func (t *StreamUnderlay) readOneSegment() (*segment, error) {
 			}
 		} else if isNewSessionReplay {
 			replay.NewSessionDecrypted.Add(1)
+			t.unauthenticated = encryptedMeta
 			err = fmt.Errorf("found possible replay attack with payload decrypted in %v", t)
 			return nil, stderror.WrapErrorWithType(err, stderror.REPLAY_ERROR)
 		}
 		t.recv = peerBlock.Clone()
+		t.user.Store(peerBlock.BlockContext().UserName)
 	} else {
 		decryptedMeta, err = t.recv.Decrypt(encryptedMeta)
 		if t.isClient {
@@ -405,11 +476,12 @@ func (t *StreamUnderlay) readOneSegment() (*segment, error) {
 }
 
 func (t *StreamUnderlay) readSessionSegment(ss *sessionStruct) (*segment, error) {
-	var decryptedPayload []byte
+	var decryptedPayload, buf []byte
 	var err error
 
 	if ss.payloadLen > 0 {
-		encryptedPayload := make([]byte, ss.payloadLen+cipher.DefaultOverhead)
+		encryptedPayload := underlayBufferPool.Get(int(ss.payloadLen) + cipher.DefaultOverhead)
+		defer underlayBufferPool.Put(encryptedPayload)
 		if _, err := io.ReadFull(t.conn, encryptedPayload); err != nil {
 			err = fmt.Errorf("payload: read %d bytes from StreamUnderlay failed: %w", ss.payloadLen+cipher.DefaultOverhead, err)
 			return nil, stderror.WrapErrorWithType(err, stderror.NETWORK_ERROR)
@@ -419,10 +491,12 @@ func (t *StreamUnderlay) readSessionSegment(ss *sessionStruct) (*segment, error)
 		} else {
 			metrics.UploadBytes.Add(int64(len(encryptedPayload)))
 		}
+		t.rekeyCounter.Add(int64(len(encryptedPayload)))
 		if streamReplayCache.IsDuplicate(encryptedPayload[:cipher.DefaultOverhead], replay.EmptyTag) {
 			replay.KnownSession.Add(1)
 		}
-		decryptedPayload, err = t.recv.Decrypt(encryptedPayload)
+		buf = underlayBufferPool.Get(int(ss.payloadLen))
+		decryptedPayload, err = t.recv.DecryptTo(buf[:0], encryptedPayload)
 		if t.isClient {
 			cipher.ClientDirectDecrypt.Add(1)
 		} else {
@@ -434,7 +508,8 @@ func (t *StreamUnderlay) readSessionSegment(ss *sessionStruct) (*segment, error)
 			} else {
 				cipher.ServerFailedDirectDecrypt.Add(1)
 			}
-			err = fmt.Errorf("Decrypt() failed: %w", err)
+			underlayBufferPool.Put(buf)
+			err = fmt.Errorf("DecryptTo() failed: %w", err)
 			return nil, stderror.WrapErrorWithType(err, stderror.CRYPTO_ERROR)
 		}
 	}
@@ -456,11 +531,12 @@ func (t *StreamUnderlay) readSessionSegment(ss *sessionStruct) (*segment, error)
 		payload:   decryptedPayload,
 		transport: common.StreamTransport,
 		block:     t.recv,
+		buf:       buf,
 	}, nil
 }
 
 func (t *StreamUnderlay) readDataAckSegment(das *dataAckStruct) (*segment, error) {
-	var decryptedPayload []byte
+	var decryptedPayload, buf []byte
 	var err error
 
 	if das.prefixLen > 0 {
@@ -476,7 +552,8 @@ func (t *StreamUnderlay) readDataAckSegment(das *dataAckStruct) (*segment, error
 		}
 	}
 	if das.payloadLen > 0 {
-		encryptedPayload := make([]byte, das.payloadLen+cipher.DefaultOverhead)
+		encryptedPayload := underlayBufferPool.Get(int(das.payloadLen) + cipher.DefaultOverhead)
+		defer underlayBufferPool.Put(encryptedPayload)
 		if _, err := io.ReadFull(t.conn, encryptedPayload); err != nil {
 			err = fmt.Errorf("payload: read %d bytes from StreamUnderlay failed: %w", das.payloadLen+cipher.DefaultOverhead, err)
 			return nil, stderror.WrapErrorWithType(err, stderror.NETWORK_ERROR)
@@ -486,10 +563,12 @@ func (t *StreamUnderlay) readDataAckSegment(das *dataAckStruct) (*segment, error
 		} else {
 			metrics.UploadBytes.Add(int64(len(encryptedPayload)))
 		}
+		t.rekeyCounter.Add(int64(len(encryptedPayload)))
 		if streamReplayCache.IsDuplicate(encryptedPayload[:cipher.DefaultOverhead], replay.EmptyTag) {
 			replay.KnownSession.Add(1)
 		}
-		decryptedPayload, err = t.recv.Decrypt(encryptedPayload)
+		buf = underlayBufferPool.Get(int(das.payloadLen))
+		decryptedPayload, err = t.recv.DecryptTo(buf[:0], encryptedPayload)
 		if t.isClient {
 			cipher.ClientDirectDecrypt.Add(1)
 		} else {
@@ -501,7 +580,8 @@ func (t *StreamUnderlay) readDataAckSegment(das *dataAckStruct) (*segment, error
 			} else {
 				cipher.ServerFailedDirectDecrypt.Add(1)
 			}
-			err = fmt.Errorf("Decrypt() failed: %w", err)
+			underlayBufferPool.Put(buf)
+			err = fmt.Errorf("DecryptTo() failed: %w", err)
 			return nil, stderror.WrapErrorWithType(err, stderror.CRYPTO_ERROR)
 		}
 	}
@@ -523,6 +603,7 @@ func (t *StreamUnderlay) readDataAckSegment(das *dataAckStruct) (*segment, error
 		payload:   decryptedPayload,
 		transport: common.StreamTransport,
 		block:     t.recv,
+		buf:       buf,
 	}, nil
 }
 
@@ -531,9 +612,27 @@ func (t *StreamUnderlay) writeOneSegment(seg *segment) error {
 		return stderror.ErrNullPointer
 	}
 
-	t.sendMutex.Lock()
-	defer t.sendMutex.Unlock()
+	switch chaosFault(seg) {
+	case chaos.Drop:
+		return nil
+	case chaos.Kill:
+		go t.Close()
+		return fmt.Errorf("%v is killed by chaos fault: %w", t, stderror.ErrDisconnected)
+	}
+
+	sessionID, _ := seg.SessionID()
+	t.egress.acquire(sessionID, t.egressWeight(sessionID))
+	defer t.egress.release()
+
+	if err := t.writeSegment(seg); err != nil {
+		return err
+	}
+	return t.maybeRekey()
+}
 
+// writeSegment encrypts and writes the segment to the connection.
+// The caller must hold the egress of the underlay.
+func (t *StreamUnderlay) writeSegment(seg *segment) error {
 	if err := t.maybeInitSendBlockCipher(); err != nil {
 		return fmt.Errorf("maybeInitSendBlockCipher() failed: %w", err)
 	}
@@ -562,7 +661,8 @@ func (t *StreamUnderlay) writeOneSegment(seg *segment) error {
 			dataToSend = append(dataToSend, encryptedPayload...)
 		}
 		dataToSend = append(dataToSend, padding...)
-		if _, err := t.conn.Write(dataToSend); err != nil {
+		chaos.OnWriteCiphertext(dataToSend[:len(encryptedMetadata)])
+		if err := t.writeConn(dataToSend); err != nil {
 			return fmt.Errorf("Write() failed: %w", err)
 		}
 		if t.isClient {
@@ -570,6 +670,7 @@ func (t *StreamUnderlay) writeOneSegment(seg *segment) error {
 		} else {
 			metrics.DownloadBytes.Add(int64(len(dataToSend)))
 		}
+		t.rekeyCounter.Add(int64(len(dataToSend)))
 		metrics.OutputPaddingBytes.Add(int64(len(padding)))
 	} else if das, ok := toDataAckStruct(seg.metadata); ok {
 		padding1 := newPadding(paddingOpts{
@@ -603,7 +704,8 @@ func (t *StreamUnderlay) writeOneSegment(seg *segment) error {
 			dataToSend = append(dataToSend, encryptedPayload...)
 		}
 		dataToSend = append(dataToSend, padding2...)
-		if _, err := t.conn.Write(dataToSend); err != nil {
+		chaos.OnWriteCiphertext(dataToSend[:len(encryptedMetadata)])
+		if err := t.writeConn(dataToSend); err != nil {
 			return fmt.Errorf("Write() failed: %w", err)
 		}
 		if t.isClient {
@@ -611,6 +713,7 @@ func (t *StreamUnderlay) writeOneSegment(seg *segment) error {
 		} else {
 			metrics.DownloadBytes.Add(int64(len(dataToSend)))
 		}
+		t.rekeyCounter.Add(int64(len(dataToSend)))
 		metrics.OutputPaddingBytes.Add(int64(len(padding1)))
 		metrics.OutputPaddingBytes.Add(int64(len(padding2)))
 	} else {
@@ -0,0 +1,213 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+const (
+	// MaxRateLimitKilobytesPerSecond is the maximum speed limit of a user.
+	MaxRateLimitKilobytesPerSecond = 10 * 1024 * 1024
+
+	// minRateLimitBurst is the minimum number of bytes that can be sent
+	// at once by a user whose speed is limited.
+	minRateLimitBurst = 16 * 1024
+)
+
+// UserTrafficBytes returns the number of bytes sent from and sent to
+// the user since the counters are created. The counters are kept after
+// restart if metrics dump is enabled.
+func UserTrafficBytes(userName string) (uploadBytes, downloadBytes int64) {
+	metricGroup := metrics.GetMetricGroupByName(fmt.Sprintf(metrics.UserMetricGroupFormat, userName))
+	if metricGroup == nil {
+		return 0, 0
+	}
+	if upload, found := metricGroup.GetMetric(metrics.UserMetricUploadBytes); found {
+		uploadBytes = upload.Load()
+	}
+	if download, found := metricGroup.GetMetric(metrics.UserMetricDownloadBytes); found {
+		downloadBytes = download.Load()
+	}
+	return
+}
+
+// QuotaUsedBytes returns the number of bytes sent from and sent to
+// the user in the current period of the quota.
+func QuotaUsedBytes(userName string, quota *appctlpb.Quota, now time.Time) (int64, error) {
+	metricGroupName := fmt.Sprintf(metrics.UserMetricGroupFormat, userName)
+	metricGroup := metrics.GetMetricGroupByName(metricGroupName)
+	if metricGroup == nil {
+		return 0, fmt.Errorf("metric group %s is not found", metricGroupName)
+	}
+	uploadBytes, found := metricGroup.GetMetric(metrics.UserMetricUploadBytes)
+	if !found {
+		return 0, fmt.Errorf("metric %s in group %s is not found", metrics.UserMetricUploadBytes, metricGroupName)
+	}
+	downloadBytes, found := metricGroup.GetMetric(metrics.UserMetricDownloadBytes)
+	if !found {
+		return 0, fmt.Errorf("metric %s in group %s is not found", metrics.UserMetricDownloadBytes, metricGroupName)
+	}
+	start := quotaPeriodStart(quota, now)
+	totalBytes := uploadBytes.(*metrics.Counter).DeltaBetween(start, now)
+	totalBytes += downloadBytes.(*metrics.Counter).DeltaBetween(start, now)
+	return totalBytes, nil
+}
+
+// quotaPeriodStart returns the beginning of the current period of the quota.
+// A monthly quota is renewed at the beginning of each calendar month in UTC,
+// and other quotas are renewed on a rolling basis.
+func quotaPeriodStart(quota *appctlpb.Quota, now time.Time) time.Time {
+	if quota.GetMonthly() {
+		utc := now.UTC()
+		return time.Date(utc.Year(), utc.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return now.Add(-time.Duration(quota.GetDays()) * 24 * time.Hour)
+}
+
+// tokenBucket limits the number of bytes per second. A caller that
+// takes more bytes than available waits until the bucket is refilled.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64 // maximum number of bytes in the bucket
+	tokens float64 // can be negative if callers are waiting
+	last   time.Time
+}
+
+func newTokenBucket(bytesPerSecond int64) *tokenBucket {
+	b := &tokenBucket{last: time.Now()}
+	b.setRate(bytesPerSecond)
+	b.tokens = b.burst
+	return b
+}
+
+// setRate changes the number of bytes per second.
+func (b *tokenBucket) setRate(bytesPerSecond int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = float64(bytesPerSecond)
+	b.burst = float64(bytesPerSecond)
+	if b.burst < minRateLimitBurst {
+		b.burst = minRateLimitBurst
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// reserve takes n bytes from the bucket, and returns how long the caller
+// needs to wait before sending them.
+func (b *tokenBucket) reserve(n int, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// wait blocks until n bytes are allowed, or the done channel is closed.
+// A nil bucket doesn't limit the rate.
+func (b *tokenBucket) wait(n int, done <-chan struct{}) {
+	if b == nil || n <= 0 {
+		return
+	}
+	d := b.reserve(n, time.Now())
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-done:
+	}
+}
+
+// userRateLimiter limits the speed of all the sessions of a user.
+type userRateLimiter struct {
+	upload   *tokenBucket // nil if the upload is not limited
+	download *tokenBucket // nil if the download is not limited
+}
+
+var (
+	userRateLimitersMu sync.Mutex
+	userRateLimiters   = map[string]*userRateLimiter{}
+)
+
+// rateLimiterOf returns the rate limiter shared by the sessions of the user.
+// It returns nil if the speed of the user is not limited. If the limit
+// of the user is changed, the rate of the existing token buckets is updated.
+func rateLimiterOf(user *appctlpb.User) *userRateLimiter {
+	uploadBytesPerSecond := int64(user.GetRateLimit().GetUploadKilobytesPerSecond()) * 1024
+	downloadBytesPerSecond := int64(user.GetRateLimit().GetDownloadKilobytesPerSecond()) * 1024
+	userRateLimitersMu.Lock()
+	defer userRateLimitersMu.Unlock()
+	if uploadBytesPerSecond <= 0 && downloadBytesPerSecond <= 0 {
+		delete(userRateLimiters, user.GetName())
+		return nil
+	}
+	// The rate limiter is not modified after it is created, because
+	// sessions use it without lock. The token buckets are shared.
+	prev := userRateLimiters[user.GetName()]
+	var prevUpload, prevDownload *tokenBucket
+	if prev != nil {
+		prevUpload, prevDownload = prev.upload, prev.download
+	}
+	limiter := &userRateLimiter{
+		upload:   updateTokenBucket(prevUpload, uploadBytesPerSecond),
+		download: updateTokenBucket(prevDownload, downloadBytesPerSecond),
+	}
+	if prev != nil && *prev == *limiter {
+		return prev
+	}
+	userRateLimiters[user.GetName()] = limiter
+	return limiter
+}
+
+// updateTokenBucket returns a token bucket with the new rate.
+// It returns nil if the rate is not limited.
+func updateTokenBucket(b *tokenBucket, bytesPerSecond int64) *tokenBucket {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	if b == nil {
+		return newTokenBucket(bytesPerSecond)
+	}
+	b.setRate(bytesPerSecond)
+	return b
+}
+
+// waitUploadLimit blocks until the user is allowed to upload n more bytes.
+func (s *Session) waitUploadLimit(n int) {
+	if limiter := s.rateLimiter.Load(); limiter != nil {
+		limiter.upload.wait(n, s.closedChan)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(100 * 1024)
+	now := b.last
+	if d := b.reserve(100*1024, now); d != 0 {
+		t.Errorf("reserve() within burst = %v, want 0", d)
+	}
+	if d := b.reserve(50*1024, now); d != 500*time.Millisecond {
+		t.Errorf("reserve() over burst = %v, want %v", d, 500*time.Millisecond)
+	}
+	// The bucket is refilled after the debt is paid.
+	if d := b.reserve(50*1024, now.Add(time.Second)); d != 0 {
+		t.Errorf("reserve() after refill = %v, want 0", d)
+	}
+
+	// The wait is cancelled when the done channel is closed.
+	done := make(chan struct{})
+	close(done)
+	start := time.Now()
+	b.wait(1024*1024, done)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait() took %v after done is closed", elapsed)
+	}
+	var nilBucket *tokenBucket
+	nilBucket.wait(1024, nil)
+}
+
+func TestQuotaPeriodStart(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 30, 0, 0, time.UTC)
+	monthly := &appctlpb.Quota{Monthly: proto.Bool(true), Megabytes: proto.Int32(1024)}
+	if got, want := quotaPeriodStart(monthly, now), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("quotaPeriodStart() of monthly quota = %v, want %v", got, want)
+	}
+	rolling := &appctlpb.Quota{Days: proto.Int32(30), Megabytes: proto.Int32(1024)}
+	if got, want := quotaPeriodStart(rolling, now), time.Date(2025, 2, 13, 12, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("quotaPeriodStart() of rolling quota = %v, want %v", got, want)
+	}
+}
+
+func TestRateLimiterOf(t *testing.T) {
+	user := &appctlpb.User{
+		Name:      proto.String("ratelimit-user"),
+		RateLimit: &appctlpb.RateLimit{DownloadKilobytesPerSecond: proto.Int32(1024)},
+	}
+	limiter := rateLimiterOf(user)
+	if limiter == nil || limiter.upload != nil || limiter.download == nil {
+		t.Fatalf("rateLimiterOf() = %+v, want only download limit", limiter)
+	}
+	if rateLimiterOf(user) != limiter {
+		t.Errorf("sessions of the same user don't share the rate limiter")
+	}
+
+	// Changing the limit keeps the existing token bucket.
+	user.RateLimit.DownloadKilobytesPerSecond = proto.Int32(2048)
+	user.RateLimit.UploadKilobytesPerSecond = proto.Int32(512)
+	updated := rateLimiterOf(user)
+	if updated.download != limiter.download || updated.upload == nil {
+		t.Errorf("rateLimiterOf() after update = %+v", updated)
+	}
+	if updated.download.rate != 2048*1024 {
+		t.Errorf("download rate = %v, want %v", updated.download.rate, 2048*1024)
+	}
+
+	user.RateLimit = nil
+	if got := rateLimiterOf(user); got != nil {
+		t.Errorf("rateLimiterOf() without limit = %+v, want nil", got)
+	}
+}
+
+func TestUserRateLimit(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("INFO")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	limitedUsers := map[string]*appctlpb.User{
+		"limited": {
+			Name:      proto.String("limited"),
+			Password:  proto.String("slowpoke"),
+			RateLimit: &appctlpb.RateLimit{UploadKilobytesPerSecond: proto.Int32(256)},
+		},
+	}
+	serverAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}
+	serverMux := NewMux(false).
+		SetServerUsers(limitedUsers).
+		SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, common.StreamTransport, serverAddr, nil)})
+	testServer := testtool.NewTestHelperServer()
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("limited", cipher.HashPassword([]byte("slowpoke"), []byte("limited"))).
+		SetEndpoints([]UnderlayProperties{NewUnderlayProperties(1400, common.StreamTransport, nil, serverAddr)})
+	conn, err := clientMux.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() failed: %v", err)
+	}
+
+	// The first 256 KB are sent with the burst, and the next 128 KB
+	// wait for at least 0.5 second.
+	start := time.Now()
+	if err := echo(conn, testtool.TestHelperGenRot13Input(384*1024)); err != nil {
+		t.Fatalf("echo() failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("upload of 384 KB took %v, want at least 400ms", elapsed)
+	}
+
+	conn.Close()
+	if err := clientMux.Close(); err != nil {
+		t.Errorf("Close client mux failed: %v", err)
+	}
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"encoding/hex"
+	"sync/atomic"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/log"
+)
+
+// serverUserSet is a snapshot of the registered users of a server mux.
+// It is not modified after creation, so the underlays can share it
+// without a lock. The mux replaces the whole set when the users change.
+type serverUserSet struct {
+	users map[string]*appctlpb.User
+
+	// candidates are the block ciphers derived from the passwords of
+	// users that are not disabled. Stream underlays try them to find
+	// the user of a new connection.
+	candidates []cipher.BlockCipher
+}
+
+func newServerUserSet(users map[string]*appctlpb.User) *serverUserSet {
+	set := &serverUserSet{users: users}
+	for _, user := range users {
+		if user.GetDisabled() {
+			continue
+		}
+		set.candidates = append(set.candidates, userBlockCiphers(user)...)
+	}
+	return set
+}
+
+// loadServerUsers returns the users of the set that p points to.
+func loadServerUsers(p *atomic.Pointer[serverUserSet]) map[string]*appctlpb.User {
+	if p == nil {
+		return nil
+	}
+	set := p.Load()
+	if set == nil {
+		return nil
+	}
+	return set.users
+}
+
+// userBlockCiphers returns the block ciphers derived from the current
+// and the next password of the user.
+func userBlockCiphers(user *appctlpb.User) []cipher.BlockCipher {
+	password, err := hex.DecodeString(user.GetHashedPassword())
+	if err != nil {
+		log.Debugf("Unable to decode hashed password %q from user %q", user.GetHashedPassword(), user.GetName())
+		return nil
+	}
+	if len(password) == 0 {
+		password = cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName()))
+	}
+	blocks, err := cipher.BlockCipherListFromPassword(password, false)
+	if err != nil {
+		log.Debugf("Unable to create block cipher of user %q", user.GetName())
+		return nil
+	}
+	for _, block := range blocks {
+		block.SetBlockContext(cipher.BlockContext{
+			UserName: user.GetName(),
+		})
+	}
+
+	// Accept the next password of the user as well.
+	next, err := nextHashedPassword(user)
+	if err != nil {
+		log.Debugf("%v", err)
+		return blocks
+	}
+	if len(next) == 0 {
+		return blocks
+	}
+	blocksFromNext, err := cipher.BlockCipherListFromPassword(next, false)
+	if err != nil {
+		log.Debugf("Unable to create block cipher of user %q from next password", user.GetName())
+		return blocks
+	}
+	for _, block := range blocksFromNext {
+		block.SetBlockContext(cipher.BlockContext{
+			UserName:     user.GetName(),
+			NextPassword: true,
+		})
+	}
+	return append(blocks, blocksFromNext...)
+}
+
+// userPasswords returns the hex encoded hashed passwords
+// that the server accepts from the user.
+func userPasswords(user *appctlpb.User) map[string]bool {
+	passwords := map[string]bool{}
+	if user.GetHashedPassword() != "" {
+		passwords[user.GetHashedPassword()] = true
+	} else if user.GetPassword() != "" {
+		passwords[hex.EncodeToString(cipher.HashPassword([]byte(user.GetPassword()), []byte(user.GetName())))] = true
+	}
+	if next, err := nextHashedPassword(user); err == nil && len(next) > 0 {
+		passwords[hex.EncodeToString(next)] = true
+	}
+	return passwords
+}
+
+// revokedUsers returns the names of users in prev whose existing
+// connections are not allowed by curr, because the user is removed,
+// disabled, or no longer accepts a password it accepted before.
+func revokedUsers(prev, curr map[string]*appctlpb.User) map[string]bool {
+	revoked := map[string]bool{}
+	for name, p := range prev {
+		if p.GetDisabled() {
+			continue
+		}
+		c, found := curr[name]
+		if !found || c.GetDisabled() {
+			revoked[name] = true
+			continue
+		}
+		accepted := userPasswords(c)
+		for password := range userPasswords(p) {
+			if !accepted[password] {
+				revoked[name] = true
+				break
+			}
+		}
+	}
+	return revoked
+}
+
+// revokeUsers closes the sessions of the revoked users in the underlay.
+// A stream underlay only serves one user, so it is closed as a whole.
+// It returns the number of closed sessions.
+func revokeUsers(underlay Underlay, revoked map[string]bool) int {
+	if s, ok := underlay.(interface{ userName() string }); ok {
+		if !revoked[s.userName()] {
+			return 0
+		}
+		n := underlay.SessionCount()
+		underlay.Close()
+		return n
+	}
+	p, ok := underlay.(*PacketUnderlay)
+	if !ok {
+		return 0
+	}
+	n := 0
+	p.sessionMap.Range(func(k, v any) bool {
+		session := v.(*Session)
+		if revoked[session.UserName()] {
+			session.Close()
+			n++
+		}
+		return true
+	})
+	return n
+}
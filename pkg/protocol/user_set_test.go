@@ -0,0 +1,96 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewServerUserSet(t *testing.T) {
+	set := newServerUserSet(map[string]*appctlpb.User{
+		"xiaochitang": {
+			Name:     proto.String("xiaochitang"),
+			Password: proto.String("kuiranbudong"),
+		},
+		"dahuangya": {
+			Name:         proto.String("dahuangya"),
+			Password:     proto.String("kuiranbudong"),
+			NextPassword: proto.String("xinmimalaile"),
+		},
+		"xiaohuangji": {
+			Name:     proto.String("xiaohuangji"),
+			Password: proto.String("kuiranbudong"),
+			Disabled: proto.Bool(true),
+		},
+	})
+	n := len(userBlockCiphers(set.users["xiaochitang"]))
+	if n == 0 {
+		t.Fatalf("userBlockCiphers() returns no block cipher")
+	}
+	if len(set.candidates) != 3*n {
+		t.Errorf("got %d candidates, want %d", len(set.candidates), 3*n)
+	}
+	for _, block := range set.candidates {
+		if block.BlockContext().UserName == "xiaohuangji" {
+			t.Errorf("found candidate of disabled user")
+		}
+	}
+}
+
+func TestRevokedUsers(t *testing.T) {
+	user := func(password, next string, disabled bool) *appctlpb.User {
+		u := &appctlpb.User{
+			Name:     proto.String("xiaochitang"),
+			Password: proto.String(password),
+		}
+		if next != "" {
+			u.NextPassword = proto.String(next)
+		}
+		if disabled {
+			u.Disabled = proto.Bool(true)
+		}
+		return u
+	}
+	testcases := []struct {
+		name    string
+		prev    *appctlpb.User
+		curr    *appctlpb.User
+		revoked bool
+	}{
+		{"unchanged", user("a", "", false), user("a", "", false), false},
+		{"removed", user("a", "", false), nil, true},
+		{"disabled", user("a", "", false), user("a", "", true), true},
+		{"enabled", user("a", "", true), user("a", "", false), false},
+		{"password changed", user("a", "", false), user("b", "", false), true},
+		{"next password added", user("a", "", false), user("a", "b", false), false},
+		{"next password promoted", user("a", "b", false), user("b", "", false), true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			prev := map[string]*appctlpb.User{"xiaochitang": tc.prev}
+			curr := map[string]*appctlpb.User{}
+			if tc.curr != nil {
+				curr["xiaochitang"] = tc.curr
+			}
+			if got := revokedUsers(prev, curr)["xiaochitang"]; got != tc.revoked {
+				t.Errorf("revokedUsers() = %v, want %v", got, tc.revoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bufio"
+	"context"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+const (
+	webSocketHandshakeTimeout = 10 * time.Second
+
+	// webSocketGUID is defined in RFC 6455 to compute Sec-WebSocket-Accept.
+	webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	wsFinBit  = 0x80
+	wsMaskBit = 0x80
+
+	// wsMaxControlPayload is the maximum payload length of a control frame.
+	wsMaxControlPayload = 125
+)
+
+var (
+	UnderlayWebSocketHandshakeErrors = metrics.RegisterMetric("underlay", "WebSocketHandshakeErrors", metrics.COUNTER)
+)
+
+// WebSocketConfig defines how stream underlays are carried by WebSocket.
+type WebSocketConfig struct {
+	// Host is the HTTP host name sent by the client.
+	// If empty, the remote address is used.
+	Host string
+
+	// Path is the HTTP path of the WebSocket endpoint.
+	// If empty, "/" is used.
+	Path string
+
+	// TLSConfig enables TLS if it is not nil.
+	// Client needs to set the server name, and server needs to set the certificates.
+	TLSConfig *tls.Config
+}
+
+func (c *WebSocketConfig) path() string {
+	if c.Path == "" {
+		return "/"
+	}
+	return c.Path
+}
+
+// WebSocketUnderlay is a StreamUnderlay that sends and receives segments
+// inside WebSocket binary frames, so it can be relayed by a CDN.
+type WebSocketUnderlay struct {
+	*StreamUnderlay
+}
+
+var _ Underlay = &WebSocketUnderlay{}
+
+// NewWebSocketUnderlay connects to the remote address "raddr" on the network,
+// finishes the TLS and WebSocket handshake, and then uses the connection
+// to transfer encrypted segments.
+//
+// This function is only used by proxy client.
+func NewWebSocketUnderlay(ctx context.Context, network, laddr, raddr string, mtu int, block cipher.BlockCipher, resolver apicommon.DNSResolver, dialer apicommon.Dialer, config *WebSocketConfig) (*WebSocketUnderlay, error) {
+	if config == nil {
+		return nil, fmt.Errorf("WebSocket config is nil")
+	}
+	t, err := NewStreamUnderlay(ctx, network, laddr, raddr, mtu, block, resolver, dialer)
+	if err != nil {
+		return nil, err
+	}
+	conn := t.conn
+	if config.TLSConfig != nil {
+		tlsConn := tls.Client(conn, config.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+	host := config.Host
+	if host == "" {
+		host = raddr
+	}
+	wsConn, err := clientWebSocketHandshake(conn, host, config.path())
+	if err != nil {
+		UnderlayWebSocketHandshakeErrors.Add(1)
+		conn.Close()
+		return nil, err
+	}
+	t.conn = wsConn
+	return &WebSocketUnderlay{StreamUnderlay: t}, nil
+}
+
+// clientWebSocketHandshake sends the WebSocket upgrade request and
+// validates the response.
+func clientWebSocketHandshake(conn net.Conn, host, path string) (*webSocketConn, error) {
+	conn.SetDeadline(time.Now().Add(webSocketHandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	keyBytes := make([]byte, 16)
+	if _, err := crand.Read(keyBytes); err != nil {
+		return nil, fmt.Errorf("generate WebSocket key failed: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: path},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       host,
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("write WebSocket request failed: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("read WebSocket response failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("WebSocket handshake failed with HTTP status %q", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != webSocketAcceptKey(key) {
+		return nil, fmt.Errorf("WebSocket handshake failed: invalid Sec-WebSocket-Accept")
+	}
+	return newWebSocketConn(conn, reader, true), nil
+}
+
+// serverWebSocketHandshake reads the WebSocket upgrade request and
+// sends the response. If the request is not a valid WebSocket request
+// to the given path, a HTTP 404 response is sent.
+func serverWebSocketHandshake(conn net.Conn, path string) (*webSocketConn, error) {
+	conn.SetDeadline(time.Now().Add(webSocketHandshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read WebSocket request failed: %w", err)
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if req.Method != http.MethodGet ||
+		req.URL.Path != path ||
+		!strings.EqualFold(req.Header.Get("Upgrade"), "websocket") ||
+		!headerContainsToken(req.Header, "Connection", "upgrade") ||
+		key == "" {
+		resp := &http.Response{
+			StatusCode: http.StatusNotFound,
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Close:      true,
+		}
+		resp.Write(conn)
+		return nil, fmt.Errorf("invalid WebSocket request %s %s", req.Method, req.URL.Path)
+	}
+
+	resp := &http.Response{
+		StatusCode: http.StatusSwitchingProtocols,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+	}
+	resp.Header.Set("Upgrade", "websocket")
+	resp.Header.Set("Connection", "Upgrade")
+	resp.Header.Set("Sec-WebSocket-Accept", webSocketAcceptKey(key))
+	if err := resp.Write(conn); err != nil {
+		return nil, fmt.Errorf("write WebSocket response failed: %w", err)
+	}
+	return newWebSocketConn(conn, reader, false), nil
+}
+
+// webSocketAcceptKey computes the Sec-WebSocket-Accept value from the key.
+func webSocketAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte(webSocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken returns true if the comma separated header value
+// contains the token, case insensitive.
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// webSocketConn sends and receives data with WebSocket binary frames.
+type webSocketConn struct {
+	net.Conn
+	reader   *bufio.Reader
+	isClient bool
+
+	// States of the frame being read.
+	remaining uint64
+	masked    bool
+	maskKey   [4]byte
+	maskPos   int
+
+	wLock     sync.Mutex
+	closeOnce sync.Once
+}
+
+var _ net.Conn = &webSocketConn{}
+
+func newWebSocketConn(conn net.Conn, reader *bufio.Reader, isClient bool) *webSocketConn {
+	return &webSocketConn{
+		Conn:     conn,
+		reader:   reader,
+		isClient: isClient,
+	}
+}
+
+// Read returns the payload of data frames. Control frames are handled
+// internally.
+func (c *webSocketConn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	for c.remaining == 0 {
+		opcode, length, err := c.readFrameHeader()
+		if err != nil {
+			return 0, err
+		}
+		switch opcode {
+		case wsOpContinuation, wsOpText, wsOpBinary:
+			c.remaining = length
+		case wsOpClose, wsOpPing, wsOpPong:
+			if length > wsMaxControlPayload {
+				return 0, fmt.Errorf("WebSocket control frame is too large")
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(c.reader, payload); err != nil {
+				return 0, err
+			}
+			c.unmask(payload)
+			switch opcode {
+			case wsOpClose:
+				c.closeOnce.Do(func() {
+					c.writeFrame(wsOpClose, nil)
+				})
+				return 0, io.EOF
+			case wsOpPing:
+				if err := c.writeFrame(wsOpPong, payload); err != nil {
+					return 0, err
+				}
+			}
+		default:
+			return 0, fmt.Errorf("unsupported WebSocket opcode %d", opcode)
+		}
+	}
+	if uint64(len(b)) > c.remaining {
+		b = b[:c.remaining]
+	}
+	n, err := c.reader.Read(b)
+	c.unmask(b[:n])
+	c.remaining -= uint64(n)
+	return n, err
+}
+
+// Write sends b in a single binary frame.
+func (c *webSocketConn) Write(b []byte) (int, error) {
+	if err := c.writeFrame(wsOpBinary, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close sends a close frame and closes the underlying connection.
+func (c *webSocketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+		if err := c.writeFrame(wsOpClose, nil); err != nil {
+			log.Debugf("failed to send WebSocket close frame: %v", err)
+		}
+	})
+	return c.Conn.Close()
+}
+
+func (c *webSocketConn) readFrameHeader() (opcode byte, length uint64, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return
+	}
+	opcode = header[0] & 0x0f
+	c.masked = header[1]&wsMaskBit != 0
+	length = uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if c.masked {
+		if _, err = io.ReadFull(c.reader, c.maskKey[:]); err != nil {
+			return
+		}
+	}
+	c.maskPos = 0
+	return
+}
+
+func (c *webSocketConn) unmask(b []byte) {
+	if !c.masked {
+		return
+	}
+	for i := range b {
+		b[i] ^= c.maskKey[c.maskPos&3]
+		c.maskPos++
+	}
+}
+
+// writeFrame writes a single frame with FIN bit set.
+// Frames sent by client are masked.
+func (c *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 0, 14)
+	header = append(header, wsFinBit|opcode)
+	var maskBit byte
+	if c.isClient {
+		maskBit = wsMaskBit
+	}
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header = append(header, maskBit|byte(length))
+	case length <= 0xffff:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	frame := make([]byte, 0, len(header)+4+length)
+	frame = append(frame, header...)
+	if c.isClient {
+		var maskKey [4]byte
+		if _, err := crand.Read(maskKey[:]); err != nil {
+			return fmt.Errorf("generate WebSocket mask key failed: %w", err)
+		}
+		frame = append(frame, maskKey[:]...)
+		for i, v := range payload {
+			frame = append(frame, v^maskKey[i&3])
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.wLock.Lock()
+	defer c.wLock.Unlock()
+	_, err := c.Conn.Write(frame)
+	return err
+}
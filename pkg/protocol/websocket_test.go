@@ -0,0 +1,190 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/cipher"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+// newTestCertificate creates a self signed certificate of the host.
+func newTestCertificate(t *testing.T, host string) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(crand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() failed: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestWebSocketUnderlay(t *testing.T) {
+	log.SetOutputToTest(t)
+	log.SetLevel("DEBUG")
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	cert, pool := newTestCertificate(t, "cdn.example.com")
+	serverProperties := NewUnderlayProperties(1400, common.StreamTransport, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port}, nil)
+	serverMux := NewMux(false).
+		SetServerUsers(users).
+		SetEndpoints([]UnderlayProperties{serverProperties}).
+		SetWebSocket(&WebSocketConfig{
+			Path:      "/mieru",
+			TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		})
+	testServer := testtool.NewTestHelperServer()
+
+	if err := serverMux.Start(); err != nil {
+		t.Fatalf("[%s] Start() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	go func() {
+		if err := testServer.Serve(serverMux); err != nil {
+			t.Errorf("[%s] Serve() failed: %v", time.Now().Format(testtool.TimeLayout), err)
+		}
+	}()
+	defer testServer.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	clientProperties := NewUnderlayProperties(1400, common.StreamTransport, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	clientMux := NewMux(true).
+		SetClientUserNamePassword("xiaochitang", cipher.HashPassword([]byte("kuiranbudong"), []byte("xiaochitang"))).
+		SetClientMultiplexFactor(2).
+		SetEndpoints([]UnderlayProperties{clientProperties}).
+		SetWebSocket(&WebSocketConfig{
+			Host:      "cdn.example.com",
+			Path:      "/mieru",
+			TLSConfig: &tls.Config{ServerName: "cdn.example.com", RootCAs: pool},
+		})
+	runClientMux(t, clientMux, 4, roundsPerFeatureTest)
+	if err := serverMux.Close(); err != nil {
+		t.Errorf("Server mux close failed: %v", err)
+	}
+}
+
+func TestWebSocketHandshakeWrongPath(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := serverWebSocketHandshake(serverConn, "/mieru")
+		errCh <- err
+		serverConn.Close()
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, "http://cdn.example.com/index.html", nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest() failed: %v", err)
+	}
+	if err := req.Write(clientConn); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(clientConn), req)
+	if err != nil {
+		t.Fatalf("http.ReadResponse() failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got HTTP status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if err := <-errCh; err == nil {
+		t.Errorf("serverWebSocketHandshake() with wrong path is not rejected")
+	}
+}
+
+func TestWebSocketConn(t *testing.T) {
+	clientRaw, serverRaw := net.Pipe()
+	type result struct {
+		conn *webSocketConn
+		err  error
+	}
+	serverCh := make(chan result, 1)
+	go func() {
+		conn, err := serverWebSocketHandshake(serverRaw, "/")
+		serverCh <- result{conn, err}
+	}()
+	client, err := clientWebSocketHandshake(clientRaw, "example.com", "/")
+	if err != nil {
+		t.Fatalf("clientWebSocketHandshake() failed: %v", err)
+	}
+	r := <-serverCh
+	if r.err != nil {
+		t.Fatalf("serverWebSocketHandshake() failed: %v", r.err)
+	}
+	server := r.conn
+
+	for _, size := range []int{1, 125, 126, 65535, 65536, 100000} {
+		payload := testtool.TestHelperGenRot13Input(size)
+		go func() {
+			if _, err := client.Write(payload); err != nil {
+				t.Errorf("Write() failed: %v", err)
+			}
+		}()
+		got := make([]byte, size)
+		if _, err := io.ReadFull(server, got); err != nil {
+			t.Fatalf("io.ReadFull() failed: %v", err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("payload of size %d is corrupted", size)
+		}
+	}
+
+	// Server receives EOF after client closes the connection.
+	go client.Close()
+	if _, err := server.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read() after close got %v, want %v", err, io.EOF)
+	}
+}
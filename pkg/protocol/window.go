@@ -0,0 +1,93 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/mathext"
+)
+
+const (
+	// initialWindowRTT is the round trip time used to compute
+	// the receive window before any RTT is measured.
+	initialWindowRTT = 200 * time.Millisecond
+
+	// minRateSampleInterval is the minimum duration of a receive rate sample.
+	minRateSampleInterval = 10 * time.Millisecond
+)
+
+// bdpWindowSize returns the number of segments needed to fill the
+// bandwidth-delay product. bandwidth is in bytes per second.
+// The result is between minWindowSize and maxWindow.
+func bdpWindowSize(bandwidth int64, rtt time.Duration, segmentSize, maxWindow int) int {
+	if bandwidth <= 0 || rtt <= 0 || segmentSize <= 0 {
+		return minWindowSize
+	}
+	bdp := float64(bandwidth) * rtt.Seconds()
+	n := int(bdp/float64(segmentSize)) + 1
+	return mathext.Max(minWindowSize, mathext.Min(n, maxWindow))
+}
+
+// receiveRateEstimator estimates the maximum rate the session receives data.
+// The estimate decays slowly when the receive rate drops, so the receive
+// window doesn't shrink immediately when the application is idle.
+type receiveRateEstimator struct {
+	sampleStart time.Time
+	sampleBytes int64
+	rate        int64 // bytes per second
+	mu          sync.Mutex
+}
+
+// newReceiveRateEstimator creates a new receiveRateEstimator
+// with the initial rate.
+func newReceiveRateEstimator(initialRate int64) *receiveRateEstimator {
+	return &receiveRateEstimator{
+		rate: initialRate,
+	}
+}
+
+// OnReceive records n bytes received at the given time. A new sample is
+// taken every rtt.
+func (e *receiveRateEstimator) OnReceive(now time.Time, n int, rtt time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.sampleStart.IsZero() {
+		e.sampleStart = now
+	}
+	e.sampleBytes += int64(n)
+	interval := now.Sub(e.sampleStart)
+	if interval < mathext.Max(rtt, minRateSampleInterval) {
+		return
+	}
+	sample := e.sampleBytes * int64(time.Second) / int64(interval)
+	if sample >= e.rate {
+		e.rate = sample
+	} else {
+		// Decay by 1/8 each sample.
+		e.rate = mathext.Max(sample, e.rate-e.rate/8)
+	}
+	e.sampleStart = now
+	e.sampleBytes = 0
+}
+
+// Rate returns the estimated receive rate in bytes per second.
+func (e *receiveRateEstimator) Rate() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rate
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBDPWindowSize(t *testing.T) {
+	testcases := []struct {
+		bandwidth   int64
+		rtt         time.Duration
+		segmentSize int
+		maxWindow   int
+		want        int
+	}{
+		{0, 200 * time.Millisecond, 1400, maxWindowSize, minWindowSize},
+		{1024 * 1024, 0, 1400, maxWindowSize, minWindowSize},
+		{1024, 10 * time.Millisecond, 1400, maxWindowSize, minWindowSize},
+		{1400 * 1000, 200 * time.Millisecond, 1400, maxWindowSize, 201},
+		{1024 * 1024 * 1024, 200 * time.Millisecond, 1400, maxWindowSize, maxWindowSize},
+		{500 * 1000 * 1000 / 8, 200 * time.Millisecond, 1400, MaxFlowControlWindow, 8929},
+	}
+	for _, tc := range testcases {
+		got := bdpWindowSize(tc.bandwidth, tc.rtt, tc.segmentSize, tc.maxWindow)
+		if got != tc.want {
+			t.Errorf("bdpWindowSize(%d, %v, %d, %d) = %d, want %d", tc.bandwidth, tc.rtt, tc.segmentSize, tc.maxWindow, got, tc.want)
+		}
+	}
+}
+
+func TestReceiveRateEstimator(t *testing.T) {
+	e := newReceiveRateEstimator(1000)
+	if e.Rate() != 1000 {
+		t.Fatalf("initial rate = %d, want %d", e.Rate(), 1000)
+	}
+
+	// Receive 100 KB in 100 ms.
+	now := time.Now()
+	for i := 0; i <= 100; i++ {
+		e.OnReceive(now.Add(time.Duration(i)*time.Millisecond), 1000, 100*time.Millisecond)
+	}
+	if e.Rate() < 1000*1000 {
+		t.Errorf("rate = %d, want at least %d", e.Rate(), 1000*1000)
+	}
+
+	// The rate decays slowly when less data is received.
+	before := e.Rate()
+	e.OnReceive(now.Add(300*time.Millisecond), 1, 100*time.Millisecond)
+	after := e.Rate()
+	if after >= before {
+		t.Errorf("rate doesn't decay: before = %d, after = %d", before, after)
+	}
+	if after < before/2 {
+		t.Errorf("rate decays too fast: before = %d, after = %d", before, after)
+	}
+}
@@ -16,10 +16,14 @@
 package replay
 
 import (
+	"container/list"
+	"fmt"
 	"hash/fnv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
 )
 
@@ -36,8 +40,79 @@ var (
 
 	// Number of replay packets sent from a known session.
 	KnownSession = metrics.RegisterMetric("replay", "KnownSession", metrics.COUNTER)
+
+	// Number of lookups that find a duplicate in replay caches.
+	CacheHits = metrics.RegisterMetric("replay", "CacheHits", metrics.COUNTER)
+
+	// Number of entries removed from replay caches before they expire,
+	// because the caches are full.
+	CacheEvictions = metrics.RegisterMetric("replay", "CacheEvictions", metrics.COUNTER)
+
+	// Number of entries in all replay caches.
+	CacheSize = metrics.RegisterMetric("replay", "CacheSize", metrics.GAUGE)
+
+	// Maximum number of entries in all replay caches.
+	CacheCapacity = metrics.RegisterMetric("replay", "CacheCapacity", metrics.GAUGE)
+
+	// Percentage of CacheSize in CacheCapacity.
+	CacheOccupancy = metrics.RegisterMetric("replay", "CacheOccupancy", metrics.GAUGE)
+
+	// Average number of CacheEvictions per minute, updated every minute.
+	CacheEvictionRate = metrics.RegisterMetric("replay", "CacheEvictionRate", metrics.GAUGE)
+
+	// Number of seconds the most recently evicted entry was kept.
+	// A replayed packet is detected only within this window.
+	ProtectionWindow = metrics.RegisterMetric("replay", "ProtectionWindow", metrics.GAUGE)
+
+	// Number of warnings that the protection window is shorter than
+	// the expire interval, because the cache is too small.
+	ProtectionWindowWarnings = metrics.RegisterMetric("replay", "ProtectionWindowWarnings", metrics.COUNTER)
+)
+
+const (
+	// evictionRateInterval is the interval to update CacheEvictionRate.
+	evictionRateInterval = time.Minute
+)
+
+var (
+	// evictionRateMu is required to update CacheEvictionRate.
+	evictionRateMu sync.Mutex
+
+	// evictionRateStart is the unix nano time of the current
+	// interval of CacheEvictionRate.
+	evictionRateStart atomic.Int64
+
+	// evictionRateBase is the value of CacheEvictions at evictionRateStart.
+	evictionRateBase int64
+)
+
+// Policy decides which entries are removed from a full replay cache.
+type Policy uint8
+
+const (
+	// PolicyRotate stores entries in two generations. When the current
+	// generation is full or expired, it replaces the previous generation.
+	// An entry is kept for one to two expire intervals, and the cache
+	// can hold up to twice the capacity.
+	PolicyRotate Policy = iota
+
+	// PolicyLRU keeps each entry for exactly one expire interval.
+	// When the cache is full, the least recently seen entry is removed.
+	// It uses more memory per entry than PolicyRotate.
+	PolicyLRU
 )
 
+func (p Policy) String() string {
+	switch p {
+	case PolicyRotate:
+		return "rotate"
+	case PolicyLRU:
+		return "LRU"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // ReplayCache stores the signature of recent decrypted packets to avoid
 // a replay attack.
 type ReplayCache struct {
@@ -56,28 +131,72 @@ type ReplayCache struct {
 	// expireInterval is the interval to reset expireTime.
 	expireInterval time.Duration
 
+	// policy decides how entries are removed.
+	policy Policy
+
 	// current stores the current set of packet signatures.
 	current map[uint64]string
 
 	// previous stores the previous set of packet signatures.
 	previous map[uint64]string
+
+	// lru stores lruEntry from the most recently seen to
+	// the least recently seen. It is only used by PolicyLRU.
+	lru *list.List
+
+	// lruIndex maps a signature to the element in lru.
+	lruIndex map[uint64]*list.Element
+
+	// reserved is the number of entries added to CacheCapacity.
+	reserved int64
+
+	// lastWarning is the time of the last warning about
+	// the protection window.
+	lastWarning time.Time
+}
+
+// lruEntry is an entry of a replay cache with PolicyLRU.
+type lruEntry struct {
+	signature uint64
+	tag       string
+	added     time.Time
 }
 
-// NewCache creates a new replay cache.
+// NewCache creates a new replay cache with PolicyRotate.
 func NewCache(capacity int, expireInterval time.Duration) *ReplayCache {
+	c := &ReplayCache{}
+	c.Configure(capacity, expireInterval, PolicyRotate)
+	return c
+}
+
+// Configure changes the capacity, the expire interval and the policy
+// of the replay cache. All the data in the replay cache is removed
+// if any of them is changed.
+func (c *ReplayCache) Configure(capacity int, expireInterval time.Duration, policy Policy) {
 	if capacity < 0 {
 		panic("replay cache capacity can't be negative")
 	}
 	if expireInterval.Nanoseconds() <= 0 {
 		panic("replay cache expire interval must be a positive time range")
 	}
-	return &ReplayCache{
-		capacity:       capacity,
-		expireTime:     time.Now().Add(expireInterval),
-		expireInterval: expireInterval,
-		current:        make(map[uint64]string),
-		previous:       make(map[uint64]string),
+	if policy != PolicyRotate && policy != PolicyLRU {
+		panic(fmt.Sprintf("replay cache policy %d is unknown", policy))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != nil && c.capacity == capacity && c.expireInterval == expireInterval && c.policy == policy {
+		return
+	}
+	c.capacity = capacity
+	c.expireInterval = expireInterval
+	c.policy = policy
+	reserved := int64(capacity)
+	if policy == PolicyRotate {
+		reserved *= 2
 	}
+	CacheCapacity.Add(reserved - c.reserved)
+	c.reserved = reserved
+	c.clear()
 }
 
 // IsDuplicate checks if the given data is present in the replay cache.
@@ -90,52 +209,138 @@ func (c *ReplayCache) IsDuplicate(data []byte, tag string) bool {
 		return false
 	}
 	signature := c.computeSignature(data)
+	updateEvictionRate(time.Now())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var duplicate bool
+	if c.policy == PolicyLRU {
+		duplicate = c.isDuplicateLRU(signature, tag)
+	} else {
+		duplicate = c.isDuplicateRotate(signature, tag)
+	}
+	if duplicate {
+		CacheHits.Add(1)
+	}
+	return duplicate
+}
+
+// Sizes returns the number of entries in `current` map and `previous` map.
+// With PolicyLRU, it returns the number of entries and 0.
+func (c *ReplayCache) Sizes() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.policy == PolicyLRU {
+		return c.lru.Len(), 0
+	}
+	return len(c.current), len(c.previous)
+}
+
+// Clear removes all the data in the replay cache.
+func (c *ReplayCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.clear()
+}
+
+// clear removes all the data in the replay cache.
+// It must be called with the lock.
+func (c *ReplayCache) clear() {
+	addSize(-int64(len(c.current) + len(c.previous)))
+	if c.lru != nil {
+		addSize(-int64(c.lru.Len()))
+	}
+	c.current = make(map[uint64]string)
+	c.previous = make(map[uint64]string)
+	c.expireTime = time.Now().Add(c.expireInterval)
+	if c.policy == PolicyLRU {
+		c.lru = list.New()
+		c.lruIndex = make(map[uint64]*list.Element)
+	} else {
+		c.lru = nil
+		c.lruIndex = nil
+	}
+}
 
+func (c *ReplayCache) isDuplicateRotate(signature uint64, tag string) bool {
 	if time.Since(c.expireTime) > c.expireInterval {
 		// Both current and previous are expired.
+		addSize(-int64(len(c.current) + len(c.previous)))
 		c.current = make(map[uint64]string)
 		c.previous = make(map[uint64]string)
 		c.expireTime = time.Now().Add(c.expireInterval)
 	}
 	if len(c.current) >= c.capacity || time.Now().After(c.expireTime) {
 		// Move current to previous.
+		addSize(-int64(len(c.previous)))
+		if !time.Now().After(c.expireTime) {
+			CacheEvictions.Add(int64(len(c.previous)))
+			// Entries in current generation are kept since it is created.
+			c.onEviction(time.Since(c.expireTime.Add(-c.expireInterval)))
+		}
 		c.previous = c.current
 		c.current = make(map[uint64]string)
 		c.expireTime = time.Now().Add(c.expireInterval)
 	}
 
 	if existingTag, ok := c.current[signature]; ok {
-		if existingTag == EmptyTag || tag == EmptyTag {
-			return true
-		}
-		return existingTag != tag
+		return isDuplicateTag(existingTag, tag)
 	} else {
 		c.current[signature] = tag
+		addSize(1)
 	}
 	if existingTag, ok := c.previous[signature]; ok {
-		if existingTag == EmptyTag || tag == EmptyTag {
-			return true
+		return isDuplicateTag(existingTag, tag)
+	}
+	return false
+}
+
+func (c *ReplayCache) isDuplicateLRU(signature uint64, tag string) bool {
+	now := time.Now()
+	if e, ok := c.lruIndex[signature]; ok {
+		entry := e.Value.(*lruEntry)
+		if now.Sub(entry.added) <= c.expireInterval {
+			c.lru.MoveToFront(e)
+			return isDuplicateTag(entry.tag, tag)
 		}
-		return existingTag != tag
+		c.removeLRU(e)
+	}
+
+	// Remove expired entries from the back.
+	for e := c.lru.Back(); e != nil && now.Sub(e.Value.(*lruEntry).added) > c.expireInterval; e = c.lru.Back() {
+		c.removeLRU(e)
+	}
+	if c.lru.Len() >= c.capacity {
+		e := c.lru.Back()
+		c.removeLRU(e)
+		CacheEvictions.Add(1)
+		c.onEviction(now.Sub(e.Value.(*lruEntry).added))
 	}
+	c.lruIndex[signature] = c.lru.PushFront(&lruEntry{signature: signature, tag: tag, added: now})
+	addSize(1)
 	return false
 }
 
-// Sizes returns the number of entries in `current` map and `previous` map.
-func (c *ReplayCache) Sizes() (int, int) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return len(c.current), len(c.previous)
+func (c *ReplayCache) removeLRU(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.lruIndex, e.Value.(*lruEntry).signature)
+	addSize(-1)
 }
 
-// Clear removes all the data in the replay cache.
-func (c *ReplayCache) Clear() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.current = make(map[uint64]string)
-	c.previous = make(map[uint64]string)
+// onEviction reports an entry is removed from the full cache
+// after it is kept for the window. It must be called with the lock.
+func (c *ReplayCache) onEviction(window time.Duration) {
+	ProtectionWindow.Store(int64(window.Seconds()))
+	if window >= c.expireInterval {
+		return
+	}
+	// Don't warn more than once per expire interval.
+	if !c.lastWarning.IsZero() && time.Since(c.lastWarning) < c.expireInterval {
+		return
+	}
+	c.lastWarning = time.Now()
+	ProtectionWindowWarnings.Add(1)
+	log.Warnf("Replay cache with capacity %d keeps entries for %v instead of %v because of the packet rate. Replayed packets may not be detected. Consider increasing the replay cache capacity.", c.capacity, window.Round(time.Second), c.expireInterval)
 }
 
 func (c *ReplayCache) computeSignature(data []byte) uint64 {
@@ -143,3 +348,42 @@ func (c *ReplayCache) computeSignature(data []byte) uint64 {
 	hash.Write(data)
 	return hash.Sum64()
 }
+
+// isDuplicateTag returns true if the tag of an existing entry
+// doesn't allow the new tag.
+func isDuplicateTag(existingTag, tag string) bool {
+	if existingTag == EmptyTag || tag == EmptyTag {
+		return true
+	}
+	return existingTag != tag
+}
+
+// addSize changes CacheSize and updates CacheOccupancy.
+func addSize(delta int64) {
+	size := CacheSize.Add(delta)
+	if capacity := CacheCapacity.Load(); capacity > 0 {
+		CacheOccupancy.Store(size * 100 / capacity)
+	}
+}
+
+// updateEvictionRate updates CacheEvictionRate if the current interval
+// is over.
+func updateEvictionRate(now time.Time) {
+	start := evictionRateStart.Load()
+	if now.UnixNano()-start < int64(evictionRateInterval) {
+		return
+	}
+	evictionRateMu.Lock()
+	defer evictionRateMu.Unlock()
+	start = evictionRateStart.Load()
+	elapsed := now.UnixNano() - start
+	if elapsed < int64(evictionRateInterval) {
+		return
+	}
+	evictions := CacheEvictions.Load()
+	if start != 0 {
+		CacheEvictionRate.Store((evictions - evictionRateBase) * int64(time.Minute) / elapsed)
+	}
+	evictionRateBase = evictions
+	evictionRateStart.Store(now.UnixNano())
+}
@@ -188,3 +188,142 @@ func TestExpireInterval(t *testing.T) {
 		t.Errorf("cache sizes are %d %d, want 1 0.", curr, prev)
 	}
 }
+
+func TestLRUCapacity(t *testing.T) {
+	cache := replay.NewCache(2, 1*time.Minute)
+	cache.Configure(2, 1*time.Minute, replay.PolicyLRU)
+	a := []byte("a")
+	b := []byte("b")
+	c := []byte("c")
+
+	cache.IsDuplicate(a, replay.EmptyTag)
+	cache.IsDuplicate(b, replay.EmptyTag)
+	evictions := replay.CacheEvictions.Load()
+	hits := replay.CacheHits.Load()
+
+	// Seeing a again makes b the least recently seen entry.
+	if res := cache.IsDuplicate(a, replay.EmptyTag); res == false {
+		t.Errorf("IsDuplicate() = false, want true")
+	}
+	if res := cache.IsDuplicate(c, replay.EmptyTag); res == true {
+		t.Errorf("IsDuplicate() = true, want false")
+	}
+	if curr, prev := cache.Sizes(); curr != 2 || prev != 0 {
+		t.Errorf("cache sizes are %d %d, want 2 0.", curr, prev)
+	}
+	if res := cache.IsDuplicate(a, replay.EmptyTag); res == false {
+		t.Errorf("IsDuplicate() = false, want true")
+	}
+	if got := replay.CacheEvictions.Load() - evictions; got != 1 {
+		t.Errorf("got %d evictions, want 1", got)
+	}
+	if got := replay.CacheHits.Load() - hits; got != 2 {
+		t.Errorf("got %d hits, want 2", got)
+	}
+
+	// b is evicted. It is not a duplicate now.
+	if res := cache.IsDuplicate(b, replay.EmptyTag); res == true {
+		t.Errorf("IsDuplicate() = true, want false")
+	}
+}
+
+func TestLRUExpireInterval(t *testing.T) {
+	cache := replay.NewCache(10, 1*time.Minute)
+	cache.Configure(10, 50*time.Millisecond, replay.PolicyLRU)
+	a := []byte("a")
+	b := []byte("b")
+
+	if res := cache.IsDuplicate(a, "tag1"); res == true {
+		t.Errorf("IsDuplicate() = true, want false")
+	}
+	if res := cache.IsDuplicate(a, "tag2"); res == false {
+		t.Errorf("IsDuplicate() = false, want true")
+	}
+
+	time.Sleep(75 * time.Millisecond)
+
+	if res := cache.IsDuplicate(b, replay.EmptyTag); res == true {
+		t.Errorf("IsDuplicate() = true, want false")
+	}
+	if curr, prev := cache.Sizes(); curr != 1 || prev != 0 {
+		t.Errorf("cache sizes are %d %d, want 1 0.", curr, prev)
+	}
+	if res := cache.IsDuplicate(a, "tag2"); res == true {
+		t.Errorf("IsDuplicate() = true, want false")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	size := replay.CacheSize.Load()
+	cache := replay.NewCache(10, 1*time.Minute)
+	cache.IsDuplicate([]byte("a"), replay.EmptyTag)
+	cache.IsDuplicate([]byte("b"), replay.EmptyTag)
+	if got := replay.CacheSize.Load() - size; got != 2 {
+		t.Errorf("cache size metric increased by %d, want 2", got)
+	}
+
+	// Data is kept if nothing is changed.
+	cache.Configure(10, 1*time.Minute, replay.PolicyRotate)
+	if curr, prev := cache.Sizes(); curr != 2 || prev != 0 {
+		t.Errorf("cache sizes are %d %d, want 2 0.", curr, prev)
+	}
+
+	cache.Configure(20, 1*time.Minute, replay.PolicyLRU)
+	if curr, prev := cache.Sizes(); curr != 0 || prev != 0 {
+		t.Errorf("cache sizes are %d %d, want 0 0.", curr, prev)
+	}
+	cache.IsDuplicate([]byte("a"), replay.EmptyTag)
+	cache.Clear()
+	if got := replay.CacheSize.Load() - size; got != 0 {
+		t.Errorf("cache size metric increased by %d, want 0", got)
+	}
+}
+
+func TestCapacityMetrics(t *testing.T) {
+	capacity := replay.CacheCapacity.Load()
+	cache := replay.NewCache(10, 1*time.Minute)
+	if got := replay.CacheCapacity.Load() - capacity; got != 20 {
+		t.Errorf("cache capacity metric increased by %d, want 20", got)
+	}
+	cache.Configure(30, 1*time.Minute, replay.PolicyLRU)
+	if got := replay.CacheCapacity.Load() - capacity; got != 30 {
+		t.Errorf("cache capacity metric increased by %d, want 30", got)
+	}
+	cache.IsDuplicate([]byte("a"), replay.EmptyTag)
+	if replay.CacheOccupancy.Load() > 100 {
+		t.Errorf("cache occupancy %d is bigger than 100", replay.CacheOccupancy.Load())
+	}
+	cache.Configure(0, 1*time.Minute, replay.PolicyLRU)
+	if got := replay.CacheCapacity.Load() - capacity; got != 0 {
+		t.Errorf("cache capacity metric increased by %d, want 0", got)
+	}
+}
+
+func TestProtectionWindowWarning(t *testing.T) {
+	for _, policy := range []replay.Policy{replay.PolicyRotate, replay.PolicyLRU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			cache := replay.NewCache(2, 1*time.Minute)
+			cache.Configure(2, 1*time.Minute, policy)
+			warnings := replay.ProtectionWindowWarnings.Load()
+
+			// Fill the cache until an entry is evicted.
+			for i := 0; i < 5; i++ {
+				cache.IsDuplicate([]byte{byte(i)}, replay.EmptyTag)
+			}
+			if got := replay.ProtectionWindowWarnings.Load() - warnings; got != 1 {
+				t.Errorf("got %d warnings, want 1", got)
+			}
+			if got := replay.ProtectionWindow.Load(); got >= 60 {
+				t.Errorf("protection window is %d seconds, want less than 60", got)
+			}
+
+			// The warning is not repeated within the expire interval.
+			for i := 5; i < 10; i++ {
+				cache.IsDuplicate([]byte{byte(i)}, replay.EmptyTag)
+			}
+			if got := replay.ProtectionWindowWarnings.Load() - warnings; got != 1 {
+				t.Errorf("got %d warnings, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+)
+
+const (
+	// DefaultAutoRouteMaxLatency is the default maximum time to
+	// connect to a destination directly.
+	DefaultAutoRouteMaxLatency = 300 * time.Millisecond
+
+	// DefaultAutoRouteCacheTTL is the default time to remember
+	// the route of a destination.
+	DefaultAutoRouteCacheTTL = 10 * time.Minute
+
+	// maxAutoRouteCacheSize is the maximum number of destinations
+	// in the route cache.
+	maxAutoRouteCacheSize = 4096
+)
+
+var (
+	AutoRouteDirect      = metrics.RegisterMetric("socks5 auto route", "Direct", metrics.COUNTER)
+	AutoRouteProxy       = metrics.RegisterMetric("socks5 auto route", "Proxy", metrics.COUNTER)
+	AutoRouteProbes      = metrics.RegisterMetric("socks5 auto route", "Probes", metrics.COUNTER)
+	AutoRouteProbeFailed = metrics.RegisterMetric("socks5 auto route", "ProbeFailed", metrics.COUNTER)
+)
+
+// AutoRoute connects to destinations directly when it is possible,
+// and only uses mieru proxy when the direct connection fails or takes
+// longer than MaxLatency. The decision is cached per destination.
+// It only applies to socks5 CONNECT command and HTTP proxy.
+type AutoRoute struct {
+	// Enable auto route.
+	Enabled bool
+
+	// Maximum time to look up the destination and connect to it
+	// directly. 0 means DefaultAutoRouteMaxLatency is used.
+	MaxLatency time.Duration
+
+	// Time to remember the route of a destination.
+	// 0 means DefaultAutoRouteCacheTTL is used.
+	CacheTTL time.Duration
+
+	// Log each decision at INFO level.
+	// Otherwise, it is logged at DEBUG level.
+	LogDecisions bool
+}
+
+func (a AutoRoute) maxLatency() time.Duration {
+	if a.MaxLatency > 0 {
+		return a.MaxLatency
+	}
+	return DefaultAutoRouteMaxLatency
+}
+
+func (a AutoRoute) cacheTTL() time.Duration {
+	if a.CacheTTL > 0 {
+		return a.CacheTTL
+	}
+	return DefaultAutoRouteCacheTTL
+}
+
+// routeCacheEntry is the cached route of a destination.
+type routeCacheEntry struct {
+	direct bool
+	expire time.Time
+}
+
+// routeCache remembers whether destinations are connected directly.
+type routeCache struct {
+	mu      sync.Mutex
+	entries map[string]routeCacheEntry
+}
+
+func newRouteCache() *routeCache {
+	return &routeCache{entries: make(map[string]routeCacheEntry)}
+}
+
+// get returns the cached route of the destination.
+// found is false if the destination is not cached or the entry expired.
+func (c *routeCache) get(key string) (direct, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(entry.expire) {
+		delete(c.entries, key)
+		return false, false
+	}
+	return entry.direct, true
+}
+
+// set stores the route of the destination.
+func (c *routeCache) set(key string, direct bool, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxAutoRouteCacheSize {
+		for k, entry := range c.entries {
+			if now.After(entry.expire) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxAutoRouteCacheSize {
+			// Remove a random entry.
+			for k := range c.entries {
+				delete(c.entries, k)
+				break
+			}
+		}
+	}
+	c.entries[key] = routeCacheEntry{direct: direct, expire: now.Add(ttl)}
+}
+
+// len returns the number of cached destinations.
+func (c *routeCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// routeCacheKey returns the key of the destination in the route cache.
+// Domain names share the cache entry regardless of the port.
+func routeCacheKey(dst *model.AddrSpec) string {
+	if dst.FQDN != "" {
+		return strings.ToLower(strings.TrimSuffix(dst.FQDN, "."))
+	}
+	return dst.IP.String()
+}
+
+// autoRouteServeConn reads the socks5 request, and connects to the
+// destination directly or with mieru proxy.
+func (s *Server) autoRouteServeConn(conn net.Conn) error {
+	req, err := s.newRequest(conn)
+	if err != nil {
+		HandshakeErrors.Add(1)
+		return fmt.Errorf("failed to read socks5 request: %w", err)
+	}
+	d := AutoRouteDecision{
+		Time:        time.Now(),
+		Destination: req.DstAddr.String(),
+		Rule:        AutoRouteRuleCommand,
+	}
+	if req.Command == constant.Socks5ConnectCmd {
+		var target net.Conn
+		target, d.Rule, d.Latency = s.dialDirect(req.DstAddr)
+		if target != nil {
+			d.Direct = true
+			s.recordAutoRoute(d)
+			AutoRouteDirect.Add(1)
+			defer target.Close()
+			local := target.LocalAddr().(*net.TCPAddr)
+			bind := model.AddrSpec{IP: local.IP, Port: local.Port}
+			if err := sendReply(conn, successReply, &bind); err != nil {
+				HandshakeErrors.Add(1)
+				return fmt.Errorf("failed to send reply: %w", err)
+			}
+			return s.relay(conn, target)
+		}
+	}
+	s.recordAutoRoute(d)
+	AutoRouteProxy.Add(1)
+	return s.proxyServeConn(conn, &replayConn{Conn: conn, r: io.MultiReader(bytes.NewReader(req.Raw), conn)}, req.Command == constant.Socks5UDPAssociateCmd)
+}
+
+// recordAutoRoute logs the auto route decision and adds it to the
+// statistics.
+func (s *Server) recordAutoRoute(d AutoRouteDecision) {
+	route := "PROXY"
+	if d.Direct {
+		route = "DIRECT"
+	}
+	if s.config.AutoRoute.LogDecisions {
+		log.Infof("socks5 auto route: request to %s matched rule %s, route is %s, direct connection latency is %v", d.Destination, d.Rule, route, d.Latency)
+	} else {
+		log.Debugf("socks5 auto route: request to %s matched rule %s, route is %s, direct connection latency is %v", d.Destination, d.Rule, route, d.Latency)
+	}
+	if s.autoRouteStats != nil {
+		s.autoRouteStats.record(d)
+	}
+}
+
+// AutoRouteStats returns the statistics of auto route decisions.
+// It returns nil if auto route is not enabled.
+func (s *Server) AutoRouteStats() *AutoRouteStats {
+	if !s.config.AutoRoute.Enabled || s.autoRouteStats == nil {
+		return nil
+	}
+	return s.autoRouteStats.export()
+}
+
+// dialDirect connects to the destination directly. It returns nil if
+// the destination should be connected with mieru proxy. It also returns
+// the rule used to decide the route, and the time spent to connect to
+// the destination directly.
+func (s *Server) dialDirect(dst *model.AddrSpec) (net.Conn, string, time.Duration) {
+	autoRoute := s.config.AutoRoute
+	key := routeCacheKey(dst)
+	direct, found := s.routes.get(key)
+	if found && !direct {
+		return nil, AutoRouteRuleCache, 0
+	}
+	rule := AutoRouteRuleCache
+	timeout := autoRoute.maxLatency()
+	if found {
+		// The destination is known to be reachable. Allow more time
+		// because a slow connection is still better than the proxy.
+		timeout = s.config.HandshakeTimeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+	} else {
+		rule = AutoRouteRuleProbe
+		AutoRouteProbes.Add(1)
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), timeout)
+	defer cancelFunc()
+	start := time.Now()
+	target, err := s.dialDirectContext(ctx, dst)
+	latency := time.Since(start)
+	if err != nil {
+		if !found {
+			AutoRouteProbeFailed.Add(1)
+		}
+		log.Debugf("socks5 auto route: connect to %v directly failed: %v", dst, err)
+		s.routes.set(key, false, autoRoute.cacheTTL())
+		return nil, rule, latency
+	}
+	if !found {
+		s.routes.set(key, true, autoRoute.cacheTTL())
+	}
+	return target, rule, latency
+}
+
+// dialDirectContext looks up the destination and connects to it directly.
+func (s *Server) dialDirectContext(ctx context.Context, dst *model.AddrSpec) (net.Conn, error) {
+	ip := dst.IP
+	if dst.FQDN != "" {
+		ip = s.hostsIP(dst.FQDN)
+		if ip == nil {
+			ips, err := s.config.Resolver.LookupIP(ctx, "ip", dst.FQDN)
+			if err != nil {
+				return nil, err
+			}
+			if len(ips) == 0 {
+				return nil, fmt.Errorf("no IP address of %s", dst.FQDN)
+			}
+			ip = ips[0]
+		}
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(dst.Port)))
+}
+
+// replayConn returns the bytes already read from the connection
+// before reading from the connection again.
+type replayConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *replayConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
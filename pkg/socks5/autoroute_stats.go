@@ -0,0 +1,128 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rules used by auto route to decide the route of a request.
+const (
+	// AutoRouteRuleCommand means the socks5 command is not CONNECT,
+	// which always uses mieru proxy.
+	AutoRouteRuleCommand = "command"
+
+	// AutoRouteRuleCache means the route of the destination is cached.
+	AutoRouteRuleCache = "cache"
+
+	// AutoRouteRuleProbe means the destination is not cached, and it is
+	// connected directly to find the route.
+	AutoRouteRuleProbe = "probe"
+)
+
+// maxRecentAutoRouteDecisions is the number of recent auto route
+// decisions to keep.
+const maxRecentAutoRouteDecisions = 64
+
+// AutoRouteDecision is the route of a request decided by auto route.
+type AutoRouteDecision struct {
+	Time time.Time
+
+	// Destination host and port of the request.
+	Destination string
+
+	// Direct is true if the destination is connected directly,
+	// and false if mieru proxy is used.
+	Direct bool
+
+	// Rule used to decide the route.
+	Rule string
+
+	// Time spent to connect to the destination directly.
+	// It is 0 if the direct connection is not tried.
+	Latency time.Duration
+}
+
+// AutoRouteRuleStats is the number of requests sent directly or with
+// mieru proxy by a rule.
+type AutoRouteRuleStats struct {
+	Rule    string
+	Direct  bool
+	Matches int64
+}
+
+// AutoRouteStats is the statistics of auto route decisions.
+type AutoRouteStats struct {
+	Rules []AutoRouteRuleStats
+
+	// Recent decisions, the newest first.
+	RecentDecisions []AutoRouteDecision
+}
+
+type autoRouteMatch struct {
+	rule   string
+	direct bool
+}
+
+// autoRouteStats counts the requests matched by each rule,
+// and keeps the recent auto route decisions.
+type autoRouteStats struct {
+	mu      sync.Mutex
+	matches map[autoRouteMatch]int64
+	recent  []AutoRouteDecision // ring buffer of recent decisions
+	next    int                 // index in recent to store the next decision
+}
+
+func newAutoRouteStats() *autoRouteStats {
+	return &autoRouteStats{
+		matches: make(map[autoRouteMatch]int64),
+		recent:  make([]AutoRouteDecision, 0, maxRecentAutoRouteDecisions),
+	}
+}
+
+func (s *autoRouteStats) record(d AutoRouteDecision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matches[autoRouteMatch{rule: d.Rule, direct: d.Direct}]++
+	if len(s.recent) < maxRecentAutoRouteDecisions {
+		s.recent = append(s.recent, d)
+	} else {
+		s.recent[s.next] = d
+	}
+	s.next = (s.next + 1) % maxRecentAutoRouteDecisions
+}
+
+func (s *autoRouteStats) export() *AutoRouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &AutoRouteStats{}
+	for m, matches := range s.matches {
+		res.Rules = append(res.Rules, AutoRouteRuleStats{Rule: m.rule, Direct: m.direct, Matches: matches})
+	}
+	sort.Slice(res.Rules, func(i, j int) bool {
+		if res.Rules[i].Rule != res.Rules[j].Rule {
+			return res.Rules[i].Rule < res.Rules[j].Rule
+		}
+		return res.Rules[i].Direct && !res.Rules[j].Direct
+	})
+	for i := 1; i <= len(s.recent); i++ {
+		// Start from the newest decision.
+		res.RecentDecisions = append(res.RecentDecisions, s.recent[(s.next-i+len(s.recent))%len(s.recent)])
+	}
+	return res
+}
@@ -0,0 +1,205 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/model"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+	"github.com/enfein/mieru/v3/pkg/testtool"
+)
+
+func TestRouteCache(t *testing.T) {
+	c := newRouteCache()
+	if _, found := c.get("example.com"); found {
+		t.Errorf("get() found a destination in empty cache")
+	}
+	c.set("example.com", true, time.Hour)
+	if direct, found := c.get("example.com"); !found || !direct {
+		t.Errorf("get() = %v, %v, want true, true", direct, found)
+	}
+	c.set("example.com", false, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if _, found := c.get("example.com"); found {
+		t.Errorf("get() found an expired destination")
+	}
+
+	for i := 0; i < maxAutoRouteCacheSize+10; i++ {
+		c.set(net.IPv4(10, 0, byte(i>>8), byte(i)).String(), true, time.Hour)
+	}
+	if c.len() > maxAutoRouteCacheSize {
+		t.Errorf("cache size %d is larger than %d", c.len(), maxAutoRouteCacheSize)
+	}
+}
+
+func TestRouteCacheKey(t *testing.T) {
+	testcases := []struct {
+		dst  *model.AddrSpec
+		want string
+	}{
+		{&model.AddrSpec{FQDN: "WWW.Example.com.", Port: 443}, "www.example.com"},
+		{&model.AddrSpec{IP: net.ParseIP("192.0.2.1"), Port: 80}, "192.0.2.1"},
+	}
+	for _, tc := range testcases {
+		if got := routeCacheKey(tc.dst); got != tc.want {
+			t.Errorf("routeCacheKey(%v) = %q, want %q", tc.dst, got, tc.want)
+		}
+	}
+}
+
+func TestAutoRouteDirect(t *testing.T) {
+	// Create a local listener as the destination target.
+	dst, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer dst.Close()
+	go func() {
+		conn, err := dst.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		conn.Write([]byte("pong"))
+	}()
+	dstAddr := dst.Addr().(*net.TCPAddr)
+
+	s := &Server{
+		config: &Config{
+			AutoRoute: AutoRoute{Enabled: true, MaxLatency: time.Second},
+		},
+		routes:         newRouteCache(),
+		autoRouteStats: newAutoRouteStats(),
+	}
+	directCnt := AutoRouteDirect.Load()
+
+	clientConn, serverConn := testtool.BufPipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	clientConn.Write([]byte{5, 1, 0, 1, 127, 0, 0, 1})
+	port := []byte{0, 0}
+	binary.BigEndian.PutUint16(port, uint16(dstAddr.Port))
+	clientConn.Write(port)
+	clientConn.Write([]byte("ping"))
+	go func() {
+		if err := s.autoRouteServeConn(serverConn); err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
+			t.Errorf("autoRouteServeConn() failed: %v", err)
+		}
+	}()
+
+	out := make([]byte, 14)
+	if _, err := io.ReadFull(clientConn, out); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if out[1] != successReply {
+		t.Errorf("got reply %d, want %d", out[1], successReply)
+	}
+	if !bytes.Equal(out[10:], []byte("pong")) {
+		t.Errorf("got %v, want %v", out[10:], []byte("pong"))
+	}
+	if AutoRouteDirect.Load() <= directCnt {
+		t.Errorf("AutoRouteDirect value is not changed")
+	}
+	if direct, found := s.routes.get("127.0.0.1"); !found || !direct {
+		t.Errorf("route of destination is %v, %v, want direct", direct, found)
+	}
+	stats := s.AutoRouteStats()
+	if stats == nil || len(stats.RecentDecisions) != 1 {
+		t.Fatalf("AutoRouteStats() = %v, want 1 decision", stats)
+	}
+	d := stats.RecentDecisions[0]
+	if d.Destination != dstAddr.String() || !d.Direct || d.Rule != AutoRouteRuleProbe || d.Latency <= 0 {
+		t.Errorf("got decision %+v, want direct probe to %s", d, dstAddr)
+	}
+}
+
+func TestAutoRouteDirectFailed(t *testing.T) {
+	// Find a port that is not listening.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	s := &Server{
+		config: &Config{
+			AutoRoute: AutoRoute{Enabled: true},
+		},
+		routes: newRouteCache(),
+	}
+	dst := &model.AddrSpec{IP: net.ParseIP("127.0.0.1"), Port: port}
+	if conn, rule, _ := s.dialDirect(dst); conn != nil {
+		conn.Close()
+		t.Fatalf("dialDirect() to a closed port returned a connection")
+	} else if rule != AutoRouteRuleProbe {
+		t.Errorf("got rule %q, want %q", rule, AutoRouteRuleProbe)
+	}
+	if direct, found := s.routes.get("127.0.0.1"); !found || direct {
+		t.Errorf("route of destination is %v, %v, want proxy", direct, found)
+	}
+
+	// The cached decision is used without probing again.
+	probeCnt := AutoRouteProbes.Load()
+	if conn, rule, latency := s.dialDirect(dst); conn != nil {
+		conn.Close()
+		t.Errorf("dialDirect() returned a connection for proxied destination")
+	} else if rule != AutoRouteRuleCache || latency != 0 {
+		t.Errorf("got rule %q and latency %v, want %q and 0", rule, latency, AutoRouteRuleCache)
+	}
+	if AutoRouteProbes.Load() != probeCnt {
+		t.Errorf("AutoRouteProbes value is changed")
+	}
+}
+
+func TestAutoRouteStats(t *testing.T) {
+	s := newAutoRouteStats()
+	for i := 0; i < maxRecentAutoRouteDecisions+2; i++ {
+		s.record(AutoRouteDecision{Destination: fmt.Sprintf("example.com:%d", i), Direct: i%2 == 0, Rule: AutoRouteRuleCache})
+	}
+	s.record(AutoRouteDecision{Destination: "example.com:443", Rule: AutoRouteRuleCommand})
+	stats := s.export()
+
+	wantRules := []AutoRouteRuleStats{
+		{Rule: AutoRouteRuleCache, Direct: true, Matches: maxRecentAutoRouteDecisions/2 + 1},
+		{Rule: AutoRouteRuleCache, Direct: false, Matches: maxRecentAutoRouteDecisions/2 + 1},
+		{Rule: AutoRouteRuleCommand, Direct: false, Matches: 1},
+	}
+	if !reflect.DeepEqual(stats.Rules, wantRules) {
+		t.Errorf("got rules %v, want %v", stats.Rules, wantRules)
+	}
+	if len(stats.RecentDecisions) != maxRecentAutoRouteDecisions {
+		t.Fatalf("got %d recent decisions, want %d", len(stats.RecentDecisions), maxRecentAutoRouteDecisions)
+	}
+	if got := stats.RecentDecisions[0].Destination; got != "example.com:443" {
+		t.Errorf("newest decision is %q, want %q", got, "example.com:443")
+	}
+	if got, want := stats.RecentDecisions[maxRecentAutoRouteDecisions-1].Destination, "example.com:3"; got != want {
+		t.Errorf("oldest decision is %q, want %q", got, want)
+	}
+}
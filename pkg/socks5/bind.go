@@ -0,0 +1,364 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/model"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+const (
+	// bindListenerIdleTimeout is the duration to keep a reverse tunnel
+	// listener open after the last pending BIND request is gone.
+	bindListenerIdleTimeout = 30 * time.Second
+
+	// bindAcceptTimeout is the maximum duration an incoming connection
+	// waits for a pending BIND request.
+	bindAcceptTimeout = 10 * time.Second
+)
+
+var (
+	ReverseTunnelMetricGroupName = "socks5 reverse tunnel"
+
+	ReverseTunnelConns     = metrics.RegisterMetric(ReverseTunnelMetricGroupName, "Conns", metrics.COUNTER)
+	ReverseTunnelRejects   = metrics.RegisterMetric(ReverseTunnelMetricGroupName, "Rejects", metrics.COUNTER)
+	ReverseTunnelListeners = metrics.RegisterMetric(ReverseTunnelMetricGroupName, "Listeners", metrics.GAUGE)
+
+	reverseTunnelPortRange = regexp.MustCompile(`^(\d+)-(\d+)$`)
+)
+
+// userNameContextKey is the context key of the user that sends the socks5 request.
+type userNameContextKey struct{}
+
+// userNameGetter returns the user that owns a network connection.
+type userNameGetter interface {
+	UserName() string
+}
+
+// destinationSetterContextKey is the context key of the network connection
+// that records the destination of the socks5 request.
+type destinationSetterContextKey struct{}
+
+// destinationSetter records the destination requested through a network connection.
+type destinationSetter interface {
+	SetDestination(dst string)
+}
+
+// bindListener is a server side TCP listener created by socks5 BIND command.
+// It is shared by all the BIND requests from the same user to the same port.
+type bindListener struct {
+	ip        net.IP // nil if the listener accepts connections to all IP addresses
+	port      int
+	userName  string
+	listener  net.Listener
+	conns     chan net.Conn
+	done      chan struct{} // closed when the listener is closed
+	pending   int
+	idleTimer *time.Timer
+}
+
+func (l *bindListener) acceptLoop() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		// A connection waiting for a BIND request doesn't block others.
+		go l.handOver(conn)
+	}
+}
+
+// handOver gives the incoming connection to a pending BIND request.
+// The connection is closed if no BIND request takes it in time.
+func (l *bindListener) handOver(conn net.Conn) {
+	timer := time.NewTimer(bindAcceptTimeout)
+	defer timer.Stop()
+	select {
+	case l.conns <- conn:
+	case <-timer.C:
+		ReverseTunnelRejects.Add(1)
+		log.Debugf("reverse tunnel on port %d has no pending BIND request, closing connection from %v", l.port, conn.RemoteAddr())
+		conn.Close()
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+// bindRegistry tracks all the reverse tunnel listeners.
+type bindRegistry struct {
+	mu        sync.Mutex
+	listeners map[int]*bindListener
+	closed    bool
+}
+
+// acquire returns the listener of the port and increases the number of
+// pending BIND requests. A new listener is created on the IP address if
+// needed, or on all IP addresses if ip is nil. An existing listener of
+// the port is shared regardless of the IP address.
+func (r *bindRegistry) acquire(userName string, ip net.IP, port, maxExposedPorts int) (*bindListener, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, stderror.ErrNotRunning
+	}
+	if r.listeners == nil {
+		r.listeners = make(map[int]*bindListener)
+	}
+	if l, found := r.listeners[port]; found {
+		if l.userName != userName {
+			return nil, fmt.Errorf("port %d is exposed by another user", port)
+		}
+		if l.idleTimer != nil {
+			l.idleTimer.Stop()
+			l.idleTimer = nil
+		}
+		l.pending++
+		return l, nil
+	}
+	if maxExposedPorts > 0 {
+		exposed := 0
+		for _, l := range r.listeners {
+			if l.userName == userName {
+				exposed++
+			}
+		}
+		if exposed >= maxExposedPorts {
+			return nil, fmt.Errorf("user %s already exposed %d ports", userName, exposed)
+		}
+	}
+	host := common.AllIPAddr()
+	if ip != nil {
+		host = ip.String()
+	}
+	listener, err := net.Listen("tcp", common.MaybeDecorateIPv6(host)+":"+strconv.Itoa(port))
+	if err != nil {
+		return nil, fmt.Errorf("net.Listen() failed: %w", err)
+	}
+	l := &bindListener{
+		ip:       ip,
+		port:     port,
+		userName: userName,
+		listener: listener,
+		conns:    make(chan net.Conn),
+		done:     make(chan struct{}),
+		pending:  1,
+	}
+	r.listeners[port] = l
+	ReverseTunnelListeners.Add(1)
+	log.Infof("user %s exposed reverse tunnel on %v", userName, listener.Addr())
+	go l.acceptLoop()
+	return l, nil
+}
+
+// release decreases the number of pending BIND requests. The listener is
+// closed if there is no pending BIND request for a while.
+func (r *bindRegistry) release(l *bindListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l.pending--
+	if l.pending > 0 || r.closed {
+		return
+	}
+	l.idleTimer = time.AfterFunc(bindListenerIdleTimeout, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if l.pending == 0 && r.listeners[l.port] == l {
+			r.removeLocked(l)
+		}
+	})
+}
+
+// closeAll closes all the listeners.
+func (r *bindRegistry) closeAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, l := range r.listeners {
+		if l.idleTimer != nil {
+			l.idleTimer.Stop()
+		}
+		r.removeLocked(l)
+	}
+}
+
+func (r *bindRegistry) removeLocked(l *bindListener) {
+	delete(r.listeners, l.port)
+	l.listener.Close()
+	close(l.done)
+	ReverseTunnelListeners.Add(-1)
+	log.Infof("closed reverse tunnel of user %s on port %d", l.userName, l.port)
+}
+
+// reverseTunnelPermission returns if the user is allowed to expose the port,
+// and the maximum number of ports the user can expose.
+func (s *Server) reverseTunnelPermission(userName string, port int) (allowed bool, maxExposedPorts int) {
+	if userName == "" {
+		return false, 0
+	}
+	for _, tunnel := range s.config.ReverseTunnels {
+		if tunnel.GetUserName() != userName {
+			continue
+		}
+		matches := reverseTunnelPortRange.FindStringSubmatch(tunnel.GetPortRange())
+		if len(matches) != 3 {
+			continue
+		}
+		small, _ := strconv.Atoi(matches[1])
+		big, _ := strconv.Atoi(matches[2])
+		if port >= small && port <= big {
+			return true, int(tunnel.GetMaxExposedPorts())
+		}
+	}
+	return false, 0
+}
+
+// handleBind is used to handle a bind command.
+// It exposes a server port to the user, and waits for an incoming connection.
+func (s *Server) handleBind(ctx context.Context, req *Request, conn io.ReadWriteCloser) error {
+	if len(s.config.ReverseTunnels) == 0 {
+		UnsupportedCommandErrors.Add(1)
+		if err := sendReply(conn, commandNotSupported, nil); err != nil {
+			HandshakeErrors.Add(1)
+			return fmt.Errorf("failed to send reply: %w", err)
+		}
+		return nil
+	}
+	userName, _ := ctx.Value(userNameContextKey{}).(string)
+	port := req.DstAddr.Port
+	allowed, maxExposedPorts := s.reverseTunnelPermission(userName, port)
+	if !allowed {
+		ReverseTunnelRejects.Add(1)
+		if err := sendReply(conn, ruleFailure, nil); err != nil {
+			HandshakeErrors.Add(1)
+			return fmt.Errorf("failed to send reply: %w", err)
+		}
+		return fmt.Errorf("user %q is not allowed to expose port %d", userName, port)
+	}
+	l, err := s.binds.acquire(userName, localIP(conn), port, maxExposedPorts)
+	if err != nil {
+		ReverseTunnelRejects.Add(1)
+		if err := sendReply(conn, serverFailure, nil); err != nil {
+			HandshakeErrors.Add(1)
+			return fmt.Errorf("failed to send reply: %w", err)
+		}
+		return fmt.Errorf("expose port %d failed: %w", port, err)
+	}
+	var releaseOnce sync.Once
+	release := func() { releaseOnce.Do(func() { s.binds.release(l) }) }
+	defer release()
+
+	// Send the first reply with the exposed address.
+	bind := model.AddrSpec{IP: net.IPv4(0, 0, 0, 0), Port: port}
+	if l.ip != nil {
+		bind.IP = l.ip
+	}
+	if err := sendReply(conn, successReply, &bind); err != nil {
+		HandshakeErrors.Add(1)
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+
+	// The client must not send anything before the second reply.
+	// Any read result before that means the client is gone.
+	firstRead := make(chan prefetchResult, 1)
+	go func() {
+		b := make([]byte, 1)
+		n, err := conn.Read(b)
+		firstRead <- prefetchResult{data: b[:n], err: err}
+	}()
+
+	var incoming net.Conn
+	select {
+	case incoming = <-l.conns:
+	case r := <-firstRead:
+		if r.err != nil {
+			return r.err
+		}
+		return fmt.Errorf("received unexpected data before BIND connection is established")
+	}
+	release()
+	defer incoming.Close()
+	ReverseTunnelConns.Add(1)
+
+	// Send the second reply with the address of incoming connection.
+	var remote model.AddrSpec
+	if tcpAddr, ok := incoming.RemoteAddr().(*net.TCPAddr); ok {
+		remote = model.AddrSpec{IP: tcpAddr.IP, Port: tcpAddr.Port}
+	}
+	if err := sendReply(conn, successReply, &remote); err != nil {
+		HandshakeErrors.Add(1)
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+	return common.BidiCopy(&prefetchConn{ReadWriteCloser: conn, first: firstRead}, incoming)
+}
+
+// localIP returns the IP address of the server that the client connects to,
+// or nil if it is not known.
+func localIP(conn io.ReadWriteCloser) net.IP {
+	c, ok := conn.(interface{ LocalAddr() net.Addr })
+	if !ok {
+		return nil
+	}
+	var ip net.IP
+	switch addr := c.LocalAddr().(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.UDPAddr:
+		ip = addr.IP
+	}
+	if ip == nil || ip.IsUnspecified() {
+		return nil
+	}
+	return ip
+}
+
+type prefetchResult struct {
+	data []byte
+	err  error
+}
+
+// prefetchConn returns the result of a read operation started
+// earlier before reading from the underlying connection.
+type prefetchConn struct {
+	io.ReadWriteCloser
+	first <-chan prefetchResult
+	done  bool
+}
+
+func (c *prefetchConn) Read(b []byte) (int, error) {
+	if !c.done {
+		c.done = true
+		r := <-c.first
+		if r.err != nil {
+			return 0, r.err
+		}
+		if len(r.data) > 0 {
+			return copy(b, r.data), nil
+		}
+	}
+	return c.ReadWriteCloser.Read(b)
+}
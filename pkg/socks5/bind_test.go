@@ -0,0 +1,232 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// userConnListener returns connections owned by a fixed user.
+type userConnListener struct {
+	net.Listener
+	userName string
+}
+
+type userConn struct {
+	net.Conn
+	userName string
+}
+
+func (c userConn) UserName() string {
+	return c.userName
+}
+
+func (l userConnListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return userConn{Conn: conn, userName: l.userName}, nil
+}
+
+func TestReverseForwarder(t *testing.T) {
+	// Create a local service.
+	service, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer service.Close()
+	go func() {
+		conn, err := service.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			t.Errorf("io.ReadFull() failed: %v", err)
+			return
+		}
+		conn.Write([]byte("pong"))
+	}()
+
+	exposedPort, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	srv, err := New(&Config{
+		ReverseTunnels: []*appctlpb.ReverseTunnel{
+			{
+				UserName:  proto.String("xiaochitang"),
+				PortRange: proto.String(fmt.Sprintf("%d-%d", exposedPort, exposedPort)),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	go srv.Serve(userConnListener{Listener: l, userName: "xiaochitang"})
+	defer srv.Close()
+
+	f := &ReverseForwarder{
+		RemotePort: exposedPort,
+		LocalAddr:  service.Addr().String(),
+		ProxyURI:   fmt.Sprintf("socks5://%s?timeout=5s", l.Addr().String()),
+	}
+	go f.Serve()
+	defer f.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(exposedPort))
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if !bytes.Equal(buf, []byte("pong")) {
+		t.Errorf("got %v, want %v", buf, []byte("pong"))
+	}
+}
+
+func TestReverseTunnelPermission(t *testing.T) {
+	srv, err := New(&Config{
+		ReverseTunnels: []*appctlpb.ReverseTunnel{
+			{
+				UserName:        proto.String("xiaochitang"),
+				PortRange:       proto.String("20000-20010"),
+				MaxExposedPorts: proto.Int32(2),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	testCases := []struct {
+		userName string
+		port     int
+		allowed  bool
+	}{
+		{"xiaochitang", 20000, true},
+		{"xiaochitang", 20010, true},
+		{"xiaochitang", 20011, false},
+		{"kuiranbudong", 20000, false},
+		{"", 20000, false},
+	}
+	for _, tc := range testCases {
+		allowed, maxExposedPorts := srv.reverseTunnelPermission(tc.userName, tc.port)
+		if allowed != tc.allowed {
+			t.Errorf("reverseTunnelPermission(%q, %d) = %v, want %v", tc.userName, tc.port, allowed, tc.allowed)
+		}
+		if allowed && maxExposedPorts != 2 {
+			t.Errorf("reverseTunnelPermission(%q, %d) maximum number of exposed ports = %d, want 2", tc.userName, tc.port, maxExposedPorts)
+		}
+	}
+}
+
+func TestBindRegistry(t *testing.T) {
+	port, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	var r bindRegistry
+	l, err := r.acquire("xiaochitang", net.ParseIP("127.0.0.1"), port, 0)
+	if err != nil {
+		t.Fatalf("acquire() failed: %v", err)
+	}
+	if ip := l.listener.Addr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("listener IP = %v, want 127.0.0.1", ip)
+	}
+	if _, err := r.acquire("kuiranbudong", nil, port, 0); err == nil {
+		t.Errorf("acquire() by another user succeeded")
+	}
+
+	// Incoming connections wait for BIND requests at the same time.
+	var clients []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", l.listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() failed: %v", err)
+		}
+		defer conn.Close()
+		clients = append(clients, conn)
+	}
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		select {
+		case conn := <-l.conns:
+			conn.Close()
+		case <-time.After(time.Second):
+			t.Fatalf("incoming connection %d is not handed over", i)
+		}
+	}
+
+	// A waiting connection is closed with the listener.
+	conn, err := net.Dial("tcp", l.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	time.Sleep(100 * time.Millisecond)
+	r.closeAll()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read() got error %v, want %v", err, io.EOF)
+	}
+}
+
+func TestLocalIP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer l.Close()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	if ip := localIP(conn); !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("localIP() = %v, want 127.0.0.1", ip)
+	}
+
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+	defer p2.Close()
+	if ip := localIP(p1); ip != nil {
+		t.Errorf("localIP() of pipe = %v, want nil", ip)
+	}
+}
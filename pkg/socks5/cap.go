@@ -0,0 +1,124 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+// TransferCap limits the number of bytes transferred by a single
+// proxy connection. 0 means no limit.
+type TransferCap struct {
+	// Maximum number of bytes sent from the initiator to the destination.
+	MaxUploadBytes int64
+
+	// Maximum number of bytes sent from the destination to the initiator.
+	MaxDownloadBytes int64
+}
+
+// TransferDirection is the direction of data in a proxy connection.
+type TransferDirection uint8
+
+const (
+	Upload TransferDirection = iota
+	Download
+)
+
+func (d TransferDirection) String() string {
+	switch d {
+	case Upload:
+		return "upload"
+	case Download:
+		return "download"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// TransferCapError is returned after a proxy connection is closed
+// because it transfers more bytes than the cap.
+type TransferCapError struct {
+	Direction TransferDirection // direction that exceeds the cap
+	Limit     int64             // number of bytes allowed
+}
+
+func (e *TransferCapError) Error() string {
+	return fmt.Sprintf("connection is closed because %s exceeds the cap of %d bytes", e.Direction, e.Limit)
+}
+
+// Unwrap returns stderror.ErrDisconnected.
+func (e *TransferCapError) Unwrap() error {
+	return stderror.ErrDisconnected
+}
+
+// relay does bi-directional data copy between the initiator of a proxy
+// connection and the destination. If a direction transfers more bytes
+// than the cap, both connections are closed and TransferCapError is returned.
+func (s *Server) relay(initiator, destination io.ReadWriteCloser) error {
+	transferCap := s.config.TransferCap
+	if transferCap.MaxUploadBytes <= 0 && transferCap.MaxDownloadBytes <= 0 {
+		return common.BidiCopy(initiator, destination)
+	}
+	var exceeded atomic.Pointer[TransferCapError]
+	if transferCap.MaxUploadBytes > 0 {
+		initiator = &cappedReadWriteCloser{
+			ReadWriteCloser: initiator,
+			remaining:       transferCap.MaxUploadBytes,
+			err:             &TransferCapError{Direction: Upload, Limit: transferCap.MaxUploadBytes},
+			exceeded:        &exceeded,
+		}
+	}
+	if transferCap.MaxDownloadBytes > 0 {
+		destination = &cappedReadWriteCloser{
+			ReadWriteCloser: destination,
+			remaining:       transferCap.MaxDownloadBytes,
+			err:             &TransferCapError{Direction: Download, Limit: transferCap.MaxDownloadBytes},
+			exceeded:        &exceeded,
+		}
+	}
+	err := common.BidiCopy(initiator, destination)
+	if capErr := exceeded.Load(); capErr != nil {
+		TransferCapExceeded.Add(1)
+		return capErr
+	}
+	return err
+}
+
+// cappedReadWriteCloser returns an error after more bytes than
+// the cap are read. The bytes above the cap are dropped.
+type cappedReadWriteCloser struct {
+	io.ReadWriteCloser
+	remaining int64
+	err       *TransferCapError
+	exceeded  *atomic.Pointer[TransferCapError]
+}
+
+func (c *cappedReadWriteCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	if int64(n) > c.remaining {
+		n = int(c.remaining)
+		c.remaining = 0
+		c.exceeded.CompareAndSwap(nil, c.err)
+		return n, c.err
+	}
+	c.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/enfein/mieru/v3/pkg/stderror"
+)
+
+func TestRelayTransferCap(t *testing.T) {
+	s := &Server{config: &Config{TransferCap: TransferCap{MaxDownloadBytes: 100}}}
+	initiator, initiatorPeer := net.Pipe()
+	destination, destinationPeer := net.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.relay(initiatorPeer, destination)
+	}()
+	go func() {
+		destinationPeer.Write(bytes.Repeat([]byte{1}, 200))
+	}()
+
+	received, _ := io.ReadAll(initiator)
+	if len(received) != 100 {
+		t.Errorf("received %d bytes, want 100", len(received))
+	}
+	err := <-errCh
+	var capErr *TransferCapError
+	if !errors.As(err, &capErr) {
+		t.Fatalf("relay() returned %v, want TransferCapError", err)
+	}
+	if capErr.Direction != Download || capErr.Limit != 100 {
+		t.Errorf("got %s cap %d, want download cap 100", capErr.Direction, capErr.Limit)
+	}
+	if !errors.Is(err, stderror.ErrDisconnected) {
+		t.Errorf("errors.Is(err, stderror.ErrDisconnected) = false, want true")
+	}
+}
+
+func TestRelayBelowTransferCap(t *testing.T) {
+	s := &Server{config: &Config{TransferCap: TransferCap{MaxUploadBytes: 100, MaxDownloadBytes: 100}}}
+	initiator, initiatorPeer := net.Pipe()
+	destination, destinationPeer := net.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.relay(initiatorPeer, destination)
+	}()
+	go func() {
+		initiator.Write(bytes.Repeat([]byte{1}, 100))
+		initiator.Close()
+	}()
+
+	received, _ := io.ReadAll(destinationPeer)
+	if len(received) != 100 {
+		t.Errorf("received %d bytes, want 100", len(received))
+	}
+	destinationPeer.Close()
+	var capErr *TransferCapError
+	if err := <-errCh; errors.As(err, &capErr) {
+		t.Errorf("relay() returned %v, want no TransferCapError", err)
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"time"
 
 	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
 	"github.com/enfein/mieru/v3/pkg/common"
 )
 
@@ -219,6 +221,17 @@ func (c *Client) dialSocks5Long(targetAddr string) (conn net.Conn, udpConn *net.
 	})
 
 	// Process the second response.
+	if c.CmdType == constant.Socks5BindCmd {
+		// The BIND command has a second reply when the incoming connection
+		// is established. Only read the first reply here.
+		if _, err = conn.Write(req.Bytes()); err != nil {
+			return nil, nil, nil, err
+		}
+		if _, err = readReply(conn); err != nil {
+			return nil, nil, nil, err
+		}
+		return conn, nil, nil, nil
+	}
 	resp, err = common.RoundTrip(ctx, conn, req.Bytes(), 512)
 	if err != nil {
 		return
@@ -257,3 +270,22 @@ func (c *Client) dialSocks5Long(targetAddr string) (conn net.Conn, udpConn *net.
 
 	return conn, nil, nil, nil
 }
+
+// readReply reads a socks5 reply and returns the bind address.
+func readReply(r io.Reader) (*model.AddrSpec, error) {
+	header := []byte{0, 0, 0}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != constant.Socks5Version {
+		return nil, fmt.Errorf("server does not support socks5")
+	}
+	addr := &model.AddrSpec{}
+	if err := addr.ReadFromSocks5(r); err != nil {
+		return nil, err
+	}
+	if header[1] != successReply {
+		return nil, fmt.Errorf("socks5 connection is not successful, reply code %d", header[1])
+	}
+	return addr, nil
+}
@@ -17,6 +17,7 @@ package socks5
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"sync/atomic"
 
@@ -25,8 +26,9 @@ import (
 )
 
 // BidiCopyUDP does bi-directional data copy between a proxy client UDP endpoint
-// and the proxy tunnel.
-func BidiCopyUDP(udpConn *net.UDPConn, tunnelConn *UDPAssociateTunnelConn) error {
+// and the proxy tunnel. Each read and write of the tunnel carries a single
+// UDP packet, for example UDPAssociateTunnelConn.
+func BidiCopyUDP(udpConn *net.UDPConn, tunnelConn io.ReadWriteCloser) error {
 	var addr atomic.Value
 	errCh := make(chan error, 2)
 
@@ -0,0 +1,279 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDestinations is the maximum number of destinations
+// remembered by DestinationStats.
+const maxDestinations = 1024
+
+// DestinationStat is the traffic of proxy connections to a destination.
+type DestinationStat struct {
+	// Destination is the domain name or IP address, without port.
+	Destination string
+
+	// Connections is the number of proxy connections.
+	Connections int64
+
+	// UploadBytes is the number of bytes sent to the destination.
+	UploadBytes int64
+
+	// DownloadBytes is the number of bytes received from the destination.
+	DownloadBytes int64
+
+	// LastSeen is the time of the latest proxy connection.
+	LastSeen time.Time
+}
+
+// DestinationStats aggregates the traffic of proxy connections
+// by destination. When there are too many destinations, the one
+// that is not seen for the longest time is removed.
+type DestinationStats struct {
+	mu    sync.Mutex
+	stats map[string]*destinationCounter
+}
+
+type destinationCounter struct {
+	connections   atomic.Int64
+	uploadBytes   atomic.Int64
+	downloadBytes atomic.Int64
+	lastSeen      atomic.Int64 // unix nanoseconds
+}
+
+// NewDestinationStats returns an empty DestinationStats.
+func NewDestinationStats() *DestinationStats {
+	return &DestinationStats{
+		stats: make(map[string]*destinationCounter),
+	}
+}
+
+// Wrap counts a new proxy connection to the destination, and returns
+// a connection that counts the bytes read from and written to conn.
+// conn is the connection to the destination or the proxy server.
+func (d *DestinationStats) Wrap(conn net.Conn, destination string) net.Conn {
+	d.mu.Lock()
+	c, ok := d.stats[destination]
+	if !ok {
+		if len(d.stats) >= maxDestinations {
+			d.evictLocked()
+		}
+		c = &destinationCounter{}
+		d.stats[destination] = c
+	}
+	d.mu.Unlock()
+
+	c.connections.Add(1)
+	c.lastSeen.Store(time.Now().UnixNano())
+	return &destinationConn{Conn: conn, counter: c}
+}
+
+// Top returns at most n destinations that transfer the most bytes.
+// If n is not positive, all the destinations are returned.
+func (d *DestinationStats) Top(n int) []DestinationStat {
+	d.mu.Lock()
+	res := make([]DestinationStat, 0, len(d.stats))
+	for destination, c := range d.stats {
+		res = append(res, DestinationStat{
+			Destination:   destination,
+			Connections:   c.connections.Load(),
+			UploadBytes:   c.uploadBytes.Load(),
+			DownloadBytes: c.downloadBytes.Load(),
+			LastSeen:      time.Unix(0, c.lastSeen.Load()),
+		})
+	}
+	d.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		ti := res[i].UploadBytes + res[i].DownloadBytes
+		tj := res[j].UploadBytes + res[j].DownloadBytes
+		if ti != tj {
+			return ti > tj
+		}
+		return res[i].Destination < res[j].Destination
+	})
+	if n > 0 && len(res) > n {
+		res = res[:n]
+	}
+	return res
+}
+
+// evictLocked removes the destination that is not seen for the longest
+// time. The caller must hold the lock.
+func (d *DestinationStats) evictLocked() {
+	var oldest string
+	var oldestTime int64
+	for destination, c := range d.stats {
+		if t := c.lastSeen.Load(); oldest == "" || t < oldestTime {
+			oldest = destination
+			oldestTime = t
+		}
+	}
+	delete(d.stats, oldest)
+}
+
+// destinationConn counts the bytes read from and written to the connection.
+type destinationConn struct {
+	net.Conn
+	counter *destinationCounter
+}
+
+func (c *destinationConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.counter.downloadBytes.Add(int64(n))
+	return n, err
+}
+
+func (c *destinationConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.counter.uploadBytes.Add(int64(n))
+	return n, err
+}
+
+// UserStat is the traffic of proxy connections of a user.
+type UserStat struct {
+	// UserName is the name of the user.
+	UserName string
+
+	// Connections is the number of proxy connections.
+	Connections int64
+
+	// UploadBytes is the number of bytes sent to the destinations.
+	UploadBytes int64
+
+	// DownloadBytes is the number of bytes received from the destinations.
+	DownloadBytes int64
+
+	// Destinations is the number of destinations remembered for the user.
+	Destinations int
+
+	// LastSeen is the time of the latest proxy connection.
+	LastSeen time.Time
+}
+
+// UserDestinationStats aggregates the traffic of proxy connections
+// by user, and by destination of each user.
+type UserDestinationStats struct {
+	mu    sync.Mutex
+	users map[string]*userDestinationStats
+
+	// If not nil, destinations are replaced by the keyed hashes.
+	hashKey []byte
+}
+
+type userDestinationStats struct {
+	total        destinationCounter
+	destinations *DestinationStats
+}
+
+// NewUserDestinationStats returns an empty UserDestinationStats.
+// If hashDestinations is true, destinations are replaced by hashes
+// with a random key, so the traffic pattern of a user is visible
+// without revealing the destinations. The hashes are only comparable
+// within the same UserDestinationStats.
+func NewUserDestinationStats(hashDestinations bool) *UserDestinationStats {
+	u := &UserDestinationStats{
+		users: make(map[string]*userDestinationStats),
+	}
+	if hashDestinations {
+		u.hashKey = make([]byte, 32)
+		if _, err := crand.Read(u.hashKey); err != nil {
+			panic(fmt.Sprintf("failed to generate destination hash key: %v", err))
+		}
+	}
+	return u
+}
+
+// Wrap counts a new proxy connection of the user to the destination,
+// and returns a connection that counts the bytes read from and written
+// to conn. conn is the connection to the destination.
+func (u *UserDestinationStats) Wrap(conn net.Conn, userName, destination string) net.Conn {
+	u.mu.Lock()
+	stats, ok := u.users[userName]
+	if !ok {
+		stats = &userDestinationStats{destinations: NewDestinationStats()}
+		u.users[userName] = stats
+	}
+	u.mu.Unlock()
+
+	stats.total.connections.Add(1)
+	stats.total.lastSeen.Store(time.Now().UnixNano())
+	conn = stats.destinations.Wrap(conn, u.destinationName(destination))
+	return &destinationConn{Conn: conn, counter: &stats.total}
+}
+
+// Users returns all the users sorted by the number of bytes transferred.
+func (u *UserDestinationStats) Users() []UserStat {
+	u.mu.Lock()
+	res := make([]UserStat, 0, len(u.users))
+	for userName, stats := range u.users {
+		stats.destinations.mu.Lock()
+		destinations := len(stats.destinations.stats)
+		stats.destinations.mu.Unlock()
+		res = append(res, UserStat{
+			UserName:      userName,
+			Connections:   stats.total.connections.Load(),
+			UploadBytes:   stats.total.uploadBytes.Load(),
+			DownloadBytes: stats.total.downloadBytes.Load(),
+			Destinations:  destinations,
+			LastSeen:      time.Unix(0, stats.total.lastSeen.Load()),
+		})
+	}
+	u.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		ti := res[i].UploadBytes + res[i].DownloadBytes
+		tj := res[j].UploadBytes + res[j].DownloadBytes
+		if ti != tj {
+			return ti > tj
+		}
+		return res[i].UserName < res[j].UserName
+	})
+	return res
+}
+
+// Top returns at most n destinations of the user that transfer the most
+// bytes. If n is not positive, all the destinations are returned.
+// It returns nil if the user has no proxy connection.
+func (u *UserDestinationStats) Top(userName string, n int) []DestinationStat {
+	u.mu.Lock()
+	stats, ok := u.users[userName]
+	u.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return stats.destinations.Top(n)
+}
+
+func (u *UserDestinationStats) destinationName(destination string) string {
+	if u.hashKey == nil {
+		return destination
+	}
+	mac := hmac.New(sha256.New, u.hashKey)
+	mac.Write([]byte(destination))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
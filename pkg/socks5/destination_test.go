@@ -0,0 +1,133 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestDestinationStats(t *testing.T) {
+	stats := NewDestinationStats()
+	for i, destination := range []string{"example.com", "1.2.3.4", "example.com"} {
+		conn, peer := net.Pipe()
+		wrapped := stats.Wrap(conn, destination)
+		go func() {
+			buf := make([]byte, 10)
+			io.ReadFull(peer, buf)
+			peer.Write(make([]byte, 100*(i+1)))
+			peer.Close()
+		}()
+		if _, err := wrapped.Write(make([]byte, 10)); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		io.ReadAll(wrapped)
+		wrapped.Close()
+	}
+
+	top := stats.Top(0)
+	if len(top) != 2 {
+		t.Fatalf("got %d destinations, want 2", len(top))
+	}
+	if top[0].Destination != "example.com" || top[0].Connections != 2 || top[0].UploadBytes != 20 || top[0].DownloadBytes != 400 {
+		t.Errorf("got %+v, want example.com with 2 connections, 20 upload bytes and 400 download bytes", top[0])
+	}
+	if top[1].Destination != "1.2.3.4" || top[1].Connections != 1 || top[1].UploadBytes != 10 || top[1].DownloadBytes != 200 {
+		t.Errorf("got %+v, want 1.2.3.4 with 1 connection, 10 upload bytes and 200 download bytes", top[1])
+	}
+	if got := stats.Top(1); len(got) != 1 || got[0].Destination != "example.com" {
+		t.Errorf("Top(1) = %+v, want example.com", got)
+	}
+}
+
+func TestDestinationStatsEviction(t *testing.T) {
+	stats := NewDestinationStats()
+	for i := 0; i <= maxDestinations; i++ {
+		conn, _ := net.Pipe()
+		stats.Wrap(conn, fmt.Sprintf("host%d.example.com", i))
+	}
+	top := stats.Top(0)
+	if len(top) != maxDestinations {
+		t.Fatalf("got %d destinations, want %d", len(top), maxDestinations)
+	}
+	newest := fmt.Sprintf("host%d.example.com", maxDestinations)
+	found := false
+	for _, stat := range top {
+		if stat.Destination == newest {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("the newest destination %s is removed", newest)
+	}
+}
+
+func TestUserDestinationStats(t *testing.T) {
+	for _, hashDestinations := range []bool{false, true} {
+		stats := NewUserDestinationStats(hashDestinations)
+		for i, input := range []struct {
+			userName    string
+			destination string
+		}{
+			{"alice", "example.com"},
+			{"bob", "example.com"},
+			{"alice", "1.2.3.4"},
+		} {
+			conn, peer := net.Pipe()
+			wrapped := stats.Wrap(conn, input.userName, input.destination)
+			go func() {
+				peer.Write(make([]byte, 100*(i+1)))
+				peer.Close()
+			}()
+			io.ReadAll(wrapped)
+			wrapped.Close()
+		}
+
+		users := stats.Users()
+		if len(users) != 2 {
+			t.Fatalf("got %d users, want 2", len(users))
+		}
+		if users[0].UserName != "alice" || users[0].Connections != 2 || users[0].DownloadBytes != 400 || users[0].Destinations != 2 {
+			t.Errorf("got %+v, want alice with 2 connections, 400 download bytes and 2 destinations", users[0])
+		}
+		if users[1].UserName != "bob" || users[1].Connections != 1 || users[1].DownloadBytes != 200 || users[1].Destinations != 1 {
+			t.Errorf("got %+v, want bob with 1 connection, 200 download bytes and 1 destination", users[1])
+		}
+
+		top := stats.Top("alice", 0)
+		if len(top) != 2 {
+			t.Fatalf("got %d destinations, want 2", len(top))
+		}
+		wantDestination := "1.2.3.4"
+		if hashDestinations {
+			wantDestination = stats.destinationName("1.2.3.4")
+			if wantDestination == "1.2.3.4" {
+				t.Errorf("destination is not hashed")
+			}
+		}
+		if top[0].Destination != wantDestination {
+			t.Errorf("got destination %q, want %q", top[0].Destination, wantDestination)
+		}
+		if bob := stats.Top("bob", 0); len(bob) != 1 || bob[0].Destination != stats.destinationName("example.com") {
+			t.Errorf("Top(bob) = %+v, want example.com", bob)
+		}
+		if stats.Top("carol", 0) != nil {
+			t.Errorf("Top(carol) is not nil")
+		}
+	}
+}
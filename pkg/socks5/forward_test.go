@@ -0,0 +1,172 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/common"
+)
+
+func TestPortForwarderTCP(t *testing.T) {
+	// Create a local listener as the destination target.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			t.Errorf("io.ReadFull() failed: %v", err)
+			return
+		}
+		conn.Write([]byte("pong"))
+	}()
+
+	socksPort, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	newTestSocksServer(socksPort)
+
+	forwardPort, err := common.UnusedTCPPort()
+	if err != nil {
+		t.Fatalf("common.UnusedTCPPort() failed: %v", err)
+	}
+	f := &PortForwarder{
+		Network:    "tcp",
+		ListenAddr: "127.0.0.1:" + strconv.Itoa(forwardPort),
+		RemoteAddr: l.Addr().String(),
+		ProxyURI:   fmt.Sprintf("socks5://127.0.0.1:%d?timeout=5s", socksPort),
+	}
+	go func() {
+		if err := f.ListenAndServe(); err != nil {
+			t.Errorf("ListenAndServe() failed: %v", err)
+		}
+	}()
+	defer f.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", f.ListenAddr)
+	if err != nil {
+		t.Fatalf("net.Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if !bytes.Equal(buf, []byte("pong")) {
+		t.Errorf("got %v, want %v", buf, []byte("pong"))
+	}
+}
+
+func TestUDPAssociateHeader(t *testing.T) {
+	testCases := []struct {
+		addr string
+		want []byte
+	}{
+		{
+			addr: "127.0.0.1:53",
+			want: []byte{0, 0, 0, 1, 127, 0, 0, 1, 0, 53},
+		},
+		{
+			addr: "[::1]:443",
+			want: []byte{0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 187},
+		},
+		{
+			addr: "a.io:80",
+			want: []byte{0, 0, 0, 3, 4, 'a', '.', 'i', 'o', 0, 80},
+		},
+	}
+	for _, tc := range testCases {
+		header, err := udpAssociateHeader(tc.addr)
+		if err != nil {
+			t.Fatalf("udpAssociateHeader(%q) failed: %v", tc.addr, err)
+		}
+		if !bytes.Equal(header, tc.want) {
+			t.Errorf("udpAssociateHeader(%q) = %v, want %v", tc.addr, header, tc.want)
+		}
+		headerLen, err := udpAssociateHeaderLen(append(header, 0xff))
+		if err != nil {
+			t.Fatalf("udpAssociateHeaderLen() failed: %v", err)
+		}
+		if headerLen != len(tc.want) {
+			t.Errorf("udpAssociateHeaderLen() = %d, want %d", headerLen, len(tc.want))
+		}
+	}
+
+	if _, err := udpAssociateHeader("localhost"); err == nil {
+		t.Errorf("udpAssociateHeader() without port is not rejected")
+	}
+}
+
+func TestUDPForwardSessionClose(t *testing.T) {
+	proxy, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	defer proxy.Close()
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("net.ListenUDP() failed: %v", err)
+	}
+	ctrlConn, peerCtrlConn := net.Pipe()
+	defer peerCtrlConn.Close()
+	session := &udpForwardSession{
+		ctrlConn:  ctrlConn,
+		udpConn:   udpConn,
+		proxyAddr: proxy.LocalAddr().(*net.UDPAddr),
+	}
+
+	if sent, err := session.write([]byte("ping")); !sent || err != nil {
+		t.Fatalf("write() = %v, %v, want true, nil", sent, err)
+	}
+	buf := make([]byte, 16)
+	proxy.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := proxy.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if string(buf[:n]) != "ping" {
+		t.Errorf("proxy received %q, want %q", buf[:n], "ping")
+	}
+
+	session.close()
+	session.close()
+	if sent, err := session.write([]byte("ping")); sent || err != nil {
+		t.Errorf("write() after close = %v, %v, want false, nil", sent, err)
+	}
+	if _, err := peerCtrlConn.Read(buf); err != io.EOF {
+		t.Errorf("control connection read returned %v, want %v", err, io.EOF)
+	}
+}
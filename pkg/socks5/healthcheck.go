@@ -0,0 +1,62 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/apis/model"
+)
+
+// healthCheckCmd is a socks5 command extension of mieru. The proxy server
+// replies to it immediately, without connecting to the destination.
+// Proxy servers that don't know it reply commandNotSupported, which also
+// proves the proxy server is reachable.
+const healthCheckCmd byte = 0xf0
+
+// ProbeProxyServer sends a health check request with the proxy connection
+// and waits for the reply of the proxy server.
+func ProbeProxyServer(conn net.Conn) error {
+	var req bytes.Buffer
+	req.Write([]byte{constant.Socks5Version, healthCheckCmd, 0})
+	dst := model.AddrSpec{IP: net.IPv4zero}
+	if err := dst.WriteToSocks5(&req); err != nil {
+		return err
+	}
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return fmt.Errorf("failed to write health check request: %w", err)
+	}
+
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("failed to read health check reply: %w", err)
+	}
+	if header[0] != constant.Socks5Version {
+		return fmt.Errorf("health check reply has unexpected socks version %d", header[0])
+	}
+	if header[1] != successReply && header[1] != commandNotSupported {
+		return fmt.Errorf("health check reply has error code %d", header[1])
+	}
+	var bindAddr model.AddrSpec
+	if err := bindAddr.ReadFromSocks5(conn); err != nil {
+		return fmt.Errorf("failed to read health check reply: %w", err)
+	}
+	return nil
+}
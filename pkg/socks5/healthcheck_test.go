@@ -0,0 +1,68 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestProbeProxyServer(t *testing.T) {
+	s := &Server{
+		config: &Config{},
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	errCnt := UnsupportedCommandErrors.Load()
+	done := make(chan error, 1)
+	go func() {
+		req, err := s.newRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- s.handleRequest(context.Background(), req, serverConn)
+	}()
+	if err := ProbeProxyServer(clientConn); err != nil {
+		t.Fatalf("ProbeProxyServer() failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("handleRequest() failed: %v", err)
+	}
+	if UnsupportedCommandErrors.Load() != errCnt {
+		t.Errorf("health check is counted as unsupported command")
+	}
+}
+
+func TestProbeProxyServerRejected(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	go func() {
+		s := &Server{config: &Config{}}
+		if _, err := s.newRequest(serverConn); err != nil {
+			return
+		}
+		sendReply(serverConn, ruleFailure, nil)
+	}()
+	if err := ProbeProxyServer(clientConn); err == nil {
+		t.Errorf("ProbeProxyServer() succeeded with a rule failure reply")
+	}
+}
@@ -10,13 +10,17 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
 	"github.com/enfein/mieru/v3/apis/constant"
 	"github.com/enfein/mieru/v3/apis/model"
 	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/egress"
 	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/stderror"
+	"github.com/enfein/mieru/v3/pkg/tracing"
 )
 
 // socks5 error types.
@@ -104,14 +108,31 @@ func (s *Server) handleRequest(ctx context.Context, req *Request, conn io.ReadWr
 		return fmt.Errorf("access to localhost resource via proxy is not allowed")
 	}
 
+	// Reject the connection if the destination is denied by the ACL.
+	if req.Command == constant.Socks5ConnectCmd && !s.allowDestination(dst.FQDN, dst.IP, dst.Port) {
+		if err := sendReply(conn, ruleFailure, nil); err != nil {
+			return fmt.Errorf("failed to send reply: %w", err)
+		}
+		return fmt.Errorf("connection to %v is denied by destination ACL", dst)
+	}
+
 	// Switch on the command.
 	switch req.Command {
 	case constant.Socks5ConnectCmd:
-		return s.handleConnect(ctx, req, conn)
+		dialer, ok := ctx.Value(directDialerContextKey{}).(egress.Dialer)
+		if !ok {
+			dialer = &net.Dialer{}
+		}
+		return s.handleConnect(ctx, req, conn, dialer)
 	case constant.Socks5BindCmd:
 		return s.handleBind(ctx, req, conn)
 	case constant.Socks5UDPAssociateCmd:
 		return s.handleAssociate(ctx, req, conn)
+	case healthCheckCmd:
+		if err := sendReply(conn, successReply, nil); err != nil {
+			return fmt.Errorf("failed to send reply: %w", err)
+		}
+		return nil
 	default:
 		UnsupportedCommandErrors.Add(1)
 		if err := sendReply(conn, commandNotSupported, nil); err != nil {
@@ -121,10 +142,15 @@ func (s *Server) handleRequest(ctx context.Context, req *Request, conn io.ReadWr
 	}
 }
 
+// directDialerContextKey is the context key of the dialer that connects
+// to the destination directly, if the egress rule chooses the source IP
+// address or the network interface.
+type directDialerContextKey struct{}
+
 // handleConnect is used to handle a connect command.
-func (s *Server) handleConnect(ctx context.Context, req *Request, conn io.ReadWriteCloser) error {
-	var d net.Dialer
-	target, err := d.DialContext(ctx, "tcp", req.DstAddr.String())
+// The dialer connects to the destination directly or through an egress proxy.
+func (s *Server) handleConnect(ctx context.Context, req *Request, conn io.ReadWriteCloser, dialer egress.Dialer) error {
+	target, err := dialer.DialContext(ctx, "tcp", req.DstAddr.String())
 	if err != nil {
 		msg := err.Error()
 		var resp uint8
@@ -144,6 +170,16 @@ func (s *Server) handleConnect(ctx context.Context, req *Request, conn io.ReadWr
 		return fmt.Errorf("connect to %v failed: %w", req.DstAddr, err)
 	}
 	defer target.Close()
+	span := spanFromContext(ctx)
+	span.AddEvent(tracing.EventDial, tracing.String("mieru.egress.address", target.RemoteAddr().String()))
+	if s.config.UserDestinationStats != nil {
+		userName, _ := ctx.Value(userNameContextKey{}).(string)
+		destination := req.DstAddr.FQDN
+		if destination == "" {
+			destination = req.DstAddr.IP.String()
+		}
+		target = s.config.UserDestinationStats.Wrap(target, userName, destination)
+	}
 
 	// Send success.
 	local := target.LocalAddr().(*net.TCPAddr)
@@ -153,21 +189,11 @@ func (s *Server) handleConnect(ctx context.Context, req *Request, conn io.ReadWr
 		return fmt.Errorf("failed to send reply: %w", err)
 	}
 
-	return common.BidiCopy(conn, target)
-}
-
-// handleBind is used to handle a bind command.
-func (s *Server) handleBind(_ context.Context, _ *Request, conn io.ReadWriteCloser) error {
-	UnsupportedCommandErrors.Add(1)
-	if err := sendReply(conn, commandNotSupported, nil); err != nil {
-		HandshakeErrors.Add(1)
-		return fmt.Errorf("failed to send reply: %w", err)
-	}
-	return nil
+	return s.relay(conn, traceFirstByte(target, span))
 }
 
 // handleAssociate is used to handle a associate command.
-func (s *Server) handleAssociate(_ context.Context, _ *Request, conn io.ReadWriteCloser) error {
+func (s *Server) handleAssociate(ctx context.Context, _ *Request, conn io.ReadWriteCloser) error {
 	// Create a UDP listener on a random port.
 	// All the requests associated to this connection will go through this port.
 	udpListenerAddr, err := apicommon.ResolveUDPAddr(s.config.Resolver, "udp", common.MaybeDecorateIPv6(common.AllIPAddr())+":0")
@@ -201,26 +227,54 @@ func (s *Server) handleAssociate(_ context.Context, _ *Request, conn io.ReadWrit
 		return fmt.Errorf("failed to send reply: %w", err)
 	}
 
-	conn = WrapUDPAssociateTunnel(conn)
+	if dc, ok := ctx.Value(datagramConnContextKey{}).(protocol.DatagramConn); ok && dc.DatagramEnabled() {
+		conn = &datagramTunnelConn{DatagramConn: dc}
+	} else {
+		conn = WrapUDPAssociateTunnel(conn)
+	}
 	var udpErr atomic.Value
-
-	// addrMap maps the UDPAddr in string to the bytes in UDP associate header.
-	var addrMap sync.Map
+	nat := newUDPNATTable(time.Now())
 
 	var wg sync.WaitGroup
 	wg.Add(2)
 
+	// Close the UDP association if it is idle, and remove idle destinations.
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(udpMappingTimeout / 10)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if nat.expire(time.Now()) {
+					log.Debugf("UDP associate %v is idle for %v", udpConn.LocalAddr(), udpMappingTimeout)
+					if udpErr.Load() == nil {
+						udpErr.Store(stderror.ErrTimeout)
+					}
+					udpConn.Close()
+					conn.Close()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
 	// Send UDP packets to destinations.
 	go func() {
 		defer wg.Done()
 		defer udpConn.Close()
 		buf := make([]byte, 1<<16)
+		var reassembler udpReassembler
 		var n int
 		var err error
 		for {
 			n, err = conn.Read(buf)
 			if err != nil {
-				udpErr.Store(err)
+				if udpErr.Load() == nil {
+					udpErr.Store(err)
+				}
 				return
 			}
 
@@ -235,73 +289,74 @@ func (s *Server) handleAssociate(_ context.Context, _ *Request, conn io.ReadWrit
 				UDPAssociateErrors.Add(1)
 				return
 			}
-			if buf[2] != 0x00 {
-				// UDP fragment is not supported.
-				udpErr.Store(stderror.ErrUnsupported)
-				UDPAssociateErrors.Add(1)
-				return
-			}
 			addrType := buf[3]
 			if addrType != 0x01 && addrType != 0x03 && addrType != 0x04 {
 				udpErr.Store(stderror.ErrInvalidArgument)
 				UDPAssociateErrors.Add(1)
 				return
 			}
-			if (addrType == 0x01 && n <= 10) || (addrType == 0x03 && n <= int(buf[4])+6) || (addrType == 0x04 && n <= 22) {
+			var headerLen int
+			switch addrType {
+			case 0x01:
+				headerLen = 10
+			case 0x03:
+				headerLen = int(buf[4]) + 7
+			case 0x04:
+				headerLen = 22
+			}
+			if n <= headerLen {
 				udpErr.Store(stderror.ErrNoEnoughData)
 				UDPAssociateErrors.Add(1)
 				return
 			}
+			packet := reassembler.add(buf[:n], headerLen, time.Now())
+			if packet == nil {
+				continue
+			}
+			header := packet[:headerLen]
+			payload := packet[headerLen:]
 
 			// Get target address and send data.
+			var dstAddr *net.UDPAddr
 			switch addrType {
 			case 0x01:
-				dstAddr := &net.UDPAddr{
-					IP:   net.IP(buf[4:8]),
-					Port: int(buf[8])<<8 + int(buf[9]),
+				dstAddr = &net.UDPAddr{
+					IP:   net.IP(header[4:8]),
+					Port: int(header[8])<<8 + int(header[9]),
 				}
-				addrMap.Store(dstAddr.String(), buf[:10])
-				ws, err := udpConn.WriteToUDP(buf[10:n], dstAddr)
-				if err != nil {
-					log.Debugf("UDP associate [%v - %v] WriteToUDP() failed: %v", udpConn.LocalAddr(), dstAddr, err)
-					UDPAssociateErrors.Add(1)
-				} else {
-					UDPAssociateUploadPackets.Add(1)
-					UDPAssociateUploadBytes.Add(int64(ws))
+				if !s.allowDestination("", dstAddr.IP, dstAddr.Port) {
+					continue
 				}
 			case 0x03:
-				fqdnLen := buf[4]
-				fqdn := string(buf[5 : 5+fqdnLen])
-				dstAddr, err := apicommon.ResolveUDPAddr(s.config.Resolver, "udp", fqdn+":"+strconv.Itoa(int(buf[5+fqdnLen])<<8+int(buf[6+fqdnLen])))
+				fqdnLen := header[4]
+				fqdn := string(header[5 : 5+fqdnLen])
+				dstAddr, err = apicommon.ResolveUDPAddr(s.config.Resolver, "udp", fqdn+":"+strconv.Itoa(int(header[5+fqdnLen])<<8+int(header[6+fqdnLen])))
 				if err != nil {
 					log.Debugf("UDP associate %v ResolveUDPAddr() failed: %v", udpConn.LocalAddr(), err)
 					UDPAssociateErrors.Add(1)
-					break
+					continue
 				}
-				addrMap.Store(dstAddr.String(), buf[:7+fqdnLen])
-				ws, err := udpConn.WriteToUDP(buf[7+fqdnLen:n], dstAddr)
-				if err != nil {
-					log.Debugf("UDP associate [%v - %v] WriteToUDP() failed: %v", udpConn.LocalAddr(), dstAddr, err)
-					UDPAssociateErrors.Add(1)
-				} else {
-					UDPAssociateUploadPackets.Add(1)
-					UDPAssociateUploadBytes.Add(int64(ws))
+				if !s.allowDestination(fqdn, dstAddr.IP, dstAddr.Port) {
+					continue
 				}
 			case 0x04:
-				dstAddr := &net.UDPAddr{
-					IP:   net.IP(buf[4:20]),
-					Port: int(buf[20])<<8 + int(buf[21]),
+				dstAddr = &net.UDPAddr{
+					IP:   net.IP(header[4:20]),
+					Port: int(header[20])<<8 + int(header[21]),
 				}
-				addrMap.Store(dstAddr.String(), buf[:22])
-				ws, err := udpConn.WriteToUDP(buf[22:n], dstAddr)
-				if err != nil {
-					log.Debugf("UDP associate [%v - %v] WriteToUDP() failed: %v", udpConn.LocalAddr(), dstAddr, err)
-					UDPAssociateErrors.Add(1)
-				} else {
-					UDPAssociateUploadPackets.Add(1)
-					UDPAssociateUploadBytes.Add(int64(ws))
+				if !s.allowDestination("", dstAddr.IP, dstAddr.Port) {
+					continue
 				}
 			}
+			nat.refresh(dstAddr.String(), header, time.Now())
+			ws, err := udpConn.WriteToUDP(payload, dstAddr)
+			if err != nil {
+				log.Debugf("UDP associate [%v - %v] WriteToUDP() failed: %v", udpConn.LocalAddr(), dstAddr, err)
+				UDPAssociateErrors.Add(1)
+			} else {
+				UDPAssociateUploadPackets.Add(1)
+				UDPAssociateUploadBytes.Add(int64(ws))
+			}
 		}
 	}()
 
@@ -325,15 +380,8 @@ func (s *Server) handleAssociate(_ context.Context, _ *Request, conn io.ReadWrit
 				}
 				return
 			}
-			var header []byte
-			v, ok := addrMap.Load(addr.String())
-			if ok {
-				header = v.([]byte)
-			} else {
-				header = udpAddrToHeader(addr)
-				addrMap.Store(addr.String(), header)
-			}
-			_, err = conn.Write(append(header, buf[:n]...))
+			header := nat.lookup(addr, time.Now())
+			_, err = conn.Write(append(append([]byte{}, header...), buf[:n]...))
 			if err != nil {
 				log.Debugf("UDP associate %v Write() to proxy client failed: %v", udpConn.LocalAddr(), err)
 				if udpErr.Load() == nil {
@@ -347,6 +395,7 @@ func (s *Server) handleAssociate(_ context.Context, _ *Request, conn io.ReadWrit
 	}()
 
 	wg.Wait()
+	close(done)
 	return udpErr.Load().(error)
 }
 
@@ -395,15 +444,27 @@ func (s *Server) proxySocks5AuthReq(conn, proxyConn net.Conn) error {
 
 // proxySocks5ConnReq transfers the socks5 connection request and response
 // between socks5 client and server. Optionally, if UDP association is used,
-// return the created UDP connection.
-func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, error) {
+// return the created UDP connection. It also returns the domain name or
+// IP address of the destination requested by the socks5 client.
+// hostsIP returns the IP address of the domain name from the static hosts.
+// It returns nil if the domain name is not in the static hosts.
+func (s *Server) hostsIP(fqdn string) net.IP {
+	ips, ok := s.hosts.Load().Lookup(fqdn)
+	if !ok {
+		return nil
+	}
+	log.Debugf("socks5 destination %s is replaced by %v from static hosts", fqdn, ips[0])
+	return ips[0]
+}
+
+func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, string, error) {
 	// Send the connection request to the server.
 	defer common.SetReadTimeout(conn, 0)
 	defer common.SetReadTimeout(proxyConn, 0)
 	common.SetReadTimeout(conn, s.config.HandshakeTimeout)
 	connReq := make([]byte, 4)
 	if _, err := io.ReadFull(conn, connReq); err != nil {
-		return nil, fmt.Errorf("failed to get socks5 connection request: %w", err)
+		return nil, "", fmt.Errorf("failed to get socks5 connection request: %w", err)
 	}
 	cmd := connReq[1]
 	reqAddrType := connReq[3]
@@ -415,23 +476,42 @@ func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, err
 	case constant.Socks5FQDNAddress:
 		reqFQDNLen = []byte{0}
 		if _, err := io.ReadFull(conn, reqFQDNLen); err != nil {
-			return nil, fmt.Errorf("failed to get FQDN length: %w", err)
+			return nil, "", fmt.Errorf("failed to get FQDN length: %w", err)
 		}
 		dstAddr = make([]byte, reqFQDNLen[0]+2)
 	case constant.Socks5IPv6Address:
 		dstAddr = make([]byte, 18)
 	default:
-		return nil, fmt.Errorf("unsupported address type: %d", reqAddrType)
+		return nil, "", fmt.Errorf("unsupported address type: %d", reqAddrType)
 	}
 	if _, err := io.ReadFull(conn, dstAddr); err != nil {
-		return nil, fmt.Errorf("failed to get destination address: %w", err)
+		return nil, "", fmt.Errorf("failed to get destination address: %w", err)
+	}
+	var destination string
+	if reqAddrType == constant.Socks5FQDNAddress {
+		destination = string(dstAddr[:len(dstAddr)-2])
+	} else {
+		destination = net.IP(dstAddr[:len(dstAddr)-2]).String()
+	}
+	if reqAddrType == constant.Socks5FQDNAddress {
+		if ip := s.hostsIP(string(dstAddr[:len(dstAddr)-2])); ip != nil {
+			port := dstAddr[len(dstAddr)-2:]
+			if ip4 := ip.To4(); ip4 != nil {
+				connReq[3] = constant.Socks5IPv4Address
+				dstAddr = append(append([]byte{}, ip4...), port...)
+			} else {
+				connReq[3] = constant.Socks5IPv6Address
+				dstAddr = append(append([]byte{}, ip.To16()...), port...)
+			}
+			reqFQDNLen = nil
+		}
 	}
 	if len(reqFQDNLen) != 0 {
 		connReq = append(connReq, reqFQDNLen...)
 	}
 	connReq = append(connReq, dstAddr...)
 	if _, err := proxyConn.Write(connReq); err != nil {
-		return nil, fmt.Errorf("failed to write connection request to the server: %w", err)
+		return nil, "", fmt.Errorf("failed to write connection request to the server: %w", err)
 	}
 	log.Debugf("Sent socks5 request %v to server", connReq)
 
@@ -439,7 +519,7 @@ func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, err
 	common.SetReadTimeout(proxyConn, s.config.HandshakeTimeout)
 	connResp := make([]byte, 4)
 	if _, err := io.ReadFull(proxyConn, connResp); err != nil {
-		return nil, fmt.Errorf("failed to read connection response from the server: %w", err)
+		return nil, "", fmt.Errorf("failed to read connection response from the server: %w", err)
 	}
 	respAddrType := connResp[3]
 	var respFQDNLen []byte
@@ -450,16 +530,16 @@ func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, err
 	case constant.Socks5FQDNAddress:
 		respFQDNLen = []byte{0}
 		if _, err := io.ReadFull(proxyConn, respFQDNLen); err != nil {
-			return nil, fmt.Errorf("failed to get FQDN length: %w", err)
+			return nil, "", fmt.Errorf("failed to get FQDN length: %w", err)
 		}
 		bindAddr = make([]byte, respFQDNLen[0]+2)
 	case constant.Socks5IPv6Address:
 		bindAddr = make([]byte, 18)
 	default:
-		return nil, fmt.Errorf("unsupported address type: %d", respAddrType)
+		return nil, "", fmt.Errorf("unsupported address type: %d", respAddrType)
 	}
 	if _, err := io.ReadFull(proxyConn, bindAddr); err != nil {
-		return nil, fmt.Errorf("failed to get bind address: %w", err)
+		return nil, "", fmt.Errorf("failed to get bind address: %w", err)
 	}
 	if len(respFQDNLen) != 0 {
 		connResp = append(connResp, respFQDNLen...)
@@ -473,18 +553,18 @@ func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, err
 		udpAddr := &net.UDPAddr{IP: net.IP{0, 0, 0, 0}, Port: 0}
 		udpConn, err = net.ListenUDP("udp4", udpAddr)
 		if err != nil {
-			return nil, fmt.Errorf("net.ListenUDP() failed: %w", err)
+			return nil, "", fmt.Errorf("net.ListenUDP() failed: %w", err)
 		}
 		// Get the port number and rewrite the response.
 		_, udpPortStr, err := net.SplitHostPort(udpConn.LocalAddr().String())
 		if err != nil {
 			udpConn.Close()
-			return nil, fmt.Errorf("net.SplitHostPort() failed: %w", err)
+			return nil, "", fmt.Errorf("net.SplitHostPort() failed: %w", err)
 		}
 		udpPort, err := strconv.Atoi(udpPortStr)
 		if err != nil {
 			udpConn.Close()
-			return nil, fmt.Errorf("strconv.Atoi() failed: %w", err)
+			return nil, "", fmt.Errorf("strconv.Atoi() failed: %w", err)
 		}
 		lenResp := len(connResp)
 		connResp[lenResp-2] = byte(udpPort >> 8)
@@ -492,10 +572,10 @@ func (s *Server) proxySocks5ConnReq(conn, proxyConn net.Conn) (*net.UDPConn, err
 	}
 
 	if _, err := conn.Write(connResp); err != nil {
-		return nil, fmt.Errorf("failed to write connection response to the socks5 client: %w", err)
+		return nil, "", fmt.Errorf("failed to write connection response to the socks5 client: %w", err)
 	}
 
-	return udpConn, nil
+	return udpConn, destination, nil
 }
 
 // sendReply is used to send a reply message.
@@ -520,6 +600,17 @@ func sendReply(w io.Writer, resp uint8, addr *model.AddrSpec) error {
 	return err
 }
 
+// allowDestination returns true if the destination ACL allows
+// the server to connect to the destination.
+func (s *Server) allowDestination(fqdn string, ip net.IP, port int) bool {
+	allowed, ruleID := s.config.DestinationACL.Allow(fqdn, ip, port)
+	if !allowed {
+		NotAllowedErrors.Add(1)
+		log.Debugf("destination %s (%v) port %d is denied by destination ACL rule %d", fqdn, ip, port, ruleID)
+	}
+	return allowed
+}
+
 func isLocalhostDst(req *Request) bool {
 	if req == nil || req.DstAddr == nil {
 		return false
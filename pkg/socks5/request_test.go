@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
+	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/egress"
 	"github.com/enfein/mieru/v3/pkg/stderror"
 	"github.com/enfein/mieru/v3/pkg/testtool"
 )
@@ -138,3 +141,90 @@ func TestRequestUnsupportedCommand(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestDeniedByDestinationACL(t *testing.T) {
+	acl, err := egress.NewACL(&appctlpb.DestinationACL{
+		Rules: []*appctlpb.DestinationACLRule{
+			{
+				Ports:  []string{"25"},
+				Action: appctlpb.ACLAction_DENY.Enum(),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewACL() failed: %v", err)
+	}
+	s := &Server{
+		config: &Config{
+			AllowLocalDestination: true,
+			DestinationACL:        acl,
+		},
+	}
+	errCnt := NotAllowedErrors.Load()
+
+	clientConn, serverConn := testtool.BufPipe()
+	clientConn.Write([]byte{5, constant.Socks5ConnectCmd, 0, 1, 127, 0, 0, 1, 0, 25})
+	req, err := s.newRequest(serverConn)
+	if err != nil {
+		t.Fatalf("NewRequest() failed: %v", err)
+	}
+	if err := s.handleRequest(context.Background(), req, serverConn); err == nil {
+		t.Fatalf("handleRequest() succeeded, want error")
+	}
+
+	want := []byte{5, ruleFailure, 0, 1, 0, 0, 0, 0, 0, 0}
+	out := make([]byte, len(want))
+	clientConn.Read(out)
+	if !bytes.Equal(out, want) {
+		t.Errorf("got %v, want %v", out, want)
+	}
+	if NotAllowedErrors.Load() <= errCnt {
+		t.Errorf("NotAllowedErrors value is not changed")
+	}
+}
+
+func TestProxyConnRequestStaticHosts(t *testing.T) {
+	testcases := []struct {
+		req  []byte
+		want []byte
+	}{
+		{
+			// Domain name in static hosts is replaced by IPv4 address.
+			append(append([]byte{5, constant.Socks5ConnectCmd, 0, constant.Socks5FQDNAddress, 11}, []byte("Example.COM")...), 0, 80),
+			[]byte{5, constant.Socks5ConnectCmd, 0, constant.Socks5IPv4Address, 192, 0, 2, 1, 0, 80},
+		},
+		{
+			// Domain name in static hosts is replaced by IPv6 address.
+			append(append([]byte{5, constant.Socks5ConnectCmd, 0, constant.Socks5FQDNAddress, 6}, []byte("v6.com")...), 1, 187),
+			append(append([]byte{5, constant.Socks5ConnectCmd, 0, constant.Socks5IPv6Address}, net.ParseIP("2001:db8::1")...), 1, 187),
+		},
+		{
+			// Other domain names are not changed.
+			append(append([]byte{5, constant.Socks5ConnectCmd, 0, constant.Socks5FQDNAddress, 9}, []byte("other.com")...), 0, 80),
+			append(append([]byte{5, constant.Socks5ConnectCmd, 0, constant.Socks5FQDNAddress, 9}, []byte("other.com")...), 0, 80),
+		},
+	}
+
+	s := &Server{config: &Config{}}
+	s.SetHosts(common.NewHosts(map[string][]net.IP{
+		"example.com": {net.ParseIP("192.0.2.1")},
+		"v6.com":      {net.ParseIP("2001:db8::1")},
+	}))
+
+	for _, tc := range testcases {
+		appConn, conn := testtool.BufPipe()
+		proxyConn, serverConn := testtool.BufPipe()
+		appConn.Write(tc.req)
+		serverConn.Write([]byte{5, 0, 0, constant.Socks5IPv4Address, 0, 0, 0, 0, 0, 0})
+		if _, _, err := s.proxySocks5ConnReq(conn, proxyConn); err != nil {
+			t.Fatalf("proxySocks5ConnReq() failed: %v", err)
+		}
+		got := make([]byte, len(tc.want))
+		if _, err := io.ReadFull(serverConn, got); err != nil {
+			t.Fatalf("ReadFull() failed: %v", err)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("request sent to server is %v, want %v", got, tc.want)
+		}
+	}
+}
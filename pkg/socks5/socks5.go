@@ -1,22 +1,28 @@
 package socks5
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	apicommon "github.com/enfein/mieru/v3/apis/common"
+	"github.com/enfein/mieru/v3/apis/constant"
 	"github.com/enfein/mieru/v3/apis/model"
 	"github.com/enfein/mieru/v3/pkg/appctl/appctlpb"
 	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/crash"
 	"github.com/enfein/mieru/v3/pkg/egress"
 	"github.com/enfein/mieru/v3/pkg/log"
 	"github.com/enfein/mieru/v3/pkg/metrics"
 	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/stderror"
+	"github.com/enfein/mieru/v3/pkg/tracing"
 )
 
 var (
@@ -27,11 +33,16 @@ var (
 	HostUnreachableErrors    = metrics.RegisterMetric("socks5", "HostUnreachableErrors", metrics.COUNTER)
 	ConnectionRefusedErrors  = metrics.RegisterMetric("socks5", "ConnectionRefusedErrors", metrics.COUNTER)
 	UDPAssociateErrors       = metrics.RegisterMetric("socks5", "UDPAssociateErrors", metrics.COUNTER)
-
-	UDPAssociateUploadBytes     = metrics.RegisterMetric("socks5 UDP associate", "UploadBytes", metrics.COUNTER)
-	UDPAssociateDownloadBytes   = metrics.RegisterMetric("socks5 UDP associate", "DownloadBytes", metrics.COUNTER)
-	UDPAssociateUploadPackets   = metrics.RegisterMetric("socks5 UDP associate", "UploadPackets", metrics.COUNTER)
-	UDPAssociateDownloadPackets = metrics.RegisterMetric("socks5 UDP associate", "DownloadPackets", metrics.COUNTER)
+	TransferCapExceeded      = metrics.RegisterMetric("socks5", "TransferCapExceeded", metrics.COUNTER)
+	NotAllowedErrors         = metrics.RegisterMetric("socks5", "NotAllowedErrors", metrics.COUNTER)
+
+	UDPAssociateUploadBytes        = metrics.RegisterMetric("socks5 UDP associate", "UploadBytes", metrics.COUNTER)
+	UDPAssociateDownloadBytes      = metrics.RegisterMetric("socks5 UDP associate", "DownloadBytes", metrics.COUNTER)
+	UDPAssociateUploadPackets      = metrics.RegisterMetric("socks5 UDP associate", "UploadPackets", metrics.COUNTER)
+	UDPAssociateDownloadPackets    = metrics.RegisterMetric("socks5 UDP associate", "DownloadPackets", metrics.COUNTER)
+	UDPAssociateReassembledPackets = metrics.RegisterMetric("socks5 UDP associate", "ReassembledPackets", metrics.COUNTER)
+	UDPAssociateDroppedFragments   = metrics.RegisterMetric("socks5 UDP associate", "DroppedFragments", metrics.COUNTER)
+	UDPAssociateExpiredMappings    = metrics.RegisterMetric("socks5 UDP associate", "ExpiredMappings", metrics.COUNTER)
 )
 
 // Config is used to setup and configure a socks5 server.
@@ -42,6 +53,10 @@ type Config struct {
 	// Egress controller.
 	EgressController egress.Controller
 
+	// Destinations that the server is allowed to connect to.
+	// If it is nil, all the destinations are allowed.
+	DestinationACL *egress.ACL
+
 	// Resolver can be provided to do custom name resolution.
 	Resolver apicommon.DNSResolver
 
@@ -60,6 +75,35 @@ type Config struct {
 
 	// Allow using socks5 to access resources served in localhost.
 	AllowLocalDestination bool
+
+	// Users that are allowed to expose server ports with BIND command.
+	ReverseTunnels []*appctlpb.ReverseTunnel
+
+	// Limit the number of bytes transferred by a single connection.
+	// It doesn't apply to UDP associate and BIND command.
+	TransferCap TransferCap
+
+	// Static hosts that replace the domain names of destinations
+	// before they are sent to mieru proxy.
+	// It doesn't apply to UDP associate.
+	Hosts *common.Hosts
+
+	// Connect to destinations directly when it is possible.
+	// It requires mieru proxy and client side authentication.
+	AutoRoute AutoRoute
+
+	// If not nil, the traffic of connections that use mieru proxy is
+	// aggregated by destination. It doesn't apply to UDP associate.
+	DestinationStats *DestinationStats
+
+	// If not nil, the traffic of direct connections from the proxy server
+	// is aggregated by user and destination. It doesn't apply to
+	// UDP associate, BIND command and egress proxies.
+	UserDestinationStats *UserDestinationStats
+
+	// If not nil, each proxy connection is recorded as a trace span
+	// with the events of dial, handshake and first byte.
+	Tracer *tracing.Tracer
 }
 
 // Server is responsible for accepting connections and handling
@@ -70,6 +114,22 @@ type Server struct {
 	chAccept    chan net.Conn
 	chAcceptErr chan error
 	die         chan struct{}
+	binds       bindRegistry
+
+	// proxyMux replaces the mux in config after it is set.
+	proxyMux atomic.Pointer[protocol.Mux]
+
+	// proxyDisabled rejects new proxy connections.
+	proxyDisabled atomic.Bool
+
+	// hosts replaces the static hosts in config after it is set.
+	hosts atomic.Pointer[common.Hosts]
+
+	// routes remembers the destinations connected directly.
+	routes *routeCache
+
+	// autoRouteStats counts the auto route decisions.
+	autoRouteStats *autoRouteStats
 }
 
 // New creates a new Server and potentially returns an error.
@@ -77,6 +137,9 @@ func New(conf *Config) (*Server, error) {
 	if conf.UseProxy && conf.ProxyMux == nil {
 		return nil, fmt.Errorf("ProxyMux must be set when proxy is enabled")
 	}
+	if conf.AutoRoute.Enabled && (!conf.UseProxy || !conf.AuthOpts.ClientSideAuthentication) {
+		return nil, fmt.Errorf("auto route requires proxy and client side authentication")
+	}
 
 	// Ensure we have a egress controller.
 	if conf.EgressController == nil {
@@ -85,7 +148,7 @@ func New(conf *Config) (*Server, error) {
 
 	// Ensure we have a DNS resolver.
 	if conf.Resolver == nil {
-		conf.Resolver = &net.Resolver{}
+		conf.Resolver = common.NewMeteredResolver(nil)
 	}
 
 	// Provide a default bind IP.
@@ -96,12 +159,56 @@ func New(conf *Config) (*Server, error) {
 		}
 	}
 
-	return &Server{
-		config:      conf,
-		chAccept:    make(chan net.Conn, 256),
-		chAcceptErr: make(chan error, 1), // non-blocking
-		die:         make(chan struct{}),
-	}, nil
+	s := &Server{
+		config:         conf,
+		chAccept:       make(chan net.Conn, 256),
+		chAcceptErr:    make(chan error, 1), // non-blocking
+		die:            make(chan struct{}),
+		routes:         newRouteCache(),
+		autoRouteStats: newAutoRouteStats(),
+	}
+	s.proxyMux.Store(conf.ProxyMux)
+	s.hosts.Store(conf.Hosts)
+	return s, nil
+}
+
+// SetProxyMux changes the mieru proxy multiplexer used by new connections.
+// Existing connections are not affected.
+func (s *Server) SetProxyMux(mux *protocol.Mux) {
+	if mux == nil {
+		panic("proxy mux is nil")
+	}
+	s.proxyMux.Store(mux)
+}
+
+// SetHosts changes the static hosts used by new connections.
+// Existing connections are not affected.
+func (s *Server) SetHosts(hosts *common.Hosts) {
+	s.hosts.Store(hosts)
+}
+
+// SetProxyDisabled rejects new connections that use mieru proxy
+// if disabled is true. Existing connections are not affected.
+func (s *Server) SetProxyDisabled(disabled bool) {
+	s.proxyDisabled.Store(disabled)
+}
+
+// EgressController returns the controller that decides the egress action
+// of each connection.
+func (s *Server) EgressController() egress.Controller {
+	return s.config.EgressController
+}
+
+// DestinationStats returns the traffic aggregated by destination.
+// It returns nil if destination statistics is not enabled.
+func (s *Server) DestinationStats() *DestinationStats {
+	return s.config.DestinationStats
+}
+
+// UserDestinationStats returns the traffic aggregated by user and destination.
+// It returns nil if user destination statistics is not enabled.
+func (s *Server) UserDestinationStats() *UserDestinationStats {
+	return s.config.UserDestinationStats
 }
 
 // ListenAndServe is used to create a listener and serve on it.
@@ -121,8 +228,12 @@ func (s *Server) Serve(l net.Listener) error {
 		select {
 		case conn := <-s.chAccept:
 			go func() {
+				defer crash.Recover("socks5 connection", func() { conn.Close() })
 				err := s.ServeConn(conn)
-				if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
+				var capErr *TransferCapError
+				if errors.As(err, &capErr) {
+					log.Infof("socks5 server listener %v: %v", s.listener.Addr(), err)
+				} else if err != nil && !stderror.IsEOF(err) && !stderror.IsClosed(err) {
 					log.Debugf("socks5 server listener %v ServeConn() failed: %v", s.listener.Addr(), err)
 				}
 			}()
@@ -145,20 +256,40 @@ func (s *Server) Serve(l net.Listener) error {
 
 // ServeConn is used to serve a single connection.
 func (s *Server) ServeConn(conn net.Conn) error {
+	ctx := context.Background()
+	if getter, ok := conn.(userNameGetter); ok {
+		ctx = context.WithValue(ctx, userNameContextKey{}, getter.UserName())
+	}
+	if setter, ok := conn.(destinationSetter); ok {
+		ctx = context.WithValue(ctx, destinationSetterContextKey{}, setter)
+	}
+	if dc, ok := conn.(protocol.DatagramConn); ok {
+		ctx = context.WithValue(ctx, datagramConnContextKey{}, dc)
+	}
+	session := conn
 	conn = common.WrapHierarchyConn(conn)
 	defer conn.Close()
 	log.Debugf("socks5 server starts to serve connection [%v - %v]", conn.LocalAddr(), conn.RemoteAddr())
 
 	if s.config.UseProxy {
 		return s.clientServeConn(conn)
-	} else {
-		return s.serverServeConn(conn)
 	}
+	// The proxy connection span of the server starts after
+	// the session is accepted.
+	span := s.config.Tracer.Start(proxyConnectionSpanName)
+	if span != nil {
+		span.SetAttributes(sessionAttributes(session)...)
+		ctx = context.WithValue(ctx, spanContextKey{}, span)
+	}
+	err := s.serverServeConn(ctx, conn)
+	endSpan(span, session, err)
+	return err
 }
 
 // Close closes the network listener used by the server.
 func (s *Server) Close() error {
 	close(s.die)
+	s.binds.closeAll()
 	return nil
 }
 
@@ -183,28 +314,76 @@ func (s *Server) clientServeConn(conn net.Conn) error {
 		}
 	}
 
+	if s.proxyDisabled.Load() {
+		return s.rejectProxyRequest(conn)
+	}
+	if s.config.AutoRoute.Enabled {
+		return s.autoRouteServeConn(conn)
+	}
+	if s.config.AuthOpts.ClientSideAuthentication {
+		// The command is known before mieru proxy is dialed,
+		// so UDP associate can use a session in datagram mode.
+		head := make([]byte, 2)
+		common.SetReadTimeout(conn, s.config.HandshakeTimeout)
+		_, err := io.ReadFull(conn, head)
+		common.SetReadTimeout(conn, 0)
+		if err != nil {
+			HandshakeErrors.Add(1)
+			return fmt.Errorf("failed to get socks5 request command: %w", err)
+		}
+		reqConn := &replayConn{Conn: conn, r: io.MultiReader(bytes.NewReader(head), conn)}
+		return s.proxyServeConn(conn, reqConn, head[1] == constant.Socks5UDPAssociateCmd)
+	}
+	return s.proxyServeConn(conn, conn, false)
+}
+
+// proxyServeConn forwards the socks5 request read from reqConn to
+// mieru proxy, and relays data between conn and the proxy connection.
+// reqConn is conn itself, or it returns the bytes of the request that
+// are already read from conn. If udpAssociate is true, the request is
+// UDP associate, and UDP packets are sent as datagrams when possible.
+func (s *Server) proxyServeConn(conn, reqConn net.Conn, udpAssociate bool) error {
 	// Forward remaining bytes to proxy.
 	ctx := context.Background()
+	mux := s.proxyMux.Load()
+	span := s.config.Tracer.Start(proxyConnectionSpanName)
 	var proxyConn net.Conn
 	var err error
-	proxyConn, err = s.config.ProxyMux.DialContext(ctx)
+	if udpAssociate && !mux.HybridKeyExchange() {
+		proxyConn, err = mux.DialDatagramContext(ctx)
+	} else {
+		proxyConn, err = mux.DialContext(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("mux DialContext() failed: %w", err)
+		err = fmt.Errorf("mux DialContext() failed: %w", err)
+		span.End(err)
+		return err
+	}
+	session := proxyConn
+	if span != nil {
+		span.SetAttributes(sessionAttributes(session)...)
+		span.AddEvent(tracing.EventDial)
 	}
 
 	if !s.config.AuthOpts.ClientSideAuthentication {
-		if err := s.proxySocks5AuthReq(conn, proxyConn); err != nil {
+		if err := s.proxySocks5AuthReq(reqConn, proxyConn); err != nil {
 			HandshakeErrors.Add(1)
 			proxyConn.Close()
+			endSpan(span, session, err)
 			return err
 		}
 	}
-	udpAssociateConn, err := s.proxySocks5ConnReq(conn, proxyConn)
+	udpAssociateConn, destination, err := s.proxySocks5ConnReq(reqConn, proxyConn)
 	if err != nil {
 		HandshakeErrors.Add(1)
 		proxyConn.Close()
+		endSpan(span, session, err)
 		return err
 	}
+	if span != nil {
+		span.SetAttributes(tracing.String("mieru.destination", destination), tracing.Bool("mieru.udp_associate", udpAssociateConn != nil))
+		span.AddEvent(tracing.EventHandshake)
+	}
 
 	if udpAssociateConn != nil {
 		log.Debugf("UDP association is listening on %v", udpAssociateConn.LocalAddr())
@@ -213,12 +392,42 @@ func (s *Server) clientServeConn(conn net.Conn) error {
 			common.ReadAllAndDiscard(conn)
 			conn.Close()
 		}()
-		return BidiCopyUDP(udpAssociateConn, WrapUDPAssociateTunnel(proxyConn))
+		var tunnelConn io.ReadWriteCloser = WrapUDPAssociateTunnel(proxyConn)
+		if dc, ok := proxyConn.(protocol.DatagramConn); ok && dc.DatagramEnabled() {
+			tunnelConn = &datagramTunnelConn{DatagramConn: dc}
+		}
+		err = BidiCopyUDP(udpAssociateConn, tunnelConn)
+		endSpan(span, session, err)
+		return err
+	}
+	if s.config.DestinationStats != nil {
+		proxyConn = s.config.DestinationStats.Wrap(proxyConn, destination)
+	}
+	err = s.relay(conn, traceFirstByte(proxyConn, span))
+	endSpan(span, session, err)
+	return err
+}
+
+// rejectProxyRequest replies the socks5 request with a failure
+// because the proxy is disabled.
+func (s *Server) rejectProxyRequest(conn net.Conn) error {
+	if !s.config.AuthOpts.ClientSideAuthentication {
+		if err := s.handleAuthentication(conn); err != nil {
+			return err
+		}
+	}
+	request, err := s.newRequest(conn)
+	if err != nil {
+		HandshakeErrors.Add(1)
+		return fmt.Errorf("failed to read destination address: %w", err)
+	}
+	if err := sendReply(conn, ruleFailure, nil); err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
 	}
-	return common.BidiCopy(conn, proxyConn)
+	return fmt.Errorf("proxy is disabled, rejected socks5 request to %v", request.DstAddr)
 }
 
-func (s *Server) serverServeConn(conn net.Conn) error {
+func (s *Server) serverServeConn(ctx context.Context, conn net.Conn) error {
 	if !s.config.AuthOpts.ClientSideAuthentication {
 		if err := s.handleAuthentication(conn); err != nil {
 			return err
@@ -235,6 +444,13 @@ func (s *Server) serverServeConn(conn net.Conn) error {
 		}
 		return fmt.Errorf("failed to read destination address: %w", err)
 	}
+	if setter, ok := ctx.Value(destinationSetterContextKey{}).(destinationSetter); ok {
+		setter.SetDestination(request.DstAddr.String())
+	}
+	if span := spanFromContext(ctx); span != nil {
+		span.SetAttributes(tracing.String("mieru.destination", request.DstAddr.String()), tracing.Int64("mieru.socks5.command", int64(request.Command)))
+		span.AddEvent(tracing.EventHandshake)
+	}
 
 	action := s.config.EgressController.FindAction(egress.Input{
 		Protocol: appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL,
@@ -252,15 +468,42 @@ func (s *Server) serverServeConn(conn net.Conn) error {
 	}
 	switch action.Action {
 	case appctlpb.EgressAction_DIRECT:
-		if err := s.handleRequest(context.Background(), request, conn); err != nil {
+		if action.SourceIP != nil || action.InterfaceName != "" {
+			ctx = context.WithValue(ctx, directDialerContextKey{}, egress.NewDirectDialer(action.SourceIP, action.InterfaceName))
+		}
+		if err := s.handleRequest(ctx, request, conn); err != nil {
 			return fmt.Errorf("handleRequest() failed: %w", err)
 		}
 	case appctlpb.EgressAction_PROXY:
 		if action.Proxy == nil {
 			return fmt.Errorf("egress action is PROXY but proxy info is unavailable")
 		}
+		// The domain name is resolved by the egress proxy,
+		// so only the requested address is checked.
+		dst := request.DstAddr
+		if request.Command == constant.Socks5ConnectCmd && !s.allowDestination(dst.FQDN, dst.IP, dst.Port) {
+			if err := sendReply(conn, ruleFailure, nil); err != nil {
+				return fmt.Errorf("failed to send reply: %w", err)
+			}
+			return fmt.Errorf("connection to %v is denied by destination ACL", dst)
+		}
+		if request.Command == constant.Socks5ConnectCmd {
+			return s.handleConnect(ctx, request, conn, egress.NewProxyDialer(action.Proxy, s.config.HandshakeTimeout))
+		}
+		if action.Proxy.GetProtocol() != appctlpb.ProxyProtocol_SOCKS5_PROXY_PROTOCOL {
+			UnsupportedCommandErrors.Add(1)
+			if err := sendReply(conn, commandNotSupported, nil); err != nil {
+				return fmt.Errorf("failed to send reply: %w", err)
+			}
+			return fmt.Errorf("command %v is not supported by egress proxy %q", request.Command, action.Proxy.GetName())
+		}
+		// Other commands are forwarded to the socks5 egress proxy as is.
 		return s.handleForwarding(request, conn, action.Proxy)
 	case appctlpb.EgressAction_REJECT:
+		NotAllowedErrors.Add(1)
+		if err := sendReply(conn, ruleFailure, nil); err != nil {
+			return fmt.Errorf("failed to send reply: %w", err)
+		}
 		return fmt.Errorf("connection is rejected by egress rules")
 	}
 	return nil
@@ -284,5 +527,5 @@ func (s *Server) handleForwarding(req *Request, conn net.Conn, proxy *appctlpb.E
 		proxyConn.Close()
 		return fmt.Errorf("failed to write socks5 request to egress proxy: %w", err)
 	}
-	return common.BidiCopy(conn, proxyConn)
+	return s.relay(conn, proxyConn)
 }
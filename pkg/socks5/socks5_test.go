@@ -11,6 +11,7 @@ import (
 
 	"github.com/enfein/mieru/v3/apis/constant"
 	"github.com/enfein/mieru/v3/pkg/common"
+	"github.com/enfein/mieru/v3/pkg/protocol"
 )
 
 func TestSocks5Connect(t *testing.T) {
@@ -241,3 +242,42 @@ func TestSocks5UDPAssociation(t *testing.T) {
 		t.Errorf("UDPAssociateDownloadPackets value %d is not increased", UDPAssociateDownloadPackets.Load())
 	}
 }
+
+func TestSocks5ProxyDisabled(t *testing.T) {
+	conf := &Config{
+		UseProxy: true,
+		ProxyMux: protocol.NewMux(true),
+		AuthOpts: Auth{
+			ClientSideAuthentication: true,
+		},
+	}
+	serv, err := New(conf)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	serv.SetProxyDisabled(true)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go serv.ServeConn(serverConn)
+
+	req := bytes.NewBuffer(nil)
+	req.Write([]byte{constant.Socks5Version, 1, constant.Socks5NoAuth})
+	req.Write([]byte{constant.Socks5Version, constant.Socks5ConnectCmd, 0, constant.Socks5IPv4Address, 127, 0, 0, 1, 0, 80})
+	go clientConn.Write(req.Bytes())
+
+	want := []byte{
+		constant.Socks5Version, constant.Socks5NoAuth,
+		constant.Socks5Version, ruleFailure, 0, constant.Socks5IPv4Address,
+		0, 0, 0, 0,
+		0, 0,
+	}
+	out := make([]byte, len(want))
+	clientConn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(clientConn, out); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Fatalf("got %v, want %v", out, want)
+	}
+}
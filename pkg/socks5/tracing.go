@@ -0,0 +1,106 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/enfein/mieru/v3/pkg/protocol"
+	"github.com/enfein/mieru/v3/pkg/tracing"
+)
+
+// proxyConnectionSpanName is the name of the span of a proxy connection.
+const proxyConnectionSpanName = "proxy connection"
+
+// spanContextKey is the context key of the span of a proxy connection.
+type spanContextKey struct{}
+
+// sessionAttributes returns the span attributes that identify
+// the mieru session and the underlay used by conn.
+// It returns nil if conn is not a mieru session.
+func sessionAttributes(conn net.Conn) []tracing.Attribute {
+	session, ok := conn.(*protocol.Session)
+	if !ok {
+		return nil
+	}
+	info := session.ToSessionInfo()
+	id, _ := strconv.ParseInt(info.ID, 10, 64)
+	attrs := []tracing.Attribute{
+		tracing.Int64("mieru.session.id", id),
+		tracing.String("mieru.underlay.protocol", info.Protocol),
+		tracing.String("mieru.underlay.local_address", info.LocalAddr),
+		tracing.String("mieru.underlay.remote_address", info.RemoteAddr),
+	}
+	if user := session.UserName(); user != "" {
+		attrs = append(attrs, tracing.String("mieru.user", user))
+	}
+	return attrs
+}
+
+// endSpan adds the transmission statistics of the mieru session
+// to the span, and finishes the span.
+func endSpan(span *tracing.Span, conn net.Conn, err error) {
+	if span == nil {
+		return
+	}
+	if session, ok := conn.(*protocol.Session); ok {
+		stats := session.Stats()
+		span.SetAttributes(
+			tracing.Int64("mieru.session.bytes_read", int64(stats.BytesRead)),
+			tracing.Int64("mieru.session.bytes_written", int64(stats.BytesWritten)),
+			tracing.Int64("mieru.session.retransmissions", int64(stats.Retransmissions)),
+			tracing.Int64("mieru.session.rtt_ms", stats.SmoothedRTT.Milliseconds()),
+		)
+	}
+	span.End(err)
+}
+
+// spanFromContext returns the span of the proxy connection, or nil
+// if the connection is not traced.
+func spanFromContext(ctx context.Context) *tracing.Span {
+	span, _ := ctx.Value(spanContextKey{}).(*tracing.Span)
+	return span
+}
+
+// tracedConn records the first byte event of the span
+// when the first byte is read from the destination.
+type tracedConn struct {
+	net.Conn
+	span *tracing.Span
+	once sync.Once
+}
+
+// traceFirstByte returns a connection that records the first byte event.
+// conn is the connection to the destination or the proxy server.
+func traceFirstByte(conn net.Conn, span *tracing.Span) net.Conn {
+	if span == nil {
+		return conn
+	}
+	return &tracedConn{Conn: conn, span: span}
+}
+
+func (c *tracedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.once.Do(func() {
+			c.span.AddEvent(tracing.EventFirstByte)
+		})
+	}
+	return n, err
+}
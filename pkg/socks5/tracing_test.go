@@ -0,0 +1,116 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package socks5
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/enfein/mieru/v3/apis/constant"
+	"github.com/enfein/mieru/v3/pkg/tracing"
+)
+
+type channelExporter chan *tracing.Span
+
+func (e channelExporter) ExportSpan(span *tracing.Span) {
+	e <- span
+}
+
+func TestSocks5ConnectTracing(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		conn.Write([]byte("pong"))
+	}()
+	lAddr := l.Addr().(*net.TCPAddr)
+
+	exporter := make(channelExporter, 1)
+	serv, err := New(&Config{
+		AllowLocalDestination: true,
+		Tracer:                tracing.NewTracer(tracing.SpanKindServer, exporter, 1),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	go serv.ServeConn(serverConn)
+
+	req := bytes.NewBuffer(nil)
+	req.Write([]byte{constant.Socks5Version, 1, constant.Socks5NoAuth})
+	req.Write([]byte{constant.Socks5Version, constant.Socks5ConnectCmd, 0, constant.Socks5IPv4Address, 127, 0, 0, 1})
+	port := []byte{0, 0}
+	binary.BigEndian.PutUint16(port, uint16(lAddr.Port))
+	req.Write(port)
+	req.Write([]byte("ping"))
+	go clientConn.Write(req.Bytes())
+
+	// Method selection reply, socks5 reply and "pong".
+	out := make([]byte, 2+10+4)
+	clientConn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(clientConn, out); err != nil {
+		t.Fatalf("io.ReadFull() failed: %v", err)
+	}
+	if !bytes.Equal(out[12:], []byte("pong")) {
+		t.Fatalf("got %v, want pong", out[12:])
+	}
+	clientConn.Close()
+
+	var span *tracing.Span
+	select {
+	case span = <-exporter:
+	case <-time.After(time.Second):
+		t.Fatalf("span is not exported after the connection is closed")
+	}
+	var events []string
+	for _, event := range span.Events() {
+		events = append(events, event.Name)
+	}
+	want := []string{tracing.EventHandshake, tracing.EventDial, tracing.EventFirstByte}
+	if len(events) != len(want) {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("got events %v, want %v", events, want)
+			break
+		}
+	}
+	found := false
+	for _, attr := range span.Attributes() {
+		if attr.Key == "mieru.destination" && attr.Value == lAddr.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("destination attribute is not found in %v", span.Attributes())
+	}
+}
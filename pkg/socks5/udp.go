@@ -16,10 +16,15 @@
 package socks5
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
 	"net"
+	"sync"
+	"time"
 
+	"github.com/enfein/mieru/v3/pkg/protocol"
 	"github.com/enfein/mieru/v3/pkg/stderror"
 )
 
@@ -91,6 +96,33 @@ func WrapUDPAssociateTunnel(conn io.ReadWriteCloser) *UDPAssociateTunnelConn {
 	return &UDPAssociateTunnelConn{ReadWriteCloser: conn}
 }
 
+// datagramConnContextKey is the context key of the proxy session
+// that can exchange datagrams.
+type datagramConnContextKey struct{}
+
+// datagramTunnelConn carries each UDP packet as a datagram of the proxy
+// session. The session keeps the boundary of packets, and lost packets
+// are not sent again.
+type datagramTunnelConn struct {
+	protocol.DatagramConn
+}
+
+func (c *datagramTunnelConn) Read(b []byte) (int, error) {
+	return c.ReadDatagram(b)
+}
+
+func (c *datagramTunnelConn) Write(b []byte) (int, error) {
+	if err := c.WriteDatagram(b); err != nil {
+		if errors.Is(err, stderror.ErrOutOfRange) {
+			// Drop the packet that doesn't fit into a datagram,
+			// like a router does when the packet exceeds MTU.
+			return len(b), nil
+		}
+		return 0, err
+	}
+	return len(b), nil
+}
+
 // udpAddrToHeader returns a UDP associate header with the given
 // destination address.
 func udpAddrToHeader(addr *net.UDPAddr) []byte {
@@ -108,3 +140,160 @@ func udpAddrToHeader(addr *net.UDPAddr) []byte {
 	}
 	return binary.BigEndian.AppendUint16(res, uint16(addr.Port))
 }
+
+const (
+	// udpFragmentEnd is the bit of the FRAG field that marks the last
+	// fragment of a UDP datagram.
+	udpFragmentEnd = 0x80
+
+	// udpReassemblyTimeout is the maximum time to wait for the remaining
+	// fragments of a UDP datagram. RFC 1928 requires at least 5 seconds.
+	udpReassemblyTimeout = 5 * time.Second
+
+	// udpMappingTimeout is the time a destination of a UDP association
+	// stays mapped without traffic. RFC 4787 recommends at least 5 minutes.
+	// The UDP association is closed if all the destinations are idle.
+	udpMappingTimeout = 5 * time.Minute
+)
+
+// udpReassembler reassembles a fragmented socks5 UDP request, as
+// described in RFC 1928 section 7. Fragments must arrive in order,
+// and the position of the first fragment is 1.
+type udpReassembler struct {
+	header   []byte // header of the first fragment with FRAG set to 0
+	payloads [][]byte
+	size     int
+	last     byte // position of the latest fragment
+	deadline time.Time
+}
+
+// add processes a socks5 UDP request with a header of headerLen bytes.
+// It returns the request if it is not fragmented, or the reassembled
+// request after the last fragment is received. Otherwise it returns nil.
+func (r *udpReassembler) add(packet []byte, headerLen int, now time.Time) []byte {
+	frag := packet[2]
+	if frag == 0 {
+		// Fragments of the previous datagram are abandoned.
+		r.reset()
+		return packet
+	}
+	pos := frag &^ udpFragmentEnd
+	header := packet[:headerLen]
+	if r.header != nil && (now.After(r.deadline) || pos != r.last+1 || !bytes.Equal(r.header[3:], header[3:])) {
+		r.reset()
+	}
+	if r.header == nil {
+		if pos != 1 {
+			UDPAssociateDroppedFragments.Add(1)
+			return nil
+		}
+		r.header = append([]byte{}, header...)
+		r.header[2] = 0
+		r.deadline = now.Add(udpReassemblyTimeout)
+	}
+	if r.size+len(packet)-headerLen > 65535-headerLen {
+		UDPAssociateDroppedFragments.Add(1)
+		r.reset()
+		return nil
+	}
+	r.payloads = append(r.payloads, append([]byte{}, packet[headerLen:]...))
+	r.size += len(packet) - headerLen
+	r.last = pos
+	if frag&udpFragmentEnd == 0 {
+		return nil
+	}
+	res := make([]byte, 0, len(r.header)+r.size)
+	res = append(res, r.header...)
+	for _, p := range r.payloads {
+		res = append(res, p...)
+	}
+	r.payloads = nil
+	r.reset()
+	UDPAssociateReassembledPackets.Add(1)
+	return res
+}
+
+// reset abandons the fragments received so far.
+func (r *udpReassembler) reset() {
+	if len(r.payloads) > 0 {
+		UDPAssociateDroppedFragments.Add(int64(len(r.payloads)))
+	}
+	r.header = nil
+	r.payloads = nil
+	r.size = 0
+	r.last = 0
+}
+
+// udpNATTable maps the destinations of a UDP association to the headers
+// of socks5 UDP requests, like the mapping table of a NAT. A mapping is
+// created or refreshed when a packet is sent to the destination, and it
+// is also refreshed when a packet is received from the destination.
+// Packets from destinations without a mapping are still accepted.
+type udpNATTable struct {
+	mu         sync.Mutex
+	mappings   map[string]*udpMapping
+	lastActive time.Time
+}
+
+type udpMapping struct {
+	header     []byte
+	lastActive time.Time
+}
+
+func newUDPNATTable(now time.Time) *udpNATTable {
+	return &udpNATTable{
+		mappings:   make(map[string]*udpMapping),
+		lastActive: now,
+	}
+}
+
+// refresh creates or refreshes the mapping of a destination.
+func (t *udpNATTable) refresh(addr string, header []byte, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastActive = now
+	if m, ok := t.mappings[addr]; ok {
+		m.lastActive = now
+		if !bytes.Equal(m.header, header) {
+			m.header = append([]byte{}, header...)
+		}
+		return
+	}
+	t.mappings[addr] = &udpMapping{
+		header:     append([]byte{}, header...),
+		lastActive: now,
+	}
+}
+
+// lookup returns the header of the packets received from the destination.
+func (t *udpNATTable) lookup(addr *net.UDPAddr, now time.Time) []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastActive = now
+	if m, ok := t.mappings[addr.String()]; ok {
+		m.lastActive = now
+		return m.header
+	}
+	return udpAddrToHeader(addr)
+}
+
+// expire removes the mappings that are idle for udpMappingTimeout.
+// It returns true if the UDP association is idle for udpMappingTimeout.
+func (t *udpNATTable) expire(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for addr, m := range t.mappings {
+		if now.Sub(m.lastActive) >= udpMappingTimeout {
+			delete(t.mappings, addr)
+			UDPAssociateExpiredMappings.Add(1)
+		}
+	}
+	return now.Sub(t.lastActive) >= udpMappingTimeout
+}
+
+// len returns the number of mappings.
+func (t *udpNATTable) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.mappings)
+}
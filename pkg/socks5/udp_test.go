@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/enfein/mieru/v3/pkg/testtool"
 )
@@ -76,3 +77,82 @@ func TestUDPAddrToHeader(t *testing.T) {
 		}
 	}
 }
+
+func TestUDPReassembler(t *testing.T) {
+	fragment := func(frag byte, payload ...byte) []byte {
+		return append([]byte{0, 0, frag, 1, 127, 0, 0, 1, 0, 53}, payload...)
+	}
+	want := fragment(0, 1, 2, 3, 4, 5)
+	now := time.Now()
+
+	var r udpReassembler
+	if got := r.add(fragment(0, 9), 10, now); !bytes.Equal(got, fragment(0, 9)) {
+		t.Errorf("add() unfragmented packet = %v, want %v", got, fragment(0, 9))
+	}
+	if got := r.add(fragment(1, 1, 2), 10, now); got != nil {
+		t.Errorf("add() first fragment = %v, want nil", got)
+	}
+	if got := r.add(fragment(2, 3, 4), 10, now); got != nil {
+		t.Errorf("add() second fragment = %v, want nil", got)
+	}
+	if got := r.add(fragment(3|udpFragmentEnd, 5), 10, now); !bytes.Equal(got, want) {
+		t.Errorf("add() last fragment = %v, want %v", got, want)
+	}
+
+	// A missing fragment abandons the datagram.
+	r.add(fragment(1, 1, 2), 10, now)
+	if got := r.add(fragment(3|udpFragmentEnd, 5), 10, now); got != nil {
+		t.Errorf("add() after a missing fragment = %v, want nil", got)
+	}
+
+	// Fragments are abandoned after the reassembly timer expires.
+	r.add(fragment(1, 1, 2), 10, now)
+	if got := r.add(fragment(2|udpFragmentEnd, 3), 10, now.Add(udpReassemblyTimeout+time.Second)); got != nil {
+		t.Errorf("add() after reassembly timeout = %v, want nil", got)
+	}
+
+	// A new datagram that is not fragmented abandons the fragments.
+	r.add(fragment(1, 1, 2), 10, now)
+	r.add(fragment(0, 9), 10, now)
+	if got := r.add(fragment(2|udpFragmentEnd, 3), 10, now); got != nil {
+		t.Errorf("add() after unfragmented packet = %v, want nil", got)
+	}
+}
+
+func TestUDPNATTable(t *testing.T) {
+	now := time.Now()
+	nat := newUDPNATTable(now)
+	addr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 53}
+	fqdnHeader := []byte{0, 0, 0, 3, 9, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't', 0, 53}
+
+	nat.refresh(addr.String(), fqdnHeader, now)
+	if got := nat.lookup(addr, now); !bytes.Equal(got, fqdnHeader) {
+		t.Errorf("lookup() = %v, want %v", got, fqdnHeader)
+	}
+	other := &net.UDPAddr{IP: net.ParseIP("127.0.0.2"), Port: 53}
+	if got := nat.lookup(other, now); !bytes.Equal(got, udpAddrToHeader(other)) {
+		t.Errorf("lookup() without mapping = %v, want %v", got, udpAddrToHeader(other))
+	}
+	if nat.len() != 1 {
+		t.Errorf("len() = %d, want 1", nat.len())
+	}
+
+	// The mapping is refreshed by the received packet.
+	if idle := nat.expire(now.Add(udpMappingTimeout - time.Second)); idle {
+		t.Errorf("expire() returns idle before timeout")
+	}
+	nat.lookup(addr, now.Add(udpMappingTimeout-time.Second))
+	if idle := nat.expire(now.Add(udpMappingTimeout + time.Second)); idle {
+		t.Errorf("expire() returns idle after the mapping is refreshed")
+	}
+	if nat.len() != 1 {
+		t.Errorf("len() = %d, want 1", nat.len())
+	}
+
+	if idle := nat.expire(now.Add(3 * udpMappingTimeout)); !idle {
+		t.Errorf("expire() doesn't return idle after timeout")
+	}
+	if nat.len() != 0 {
+		t.Errorf("len() = %d, want 0", nat.len())
+	}
+}
@@ -42,6 +42,13 @@ func IsEOF(err error) bool {
 	return errors.Is(err, io.EOF)
 }
 
+// IsNoSuchFile returns true if the cause of error is a missing file,
+// such as the unix domain socket of a daemon that is not running.
+func IsNoSuchFile(err error) bool {
+	s := strings.ToLower(err.Error())
+	return strings.Contains(s, "no such file or directory") || strings.Contains(s, "the system cannot find the file specified")
+}
+
 // IsNotReady returns true if the caller should retry the same operation again.
 func IsNotReady(err error) bool {
 	return errors.Is(err, ErrNotReady)
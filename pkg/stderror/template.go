@@ -16,32 +16,47 @@
 package stderror
 
 const (
+	AddUserFailedErr                        = "add user failed: %w"
 	ClientConfigIsEmpty                     = "mieru client config is empty"
 	ClientConfigNotExist                    = "mieru client config file doesn't exist"
 	ClientGetActiveProfileFailedErr         = "mieru client get active profile failed: %w"
 	ClientNotRunning                        = "mieru client is not running"
 	ClientNotRunningErr                     = "mieru client is not running: %w"
+	CreateBugReportFailedErr                = "create bug report failed: %w"
 	CreateClientLifecycleRPCClientFailedErr = "create mieru client lifecycle RPC client failed: %w"
+	CreateDNSResolverFailedErr              = "create DNS resolver failed: %w"
 	CreateEmptyServerConfigFailedErr        = "create empty mita server config file failed: %w"
 	CreateServerConfigRPCClientFailedErr    = "create mita server config RPC client failed: %w"
 	CreateServerLifecycleRPCClientFailedErr = "create mita server lifecycle RPC client failed: %w"
 	CreateSocks5ServerFailedErr             = "create socks5 server failed: %w"
+	CreateTracerFailedErr                   = "create tracer failed: %w"
 	DecodeHashedPasswordFailedErr           = "decode hashed password failed: %w"
+	DrainServerProxyFailedErr               = "drain mita server proxy failed: %w"
 	ExitFailedErr                           = "process exit failed: %w"
 	GetClientConfigFailedErr                = "get mieru client config failed: %w"
 	GetConnectionsFailedErr                 = "get connections failed: %w"
+	GetDestinationStatsFailedErr            = "get destination statistics failed: %w"
 	GetHeapProfileFailedErr                 = "get heap profile failed: %w"
+	GetLogsFailedErr                        = "get logs failed: %w"
+	GetManagementTokenFailedErr             = "get management API token failed: %w"
 	GetMemoryStatisticsFailedErr            = "get memory statistics failed: %w"
 	GetMetricsFailedErr                     = "get metrics failed: %w"
+	GetRouteStatsFailedErr                  = "get route stats failed: %w"
 	GetServerConfigFailedErr                = "get mita server config failed: %w"
 	GetServerStatusFailedErr                = "get mita server status failed: %w"
+	GetSessionStatesFailedErr               = "get session states failed: %w"
 	GetThreadDumpFailedErr                  = "get thread dump failed: %w"
+	GetTrafficFailedErr                     = "get traffic failed: %w"
+	GetUsersFailedErr                       = "get users failed: %w"
+	GetUserStatsFailedErr                   = "get user statistics failed: %w"
 	InvalidPortBindingsErr                  = "invalid port bindings: %w"
 	InvalidTransportProtocol                = "invalid transport protocol"
 	IPAddressNotFound                       = "IP address not found from domain name %q"
 	LookupIPFailedErr                       = "look up IP address failed: %w"
 	ParseIPFailed                           = "parse IP address failed"
+	ReloadDNSFailedErr                      = "reload DNS failed: %w"
 	ReloadServerFailedErr                   = "reload mita server failed: %w"
+	RemoveUserFailedErr                     = "remove user failed: %w"
 	SegmentSizeTooBig                       = "segment size too big"
 	ServerNotRunningErr                     = "mita server daemon is not running: %w"
 	ServerNotRunningWithCommand             = "mita server daemon is not running; please run command \"sudo systemctl restart mita\" to start server daemon; run command \"sudo journalctl -e -u mita --no-pager\" to check log if unable to start"
@@ -52,6 +67,7 @@ const (
 	StartServerProxyFailedErr               = "start mita server proxy failed: %w"
 	StopServerProxyFailedErr                = "stop mita server proxy failed: %w"
 	StoreClientConfigFailedErr              = "store mieru client config failed: %w"
+	UpdateUserFailedErr                     = "update user failed: %w"
 	ValidateFullClientConfigFailedErr       = "validate full client config failed: %w"
 	ValidateServerConfigPatchFailedErr      = "validate server config patch failed: %w"
 )
@@ -0,0 +1,614 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package toml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Unmarshal parses the TOML document.
+func Unmarshal(b []byte) (map[string]any, error) {
+	if !utf8.Valid(b) {
+		return nil, fmt.Errorf("document is not valid UTF-8")
+	}
+	p := &parser{
+		src:     []rune(string(b)),
+		line:    1,
+		root:    map[string]any{},
+		defined: map[string]bool{},
+		sealed:  map[string]bool{},
+	}
+	p.current = p.root
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return p.root, nil
+}
+
+type parser struct {
+	src  []rune
+	pos  int
+	line int
+
+	root    map[string]any
+	current map[string]any // the table that receives key value pairs
+
+	// defined records the tables created by headers and inline tables,
+	// which can't be defined again.
+	defined map[string]bool
+	// sealed records the inline tables, which can't be extended.
+	sealed map[string]bool
+	// prefix is the path of the current table, including the index of
+	// the array of tables elements.
+	prefix string
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipWhitespaceAndNewlines()
+		if p.eof() {
+			return nil
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.parseHeader()
+		} else {
+			err = p.parseKeyValue(p.current, p.prefix)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.expectLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+// parseHeader parses [table] and [[array.of.tables]].
+func (p *parser) parseHeader() error {
+	p.next() // [
+	isArray := false
+	if !p.eof() && p.peek() == '[' {
+		p.next()
+		isArray = true
+	}
+	p.skipWhitespace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipWhitespace()
+	if !p.consume(']') || (isArray && !p.consume(']')) {
+		return fmt.Errorf("table header is not closed")
+	}
+
+	table := p.root
+	prefix := ""
+	for i, k := range keys {
+		prefix += "." + k
+		if p.sealed[prefix] {
+			return fmt.Errorf("inline table %q can't be extended", strings.Join(keys[:i+1], "."))
+		}
+		last := i == len(keys)-1
+		v, found := table[k]
+		if last && isArray {
+			if !found {
+				v = []any{}
+			}
+			arr, ok := v.([]any)
+			if !ok || p.defined[prefix] {
+				return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+			}
+			next := map[string]any{}
+			table[k] = append(arr, next)
+			prefix += fmt.Sprintf("[%d]", len(arr))
+			table = next
+			break
+		}
+		if !found {
+			next := map[string]any{}
+			table[k] = next
+			table = next
+			continue
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			if last && p.defined[prefix] {
+				return fmt.Errorf("table %q is already defined", strings.Join(keys, "."))
+			}
+			table = val
+		case []any:
+			// A sub-table of an array of tables belongs to the last element.
+			if len(val) == 0 || p.defined[prefix] || last {
+				return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+			}
+			next, ok := val[len(val)-1].(map[string]any)
+			if !ok {
+				return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+			}
+			prefix += fmt.Sprintf("[%d]", len(val)-1)
+			table = next
+		default:
+			return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+		}
+	}
+	p.defined[prefix] = true
+	p.current = table
+	p.prefix = prefix
+	return nil
+}
+
+// parseKeyValue parses a key value pair and stores it into the table.
+func (p *parser) parseKeyValue(table map[string]any, prefix string) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipWhitespace()
+	if !p.consume('=') {
+		return fmt.Errorf("expect '=' after key %q", strings.Join(keys, "."))
+	}
+	p.skipWhitespace()
+	for _, k := range keys[:len(keys)-1] {
+		prefix += "." + k
+		v, found := table[k]
+		if !found {
+			next := map[string]any{}
+			table[k] = next
+			table = next
+			continue
+		}
+		next, ok := v.(map[string]any)
+		if !ok || p.sealed[prefix] {
+			return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+		}
+		table = next
+	}
+	k := keys[len(keys)-1]
+	if _, found := table[k]; found {
+		return fmt.Errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+	v, err := p.parseValue(prefix + "." + k)
+	if err != nil {
+		return err
+	}
+	table[k] = v
+	return nil
+}
+
+// parseKey parses a bare, quoted or dotted key.
+func (p *parser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipWhitespace()
+		if p.eof() {
+			return nil, fmt.Errorf("unexpected end of document, expect a key")
+		}
+		var k string
+		var err error
+		switch c := p.peek(); {
+		case c == '"':
+			k, err = p.parseBasicString()
+		case c == '\'':
+			k, err = p.parseLiteralString()
+		case isBareKeyChar(c):
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.next()
+			}
+			k = string(p.src[start:p.pos])
+		default:
+			return nil, fmt.Errorf("invalid character %q in key", c)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+		p.skipWhitespace()
+		if !p.consume('.') {
+			return keys, nil
+		}
+	}
+}
+
+// parseValue parses a value. The path identifies the value
+// if it is an inline table.
+func (p *parser) parseValue(path string) (any, error) {
+	if p.eof() {
+		return nil, fmt.Errorf("unexpected end of document, expect a value")
+	}
+	switch c := p.peek(); {
+	case c == '"':
+		if p.hasPrefix(`"""`) {
+			return p.parseMultilineBasicString()
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if p.hasPrefix(`'''`) {
+			return p.parseMultilineLiteralString()
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray(path)
+	case c == '{':
+		return p.parseInlineTable(path)
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	default:
+		return p.parseNumber()
+	}
+}
+
+func (p *parser) parseArray(path string) ([]any, error) {
+	p.next() // [
+	arr := []any{}
+	for {
+		p.skipWhitespaceAndNewlines()
+		if p.eof() {
+			return nil, fmt.Errorf("array is not closed")
+		}
+		if p.consume(']') {
+			return arr, nil
+		}
+		v, err := p.parseValue(fmt.Sprintf("%s[%d]", path, len(arr)))
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		p.skipWhitespaceAndNewlines()
+		if p.consume(']') {
+			return arr, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expect ',' or ']' in array")
+		}
+	}
+}
+
+func (p *parser) parseInlineTable(path string) (map[string]any, error) {
+	p.next() // {
+	table := map[string]any{}
+	p.defined[path] = true
+	p.sealed[path] = true
+	p.skipWhitespace()
+	if p.consume('}') {
+		return table, nil
+	}
+	for {
+		p.skipWhitespace()
+		if err := p.parseKeyValue(table, path); err != nil {
+			return nil, err
+		}
+		p.skipWhitespace()
+		if p.consume('}') {
+			return table, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expect ',' or '}' in inline table")
+		}
+	}
+}
+
+func (p *parser) parseNumber() (any, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == ',' || c == ']' || c == '}' || c == '#' || c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			break
+		}
+		p.next()
+	}
+	s := string(p.src[start:p.pos])
+	if s == "" {
+		return nil, fmt.Errorf("expect a value")
+	}
+	switch strings.TrimLeft(s, "+-") {
+	case "inf":
+		if strings.HasPrefix(s, "-") {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	if strings.Contains(s, "__") || strings.HasPrefix(s, "_") || strings.HasSuffix(s, "_") {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	clean := strings.ReplaceAll(s, "_", "")
+	if len(clean) > 2 && clean[0] == '0' {
+		var base int
+		switch clean[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 0 {
+			v, err := strconv.ParseInt(clean[2:], base, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer %q", s)
+			}
+			return v, nil
+		}
+	}
+	if v, err := strconv.ParseInt(clean, 10, 64); err == nil {
+		digits := strings.TrimLeft(clean, "+-")
+		if len(digits) > 1 && digits[0] == '0' {
+			return nil, fmt.Errorf("invalid integer %q with leading zero", s)
+		}
+		return v, nil
+	}
+	if strings.ContainsAny(clean, ":") || strings.Count(clean, "-") > 1 {
+		return nil, fmt.Errorf("date and time value %q is not supported", s)
+	}
+	if strings.Trim(clean, "0123456789+-.eE") != "" {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	v, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+func (p *parser) parseBasicString() (string, error) {
+	p.next() // "
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("string is not closed")
+		}
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\n':
+			return "", fmt.Errorf("newline in string")
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(c)
+		}
+	}
+}
+
+func (p *parser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.trimFirstNewline()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("string is not closed")
+		}
+		if p.hasPrefix(`"""`) {
+			p.pos += 3
+			// Up to 2 quotes are allowed right before the delimiter.
+			for i := 0; i < 2 && !p.eof() && p.peek() == '"'; i++ {
+				b.WriteRune(p.next())
+			}
+			return b.String(), nil
+		}
+		c := p.next()
+		if c != '\\' {
+			b.WriteRune(c)
+			continue
+		}
+		// A line ending backslash trims the whitespace up to the next
+		// non-whitespace character.
+		save, saveLine := p.pos, p.line
+		p.skipWhitespace()
+		if !p.eof() && (p.peek() == '\n' || p.hasPrefix("\r\n")) {
+			p.skipWhitespaceAndBlankLines()
+			continue
+		}
+		p.pos, p.line = save, saveLine
+		if err := p.parseEscape(&b); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *parser) parseLiteralString() (string, error) {
+	p.next() // '
+	start := p.pos
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("string is not closed")
+		}
+		switch p.next() {
+		case '\'':
+			return string(p.src[start : p.pos-1]), nil
+		case '\n':
+			return "", fmt.Errorf("newline in string")
+		}
+	}
+}
+
+func (p *parser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.trimFirstNewline()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("string is not closed")
+		}
+		if p.hasPrefix("'''") {
+			p.pos += 3
+			for i := 0; i < 2 && !p.eof() && p.peek() == '\''; i++ {
+				b.WriteRune(p.next())
+			}
+			return b.String(), nil
+		}
+		b.WriteRune(p.next())
+	}
+}
+
+// parseEscape parses the escape sequence after a backslash.
+func (p *parser) parseEscape(b *strings.Builder) error {
+	if p.eof() {
+		return fmt.Errorf("string is not closed")
+	}
+	c := p.next()
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return fmt.Errorf("invalid unicode escape")
+		}
+		v, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(v)) {
+			return fmt.Errorf("invalid unicode escape")
+		}
+		p.pos += n
+		b.WriteRune(rune(v))
+	default:
+		return fmt.Errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// expectLineEnd skips the whitespace and comment, then expects
+// a newline or the end of document.
+func (p *parser) expectLineEnd() error {
+	p.skipWhitespace()
+	p.skipComment()
+	if p.eof() {
+		return nil
+	}
+	if p.consume('\n') {
+		return nil
+	}
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		p.line++
+		return nil
+	}
+	return fmt.Errorf("unexpected character %q", p.peek())
+}
+
+func (p *parser) trimFirstNewline() {
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		p.line++
+	} else if !p.eof() && p.peek() == '\n' {
+		p.next()
+	}
+}
+
+func (p *parser) skipWhitespace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+// skipWhitespaceAndBlankLines skips whitespace and newlines, but not comments.
+func (p *parser) skipWhitespaceAndBlankLines() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// skipWhitespaceAndNewlines skips whitespace, newlines and comments.
+func (p *parser) skipWhitespaceAndNewlines() {
+	for {
+		p.skipWhitespaceAndBlankLines()
+		if p.eof() || p.peek() != '#' {
+			return
+		}
+		p.skipComment()
+	}
+}
+
+func (p *parser) skipComment() {
+	if p.eof() || p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *parser) next() rune {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *parser) consume(c rune) bool {
+	if !p.eof() && p.peek() == c {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	rs := []rune(s)
+	if p.pos+len(rs) > len(p.src) {
+		return false
+	}
+	for i, r := range rs {
+		if p.src[p.pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
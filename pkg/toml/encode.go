@@ -0,0 +1,239 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package toml converts between TOML documents and generic values.
+//
+// A document is represented by a map[string]any. The values are string,
+// bool, int64, float64, []any and map[string]any, the same as the values
+// produced by encoding/json with numbers converted. Only the subset of
+// TOML needed by configuration files is supported. Date and time values
+// are rejected.
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the TOML encoding of the document.
+// Map keys are written in sorted order.
+func Marshal(doc map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeTable(&buf, nil, doc, false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeTable writes the key value pairs of the table, followed by
+// its sub-tables and arrays of tables.
+func encodeTable(buf *bytes.Buffer, path []string, table map[string]any, header bool) error {
+	keys := sortedKeys(table)
+	if header {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(buf, "[%s]\n", joinKeys(path))
+	}
+	for _, k := range keys {
+		v := table[k]
+		if isTable(v) || isArrayOfTables(v) {
+			continue
+		}
+		s, err := encodeValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", joinKeys(append(path, k)), err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", quoteKey(k), s)
+	}
+	for _, k := range keys {
+		sub, ok := table[k].(map[string]any)
+		if !ok {
+			continue
+		}
+		if err := encodeTable(buf, appendKey(path, k), sub, true); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if !isArrayOfTables(table[k]) {
+			continue
+		}
+		subPath := appendKey(path, k)
+		for _, elem := range table[k].([]any) {
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintf(buf, "[[%s]]\n", joinKeys(subPath))
+			// The header is already written, so the element is encoded
+			// without another one.
+			if err := encodeTable(buf, subPath, elem.(map[string]any), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeValue returns the inline TOML encoding of the value.
+func encodeValue(v any) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return quoteString(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case uint64:
+		return strconv.FormatUint(val, 10), nil
+	case float64:
+		switch {
+		case math.IsInf(val, 1):
+			return "inf", nil
+		case math.IsInf(val, -1):
+			return "-inf", nil
+		case math.IsNaN(val):
+			return "nan", nil
+		}
+		s := strconv.FormatFloat(val, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEn") {
+			s += ".0"
+		}
+		return s, nil
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, elem := range val {
+			s, err := encodeValue(elem)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case map[string]any:
+		parts := make([]string, 0, len(val))
+		for _, k := range sortedKeys(val) {
+			s, err := encodeValue(val[k])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, quoteKey(k)+" = "+s)
+		}
+		return "{" + strings.Join(parts, ", ") + "}", nil
+	case nil:
+		return "", fmt.Errorf("null value is not supported")
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+func isTable(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
+
+// isArrayOfTables returns true if v is a non-empty array
+// and all the elements are tables.
+func isArrayOfTables(v any) bool {
+	arr, ok := v.([]any)
+	if !ok || len(arr) == 0 {
+		return false
+	}
+	for _, elem := range arr {
+		if !isTable(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func appendKey(path []string, k string) []string {
+	res := make([]string, 0, len(path)+1)
+	res = append(res, path...)
+	return append(res, k)
+}
+
+func joinKeys(path []string) string {
+	parts := make([]string, 0, len(path))
+	for _, k := range path {
+		parts = append(parts, quoteKey(k))
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteKey returns the key as is if it is a bare key,
+// otherwise it returns the quoted key.
+func quoteKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for _, c := range k {
+		if !isBareKeyChar(c) {
+			return quoteString(k)
+		}
+	}
+	return k
+}
+
+func isBareKeyChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-'
+}
+
+// quoteString returns a TOML basic string.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package toml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	doc := `# mita server config
+loggingLevel = "INFO"
+mtu = 1_400
+ratio = 0.5
+enabled = true
+ports = [
+    8964,  # first port
+    9000,
+]
+'quoted key' = 'C:\path'
+server.name = "a\tb\u00e9"
+note = """
+line 1
+line 2"""
+
+[advancedSettings]
+noCheckUpdate = true
+fec = {groupSize = 10, enabled = false}
+
+[[portBindings]]
+port = 0x22b4
+protocol = "TCP"
+
+[[portBindings]]
+portRange = "9000-9100"
+protocol = "UDP"
+
+[portBindings.options]
+fast = true
+`
+	got, err := Unmarshal([]byte(doc))
+	if err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	want := map[string]any{
+		"loggingLevel": "INFO",
+		"mtu":          int64(1400),
+		"ratio":        0.5,
+		"enabled":      true,
+		"ports":        []any{int64(8964), int64(9000)},
+		"quoted key":   `C:\path`,
+		"server":       map[string]any{"name": "a\tbé"},
+		"note":         "line 1\nline 2",
+		"advancedSettings": map[string]any{
+			"noCheckUpdate": true,
+			"fec":           map[string]any{"groupSize": int64(10), "enabled": false},
+		},
+		"portBindings": []any{
+			map[string]any{"port": int64(8884), "protocol": "TCP"},
+			map[string]any{"portRange": "9000-9100", "protocol": "UDP", "options": map[string]any{"fast": true}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"Duplicated key", "a = 1\na = 2"},
+		{"Duplicated table", "[a]\n[a]"},
+		{"Table redefines value", "a = 1\n[a]"},
+		{"Extend inline table", "a = {b = 1}\n[a.c]"},
+		{"Missing value", "a ="},
+		{"Missing equal sign", "a 1"},
+		{"Unclosed string", `a = "abc`},
+		{"Unclosed array", "a = [1, 2"},
+		{"Unclosed header", "[a"},
+		{"Leading zero", "a = 012"},
+		{"Date time", "a = 1979-05-27T07:32:00Z"},
+		{"Invalid escape", `a = "\q"`},
+		{"Two values in a line", "a = 1 b = 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Unmarshal([]byte(tt.doc)); err == nil {
+				t.Errorf("Unmarshal(%q) returned no error", tt.doc)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc := map[string]any{
+		"loggingLevel": "INFO",
+		"mtu":          int64(1400),
+		"ratio":        2.0,
+		"empty":        []any{},
+		"names":        []any{"a\"b", "c\nd"},
+		"key.with.dot": true,
+		"advancedSettings": map[string]any{
+			"noCheckUpdate": true,
+			"nested":        map[string]any{},
+		},
+		"users": []any{
+			map[string]any{
+				"name":  "alice",
+				"quota": []any{map[string]any{"days": int64(1), "megabytes": int64(100)}},
+			},
+			map[string]any{
+				"name":    "bob",
+				"allowed": map[string]any{"ports": []any{int64(1), int64(2)}},
+			},
+		},
+		"matrix": []any{[]any{int64(1)}, []any{map[string]any{"a": "b"}}},
+	}
+	b, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	got, err := Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() failed: %v\n%s", err, b)
+	}
+	if !reflect.DeepEqual(got, doc) {
+		t.Errorf("round trip = %v, want %v\n%s", got, doc, b)
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/enfein/mieru/v3/pkg/log"
+	"github.com/enfein/mieru/v3/pkg/metrics"
+	"github.com/enfein/mieru/v3/pkg/version"
+)
+
+const (
+	// exportInterval is the max time a finished span waits in the queue.
+	exportInterval = 5 * time.Second
+
+	// maxBatchSize is the max number of spans sent in a request.
+	maxBatchSize = 512
+
+	// maxQueuedSpans is the max number of spans waiting to be sent.
+	// New spans are dropped if the collector can't keep up.
+	maxQueuedSpans = 4096
+
+	// exportTimeout is the timeout of a request to the collector.
+	exportTimeout = 10 * time.Second
+
+	// scopeName is the instrumentation scope of the spans.
+	scopeName = "github.com/enfein/mieru/v3/pkg/tracing"
+
+	// statusCodeError is the OTLP status code of a failed span.
+	statusCodeError = 2
+)
+
+var (
+	// Number of spans sent to the collector.
+	ExportedSpans = metrics.RegisterMetric("tracing", "ExportedSpans", metrics.COUNTER)
+
+	// Number of spans dropped because the queue is full
+	// or the collector returns an error.
+	DroppedSpans = metrics.RegisterMetric("tracing", "DroppedSpans", metrics.COUNTER)
+
+	// Number of failed requests to the collector.
+	ExportErrors = metrics.RegisterMetric("tracing", "ExportErrors", metrics.COUNTER)
+)
+
+// ValidateOTLPEndpoint returns an error if the endpoint is not a HTTP URL.
+func ValidateOTLPEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid OTLP endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid OTLP endpoint %q: host is empty", endpoint)
+	}
+	return nil
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector in batches,
+// with the OTLP/HTTP protocol and JSON encoding.
+type OTLPExporter struct {
+	endpoint string
+	resource []Attribute
+	client   *http.Client
+
+	mu     sync.Mutex
+	queue  []*Span
+	closed bool
+
+	flush     chan struct{}
+	die       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var _ Exporter = (*OTLPExporter)(nil)
+
+// NewOTLPExporter returns an exporter that sends spans to the endpoint,
+// for example "http://127.0.0.1:4318/v1/traces". The service name
+// identifies mieru client or mita server in the trace backend.
+func NewOTLPExporter(endpoint, serviceName string) (*OTLPExporter, error) {
+	if err := ValidateOTLPEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	e := &OTLPExporter{
+		endpoint: endpoint,
+		resource: []Attribute{
+			String("service.name", serviceName),
+			String("service.version", version.AppVersion),
+		},
+		client: &http.Client{Timeout: exportTimeout},
+		flush:  make(chan struct{}, 1),
+		die:    make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// Endpoint returns the URL that receives the spans.
+func (e *OTLPExporter) Endpoint() string {
+	return e.endpoint
+}
+
+// ExportSpan implements Exporter.
+func (e *OTLPExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	if e.closed || len(e.queue) >= maxQueuedSpans {
+		e.mu.Unlock()
+		DroppedSpans.Add(1)
+		return
+	}
+	e.queue = append(e.queue, span)
+	full := len(e.queue) >= maxBatchSize
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close sends the queued spans and stops the exporter.
+// Spans finished after Close are dropped.
+func (e *OTLPExporter) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.die)
+		<-e.done
+	})
+	return nil
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.exportQueued()
+		case <-e.flush:
+			e.exportQueued()
+		case <-e.die:
+			e.mu.Lock()
+			e.closed = true
+			e.mu.Unlock()
+			e.exportQueued()
+			return
+		}
+	}
+}
+
+// exportQueued sends all the queued spans.
+func (e *OTLPExporter) exportQueued() {
+	for {
+		e.mu.Lock()
+		n := len(e.queue)
+		if n > maxBatchSize {
+			n = maxBatchSize
+		}
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			ExportErrors.Add(1)
+			DroppedSpans.Add(int64(len(batch)))
+			log.Debugf("export %d spans to %s failed: %v", len(batch), e.endpoint, err)
+			return
+		}
+		ExportedSpans.Add(int64(len(batch)))
+	}
+}
+
+func (e *OTLPExporter) export(spans []*Span) error {
+	b, err := marshalSpans(e.resource, spans)
+	if err != nil {
+		return fmt.Errorf("marshalSpans() failed: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below are the JSON encoding of OTLP
+// ExportTraceServiceRequest.
+
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+// marshalSpans returns the OTLP JSON encoding of the finished spans.
+func marshalSpans(resource []Attribute, spans []*Span) ([]byte, error) {
+	scopeSpans := otlpScopeSpans{
+		Scope: otlpScope{Name: scopeName, Version: version.AppVersion},
+		Spans: make([]otlpSpan, 0, len(spans)),
+	}
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              int(s.kind),
+			StartTimeUnixNano: unixNano(s.start),
+			EndTimeUnixNano:   unixNano(s.end),
+			Attributes:        otlpAttributes(s.attributes),
+		}
+		for _, event := range s.events {
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: unixNano(event.Time),
+				Name:         event.Name,
+				Attributes:   otlpAttributes(event.Attributes),
+			})
+		}
+		if s.err != nil {
+			span.Status = &otlpStatus{Code: statusCodeError, Message: s.err.Error()}
+		}
+		s.mu.Unlock()
+		scopeSpans.Spans = append(scopeSpans.Spans, span)
+	}
+	return json.Marshal(otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource:   otlpResource{Attributes: otlpAttributes(resource)},
+				ScopeSpans: []otlpScopeSpans{scopeSpans},
+			},
+		},
+	})
+}
+
+func otlpAttributes(attrs []Attribute) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	res := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kv := otlpKeyValue{Key: attr.Key}
+		switch v := attr.Value.(type) {
+		case string:
+			kv.Value.StringValue = &v
+		case int64:
+			// OTLP JSON encodes 64 bit integers as strings.
+			s := strconv.FormatInt(v, 10)
+			kv.Value.IntValue = &s
+		case bool:
+			kv.Value.BoolValue = &v
+		default:
+			s := fmt.Sprintf("%v", v)
+			kv.Value.StringValue = &s
+		}
+		res = append(res, kv)
+	}
+	return res
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
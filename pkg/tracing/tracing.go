@@ -0,0 +1,212 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tracing records a span of each proxy connection and exports
+// the spans to an OpenTelemetry collector, so slow connections can be
+// correlated with the servers and transports they use.
+package tracing
+
+import (
+	crand "crypto/rand"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Names of the events recorded in a proxy connection span.
+const (
+	// EventDial is recorded after the connection to the proxy server
+	// or the destination is established.
+	EventDial = "dial"
+
+	// EventHandshake is recorded after the socks5 request is accepted.
+	EventHandshake = "handshake"
+
+	// EventFirstByte is recorded after the first byte is received
+	// from the destination.
+	EventFirstByte = "first byte"
+)
+
+// SpanKind is the role of the span in a trace.
+type SpanKind int
+
+const (
+	// SpanKindServer is the span of mita server.
+	SpanKindServer SpanKind = 2
+
+	// SpanKindClient is the span of mieru client.
+	SpanKindClient SpanKind = 3
+)
+
+// Exporter sends the finished spans to a trace backend.
+type Exporter interface {
+	// ExportSpan queues a finished span. It must not block.
+	ExportSpan(span *Span)
+}
+
+// Tracer creates the spans of proxy connections.
+// A nil Tracer creates no span.
+type Tracer struct {
+	kind        SpanKind
+	exporter    Exporter
+	sampleRatio float64
+}
+
+// NewTracer returns a Tracer that sends spans to the exporter.
+// A span is created with the probability of sampleRatio.
+// If sampleRatio is not in (0, 1], every span is created.
+func NewTracer(kind SpanKind, exporter Exporter, sampleRatio float64) *Tracer {
+	if sampleRatio <= 0 || sampleRatio > 1 {
+		sampleRatio = 1
+	}
+	return &Tracer{
+		kind:        kind,
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+	}
+}
+
+// Start creates a new span that starts now. It returns nil if the
+// tracer is nil or the span is not sampled. All the methods of Span
+// can be called with a nil Span.
+func (t *Tracer) Start(name string) *Span {
+	if t == nil || t.exporter == nil {
+		return nil
+	}
+	if t.sampleRatio < 1 && rand.Float64() >= t.sampleRatio {
+		return nil
+	}
+	s := &Span{
+		name:     name,
+		kind:     t.kind,
+		start:    time.Now(),
+		exporter: t.exporter,
+	}
+	crand.Read(s.traceID[:])
+	crand.Read(s.spanID[:])
+	return s
+}
+
+// Attribute is a key value pair that describes a span or an event.
+// The value is a string, an int64 or a bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int64 returns an integer attribute.
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool returns a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Event is something happened at a time during a span.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// Span is the time of a proxy connection from dial to close.
+// It is safe to use a Span from multiple goroutines.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	name     string
+	kind     SpanKind
+	start    time.Time
+	exporter Exporter
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	events     []Event
+	err        error
+	ended      bool
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.attributes = append(s.attributes, attrs...)
+}
+
+// AddEvent records an event that happens now.
+func (s *Span) AddEvent(name string, attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.events = append(s.events, Event{Name: name, Time: time.Now(), Attributes: attrs})
+}
+
+// End finishes the span and sends it to the exporter.
+// If err is not nil, the span is marked as failed.
+// It does nothing if the span is already finished.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.err = err
+	s.mu.Unlock()
+	s.exporter.ExportSpan(s)
+}
+
+// Events returns the events recorded in the span.
+func (s *Span) Events() []Event {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// Attributes returns the attributes of the span.
+func (s *Span) Attributes() []Attribute {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Attribute(nil), s.attributes...)
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *recordingExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	span := tracer.Start("proxy connection")
+	if span != nil {
+		t.Fatalf("nil Tracer created a span")
+	}
+	span.SetAttributes(String("a", "b"))
+	span.AddEvent(EventDial)
+	span.End(nil)
+	if len(span.Events()) != 0 || len(span.Attributes()) != 0 {
+		t.Errorf("nil Span has events or attributes")
+	}
+}
+
+func TestSpanEnd(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(SpanKindClient, exporter, 0)
+	span := tracer.Start("proxy connection")
+	span.SetAttributes(String("mieru.destination", "example.com:443"), Int64("mieru.session.id", 1))
+	span.AddEvent(EventDial)
+	span.AddEvent(EventHandshake)
+	span.End(nil)
+	span.End(errors.New("closed twice"))
+	span.AddEvent(EventFirstByte)
+	if len(exporter.spans) != 1 {
+		t.Fatalf("got %d exported spans, want 1", len(exporter.spans))
+	}
+	if got := len(span.Events()); got != 2 {
+		t.Errorf("got %d events, want 2", got)
+	}
+	if span.err != nil {
+		t.Errorf("span error is %v, want nil", span.err)
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := NewTracer(SpanKindServer, exporter, 0.000001)
+	created := 0
+	for i := 0; i < 100; i++ {
+		if tracer.Start("proxy connection") != nil {
+			created++
+		}
+	}
+	if created > 10 {
+		t.Errorf("created %d spans with a tiny sample ratio", created)
+	}
+}
+
+func TestValidateOTLPEndpoint(t *testing.T) {
+	testCases := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{"http://127.0.0.1:4318/v1/traces", false},
+		{"https://otel.example.com/v1/traces", false},
+		{"127.0.0.1:4318", true},
+		{"grpc://127.0.0.1:4317", true},
+		{"http:///v1/traces", true},
+	}
+	for _, tc := range testCases {
+		err := ValidateOTLPEndpoint(tc.endpoint)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ValidateOTLPEndpoint(%q) returned error %v, want error %v", tc.endpoint, err, tc.wantErr)
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan otlpTraceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got content type %q, want application/json", r.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		var req otlpTraceRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Errorf("json.Unmarshal() failed: %v", err)
+		}
+		received <- req
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(collector.URL+"/v1/traces", "mieru")
+	if err != nil {
+		t.Fatalf("NewOTLPExporter() failed: %v", err)
+	}
+	tracer := NewTracer(SpanKindClient, exporter, 1)
+	span := tracer.Start("proxy connection")
+	span.SetAttributes(String("mieru.underlay.protocol", "TCP"), Int64("mieru.session.id", 42), Bool("ok", true))
+	span.AddEvent(EventDial)
+	span.End(errors.New("connection reset"))
+	exporter.Close()
+
+	req := <-received
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	if len(got.TraceID) != 32 || len(got.SpanID) != 16 {
+		t.Errorf("invalid trace ID %q or span ID %q", got.TraceID, got.SpanID)
+	}
+	if got.Kind != int(SpanKindClient) {
+		t.Errorf("got span kind %d, want %d", got.Kind, SpanKindClient)
+	}
+	if len(got.Attributes) != 3 || got.Attributes[1].Value.IntValue == nil || *got.Attributes[1].Value.IntValue != "42" {
+		t.Errorf("unexpected attributes %+v", got.Attributes)
+	}
+	if len(got.Events) != 1 || got.Events[0].Name != EventDial {
+		t.Errorf("unexpected events %+v", got.Events)
+	}
+	if got.Status == nil || got.Status.Code != statusCodeError {
+		t.Errorf("span status is not error")
+	}
+
+	// Spans are dropped after the exporter is closed.
+	before := DroppedSpans.Load()
+	exporter.ExportSpan(tracer.Start("proxy connection"))
+	if DroppedSpans.Load() != before+1 {
+		t.Errorf("span is not dropped after Close()")
+	}
+}
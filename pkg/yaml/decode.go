@@ -0,0 +1,755 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	decimalRegexp = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	octalRegexp   = regexp.MustCompile(`^0o[0-7]+$`)
+	hexRegexp     = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	floatRegexp   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// Unmarshal parses the YAML document. It returns nil if the document
+// is empty.
+func Unmarshal(b []byte) (any, error) {
+	if !utf8.Valid(b) {
+		return nil, fmt.Errorf("document is not valid UTF-8")
+	}
+	p := &parser{}
+	if err := p.split(string(b)); err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if p.eof() {
+		return nil, nil
+	}
+	v, err := p.parseNode(-1)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if !p.eof() {
+		return nil, p.errorf("unexpected content %q", p.cur().text)
+	}
+	return v, nil
+}
+
+// line is a line of the document.
+type line struct {
+	num    int
+	raw    string
+	indent int
+	text   string // the content without indentation and comment
+}
+
+type parser struct {
+	lines []line
+	pos   int
+}
+
+// split splits the document into lines, and removes the document
+// start and end markers.
+func (p *parser) split(doc string) error {
+	doc = strings.TrimPrefix(doc, "\ufeff")
+	started := false
+	for i, raw := range strings.Split(doc, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		content := strings.TrimLeft(raw, " ")
+		l := line{
+			num:    i + 1,
+			raw:    raw,
+			indent: len(raw) - len(content),
+			text:   strings.TrimRight(stripComment(content), " \t"),
+		}
+		if strings.HasPrefix(l.text, "\t") {
+			return fmt.Errorf("line %d: tab character can't be used in indentation", l.num)
+		}
+		if l.indent == 0 {
+			switch {
+			case strings.HasPrefix(l.text, "%"):
+				return fmt.Errorf("line %d: directives are not supported", l.num)
+			case l.text == "---" || strings.HasPrefix(l.text, "--- "):
+				if started {
+					return fmt.Errorf("line %d: multiple documents are not supported", l.num)
+				}
+				started = true
+				l.text = strings.TrimLeft(strings.TrimPrefix(l.text, "---"), " ")
+				if l.text != "" {
+					return fmt.Errorf("line %d: content after document start marker is not supported", l.num)
+				}
+			case l.text == "...":
+				return nil
+			}
+		}
+		if l.text != "" {
+			started = true
+		}
+		p.lines = append(p.lines, l)
+	}
+	return nil
+}
+
+// parseNode parses the block node starting from the current line.
+// The node belongs to a parent node with the given indentation.
+func (p *parser) parseNode(parent int) (any, error) {
+	l := p.cur()
+	if isSequenceItem(l.text) {
+		return p.parseSequence(l.indent)
+	}
+	if _, _, ok, err := splitKey(l.text); err != nil {
+		return nil, p.errorf("%v", err)
+	} else if ok {
+		return p.parseMapping(l.indent)
+	}
+	p.pos++
+	return p.parseValue(l.text, parent, l.num)
+}
+
+// parseMapping parses a block mapping with the indentation.
+func (p *parser) parseMapping(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for {
+		p.skipBlank()
+		if p.eof() || p.cur().indent < indent {
+			return m, nil
+		}
+		l := p.cur()
+		if l.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if isSequenceItem(l.text) {
+			return nil, p.errorf("unexpected sequence item in mapping")
+		}
+		key, rest, ok, err := splitKey(l.text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if !ok {
+			return nil, p.errorf("expect a mapping key in %q", l.text)
+		}
+		if _, found := m[key]; found {
+			return nil, p.errorf("key %q is already defined", key)
+		}
+		p.pos++
+		var v any
+		if rest == "" {
+			p.skipBlank()
+			// A sequence can have the same indentation as its key.
+			if !p.eof() && (p.cur().indent > indent || (p.cur().indent == indent && isSequenceItem(p.cur().text))) {
+				v, err = p.parseNode(indent)
+			}
+		} else {
+			v, err = p.parseValue(rest, indent, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+}
+
+// parseSequence parses a block sequence with the indentation.
+func (p *parser) parseSequence(indent int) ([]any, error) {
+	arr := []any{}
+	for {
+		p.skipBlank()
+		if p.eof() || p.cur().indent < indent || !isSequenceItem(p.cur().text) {
+			return arr, nil
+		}
+		l := p.cur()
+		if l.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		rest := strings.TrimLeft(l.text[1:], " ")
+		var v any
+		var err error
+		if rest == "" {
+			p.pos++
+			p.skipBlank()
+			if !p.eof() && p.cur().indent > indent {
+				v, err = p.parseNode(indent)
+			}
+		} else if _, _, isKey, _ := splitKey(rest); isKey || isSequenceItem(rest) {
+			// A compact collection starts from the same line as "- ".
+			// Parse the rest of the line as if it is indented.
+			p.lines[p.pos].indent = l.indent + len(l.text) - len(rest)
+			p.lines[p.pos].text = rest
+			v, err = p.parseNode(indent)
+		} else {
+			p.pos++
+			v, err = p.parseValue(rest, indent, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+}
+
+// parseValue parses the value that starts in a line that is already
+// consumed. The value may continue in the following lines, which must be
+// indented more than the parent node.
+func (p *parser) parseValue(text string, parent int, num int) (any, error) {
+	switch text[0] {
+	case '|', '>':
+		v, err := p.parseBlockScalar(text, parent)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		return v, nil
+	case '[', '{':
+		// A flow collection can span multiple lines.
+		for !isBalanced(text) {
+			p.skipBlank()
+			if p.eof() {
+				break
+			}
+			text += " " + p.cur().text
+			p.pos++
+		}
+		s := &scanner{src: []rune(text)}
+		v, err := s.parseFlow()
+		if err == nil {
+			s.skipSpaces()
+			if !s.eof() {
+				err = fmt.Errorf("unexpected %q after flow collection", string(s.src[s.pos:]))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		return v, nil
+	case '"', '\'':
+		s := &scanner{src: []rune(text)}
+		v, err := s.parseQuoted()
+		if err == nil {
+			s.skipSpaces()
+			if !s.eof() {
+				err = fmt.Errorf("unexpected %q after quoted string", string(s.src[s.pos:]))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", num, err)
+		}
+		return v, nil
+	}
+	if err := checkIndicator(text); err != nil {
+		return nil, fmt.Errorf("line %d: %w", num, err)
+	}
+	// A plain scalar can be folded into multiple lines.
+	folded := false
+	for {
+		p.skipBlank()
+		if p.eof() || p.cur().indent <= parent {
+			break
+		}
+		if _, _, ok, _ := splitKey(p.cur().text); ok || isSequenceItem(p.cur().text) {
+			return nil, p.errorf("unexpected indentation")
+		}
+		text += " " + p.cur().text
+		folded = true
+		p.pos++
+	}
+	if folded {
+		return text, nil
+	}
+	return resolvePlain(text), nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar.
+// The header is already consumed.
+func (p *parser) parseBlockScalar(header string, parent int) (string, error) {
+	literal := header[0] == '|'
+	chomp := byte(0)
+	contentIndent := -1
+	for _, c := range header[1:] {
+		switch {
+		case (c == '-' || c == '+') && chomp == 0:
+			chomp = byte(c)
+		case c >= '1' && c <= '9' && contentIndent < 0:
+			contentIndent = int(c - '0')
+			if parent > 0 {
+				contentIndent += parent
+			}
+		default:
+			return "", fmt.Errorf("invalid block scalar header %q", header)
+		}
+	}
+
+	var lines []string
+	for !p.eof() {
+		raw := p.lines[p.pos].raw
+		if strings.TrimLeft(raw, " ") == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		indent := p.lines[p.pos].indent
+		if indent <= parent || (contentIndent >= 0 && indent < contentIndent) {
+			break
+		}
+		if contentIndent < 0 {
+			contentIndent = indent
+		}
+		lines = append(lines, raw[contentIndent:])
+		p.pos++
+	}
+
+	// Trailing blank lines are only kept by the "+" chomping indicator.
+	trailing := 0
+	for i := len(lines) - 1; i >= 0 && lines[i] == ""; i-- {
+		trailing++
+	}
+	content := lines[:len(lines)-trailing]
+
+	var b strings.Builder
+	for i, l := range content {
+		if i > 0 {
+			// A folded line break becomes a space, unless the line is
+			// empty or more indented.
+			prev := content[i-1]
+			switch {
+			case literal || l == "" || strings.HasPrefix(l, " ") || strings.HasPrefix(prev, " "):
+				b.WriteByte('\n')
+			case prev == "":
+				// The line break is already written by the empty line.
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(l)
+	}
+	s := b.String()
+	switch chomp {
+	case '-':
+	case '+':
+		if len(content) > 0 {
+			s += "\n"
+		}
+		s += strings.Repeat("\n", trailing)
+	default:
+		if len(content) > 0 {
+			s += "\n"
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) skipBlank() {
+	for !p.eof() && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.lines)
+}
+
+func (p *parser) cur() line {
+	return p.lines[p.pos]
+}
+
+// errorf returns an error of the current line.
+func (p *parser) errorf(format string, a ...any) error {
+	num := 0
+	if !p.eof() {
+		num = p.lines[p.pos].num
+	} else if len(p.lines) > 0 {
+		num = p.lines[len(p.lines)-1].num
+	}
+	return fmt.Errorf("line %d: %s", num, fmt.Sprintf(format, a...))
+}
+
+// scanner reads flow collections and quoted scalars.
+type scanner struct {
+	src []rune
+	pos int
+}
+
+// parseFlow parses a flow collection, a quoted scalar,
+// or a plain scalar in a flow collection.
+func (s *scanner) parseFlow() (any, error) {
+	s.skipSpaces()
+	if s.eof() {
+		return nil, fmt.Errorf("flow collection is not closed")
+	}
+	switch s.peek() {
+	case '[':
+		s.pos++
+		arr := []any{}
+		for {
+			s.skipSpaces()
+			if s.consume(']') {
+				return arr, nil
+			}
+			v, err := s.parseFlow()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+			s.skipSpaces()
+			if s.consume(',') {
+				continue
+			}
+			if !s.consume(']') {
+				return nil, fmt.Errorf("expect ',' or ']' in flow sequence")
+			}
+			return arr, nil
+		}
+	case '{':
+		s.pos++
+		m := map[string]any{}
+		for {
+			s.skipSpaces()
+			if s.consume('}') {
+				return m, nil
+			}
+			var key string
+			if c := s.peek(); c == '"' || c == '\'' {
+				k, err := s.parseQuoted()
+				if err != nil {
+					return nil, err
+				}
+				key = k
+			} else {
+				key = s.readPlain()
+			}
+			if key == "" {
+				return nil, fmt.Errorf("expect a key in flow mapping")
+			}
+			s.skipSpaces()
+			if !s.consume(':') {
+				return nil, fmt.Errorf("expect ':' after key %q", key)
+			}
+			if _, found := m[key]; found {
+				return nil, fmt.Errorf("key %q is already defined", key)
+			}
+			s.skipSpaces()
+			var v any
+			if c := s.peek(); c != ',' && c != '}' {
+				var err error
+				if v, err = s.parseFlow(); err != nil {
+					return nil, err
+				}
+			}
+			m[key] = v
+			s.skipSpaces()
+			if s.consume(',') {
+				continue
+			}
+			if !s.consume('}') {
+				return nil, fmt.Errorf("expect ',' or '}' in flow mapping")
+			}
+			return m, nil
+		}
+	case '"', '\'':
+		return s.parseQuoted()
+	}
+	text := s.readPlain()
+	if text == "" {
+		return nil, fmt.Errorf("unexpected %q in flow collection", s.peek())
+	}
+	if err := checkIndicator(text); err != nil {
+		return nil, err
+	}
+	return resolvePlain(text), nil
+}
+
+// readPlain reads a plain scalar in a flow collection.
+func (s *scanner) readPlain() string {
+	start := s.pos
+	for !s.eof() {
+		c := s.peek()
+		if strings.ContainsRune(",[]{}", c) {
+			break
+		}
+		if c == ':' && (s.pos+1 == len(s.src) || strings.ContainsRune(" ,[]{}", s.src[s.pos+1])) {
+			break
+		}
+		s.pos++
+	}
+	return strings.TrimRight(string(s.src[start:s.pos]), " ")
+}
+
+// parseQuoted parses a single or double quoted scalar.
+func (s *scanner) parseQuoted() (string, error) {
+	quote := s.src[s.pos]
+	s.pos++
+	var b strings.Builder
+	for {
+		if s.eof() {
+			return "", fmt.Errorf("string is not closed")
+		}
+		c := s.src[s.pos]
+		s.pos++
+		switch {
+		case c == quote && quote == '\'':
+			if s.consume('\'') {
+				b.WriteRune('\'')
+				continue
+			}
+			return b.String(), nil
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && quote == '"':
+			if err := s.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(c)
+		}
+	}
+}
+
+func (s *scanner) parseEscape(b *strings.Builder) error {
+	if s.eof() {
+		return fmt.Errorf("string is not closed")
+	}
+	c := s.src[s.pos]
+	s.pos++
+	switch c {
+	case '0':
+		b.WriteRune(0)
+	case 'a':
+		b.WriteRune('\a')
+	case 'b':
+		b.WriteRune('\b')
+	case 't', '\t':
+		b.WriteRune('\t')
+	case 'n':
+		b.WriteRune('\n')
+	case 'v':
+		b.WriteRune('\v')
+	case 'f':
+		b.WriteRune('\f')
+	case 'r':
+		b.WriteRune('\r')
+	case 'e':
+		b.WriteRune(0x1b)
+	case ' ', '"', '/', '\\':
+		b.WriteRune(c)
+	case 'N':
+		b.WriteRune(0x85)
+	case '_':
+		b.WriteRune(0xa0)
+	case 'L':
+		b.WriteRune(0x2028)
+	case 'P':
+		b.WriteRune(0x2029)
+	case 'x', 'u', 'U':
+		n := map[rune]int{'x': 2, 'u': 4, 'U': 8}[c]
+		if s.pos+n > len(s.src) {
+			return fmt.Errorf("invalid escape \\%c", c)
+		}
+		code, err := strconv.ParseUint(string(s.src[s.pos:s.pos+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("invalid escape \\%c%s", c, string(s.src[s.pos:s.pos+n]))
+		}
+		b.WriteRune(rune(code))
+		s.pos += n
+	default:
+		return fmt.Errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (s *scanner) skipSpaces() {
+	for !s.eof() && (s.src[s.pos] == ' ' || s.src[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+func (s *scanner) eof() bool {
+	return s.pos >= len(s.src)
+}
+
+func (s *scanner) peek() rune {
+	if s.eof() {
+		return 0
+	}
+	return s.src[s.pos]
+}
+
+func (s *scanner) consume(c rune) bool {
+	if !s.eof() && s.src[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// splitKey splits "key: value" into the key and the value.
+// ok is false if the text is not a mapping entry.
+func splitKey(text string) (key, rest string, ok bool, err error) {
+	if text == "" || strings.ContainsRune("[{", rune(text[0])) {
+		return "", "", false, nil
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		s := &scanner{src: []rune(text)}
+		k, err := s.parseQuoted()
+		if err != nil {
+			return "", "", false, nil
+		}
+		s.skipSpaces()
+		if !s.consume(':') || !(s.eof() || s.peek() == ' ') {
+			return "", "", false, nil
+		}
+		return k, strings.TrimSpace(string(s.src[s.pos:])), true, nil
+	}
+	if strings.HasPrefix(text, "? ") || text == "?" {
+		return "", "", false, fmt.Errorf("complex mapping keys are not supported")
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			key = strings.TrimRight(text[:i], " ")
+			if err := checkIndicator(key); err != nil {
+				return "", "", false, err
+			}
+			return key, strings.TrimSpace(text[i+1:]), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// checkIndicator returns an error if the plain scalar starts with
+// an indicator that is not supported.
+func checkIndicator(text string) error {
+	if text == "" {
+		return nil
+	}
+	switch text[0] {
+	case '&', '*':
+		return fmt.Errorf("anchors and aliases are not supported")
+	case '!':
+		return fmt.Errorf("tags are not supported")
+	case '@', '`':
+		return fmt.Errorf("plain scalar can't start with reserved indicator %q", text[0])
+	}
+	return nil
+}
+
+// isBalanced returns true if the brackets of the flow collection
+// are closed.
+func isBalanced(text string) bool {
+	depth := 0
+	var quote rune
+	escaped := false
+	for _, c := range text {
+		switch {
+		case quote == '"' && escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// stripComment removes the comment from the line. A comment starts with
+// '#' at the beginning of the line or after a whitespace, and is not in
+// a quoted scalar.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote = 0
+			}
+		case quote == '\'':
+			if c == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:-", text[i-1]) >= 0):
+			quote = c
+		}
+	}
+	return text
+}
+
+// resolvePlain returns the value of a plain scalar
+// with the YAML 1.2 core schema.
+func resolvePlain(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1)
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1)
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN()
+	}
+	switch {
+	case decimalRegexp.MatchString(text):
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(strings.TrimPrefix(text, "+"), 10, 64); err == nil {
+			return u
+		}
+	case octalRegexp.MatchString(text):
+		if i, err := strconv.ParseInt(text[2:], 8, 64); err == nil {
+			return i
+		}
+	case hexRegexp.MatchString(text):
+		if i, err := strconv.ParseInt(text[2:], 16, 64); err == nil {
+			return i
+		}
+	}
+	if floatRegexp.MatchString(text) {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f
+		}
+	}
+	return text
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package yaml converts between YAML documents and generic values.
+//
+// The values are nil, string, bool, int64, uint64, float64, []any and
+// map[string]any, the same as the values produced by encoding/json with
+// numbers converted. Only the subset of YAML needed by configuration
+// files is supported: block and flow collections, plain, quoted and block
+// scalars, and the YAML 1.2 core schema. Anchors, aliases, tags, complex
+// keys and multiple documents are rejected.
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// indentWidth is the number of spaces of each indentation level.
+const indentWidth = 2
+
+// Marshal returns the YAML encoding of the value.
+// Map keys are written in sorted order.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			buf.WriteString("{}\n")
+		} else {
+			err = encodeMapping(&buf, val, 0, false)
+		}
+	case []any:
+		if len(val) == 0 {
+			buf.WriteString("[]\n")
+		} else {
+			err = encodeSequence(&buf, val, 0, false)
+		}
+	default:
+		var s string
+		s, err = encodeScalar(v)
+		buf.WriteString(s + "\n")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeMapping writes the block mapping. If inline is true, the first
+// key is written without indentation, because it follows "- ".
+func encodeMapping(buf *bytes.Buffer, m map[string]any, indent int, inline bool) error {
+	for i, k := range sortedKeys(m) {
+		if i > 0 || !inline {
+			writeIndent(buf, indent)
+		}
+		buf.WriteString(quoteIfNeeded(k) + ":")
+		if err := encodeValue(buf, m[k], indent+indentWidth); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// encodeSequence writes the block sequence. If inline is true, the first
+// item is written without indentation, because it follows "- ".
+func encodeSequence(buf *bytes.Buffer, arr []any, indent int, inline bool) error {
+	for i, elem := range arr {
+		if i > 0 || !inline {
+			writeIndent(buf, indent)
+		}
+		buf.WriteString("-")
+		var err error
+		switch val := elem.(type) {
+		case map[string]any:
+			if len(val) > 0 {
+				buf.WriteByte(' ')
+				err = encodeMapping(buf, val, indent+indentWidth, true)
+				break
+			}
+			buf.WriteString(" {}\n")
+		case []any:
+			if len(val) > 0 {
+				buf.WriteByte(' ')
+				err = encodeSequence(buf, val, indent+indentWidth, true)
+				break
+			}
+			buf.WriteString(" []\n")
+		default:
+			var s string
+			s, err = encodeScalar(elem)
+			buf.WriteString(" " + s + "\n")
+		}
+		if err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// encodeValue writes the value of a mapping key. Non-empty collections
+// start from the next line with the given indentation.
+func encodeValue(buf *bytes.Buffer, v any, indent int) error {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) > 0 {
+			buf.WriteByte('\n')
+			return encodeMapping(buf, val, indent, false)
+		}
+		buf.WriteString(" {}\n")
+	case []any:
+		if len(val) > 0 {
+			buf.WriteByte('\n')
+			return encodeSequence(buf, val, indent, false)
+		}
+		buf.WriteString(" []\n")
+	default:
+		s, err := encodeScalar(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(" " + s + "\n")
+	}
+	return nil
+}
+
+// encodeScalar returns the YAML encoding of a scalar value.
+func encodeScalar(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return quoteIfNeeded(val), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case uint64:
+		return strconv.FormatUint(val, 10), nil
+	case float64:
+		switch {
+		case math.IsInf(val, 1):
+			return ".inf", nil
+		case math.IsInf(val, -1):
+			return "-.inf", nil
+		case math.IsNaN(val):
+			return ".nan", nil
+		}
+		s := strconv.FormatFloat(val, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s, nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// quoteIfNeeded returns the string as a plain scalar if it is read back
+// as the same string, otherwise it returns a double quoted scalar.
+func quoteIfNeeded(s string) string {
+	if needQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	if _, ok := resolvePlain(s).(string); !ok {
+		return true
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@` ", rune(s[0])) {
+		return true
+	}
+	if strings.HasSuffix(s, " ") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return true
+	}
+	for _, c := range s {
+		if !unicode.IsPrint(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeIndent(buf *bytes.Buffer, indent int) {
+	buf.WriteString(strings.Repeat(" ", indent))
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
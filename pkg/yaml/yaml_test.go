@@ -0,0 +1,232 @@
+// Copyright (C) 2024  mieru authors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package yaml
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	doc := `---
+# mita server config
+loggingLevel: INFO   # inline comment
+mtu: 1400
+ratio: 0.5
+enabled: true
+disabled: ~
+hex: 0x22b4
+ports: [8964, 9000,
+  9001]
+'quoted key': 'it''s # not a comment'
+name: "a\tb\u00e9"
+url: http://example.com/a#b
+folded plain: first
+  second
+literal: |
+  line 1
+   line 2
+
+folded: >-
+  word 1
+  word 2
+
+  word 3
+advancedSettings:
+  noCheckUpdate: true
+  fec: {groupSize: 10, enabled: false, "name": x}
+portBindings:
+- port: 8964
+  protocol: TCP
+-   portRange: 9000-9100
+    protocol: UDP
+    options:
+      fast: true
+matrix:
+  - - 1
+    - 2
+  -
+    - a
+  - []
+...
+ignored: true
+`
+	got, err := Unmarshal([]byte(doc))
+	if err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	want := map[string]any{
+		"loggingLevel": "INFO",
+		"mtu":          int64(1400),
+		"ratio":        0.5,
+		"enabled":      true,
+		"disabled":     nil,
+		"hex":          int64(8884),
+		"ports":        []any{int64(8964), int64(9000), int64(9001)},
+		"quoted key":   "it's # not a comment",
+		"name":         "a\tbé",
+		"url":          "http://example.com/a#b",
+		"folded plain": "first second",
+		"literal":      "line 1\n line 2\n",
+		"folded":       "word 1 word 2\nword 3",
+		"advancedSettings": map[string]any{
+			"noCheckUpdate": true,
+			"fec":           map[string]any{"groupSize": int64(10), "enabled": false, "name": "x"},
+		},
+		"portBindings": []any{
+			map[string]any{"port": int64(8964), "protocol": "TCP"},
+			map[string]any{"portRange": "9000-9100", "protocol": "UDP", "options": map[string]any{"fast": true}},
+		},
+		"matrix": []any{
+			[]any{int64(1), int64(2)},
+			[]any{"a"},
+			[]any{},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshalScalar(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want any
+	}{
+		{"", nil},
+		{"# only comment", nil},
+		{"null", nil},
+		{"False", false},
+		{"-12", int64(-12)},
+		{"0o17", int64(15)},
+		{"1e3", 1000.0},
+		{"-.inf", math.Inf(-1)},
+		{"18446744073709551615", uint64(math.MaxUint64)},
+		{"1.2.3", "1.2.3"},
+		{`"\x41\u00e9\U0001F600"`, "Aé😀"},
+	}
+	for _, tt := range tests {
+		got, err := Unmarshal([]byte(tt.doc))
+		if err != nil {
+			t.Errorf("Unmarshal(%q) failed: %v", tt.doc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unmarshal(%q) = %#v, want %#v", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"Duplicated key", "a: 1\na: 2"},
+		{"Duplicated flow key", "a: {b: 1, b: 2}"},
+		{"Bad indentation", "a:\n  b: 1\n   c: 2"},
+		{"Sequence in mapping", "a: 1\n- b"},
+		{"Mapping after scalar", "a: b\n  c: d"},
+		{"Unclosed string", `a: "abc`},
+		{"Unclosed flow sequence", "a: [1, 2"},
+		{"Unclosed flow mapping", "a: {b: 1"},
+		{"Invalid escape", `a: "\q"`},
+		{"Anchor", "a: &x 1"},
+		{"Alias", "a: *x"},
+		{"Tag", "a: !!str 1"},
+		{"Complex key", "? a\n: b"},
+		{"Multiple documents", "a: 1\n---\nb: 2"},
+		{"Directive", "%YAML 1.2\n---\na: 1"},
+		{"Tab indentation", "a:\n\tb: 1"},
+		{"Content after flow", "a: [1] 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Unmarshal([]byte(tt.doc)); err == nil {
+				t.Errorf("Unmarshal(%q) returned no error", tt.doc)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc := map[string]any{
+		"loggingLevel": "INFO",
+		"mtu":          int64(1400),
+		"ratio":        2.0,
+		"nothing":      nil,
+		"empty":        []any{},
+		"names":        []any{"a\"b", "c\nd", "true", "123", "- x", "a: b", "a #b", " x", "", "#"},
+		"key: colon":   true,
+		"advancedSettings": map[string]any{
+			"noCheckUpdate": true,
+			"nested":        map[string]any{},
+		},
+		"users": []any{
+			map[string]any{
+				"name":  "alice",
+				"quota": []any{map[string]any{"days": int64(1), "megabytes": int64(100)}},
+			},
+			map[string]any{
+				"name":    "bob",
+				"allowed": map[string]any{"ports": []any{int64(1), int64(2)}},
+			},
+			map[string]any{},
+		},
+		"matrix": []any{[]any{int64(1)}, []any{map[string]any{"a": "b"}}, []any{}},
+	}
+	b, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	got, err := Unmarshal(b)
+	if err != nil {
+		t.Fatalf("Unmarshal() failed: %v\n%s", err, b)
+	}
+	if !reflect.DeepEqual(got, doc) {
+		t.Errorf("round trip = %v, want %v\n%s", got, doc, b)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	doc := map[string]any{
+		"portBindings": []any{
+			map[string]any{"port": int64(8964), "protocol": "TCP"},
+		},
+		"users": []any{
+			map[string]any{"name": "alice", "quotas": []any{map[string]any{"days": int64(1)}}},
+		},
+		"mtu": int64(1400),
+	}
+	want := `mtu: 1400
+portBindings:
+  - port: 8964
+    protocol: TCP
+users:
+  - name: alice
+    quotas:
+      - days: 1
+`
+	b, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if string(b) != want {
+		t.Errorf("Marshal() = %q, want %q", b, want)
+	}
+}